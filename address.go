@@ -0,0 +1,102 @@
+package x402
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/sha3"
+)
+
+// ValidateAddress validates a payment address for the given network.
+//
+// EVM addresses must be "0x" followed by 40 hex characters. Mixed-case addresses must
+// carry a valid EIP-55 checksum, so a single mistyped character is detected; all-lowercase
+// or all-uppercase addresses carry no checksum and are accepted as-is.
+//
+// Solana addresses must be base58 strings that decode to exactly 32 bytes. Off-curve
+// addresses are accepted because program-derived accounts (e.g. multisig vaults) are
+// valid payment recipients.
+//
+// Returns an error wrapping ErrInvalidAddress or ErrInvalidNetwork.
+func ValidateAddress(network, address string) error {
+	if address == "" {
+		return fmt.Errorf("%w: cannot be empty", ErrInvalidAddress)
+	}
+
+	networkType, err := ValidateNetwork(network)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNetwork, err)
+	}
+
+	switch networkType {
+	case NetworkTypeEVM:
+		return validateEVMAddress(address)
+	case NetworkTypeSVM:
+		return validateSolanaAddress(address)
+	default:
+		return fmt.Errorf("%w: no address rules for network %s", ErrInvalidNetwork, network)
+	}
+}
+
+// validateEVMAddress checks the format and, for mixed-case input, the EIP-55 checksum.
+func validateEVMAddress(address string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return fmt.Errorf("%w: %s (expected 0x followed by 40 hex characters)", ErrInvalidAddress, address)
+	}
+	body := address[2:]
+	if _, err := hex.DecodeString(body); err != nil {
+		return fmt.Errorf("%w: %s (expected 0x followed by 40 hex characters)", ErrInvalidAddress, address)
+	}
+
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return nil
+	}
+	if checksummed := ChecksumEVMAddress(address); checksummed != address {
+		return fmt.Errorf("%w: %s has an invalid EIP-55 checksum (expected %s)", ErrInvalidAddress, address, checksummed)
+	}
+	return nil
+}
+
+// validateSolanaAddress checks that the address is base58 and decodes to a 32-byte public key.
+func validateSolanaAddress(address string) error {
+	decoded, err := base58.Decode(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s is not valid base58", ErrInvalidAddress, address)
+	}
+	if len(decoded) != 32 {
+		return fmt.Errorf("%w: %s decodes to %d bytes (expected 32)", ErrInvalidAddress, address, len(decoded))
+	}
+	return nil
+}
+
+// ChecksumEVMAddress returns the EIP-55 mixed-case checksum form of an EVM address.
+// The input must be "0x" followed by 40 hex characters in any case; other input is
+// returned unchanged.
+func ChecksumEVMAddress(address string) string {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return address
+	}
+	lower := strings.ToLower(address[2:])
+
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte(lower))
+	hash := hasher.Sum(nil)
+
+	result := []byte(lower)
+	for i, c := range result {
+		if c < 'a' || c > 'f' {
+			continue
+		}
+		// Each hex character is checked against the matching nibble of the hash
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0x0f >= 8 {
+			result[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(result)
+}
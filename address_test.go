@@ -0,0 +1,69 @@
+package x402
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidateAddress verifies address validation for EVM and Solana networks
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		address string
+		wantErr error
+	}{
+		{"evm checksummed", "base", "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", nil},
+		{"evm lowercase", "base", "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913", nil},
+		{"evm uppercase", "polygon", "0x833589FCD6EDB6E08F4C7C32D4F71B54BDA02913", nil},
+		{"evm bad checksum", "base", "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"[:41] + "4", ErrInvalidAddress},
+		{"evm wrong case", "base", "0x833589FcD6eDb6E08f4c7C32D4f71b54bdA02913", ErrInvalidAddress},
+		{"evm too short", "base", "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA029", ErrInvalidAddress},
+		{"evm missing prefix", "base", "833589fCD6eDb6E08f4c7C32D4f71b54bdA0291300", ErrInvalidAddress},
+		{"evm non-hex", "base", "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA0291z", ErrInvalidAddress},
+		{"solana valid", "solana", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", nil},
+		{"solana devnet valid", "solana-devnet", "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU", nil},
+		{"solana invalid base58", "solana", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt10", ErrInvalidAddress},
+		{"solana wrong length", "solana", "EPjFWdd5AufqSSqeM2qN1xzyb", ErrInvalidAddress},
+		{"solana given evm address", "solana", "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", ErrInvalidAddress},
+		{"empty address", "base", "", ErrInvalidAddress},
+		{"unknown network", "bitcoin", "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", ErrInvalidNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAddress(tt.network, tt.address)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("ValidateAddress(%q, %q) unexpected error: %v", tt.network, tt.address, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateAddress(%q, %q) error = %v, want %v", tt.network, tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestChecksumEVMAddress verifies EIP-55 checksum encoding
+func TestChecksumEVMAddress(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Test vectors from EIP-55
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{"0xFB6916095CA1DF60BB79CE92CE3EA74C37C5D359", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"},
+		{"0xdbf03b407c01e7cd3cbea99509d93f8dddc8c6fb", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB"},
+		{"0xd1220a0cf47c7b9be7a2e6ba89f429762e7b9adb", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb"},
+		// Invalid input is returned unchanged
+		{"not-an-address", "not-an-address"},
+	}
+
+	for _, tt := range tests {
+		if got := ChecksumEVMAddress(tt.input); got != tt.want {
+			t.Errorf("ChecksumEVMAddress(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
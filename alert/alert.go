@@ -0,0 +1,312 @@
+// Package alert tells operators about money problems as they happen. A Monitor watches
+// payments through the middleware's and client's hooks and sends an Alert to a Notifier,
+// such as a Slack webhook or email, when one of its rules fires: a payment above a
+// threshold, a run of consecutive settlement failures, or a client's spending limit
+// running out.
+//
+// Example usage:
+//
+//	monitor, err := alert.New(alert.Multi{
+//	    &alert.SlackNotifier{WebhookURL: os.Getenv("SLACK_WEBHOOK_URL")},
+//	    &alert.SMTPNotifier{Addr: "smtp.example.com:587", From: "x402@example.com", To: []string{"ops@example.com"}},
+//	},
+//	    alert.WithLargePayment("USDC", "1000000000"), // 1000 USDC
+//	    alert.WithConsecutiveFailures(3),
+//	)
+//	config.FacilitatorOnAfterSettle = monitor.OnAfterSettle
+//
+//	client, err := x402http.NewClient(
+//	    x402http.WithPaymentCallback(x402.PaymentEventFailure, monitor.OnPaymentFailure),
+//	)
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+const (
+	// DefaultTimeout bounds how long sending an alert may take by default.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultCooldown is how long a Monitor waits by default before repeating a budget
+	// exhausted alert.
+	DefaultCooldown = 15 * time.Minute
+)
+
+// Kind is the rule that raised an alert.
+type Kind string
+
+const (
+	// KindLargePayment is a settled payment at or above the configured threshold.
+	KindLargePayment Kind = "large_payment"
+
+	// KindSettlementFailures is a run of consecutive settlement failures.
+	KindSettlementFailures Kind = "settlement_failures"
+
+	// KindBudgetExhausted is a client payment refused by its spending limit.
+	KindBudgetExhausted Kind = "budget_exhausted"
+)
+
+// Alert describes a payment event an operator should know about. Fields that do not
+// apply to its kind are empty.
+type Alert struct {
+	Kind Kind `json:"kind"`
+
+	// Message summarizes the alert in one line.
+	Message string `json:"message"`
+
+	Network     string `json:"network,omitempty"`
+	Asset       string `json:"asset,omitempty"`
+	Amount      string `json:"amount,omitempty"`
+	Payer       string `json:"payer,omitempty"`
+	Transaction string `json:"transaction,omitempty"`
+	URL         string `json:"url,omitempty"`
+
+	// Failures is the number of consecutive settlement failures.
+	Failures int `json:"failures,omitempty"`
+
+	// Error is the last error, if the alert is about a failure.
+	Error string `json:"error,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notifier delivers alerts, e.g. to a chat channel or a mailbox.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Multi is a Notifier that delivers each alert to all of its notifiers.
+type Multi []Notifier
+
+// Notify implements Notifier. It returns the errors of the notifiers that failed.
+func (m Multi) Notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Monitor raises alerts from payment events. Its OnAfterSettle watches settlements on a
+// server and its OnPaymentFailure watches payments made by a client. With no rules
+// configured it raises no alerts. Monitor is safe for concurrent use.
+type Monitor struct {
+	notifier    Notifier
+	assets      *x402.AssetRegistry
+	thresholds  map[string]*big.Int
+	maxFailures int
+	cooldown    time.Duration
+	timeout     time.Duration
+	logger      *slog.Logger
+	now         func() time.Time
+
+	mu            sync.Mutex
+	failures      int
+	budgetAlertAt time.Time
+}
+
+// Option configures a Monitor.
+type Option func(*Monitor) error
+
+// New creates a Monitor that sends its alerts to notifier.
+func New(notifier Notifier, opts ...Option) (*Monitor, error) {
+	if notifier == nil {
+		return nil, errors.New("alert: notifier is nil")
+	}
+	m := &Monitor{
+		notifier:   notifier,
+		assets:     x402.DefaultAssetRegistry,
+		thresholds: make(map[string]*big.Int),
+		cooldown:   DefaultCooldown,
+		timeout:    DefaultTimeout,
+		logger:     slog.Default(),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// WithLargePayment alerts on settled payments of the logical asset symbol (see
+// x402.AssetRegistry) of at least threshold, in atomic units of the asset. It can be
+// given once per asset.
+func WithLargePayment(symbol, threshold string) Option {
+	return func(m *Monitor) error {
+		amount, ok := new(big.Int).SetString(threshold, 10)
+		if !ok || amount.Sign() <= 0 {
+			return fmt.Errorf("alert: invalid %s threshold %q", symbol, threshold)
+		}
+		m.thresholds[symbol] = amount
+		return nil
+	}
+}
+
+// WithAssetRegistry sets the registry mapping tokens to the assets of large payment
+// thresholds (default x402.DefaultAssetRegistry).
+func WithAssetRegistry(registry *x402.AssetRegistry) Option {
+	return func(m *Monitor) error {
+		if registry == nil {
+			return errors.New("alert: asset registry is nil")
+		}
+		m.assets = registry
+		return nil
+	}
+}
+
+// WithConsecutiveFailures alerts when n settlements in a row fail. The run is reported
+// once; a successful settlement ends it.
+func WithConsecutiveFailures(n int) Option {
+	return func(m *Monitor) error {
+		if n <= 0 {
+			return fmt.Errorf("alert: consecutive failures must be positive, got %d", n)
+		}
+		m.maxFailures = n
+		return nil
+	}
+}
+
+// WithCooldown sets how long to wait before repeating a budget exhausted alert
+// (default DefaultCooldown).
+func WithCooldown(d time.Duration) Option {
+	return func(m *Monitor) error {
+		if d < 0 {
+			return fmt.Errorf("alert: cooldown must not be negative, got %v", d)
+		}
+		m.cooldown = d
+		return nil
+	}
+}
+
+// WithTimeout bounds how long sending an alert may take (default DefaultTimeout).
+func WithTimeout(d time.Duration) Option {
+	return func(m *Monitor) error {
+		if d <= 0 {
+			return fmt.Errorf("alert: timeout must be positive, got %v", d)
+		}
+		m.timeout = d
+		return nil
+	}
+}
+
+// WithLogger sets the logger (default slog.Default()).
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Monitor) error {
+		if logger == nil {
+			return errors.New("alert: logger is nil")
+		}
+		m.logger = logger
+		return nil
+	}
+}
+
+// OnAfterSettle checks a settlement against the large payment and consecutive failure
+// rules. It matches http.OnAfterSettleFunc, so it can be set as
+// Config.FacilitatorOnAfterSettle. Simulated settlements are ignored.
+func (m *Monitor) OnAfterSettle(ctx context.Context, _ x402.PaymentPayload, requirement x402.PaymentRequirement, settlement *x402.SettlementResponse, err error) {
+	if settlement != nil && settlement.Simulated {
+		return
+	}
+	if err == nil && (settlement == nil || !settlement.Success) {
+		err = errors.New("settlement failed")
+		if settlement != nil && settlement.ErrorReason != "" {
+			err = errors.New(settlement.ErrorReason)
+		}
+	}
+
+	if err != nil {
+		m.mu.Lock()
+		m.failures++
+		failures := m.failures
+		m.mu.Unlock()
+		if m.maxFailures > 0 && failures == m.maxFailures {
+			m.send(ctx, Alert{
+				Kind:     KindSettlementFailures,
+				Message:  fmt.Sprintf("%d consecutive settlement failures on %s: %v", failures, requirement.Network, err),
+				Network:  requirement.Network,
+				Failures: failures,
+				Error:    err.Error(),
+			})
+		}
+		return
+	}
+
+	m.mu.Lock()
+	m.failures = 0
+	m.mu.Unlock()
+
+	amount := parseAmount(requirement.MaxAmountRequired)
+	symbol, normalized, ok := m.assets.Normalize(requirement.Network, requirement.Asset, amount)
+	threshold, watched := m.thresholds[symbol]
+	if !ok || !watched || normalized.Cmp(threshold) < 0 {
+		return
+	}
+	_, decimals, _ := m.assets.Lookup(requirement.Network, requirement.Asset)
+	m.send(ctx, Alert{
+		Kind:        KindLargePayment,
+		Message:     fmt.Sprintf("Large payment of %s %s on %s from %s", x402.NewAtomicAmount(amount, decimals), symbol, requirement.Network, settlement.Payer),
+		Network:     requirement.Network,
+		Asset:       requirement.Asset,
+		Amount:      requirement.MaxAmountRequired,
+		Payer:       settlement.Payer,
+		Transaction: settlement.Transaction,
+	})
+}
+
+// OnPaymentFailure checks a client's failed payment against the budget exhausted rule.
+// It is an x402.PaymentCallback, to be registered for x402.PaymentEventFailure. Budget
+// exhausted alerts are repeated at most once per cooldown.
+func (m *Monitor) OnPaymentFailure(event x402.PaymentEvent) {
+	if event.Type != x402.PaymentEventFailure || !errors.Is(event.Error, x402.ErrBudgetExceeded) {
+		return
+	}
+	now := m.now()
+	m.mu.Lock()
+	if !m.budgetAlertAt.IsZero() && now.Sub(m.budgetAlertAt) < m.cooldown {
+		m.mu.Unlock()
+		return
+	}
+	m.budgetAlertAt = now
+	m.mu.Unlock()
+
+	m.send(context.Background(), Alert{
+		Kind:    KindBudgetExhausted,
+		Message: fmt.Sprintf("Spending limit exhausted paying for %s", event.URL),
+		URL:     event.URL,
+		Error:   event.Error.Error(),
+	})
+}
+
+// send delivers alert within the timeout. Delivery failures are logged; they do not
+// affect the payment.
+func (m *Monitor) send(ctx context.Context, alert Alert) {
+	alert.Timestamp = m.now()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.timeout)
+	defer cancel()
+	if err := m.notifier.Notify(ctx, alert); err != nil {
+		m.logger.Warn("failed to send alert", "kind", alert.Kind, "error", err)
+	}
+}
+
+// parseAmount parses an atomic amount, returning zero if it is invalid.
+func parseAmount(amount string) *big.Int {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(amount), 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return n
+}
@@ -0,0 +1,260 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// recorder is a Notifier that records the alerts it is sent.
+type recorder struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (r *recorder) Notify(_ context.Context, alert Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func (r *recorder) kinds() []Kind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var kinds []Kind
+	for _, alert := range r.alerts {
+		kinds = append(kinds, alert.Kind)
+	}
+	return kinds
+}
+
+func usdc(amount string) x402.PaymentRequirement {
+	return x402.PaymentRequirement{Network: "base", Asset: x402.BaseMainnet.USDCAddress, MaxAmountRequired: amount}
+}
+
+func settled(tx string) *x402.SettlementResponse {
+	return &x402.SettlementResponse{Success: true, Transaction: tx, Network: "base", Payer: "0xpayer"}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		notifier Notifier
+		opts     []Option
+		wantErr  bool
+	}{
+		{name: "defaults", notifier: &recorder{}},
+		{name: "nil notifier", wantErr: true},
+		{name: "large payment", notifier: &recorder{}, opts: []Option{WithLargePayment("USDC", "1000000")}},
+		{name: "invalid threshold", notifier: &recorder{}, opts: []Option{WithLargePayment("USDC", "1.5")}, wantErr: true},
+		{name: "zero threshold", notifier: &recorder{}, opts: []Option{WithLargePayment("USDC", "0")}, wantErr: true},
+		{name: "zero failures", notifier: &recorder{}, opts: []Option{WithConsecutiveFailures(0)}, wantErr: true},
+		{name: "negative cooldown", notifier: &recorder{}, opts: []Option{WithCooldown(-time.Minute)}, wantErr: true},
+		{name: "zero timeout", notifier: &recorder{}, opts: []Option{WithTimeout(0)}, wantErr: true},
+		{name: "nil registry", notifier: &recorder{}, opts: []Option{WithAssetRegistry(nil)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.notifier, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMonitor_LargePayment(t *testing.T) {
+	rec := &recorder{}
+	monitor, err := New(rec, WithLargePayment("USDC", "1000000000"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	monitor.OnAfterSettle(ctx, x402.PaymentPayload{}, usdc("999999999"), settled("0xsmall"), nil)
+	monitor.OnAfterSettle(ctx, x402.PaymentPayload{}, x402.PaymentRequirement{Network: "base", Asset: "0xother", MaxAmountRequired: "5000000000"}, settled("0xother"), nil)
+	monitor.OnAfterSettle(ctx, x402.PaymentPayload{}, usdc("1500000000"), &x402.SettlementResponse{Success: true, Simulated: true}, nil)
+	if len(rec.alerts) != 0 {
+		t.Fatalf("unexpected alerts %v", rec.alerts)
+	}
+
+	monitor.OnAfterSettle(ctx, x402.PaymentPayload{}, usdc("1500000000"), settled("0xlarge"), nil)
+	if len(rec.alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(rec.alerts))
+	}
+	got := rec.alerts[0]
+	if got.Kind != KindLargePayment || got.Amount != "1500000000" || got.Transaction != "0xlarge" || got.Payer != "0xpayer" {
+		t.Errorf("alert = %+v", got)
+	}
+	if want := "Large payment of 1500 USDC on base from 0xpayer"; got.Message != want {
+		t.Errorf("message = %q, want %q", got.Message, want)
+	}
+	if got.Timestamp.IsZero() {
+		t.Error("alert has no timestamp")
+	}
+}
+
+func TestMonitor_ConsecutiveFailures(t *testing.T) {
+	rec := &recorder{}
+	monitor, err := New(rec, WithConsecutiveFailures(3))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	fail := func(err error, settlement *x402.SettlementResponse) {
+		monitor.OnAfterSettle(ctx, x402.PaymentPayload{}, usdc("1000"), settlement, err)
+	}
+
+	fail(errors.New("facilitator unavailable"), nil)
+	fail(nil, &x402.SettlementResponse{Success: false, ErrorReason: "insufficient_funds"})
+	fail(nil, settled("0xok")) // ends the run
+	fail(errors.New("facilitator unavailable"), nil)
+	fail(errors.New("facilitator unavailable"), nil)
+	if len(rec.alerts) != 0 {
+		t.Fatalf("alerted before 3 consecutive failures: %v", rec.alerts)
+	}
+
+	fail(nil, &x402.SettlementResponse{Success: false, ErrorReason: "insufficient_funds"})
+	fail(errors.New("facilitator unavailable"), nil) // the run is reported once
+	if len(rec.alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(rec.alerts))
+	}
+	got := rec.alerts[0]
+	if got.Kind != KindSettlementFailures || got.Failures != 3 || got.Error != "insufficient_funds" || got.Network != "base" {
+		t.Errorf("alert = %+v", got)
+	}
+
+	fail(nil, settled("0xok"))
+	fail(errors.New("a"), nil)
+	fail(errors.New("b"), nil)
+	fail(errors.New("c"), nil)
+	if len(rec.alerts) != 2 {
+		t.Errorf("got %d alerts, want a second run reported", len(rec.alerts))
+	}
+}
+
+func TestMonitor_BudgetExhausted(t *testing.T) {
+	rec := &recorder{}
+	monitor, err := New(rec, WithCooldown(time.Hour))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC)
+	monitor.now = func() time.Time { return now }
+
+	exhausted := x402.PaymentEvent{
+		Type:  x402.PaymentEventFailure,
+		URL:   "https://api.example.com/data",
+		Error: x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to sign payment", fmt.Errorf("%w: over limit", x402.ErrBudgetExceeded)),
+	}
+	monitor.OnPaymentFailure(x402.PaymentEvent{Type: x402.PaymentEventFailure, Error: errors.New("network error")})
+	monitor.OnPaymentFailure(exhausted)
+	now = now.Add(30 * time.Minute)
+	monitor.OnPaymentFailure(exhausted)
+	now = now.Add(30 * time.Minute)
+	monitor.OnPaymentFailure(exhausted)
+
+	kinds := rec.kinds()
+	if len(kinds) != 2 || kinds[0] != KindBudgetExhausted || kinds[1] != KindBudgetExhausted {
+		t.Fatalf("alerts = %v, want 2 budget exhausted alerts an hour apart", kinds)
+	}
+	if rec.alerts[0].URL != exhausted.URL {
+		t.Errorf("alert URL = %q, want %q", rec.alerts[0].URL, exhausted.URL)
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if strings.HasSuffix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer server.Close()
+
+	alert := Alert{Kind: KindLargePayment, Message: "Large payment of 1500 USDC on base from 0xpayer", Transaction: "0xtx"}
+	if err := (&SlackNotifier{WebhookURL: server.URL}).Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if !strings.Contains(got["text"], alert.Message) || !strings.Contains(got["text"], "0xtx") {
+		t.Errorf("Slack text = %q", got["text"])
+	}
+
+	if err := (&SlackNotifier{WebhookURL: server.URL + "/gone"}).Notify(context.Background(), alert); err == nil {
+		t.Error("expected an error for a rejected webhook")
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	notifier := &SMTPNotifier{
+		Addr: "smtp.example.com:587",
+		From: "x402@example.com",
+		To:   []string{"ops@example.com", "cfo@example.com"},
+		sendMail: func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+			gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+			return nil
+		},
+	}
+
+	alert := Alert{
+		Kind:      KindSettlementFailures,
+		Message:   "3 consecutive settlement failures on base:\r\nBcc: attacker@example.com",
+		Network:   "base",
+		Failures:  3,
+		Error:     "insufficient_funds",
+		Timestamp: time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC),
+	}
+	if err := notifier.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if gotAddr != notifier.Addr || gotFrom != notifier.From || len(gotTo) != 2 {
+		t.Errorf("sent to %s from %s to %v", gotAddr, gotFrom, gotTo)
+	}
+	headers, body, _ := strings.Cut(string(gotMsg), "\r\n\r\n")
+	if !strings.Contains(headers, "Subject: [x402] 3 consecutive settlement failures on base:  Bcc: attacker@example.com\r\n") {
+		t.Errorf("headers = %q, want the message as a single-line subject", headers)
+	}
+	if !strings.Contains(headers, "To: ops@example.com, cfo@example.com\r\n") {
+		t.Errorf("headers = %q, want both recipients", headers)
+	}
+	for _, want := range []string{"Kind: settlement_failures", "Failures: 3", "Error: insufficient_funds"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body = %q, want %q", body, want)
+		}
+	}
+
+	if err := (&SMTPNotifier{Addr: "smtp.example.com:587"}).Notify(context.Background(), alert); err == nil {
+		t.Error("expected an error without recipients")
+	}
+}
+
+func TestMulti(t *testing.T) {
+	first, second := &recorder{}, &recorder{}
+	failing := &SMTPNotifier{To: []string{"ops@example.com"}, sendMail: func(string, smtp.Auth, string, []string, []byte) error {
+		return errors.New("connection refused")
+	}}
+
+	err := Multi{first, failing, second}.Notify(context.Background(), Alert{Kind: KindBudgetExhausted})
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Notify() error = %v, want the failing notifier's error", err)
+	}
+	if len(first.alerts) != 1 || len(second.alerts) != 1 {
+		t.Error("expected every notifier to receive the alert")
+	}
+}
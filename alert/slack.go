@@ -0,0 +1,58 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SlackNotifier posts alerts to a Slack incoming webhook. Any chat service accepting
+// Slack's {"text": ...} payload works too.
+type SlackNotifier struct {
+	// WebhookURL is the incoming webhook URL.
+	WebhookURL string
+
+	// HTTPClient sends the webhook requests (default http.DefaultClient).
+	HTTPClient *http.Client
+}
+
+// Notify implements Notifier.
+func (s *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(map[string]string{"text": slackText(alert)})
+	if err != nil {
+		return fmt.Errorf("alert: failed to marshal Slack message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alert: failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert: Slack webhook failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert: Slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// slackText formats alert as a Slack message: the message in bold, then the
+// transaction, if any.
+func slackText(alert Alert) string {
+	text := ":rotating_light: *" + alert.Message + "*"
+	if alert.Transaction != "" {
+		text += "\nTransaction: `" + alert.Transaction + "`"
+	}
+	return text
+}
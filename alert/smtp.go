@@ -0,0 +1,87 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier emails alerts through an SMTP server.
+type SMTPNotifier struct {
+	// Addr is the server address, host:port. The connection is upgraded with STARTTLS
+	// when the server supports it.
+	Addr string
+
+	// Auth authenticates with the server, e.g. smtp.PlainAuth; nil to send without
+	// authentication.
+	Auth smtp.Auth
+
+	// From is the sender address and To the recipient addresses.
+	From string
+	To   []string
+
+	// sendMail sends the message (default smtp.SendMail); replaced in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// Notify implements Notifier. The mail is sent even if ctx ends first, since net/smtp
+// does not take a context, but Notify returns when ctx is done.
+func (s *SMTPNotifier) Notify(ctx context.Context, alert Alert) error {
+	if len(s.To) == 0 {
+		return errors.New("alert: no email recipients")
+	}
+	send := s.sendMail
+	if send == nil {
+		send = smtp.SendMail
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- send(s.Addr, s.Auth, s.From, s.To, s.message(alert)) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("alert: failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("alert: failed to send email: %w", ctx.Err())
+	}
+}
+
+// message formats alert as an RFC 5322 plain text email.
+func (s *SMTPNotifier) message(alert Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&b, "Subject: [x402] %s\r\n", headerValue(alert.Message))
+	fmt.Fprintf(&b, "Date: %s\r\n", alert.Timestamp.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	b.WriteString(alert.Message + "\r\n\r\n")
+	for _, field := range [][2]string{
+		{"Kind", string(alert.Kind)},
+		{"Network", alert.Network},
+		{"Asset", alert.Asset},
+		{"Amount", alert.Amount},
+		{"Payer", alert.Payer},
+		{"Transaction", alert.Transaction},
+		{"URL", alert.URL},
+		{"Error", alert.Error},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", field[0], field[1])
+		}
+	}
+	if alert.Failures > 0 {
+		fmt.Fprintf(&b, "Failures: %d\r\n", alert.Failures)
+	}
+	return []byte(b.String())
+}
+
+// headerValue makes s safe for a single-line email header.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,253 @@
+package x402
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Amount is an exact token amount stored in atomic units together with the token's decimals.
+// It removes the ambiguity between human-readable amounts ("1.50" USDC) and atomic amounts
+// ("1500000"), which PaymentRequirement.MaxAmountRequired always uses.
+//
+// The zero value is a zero amount with 0 decimals. Amount values are immutable; arithmetic
+// methods return new values.
+type Amount struct {
+	atomic   *big.Int
+	decimals int
+}
+
+// ParseAmount parses a human-readable decimal amount (e.g., "1.50") for a token with the
+// given number of decimals. Parsing is exact: amounts with more fractional digits than the
+// token supports are rejected unless the extra digits are zero.
+//
+// Returns ErrInvalidAmount if the string is not a plain decimal number.
+func ParseAmount(s string, decimals int) (Amount, error) {
+	if decimals < 0 {
+		return Amount{}, fmt.Errorf("%w: negative decimals %d", ErrInvalidAmount, decimals)
+	}
+
+	str := strings.TrimSpace(s)
+	negative := strings.HasPrefix(str, "-")
+	str = strings.TrimPrefix(str, "-")
+
+	whole, frac, _ := strings.Cut(str, ".")
+	if whole == "" && frac == "" {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	// Drop insignificant trailing zeros beyond the token precision
+	if len(frac) > decimals {
+		if strings.Trim(frac[decimals:], "0") != "" {
+			return Amount{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, decimals)
+		}
+		frac = frac[:decimals]
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	atomic := new(big.Int)
+	if digits := whole + frac; digits != "" {
+		atomic.SetString(digits, 10)
+	}
+	if negative {
+		atomic.Neg(atomic)
+	}
+
+	return Amount{atomic: atomic, decimals: decimals}, nil
+}
+
+// ParseAtomicAmount parses an amount already expressed in atomic units (e.g., "1500000"),
+// such as PaymentRequirement.MaxAmountRequired.
+//
+// Returns ErrInvalidAmount if the string is not a base-10 integer.
+func ParseAtomicAmount(s string, decimals int) (Amount, error) {
+	if decimals < 0 {
+		return Amount{}, fmt.Errorf("%w: negative decimals %d", ErrInvalidAmount, decimals)
+	}
+	atomic, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q is not an atomic amount", ErrInvalidAmount, s)
+	}
+	return Amount{atomic: atomic, decimals: decimals}, nil
+}
+
+// NewAtomicAmount creates an Amount from atomic units. The value is copied.
+func NewAtomicAmount(atomic *big.Int, decimals int) Amount {
+	value := new(big.Int)
+	if atomic != nil {
+		value.Set(atomic)
+	}
+	return Amount{atomic: value, decimals: decimals}
+}
+
+// Atomic returns a copy of the amount in atomic units.
+func (a Amount) Atomic() *big.Int {
+	if a.atomic == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.atomic)
+}
+
+// AtomicString returns the amount in atomic units, suitable for MaxAmountRequired.
+func (a Amount) AtomicString() string {
+	return a.Atomic().String()
+}
+
+// Decimals returns the number of decimal places of the token.
+func (a Amount) Decimals() int {
+	return a.decimals
+}
+
+// String returns the human-readable amount with trailing fractional zeros removed
+// (e.g., 1500000 with 6 decimals is "1.5").
+func (a Amount) String() string {
+	s := a.Format()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	return s
+}
+
+// Format returns the human-readable amount with exactly Decimals fractional digits
+// (e.g., 1500000 with 6 decimals is "1.500000").
+func (a Amount) Format() string {
+	atomic := a.Atomic()
+	sign := ""
+	if atomic.Sign() < 0 {
+		sign = "-"
+		atomic.Neg(atomic)
+	}
+
+	digits := atomic.String()
+	if a.decimals == 0 {
+		return sign + digits
+	}
+	if len(digits) <= a.decimals {
+		digits = strings.Repeat("0", a.decimals-len(digits)+1) + digits
+	}
+	point := len(digits) - a.decimals
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// IsZero reports whether the amount is zero.
+func (a Amount) IsZero() bool {
+	return a.atomic == nil || a.atomic.Sign() == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount.
+func (a Amount) Sign() int {
+	if a.atomic == nil {
+		return 0
+	}
+	return a.atomic.Sign()
+}
+
+// Add returns a + b. Both amounts must have the same decimals.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if err := a.checkDecimals(b); err != nil {
+		return Amount{}, err
+	}
+	return Amount{atomic: new(big.Int).Add(a.Atomic(), b.Atomic()), decimals: a.decimals}, nil
+}
+
+// Sub returns a - b. Both amounts must have the same decimals.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if err := a.checkDecimals(b); err != nil {
+		return Amount{}, err
+	}
+	return Amount{atomic: new(big.Int).Sub(a.Atomic(), b.Atomic()), decimals: a.decimals}, nil
+}
+
+// MulInt returns the amount multiplied by n (e.g., a per-item price times a quantity).
+func (a Amount) MulInt(n int64) Amount {
+	return Amount{atomic: new(big.Int).Mul(a.Atomic(), big.NewInt(n)), decimals: a.decimals}
+}
+
+// Cmp compares a and b by value and returns -1, 0 or +1.
+// Amounts with different decimals are compared after scaling to a common precision.
+func (a Amount) Cmp(b Amount) int {
+	x, y := a.Atomic(), b.Atomic()
+	switch {
+	case a.decimals < b.decimals:
+		x.Mul(x, pow10(b.decimals-a.decimals))
+	case a.decimals > b.decimals:
+		y.Mul(y, pow10(a.decimals-b.decimals))
+	}
+	return x.Cmp(y)
+}
+
+// RoundingMode selects how a price with more fractional digits than the token supports
+// is rounded to atomic units.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest atomic unit, ties to even (banker's rounding).
+	RoundHalfEven RoundingMode = iota
+
+	// RoundUp rounds towards positive infinity, so the payee never receives less than the price.
+	RoundUp
+
+	// RoundDown rounds towards zero, so the payer never pays more than the price.
+	RoundDown
+)
+
+// roundToAtomic converts a human-readable amount to atomic units with the given
+// decimals and rounding mode.
+func roundToAtomic(amount *big.Rat, decimals int, mode RoundingMode) (*big.Int, error) {
+	scaled := new(big.Rat).Mul(amount, new(big.Rat).SetInt(pow10(decimals)))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient, nil
+	}
+
+	// Rounding away from zero adds the sign of the amount
+	away := big.NewInt(int64(scaled.Sign()))
+	switch mode {
+	case RoundHalfEven:
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+		switch twice.Cmp(scaled.Denom()) {
+		case 1:
+			quotient.Add(quotient, away)
+		case 0:
+			if quotient.Bit(0) == 1 {
+				quotient.Add(quotient, away)
+			}
+		}
+	case RoundUp:
+		if scaled.Sign() > 0 {
+			quotient.Add(quotient, away)
+		}
+	case RoundDown:
+	default:
+		return nil, fmt.Errorf("%w: unknown rounding mode %d", ErrInvalidAmount, mode)
+	}
+	return quotient, nil
+}
+
+// checkDecimals ensures two amounts share the same precision.
+func (a Amount) checkDecimals(b Amount) error {
+	if a.decimals != b.decimals {
+		return fmt.Errorf("%w: decimals mismatch (%d vs %d)", ErrInvalidAmount, a.decimals, b.decimals)
+	}
+	return nil
+}
+
+// pow10 returns 10^n as a *big.Int.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// isDigits reports whether s consists only of ASCII digits (an empty string is allowed).
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package x402
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestParseAmount verifies exact decimal parsing into atomic units
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		decimals int
+		want     string
+		wantErr  bool
+	}{
+		{"whole number", "1", 6, "1000000", false},
+		{"fractional", "1.50", 6, "1500000", false},
+		{"leading point", ".5", 6, "500000", false},
+		{"trailing point", "2.", 6, "2000000", false},
+		{"smallest unit", "0.000001", 6, "1", false},
+		{"extra zero digits", "1.5000000", 6, "1500000", false},
+		{"18 decimals", "0.1", 18, "100000000000000000", false},
+		{"negative", "-0.25", 6, "-250000", false},
+		{"zero decimals", "42", 0, "42", false},
+		{"too precise", "0.0000001", 6, "", true},
+		{"empty", "", 6, "", true},
+		{"only point", ".", 6, "", true},
+		{"non-numeric", "abc", 6, "", true},
+		{"scientific notation", "1e6", 6, "", true},
+		{"multiple points", "1.2.3", 6, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.input, tt.decimals)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Errorf("ParseAmount(%q) error = %v, want ErrInvalidAmount", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAmount(%q) unexpected error: %v", tt.input, err)
+			}
+			if got.AtomicString() != tt.want {
+				t.Errorf("ParseAmount(%q) = %s, want %s", tt.input, got.AtomicString(), tt.want)
+			}
+		})
+	}
+}
+
+// TestAmount_Formatting verifies human-readable output
+func TestAmount_Formatting(t *testing.T) {
+	tests := []struct {
+		name       string
+		atomic     int64
+		decimals   int
+		wantString string
+		wantFormat string
+	}{
+		{"whole", 1000000, 6, "1", "1.000000"},
+		{"fractional", 1500000, 6, "1.5", "1.500000"},
+		{"smallest unit", 1, 6, "0.000001", "0.000001"},
+		{"zero", 0, 6, "0", "0.000000"},
+		{"negative", -250000, 6, "-0.25", "-0.250000"},
+		{"zero decimals", 42, 0, "42", "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAtomicAmount(big.NewInt(tt.atomic), tt.decimals)
+			if got := a.String(); got != tt.wantString {
+				t.Errorf("String() = %s, want %s", got, tt.wantString)
+			}
+			if got := a.Format(); got != tt.wantFormat {
+				t.Errorf("Format() = %s, want %s", got, tt.wantFormat)
+			}
+		})
+	}
+}
+
+// TestAmount_Arithmetic verifies addition, subtraction and comparison
+func TestAmount_Arithmetic(t *testing.T) {
+	a, _ := ParseAmount("1.25", 6)
+	b, _ := ParseAmount("0.75", 6)
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if sum.String() != "2" {
+		t.Errorf("Add = %s, want 2", sum.String())
+	}
+
+	diff, err := a.Sub(b)
+	if err != nil {
+		t.Fatalf("Sub failed: %v", err)
+	}
+	if diff.String() != "0.5" {
+		t.Errorf("Sub = %s, want 0.5", diff.String())
+	}
+
+	if got := b.MulInt(4).String(); got != "3" {
+		t.Errorf("MulInt = %s, want 3", got)
+	}
+
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 {
+		t.Error("Cmp returned unexpected ordering")
+	}
+
+	// Operands must not be mutated
+	if a.String() != "1.25" || b.String() != "0.75" {
+		t.Errorf("operands mutated: a=%s b=%s", a, b)
+	}
+}
+
+// TestAmount_DecimalsMismatch verifies mixed-precision handling
+func TestAmount_DecimalsMismatch(t *testing.T) {
+	usdc, _ := ParseAmount("1", 6)
+	eth, _ := ParseAmount("1", 18)
+
+	if _, err := usdc.Add(eth); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Add with mismatched decimals error = %v, want ErrInvalidAmount", err)
+	}
+	if usdc.Cmp(eth) != 0 {
+		t.Error("Cmp should compare values across decimals")
+	}
+}
+
+// TestParseAtomicAmount verifies parsing of atomic amounts
+func TestParseAtomicAmount(t *testing.T) {
+	a, err := ParseAtomicAmount("1500000", 6)
+	if err != nil {
+		t.Fatalf("ParseAtomicAmount failed: %v", err)
+	}
+	if a.String() != "1.5" {
+		t.Errorf("String() = %s, want 1.5", a.String())
+	}
+
+	if _, err := ParseAtomicAmount("1.5", 6); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("ParseAtomicAmount(1.5) error = %v, want ErrInvalidAmount", err)
+	}
+}
@@ -0,0 +1,125 @@
+package x402
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// AssetToken is a token on one network that represents a logical asset.
+type AssetToken struct {
+	// Network is the x402 network identifier (e.g., "base").
+	Network string
+
+	// Address is the token contract address (EVM) or mint address (Solana).
+	Address string
+
+	// Decimals is the number of decimal places of the token on this network.
+	Decimals int
+}
+
+// AssetRegistry records which tokens on different networks are the same logical asset,
+// e.g. USDC on Base and USDC on Polygon, so spending can be limited, reported and
+// compared per asset across chains instead of per token address.
+//
+// AssetRegistry is safe for concurrent use. The zero value is an empty registry.
+type AssetRegistry struct {
+	mu       sync.RWMutex
+	tokens   map[assetKey]registeredToken
+	decimals map[string]int
+}
+
+// assetKey identifies a token by network and lowercase address.
+type assetKey struct {
+	network string
+	address string
+}
+
+// registeredToken is a token of a logical asset.
+type registeredToken struct {
+	symbol   string
+	decimals int
+}
+
+// DefaultAssetRegistry maps the built-in mainnet USDC tokens to the logical asset
+// "USDC". Testnet tokens are not registered: they are not worth the mainnet asset.
+var DefaultAssetRegistry = func() *AssetRegistry {
+	r := NewAssetRegistry()
+	var tokens []AssetToken
+	for _, chain := range []ChainConfig{SolanaMainnet, BaseMainnet, PolygonMainnet, AvalancheMainnet} {
+		tokens = append(tokens, AssetToken{Network: chain.NetworkID, Address: chain.USDCAddress, Decimals: int(chain.Decimals)})
+	}
+	r.Register("USDC", 6, tokens...)
+	return r
+}()
+
+// NewAssetRegistry creates an empty AssetRegistry.
+func NewAssetRegistry() *AssetRegistry {
+	return &AssetRegistry{}
+}
+
+// Register adds tokens to the logical asset symbol, whose normalized amounts have
+// decimals places. Registering a token again moves it to symbol; registering symbol
+// again updates its decimals.
+func (r *AssetRegistry) Register(symbol string, decimals int, tokens ...AssetToken) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tokens == nil {
+		r.tokens = make(map[assetKey]registeredToken)
+		r.decimals = make(map[string]int)
+	}
+	r.decimals[symbol] = decimals
+	for _, token := range tokens {
+		r.tokens[newAssetKey(token.Network, token.Address)] = registeredToken{symbol: symbol, decimals: token.Decimals}
+	}
+}
+
+// Lookup returns the logical asset of the token at address on network, and the
+// token's decimals. Addresses are matched case-insensitively.
+func (r *AssetRegistry) Lookup(network, address string) (symbol string, decimals int, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	token, ok := r.tokens[newAssetKey(network, address)]
+	return token.symbol, token.decimals, ok
+}
+
+// Same reports whether two tokens are the same logical asset. A token is always the same
+// asset as itself, registered or not.
+func (r *AssetRegistry) Same(networkA, addressA, networkB, addressB string) bool {
+	if newAssetKey(networkA, addressA) == newAssetKey(networkB, addressB) {
+		return true
+	}
+	a, _, okA := r.Lookup(networkA, addressA)
+	b, _, okB := r.Lookup(networkB, addressB)
+	return okA && okB && a == b
+}
+
+// Normalize converts amount, in atomic units of the token at address on network, to
+// atomic units of its logical asset, rounding up. It reports false for an unregistered
+// token.
+func (r *AssetRegistry) Normalize(network, address string, amount *big.Int) (symbol string, normalized *big.Int, ok bool) {
+	r.mu.RLock()
+	token, ok := r.tokens[newAssetKey(network, address)]
+	decimals := r.decimals[token.symbol]
+	r.mu.RUnlock()
+	if !ok {
+		return "", nil, false
+	}
+
+	switch shift := decimals - token.decimals; {
+	case shift >= 0:
+		normalized = new(big.Int).Mul(amount, pow10(shift))
+	default:
+		var rem big.Int
+		normalized, _ = new(big.Int).QuoRem(amount, pow10(-shift), &rem)
+		if rem.Sign() > 0 {
+			normalized.Add(normalized, big.NewInt(1))
+		}
+	}
+	return token.symbol, normalized, true
+}
+
+func newAssetKey(network, address string) assetKey {
+	return assetKey{network: network, address: strings.ToLower(address)}
+}
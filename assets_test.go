@@ -0,0 +1,71 @@
+package x402
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestAssetRegistry(t *testing.T) {
+	r := NewAssetRegistry()
+	r.Register("USDC", 6,
+		AssetToken{Network: "base", Address: "0xBaseUSDC", Decimals: 6},
+		AssetToken{Network: "bsc", Address: "0xBscUSDC", Decimals: 18},
+	)
+	r.Register("WETH", 18, AssetToken{Network: "base", Address: "0xWETH", Decimals: 18})
+
+	if symbol, decimals, ok := r.Lookup("bsc", "0xbscusdc"); !ok || symbol != "USDC" || decimals != 18 {
+		t.Errorf("Lookup() = %q, %d, %v; want USDC, 18, true", symbol, decimals, ok)
+	}
+	if _, _, ok := r.Lookup("polygon", "0xBaseUSDC"); ok {
+		t.Error("Lookup() found a token on the wrong network")
+	}
+
+	same := []struct {
+		networkA, addressA, networkB, addressB string
+		want                                   bool
+	}{
+		{"base", "0xBaseUSDC", "bsc", "0xBscUSDC", true},
+		{"base", "0xBaseUSDC", "base", "0xWETH", false},
+		{"base", "0xDAI", "base", "0xdai", true},
+		{"base", "0xDAI", "polygon", "0xDAI", false},
+	}
+	for _, tt := range same {
+		if got := r.Same(tt.networkA, tt.addressA, tt.networkB, tt.addressB); got != tt.want {
+			t.Errorf("Same(%s %s, %s %s) = %v, want %v", tt.networkA, tt.addressA, tt.networkB, tt.addressB, got, tt.want)
+		}
+	}
+
+	normalize := []struct {
+		name           string
+		network, asset string
+		amount         string
+		want           string
+		wantOK         bool
+	}{
+		{name: "same decimals", network: "base", asset: "0xBaseUSDC", amount: "1500000", want: "1500000", wantOK: true},
+		{name: "more decimals", network: "bsc", asset: "0xBscUSDC", amount: "1500000000000000000", want: "1500000", wantOK: true},
+		{name: "rounds up", network: "bsc", asset: "0xBscUSDC", amount: "1000000000001", want: "2", wantOK: true},
+		{name: "unregistered", network: "base", asset: "0xDAI", amount: "1"},
+	}
+	for _, tt := range normalize {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, _ := new(big.Int).SetString(tt.amount, 10)
+			symbol, got, ok := r.Normalize(tt.network, tt.asset, amount)
+			if ok != tt.wantOK {
+				t.Fatalf("Normalize() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (symbol != "USDC" || got.String() != tt.want) {
+				t.Errorf("Normalize() = %s %s, want USDC %s", got, symbol, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultAssetRegistry(t *testing.T) {
+	if !DefaultAssetRegistry.Same(BaseMainnet.NetworkID, BaseMainnet.USDCAddress, SolanaMainnet.NetworkID, SolanaMainnet.USDCAddress) {
+		t.Error("expected USDC on Base and Solana to be the same asset")
+	}
+	if DefaultAssetRegistry.Same(BaseMainnet.NetworkID, BaseMainnet.USDCAddress, BaseSepolia.NetworkID, BaseSepolia.USDCAddress) {
+		t.Error("expected testnet USDC not to be mainnet USDC")
+	}
+}
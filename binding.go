@@ -0,0 +1,116 @@
+package x402
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BindingDPoP is the PaymentBinding method binding a payment to a DPoP key (RFC 9449) by
+// the key's JWK SHA-256 thumbprint (RFC 7638). The paid request carries a DPoP proof
+// signed with the key.
+const BindingDPoP = "dpop"
+
+// bindingSaltSize is the size of the random salt of each binding.
+const bindingSaltSize = 16
+
+// PaymentBinding binds a payment to the client presenting it. Instead of a random
+// nonce, the payment's authorization is signed with BindingNonce of the binding's
+// material, the thumbprint of the client's DPoP key, so the nonce is covered by the
+// payer's signature. A server that checks the binding rejects the payment when it is
+// presented without a proof of the DPoP key, even within its validity window.
+//
+// The PaymentBinding itself is not signed: anyone holding a bound payment can remove it
+// and present the payment as unbound. Servers must therefore reject unbound payments for
+// a binding to protect anything.
+//
+// Only EIP-3009 authorizations, whose nonce the payer chooses, can be bound.
+type PaymentBinding struct {
+	// Method is BindingDPoP.
+	Method string `json:"method"`
+
+	// Salt is the hex-encoded random salt of the binding nonce.
+	Salt string `json:"salt"`
+}
+
+// BindingNonce returns the authorization nonce binding a payment with method to
+// material, salted with salt.
+func BindingNonce(method string, material, salt []byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte("x402-binding:" + method + ":"))
+	h.Write(material)
+	h.Write(salt)
+	var nonce [32]byte
+	copy(nonce[:], h.Sum(nil))
+	return nonce
+}
+
+// Verify reports whether nonce, the hex-encoded nonce of the payment's authorization,
+// binds the payment to material.
+func (b *PaymentBinding) Verify(nonce string, material []byte) bool {
+	salt, err := hex.DecodeString(b.Salt)
+	if err != nil || len(salt) == 0 {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(nonce), "0x"))
+	if err != nil {
+		return false
+	}
+	want := BindingNonce(b.Method, material, salt)
+	return subtle.ConstantTimeCompare(got, want[:]) == 1
+}
+
+// BoundSigner is implemented by signers that can sign payments with a nonce chosen by
+// the caller, to bind them (see PaymentBinding).
+type BoundSigner interface {
+	Signer
+
+	// SignBound signs requirements like Sign, with nonce as the authorization's nonce.
+	// It returns ErrBindingUnsupported for requirements it can only sign unbound.
+	SignBound(requirements *PaymentRequirement, nonce [32]byte) (*PaymentPayload, error)
+}
+
+// BindSigners returns signers that bind the payments they sign with method to
+// material, e.g. the thumbprint of the client's DPoP key. Signers that are not
+// BoundSigners, or cannot bind a payment, sign it unbound; servers that require
+// bindings reject such payments.
+func BindSigners(signers []Signer, method string, material []byte) []Signer {
+	bound := make([]Signer, len(signers))
+	for i, signer := range signers {
+		bound[i] = &bindingSigner{Signer: signer, method: method, material: material}
+	}
+	return bound
+}
+
+// bindingSigner binds the payments of a Signer.
+type bindingSigner struct {
+	Signer
+	method   string
+	material []byte
+}
+
+// Sign implements Signer.
+func (s *bindingSigner) Sign(requirements *PaymentRequirement) (*PaymentPayload, error) {
+	signer, ok := s.Signer.(BoundSigner)
+	if !ok {
+		return s.Signer.Sign(requirements)
+	}
+
+	salt := make([]byte, bindingSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate binding salt: %w", err)
+	}
+	payment, err := signer.SignBound(requirements, BindingNonce(s.method, s.material, salt))
+	if errors.Is(err, ErrBindingUnsupported) {
+		return s.Signer.Sign(requirements)
+	}
+	if err != nil {
+		return nil, err
+	}
+	payment.Binding = &PaymentBinding{Method: s.method, Salt: hex.EncodeToString(salt)}
+	return payment, nil
+}
@@ -0,0 +1,95 @@
+package x402
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// boundMockSigner is a mockSignerForSelector that implements BoundSigner.
+type boundMockSigner struct {
+	mockSignerForSelector
+	bindErr error
+}
+
+func (m *boundMockSigner) SignBound(req *PaymentRequirement, nonce [32]byte) (*PaymentPayload, error) {
+	if m.bindErr != nil {
+		return nil, m.bindErr
+	}
+	return &PaymentPayload{
+		X402Version: 1,
+		Scheme:      m.scheme,
+		Network:     m.network,
+		Payload:     EVMPayload{Authorization: EVMAuthorization{Nonce: "0x" + hex.EncodeToString(nonce[:])}},
+	}, nil
+}
+
+func TestBindSigners(t *testing.T) {
+	material := []byte("thumbprint")
+	requirement := &PaymentRequirement{Scheme: "exact", Network: "base"}
+
+	tests := []struct {
+		name      string
+		signer    Signer
+		wantBound bool
+	}{
+		{name: "bound signer", signer: &boundMockSigner{mockSignerForSelector: mockSignerForSelector{network: "base", scheme: "exact"}}, wantBound: true},
+		{name: "binding unsupported", signer: &boundMockSigner{mockSignerForSelector: mockSignerForSelector{network: "base", scheme: "exact"}, bindErr: ErrBindingUnsupported}},
+		{name: "plain signer", signer: &mockSignerForSelector{network: "base", scheme: "exact"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment, err := BindSigners([]Signer{tt.signer}, BindingDPoP, material)[0].Sign(requirement)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if (payment.Binding != nil) != tt.wantBound {
+				t.Fatalf("Binding = %+v, want bound %v", payment.Binding, tt.wantBound)
+			}
+			if !tt.wantBound {
+				return
+			}
+			nonce := payment.Payload.(EVMPayload).Authorization.Nonce
+			if !payment.Binding.Verify(nonce, material) {
+				t.Errorf("nonce %s does not bind the payment", nonce)
+			}
+			if payment.Binding.Verify(nonce, []byte("another key")) {
+				t.Error("nonce binds the payment to other material")
+			}
+		})
+	}
+
+	failing := &boundMockSigner{bindErr: errors.New("hardware wallet unplugged")}
+	if _, err := BindSigners([]Signer{failing}, BindingDPoP, material)[0].Sign(requirement); err == nil {
+		t.Error("Sign() succeeded although SignBound failed")
+	}
+}
+
+func TestPaymentBinding_Verify(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	nonce := BindingNonce(BindingDPoP, []byte("thumbprint"), salt)
+	binding := &PaymentBinding{Method: BindingDPoP, Salt: hex.EncodeToString(salt)}
+
+	tests := []struct {
+		name    string
+		binding *PaymentBinding
+		nonce   string
+		want    bool
+	}{
+		{name: "match", binding: binding, nonce: "0x" + hex.EncodeToString(nonce[:]), want: true},
+		{name: "upper case nonce", binding: binding, nonce: "0x" + strings.ToUpper(hex.EncodeToString(nonce[:])), want: true},
+		{name: "other method", binding: &PaymentBinding{Method: "tls-exporter", Salt: binding.Salt}, nonce: "0x" + hex.EncodeToString(nonce[:])},
+		{name: "no salt", binding: &PaymentBinding{Method: BindingDPoP}, nonce: "0x" + hex.EncodeToString(nonce[:])},
+		{name: "malformed nonce", binding: binding, nonce: "0xnope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.binding.Verify(tt.nonce, []byte("thumbprint")); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
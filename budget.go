@@ -0,0 +1,241 @@
+package x402
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// BudgetStore tracks spending against a SpendingLimit. Share one store (e.g. backed by
+// Redis) between processes that pay from the same wallet to enforce a single budget
+// across the fleet.
+//
+// Implementations must be safe for concurrent use.
+type BudgetStore interface {
+	// Reserve atomically adds amount to the spend recorded for key if the total stays
+	// within limit, and reports whether it did. The spend for key resets window after
+	// its first reservation; a zero window never resets.
+	Reserve(ctx context.Context, key string, amount, limit *big.Int, window time.Duration) (bool, error)
+
+	// Release subtracts a previously reserved amount from the spend recorded for key.
+	Release(ctx context.Context, key string, amount *big.Int) error
+}
+
+// SpendingLimit caps the total amount paid within a time window, in atomic token units.
+//
+// Amounts are reserved before a payment is signed and released if signing or the paid
+// request fails, so concurrent clients sharing a Store can never sign more than Limit
+// between them.
+type SpendingLimit struct {
+	// Limit is the maximum total amount per window (required).
+	Limit *big.Int
+
+	// Window is the budget period, e.g. 24*time.Hour. Zero means the budget never resets.
+	Window time.Duration
+
+	// Key identifies the budget in Store (default "default"). Clients sharing a wallet
+	// should use the same key.
+	Key string
+
+	// Store records spending. Defaults to an in-memory store, which only enforces the
+	// limit within a single process.
+	Store BudgetStore
+
+	// Asset optionally restricts the limit to one logical asset of Assets, e.g. "USDC".
+	// Limit is then in atomic units of that asset and covers its tokens on every
+	// registered network, whatever their decimals. Payments in other assets fail with
+	// ErrBudgetExceeded.
+	Asset string
+
+	// Assets maps tokens to logical assets for Asset (default DefaultAssetRegistry).
+	Assets *AssetRegistry
+
+	once         sync.Once
+	defaultStore BudgetStore
+}
+
+// NewSpendingLimit creates a SpendingLimit from a decimal amount in atomic units.
+func NewSpendingLimit(limit string, window time.Duration, store BudgetStore) (*SpendingLimit, error) {
+	amount, ok := new(big.Int).SetString(limit, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: spending limit %q", ErrInvalidAmount, limit)
+	}
+	return &SpendingLimit{Limit: amount, Window: window, Store: store}, nil
+}
+
+// Reserve holds amount against the budget. It returns ErrBudgetExceeded if the amount
+// does not fit in what remains of the current window. The returned release function
+// gives the amount back and may be called at most once.
+func (l *SpendingLimit) Reserve(ctx context.Context, amount *big.Int) (release func(), err error) {
+	key := l.Key
+	if key == "" {
+		key = "default"
+	}
+	store := l.store()
+
+	ok, err := store.Reserve(ctx, key, amount, l.Limit, l.Window)
+	if err != nil {
+		return nil, fmt.Errorf("budget store: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s would exceed limit %s", ErrBudgetExceeded, amount, l.Limit)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			// Release even if the request context was cancelled
+			_ = store.Release(context.WithoutCancel(ctx), key, amount)
+		})
+	}, nil
+}
+
+// Signers wraps signers so that each Sign call first reserves the requirement's amount
+// against the budget, failing with ErrBudgetExceeded when it does not fit. Signing
+// failures release their reservation. The returned release function gives back any
+// amount reserved by a successful Sign; call it when the paid request fails.
+func (l *SpendingLimit) Signers(ctx context.Context, signers []Signer) (wrapped []Signer, release func()) {
+	var mu sync.Mutex
+	var releases []func()
+
+	wrapped = make([]Signer, len(signers))
+	for i, signer := range signers {
+		wrapped[i] = &budgetSigner{
+			Signer: signer,
+			amount: l.amount,
+			reserve: func(amount *big.Int) (func(), error) {
+				r, err := l.Reserve(ctx, amount)
+				if err != nil {
+					return nil, err
+				}
+				mu.Lock()
+				releases = append(releases, r)
+				mu.Unlock()
+				return r, nil
+			},
+		}
+	}
+
+	return wrapped, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, r := range releases {
+			r()
+		}
+		releases = nil
+	}
+}
+
+// amount returns the amount of requirements counted against the limit.
+func (l *SpendingLimit) amount(requirements *PaymentRequirement) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAmount, requirements.MaxAmountRequired)
+	}
+	if l.Asset == "" {
+		return amount, nil
+	}
+
+	assets := l.Assets
+	if assets == nil {
+		assets = DefaultAssetRegistry
+	}
+	symbol, normalized, ok := assets.Normalize(requirements.Network, requirements.Asset, amount)
+	if !ok || symbol != l.Asset {
+		return nil, fmt.Errorf("%w: %s on %s is not %s", ErrBudgetExceeded, requirements.Asset, requirements.Network, l.Asset)
+	}
+	return normalized, nil
+}
+
+// store returns the configured store or a lazily created in-memory one.
+func (l *SpendingLimit) store() BudgetStore {
+	if l.Store != nil {
+		return l.Store
+	}
+	l.once.Do(func() { l.defaultStore = NewMemoryBudgetStore() })
+	return l.defaultStore
+}
+
+// budgetSigner reserves budget before delegating to the wrapped signer.
+type budgetSigner struct {
+	Signer
+	amount  func(requirements *PaymentRequirement) (*big.Int, error)
+	reserve func(amount *big.Int) (func(), error)
+}
+
+// Sign implements Signer.
+func (s *budgetSigner) Sign(requirements *PaymentRequirement) (*PaymentPayload, error) {
+	amount, err := s.amount(requirements)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.reserve(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.Signer.Sign(requirements)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return payment, nil
+}
+
+// MemoryBudgetStore is an in-memory BudgetStore for single-process clients.
+type MemoryBudgetStore struct {
+	mu      sync.Mutex
+	budgets map[string]*memoryBudget
+}
+
+type memoryBudget struct {
+	spent   *big.Int
+	resetAt time.Time
+}
+
+// NewMemoryBudgetStore creates an empty in-memory BudgetStore.
+func NewMemoryBudgetStore() *MemoryBudgetStore {
+	return &MemoryBudgetStore{budgets: make(map[string]*memoryBudget)}
+}
+
+// Reserve implements BudgetStore.
+func (s *MemoryBudgetStore) Reserve(_ context.Context, key string, amount, limit *big.Int, window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	budget, ok := s.budgets[key]
+	if !ok || (!budget.resetAt.IsZero() && !now.Before(budget.resetAt)) {
+		budget = &memoryBudget{spent: new(big.Int)}
+		if window > 0 {
+			budget.resetAt = now.Add(window)
+		}
+		s.budgets[key] = budget
+	}
+
+	total := new(big.Int).Add(budget.spent, amount)
+	if total.Cmp(limit) > 0 {
+		return false, nil
+	}
+	budget.spent = total
+	return true, nil
+}
+
+// Release implements BudgetStore.
+func (s *MemoryBudgetStore) Release(_ context.Context, key string, amount *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	budget, ok := s.budgets[key]
+	if !ok {
+		return nil
+	}
+	budget.spent.Sub(budget.spent, amount)
+	if budget.spent.Sign() < 0 {
+		budget.spent.SetInt64(0)
+	}
+	return nil
+}
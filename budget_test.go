@@ -0,0 +1,140 @@
+package x402
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNewSpendingLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		wantErr bool
+	}{
+		{"valid", "1000000", false},
+		{"zero", "0", true},
+		{"negative", "-1", true},
+		{"not a number", "1.5", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSpendingLimit(tt.limit, time.Hour, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSpendingLimit(%q) error = %v, wantErr %v", tt.limit, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("Expected ErrInvalidAmount, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSpendingLimit_Reserve(t *testing.T) {
+	limit, err := NewSpendingLimit("100", 0, nil)
+	if err != nil {
+		t.Fatalf("NewSpendingLimit failed: %v", err)
+	}
+	ctx := context.Background()
+
+	release, err := limit.Reserve(ctx, big.NewInt(70))
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if _, err := limit.Reserve(ctx, big.NewInt(40)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+	}
+
+	// Releasing twice must only return the amount once
+	release()
+	release()
+	if _, err := limit.Reserve(ctx, big.NewInt(100)); err != nil {
+		t.Errorf("Reserve after release failed: %v", err)
+	}
+	if _, err := limit.Reserve(ctx, big.NewInt(1)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded after double release, got %v", err)
+	}
+}
+
+func TestSpendingLimit_WindowReset(t *testing.T) {
+	limit, _ := NewSpendingLimit("10", 20*time.Millisecond, nil)
+	ctx := context.Background()
+
+	if _, err := limit.Reserve(ctx, big.NewInt(10)); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if _, err := limit.Reserve(ctx, big.NewInt(1)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := limit.Reserve(ctx, big.NewInt(10)); err != nil {
+		t.Errorf("Reserve after window failed: %v", err)
+	}
+}
+
+func TestSpendingLimit_Signers(t *testing.T) {
+	limit, _ := NewSpendingLimit("150", 0, nil)
+	requirement := &PaymentRequirement{Network: "base", Scheme: "exact", MaxAmountRequired: "100"}
+
+	failing := &mockSignerForSelector{network: "base", scheme: "exact", signError: errors.New("boom")}
+	signers, release := limit.Signers(context.Background(), []Signer{failing})
+	if _, err := signers[0].Sign(requirement); err == nil {
+		t.Fatal("Expected signing error")
+	}
+	release()
+
+	// The failed signature must not have consumed budget
+	working := &mockSignerForSelector{network: "base", scheme: "exact"}
+	signers, release = limit.Signers(context.Background(), []Signer{working})
+	if _, err := signers[0].Sign(requirement); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	working.signCalled = false
+	if _, err := signers[0].Sign(requirement); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+	}
+	if working.signCalled {
+		t.Error("Signer should not be called once the budget is exhausted")
+	}
+
+	// Releasing gives back the successful reservation
+	release()
+	if _, err := limit.Reserve(context.Background(), big.NewInt(150)); err != nil {
+		t.Errorf("Reserve after release failed: %v", err)
+	}
+}
+
+func TestSpendingLimit_Asset(t *testing.T) {
+	assets := NewAssetRegistry()
+	assets.Register("USDC", 6,
+		AssetToken{Network: "base", Address: "0xBaseUSDC", Decimals: 6},
+		AssetToken{Network: "bsc", Address: "0xBscUSDC", Decimals: 18},
+	)
+	limit, _ := NewSpendingLimit("2000000", 0, nil)
+	limit.Asset = "USDC"
+	limit.Assets = assets
+
+	signers, _ := limit.Signers(context.Background(), []Signer{&mockSignerForSelector{network: "base", scheme: "exact"}})
+	sign := func(network, asset, amount string) error {
+		_, err := signers[0].Sign(&PaymentRequirement{Network: network, Asset: asset, MaxAmountRequired: amount})
+		return err
+	}
+
+	// One USDC on each chain fills the two USDC budget
+	if err := sign("base", "0xbaseusdc", "1000000"); err != nil {
+		t.Fatalf("Sign on base failed: %v", err)
+	}
+	if err := sign("bsc", "0xBscUSDC", "1000000000000000000"); err != nil {
+		t.Fatalf("Sign on bsc failed: %v", err)
+	}
+	if err := sign("base", "0xBaseUSDC", "1"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded once the budget is spent, got %v", err)
+	}
+	if err := sign("base", "0xDAI", "1"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded for another asset, got %v", err)
+	}
+}
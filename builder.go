@@ -0,0 +1,202 @@
+package x402
+
+import (
+	"fmt"
+	"time"
+)
+
+// RequirementBuilder builds USDC payment requirements with a fluent API.
+// Create one with Require, chain setters, and finish with Build or BuildAll.
+//
+// Example:
+//
+//	req, err := x402.Require().
+//	    OnChain(x402.BaseMainnet).
+//	    Amount("0.25").
+//	    To("0xYourAddress").
+//	    Describe("Premium data").
+//	    Timeout(60 * time.Second).
+//	    Build()
+//
+// Use AlsoOn to accept the same price on several chains, and ToOn to give
+// chains with a different address format their own recipient:
+//
+//	accepts, err := x402.Require().
+//	    OnChain(x402.BaseMainnet).
+//	    AlsoOn(x402.PolygonMainnet).
+//	    AlsoOn(x402.SolanaMainnet).
+//	    Amount("0.25").
+//	    To("0xYourAddress").
+//	    ToOn(x402.SolanaMainnet, "YourSolanaAddress").
+//	    BuildAll()
+type RequirementBuilder struct {
+	chains     []ChainConfig
+	recipients map[string]string
+	feePayer   string
+	config     USDCRequirementConfig
+	err        error
+}
+
+// Require starts a new payment requirement builder.
+func Require() *RequirementBuilder {
+	return &RequirementBuilder{
+		recipients: make(map[string]string),
+	}
+}
+
+// OnChain sets the primary chain for the requirement.
+func (b *RequirementBuilder) OnChain(chain ChainConfig) *RequirementBuilder {
+	if len(b.chains) == 0 {
+		b.chains = append(b.chains, chain)
+	} else {
+		b.chains[0] = chain
+	}
+	return b
+}
+
+// AlsoOn adds additional chains that accept the same amount and terms.
+// Each chain produces one entry in the list returned by BuildAll.
+func (b *RequirementBuilder) AlsoOn(chains ...ChainConfig) *RequirementBuilder {
+	if len(b.chains) == 0 && len(chains) > 0 {
+		b.chains = append(b.chains, chains[0])
+		chains = chains[1:]
+	}
+	b.chains = append(b.chains, chains...)
+	return b
+}
+
+// Amount sets the human-readable USDC amount (e.g., "0.25" = 0.25 USDC).
+func (b *RequirementBuilder) Amount(amount string) *RequirementBuilder {
+	b.config.Amount = amount
+	return b
+}
+
+// RoundWith sets how an amount with more than 6 decimals is rounded (defaults to RoundHalfEven).
+func (b *RequirementBuilder) RoundWith(mode RoundingMode) *RequirementBuilder {
+	b.config.Rounding = mode
+	return b
+}
+
+// Minimum sets the human-readable floor for non-zero amounts; smaller amounts are raised to it.
+func (b *RequirementBuilder) Minimum(amount string) *RequirementBuilder {
+	b.config.MinimumAmount = amount
+	return b
+}
+
+// To sets the default payment recipient for all chains.
+func (b *RequirementBuilder) To(address string) *RequirementBuilder {
+	b.config.RecipientAddress = address
+	return b
+}
+
+// ToOn overrides the payment recipient for a single chain.
+// This is needed when mixing EVM and Solana chains, whose addresses differ.
+func (b *RequirementBuilder) ToOn(chain ChainConfig, address string) *RequirementBuilder {
+	b.recipients[chain.NetworkID] = address
+	return b
+}
+
+// FeePayer sets the fee payer of the requirements for SVM chains, usually the
+// facilitator's address. Without it, the middleware fills it in from the facilitator's
+// /supported endpoint at startup.
+func (b *RequirementBuilder) FeePayer(address string) *RequirementBuilder {
+	b.feePayer = address
+	return b
+}
+
+// Describe sets the human-readable payment description.
+func (b *RequirementBuilder) Describe(description string) *RequirementBuilder {
+	b.config.Description = description
+	return b
+}
+
+// Timeout sets the validity period for the payment authorization.
+// The duration is truncated to whole seconds and must be at least one second.
+// The authorization must outlive verification, the handler and settlement, so it should
+// be no shorter than the server's settle timeout (DefaultTimeouts.SettleTimeout unless
+// configured); the http middleware warns about shorter ones.
+func (b *RequirementBuilder) Timeout(d time.Duration) *RequirementBuilder {
+	if d < time.Second {
+		b.setErr(fmt.Errorf("timeout: must be at least 1s, got %v", d))
+		return b
+	}
+	b.config.MaxTimeoutSeconds = uint32(d / time.Second)
+	return b
+}
+
+// Scheme sets the payment scheme (defaults to "exact").
+func (b *RequirementBuilder) Scheme(scheme string) *RequirementBuilder {
+	b.config.Scheme = scheme
+	return b
+}
+
+// MimeType sets the response MIME type (defaults to "application/json").
+func (b *RequirementBuilder) MimeType(mimeType string) *RequirementBuilder {
+	b.config.MimeType = mimeType
+	return b
+}
+
+// Build returns the requirement for the primary chain.
+// It returns an error if no chain was set or if the requirement fails validation.
+func (b *RequirementBuilder) Build() (PaymentRequirement, error) {
+	if b.err != nil {
+		return PaymentRequirement{}, b.err
+	}
+	if len(b.chains) == 0 {
+		return PaymentRequirement{}, fmt.Errorf("chain: must be set with OnChain")
+	}
+	return b.buildFor(b.chains[0])
+}
+
+// BuildAll returns one requirement per configured chain, in the order the chains were added.
+// It returns an error if no chain was set or if any requirement fails validation.
+func (b *RequirementBuilder) BuildAll() ([]PaymentRequirement, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.chains) == 0 {
+		return nil, fmt.Errorf("chain: must be set with OnChain")
+	}
+
+	requirements := make([]PaymentRequirement, 0, len(b.chains))
+	for _, chain := range b.chains {
+		req, err := b.buildFor(chain)
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, req)
+	}
+	return requirements, nil
+}
+
+// buildFor creates the requirement for a single chain.
+func (b *RequirementBuilder) buildFor(chain ChainConfig) (PaymentRequirement, error) {
+	if b.config.Amount == "" {
+		return PaymentRequirement{}, fmt.Errorf("amount: must be set with Amount")
+	}
+
+	config := b.config
+	config.Chain = chain
+	if recipient, ok := b.recipients[chain.NetworkID]; ok {
+		config.RecipientAddress = recipient
+	}
+
+	req, err := NewUSDCPaymentRequirement(config)
+	if err != nil {
+		return PaymentRequirement{}, fmt.Errorf("%s: %w", chain.NetworkID, err)
+	}
+	if networkType, _ := ValidateNetwork(chain.NetworkID); networkType == NetworkTypeSVM && b.feePayer != "" {
+		if err := ValidateAddress(chain.NetworkID, b.feePayer); err != nil {
+			return PaymentRequirement{}, fmt.Errorf("%s: feePayer: %w", chain.NetworkID, err)
+		}
+		req.SetFeePayer(b.feePayer)
+	}
+	return req, nil
+}
+
+// setErr records the first error encountered while configuring the builder.
+func (b *RequirementBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,189 @@
+package x402
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRequirementBuilder_Build verifies the builder produces the same requirement as NewUSDCPaymentRequirement
+func TestRequirementBuilder_Build(t *testing.T) {
+	req, err := Require().
+		OnChain(BaseMainnet).
+		Amount("0.25").
+		To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C").
+		Describe("Premium data").
+		Timeout(60 * time.Second).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if req.Network != "base" {
+		t.Errorf("Network = %s, want base", req.Network)
+	}
+	if req.MaxAmountRequired != "250000" {
+		t.Errorf("MaxAmountRequired = %s, want 250000", req.MaxAmountRequired)
+	}
+	if req.Asset != BaseMainnet.USDCAddress {
+		t.Errorf("Asset = %s, want %s", req.Asset, BaseMainnet.USDCAddress)
+	}
+	if req.Description != "Premium data" {
+		t.Errorf("Description = %s, want Premium data", req.Description)
+	}
+	if req.MaxTimeoutSeconds != 60 {
+		t.Errorf("MaxTimeoutSeconds = %d, want 60", req.MaxTimeoutSeconds)
+	}
+	if req.Scheme != "exact" {
+		t.Errorf("Scheme = %s, want exact", req.Scheme)
+	}
+	if req.Extra["name"] != BaseMainnet.EIP3009Name {
+		t.Errorf("Extra[name] = %v, want %s", req.Extra["name"], BaseMainnet.EIP3009Name)
+	}
+}
+
+// TestRequirementBuilder_BuildAll verifies multi-chain accepts lists and per-chain recipients
+func TestRequirementBuilder_BuildAll(t *testing.T) {
+	evmAddr := "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+	svmAddr := "DRpbCBMxVnDK7maPM5tGv6MvB3v1sRMC86PZ8okm21hy"
+
+	accepts, err := Require().
+		OnChain(BaseMainnet).
+		AlsoOn(PolygonMainnet, SolanaMainnet).
+		Amount("1").
+		To(evmAddr).
+		ToOn(SolanaMainnet, svmAddr).
+		BuildAll()
+	if err != nil {
+		t.Fatalf("BuildAll failed: %v", err)
+	}
+
+	if len(accepts) != 3 {
+		t.Fatalf("len(accepts) = %d, want 3", len(accepts))
+	}
+
+	wantNetworks := []string{"base", "polygon", "solana"}
+	wantPayTo := []string{evmAddr, evmAddr, svmAddr}
+	for i, req := range accepts {
+		if req.Network != wantNetworks[i] {
+			t.Errorf("accepts[%d].Network = %s, want %s", i, req.Network, wantNetworks[i])
+		}
+		if req.PayTo != wantPayTo[i] {
+			t.Errorf("accepts[%d].PayTo = %s, want %s", i, req.PayTo, wantPayTo[i])
+		}
+		if req.MaxAmountRequired != "1000000" {
+			t.Errorf("accepts[%d].MaxAmountRequired = %s, want 1000000", i, req.MaxAmountRequired)
+		}
+	}
+}
+
+// TestRequirementBuilder_FeePayer verifies the fee payer is set on SVM chains only
+func TestRequirementBuilder_FeePayer(t *testing.T) {
+	feePayer := "2wKupLR9q6wXYppw8Gr2NvWxKBUqm4PPJKkQfoxHDBg4"
+	accepts, err := Require().
+		OnChain(BaseMainnet).
+		AlsoOn(SolanaMainnet).
+		Amount("1").
+		To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C").
+		ToOn(SolanaMainnet, "DRpbCBMxVnDK7maPM5tGv6MvB3v1sRMC86PZ8okm21hy").
+		FeePayer(feePayer).
+		BuildAll()
+	if err != nil {
+		t.Fatalf("BuildAll failed: %v", err)
+	}
+
+	if _, ok := accepts[0].Extra["feePayer"]; ok {
+		t.Errorf("accepts[0].Extra = %v, want no feePayer on EVM", accepts[0].Extra)
+	}
+	if accepts[1].Extra["feePayer"] != feePayer {
+		t.Errorf("accepts[1].Extra[feePayer] = %v, want %s", accepts[1].Extra["feePayer"], feePayer)
+	}
+	for i, req := range accepts {
+		if err := req.ValidateForScheme(); err != nil {
+			t.Errorf("accepts[%d].ValidateForScheme() = %v", i, err)
+		}
+	}
+
+	_, err = Require().
+		OnChain(SolanaMainnet).
+		Amount("1").
+		To("DRpbCBMxVnDK7maPM5tGv6MvB3v1sRMC86PZ8okm21hy").
+		FeePayer("0x209693Bc6afc0C5328bA36FaF03C514EF312287C").
+		Build()
+	if err == nil || !strings.Contains(err.Error(), "feePayer") {
+		t.Errorf("Build with an EVM fee payer = %v, want feePayer error", err)
+	}
+}
+
+// TestRequirementBuilder_Errors verifies builder validation errors
+func TestRequirementBuilder_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		builder     *RequirementBuilder
+		errContains string
+	}{
+		{
+			name:        "missing chain",
+			builder:     Require().Amount("1").To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C"),
+			errContains: "chain",
+		},
+		{
+			name:        "missing amount",
+			builder:     Require().OnChain(BaseMainnet).To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C"),
+			errContains: "amount",
+		},
+		{
+			name:        "missing recipient",
+			builder:     Require().OnChain(BaseMainnet).Amount("1"),
+			errContains: "recipientAddress",
+		},
+		{
+			name:        "invalid amount",
+			builder:     Require().OnChain(BaseMainnet).Amount("abc").To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C"),
+			errContains: "amount",
+		},
+		{
+			name:        "sub-second timeout",
+			builder:     Require().OnChain(BaseMainnet).Amount("1").To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C").Timeout(500 * time.Millisecond),
+			errContains: "timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.errContains)
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %q", tt.errContains, err.Error())
+			}
+		})
+	}
+}
+
+// TestRequirementBuilder_RoundingPolicy verifies the rounding mode and minimum reach the requirement
+func TestRequirementBuilder_RoundingPolicy(t *testing.T) {
+	req, err := Require().
+		OnChain(BaseMainnet).
+		Amount("0.0012345").
+		RoundWith(RoundUp).
+		Minimum("0.001").
+		To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C").
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if req.MaxAmountRequired != "1235" {
+		t.Errorf("MaxAmountRequired = %s, want 1235", req.MaxAmountRequired)
+	}
+
+	req, err = Require().OnChain(BaseMainnet).Amount("0.0001").Minimum("0.001").
+		To("0x209693Bc6afc0C5328bA36FaF03C514EF312287C").Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if req.MaxAmountRequired != "1000" {
+		t.Errorf("MaxAmountRequired = %s, want the 1000 minimum", req.MaxAmountRequired)
+	}
+}
@@ -0,0 +1,160 @@
+package x402
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// CanonicalJSON returns the canonical JSON encoding of v: the same bytes for values that
+// are equal as x402 data, however they were built or decoded. It is meant for pinning
+// requirements, cache keys and signing over requirements or payments.
+//
+// The canonical form is v's JSON encoding with:
+//   - object keys sorted by their bytes, recursively (including Extra and payload maps)
+//   - members whose value is null, "", {} or [] omitted, so a missing field and an empty
+//     one encode alike; numbers and booleans are always kept
+//   - no insignificant whitespace and no HTML escaping
+//   - numbers written as they appear in v's encoding
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("x402: canonical JSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("x402: canonical JSON: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, value); err != nil {
+		return nil, fmt.Errorf("x402: canonical JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// CanonicalHash returns the hex-encoded SHA-256 of the canonical JSON encoding of v.
+func CanonicalHash(v interface{}) (string, error) {
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CanonicalJSON returns the canonical JSON encoding of the requirement. See CanonicalJSON.
+func (r PaymentRequirement) CanonicalJSON() ([]byte, error) {
+	return CanonicalJSON(r)
+}
+
+// CanonicalHash returns the hex-encoded SHA-256 of the requirement's canonical JSON, a
+// stable identifier of the payment option it describes.
+func (r PaymentRequirement) CanonicalHash() (string, error) {
+	return CanonicalHash(r)
+}
+
+// CanonicalJSON returns the canonical JSON encoding of the payment. See CanonicalJSON.
+func (p PaymentPayload) CanonicalJSON() ([]byte, error) {
+	return CanonicalJSON(p)
+}
+
+// CanonicalHash returns the hex-encoded SHA-256 of the payment's canonical JSON. It is
+// the same for a typed payload and its decoded map form.
+func (p PaymentPayload) CanonicalHash() (string, error) {
+	return CanonicalHash(p)
+}
+
+// writeCanonical writes a decoded JSON value in canonical form.
+func writeCanonical(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k, member := range v {
+			if !isEmptyJSON(member) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeString(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case string:
+		return writeString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("unexpected JSON value %T", value)
+	}
+	return nil
+}
+
+// writeString writes s as a JSON string without HTML escaping.
+func writeString(buf *bytes.Buffer, s string) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// isEmptyJSON reports whether a decoded JSON member is omitted from the canonical form.
+func isEmptyJSON(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		for _, member := range v {
+			if !isEmptyJSON(member) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
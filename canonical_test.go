@@ -0,0 +1,139 @@
+package x402
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "sorted keys",
+			value: map[string]interface{}{"b": 1, "a": "x", "c": map[string]interface{}{"z": true, "y": false}},
+			want:  `{"a":"x","b":1,"c":{"y":false,"z":true}}`,
+		},
+		{
+			name:  "empty members omitted",
+			value: map[string]interface{}{"a": "", "b": nil, "c": map[string]interface{}{"d": ""}, "e": []interface{}{}, "f": 0},
+			want:  `{"f":0}`,
+		},
+		{
+			name:  "array order kept",
+			value: []interface{}{"b", "a", map[string]interface{}{"y": 1, "x": 2}},
+			want:  `["b","a",{"x":2,"y":1}]`,
+		},
+		{
+			name:  "no html escaping",
+			value: map[string]interface{}{"description": "<a & b>"},
+			want:  `{"description":"<a & b>"}`,
+		},
+		{
+			name:  "large numbers unchanged",
+			value: json.RawMessage(`{"value":123456789012345678901234567890}`),
+			want:  `{"value":123456789012345678901234567890}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalJSON(tt.value)
+			if err != nil {
+				t.Fatalf("CanonicalJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("CanonicalJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaymentRequirement_CanonicalHash(t *testing.T) {
+	requirement := PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "10000",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+		Extra:             map[string]interface{}{"version": "2", "name": "USD Coin"},
+	}
+
+	data, err := requirement.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("CanonicalJSON() error = %v", err)
+	}
+	want := `{"asset":"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913","extra":{"name":"USD Coin","version":"2"},` +
+		`"maxAmountRequired":"10000","maxTimeoutSeconds":60,"network":"base",` +
+		`"payTo":"0x209693Bc6afc0C5328bA36FaF03C514EF312287C","scheme":"exact"}`
+	if string(data) != want {
+		t.Errorf("CanonicalJSON() = %s, want %s", data, want)
+	}
+
+	hash, err := requirement.CanonicalHash()
+	if err != nil {
+		t.Fatalf("CanonicalHash() error = %v", err)
+	}
+	if len(hash) != 64 {
+		t.Errorf("CanonicalHash() = %q, want 64 hex characters", hash)
+	}
+
+	// A requirement decoded from JSON with explicit empty fields hashes alike
+	var decoded PaymentRequirement
+	if err := json.Unmarshal([]byte(`{"scheme":"exact","network":"base","maxAmountRequired":"10000",`+
+		`"resource":"","description":"","mimeType":"","outputSchema":null,"maxTimeoutSeconds":60,`+
+		`"asset":"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913","payTo":"0x209693Bc6afc0C5328bA36FaF03C514EF312287C",`+
+		`"extra":{"name":"USD Coin","version":"2"}}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := decoded.CanonicalHash(); got != hash {
+		t.Errorf("decoded CanonicalHash() = %s, want %s", got, hash)
+	}
+
+	// Any change to the payment terms changes the hash
+	changed := requirement
+	changed.MaxAmountRequired = "10001"
+	if got, _ := changed.CanonicalHash(); got == hash {
+		t.Error("CanonicalHash() ignores MaxAmountRequired")
+	}
+}
+
+func TestPaymentPayload_CanonicalHash(t *testing.T) {
+	typed := PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base",
+		Payload: EVMPayload{
+			Signature: "0xsig",
+			Authorization: EVMAuthorization{
+				From:        "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				To:          "0x1111111111111111111111111111111111111111",
+				Value:       "10000",
+				ValidAfter:  "0",
+				ValidBefore: "1700000000",
+				Nonce:       "0x01",
+			},
+		},
+	}
+
+	// Decoded from a header, the payload is a map with its own key order
+	data, err := json.Marshal(typed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PaymentPayload
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := typed.CanonicalHash()
+	if err != nil {
+		t.Fatalf("CanonicalHash() error = %v", err)
+	}
+	if got, _ := decoded.CanonicalHash(); got != want {
+		t.Errorf("decoded CanonicalHash() = %s, want %s", got, want)
+	}
+}
@@ -8,8 +8,9 @@ package x402
 
 import (
 	"fmt"
-	"math"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 // NetworkType represents the blockchain virtual machine type.
@@ -68,6 +69,14 @@ type USDCRequirementConfig struct {
 
 	// MimeType is the response MIME type (optional, defaults to "application/json").
 	MimeType string
+
+	// Rounding is how an Amount with more than 6 decimals, such as a converted fiat
+	// price, is rounded to atomic units (optional, defaults to RoundHalfEven).
+	Rounding RoundingMode
+
+	// MinimumAmount is the human-readable floor for non-zero amounts (optional), e.g. the
+	// smallest payment worth its settlement cost. Smaller non-zero amounts are raised to it.
+	MinimumAmount string
 }
 
 // Mainnet chain configurations
@@ -177,7 +186,9 @@ func NewUSDCTokenConfig(chain ChainConfig, priority int) TokenConfig {
 // It validates inputs, converts the amount to atomic units (assuming 6 decimals for USDC),
 // applies defaults for optional fields, and populates EIP-3009 parameters for EVM chains.
 //
-// Amount conversion uses standard float64 rounding (banker's rounding) for precision beyond 6 decimals.
+// Amounts with more than 6 decimals are rounded exactly according to config.Rounding
+// (banker's rounding by default), then raised to config.MinimumAmount if set. A non-zero
+// amount that rounds to zero atomic units returns ErrAmountTooSmall rather than a dust price.
 // Zero amounts ("0" or "0.0") are explicitly allowed for free-with-signature authorization flows.
 //
 // Default values:
@@ -191,19 +202,25 @@ func NewUSDCPaymentRequirement(config USDCRequirementConfig) (PaymentRequirement
 	if config.RecipientAddress == "" {
 		return PaymentRequirement{}, fmt.Errorf("recipientAddress: cannot be empty")
 	}
+	if err := ValidateAddress(config.Chain.NetworkID, config.RecipientAddress); err != nil {
+		return PaymentRequirement{}, fmt.Errorf("recipientAddress: %w", err)
+	}
 
-	// Parse and validate amount
-	amount, err := strconv.ParseFloat(config.Amount, 64)
+	// Parse, validate and convert amount to atomic units (USDC always has 6 decimals)
+	atomicUnits, err := usdcAtomicAmount(config.Amount, config.Rounding)
 	if err != nil {
-		return PaymentRequirement{}, fmt.Errorf("amount: invalid format")
+		return PaymentRequirement{}, fmt.Errorf("amount: %w", err)
 	}
-	if amount < 0 {
-		return PaymentRequirement{}, fmt.Errorf("amount: must be non-negative")
+	if config.MinimumAmount != "" && atomicUnits.Sign() > 0 {
+		minimum, err := usdcAtomicAmount(config.MinimumAmount, RoundUp)
+		if err != nil {
+			return PaymentRequirement{}, fmt.Errorf("minimumAmount: %w", err)
+		}
+		if atomicUnits.Cmp(minimum) < 0 {
+			atomicUnits = minimum
+		}
 	}
-
-	// Convert to atomic units (USDC always has 6 decimals)
-	atomicUnits := uint64(math.RoundToEven(amount * 1e6))
-	atomicString := strconv.FormatUint(atomicUnits, 10)
+	atomicString := atomicUnits.String()
 
 	// Apply defaults
 	scheme := config.Scheme
@@ -235,15 +252,36 @@ func NewUSDCPaymentRequirement(config USDCRequirementConfig) (PaymentRequirement
 
 	// Populate EIP-3009 extra field for EVM chains
 	if config.Chain.EIP3009Name != "" {
-		req.Extra = map[string]interface{}{
-			"name":    config.Chain.EIP3009Name,
-			"version": config.Chain.EIP3009Version,
-		}
+		req.SetEIP3009Domain(config.Chain.EIP3009Name, config.Chain.EIP3009Version)
 	}
 
 	return req, nil
 }
 
+// usdcAtomicAmount converts a human-readable USDC amount to atomic units.
+func usdcAtomicAmount(amount string, mode RoundingMode) (*big.Int, error) {
+	// ParseFloat defines the accepted formats; the conversion itself is exact
+	if _, err := strconv.ParseFloat(amount, 64); err != nil {
+		return nil, fmt.Errorf("invalid format")
+	}
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, fmt.Errorf("invalid format")
+	}
+	if value.Sign() < 0 {
+		return nil, fmt.Errorf("must be non-negative")
+	}
+
+	atomic, err := roundToAtomic(value, 6, mode)
+	if err != nil {
+		return nil, err
+	}
+	if atomic.Sign() == 0 && value.Sign() > 0 {
+		return nil, fmt.Errorf("%w: %s USDC is less than one atomic unit", ErrAmountTooSmall, amount)
+	}
+	return atomic, nil
+}
+
 // ValidateNetwork validates a network identifier and returns its type.
 // Returns NetworkTypeEVM for EVM chains, NetworkTypeSVM for Solana chains,
 // or NetworkTypeUnknown with an error for unrecognized networks.
@@ -277,3 +315,29 @@ func ValidateNetwork(networkID string) (NetworkType, error) {
 
 	return netType, nil
 }
+
+// IsTestnet reports whether networkID is one of the supported testnets:
+// base-sepolia, polygon-amoy, avalanche-fuji or solana-devnet.
+func IsTestnet(networkID string) bool {
+	switch networkID {
+	case BaseSepolia.NetworkID, PolygonAmoy.NetworkID, AvalancheFuji.NetworkID, SolanaDevnet.NetworkID:
+		return true
+	}
+	return false
+}
+
+// LookupUSDC returns the chain configuration whose USDC asset is asset on networkID,
+// and false if asset is not one of the built-in USDC addresses.
+func LookupUSDC(networkID, asset string) (ChainConfig, bool) {
+	for _, chain := range []ChainConfig{
+		SolanaMainnet, SolanaDevnet,
+		BaseMainnet, BaseSepolia,
+		PolygonMainnet, PolygonAmoy,
+		AvalancheMainnet, AvalancheFuji,
+	} {
+		if chain.NetworkID == networkID && strings.EqualFold(chain.USDCAddress, asset) {
+			return chain, true
+		}
+	}
+	return ChainConfig{}, false
+}
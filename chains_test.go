@@ -1,6 +1,7 @@
 package x402
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -128,7 +129,7 @@ func TestNewUSDCPaymentRequirementValidInputs(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            BaseMainnet,
 				Amount:           "1.0",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantNetwork:       "base",
 			wantAsset:         BaseMainnet.USDCAddress,
@@ -158,7 +159,7 @@ func TestNewUSDCPaymentRequirementValidInputs(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            PolygonMainnet,
 				Amount:           "2.5",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantNetwork:       "polygon",
 			wantAsset:         PolygonMainnet.USDCAddress,
@@ -173,7 +174,7 @@ func TestNewUSDCPaymentRequirementValidInputs(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            BaseSepolia,
 				Amount:           "0.1",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantNetwork:       "base-sepolia",
 			wantAsset:         BaseSepolia.USDCAddress,
@@ -188,7 +189,7 @@ func TestNewUSDCPaymentRequirementValidInputs(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            AvalancheMainnet,
 				Amount:           "100",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantNetwork:       "avalanche",
 			wantAsset:         AvalancheMainnet.USDCAddress,
@@ -218,7 +219,7 @@ func TestNewUSDCPaymentRequirementValidInputs(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            PolygonAmoy,
 				Amount:           "0.000001",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantNetwork:       "polygon-amoy",
 			wantAsset:         PolygonAmoy.USDCAddress,
@@ -233,7 +234,7 @@ func TestNewUSDCPaymentRequirementValidInputs(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            AvalancheFuji,
 				Amount:           "999.999999",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantNetwork:       "avalanche-fuji",
 			wantAsset:         AvalancheFuji.USDCAddress,
@@ -308,7 +309,7 @@ func TestNewUSDCPaymentRequirementEVMExtra(t *testing.T) {
 			req, err := NewUSDCPaymentRequirement(USDCRequirementConfig{
 				Chain:            tt.chain,
 				Amount:           "1.0",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			})
 			if err != nil {
 				t.Fatalf("NewUSDCPaymentRequirement() error = %v", err)
@@ -382,7 +383,7 @@ func TestNewUSDCPaymentRequirementAmountConversion(t *testing.T) {
 			req, err := NewUSDCPaymentRequirement(USDCRequirementConfig{
 				Chain:            BaseMainnet,
 				Amount:           tt.amount,
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			})
 			if err != nil {
 				t.Fatalf("NewUSDCPaymentRequirement() error = %v", err)
@@ -413,7 +414,7 @@ func TestNewUSDCPaymentRequirementRounding(t *testing.T) {
 			req, err := NewUSDCPaymentRequirement(USDCRequirementConfig{
 				Chain:            BaseMainnet,
 				Amount:           tt.amount,
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			})
 			if err != nil {
 				t.Fatalf("NewUSDCPaymentRequirement() error = %v", err)
@@ -426,6 +427,54 @@ func TestNewUSDCPaymentRequirementRounding(t *testing.T) {
 	}
 }
 
+// TestNewUSDCPaymentRequirementRoundingPolicy tests rounding modes and the minimum-amount floor
+func TestNewUSDCPaymentRequirementRoundingPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     string
+		rounding   RoundingMode
+		minimum    string
+		wantAtomic string
+		wantErr    error
+	}{
+		{name: "up", amount: "0.0000101", rounding: RoundUp, wantAtomic: "11"},
+		{name: "down", amount: "0.0000109", rounding: RoundDown, wantAtomic: "10"},
+		{name: "exact value ignores mode", amount: "0.25", rounding: RoundUp, wantAtomic: "250000"},
+		{name: "half even is exact", amount: "0.0000015", wantAtomic: "2"},
+		{name: "dust rounds to zero", amount: "0.0000004", wantErr: ErrAmountTooSmall},
+		{name: "dust rounded down", amount: "0.0000009", rounding: RoundDown, wantErr: ErrAmountTooSmall},
+		{name: "dust rounded up", amount: "0.0000001", rounding: RoundUp, wantAtomic: "1"},
+		{name: "raised to minimum", amount: "0.001", minimum: "0.01", wantAtomic: "10000"},
+		{name: "above minimum", amount: "0.5", minimum: "0.01", wantAtomic: "500000"},
+		{name: "zero stays free", amount: "0", minimum: "0.01", wantAtomic: "0"},
+		{name: "minimum does not hide dust", amount: "0.0000001", minimum: "0.01", wantErr: ErrAmountTooSmall},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewUSDCPaymentRequirement(USDCRequirementConfig{
+				Chain:            BaseMainnet,
+				Amount:           tt.amount,
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
+				Rounding:         tt.rounding,
+				MinimumAmount:    tt.minimum,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewUSDCPaymentRequirement() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewUSDCPaymentRequirement() error = %v", err)
+			}
+			if req.MaxAmountRequired != tt.wantAtomic {
+				t.Errorf("MaxAmountRequired = %s, want %s", req.MaxAmountRequired, tt.wantAtomic)
+			}
+		})
+	}
+}
+
 // TestNewUSDCPaymentRequirementZeroAmounts tests that zero amounts are allowed
 func TestNewUSDCPaymentRequirementZeroAmounts(t *testing.T) {
 	tests := []struct {
@@ -442,7 +491,7 @@ func TestNewUSDCPaymentRequirementZeroAmounts(t *testing.T) {
 			req, err := NewUSDCPaymentRequirement(USDCRequirementConfig{
 				Chain:            BaseMainnet,
 				Amount:           tt.amount,
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			})
 			if err != nil {
 				t.Fatalf("NewUSDCPaymentRequirement() error = %v, want nil", err)
@@ -467,7 +516,7 @@ func TestNewUSDCPaymentRequirementErrors(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            BaseMainnet,
 				Amount:           "-5",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantError: "amount: must be non-negative",
 		},
@@ -485,7 +534,7 @@ func TestNewUSDCPaymentRequirementErrors(t *testing.T) {
 			config: USDCRequirementConfig{
 				Chain:            BaseMainnet,
 				Amount:           "abc",
-				RecipientAddress: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+				RecipientAddress: "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 			},
 			wantError: "amount: invalid format",
 		},
@@ -510,7 +559,7 @@ func TestNewUSDCPaymentRequirementCustomConfig(t *testing.T) {
 	req, err := NewUSDCPaymentRequirement(USDCRequirementConfig{
 		Chain:             BaseMainnet,
 		Amount:            "5.0",
-		RecipientAddress:  "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+		RecipientAddress:  "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 		Scheme:            "estimate",
 		MaxTimeoutSeconds: 600,
 		MimeType:          "text/plain",
@@ -733,3 +782,27 @@ func TestValidateNetworkEmpty(t *testing.T) {
 		t.Errorf("error = %v, want %v", err.Error(), wantError)
 	}
 }
+
+func TestIsTestnet(t *testing.T) {
+	tests := []struct {
+		networkID string
+		want      bool
+	}{
+		{"base-sepolia", true},
+		{"polygon-amoy", true},
+		{"avalanche-fuji", true},
+		{"solana-devnet", true},
+		{"base", false},
+		{"solana", false},
+		{"", false},
+		{"unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.networkID, func(t *testing.T) {
+			if got := IsTestnet(tt.networkID); got != tt.want {
+				t.Errorf("IsTestnet(%q) = %v, want %v", tt.networkID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+// payerHeader carries the verified payer's address on allowed requests.
+const payerHeader = "X-X402-Payer"
+
+// newAuthzHandler returns the authorization handler. pathPrefix is stripped from the
+// request path, for proxies (like Envoy) that forward the original path under a prefix.
+func newAuthzHandler(engine *httpx402.Engine, pathPrefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision := engine.Authorize(r.Context(), forwardedRequest(r, pathPrefix))
+		if decision.Proceed {
+			decision = engine.Settle(r.Context(), decision)
+		}
+		if decision.Replay != nil {
+			// The proxy relays denied responses to the client, so a replay reaches it too
+			decision.Replay.Write(w)
+			return
+		}
+		if decision.Status == http.StatusNotModified {
+			decision.CopyHeader(w.Header())
+			w.WriteHeader(decision.Status)
+			return
+		}
+		if !decision.Proceed {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(decision.Status)
+			_ = json.NewEncoder(w).Encode(decision.Body())
+			return
+		}
+
+		decision.CopyHeader(w.Header())
+		if decision.Payment != nil {
+			w.Header().Set(payerHeader, decision.Payment.Payer)
+		} else if decision.Session != nil {
+			w.Header().Set(payerHeader, decision.Session.Payer)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// forwardedRequest describes the client's original request. Traefik reports it in
+// X-Forwarded-Method, X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Uri; Envoy
+// forwards the original method, host and path (below pathPrefix) directly.
+func forwardedRequest(r *http.Request, pathPrefix string) httpx402.EngineRequest {
+	req := httpx402.NewEngineRequest(r)
+
+	if method := r.Header.Get("X-Forwarded-Method"); method != "" {
+		req.Method = method
+	}
+
+	uri := r.Header.Get("X-Forwarded-Uri")
+	if uri == "" {
+		uri = strings.TrimPrefix(r.RequestURI, pathPrefix)
+		if !strings.HasPrefix(uri, "/") {
+			uri = "/" + uri
+		}
+	}
+	req.Path = uri
+	if u, err := url.ParseRequestURI(uri); err == nil {
+		req.Path = u.Path
+	}
+
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	scheme := r.Header.Get("X-Forwarded-Proto")
+	if scheme == "" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+	req.ResourceURL = scheme + "://" + host + uri
+
+	// The first X-Forwarded-For entry is the client as seen by the proxy
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		client, _, _ := strings.Cut(forwardedFor, ",")
+		req.ClientIP = strings.TrimSpace(client)
+	}
+	return req
+}
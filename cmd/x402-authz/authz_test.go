@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+const testPayer = "0x857b06519E91e3A54538791bDbb0E22373e36b66"
+
+func TestForwardedRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		header       map[string]string
+		pathPrefix   string
+		wantMethod   string
+		wantPath     string
+		wantResource string
+		wantClientIP string
+	}{
+		{
+			name:   "traefik forwardAuth",
+			target: "/",
+			header: map[string]string{
+				"X-Forwarded-Method": "POST",
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "api.example.com",
+				"X-Forwarded-Uri":    "/premium/data?limit=10",
+				"X-Forwarded-For":    "198.51.100.7, 10.0.0.2",
+			},
+			wantMethod:   "POST",
+			wantPath:     "/premium/data",
+			wantResource: "https://api.example.com/premium/data?limit=10",
+			wantClientIP: "198.51.100.7",
+		},
+		{
+			name:         "envoy ext_authz",
+			target:       "/authz/premium/data",
+			header:       map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-For": "198.51.100.7"},
+			pathPrefix:   "/authz",
+			wantMethod:   "GET",
+			wantPath:     "/premium/data",
+			wantResource: "https://api.example.com/premium/data",
+			wantClientIP: "198.51.100.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.target, nil)
+			r.Host = "api.example.com"
+			for key, value := range tt.header {
+				r.Header.Set(key, value)
+			}
+
+			req := forwardedRequest(r, tt.pathPrefix)
+			if req.Method != tt.wantMethod || req.Path != tt.wantPath || req.ResourceURL != tt.wantResource || req.ClientIP != tt.wantClientIP {
+				t.Errorf("forwardedRequest() = %+v", req)
+			}
+		})
+	}
+}
+
+func TestAuthzHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+		case "/settle":
+			_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+		}
+	}))
+	defer server.Close()
+
+	requirement, err := x402.NewUSDCPaymentRequirement(x402.USDCRequirementConfig{
+		Chain:            x402.BaseSepolia,
+		Amount:           "0.01",
+		RecipientAddress: "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+	})
+	if err != nil {
+		t.Fatalf("NewUSDCPaymentRequirement: %v", err)
+	}
+	engine, err := httpx402.NewEngine(&httpx402.Config{
+		FacilitatorURL:      server.URL,
+		PaymentRequirements: []x402.PaymentRequirement{requirement},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	handler := newAuthzHandler(engine, "")
+
+	// Unpaid requests receive the 402 body for the client
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-Uri", "/premium")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected status 402, got %d", rec.Code)
+	}
+	var body x402.PaymentRequirementsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body.Accepts) != 1 {
+		t.Fatalf("expected payment requirements, got %s", rec.Body.String())
+	}
+
+	// Paid requests are settled and allowed
+	payment, err := encoding.EncodePayment(x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base-sepolia",
+		Payload: x402.EVMPayload{
+			Signature:     "0xsig",
+			Authorization: x402.EVMAuthorization{From: testPayer, Value: requirement.MaxAmountRequired},
+		},
+	})
+	if err != nil {
+		t.Fatalf("EncodePayment: %v", err)
+	}
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-Uri", "/premium")
+	req.Header.Set("X-PAYMENT", payment)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-PAYMENT-RESPONSE") == "" {
+		t.Error("expected X-PAYMENT-RESPONSE header")
+	}
+	if rec.Header().Get(payerHeader) != testPayer {
+		t.Errorf("expected payer %s, got %q", testPayer, rec.Header().Get(payerHeader))
+	}
+}
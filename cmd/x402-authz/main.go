@@ -0,0 +1,115 @@
+// Command x402-authz enforces x402 payments at the edge as an external authorization
+// service for Envoy (ext_authz, HTTP service mode) or Traefik (forwardAuth).
+//
+// For every request the proxy asks x402-authz whether to let it through. Requests
+// without a valid X-PAYMENT header are answered with a 402 and the payment requirements,
+// which the proxy returns to the client unchanged. Paid requests are verified and
+// settled with the facilitator and allowed with a 200 whose X-PAYMENT-RESPONSE and
+// X-X402-Payer headers can be passed on to the upstream or the client.
+//
+// Traefik:
+//
+//	http:
+//	  middlewares:
+//	    x402:
+//	      forwardAuth:
+//	        address: http://x402-authz:8080
+//	        authResponseHeaders: ["X-PAYMENT-RESPONSE", "X-X402-Payer"]
+//
+// Envoy (http_service, with --path-prefix /authz):
+//
+//	http_service:
+//	  server_uri: {uri: "x402-authz:8080", cluster: x402_authz, timeout: 30s}
+//	  path_prefix: /authz
+//	  authorization_request:
+//	    allowed_headers: {patterns: [{exact: x-payment}, {exact: x-api-key}]}
+//	  authorization_response:
+//	    allowed_upstream_headers: {patterns: [{exact: x-x402-payer}]}
+//	    allowed_client_headers_on_success: {patterns: [{exact: x-payment-response}]}
+//
+// The original client address is taken from X-Forwarded-For, so the service must only
+// be reachable by the proxy.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+func main() {
+	listen := flag.String("listen", ":8080", "Address to listen on")
+	facilitatorURL := flag.String("facilitator", "https://facilitator.x402.rs", "Facilitator URL")
+	network := flag.String("network", "base-sepolia", "Network to accept payments on (base, base-sepolia, solana, solana-devnet, ...)")
+	payTo := flag.String("pay-to", "", "Address to receive payments (required)")
+	amount := flag.String("amount", "0.001", "Payment amount in USDC per request")
+	description := flag.String("description", "", "Description of the paid resource")
+	pathPrefix := flag.String("path-prefix", "", "Prefix the proxy adds to authorization request paths (Envoy path_prefix)")
+	verifyOnly := flag.Bool("verify-only", false, "Verify payments without settling them")
+	flag.Parse()
+
+	if *payTo == "" {
+		fmt.Println("Error: --pay-to is required")
+		fmt.Println()
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	chain, ok := chainFor(*network)
+	if !ok {
+		log.Fatalf("Unsupported network %s", *network)
+	}
+	requirement, err := x402.NewUSDCPaymentRequirement(x402.USDCRequirementConfig{
+		Chain:            chain,
+		Amount:           *amount,
+		RecipientAddress: *payTo,
+		Description:      *description,
+	})
+	if err != nil {
+		log.Fatalf("Invalid payment requirement: %v", err)
+	}
+
+	engine, err := httpx402.NewEngine(&httpx402.Config{
+		FacilitatorURL:      *facilitatorURL,
+		PaymentRequirements: []x402.PaymentRequirement{requirement},
+		VerifyOnly:          *verifyOnly,
+	})
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           newAuthzHandler(engine, *pathPrefix),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	slog.Info("x402-authz listening", "addr", *listen, "network", *network, "amount", *amount, "payTo", *payTo)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// chainFor returns the built-in chain configuration for network.
+func chainFor(network string) (x402.ChainConfig, bool) {
+	for _, chain := range []x402.ChainConfig{
+		x402.SolanaMainnet, x402.SolanaDevnet,
+		x402.BaseMainnet, x402.BaseSepolia,
+		x402.PolygonMainnet, x402.PolygonAmoy,
+		x402.AvalancheMainnet, x402.AvalancheFuji,
+	} {
+		if chain.NetworkID == strings.ToLower(network) {
+			return chain, true
+		}
+	}
+	return x402.ChainConfig{}, false
+}
@@ -0,0 +1,179 @@
+// Command x402-signerd serves a local x402 signer over mutual TLS, so private keys live
+// on one hardened host while many clients pay through it with signers/remote.
+//
+// Callers authenticate with client certificates issued by the CA in --client-ca and are
+// identified by the certificate's common name. A policy file restricts each caller:
+//
+//	{
+//	  "callers": {
+//	    "agent-1": {"maxAmountPerCall": "100000", "allowedPayTo": ["0x..."]}
+//	  },
+//	  "default": {"maxAmountPerCall": "10000"}
+//	}
+//
+// Callers not listed are rejected unless a default policy is set.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/signers/evm"
+	"github.com/mark3labs/x402-go/signers/remote"
+	"github.com/mark3labs/x402-go/signers/svm"
+)
+
+// policyFile is the format of the --policies file.
+type policyFile struct {
+	Callers map[string]remote.Policy `json:"callers"`
+	Default *remote.Policy           `json:"default,omitempty"`
+}
+
+func main() {
+	listen := flag.String("listen", ":8443", "Address to listen on")
+	network := flag.String("network", "base-sepolia", "Network to sign for (base, base-sepolia, solana, solana-devnet, ...)")
+	keyEnv := flag.String("key-env", "X402_PRIVATE_KEY", "Environment variable holding the private key (hex for EVM, base58 for Solana)")
+	keyFile := flag.String("key-file", "", "Solana keygen file or EVM keystore file (instead of --key-env)")
+	passwordEnv := flag.String("keystore-password-env", "X402_KEYSTORE_PASSWORD", "Environment variable holding the EVM keystore password")
+	tokenAddr := flag.String("token", "", "Token address (defaults to USDC on the network)")
+	maxAmount := flag.String("max-amount", "", "Maximum amount per payment in atomic units, for all callers")
+	policies := flag.String("policies", "", "Per-caller policy file (JSON)")
+	tlsCert := flag.String("tls-cert", "", "Server certificate file (required)")
+	tlsKey := flag.String("tls-key", "", "Server private key file (required)")
+	clientCA := flag.String("client-ca", "", "CA file used to verify client certificates (required)")
+	flag.Parse()
+
+	if *tlsCert == "" || *tlsKey == "" || *clientCA == "" {
+		fmt.Println("Error: --tls-cert, --tls-key and --client-ca are required")
+		fmt.Println()
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *tokenAddr == "" {
+		*tokenAddr = usdcAddress(*network)
+		if *tokenAddr == "" {
+			log.Fatalf("No default token for network %s, use --token", *network)
+		}
+	}
+
+	signer, address, err := newSigner(*network, *keyEnv, *keyFile, *passwordEnv, *tokenAddr, *maxAmount)
+	if err != nil {
+		log.Fatalf("Failed to create signer: %v", err)
+	}
+
+	config := remote.ServerConfig{Signer: signer, Address: address}
+	if *policies != "" {
+		policy, err := loadPolicies(*policies)
+		if err != nil {
+			log.Fatalf("Failed to load policies: %v", err)
+		}
+		config.Policies = policy.Callers
+		config.DefaultPolicy = policy.Default
+	}
+	if len(config.Policies) == 0 && config.DefaultPolicy == nil {
+		log.Fatal("No caller policies configured; every request would be rejected")
+	}
+
+	handler, err := remote.NewServer(config)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	tlsConfig, err := remote.ServerTLSConfig(*tlsCert, *tlsKey, *clientCA)
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	slog.Info("x402-signerd listening", "addr", *listen, "network", *network, "address", address,
+		"callers", len(config.Policies), "defaultPolicy", config.DefaultPolicy != nil)
+	if err := server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// usdcAddress returns the USDC address on a built-in network, or "" if unknown.
+func usdcAddress(network string) string {
+	for _, chain := range []x402.ChainConfig{
+		x402.SolanaMainnet, x402.SolanaDevnet,
+		x402.BaseMainnet, x402.BaseSepolia,
+		x402.PolygonMainnet, x402.PolygonAmoy,
+		x402.AvalancheMainnet, x402.AvalancheFuji,
+	} {
+		if chain.NetworkID == strings.ToLower(network) {
+			return chain.USDCAddress
+		}
+	}
+	return ""
+}
+
+// newSigner creates the key-holding signer for network and returns it with its address.
+func newSigner(network, keyEnv, keyFile, passwordEnv, tokenAddr, maxAmount string) (x402.Signer, string, error) {
+	if strings.HasPrefix(strings.ToLower(network), "solana") {
+		opts := []svm.SignerOption{
+			svm.WithNetwork(network),
+			svm.WithToken(tokenAddr, "USDC", 6),
+		}
+		if keyFile != "" {
+			opts = append(opts, svm.WithKeygenFile(keyFile))
+		} else {
+			opts = append(opts, svm.WithPrivateKey(os.Getenv(keyEnv)))
+		}
+		if maxAmount != "" {
+			opts = append(opts, svm.WithMaxAmountPerCall(maxAmount))
+		}
+
+		signer, err := svm.NewSigner(opts...)
+		if err != nil {
+			return nil, "", err
+		}
+		return signer, signer.Address(), nil
+	}
+
+	opts := []evm.SignerOption{
+		evm.WithNetwork(network),
+		evm.WithToken(tokenAddr, "USDC", 6),
+	}
+	if keyFile != "" {
+		opts = append(opts, evm.WithKeystore(keyFile, os.Getenv(passwordEnv)))
+	} else {
+		opts = append(opts, evm.WithPrivateKey(os.Getenv(keyEnv)))
+	}
+	if maxAmount != "" {
+		opts = append(opts, evm.WithMaxAmountPerCall(maxAmount))
+	}
+
+	signer, err := evm.NewSigner(opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	return signer, signer.Address().Hex(), nil
+}
+
+// loadPolicies reads a policy file.
+func loadPolicies(path string) (*policyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policies policyFile
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return &policies, nil
+}
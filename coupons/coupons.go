@@ -0,0 +1,166 @@
+// Package coupons mints and redeems signed coupons that grant access to x402-protected
+// resources without payment. Coupons are HMAC-signed tokens carrying an ID, an expiry and
+// an optional redemption limit, so servers can hand out promo codes without separate
+// auth infrastructure.
+package coupons
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Header is the request header that carries a coupon token.
+const Header = "X-X402-Coupon"
+
+var (
+	// ErrInvalidCoupon indicates a coupon token is malformed or has a bad signature.
+	ErrInvalidCoupon = errors.New("x402: invalid coupon")
+
+	// ErrCouponExpired indicates a coupon is past its expiry time.
+	ErrCouponExpired = errors.New("x402: coupon expired")
+
+	// ErrCouponExhausted indicates a coupon has reached its redemption limit.
+	ErrCouponExhausted = errors.New("x402: coupon redemption limit reached")
+
+	// ErrCouponNotApplicable indicates a coupon does not cover the requested resource.
+	ErrCouponNotApplicable = errors.New("x402: coupon not valid for this resource")
+)
+
+// Coupon describes the access a coupon grants.
+type Coupon struct {
+	// ID identifies the coupon. Redemptions are counted per ID.
+	ID string `json:"id"`
+
+	// ExpiresAt is when the coupon stops being accepted. Zero means it never expires.
+	ExpiresAt time.Time `json:"exp,omitempty"`
+
+	// MaxRedemptions limits how many requests the coupon can pay for. Zero means unlimited.
+	MaxRedemptions int `json:"max,omitempty"`
+
+	// PathPrefix restricts the coupon to request paths with this prefix (optional).
+	PathPrefix string `json:"path,omitempty"`
+}
+
+// Store counts coupon redemptions.
+// Implementations must be safe for concurrent use; share one store between replicas
+// to enforce redemption limits across a deployment.
+type Store interface {
+	// Increment adds one to the counter for key and returns the new count.
+	Increment(ctx context.Context, key string) (int, error)
+}
+
+// Issuer mints and redeems coupons signed with a shared secret.
+// Issuer is safe for concurrent use.
+type Issuer struct {
+	secret []byte
+	store  Store
+}
+
+// NewIssuer creates an Issuer. A nil store uses an in-memory store, which only enforces
+// redemption limits within a single process.
+func NewIssuer(secret []byte, store Store) *Issuer {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Issuer{secret: secret, store: store}
+}
+
+// Mint returns a signed token for the coupon, suitable for the X-X402-Coupon header.
+func (i *Issuer) Mint(coupon Coupon) (string, error) {
+	if coupon.ID == "" {
+		return "", fmt.Errorf("%w: id cannot be empty", ErrInvalidCoupon)
+	}
+	if coupon.MaxRedemptions < 0 {
+		return "", fmt.Errorf("%w: max redemptions cannot be negative", ErrInvalidCoupon)
+	}
+
+	data, err := json.Marshal(coupon)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal coupon: %w", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + i.sign(payload), nil
+}
+
+// Parse checks a token's signature and expiry and returns the coupon it carries.
+// It does not count a redemption.
+func (i *Issuer) Parse(token string) (*Coupon, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCoupon)
+	}
+	if !hmac.Equal([]byte(signature), []byte(i.sign(payload))) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidCoupon)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCoupon)
+	}
+	var coupon Coupon
+	if err := json.Unmarshal(data, &coupon); err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCoupon)
+	}
+
+	if !coupon.ExpiresAt.IsZero() && !time.Now().Before(coupon.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %s expired at %s", ErrCouponExpired, coupon.ID, coupon.ExpiresAt.Format(time.RFC3339))
+	}
+	return &coupon, nil
+}
+
+// Redeem validates a token for a request path and counts one redemption against it.
+// It returns the coupon if the request may proceed without payment.
+func (i *Issuer) Redeem(ctx context.Context, token, path string) (*Coupon, error) {
+	coupon, err := i.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if coupon.PathPrefix != "" && !strings.HasPrefix(path, coupon.PathPrefix) {
+		return nil, fmt.Errorf("%w: %s", ErrCouponNotApplicable, path)
+	}
+
+	if coupon.MaxRedemptions > 0 {
+		count, err := i.store.Increment(ctx, coupon.ID)
+		if err != nil {
+			return nil, fmt.Errorf("coupon store: %w", err)
+		}
+		if count > coupon.MaxRedemptions {
+			return nil, fmt.Errorf("%w: %s", ErrCouponExhausted, coupon.ID)
+		}
+	}
+	return coupon, nil
+}
+
+// sign returns the base64url-encoded HMAC-SHA256 of the token payload.
+func (i *Issuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// MemoryStore is an in-memory Store for single-instance deployments.
+type MemoryStore struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewMemoryStore creates an empty in-memory redemption store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counts: make(map[string]int)}
+}
+
+// Increment implements Store.
+func (s *MemoryStore) Increment(_ context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[key]++
+	return s.counts[key], nil
+}
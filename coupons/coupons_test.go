@@ -0,0 +1,82 @@
+package coupons
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssuer_MintAndRedeem(t *testing.T) {
+	issuer := NewIssuer([]byte("secret"), nil)
+	token, err := issuer.Mint(Coupon{ID: "launch", MaxRedemptions: 2})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		coupon, err := issuer.Redeem(ctx, token, "/api/data")
+		if err != nil {
+			t.Fatalf("Redeem %d failed: %v", i+1, err)
+		}
+		if coupon.ID != "launch" {
+			t.Errorf("Expected coupon ID launch, got %s", coupon.ID)
+		}
+	}
+
+	if _, err := issuer.Redeem(ctx, token, "/api/data"); !errors.Is(err, ErrCouponExhausted) {
+		t.Errorf("Expected ErrCouponExhausted, got %v", err)
+	}
+}
+
+func TestIssuer_Errors(t *testing.T) {
+	issuer := NewIssuer([]byte("secret"), nil)
+	ctx := context.Background()
+
+	expired, _ := issuer.Mint(Coupon{ID: "old", ExpiresAt: time.Now().Add(-time.Minute)})
+	scoped, _ := issuer.Mint(Coupon{ID: "docs", PathPrefix: "/docs/"})
+	valid, _ := issuer.Mint(Coupon{ID: "valid"})
+	forged, _ := NewIssuer([]byte("other"), nil).Mint(Coupon{ID: "valid"})
+
+	payload, _, _ := strings.Cut(valid, ".")
+	tampered := payload + "x." + strings.SplitN(valid, ".", 2)[1]
+
+	tests := []struct {
+		name    string
+		token   string
+		path    string
+		wantErr error
+	}{
+		{"expired", expired, "/", ErrCouponExpired},
+		{"wrong path", scoped, "/api/data", ErrCouponNotApplicable},
+		{"matching path", scoped, "/docs/intro", nil},
+		{"forged signature", forged, "/", ErrInvalidCoupon},
+		{"tampered payload", tampered, "/", ErrInvalidCoupon},
+		{"malformed", "not-a-token", "/", ErrInvalidCoupon},
+		{"unlimited", valid, "/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := issuer.Redeem(ctx, tt.token, tt.path)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Redeem unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Redeem error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssuer_MintRequiresID(t *testing.T) {
+	issuer := NewIssuer([]byte("secret"), nil)
+	if _, err := issuer.Mint(Coupon{}); !errors.Is(err, ErrInvalidCoupon) {
+		t.Errorf("Expected ErrInvalidCoupon, got %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// PaymentEncodingCBOR names the compact encoding of X-PAYMENT headers for constrained
+// clients: base64-encoded CBOR (RFC 8949) of the payment's JSON object. The field names
+// of x402 payments are encoded as small integers (see cborKeys), and lower-case "0x" hex
+// strings, such as signatures and nonces, as byte strings tagged for base16 conversion
+// (tag 23). EVM payment headers are about half the size of the default base64-encoded
+// JSON.
+const PaymentEncodingCBOR = "cbor"
+
+// PaymentMediaTypeCBOR is the media type of PaymentEncodingCBOR, accepted as its name.
+const PaymentMediaTypeCBOR = "application/cbor"
+
+// maxCBORDepth bounds the nesting of decoded CBOR values.
+const maxCBORDepth = 32
+
+// CBOR major types.
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTag      = 6
+	cborSimple   = 7
+)
+
+// cborKeys numbers the field names of x402 payments, which are encoded as their index
+// instead of as text. Only append to it: the numbers are part of the encoding.
+var cborKeys = []string{
+	1: "x402Version", 2: "scheme", 3: "network", 4: "payload", 5: "simulated",
+	6: "reference", 7: "binding", 8: "signature", 9: "authorization", 10: "from",
+	11: "to", 12: "value", 13: "validAfter", 14: "validBefore", 15: "nonce",
+	16: "permit", 17: "owner", 18: "spender", 19: "deadline", 20: "transaction",
+	21: "method", 22: "salt",
+}
+
+// cborKeyNumbers maps the field names of cborKeys to their numbers.
+var cborKeyNumbers = func() map[string]uint64 {
+	numbers := make(map[string]uint64, len(cborKeys))
+	for i, key := range cborKeys {
+		if key != "" {
+			numbers[key] = uint64(i)
+		}
+	}
+	return numbers
+}()
+
+// cborTagBase16 tags byte strings expected to be converted to base16, which encode
+// lower-case "0x" hex strings.
+const cborTagBase16 = 23
+
+// EncodePaymentCBOR converts a PaymentPayload to base64-encoded CBOR, the
+// PaymentEncodingCBOR encoding of X-PAYMENT headers.
+//
+// Returns an error if JSON marshaling fails.
+func EncodePaymentCBOR(payment x402.PaymentPayload) (string, error) {
+	data, err := json.Marshal(payment)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payment: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("failed to marshal payment: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeCBOR(&buf, value); err != nil {
+		return "", fmt.Errorf("failed to marshal payment: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodePaymentCBOR converts base64-encoded CBOR, the PaymentEncodingCBOR encoding of
+// X-PAYMENT headers, to PaymentPayload. Only the JSON data model is supported: maps
+// with text keys or the numbers of cborKeys, arrays, text, integers, floats, booleans
+// and null.
+//
+// Returns an error if base64 or CBOR decoding fails.
+func DecodePaymentCBOR(encoded string) (x402.PaymentPayload, error) {
+	var payment x402.PaymentPayload
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return payment, fmt.Errorf("failed to decode base64: %w", err)
+	}
+	r := &cborReader{data: data}
+	value, err := r.value(0)
+	if err == nil && r.pos != len(data) {
+		err = errors.New("trailing data")
+	}
+	if err != nil {
+		return payment, fmt.Errorf("failed to decode CBOR: %w", err)
+	}
+
+	// Round-trip through JSON so the payload decodes like a JSON header
+	jsonData, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(jsonData, &payment)
+	}
+	if err != nil {
+		return payment, fmt.Errorf("failed to unmarshal payment: %w", err)
+	}
+	return payment, nil
+}
+
+// writeCBOR appends the CBOR encoding of a JSON value decoded with UseNumber. Map keys
+// are sorted, so equal values encode identically, and those in cborKeys are numbered.
+func writeCBOR(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(cborSimple<<5 | 22)
+	case bool:
+		if v {
+			buf.WriteByte(cborSimple<<5 | 21)
+		} else {
+			buf.WriteByte(cborSimple<<5 | 20)
+		}
+	case string:
+		if b, ok := hexString(v); ok {
+			writeCBORHead(buf, cborTag, cborTagBase16)
+			writeCBORHead(buf, cborBytes, uint64(len(b)))
+			buf.Write(b)
+			return nil
+		}
+		writeCBORHead(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			if n >= 0 {
+				writeCBORHead(buf, cborUnsigned, uint64(n))
+			} else {
+				writeCBORHead(buf, cborNegative, uint64(-(n + 1)))
+			}
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(cborSimple<<5 | 27)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	case []any:
+		writeCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := writeCBOR(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		writeCBORHead(buf, cborMap, uint64(len(v)))
+		for _, key := range keys {
+			if number, ok := cborKeyNumbers[key]; ok {
+				writeCBORHead(buf, cborUnsigned, number)
+			} else {
+				writeCBORHead(buf, cborText, uint64(len(key)))
+				buf.WriteString(key)
+			}
+			if err := writeCBOR(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported value of type %T", value)
+	}
+	return nil
+}
+
+// writeCBORHead appends the head of a data item of major type with argument n, in its
+// shortest form.
+func writeCBORHead(buf *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major | 27)
+		_ = binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+// cborReader decodes CBOR data items into JSON values.
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+// value decodes the next data item, nested depth levels deep.
+func (r *cborReader) value(depth int) (any, error) {
+	if depth > maxCBORDepth {
+		return nil, errors.New("nested too deeply")
+	}
+	major, info, n, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		return n, nil
+	case cborNegative:
+		if n > math.MaxInt64 {
+			return nil, errors.New("integer out of range")
+		}
+		return -int64(n) - 1, nil
+	case cborText:
+		text, err := r.bytes(n)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(text) {
+			return nil, errors.New("invalid UTF-8 text")
+		}
+		return string(text), nil
+	case cborTag:
+		if n != cborTagBase16 {
+			return nil, fmt.Errorf("unsupported tag %d", n)
+		}
+		major, _, n, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+		if major != cborBytes {
+			return nil, errors.New("base16 tag on a data item other than a byte string")
+		}
+		b, err := r.bytes(n)
+		if err != nil {
+			return nil, err
+		}
+		return "0x" + hex.EncodeToString(b), nil
+	case cborArray:
+		// Every item takes at least a byte
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, errors.New("unexpected end of data")
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = r.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case cborMap:
+		if n > uint64(len(r.data)-r.pos)/2 {
+			return nil, errors.New("unexpected end of data")
+		}
+		m := make(map[string]any, n)
+		for i := uint64(0); i < n; i++ {
+			key, err := r.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			text, ok := key.(string)
+			if number, isNumber := key.(uint64); isNumber && number < uint64(len(cborKeys)) && cborKeys[number] != "" {
+				text, ok = cborKeys[number], true
+			}
+			if !ok {
+				return nil, errors.New("map key is neither text nor a known field")
+			}
+			if m[text], err = r.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case cborSimple:
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		case 25:
+			return float16(uint16(n)), nil
+		case 26:
+			return float64(math.Float32frombits(uint32(n))), nil
+		case 27:
+			return math.Float64frombits(n), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported data item (major type %d, info %d)", major, info)
+}
+
+// head decodes the head of the next data item: its major type, additional information
+// and argument.
+func (r *cborReader) head() (major, info byte, n uint64, err error) {
+	if r.pos >= len(r.data) {
+		return 0, 0, 0, errors.New("unexpected end of data")
+	}
+	initial := r.data[r.pos]
+	r.pos++
+	major, info = initial>>5, initial&0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		arg, err := r.bytes(uint64(size))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for _, b := range arg {
+			n = n<<8 | uint64(b)
+		}
+		return major, info, n, nil
+	default:
+		// Indefinite lengths and reserved values
+		return 0, 0, 0, fmt.Errorf("unsupported additional information %d", info)
+	}
+}
+
+// bytes returns the next n bytes.
+func (r *cborReader) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errors.New("unexpected end of data")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// hexString returns the bytes of s if it is a lower-case "0x" hex string that encodes
+// back to itself.
+func hexString(s string) ([]byte, bool) {
+	if len(s) < 4 || len(s)%2 != 0 || s[:2] != "0x" {
+		return nil, false
+	}
+	for _, c := range s[2:] {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s[2:])
+	return b, err == nil
+}
+
+// float16 converts an IEEE 754 half-precision float to float64.
+func float16(h uint16) float64 {
+	exponent := int(h>>10) & 0x1f
+	mantissa := float64(h & 0x3ff)
+	var f float64
+	switch exponent {
+	case 0:
+		f = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa != 0 {
+			f = math.NaN()
+		} else {
+			f = math.Inf(1)
+		}
+	default:
+		f = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -1,5 +1,8 @@
 // Package encoding provides utilities for encoding and decoding x402 payment data.
 // It handles base64 and JSON marshaling for payment payloads, settlements, and requirements.
+//
+// Payment and settlement encoding reuse pooled buffers, since they run on every paid
+// request.
 package encoding
 
 import (
@@ -15,11 +18,11 @@ import (
 //
 // Returns an error if JSON marshaling fails.
 func EncodePayment(payment x402.PaymentPayload) (string, error) {
-	paymentJSON, err := json.Marshal(payment)
+	encoded, err := encodeBase64JSON(payment)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal payment: %w", err)
 	}
-	return base64.StdEncoding.EncodeToString(paymentJSON), nil
+	return encoded, nil
 }
 
 // DecodePayment converts a base64-encoded JSON string to PaymentPayload.
@@ -28,13 +31,12 @@ func EncodePayment(payment x402.PaymentPayload) (string, error) {
 func DecodePayment(encoded string) (x402.PaymentPayload, error) {
 	var payment x402.PaymentPayload
 
-	decoded, err := base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		return payment, fmt.Errorf("failed to decode base64: %w", err)
+	decodeErr, unmarshalErr := decodeBase64JSON(encoded, &payment)
+	if decodeErr != nil {
+		return payment, fmt.Errorf("failed to decode base64: %w", decodeErr)
 	}
-
-	if err := json.Unmarshal(decoded, &payment); err != nil {
-		return payment, fmt.Errorf("failed to unmarshal payment: %w", err)
+	if unmarshalErr != nil {
+		return payment, fmt.Errorf("failed to unmarshal payment: %w", unmarshalErr)
 	}
 
 	return payment, nil
@@ -45,11 +47,11 @@ func DecodePayment(encoded string) (x402.PaymentPayload, error) {
 //
 // Returns an error if JSON marshaling fails.
 func EncodeSettlement(settlement x402.SettlementResponse) (string, error) {
-	settlementJSON, err := json.Marshal(settlement)
+	encoded, err := encodeBase64JSON(settlement)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal settlement: %w", err)
 	}
-	return base64.StdEncoding.EncodeToString(settlementJSON), nil
+	return encoded, nil
 }
 
 // DecodeSettlement converts a base64-encoded JSON string to SettlementResponse.
@@ -58,13 +60,12 @@ func EncodeSettlement(settlement x402.SettlementResponse) (string, error) {
 func DecodeSettlement(encoded string) (x402.SettlementResponse, error) {
 	var settlement x402.SettlementResponse
 
-	decoded, err := base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		return settlement, fmt.Errorf("failed to decode base64: %w", err)
+	decodeErr, unmarshalErr := decodeBase64JSON(encoded, &settlement)
+	if decodeErr != nil {
+		return settlement, fmt.Errorf("failed to decode base64: %w", decodeErr)
 	}
-
-	if err := json.Unmarshal(decoded, &settlement); err != nil {
-		return settlement, fmt.Errorf("failed to unmarshal settlement: %w", err)
+	if unmarshalErr != nil {
+		return settlement, fmt.Errorf("failed to unmarshal settlement: %w", unmarshalErr)
 	}
 
 	return settlement, nil
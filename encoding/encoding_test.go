@@ -3,7 +3,10 @@ package encoding
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/mark3labs/x402-go"
@@ -413,3 +416,203 @@ func TestRoundTrip(t *testing.T) {
 		}
 	})
 }
+
+// benchmarkPayment is an EVM payment as sent in a typical X-PAYMENT header.
+var benchmarkPayment = x402.PaymentPayload{
+	X402Version: 1,
+	Scheme:      "exact",
+	Network:     "base",
+	Payload: x402.EVMPayload{
+		Signature: "0x" + strings.Repeat("ab", 65),
+		Authorization: x402.EVMAuthorization{
+			From:        "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+			To:          "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			Value:       "10000",
+			ValidAfter:  "0",
+			ValidBefore: "1999999999",
+			Nonce:       "0x" + strings.Repeat("01", 32),
+		},
+	},
+}
+
+// BenchmarkEncodePayment measures X-PAYMENT header encoding (target: <10µs/op).
+func BenchmarkEncodePayment(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncodePayment(benchmarkPayment); err != nil {
+			b.Fatalf("encode error: %v", err)
+		}
+	}
+}
+
+// BenchmarkDecodePayment measures X-PAYMENT header decoding (target: <20µs/op).
+func BenchmarkDecodePayment(b *testing.B) {
+	encoded, err := EncodePayment(benchmarkPayment)
+	if err != nil {
+		b.Fatalf("encode error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodePayment(encoded); err != nil {
+			b.Fatalf("decode error: %v", err)
+		}
+	}
+}
+
+func TestPooledEncoding_Concurrent(t *testing.T) {
+	// Payloads of different sizes make reused buffers shrink and grow between calls
+	payments := make([]x402.PaymentPayload, 8)
+	for i := range payments {
+		payments[i] = benchmarkPayment
+		payments[i].Payload = map[string]interface{}{"data": strings.Repeat("x", i*500)}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for g := 0; g < 100; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				payment := payments[(g+i)%len(payments)]
+				raw, _ := json.Marshal(payment)
+				want := base64.StdEncoding.EncodeToString(raw)
+
+				encoded, err := EncodePayment(payment)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if encoded != want {
+					errs <- fmt.Errorf("encoding differs from json.Marshal for payload %d", (g+i)%len(payments))
+					return
+				}
+
+				decoded, err := DecodePayment(encoded)
+				if err != nil {
+					errs <- err
+					return
+				}
+				data, _ := decoded.Payload.(map[string]interface{})["data"].(string)
+				if len(data) != ((g+i)%len(payments))*500 {
+					errs <- fmt.Errorf("decoded payload %d has %d bytes", (g+i)%len(payments), len(data))
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestPaymentCBOR(t *testing.T) {
+	payment := x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base",
+		Payload: x402.EVMPayload{
+			Signature: "0x2d6a7588d6acca505cbf0d9a4a227e0c52c6c34008c8e8986a1283259764173608a2ce6496642e377d6da8dbbf5836e9bd15092f9ecab05ded3d6293af148b571c",
+			Authorization: x402.EVMAuthorization{
+				From:        "0x857b06519E91e3A54538791bDbb0E22373e36b66",
+				To:          "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				Value:       "10000",
+				ValidAfter:  "1740672089",
+				ValidBefore: "1740672154",
+				Nonce:       "0xf3746613c2d920b5fdabc0856f2aeb2d4f88ee6037b8cc5d04a71a4462f13480",
+			},
+		},
+		Binding: &x402.PaymentBinding{Method: x402.BindingDPoP, Salt: "00ff"},
+	}
+
+	encoded, err := EncodePaymentCBOR(payment)
+	if err != nil {
+		t.Fatalf("EncodePaymentCBOR: %v", err)
+	}
+	jsonEncoded, _ := EncodePayment(payment)
+	if len(encoded) > len(jsonEncoded)*65/100 {
+		t.Errorf("CBOR header is %d bytes, JSON %d; want it at least 35%% smaller", len(encoded), len(jsonEncoded))
+	}
+
+	decoded, err := DecodePaymentCBOR(encoded)
+	if err != nil {
+		t.Fatalf("DecodePaymentCBOR: %v", err)
+	}
+	fromJSON, _ := DecodePayment(jsonEncoded)
+	if !reflect.DeepEqual(decoded, fromJSON) {
+		t.Errorf("CBOR decoded %+v, JSON %+v", decoded, fromJSON)
+	}
+
+	// Encoding is deterministic
+	again, _ := EncodePaymentCBOR(payment)
+	if again != encoded {
+		t.Error("encoding the same payment twice differs")
+	}
+}
+
+func TestDecodePaymentCBOR(t *testing.T) {
+	cbor := func(b ...byte) string { return base64.StdEncoding.EncodeToString(b) }
+
+	tests := []struct {
+		name    string
+		encoded string
+		want    x402.PaymentPayload
+		wantErr bool
+	}{
+		{
+			// {"x402Version": 1, "scheme": "exact", "simulated": true}
+			name:    "map",
+			encoded: cbor(0xa3, 0x6b, 'x', '4', '0', '2', 'V', 'e', 'r', 's', 'i', 'o', 'n', 0x01, 0x66, 's', 'c', 'h', 'e', 'm', 'e', 0x65, 'e', 'x', 'a', 'c', 't', 0x69, 's', 'i', 'm', 'u', 'l', 'a', 't', 'e', 'd', 0xf5),
+			want:    x402.PaymentPayload{X402Version: 1, Scheme: "exact", Simulated: true},
+		},
+		{
+			// {"payload": [1.5, -2, null]}, 1.5 as a half-precision float
+			name:    "array of numbers",
+			encoded: cbor(0xa1, 0x67, 'p', 'a', 'y', 'l', 'o', 'a', 'd', 0x83, 0xf9, 0x3e, 0x00, 0x21, 0xf6),
+			want:    x402.PaymentPayload{Payload: []any{1.5, float64(-2), nil}},
+		},
+		{name: "invalid base64", encoded: "!!!", wantErr: true},
+		{name: "truncated", encoded: cbor(0xa1, 0x67, 'p', 'a'), wantErr: true},
+		{name: "trailing data", encoded: cbor(0xa0, 0x00), wantErr: true},
+		{
+			// {"reference": 23("0x00ff")}
+			name:    "base16 byte string",
+			encoded: cbor(0xa1, 0x69, 'r', 'e', 'f', 'e', 'r', 'e', 'n', 'c', 'e', 0xd7, 0x42, 0x00, 0xff),
+			want:    x402.PaymentPayload{Reference: "0x00ff"},
+		},
+		{name: "untagged byte string", encoded: cbor(0x41, 0x00), wantErr: true},
+		{name: "other tag", encoded: cbor(0xc1, 0x00), wantErr: true},
+		{name: "indefinite length", encoded: cbor(0xbf, 0xff), wantErr: true},
+		{
+			// {1: 1, "scheme": "exact"}: numbered and text field names
+			name:    "numbered keys",
+			encoded: cbor(0xa2, 0x01, 0x01, 0x66, 's', 'c', 'h', 'e', 'm', 'e', 0x65, 'e', 'x', 'a', 'c', 't'),
+			want:    x402.PaymentPayload{X402Version: 1, Scheme: "exact"},
+		},
+		{name: "unknown key number", encoded: cbor(0xa1, 0x18, 0x64, 0x01), wantErr: true},
+		{name: "huge array", encoded: cbor(0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff), wantErr: true},
+		{name: "nested too deeply", encoded: cbor(append(bytes33(0x81), 0xf6)...), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePaymentCBOR(tt.encoded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodePaymentCBOR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodePaymentCBOR() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bytes33 returns 33 copies of b, one more than the nesting CBOR decoding allows.
+func bytes33(b byte) []byte {
+	return []byte(strings.Repeat(string([]byte{b}), maxCBORDepth+1))
+}
@@ -0,0 +1,78 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBuffer caps the size of buffers returned to the pools, so one oversized
+// payload does not pin its memory for the life of the process.
+const maxPooledBuffer = 64 << 10
+
+// encodeState holds the reusable buffers for one base64+JSON encoding.
+type encodeState struct {
+	json    bytes.Buffer
+	encoder *json.Encoder
+	base64  []byte
+}
+
+var encodePool = sync.Pool{
+	New: func() any {
+		s := &encodeState{}
+		s.encoder = json.NewEncoder(&s.json)
+		return s
+	},
+}
+
+// decodeState holds the reusable buffers for one base64+JSON decoding.
+type decodeState struct {
+	src []byte
+	dst []byte
+}
+
+var decodePool = sync.Pool{
+	New: func() any { return &decodeState{} },
+}
+
+// encodeBase64JSON marshals v to JSON and returns it base64-encoded, using pooled
+// buffers. The output is identical to base64 of json.Marshal(v).
+func encodeBase64JSON(v any) (string, error) {
+	s := encodePool.Get().(*encodeState)
+	defer func() {
+		if s.json.Cap() <= maxPooledBuffer && cap(s.base64) <= maxPooledBuffer {
+			encodePool.Put(s)
+		}
+	}()
+
+	s.json.Reset()
+	if err := s.encoder.Encode(v); err != nil {
+		return "", err
+	}
+	// Encode terminates the value with a newline that Marshal does not emit
+	data := bytes.TrimSuffix(s.json.Bytes(), []byte{'\n'})
+
+	s.base64 = base64.StdEncoding.AppendEncode(s.base64[:0], data)
+	return string(s.base64), nil
+}
+
+// decodeBase64JSON decodes base64-encoded JSON into v, using pooled buffers.
+// decodeErr reports invalid base64 and unmarshalErr invalid JSON.
+func decodeBase64JSON(encoded string, v any) (decodeErr, unmarshalErr error) {
+	s := decodePool.Get().(*decodeState)
+	defer func() {
+		if cap(s.src) <= maxPooledBuffer && cap(s.dst) <= maxPooledBuffer {
+			decodePool.Put(s)
+		}
+	}()
+
+	s.src = append(s.src[:0], encoded...)
+	var err error
+	s.dst, err = base64.StdEncoding.AppendDecode(s.dst[:0], s.src)
+	if err != nil {
+		return err, nil
+	}
+	// Unmarshal copies everything it keeps, so the buffer can be reused
+	return nil, json.Unmarshal(s.dst, v)
+}
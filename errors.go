@@ -10,6 +10,9 @@ var (
 	// ErrAmountExceeded indicates the payment amount exceeds the per-call limit.
 	ErrAmountExceeded = errors.New("x402: payment amount exceeds per-call limit")
 
+	// ErrBudgetExceeded indicates a payment would exceed the configured spending limit.
+	ErrBudgetExceeded = errors.New("x402: spending limit exceeded")
+
 	// ErrInvalidRequirements indicates the payment requirements from the server are invalid.
 	ErrInvalidRequirements = errors.New("x402: invalid payment requirements")
 
@@ -22,12 +25,18 @@ var (
 	// ErrInvalidAmount indicates an invalid amount string.
 	ErrInvalidAmount = errors.New("x402: invalid amount")
 
+	// ErrAmountTooSmall indicates a non-zero price rounds to zero atomic units.
+	ErrAmountTooSmall = errors.New("x402: amount rounds to zero")
+
 	// ErrInvalidKey indicates an invalid private key.
 	ErrInvalidKey = errors.New("x402: invalid private key")
 
 	// ErrInvalidNetwork indicates an unsupported network.
 	ErrInvalidNetwork = errors.New("x402: invalid or unsupported network")
 
+	// ErrInvalidAddress indicates a malformed or mistyped payment address.
+	ErrInvalidAddress = errors.New("x402: invalid address")
+
 	// ErrInvalidToken indicates invalid token configuration.
 	ErrInvalidToken = errors.New("x402: invalid token configuration")
 
@@ -57,6 +66,25 @@ var (
 
 	// ErrSettlementFailed indicates payment settlement failed.
 	ErrSettlementFailed = errors.New("x402: payment settlement failed")
+
+	// ErrInvalidReceipt indicates a settlement receipt is unsigned or its signature is invalid.
+	ErrInvalidReceipt = errors.New("x402: invalid payment receipt signature")
+
+	// ErrInvalidRequirementsSignature indicates the payment requirements of a 402 response
+	// are unsigned or their signature is invalid.
+	ErrInvalidRequirementsSignature = errors.New("x402: invalid payment requirements signature")
+
+	// ErrPayToChanged indicates a server asks to be paid at another address than the
+	// one it was paid at before.
+	ErrPayToChanged = errors.New("x402: payment recipient changed")
+
+	// ErrValidityWindowTooShort indicates every payment option expires too soon after
+	// signing to be verified and settled.
+	ErrValidityWindowTooShort = errors.New("x402: payment validity window too short")
+
+	// ErrBindingUnsupported indicates a signer cannot bind the payment it signs, e.g.
+	// because its scheme has no nonce of the payer's choosing.
+	ErrBindingUnsupported = errors.New("x402: payment binding not supported")
 )
 
 // PaymentError represents a structured error with additional context.
@@ -95,6 +123,56 @@ const (
 
 	// ErrCodeUnsupportedScheme indicates unsupported payment scheme or network.
 	ErrCodeUnsupportedScheme ErrorCode = "UNSUPPORTED_SCHEME"
+
+	// ErrCodeInvalidReceipt indicates the server's settlement receipt failed verification.
+	ErrCodeInvalidReceipt ErrorCode = "INVALID_RECEIPT"
+
+	// ErrCodeInvalidRequirementsSignature indicates the server's payment requirements
+	// failed signature verification.
+	ErrCodeInvalidRequirementsSignature ErrorCode = "INVALID_REQUIREMENTS_SIGNATURE"
+
+	// ErrCodePayToChanged indicates the server's payment address differs from the
+	// pinned one.
+	ErrCodePayToChanged ErrorCode = "PAYTO_CHANGED"
+
+	// ErrCodeRequestTimeout indicates the initial request timed out before the server
+	// asked for payment.
+	ErrCodeRequestTimeout ErrorCode = "REQUEST_TIMEOUT"
+
+	// ErrCodeSigningTimeout indicates the payment deadline passed while signing.
+	ErrCodeSigningTimeout ErrorCode = "SIGNING_TIMEOUT"
+
+	// ErrCodePaymentTimeout indicates the paid request timed out, including the server's
+	// verification and settlement.
+	ErrCodePaymentTimeout ErrorCode = "PAYMENT_TIMEOUT"
+
+	// ErrCodeVerifyTimeout indicates facilitator verification timed out.
+	ErrCodeVerifyTimeout ErrorCode = "VERIFY_TIMEOUT"
+
+	// ErrCodeSettleTimeout indicates facilitator settlement timed out.
+	ErrCodeSettleTimeout ErrorCode = "SETTLE_TIMEOUT"
+
+	// ErrCodeMalformedHeader indicates the X-PAYMENT header could not be parsed.
+	ErrCodeMalformedHeader ErrorCode = "MALFORMED_HEADER"
+
+	// ErrCodeFacilitatorUnavailable indicates the facilitator could not be reached.
+	ErrCodeFacilitatorUnavailable ErrorCode = "FACILITATOR_UNAVAILABLE"
+
+	// ErrCodeVerificationFailed indicates payment verification failed.
+	ErrCodeVerificationFailed ErrorCode = "VERIFICATION_FAILED"
+
+	// ErrCodeSettlementFailed indicates payment settlement failed.
+	ErrCodeSettlementFailed ErrorCode = "SETTLEMENT_FAILED"
+
+	// ErrCodePayerDenied indicates the server refuses payments from the payer.
+	ErrCodePayerDenied ErrorCode = "PAYER_DENIED"
+
+	// ErrCodeAddressBlocked indicates the payer or payTo address failed sanctions screening.
+	ErrCodeAddressBlocked ErrorCode = "ADDRESS_BLOCKED"
+
+	// ErrCodeRequestInProgress indicates a request with the same Idempotency-Key is
+	// still being processed.
+	ErrCodeRequestInProgress ErrorCode = "REQUEST_IN_PROGRESS"
 )
 
 // Error implements the error interface.
@@ -13,6 +13,7 @@ func TestErrorDefinitions(t *testing.T) {
 	}{
 		{"NoValidSigner", ErrNoValidSigner, "x402: no signer can satisfy payment requirements"},
 		{"AmountExceeded", ErrAmountExceeded, "x402: payment amount exceeds per-call limit"},
+		{"BudgetExceeded", ErrBudgetExceeded, "x402: spending limit exceeded"},
 		{"InvalidRequirements", ErrInvalidRequirements, "x402: invalid payment requirements"},
 		{"SigningFailed", ErrSigningFailed, "x402: payment signing failed"},
 		{"NetworkError", ErrNetworkError, "x402: network error during payment"},
@@ -29,6 +30,10 @@ func TestErrorDefinitions(t *testing.T) {
 		{"UnsupportedVersion", ErrUnsupportedVersion, "x402: unsupported protocol version"},
 		{"UnsupportedScheme", ErrUnsupportedScheme, "x402: unsupported payment scheme"},
 		{"SettlementFailed", ErrSettlementFailed, "x402: payment settlement failed"},
+		{"InvalidReceipt", ErrInvalidReceipt, "x402: invalid payment receipt signature"},
+		{"InvalidRequirementsSignature", ErrInvalidRequirementsSignature, "x402: invalid payment requirements signature"},
+		{"PayToChanged", ErrPayToChanged, "x402: payment recipient changed"},
+		{"ValidityWindowTooShort", ErrValidityWindowTooShort, "x402: payment validity window too short"},
 	}
 
 	for _, tt := range tests {
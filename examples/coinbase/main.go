@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -233,11 +232,21 @@ func runClient(args []string) {
 	accountName := fs.String("account-name", "x402-payment-wallet", "CDP account name (unique identifier for your wallet)")
 	url := fs.String("url", "", "URL to fetch (must be paywalled with x402)")
 	tokenAddr := fs.String("token", "", "Token address (auto-detected based on network if not specified)")
-	maxAmount := fs.String("max-amount", "", "Maximum amount per call (optional)")
+	maxAmount := fs.String("max-amount", "", "Maximum USDC amount per call, e.g. 0.01 (optional)")
 	verbose := fs.Bool("verbose", false, "Enable verbose debug output")
 
 	_ = fs.Parse(args)
 
+	// Convert the human-readable USDC limit to atomic units expected by signers
+	if *maxAmount != "" {
+		limit, err := x402.ParseAmount(*maxAmount, 6)
+		if err != nil {
+			fmt.Printf("Error: invalid --max-amount %q: %v\n", *maxAmount, err)
+			os.Exit(1)
+		}
+		*maxAmount = limit.AtomicString()
+	}
+
 	// Get credentials from flags or environment
 	if *apiKeyName == "" {
 		*apiKeyName = os.Getenv("CDP_API_KEY_NAME")
@@ -332,6 +341,11 @@ func runClient(args []string) {
 		log.Fatalf("Failed to create client: %v", err)
 	}
 
+	// In verbose mode, print the raw x402 messages of each payment
+	if *verbose {
+		client.Transport.(*x402http.X402Transport).Observer = x402http.DebugObserver(os.Stdout)
+	}
+
 	fmt.Printf("\nFetching: %s\n", *url)
 
 	// Make the request
@@ -341,24 +355,6 @@ func runClient(args []string) {
 	}
 	defer resp.Body.Close()
 
-	// Verbose output: show payment header if sent
-	if *verbose && resp.Request.Header.Get("X-PAYMENT") != "" {
-		fmt.Println("\n=== DEBUG: Payment Header ===")
-		paymentHeader := resp.Request.Header.Get("X-PAYMENT")
-		fmt.Printf("X-PAYMENT (base64): %s\n", paymentHeader)
-		fmt.Printf("Length: %d bytes\n", len(paymentHeader))
-
-		// Decode and show the actual payload
-		if decoded, err := base64.StdEncoding.DecodeString(paymentHeader); err == nil {
-			var payload map[string]interface{}
-			if err := json.Unmarshal(decoded, &payload); err == nil {
-				prettyJSON, _ := json.MarshalIndent(payload, "", "  ")
-				fmt.Printf("\nDecoded Payload:\n%s\n", string(prettyJSON))
-			}
-		}
-		fmt.Println("=============================")
-	}
-
 	// Check for settlement info
 	if settlement := x402http.GetSettlement(resp); settlement != nil {
 		if settlement.Success {
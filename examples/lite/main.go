@@ -0,0 +1,85 @@
+// Command lite is a minimal x402 client for binary-size-sensitive devices such as
+// routers and sensors. It pays with signers/evmlite and should be built with the
+// x402lite tag, so neither go-ethereum nor solana-go is linked in:
+//
+//	go build -tags x402lite -ldflags="-s -w" -o lite ./examples/lite
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/mark3labs/x402-go"
+	x402http "github.com/mark3labs/x402-go/http"
+	"github.com/mark3labs/x402-go/signers/evmlite"
+)
+
+func main() {
+	network := flag.String("network", "base-sepolia", "Network to pay on (base, base-sepolia)")
+	key := flag.String("key", os.Getenv("X402_PRIVATE_KEY"), "Hex private key (default $X402_PRIVATE_KEY)")
+	url := flag.String("url", "", "URL to fetch (must be paywalled with x402)")
+	maxAmount := flag.String("max-amount", "", "Maximum USDC amount per call, e.g. 0.01 (optional)")
+	compact := flag.Bool("compact", true, "Send CBOR-encoded payment headers to servers that accept them")
+	flag.Parse()
+
+	if *key == "" || *url == "" {
+		fmt.Println("Error: --key and --url are required")
+		fmt.Println()
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	var usdc x402.ChainConfig
+	switch *network {
+	case "base":
+		usdc = x402.BaseMainnet
+	case "base-sepolia":
+		usdc = x402.BaseSepolia
+	default:
+		log.Fatalf("Unsupported network %q", *network)
+	}
+
+	opts := []evmlite.SignerOption{
+		evmlite.WithPrivateKey(*key),
+		evmlite.WithNetwork(*network),
+		evmlite.WithToken(usdc.USDCAddress, "USDC", int(usdc.Decimals)),
+	}
+	if *maxAmount != "" {
+		limit, err := x402.ParseAmount(*maxAmount, int(usdc.Decimals))
+		if err != nil {
+			log.Fatalf("Invalid --max-amount %q: %v", *maxAmount, err)
+		}
+		opts = append(opts, evmlite.WithMaxAmountPerCall(limit.AtomicString()))
+	}
+	signer, err := evmlite.NewSigner(opts...)
+	if err != nil {
+		log.Fatalf("Failed to create signer: %v", err)
+	}
+
+	clientOpts := []x402http.ClientOption{x402http.WithSigner(signer)}
+	if *compact {
+		clientOpts = append(clientOpts, x402http.WithCompactPayments())
+	}
+	client, err := x402http.NewClient(clientOpts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(*url)
+	if err != nil {
+		log.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if settlement := x402http.GetSettlement(resp); settlement != nil && settlement.Success {
+		fmt.Fprintf(os.Stderr, "Paid from %s in %s\n", signer.Address(), settlement.Transaction)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("Unexpected status %s", resp.Status)
+	}
+	_, _ = io.Copy(os.Stdout, resp.Body)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -16,6 +15,8 @@ import (
 	mcpclient "github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	x402http "github.com/mark3labs/x402-go/http"
 	"github.com/mark3labs/x402-go/mcp/client"
 	"github.com/mark3labs/x402-go/mcp/server"
 	"github.com/mark3labs/x402-go/signers/evm"
@@ -126,13 +127,15 @@ func runServer(args []string) {
 	}
 
 	// Create x402 MCP server
-	config := &server.Config{
-		FacilitatorURL: *facilitatorURL,
-		VerifyOnly:     *verifyOnly,
-		Verbose:        *verbose,
+	opts := []server.Option{server.WithFacilitatorURL(*facilitatorURL)}
+	if *verifyOnly {
+		opts = append(opts, server.WithVerifyOnly())
+	}
+	if *verbose {
+		opts = append(opts, server.WithVerbose())
 	}
 
-	srv := server.NewX402Server("x402-mcp-example", "1.0.0", config)
+	srv := server.NewX402Server("x402-mcp-example", "1.0.0", opts...)
 
 	// Enrich payment requirements with facilitator-specific data (like feePayer for Solana)
 	enrichedRequirement, err := enrichRequirement(requirement, *facilitatorURL)
@@ -228,11 +231,21 @@ func runClient(args []string) {
 	keyFile := fs.String("key-file", "", "Solana keygen JSON file (alternative to --key for Solana)")
 	serverURL := fs.String("server", "http://localhost:8080", "MCP server URL")
 	tokenAddr := fs.String("token", "", "Token address (auto-detected based on network if not specified)")
-	maxAmount := fs.String("max-amount", "", "Maximum amount per call (optional)")
+	maxAmount := fs.String("max-amount", "", "Maximum USDC amount per call, e.g. 0.01 (optional)")
 	verbose := fs.Bool("verbose", false, "Enable verbose debug output")
 
 	_ = fs.Parse(args)
 
+	// Convert the human-readable USDC limit to atomic units expected by signers
+	if *maxAmount != "" {
+		limit, err := x402.ParseAmount(*maxAmount, 6)
+		if err != nil {
+			fmt.Printf("Error: invalid --max-amount %q: %v\n", *maxAmount, err)
+			os.Exit(1)
+		}
+		*maxAmount = limit.AtomicString()
+	}
+
 	// Validate inputs
 	if *key == "" && *keyFile == "" {
 		fmt.Println("Error: --key or --key-file is required")
@@ -408,6 +421,9 @@ func runClient(args []string) {
 		log.Fatalf("Search call failed: %v", err)
 	}
 	log.Printf("Search result: %v", searchResult.Content[0])
+	if settlement, ok := client.SettlementFromResult(searchResult); ok {
+		log.Printf("Paid %s (asset %s) on %s, transaction %s", settlement.Amount, settlement.Asset, settlement.Network, settlement.Transaction)
+	}
 
 	log.Println("\n=== Example completed successfully ===")
 
@@ -437,48 +453,14 @@ func paymentLogger(event x402.PaymentEvent) {
 
 // enrichRequirement enriches a payment requirement with facilitator-specific data (like feePayer for Solana)
 func enrichRequirement(req x402.PaymentRequirement, facilitatorURL string) (x402.PaymentRequirement, error) {
-	// Create facilitator client
-	client := &http.Client{Timeout: 10 * time.Second}
+	fc := &x402http.FacilitatorClient{
+		BaseURL: facilitatorURL,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
 
-	// Fetch supported payment types from facilitator
-	resp, err := client.Get(facilitatorURL + "/supported")
+	kinds, err := fc.SupportedKinds(context.Background())
 	if err != nil {
 		return req, fmt.Errorf("failed to fetch supported types: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return req, fmt.Errorf("facilitator returned status %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var supported struct {
-		Kinds []struct {
-			Network string                 `json:"network"`
-			Scheme  string                 `json:"scheme"`
-			Extra   map[string]interface{} `json:"extra"`
-		} `json:"kinds"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&supported); err != nil {
-		return req, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Find matching network+scheme
-	for _, kind := range supported.Kinds {
-		if kind.Network == req.Network && kind.Scheme == req.Scheme {
-			// Merge extra fields
-			if len(kind.Extra) > 0 {
-				if req.Extra == nil {
-					req.Extra = make(map[string]interface{})
-				}
-				for k, v := range kind.Extra {
-					req.Extra[k] = v
-				}
-			}
-			break
-		}
-	}
-
-	return req, nil
+	return facilitator.EnrichFrom(req, kinds), nil
 }
@@ -20,7 +20,7 @@ func main() {
 	requirement, err := x402.NewUSDCPaymentRequirement(x402.USDCRequirementConfig{
 		Chain:             x402.BaseSepolia,                             // TESTNET - change to x402.BaseMainnet for production
 		Amount:            "0.01",                                       // 0.01 USDC
-		RecipientAddress:  "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0", // REPLACE WITH YOUR WALLET ADDRESS
+		RecipientAddress:  "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0", // REPLACE WITH YOUR WALLET ADDRESS
 		Description:       "Access to premium content",
 		MaxTimeoutSeconds: 300,
 	})
@@ -0,0 +1,446 @@
+// Package evm provides a facilitator.Interface settling payments of the permit scheme on
+// EVM networks: EIP-2612 permits signed by an evm.Signer configured with WithPermit.
+//
+// The facilitator's Relayer broadcasts its transactions, and the relayer's account is the
+// spender of the permits. Settling a payment submits the permit and then transfers the
+// amount from the payer to the requirement's payTo with transferFrom. Supported
+// advertises the relayer's address as the spender extra, so servers using this
+// facilitator behind an HTTP facilitator service get it filled in automatically.
+//
+// It also settles exact payments whose requirements ask for an EIP-3009
+// receiveWithAuthorization (see x402.ExtraReceiveWithAuthorization). Only the payee can
+// submit those, so the relayer's account must be the requirement's payTo: the payee
+// settles its own payments.
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	evmsigner "github.com/mark3labs/x402-go/signers/evm"
+)
+
+// ERC-20, EIP-2612 and EIP-3009 function selectors.
+var (
+	balanceOfSelector                = []byte{0x70, 0xa0, 0x82, 0x31} // balanceOf(address)
+	noncesSelector                   = []byte{0x7e, 0xce, 0xbe, 0x00} // nonces(address)
+	permitSelector                   = []byte{0xd5, 0x05, 0xac, 0xcf} // permit(address,address,uint256,uint256,uint8,bytes32,bytes32)
+	transferFromSelector             = []byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+	authorizationStateSelector       = []byte{0xe9, 0x4a, 0x01, 0x02} // authorizationState(address,bytes32)
+	receiveWithAuthorizationSelector = []byte{0xef, 0x55, 0xbe, 0xc6} // receiveWithAuthorization(address,address,uint256,uint256,uint256,bytes32,uint8,bytes32,bytes32)
+)
+
+// Invalid reasons reported by Verify and Settle.
+const (
+	ReasonUnsupportedScheme = "unsupported_scheme"
+	ReasonInvalidNetwork    = "invalid_network"
+	ReasonInvalidPayload    = "invalid_permit_payload"
+	ReasonInvalidSpender    = "invalid_permit_spender"
+	ReasonInvalidAmount     = "invalid_permit_value"
+	ReasonExpired           = "permit_expired"
+	ReasonInvalidSignature  = "invalid_permit_signature"
+	ReasonInvalidNonce      = "invalid_permit_nonce"
+	ReasonInsufficientFunds = "insufficient_funds"
+
+	// Reasons specific to receiveWithAuthorization payments
+	ReasonInvalidAuthorization          = "invalid_exact_evm_payload"
+	ReasonInvalidAuthorizationSignature = "invalid_exact_evm_payload_signature"
+	ReasonInvalidAuthorizationValue     = "invalid_exact_evm_payload_authorization_value"
+	ReasonAuthorizationNotYetValid      = "invalid_exact_evm_payload_authorization_valid_after"
+	ReasonAuthorizationExpired          = "invalid_exact_evm_payload_authorization_valid_before"
+	ReasonAuthorizationUsed             = "invalid_exact_evm_payload_authorization_used"
+	ReasonRecipientMismatch             = "invalid_exact_evm_payload_recipient_mismatch"
+)
+
+// Facilitator verifies and settles permit and receiveWithAuthorization payments on one
+// EVM network. It is safe for concurrent use.
+type Facilitator struct {
+	client     chain
+	relayer    Relayer
+	privateKey *ecdsa.PrivateKey
+	network    string
+	rpcURL     string
+
+	// chainIDMu guards chainID, read from the RPC endpoint on first use.
+	chainIDMu sync.Mutex
+	chainID   *big.Int
+}
+
+// Option configures a Facilitator.
+type Option func(*Facilitator) error
+
+// NewFacilitator creates a Facilitator. A network and RPC URL are required, and either
+// a Relayer or a private key, which makes the Facilitator relay its transactions itself
+// with an RPCRelayer.
+func NewFacilitator(opts ...Option) (*Facilitator, error) {
+	f := &Facilitator{}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.relayer == nil && f.privateKey == nil {
+		return nil, x402.ErrInvalidKey
+	}
+	if f.network == "" {
+		return nil, x402.ErrInvalidNetwork
+	}
+	if f.rpcURL == "" {
+		return nil, errors.New("evm: RPC URL is required")
+	}
+
+	client, err := ethclient.Dial(f.rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("evm: failed to connect to RPC: %w", err)
+	}
+	f.client = client
+	if f.relayer == nil {
+		f.relayer = newRPCRelayer(client, f.privateKey)
+	}
+	return f, nil
+}
+
+// WithPrivateKey sets the private key of the account relaying the transactions, from a
+// hex string. Its address is the spender of the permits. WithRelayer takes precedence.
+func WithPrivateKey(hexKey string) Option {
+	return func(f *Facilitator) error {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+		if err != nil {
+			return x402.ErrInvalidKey
+		}
+		f.privateKey = privateKey
+		return nil
+	}
+}
+
+// WithNetwork sets the x402 network identifier (e.g., "base").
+func WithNetwork(network string) Option {
+	return func(f *Facilitator) error {
+		if networkType, err := x402.ValidateNetwork(network); err != nil || networkType != x402.NetworkTypeEVM {
+			return x402.ErrInvalidNetwork
+		}
+		f.network = network
+		return nil
+	}
+}
+
+// WithRPCURL sets the JSON-RPC endpoint the chain is read from (and transactions are
+// relayed through, without WithRelayer).
+func WithRPCURL(url string) Option {
+	return func(f *Facilitator) error {
+		f.rpcURL = url
+		return nil
+	}
+}
+
+// WithRelayer sets the Relayer broadcasting the settlement transactions. Its address is
+// the spender of the permits.
+func WithRelayer(relayer Relayer) Option {
+	return func(f *Facilitator) error {
+		if relayer == nil {
+			return errors.New("evm: relayer cannot be nil")
+		}
+		f.relayer = relayer
+		return nil
+	}
+}
+
+// Address returns the relayer's address, the spender of the permits.
+func (f *Facilitator) Address() string {
+	return f.relayer.Address().Hex()
+}
+
+// Supported implements facilitator.Interface. It advertises the permit scheme on the
+// facilitator's network with the relayer's address as spender, and the exact scheme with
+// receiveWithAuthorization.
+func (f *Facilitator) Supported(ctx context.Context) (*facilitator.SupportedResponse, error) {
+	return &facilitator.SupportedResponse{
+		Kinds: []facilitator.SupportedKind{
+			{
+				X402Version: 1,
+				Scheme:      x402.SchemePermit,
+				Network:     f.network,
+				Extra:       map[string]interface{}{x402.ExtraSpender: f.Address()},
+			},
+			{
+				X402Version: 1,
+				Scheme:      x402.SchemeExact,
+				Network:     f.network,
+				Extra:       map[string]interface{}{x402.ExtraReceiveWithAuthorization: true},
+			},
+		},
+	}, nil
+}
+
+// Verify implements facilitator.Interface. It checks the permit's signature, spender,
+// value and deadline, and that its nonce is current and the payer holds the amount. For
+// receiveWithAuthorization payments, it checks the authorization's signature, recipient,
+// value and validity window, and that it is unused and the payer holds the amount.
+func (f *Facilitator) Verify(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement) (*facilitator.VerifyResponse, error) {
+	var owner, reason string
+	var err error
+	if payment.Scheme == x402.SchemeExact {
+		_, owner, reason, err = f.verifyReceive(ctx, payment, requirement)
+	} else {
+		_, owner, reason, err = f.verify(ctx, payment, requirement)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &facilitator.VerifyResponse{
+		IsValid:        reason == "",
+		InvalidReason:  reason,
+		Payer:          owner,
+		PaymentPayload: payment,
+	}, nil
+}
+
+// Settle implements facilitator.Interface. It verifies the payment, submits the permit
+// and transfers the required amount to the requirement's payTo, waiting for both
+// transactions to be mined or ctx to end. A receiveWithAuthorization payment is settled
+// with a single receiveWithAuthorization transaction.
+func (f *Facilitator) Settle(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement) (*x402.SettlementResponse, error) {
+	if payment.Scheme == x402.SchemeExact {
+		return f.settleReceive(ctx, payment, requirement)
+	}
+
+	payload, owner, reason, err := f.verify(ctx, payment, requirement)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return &x402.SettlementResponse{Success: false, ErrorReason: reason, Network: f.network, Payer: owner}, nil
+	}
+
+	token := common.HexToAddress(requirement.Asset)
+	ownerAddr := common.HexToAddress(owner)
+	amount, _ := new(big.Int).SetString(requirement.MaxAmountRequired, 10)
+	fee := new(big.Int)
+
+	// Always submit the permit, even if an allowance is left, so its nonce is consumed
+	// and the payment cannot be presented again
+	relayed, err := f.relayer.Relay(ctx, token, permitCall(payload))
+	if err != nil {
+		return nil, fmt.Errorf("evm: permit failed: %w", err)
+	}
+	fee = addFee(fee, relayed)
+
+	data := append(append([]byte{}, transferFromSelector...), common.LeftPadBytes(ownerAddr.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(requirement.PayTo).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+	relayed, err = f.relayer.Relay(ctx, token, data)
+	if err != nil {
+		return nil, fmt.Errorf("evm: transferFrom failed: %w", err)
+	}
+	fee = addFee(fee, relayed)
+
+	settlement := &x402.SettlementResponse{
+		Success:     true,
+		Transaction: relayed.Hash.Hex(),
+		Network:     f.network,
+		Payer:       owner,
+		BlockNumber: relayed.BlockNumber,
+		SettledAt:   time.Now().Unix(),
+	}
+	if fee != nil {
+		settlement.FeePaid = fee.String()
+	}
+	return settlement, nil
+}
+
+// verify checks payment against requirement. It returns the decoded permit, the payer
+// and, if the payment is invalid, the reason. Errors are reserved for RPC failures.
+func (f *Facilitator) verify(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement) (*x402.EVMPermitPayload, string, string, error) {
+	if payment.Scheme != x402.SchemePermit || requirement.Scheme != x402.SchemePermit {
+		return nil, "", ReasonUnsupportedScheme, nil
+	}
+	if payment.Network != f.network || requirement.Network != f.network {
+		return nil, "", ReasonInvalidNetwork, nil
+	}
+
+	payload, err := decodePermit(payment.Payload)
+	if err != nil {
+		return nil, "", ReasonInvalidPayload, nil
+	}
+	permit := payload.Permit
+	owner := permit.Owner
+
+	if !common.IsHexAddress(permit.Spender) || common.HexToAddress(permit.Spender) != f.relayer.Address() {
+		return nil, owner, ReasonInvalidSpender, nil
+	}
+	value, ok := new(big.Int).SetString(permit.Value, 10)
+	required, okRequired := new(big.Int).SetString(requirement.MaxAmountRequired, 10)
+	if !ok || !okRequired || value.Cmp(required) < 0 {
+		return nil, owner, ReasonInvalidAmount, nil
+	}
+	nonce, ok := new(big.Int).SetString(permit.Nonce, 10)
+	if !ok {
+		return nil, owner, ReasonInvalidPayload, nil
+	}
+	deadline, ok := new(big.Int).SetString(permit.Deadline, 10)
+	if !ok {
+		return nil, owner, ReasonInvalidPayload, nil
+	}
+	if deadline.Cmp(big.NewInt(time.Now().Unix())) <= 0 {
+		return nil, owner, ReasonExpired, nil
+	}
+
+	chainID, err := f.getChainID(ctx)
+	if err != nil {
+		return nil, owner, "", err
+	}
+	name, _ := requirement.Extra[x402.ExtraName].(string)
+	version, _ := requirement.Extra[x402.ExtraVersion].(string)
+	token := common.HexToAddress(requirement.Asset)
+	ownerAddr := common.HexToAddress(owner)
+	digest, err := evmsigner.HashPermit(token, chainID, &evmsigner.EIP2612Permit{
+		Owner:    ownerAddr,
+		Spender:  f.relayer.Address(),
+		Value:    value,
+		Nonce:    nonce,
+		Deadline: deadline,
+	}, name, version)
+	if err != nil {
+		return nil, owner, ReasonInvalidPayload, nil
+	}
+	if signer, err := recoverSigner(digest, payload.Signature); err != nil || signer != ownerAddr {
+		return nil, owner, ReasonInvalidSignature, nil
+	}
+
+	current, err := f.callUint(ctx, token, noncesSelector, ownerAddr)
+	if err != nil {
+		return nil, owner, "", err
+	}
+	if current.Cmp(nonce) != 0 {
+		return nil, owner, ReasonInvalidNonce, nil
+	}
+	balance, err := f.callUint(ctx, token, balanceOfSelector, ownerAddr)
+	if err != nil {
+		return nil, owner, "", err
+	}
+	if balance.Cmp(required) < 0 {
+		return nil, owner, ReasonInsufficientFunds, nil
+	}
+
+	return payload, owner, "", nil
+}
+
+// getChainID returns the chain ID of the RPC endpoint, read once it succeeds.
+func (f *Facilitator) getChainID(ctx context.Context) (*big.Int, error) {
+	f.chainIDMu.Lock()
+	defer f.chainIDMu.Unlock()
+	if f.chainID == nil {
+		chainID, err := f.client.ChainID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("evm: failed to get chain ID: %w", err)
+		}
+		f.chainID = chainID
+	}
+	return f.chainID, nil
+}
+
+// callUint calls a token function taking addresses and returning a uint256.
+func (f *Facilitator) callUint(ctx context.Context, token common.Address, selector []byte, args ...common.Address) (*big.Int, error) {
+	data := append([]byte{}, selector...)
+	for _, arg := range args {
+		data = append(data, common.LeftPadBytes(arg.Bytes(), 32)...)
+	}
+	return f.call(ctx, token, data)
+}
+
+// call calls a token function returning a uint256 (or a bool) with the encoded data.
+func (f *Facilitator) call(ctx context.Context, token common.Address, data []byte) (*big.Int, error) {
+	result, err := f.client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("evm: call to %s failed: %w", token.Hex(), err)
+	}
+	if len(result) < 32 {
+		return nil, fmt.Errorf("evm: call to %s returned %d bytes", token.Hex(), len(result))
+	}
+	return new(big.Int).SetBytes(result[:32]), nil
+}
+
+// decodePermit decodes a permit payload, which is a map after JSON decoding.
+func decodePermit(payload interface{}) (*x402.EVMPermitPayload, error) {
+	switch p := payload.(type) {
+	case x402.EVMPermitPayload:
+		return &p, nil
+	case *x402.EVMPermitPayload:
+		return p, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var permit x402.EVMPermitPayload
+	if err := json.Unmarshal(data, &permit); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(permit.Permit.Owner) {
+		return nil, errors.New("invalid permit owner")
+	}
+	return &permit, nil
+}
+
+// recoverSigner returns the address that produced the hex signature of digest.
+func recoverSigner(digest []byte, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("invalid signature encoding")
+	}
+	// Signatures carry v as 27 or 28; recovery expects 0 or 1
+	sig = append([]byte{}, sig...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// permitCall encodes the permit call submitting payload.
+func permitCall(payload *x402.EVMPermitPayload) []byte {
+	permit := payload.Permit
+	sig, _ := hexutil.Decode(payload.Signature)
+	value, _ := new(big.Int).SetString(permit.Value, 10)
+	deadline, _ := new(big.Int).SetString(permit.Deadline, 10)
+
+	data := append([]byte{}, permitSelector...)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(permit.Owner).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(permit.Spender).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(value.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(deadline.Bytes(), 32)...)
+	v := sig[64]
+	if v < 27 {
+		v += 27
+	}
+	data = append(data, common.LeftPadBytes([]byte{v}, 32)...)
+	data = append(data, sig[:32]...)
+	data = append(data, sig[32:64]...)
+	return data
+}
+
+// addFee adds the fee of relayed to fee. The total is nil once a fee is unknown.
+func addFee(fee *big.Int, relayed *RelayedTx) *big.Int {
+	if fee == nil || relayed.Fee == nil {
+		return nil
+	}
+	return fee.Add(fee, relayed.Fee)
+}
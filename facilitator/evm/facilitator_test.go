@@ -0,0 +1,334 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mark3labs/x402-go"
+	evmsigner "github.com/mark3labs/x402-go/signers/evm"
+)
+
+// Test private keys (DO NOT use in production)
+const (
+	payerKeyHex    = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	operatorKeyHex = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+)
+
+const testToken = "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb"
+
+// fakeChain is an EVM chain holding one token, recording sent transactions.
+type fakeChain struct {
+	nonce   *big.Int
+	balance *big.Int
+	used    bool // whether EIP-3009 authorizations are used
+	sent    []*types.Transaction
+}
+
+func (c *fakeChain) ChainID(ctx context.Context) (*big.Int, error) { return big.NewInt(8453), nil }
+
+func (c *fakeChain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	switch string(msg.Data[:4]) {
+	case string(noncesSelector):
+		return common.LeftPadBytes(c.nonce.Bytes(), 32), nil
+	case string(balanceOfSelector):
+		return common.LeftPadBytes(c.balance.Bytes(), 32), nil
+	case string(authorizationStateSelector):
+		if c.used {
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func (c *fakeChain) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return uint64(len(c.sent)), nil
+}
+
+func (c *fakeChain) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{BaseFee: big.NewInt(10)}, nil
+}
+
+func (c *fakeChain) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 50000, nil
+}
+
+func (c *fakeChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *fakeChain) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		TxHash:            txHash,
+		BlockNumber:       big.NewInt(100),
+		GasUsed:           40000,
+		EffectiveGasPrice: big.NewInt(11),
+	}, nil
+}
+
+func newTestFacilitator(t *testing.T, client *fakeChain) *Facilitator {
+	t.Helper()
+	operatorKey, err := crypto.HexToECDSA(operatorKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Facilitator{
+		client:  client,
+		relayer: newRPCRelayer(client, operatorKey),
+		network: "base",
+	}
+}
+
+// signedPermit returns a permit payment of value to spender, signed by the payer.
+func signedPermit(t *testing.T, spender common.Address, value, nonce int64, timeoutSeconds int) x402.PaymentPayload {
+	t.Helper()
+	payerKey, err := crypto.HexToECDSA(payerKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permit := evmsigner.CreatePermit(crypto.PubkeyToAddress(payerKey.PublicKey), spender, big.NewInt(value), big.NewInt(nonce), timeoutSeconds)
+	signature, err := evmsigner.SignPermit(payerKey, common.HexToAddress(testToken), big.NewInt(8453), permit, "Dai Stablecoin", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "permit",
+		Network:     "base",
+		Payload: x402.EVMPermitPayload{
+			Signature: signature,
+			Permit: x402.EVMPermit{
+				Owner:    permit.Owner.Hex(),
+				Spender:  permit.Spender.Hex(),
+				Value:    permit.Value.String(),
+				Nonce:    permit.Nonce.String(),
+				Deadline: permit.Deadline.String(),
+			},
+		},
+	}
+}
+
+func testRequirement() x402.PaymentRequirement {
+	return x402.PaymentRequirement{
+		Scheme:            "permit",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		Asset:             testToken,
+		PayTo:             "0x1111111111111111111111111111111111111111",
+		MaxTimeoutSeconds: 60,
+		Extra:             map[string]interface{}{"name": "Dai Stablecoin", "version": "1"},
+	}
+}
+
+func TestFacilitator_Verify(t *testing.T) {
+	operator := mustAddress(t, operatorKeyHex)
+	stranger := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	tests := []struct {
+		name       string
+		payment    func() x402.PaymentPayload
+		balance    int64
+		wantReason string
+	}{
+		{
+			name:    "valid",
+			payment: func() x402.PaymentPayload { return signedPermit(t, operator, 1000, 4, 60) },
+			balance: 5000,
+		},
+		{
+			name:       "other spender",
+			payment:    func() x402.PaymentPayload { return signedPermit(t, stranger, 1000, 4, 60) },
+			balance:    5000,
+			wantReason: ReasonInvalidSpender,
+		},
+		{
+			name:       "value too low",
+			payment:    func() x402.PaymentPayload { return signedPermit(t, operator, 999, 4, 60) },
+			balance:    5000,
+			wantReason: ReasonInvalidAmount,
+		},
+		{
+			name:       "expired",
+			payment:    func() x402.PaymentPayload { return signedPermit(t, operator, 1000, 4, -1) },
+			balance:    5000,
+			wantReason: ReasonExpired,
+		},
+		{
+			name:       "stale nonce",
+			payment:    func() x402.PaymentPayload { return signedPermit(t, operator, 1000, 3, 60) },
+			balance:    5000,
+			wantReason: ReasonInvalidNonce,
+		},
+		{
+			name: "tampered value",
+			payment: func() x402.PaymentPayload {
+				payment := signedPermit(t, operator, 1000, 4, 60)
+				payload := payment.Payload.(x402.EVMPermitPayload)
+				payload.Permit.Value = "2000"
+				payment.Payload = payload
+				return payment
+			},
+			balance:    5000,
+			wantReason: ReasonInvalidSignature,
+		},
+		{
+			name:       "insufficient balance",
+			payment:    func() x402.PaymentPayload { return signedPermit(t, operator, 1000, 4, 60) },
+			balance:    999,
+			wantReason: ReasonInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFacilitator(t, &fakeChain{nonce: big.NewInt(4), balance: big.NewInt(tt.balance)})
+			resp, err := f.Verify(context.Background(), tt.payment(), testRequirement())
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if resp.InvalidReason != tt.wantReason || resp.IsValid != (tt.wantReason == "") {
+				t.Errorf("Verify() = %+v, want reason %q", resp, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestFacilitator_Settle(t *testing.T) {
+	client := &fakeChain{nonce: big.NewInt(0), balance: big.NewInt(1000)}
+	f := newTestFacilitator(t, client)
+	payment := signedPermit(t, f.relayer.Address(), 1000, 0, 60)
+
+	resp, err := f.Settle(context.Background(), payment, testRequirement())
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	if !resp.Success || resp.Payer != "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266" || resp.BlockNumber != 100 {
+		t.Errorf("Settle() = %+v, want a successful settlement in block 100", resp)
+	}
+	if resp.FeePaid != "880000" {
+		t.Errorf("FeePaid = %s, want the gas of both transactions (880000)", resp.FeePaid)
+	}
+
+	if len(client.sent) != 2 {
+		t.Fatalf("sent %d transactions, want permit and transferFrom", len(client.sent))
+	}
+	if got := client.sent[0].Data()[:4]; string(got) != string(permitSelector) {
+		t.Errorf("first transaction calls %x, want permit", got)
+	}
+	transfer := client.sent[1]
+	if got := transfer.Data()[:4]; string(got) != string(transferFromSelector) {
+		t.Errorf("second transaction calls %x, want transferFrom", got)
+	}
+	if resp.Transaction != transfer.Hash().Hex() {
+		t.Errorf("Transaction = %s, want the transferFrom hash %s", resp.Transaction, transfer.Hash().Hex())
+	}
+	if *transfer.To() != common.HexToAddress(testToken) {
+		t.Errorf("transferFrom sent to %s, want the token", transfer.To().Hex())
+	}
+
+	// Invalid payments are reported without sending transactions
+	resp, err = f.Settle(context.Background(), signedPermit(t, f.relayer.Address(), 1, 0, 60), testRequirement())
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	if resp.Success || resp.ErrorReason != ReasonInvalidAmount || len(client.sent) != 2 {
+		t.Errorf("Settle() = %+v after %d transactions, want %s and no new transaction", resp, len(client.sent), ReasonInvalidAmount)
+	}
+}
+
+func TestNewFacilitator(t *testing.T) {
+	if _, err := NewFacilitator(WithNetwork("base"), WithRPCURL("https://mainnet.base.org")); !errors.Is(err, x402.ErrInvalidKey) {
+		t.Errorf("NewFacilitator() without key error = %v, want ErrInvalidKey", err)
+	}
+	if _, err := NewFacilitator(WithPrivateKey(operatorKeyHex), WithNetwork("solana")); !errors.Is(err, x402.ErrInvalidNetwork) {
+		t.Errorf("NewFacilitator() on solana error = %v, want ErrInvalidNetwork", err)
+	}
+
+	relayer := &recordingRelayer{address: common.HexToAddress("0x3333333333333333333333333333333333333333")}
+	if _, err := NewFacilitator(WithRelayer(relayer), WithNetwork("base"), WithRPCURL("https://mainnet.base.org")); err != nil {
+		t.Errorf("NewFacilitator() with a relayer and no key error = %v", err)
+	}
+
+	f, err := NewFacilitator(WithPrivateKey(operatorKeyHex), WithNetwork("base"), WithRPCURL("https://mainnet.base.org"))
+	if err != nil {
+		t.Fatalf("NewFacilitator() error = %v", err)
+	}
+	supported, err := f.Supported(context.Background())
+	if err != nil {
+		t.Fatalf("Supported() error = %v", err)
+	}
+	if len(supported.Kinds) != 2 || supported.Kinds[0].Scheme != "permit" || supported.Kinds[0].Extra["spender"] != f.Address() {
+		t.Errorf("Supported() = %+v, want the permit scheme with spender %s", supported, f.Address())
+	}
+	if supported.Kinds[1].Scheme != "exact" || supported.Kinds[1].Extra["receiveWithAuthorization"] != true {
+		t.Errorf("Supported() = %+v, want the exact scheme with receiveWithAuthorization", supported)
+	}
+}
+
+// recordingRelayer is a Relayer reporting no fees, recording the relayed calls.
+type recordingRelayer struct {
+	address common.Address
+	calls   [][]byte
+}
+
+func (r *recordingRelayer) Address() common.Address { return r.address }
+
+func (r *recordingRelayer) Relay(ctx context.Context, to common.Address, data []byte) (*RelayedTx, error) {
+	r.calls = append(r.calls, data)
+	return &RelayedTx{Hash: common.BytesToHash([]byte{byte(len(r.calls))}), BlockNumber: 7}, nil
+}
+
+func TestFacilitator_WithRelayer(t *testing.T) {
+	relayer := &recordingRelayer{address: common.HexToAddress("0x3333333333333333333333333333333333333333")}
+	f := newTestFacilitator(t, &fakeChain{nonce: big.NewInt(0), balance: big.NewInt(1000)})
+	if err := WithRelayer(relayer)(f); err != nil {
+		t.Fatalf("WithRelayer() error = %v", err)
+	}
+	if f.Address() != relayer.address.Hex() {
+		t.Errorf("Address() = %s, want the relayer's %s", f.Address(), relayer.address.Hex())
+	}
+
+	// Permits must name the relayer as spender
+	resp, err := f.Settle(context.Background(), signedPermit(t, mustAddress(t, operatorKeyHex), 1000, 0, 60), testRequirement())
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	if resp.Success || resp.ErrorReason != ReasonInvalidSpender {
+		t.Errorf("Settle() with the operator as spender = %+v, want %s", resp, ReasonInvalidSpender)
+	}
+
+	resp, err = f.Settle(context.Background(), signedPermit(t, relayer.address, 1000, 0, 60), testRequirement())
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	if !resp.Success || resp.BlockNumber != 7 || resp.FeePaid != "" || len(relayer.calls) != 2 {
+		t.Errorf("Settle() = %+v after %d calls, want success without a fee after 2 calls", resp, len(relayer.calls))
+	}
+}
+
+func mustAddress(t *testing.T, hexKey string) common.Address {
+	t.Helper()
+	return crypto.PubkeyToAddress(mustKey(t, hexKey).PublicKey)
+}
+
+func mustKey(t *testing.T, hexKey string) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,181 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/mark3labs/x402-go"
+	evmsigner "github.com/mark3labs/x402-go/signers/evm"
+)
+
+// settleReceive settles an exact payment with receiveWithAuthorization, waiting for the
+// transaction to be mined or ctx to end.
+func (f *Facilitator) settleReceive(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement) (*x402.SettlementResponse, error) {
+	payload, from, reason, err := f.verifyReceive(ctx, payment, requirement)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return &x402.SettlementResponse{Success: false, ErrorReason: reason, Network: f.network, Payer: from}, nil
+	}
+
+	relayed, err := f.relayer.Relay(ctx, common.HexToAddress(requirement.Asset), receiveCall(payload))
+	if err != nil {
+		return nil, fmt.Errorf("evm: receiveWithAuthorization failed: %w", err)
+	}
+
+	settlement := &x402.SettlementResponse{
+		Success:     true,
+		Transaction: relayed.Hash.Hex(),
+		Network:     f.network,
+		Payer:       from,
+		BlockNumber: relayed.BlockNumber,
+		SettledAt:   time.Now().Unix(),
+	}
+	if relayed.Fee != nil {
+		settlement.FeePaid = relayed.Fee.String()
+	}
+	return settlement, nil
+}
+
+// verifyReceive checks an exact payment with receiveWithAuthorization against
+// requirement. It returns the decoded authorization, the payer and, if the payment is
+// invalid, the reason. Errors are reserved for RPC failures.
+func (f *Facilitator) verifyReceive(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement) (*x402.EVMPayload, string, string, error) {
+	// Only receiveWithAuthorization payments can be settled by the payee's relayer
+	if requirement.Scheme != x402.SchemeExact || !requirement.ReceiveWithAuthorization() {
+		return nil, "", ReasonUnsupportedScheme, nil
+	}
+	if payment.Network != f.network || requirement.Network != f.network {
+		return nil, "", ReasonInvalidNetwork, nil
+	}
+
+	payload, err := decodeAuthorization(payment.Payload)
+	if err != nil {
+		return nil, "", ReasonInvalidAuthorization, nil
+	}
+	auth := payload.Authorization
+	from := auth.From
+
+	to := common.HexToAddress(auth.To)
+	if !common.IsHexAddress(auth.To) || to != common.HexToAddress(requirement.PayTo) || to != f.relayer.Address() {
+		return nil, from, ReasonRecipientMismatch, nil
+	}
+	value, ok := new(big.Int).SetString(auth.Value, 10)
+	required, okRequired := new(big.Int).SetString(requirement.MaxAmountRequired, 10)
+	if !ok || !okRequired || value.Cmp(required) < 0 {
+		return nil, from, ReasonInvalidAuthorizationValue, nil
+	}
+	validAfter, ok := new(big.Int).SetString(auth.ValidAfter, 10)
+	if !ok {
+		return nil, from, ReasonInvalidAuthorization, nil
+	}
+	validBefore, ok := new(big.Int).SetString(auth.ValidBefore, 10)
+	if !ok {
+		return nil, from, ReasonInvalidAuthorization, nil
+	}
+	nonce, err := hexutil.Decode(auth.Nonce)
+	if err != nil || len(nonce) != common.HashLength {
+		return nil, from, ReasonInvalidAuthorization, nil
+	}
+	now := big.NewInt(time.Now().Unix())
+	if validAfter.Cmp(now) > 0 {
+		return nil, from, ReasonAuthorizationNotYetValid, nil
+	}
+	if validBefore.Cmp(now) <= 0 {
+		return nil, from, ReasonAuthorizationExpired, nil
+	}
+
+	chainID, err := f.getChainID(ctx)
+	if err != nil {
+		return nil, from, "", err
+	}
+	name, _ := requirement.Extra[x402.ExtraName].(string)
+	version, _ := requirement.Extra[x402.ExtraVersion].(string)
+	token := common.HexToAddress(requirement.Asset)
+	fromAddr := common.HexToAddress(from)
+	digest, err := evmsigner.HashReceiveAuthorization(token, chainID, &evmsigner.EIP3009Authorization{
+		From:        fromAddr,
+		To:          to,
+		Value:       value,
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+		Nonce:       common.BytesToHash(nonce),
+	}, name, version)
+	if err != nil {
+		return nil, from, ReasonInvalidAuthorization, nil
+	}
+	if signer, err := recoverSigner(digest, payload.Signature); err != nil || signer != fromAddr {
+		return nil, from, ReasonInvalidAuthorizationSignature, nil
+	}
+
+	data := append(append([]byte{}, authorizationStateSelector...), common.LeftPadBytes(fromAddr.Bytes(), 32)...)
+	used, err := f.call(ctx, token, append(data, nonce...))
+	if err != nil {
+		return nil, from, "", err
+	}
+	if used.Sign() != 0 {
+		return nil, from, ReasonAuthorizationUsed, nil
+	}
+	balance, err := f.callUint(ctx, token, balanceOfSelector, fromAddr)
+	if err != nil {
+		return nil, from, "", err
+	}
+	if balance.Cmp(required) < 0 {
+		return nil, from, ReasonInsufficientFunds, nil
+	}
+
+	return payload, from, "", nil
+}
+
+// decodeAuthorization decodes an EIP-3009 payload, which is a map after JSON decoding.
+func decodeAuthorization(payload interface{}) (*x402.EVMPayload, error) {
+	switch p := payload.(type) {
+	case x402.EVMPayload:
+		return &p, nil
+	case *x402.EVMPayload:
+		return p, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var authorization x402.EVMPayload
+	if err := json.Unmarshal(data, &authorization); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(authorization.Authorization.From) {
+		return nil, errors.New("invalid authorization sender")
+	}
+	return &authorization, nil
+}
+
+// receiveCall encodes the receiveWithAuthorization call submitting payload.
+func receiveCall(payload *x402.EVMPayload) []byte {
+	auth := payload.Authorization
+	sig, _ := hexutil.Decode(payload.Signature)
+	nonce, _ := hexutil.Decode(auth.Nonce)
+
+	data := append([]byte{}, receiveWithAuthorizationSelector...)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(auth.From).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(auth.To).Bytes(), 32)...)
+	for _, n := range []string{auth.Value, auth.ValidAfter, auth.ValidBefore} {
+		v, _ := new(big.Int).SetString(n, 10)
+		data = append(data, common.LeftPadBytes(v.Bytes(), 32)...)
+	}
+	data = append(data, nonce...)
+	v := sig[64]
+	if v < 27 {
+		v += 27
+	}
+	data = append(data, common.LeftPadBytes([]byte{v}, 32)...)
+	data = append(data, sig[:32]...)
+	data = append(data, sig[32:64]...)
+	return data
+}
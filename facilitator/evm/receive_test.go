@@ -0,0 +1,200 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mark3labs/x402-go"
+	evmsigner "github.com/mark3labs/x402-go/signers/evm"
+)
+
+// signedReceive returns an exact payment of value to to with receiveWithAuthorization,
+// signed by the payer.
+func signedReceive(t *testing.T, to common.Address, value int64, timeoutSeconds int) x402.PaymentPayload {
+	t.Helper()
+	payerKey := mustKey(t, payerKeyHex)
+	auth, err := evmsigner.CreateEIP3009Authorization(crypto.PubkeyToAddress(payerKey.PublicKey), to, big.NewInt(value), timeoutSeconds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := evmsigner.SignReceiveAuthorization(payerKey, common.HexToAddress(testToken), big.NewInt(8453), auth, "USD Coin", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base",
+		Payload: x402.EVMPayload{
+			Signature: signature,
+			Authorization: x402.EVMAuthorization{
+				From:        auth.From.Hex(),
+				To:          auth.To.Hex(),
+				Value:       auth.Value.String(),
+				ValidAfter:  auth.ValidAfter.String(),
+				ValidBefore: auth.ValidBefore.String(),
+				Nonce:       auth.Nonce.Hex(),
+			},
+		},
+	}
+}
+
+// receiveRequirement returns an exact requirement paying payTo with
+// receiveWithAuthorization.
+func receiveRequirement(payTo common.Address) x402.PaymentRequirement {
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		Asset:             testToken,
+		PayTo:             payTo.Hex(),
+		MaxTimeoutSeconds: 60,
+	}
+	requirement.SetEIP3009Domain("USD Coin", "2")
+	requirement.SetReceiveWithAuthorization()
+	return requirement
+}
+
+func TestFacilitator_VerifyReceive(t *testing.T) {
+	operator := mustAddress(t, operatorKeyHex)
+	stranger := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	tests := []struct {
+		name        string
+		payment     func() x402.PaymentPayload
+		requirement x402.PaymentRequirement
+		used        bool
+		balance     int64
+		wantReason  string
+	}{
+		{
+			name:        "valid",
+			payment:     func() x402.PaymentPayload { return signedReceive(t, operator, 1000, 60) },
+			requirement: receiveRequirement(operator),
+			balance:     5000,
+		},
+		{
+			name:    "transferWithAuthorization requirement",
+			payment: func() x402.PaymentPayload { return signedReceive(t, operator, 1000, 60) },
+			requirement: func() x402.PaymentRequirement {
+				r := receiveRequirement(operator)
+				r.Extra = map[string]interface{}{"name": "USD Coin", "version": "2"}
+				return r
+			}(),
+			balance:    5000,
+			wantReason: ReasonUnsupportedScheme,
+		},
+		{
+			name:        "payee is not the relayer",
+			payment:     func() x402.PaymentPayload { return signedReceive(t, stranger, 1000, 60) },
+			requirement: receiveRequirement(stranger),
+			balance:     5000,
+			wantReason:  ReasonRecipientMismatch,
+		},
+		{
+			name:        "value too low",
+			payment:     func() x402.PaymentPayload { return signedReceive(t, operator, 999, 60) },
+			requirement: receiveRequirement(operator),
+			balance:     5000,
+			wantReason:  ReasonInvalidAuthorizationValue,
+		},
+		{
+			name:        "expired",
+			payment:     func() x402.PaymentPayload { return signedReceive(t, operator, 1000, -1) },
+			requirement: receiveRequirement(operator),
+			balance:     5000,
+			wantReason:  ReasonAuthorizationExpired,
+		},
+		{
+			name: "transferWithAuthorization signature",
+			payment: func() x402.PaymentPayload {
+				payment := signedReceive(t, operator, 1000, 60)
+				payload := payment.Payload.(x402.EVMPayload)
+				a := payload.Authorization
+				value, _ := new(big.Int).SetString(a.Value, 10)
+				validAfter, _ := new(big.Int).SetString(a.ValidAfter, 10)
+				validBefore, _ := new(big.Int).SetString(a.ValidBefore, 10)
+				payload.Signature, _ = evmsigner.SignTransferAuthorization(mustKey(t, payerKeyHex), common.HexToAddress(testToken), big.NewInt(8453), &evmsigner.EIP3009Authorization{
+					From:        common.HexToAddress(a.From),
+					To:          common.HexToAddress(a.To),
+					Value:       value,
+					ValidAfter:  validAfter,
+					ValidBefore: validBefore,
+					Nonce:       common.HexToHash(a.Nonce),
+				}, "USD Coin", "2")
+				payment.Payload = payload
+				return payment
+			},
+			requirement: receiveRequirement(operator),
+			balance:     5000,
+			wantReason:  ReasonInvalidAuthorizationSignature,
+		},
+		{
+			name:        "used authorization",
+			payment:     func() x402.PaymentPayload { return signedReceive(t, operator, 1000, 60) },
+			requirement: receiveRequirement(operator),
+			used:        true,
+			balance:     5000,
+			wantReason:  ReasonAuthorizationUsed,
+		},
+		{
+			name:        "insufficient balance",
+			payment:     func() x402.PaymentPayload { return signedReceive(t, operator, 1000, 60) },
+			requirement: receiveRequirement(operator),
+			balance:     999,
+			wantReason:  ReasonInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFacilitator(t, &fakeChain{nonce: big.NewInt(0), balance: big.NewInt(tt.balance), used: tt.used})
+			resp, err := f.Verify(context.Background(), tt.payment(), tt.requirement)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if resp.InvalidReason != tt.wantReason || resp.IsValid != (tt.wantReason == "") {
+				t.Errorf("Verify() = %+v, want reason %q", resp, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestFacilitator_SettleReceive(t *testing.T) {
+	client := &fakeChain{nonce: big.NewInt(0), balance: big.NewInt(1000)}
+	f := newTestFacilitator(t, client)
+	operator := f.relayer.Address()
+
+	resp, err := f.Settle(context.Background(), signedReceive(t, operator, 1000, 60), receiveRequirement(operator))
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	if !resp.Success || resp.Payer != "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266" || resp.BlockNumber != 100 {
+		t.Errorf("Settle() = %+v, want a successful settlement in block 100", resp)
+	}
+	if len(client.sent) != 1 {
+		t.Fatalf("sent %d transactions, want receiveWithAuthorization", len(client.sent))
+	}
+	tx := client.sent[0]
+	if got := tx.Data()[:4]; string(got) != string(receiveWithAuthorizationSelector) {
+		t.Errorf("transaction calls %x, want receiveWithAuthorization", got)
+	}
+	if len(tx.Data()) != 4+9*32 {
+		t.Errorf("receiveWithAuthorization call has %d bytes, want %d", len(tx.Data()), 4+9*32)
+	}
+	if *tx.To() != common.HexToAddress(testToken) || resp.Transaction != tx.Hash().Hex() {
+		t.Errorf("transaction to %s with hash %s, want the token and %s", tx.To().Hex(), tx.Hash().Hex(), resp.Transaction)
+	}
+
+	// Invalid payments are reported without sending transactions
+	resp, err = f.Settle(context.Background(), signedReceive(t, operator, 1, 60), receiveRequirement(operator))
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	if resp.Success || resp.ErrorReason != ReasonInvalidAuthorizationValue || len(client.sent) != 1 {
+		t.Errorf("Settle() = %+v after %d transactions, want %s and no new transaction", resp, len(client.sent), ReasonInvalidAuthorizationValue)
+	}
+}
@@ -0,0 +1,202 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mark3labs/x402-go"
+)
+
+// Relayer broadcasts the transactions a Facilitator settles payments with, so the relay
+// infrastructure (a self-managed key and RPC endpoint, or a relay service such as
+// OpenZeppelin Defender) is chosen by configuration rather than by the settlement code.
+//
+// The relayed calls must come from an account dedicated to the relayer: it is the
+// spender of the permits, so anyone able to send calls from it can spend them. Shared
+// forwarders, such as Gelato's sponsored call contracts, must not be used.
+type Relayer interface {
+	// Address returns the account the relayed calls are sent from (their msg.sender).
+	Address() common.Address
+
+	// Relay sends a call of data to the contract at to and waits until it is mined or
+	// ctx ends. It fails if the call reverts.
+	Relay(ctx context.Context, to common.Address, data []byte) (*RelayedTx, error)
+}
+
+// RelayedTx is a mined transaction sent by a Relayer.
+type RelayedTx struct {
+	// Hash is the transaction hash.
+	Hash common.Hash
+
+	// BlockNumber is the block the transaction was included in.
+	BlockNumber uint64
+
+	// Fee is the gas fee paid in wei, or nil if the relayer does not report it.
+	Fee *big.Int
+}
+
+// receiptPollInterval is how often RPCRelayer checks whether its transactions were mined.
+const receiptPollInterval = time.Second
+
+// chain is the part of ethclient.Client the Facilitator and RPCRelayer use.
+type chain interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// RPCRelayer is a Relayer signing EIP-1559 transactions with its own key and
+// broadcasting them through a JSON-RPC endpoint. Its account needs a native balance
+// for gas. It is safe for concurrent use.
+type RPCRelayer struct {
+	client     chain
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+
+	// mu keeps the nonces of concurrent transactions apart and guards chainID.
+	mu      sync.Mutex
+	chainID *big.Int
+}
+
+// NewRPCRelayer creates an RPCRelayer sending from the account of the hex private key
+// through the JSON-RPC endpoint at rpcURL.
+func NewRPCRelayer(hexKey, rpcURL string) (*RPCRelayer, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, x402.ErrInvalidKey
+	}
+	if rpcURL == "" {
+		return nil, errors.New("evm: RPC URL is required")
+	}
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("evm: failed to connect to RPC: %w", err)
+	}
+	return newRPCRelayer(client, privateKey), nil
+}
+
+// newRPCRelayer creates an RPCRelayer using client.
+func newRPCRelayer(client chain, privateKey *ecdsa.PrivateKey) *RPCRelayer {
+	return &RPCRelayer{
+		client:     client,
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+}
+
+// Address implements Relayer.
+func (r *RPCRelayer) Address() common.Address {
+	return r.address
+}
+
+// Relay implements Relayer.
+func (r *RPCRelayer) Relay(ctx context.Context, to common.Address, data []byte) (*RelayedTx, error) {
+	signed, err := r.send(ctx, to, data)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := r.waitMined(ctx, signed.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return nil, fmt.Errorf("transaction %s reverted", signed.Hash().Hex())
+	}
+
+	relayed := &RelayedTx{Hash: receipt.TxHash, BlockNumber: receipt.BlockNumber.Uint64()}
+	if receipt.EffectiveGasPrice != nil {
+		relayed.Fee = new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	}
+	return relayed, nil
+}
+
+// send signs and broadcasts an EIP-1559 transaction calling to with data.
+func (r *RPCRelayer) send(ctx context.Context, to common.Address, data []byte) (*types.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.chainID == nil {
+		chainID, err := r.client.ChainID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chain ID: %w", err)
+		}
+		r.chainID = chainID
+	}
+	nonce, err := r.client.PendingNonceAt(ctx, r.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	tipCap, err := r.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas tip: %w", err)
+	}
+	head, err := r.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, errors.New("chain does not support EIP-1559 transactions")
+	}
+	// Allow the base fee to double before the transaction stops being includable
+	feeCap := new(big.Int).Add(tipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	gas, err := r.client.EstimateGas(ctx, ethereum.CallMsg{From: r.address, To: &to, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   r.chainID,
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &to,
+		Value:     new(big.Int),
+		Data:      data,
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(r.chainID), r.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", x402.ErrSigningFailed, err)
+	}
+	if err := r.client.SendTransaction(ctx, signed); err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	return signed, nil
+}
+
+// waitMined polls for the receipt of hash until it is mined or ctx ends.
+func (r *RPCRelayer) waitMined(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	for {
+		receipt, err := r.client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("failed to get receipt: %w", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("transaction %s not mined: %w", hash.Hex(), ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package facilitator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// HTTPOption configures the HTTP client built by NewHTTPClient.
+type HTTPOption func(*httpConfig) error
+
+// httpConfig collects the settings applied by HTTPOptions.
+type httpConfig struct {
+	client       *http.Client
+	proxy        *url.URL
+	certificates []tls.Certificate
+	rootCAs      *x509.CertPool
+}
+
+// NewHTTPClient builds an HTTP client for reaching a facilitator, e.g. from behind a
+// corporate proxy or with a client certificate. Pass the result to
+// http.Config.FacilitatorHTTPClient (or the equivalent signer option).
+//
+// Example:
+//
+//	client, err := facilitator.NewHTTPClient(
+//	    facilitator.WithProxy("http://proxy.internal:3128"),
+//	    facilitator.WithTLSClientCert("client.pem", "client-key.pem"),
+//	)
+func NewHTTPClient(opts ...HTTPOption) (*http.Client, error) {
+	var cfg httpConfig
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	client := &http.Client{}
+	if cfg.client != nil {
+		*client = *cfg.client
+	}
+	if cfg.proxy == nil && len(cfg.certificates) == 0 && cfg.rootCAs == nil {
+		return client, nil
+	}
+
+	// Clone the transport so the base client is left untouched
+	var transport *http.Transport
+	switch base := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = base.Clone()
+	default:
+		return nil, fmt.Errorf("facilitator: cannot apply proxy or TLS settings to transport %T", base)
+	}
+
+	if cfg.proxy != nil {
+		transport.Proxy = http.ProxyURL(cfg.proxy)
+	}
+	if len(cfg.certificates) > 0 || cfg.rootCAs != nil {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if len(cfg.certificates) > 0 {
+			transport.TLSClientConfig.Certificates = cfg.certificates
+		}
+		if cfg.rootCAs != nil {
+			transport.TLSClientConfig.RootCAs = cfg.rootCAs
+		}
+	}
+	client.Transport = transport
+	return client, nil
+}
+
+// WithHTTPClient uses client as the base for the built client. Its timeout and
+// transport settings are kept; other options are applied to a copy of its transport.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(c *httpConfig) error {
+		c.client = client
+		return nil
+	}
+}
+
+// WithProxy sends facilitator requests through the proxy at proxyURL,
+// e.g. "http://proxy.internal:3128". By default the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables are honored.
+func WithProxy(proxyURL string) HTTPOption {
+	return func(c *httpConfig) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("facilitator: invalid proxy URL %q", proxyURL)
+		}
+		c.proxy = u
+		return nil
+	}
+}
+
+// WithTLSClientCert authenticates to the facilitator with the client certificate in
+// certFile/keyFile (PEM), for facilitators that require mutual TLS.
+func WithTLSClientCert(certFile, keyFile string) HTTPOption {
+	return func(c *httpConfig) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("facilitator: failed to load client certificate: %w", err)
+		}
+		c.certificates = append(c.certificates, cert)
+		return nil
+	}
+}
+
+// WithRootCAs trusts only server certificates signed by the PEM certificates in caFile,
+// e.g. a private facilitator's internal CA.
+func WithRootCAs(caFile string) HTTPOption {
+	return func(c *httpConfig) error {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("facilitator: failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("facilitator: no certificates found in %s", caFile)
+		}
+		c.rootCAs = pool
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package facilitator
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	base := &http.Client{Timeout: 5 * time.Second}
+
+	tests := []struct {
+		name        string
+		opts        []HTTPOption
+		wantErr     bool
+		wantTimeout time.Duration
+		wantProxy   string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:        "base client kept",
+			opts:        []HTTPOption{WithHTTPClient(base)},
+			wantTimeout: 5 * time.Second,
+		},
+		{
+			name:        "proxy on base client",
+			opts:        []HTTPOption{WithHTTPClient(base), WithProxy("http://proxy.internal:3128")},
+			wantTimeout: 5 * time.Second,
+			wantProxy:   "http://proxy.internal:3128",
+		},
+		{
+			name:    "invalid proxy",
+			opts:    []HTTPOption{WithProxy("proxy.internal")},
+			wantErr: true,
+		},
+		{
+			name:    "missing client certificate",
+			opts:    []HTTPOption{WithTLSClientCert("missing.pem", "missing-key.pem")},
+			wantErr: true,
+		},
+		{
+			name:    "missing CA file",
+			opts:    []HTTPOption{WithRootCAs("missing-ca.pem")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(tt.opts...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewHTTPClient: %v", err)
+			}
+			if client.Timeout != tt.wantTimeout {
+				t.Errorf("Timeout = %v, want %v", client.Timeout, tt.wantTimeout)
+			}
+			if tt.wantProxy == "" {
+				return
+			}
+
+			transport, ok := client.Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("Transport = %T, want *http.Transport", client.Transport)
+			}
+			req, _ := http.NewRequest(http.MethodGet, "https://facilitator.example.com/verify", nil)
+			proxy, err := transport.Proxy(req)
+			if err != nil || proxy == nil || proxy.String() != tt.wantProxy {
+				t.Errorf("Proxy = %v, %v; want %s", proxy, err, tt.wantProxy)
+			}
+		})
+	}
+
+	if base.Transport != nil {
+		t.Error("base client transport was modified")
+	}
+}
+
+func TestNewHTTPClient_ProxyRoundTrip(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL
+		requested = r.URL.String()
+		_, _ = io.WriteString(w, `{"kinds":[]}`)
+	}))
+	defer proxy.Close()
+
+	client, err := NewHTTPClient(WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+
+	resp, err := client.Get("http://facilitator.invalid/supported")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+
+	if requested != "http://facilitator.invalid/supported" {
+		t.Errorf("proxy received %q", requested)
+	}
+}
@@ -0,0 +1,232 @@
+package facilitator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNoFixture indicates a replaying Recorder has no recorded response left for a request.
+var ErrNoFixture = errors.New("facilitator: no recorded interaction for request")
+
+// RecorderMode selects whether a Recorder records or replays facilitator interactions.
+type RecorderMode int
+
+const (
+	// ModeReplay answers requests from the fixture file without contacting the facilitator.
+	ModeReplay RecorderMode = iota
+
+	// ModeRecord forwards requests to the facilitator and records the exchanges, which
+	// Save writes to the fixture file.
+	ModeRecord
+)
+
+// Interaction is one recorded facilitator exchange. Bodies are kept as JSON so fixtures
+// can be read and edited; a body that is not JSON is stored as a JSON string.
+type Interaction struct {
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// fixture is the content of a fixture file.
+type fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records facilitator exchanges (verify, settle,
+// supported) to a JSON fixture file and replays them, for deterministic tests against
+// real facilitator behavior. Record once against a testnet facilitator, commit the
+// fixture, and replay it in CI:
+//
+//	rec, err := facilitator.NewRecorder("testdata/settle.json", facilitator.ModeReplay)
+//	config.FacilitatorHTTPClient = rec.Client()
+//
+// Replay matches requests by method and path and returns the recorded responses for
+// each in the order they were recorded; request bodies are recorded for reference but
+// not matched, since signatures and nonces change between runs. Request headers,
+// including Authorization, are never recorded.
+//
+// Recorder is safe for concurrent use.
+type Recorder struct {
+	path string
+	mode RecorderMode
+	base http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[int]bool
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(*Recorder)
+
+// WithRecorderTransport sets the RoundTripper a recording Recorder forwards requests to
+// (default http.DefaultTransport).
+func WithRecorderTransport(base http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.base = base
+	}
+}
+
+// NewRecorder creates a Recorder for the fixture file at path. In ModeReplay the file
+// is loaded and must exist.
+func NewRecorder(path string, mode RecorderMode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		base:     http.DefaultTransport,
+		replayed: make(map[int]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("facilitator: failed to read fixture: %w", err)
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("facilitator: invalid fixture %s: %w", path, err)
+		}
+		r.interactions = f.Interactions
+	}
+	return r, nil
+}
+
+// Client returns an HTTP client using the Recorder, for http.Config.FacilitatorHTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the recorded or loaded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("facilitator: failed to read request body: %w", err)
+		}
+		requestBody = data
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+
+	forwarded := req.Clone(req.Context())
+	forwarded.Body = io.NopCloser(bytes.NewReader(requestBody))
+	resp, err := r.base.RoundTrip(forwarded)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("facilitator: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		Request:  fixtureBody(requestBody),
+		Status:   resp.StatusCode,
+		Response: fixtureBody(data),
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay answers req with the first interaction for its method and path not yet replayed.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.replayed[i] || interaction.Method != req.Method || interaction.Path != req.URL.Path {
+			continue
+		}
+		r.replayed[i] = true
+		body := responseBody(interaction.Response)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+			StatusCode:    interaction.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoFixture, req.Method, req.URL.Path)
+}
+
+// Save writes the recorded interactions to the fixture file, creating its directory.
+// It does nothing in ModeReplay.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(fixture{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("facilitator: failed to marshal fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("facilitator: failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("facilitator: failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// fixtureBody returns body as JSON for a fixture: unchanged if it is JSON, otherwise
+// as a JSON string.
+func fixtureBody(body []byte) json.RawMessage {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// responseBody reverses fixtureBody, compacting JSON indented in the fixture file.
+func responseBody(body json.RawMessage) []byte {
+	var text string
+	if json.Unmarshal(body, &text) == nil {
+		return []byte(text)
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) != nil {
+		return body
+	}
+	return compact.Bytes()
+}
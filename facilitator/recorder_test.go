@@ -0,0 +1,80 @@
+package facilitator
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/verify":
+			_, _ = io.WriteString(w, `{"isValid":false,"invalidReason":"invalid_exact_evm_payload_authorization_valid_before","payer":"0xpayer"}`)
+		case "/settle":
+			http.Error(w, "facilitator overloaded", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "facilitator.json")
+	recorder, err := NewRecorder(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	recorded := exchange(t, recorder.Client(), server.URL)
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 facilitator calls while recording, got %d", calls)
+	}
+
+	replayer, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	replayed := exchange(t, replayer.Client(), "http://facilitator.invalid")
+	if calls != 2 {
+		t.Errorf("replay contacted the facilitator")
+	}
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("response %d: replayed %q, recorded %q", i, replayed[i], recorded[i])
+		}
+	}
+
+	// Each recorded response is replayed once
+	_, err = replayer.Client().Post("http://facilitator.invalid/verify", "application/json", nil)
+	if !errors.Is(err, ErrNoFixture) {
+		t.Errorf("expected ErrNoFixture, got %v", err)
+	}
+}
+
+// exchange sends a verify and a settle request and returns the status and body of each.
+func exchange(t *testing.T, client *http.Client, baseURL string) []string {
+	t.Helper()
+	var responses []string
+	for _, path := range []string{"/verify", "/settle"} {
+		resp, err := client.Post(baseURL+path, "application/json", strings.NewReader(`{"x402Version":1}`))
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		responses = append(responses, resp.Status+" "+strings.TrimSpace(string(body)))
+	}
+	return responses
+}
+
+func TestNewRecorder_MissingFixture(t *testing.T) {
+	if _, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); err == nil {
+		t.Error("expected error for missing fixture")
+	}
+}
@@ -0,0 +1,36 @@
+package facilitator
+
+import (
+	"github.com/mark3labs/x402-go"
+)
+
+// FindKind returns the kind in kinds for network and scheme.
+func FindKind(kinds []SupportedKind, network, scheme string) (SupportedKind, bool) {
+	for _, kind := range kinds {
+		if kind.Network == network && kind.Scheme == scheme {
+			return kind, true
+		}
+	}
+	return SupportedKind{}, false
+}
+
+// EnrichFrom returns requirement with the extra data the facilitator advertises for its
+// network and scheme in kinds, such as the feePayer of SVM networks, added to Extra.
+// Values already present in requirement.Extra take precedence. requirement is not
+// modified.
+func EnrichFrom(requirement x402.PaymentRequirement, kinds []SupportedKind) x402.PaymentRequirement {
+	kind, ok := FindKind(kinds, requirement.Network, requirement.Scheme)
+	if !ok || len(kind.Extra) == 0 {
+		return requirement
+	}
+
+	extra := make(map[string]interface{}, len(requirement.Extra)+len(kind.Extra))
+	for k, v := range kind.Extra {
+		extra[k] = v
+	}
+	for k, v := range requirement.Extra {
+		extra[k] = v
+	}
+	requirement.Extra = extra
+	return requirement
+}
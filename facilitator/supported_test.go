@@ -0,0 +1,57 @@
+package facilitator
+
+import (
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestEnrichFrom(t *testing.T) {
+	kinds := []SupportedKind{
+		{X402Version: 1, Scheme: "exact", Network: "base"},
+		{X402Version: 1, Scheme: "exact", Network: "solana", Extra: map[string]interface{}{"feePayer": "FeePayer111", "memo": "facilitator"}},
+	}
+
+	tests := []struct {
+		name      string
+		req       x402.PaymentRequirement
+		wantExtra map[string]interface{}
+	}{
+		{
+			name:      "adds extra data",
+			req:       x402.PaymentRequirement{Scheme: "exact", Network: "solana"},
+			wantExtra: map[string]interface{}{"feePayer": "FeePayer111", "memo": "facilitator"},
+		},
+		{
+			name:      "keeps configured values",
+			req:       x402.PaymentRequirement{Scheme: "exact", Network: "solana", Extra: map[string]interface{}{"memo": "mine"}},
+			wantExtra: map[string]interface{}{"feePayer": "FeePayer111", "memo": "mine"},
+		},
+		{
+			name: "kind without extra data",
+			req:  x402.PaymentRequirement{Scheme: "exact", Network: "base"},
+		},
+		{
+			name: "unsupported kind",
+			req:  x402.PaymentRequirement{Scheme: "upto", Network: "solana"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configured := len(tt.req.Extra)
+			got := EnrichFrom(tt.req, kinds)
+			if len(got.Extra) != len(tt.wantExtra) {
+				t.Fatalf("Extra = %v, want %v", got.Extra, tt.wantExtra)
+			}
+			for k, v := range tt.wantExtra {
+				if got.Extra[k] != v {
+					t.Errorf("Extra[%q] = %v, want %v", k, got.Extra[k], v)
+				}
+			}
+			if len(tt.req.Extra) != configured {
+				t.Error("EnrichFrom modified the requirement's Extra")
+			}
+		})
+	}
+}
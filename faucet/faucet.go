@@ -0,0 +1,175 @@
+// Package faucet requests testnet USDC for an address, to fund wallets for integration
+// tests and example onboarding. By default it uses Circle's faucet API, which needs an
+// API key; a self-hosted faucet accepting the same request can be configured instead.
+//
+// Example usage:
+//
+//	f, err := faucet.New(faucet.WithAPIKey(os.Getenv("CIRCLE_API_KEY")))
+//	address, err := f.FundSigner(ctx, signer)
+package faucet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// CircleFaucetURL is the default faucet endpoint, Circle's testnet faucet API.
+const CircleFaucetURL = "https://api.circle.com/v1/faucet/drips"
+
+var (
+	// ErrUnsupportedNetwork indicates the faucet does not serve the network.
+	ErrUnsupportedNetwork = errors.New("x402: faucet does not support network")
+
+	// ErrRateLimited indicates the faucet refused the request because the address or
+	// API key was funded recently.
+	ErrRateLimited = errors.New("x402: faucet rate limit reached")
+
+	// ErrNoAddress indicates a signer does not expose the address it pays from.
+	ErrNoAddress = errors.New("x402: signer has no address")
+)
+
+// blockchains maps the supported x402 networks to the faucet's blockchain identifiers.
+var blockchains = map[string]string{
+	x402.BaseSepolia.NetworkID:  "BASE-SEPOLIA",
+	x402.SolanaDevnet.NetworkID: "SOL-DEVNET",
+}
+
+// Faucet requests testnet USDC. Faucet is safe for concurrent use.
+type Faucet struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Option configures a Faucet.
+type Option func(*Faucet) error
+
+// New creates a Faucet using Circle's faucet API unless WithEndpoint is given.
+func New(opts ...Option) (*Faucet, error) {
+	f := &Faucet{
+		endpoint:   CircleFaucetURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// WithEndpoint sets the faucet endpoint, for a self-hosted faucet accepting Circle's
+// request format.
+func WithEndpoint(url string) Option {
+	return func(f *Faucet) error {
+		if url == "" {
+			return errors.New("faucet: endpoint cannot be empty")
+		}
+		f.endpoint = url
+		return nil
+	}
+}
+
+// WithAPIKey sets the API key sent as a bearer token. Circle's faucet requires one.
+func WithAPIKey(key string) Option {
+	return func(f *Faucet) error {
+		f.apiKey = key
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for faucet requests (default http.DefaultClient).
+func WithHTTPClient(client *http.Client) Option {
+	return func(f *Faucet) error {
+		if client == nil {
+			return errors.New("faucet: HTTP client is nil")
+		}
+		f.httpClient = client
+		return nil
+	}
+}
+
+// dripRequest is the body of a faucet request.
+type dripRequest struct {
+	Address    string `json:"address"`
+	Blockchain string `json:"blockchain"`
+	USDC       bool   `json:"usdc"`
+}
+
+// Request asks the faucet to send USDC to address on network ("base-sepolia" or
+// "solana-devnet"). The faucet sends the funds asynchronously; they may take a few
+// seconds to arrive.
+func (f *Faucet) Request(ctx context.Context, network, address string) error {
+	blockchain, ok := blockchains[network]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedNetwork, network)
+	}
+	if err := x402.ValidateAddress(network, address); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(dripRequest{Address: address, Blockchain: blockchain, USDC: true})
+	if err != nil {
+		return fmt.Errorf("faucet: failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("faucet: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+f.apiKey)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("faucet: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("faucet: request failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// FundSigner requests USDC for the address signer pays from, on the signer's network,
+// and returns that address. The signer must have an Address method, as the signers in
+// this module do.
+func (f *Faucet) FundSigner(ctx context.Context, signer x402.Signer) (string, error) {
+	address, err := signerAddress(signer)
+	if err != nil {
+		return "", err
+	}
+	return address, f.Request(ctx, signer.Network(), address)
+}
+
+// signerAddress returns the result of the signer's Address method as a string. The
+// method is found by reflection because signers return different address types (the
+// EVM signer returns a go-ethereum common.Address).
+func signerAddress(signer x402.Signer) (string, error) {
+	if s, ok := signer.(interface{ Address() string }); ok {
+		return s.Address(), nil
+	}
+	method := reflect.ValueOf(signer).MethodByName("Address")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return "", ErrNoAddress
+	}
+	address, ok := method.Call(nil)[0].Interface().(fmt.Stringer)
+	if !ok {
+		return "", ErrNoAddress
+	}
+	return address.String(), nil
+}
@@ -0,0 +1,130 @@
+package faucet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+const (
+	evmAddress    = "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+	solanaAddress = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+)
+
+func TestFaucet_Request(t *testing.T) {
+	tests := []struct {
+		name           string
+		network        string
+		address        string
+		status         int
+		wantBlockchain string
+		wantErr        error
+	}{
+		{name: "base sepolia", network: "base-sepolia", address: evmAddress, status: http.StatusNoContent, wantBlockchain: "BASE-SEPOLIA"},
+		{name: "solana devnet", network: "solana-devnet", address: solanaAddress, status: http.StatusOK, wantBlockchain: "SOL-DEVNET"},
+		{name: "mainnet", network: "base", address: evmAddress, wantErr: ErrUnsupportedNetwork},
+		{name: "invalid address", network: "base-sepolia", address: "0x123", wantErr: x402.ErrInvalidAddress},
+		{name: "rate limited", network: "base-sepolia", address: evmAddress, status: http.StatusTooManyRequests, wantErr: ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got dripRequest
+			var authorization string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			f, err := New(WithEndpoint(server.URL), WithAPIKey("key"))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			err = f.Request(context.Background(), tt.network, tt.address)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Request() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Request() error = %v", err)
+			}
+			want := dripRequest{Address: tt.address, Blockchain: tt.wantBlockchain, USDC: true}
+			if got != want || authorization != "Bearer key" {
+				t.Errorf("faucet received %+v with Authorization %q, want %+v", got, authorization, want)
+			}
+		})
+	}
+}
+
+func TestFaucet_RequestFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	f, _ := New(WithEndpoint(server.URL))
+	if err := f.Request(context.Background(), "base-sepolia", evmAddress); err == nil {
+		t.Fatal("expected error for rejected request")
+	}
+}
+
+// stringerAddress mimics go-ethereum's common.Address.
+type stringerAddress string
+
+func (a stringerAddress) String() string { return string(a) }
+
+type addressSigner struct{ network string }
+
+func (addressSigner) Scheme() string                                              { return "exact" }
+func (addressSigner) CanSign(*x402.PaymentRequirement) bool                       { return true }
+func (addressSigner) GetPriority() int                                            { return 0 }
+func (addressSigner) GetTokens() []x402.TokenConfig                               { return nil }
+func (addressSigner) GetMaxAmount() *big.Int                                      { return nil }
+func (addressSigner) Sign(*x402.PaymentRequirement) (*x402.PaymentPayload, error) { return nil, nil }
+func (s addressSigner) Network() string                                           { return s.network }
+
+type evmSigner struct{ addressSigner }
+
+func (evmSigner) Address() stringerAddress { return evmAddress }
+
+type svmSigner struct{ addressSigner }
+
+func (svmSigner) Address() string { return solanaAddress }
+
+func TestFaucet_FundSigner(t *testing.T) {
+	var funded []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req dripRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		funded = append(funded, req.Address)
+	}))
+	defer server.Close()
+
+	f, _ := New(WithEndpoint(server.URL))
+	signers := []x402.Signer{
+		evmSigner{addressSigner{network: "base-sepolia"}},
+		svmSigner{addressSigner{network: "solana-devnet"}},
+	}
+	for _, signer := range signers {
+		if _, err := f.FundSigner(context.Background(), signer); err != nil {
+			t.Fatalf("FundSigner(%s): %v", signer.Network(), err)
+		}
+	}
+	if len(funded) != 2 || funded[0] != evmAddress || funded[1] != solanaAddress {
+		t.Errorf("funded %v, want %s and %s", funded, evmAddress, solanaAddress)
+	}
+
+	if _, err := f.FundSigner(context.Background(), addressSigner{network: "base-sepolia"}); !errors.Is(err, ErrNoAddress) {
+		t.Errorf("expected ErrNoAddress, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package evm provides a finality.Chain for EVM networks, which treats a transaction as
+// final once it has a number of confirmations.
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mark3labs/x402-go/finality"
+)
+
+// DefaultConfirmations is the number of confirmations after which a transaction is
+// final by default.
+const DefaultConfirmations = 12
+
+// Chain reports transaction state from an EVM JSON-RPC endpoint.
+type Chain struct {
+	client        *ethclient.Client
+	confirmations uint64
+}
+
+// ChainOption configures a Chain.
+type ChainOption func(*Chain) error
+
+// NewChain creates a Chain for the JSON-RPC endpoint at rpcURL.
+func NewChain(rpcURL string, opts ...ChainOption) (*Chain, error) {
+	c := &Chain{confirmations: DefaultConfirmations}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("evm: failed to connect to RPC: %w", err)
+	}
+	c.client = client
+	return c, nil
+}
+
+// WithConfirmations sets the number of confirmations after which a transaction is
+// final (default DefaultConfirmations).
+func WithConfirmations(n uint64) ChainOption {
+	return func(c *Chain) error {
+		if n == 0 {
+			return errors.New("evm: confirmations must be positive")
+		}
+		c.confirmations = n
+		return nil
+	}
+}
+
+// TxState implements finality.Chain.
+func (c *Chain) TxState(ctx context.Context, transaction string) (finality.TxState, error) {
+	receipt, err := c.client.TransactionReceipt(ctx, common.HexToHash(transaction))
+	if errors.Is(err, ethereum.NotFound) {
+		return finality.TxState{}, nil
+	}
+	if err != nil {
+		return finality.TxState{}, fmt.Errorf("evm: failed to get receipt: %w", err)
+	}
+	head, err := c.client.BlockNumber(ctx)
+	if err != nil {
+		return finality.TxState{}, fmt.Errorf("evm: failed to get block number: %w", err)
+	}
+
+	// The node may serve the receipt before its head advances to the block
+	var confirmations uint64 = 1
+	if block := receipt.BlockNumber.Uint64(); head >= block {
+		confirmations = head - block + 1
+	}
+	return finality.TxState{
+		Found:         true,
+		Confirmations: confirmations,
+		Final:         confirmations >= c.confirmations,
+		Failed:        receipt.Status == types.ReceiptStatusFailed,
+	}, nil
+}
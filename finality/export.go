@@ -0,0 +1,75 @@
+package finality
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ExportCSV writes settlements as CSV with a header row, one row per settlement. Each
+// currency found in the settlements' FiatValues gets a "value_<currency>" column.
+func ExportCSV(w io.Writer, settlements []Settlement) error {
+	currencySet := make(map[string]bool)
+	for _, settlement := range settlements {
+		for currency := range settlement.FiatValues {
+			currencySet[currency] = true
+		}
+	}
+	currencies := make([]string, 0, len(currencySet))
+	for currency := range currencySet {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	header := []string{"settled_at", "transaction", "network", "payer", "pay_to", "asset", "amount", "status", "confirmations", "reference"}
+	for _, currency := range currencies {
+		header = append(header, "value_"+currency)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("finality: failed to write CSV: %w", err)
+	}
+	for _, settlement := range settlements {
+		row := []string{
+			settlement.SettledAt.UTC().Format(time.RFC3339),
+			settlement.Transaction,
+			settlement.Network,
+			settlement.Payer,
+			settlement.Requirement.PayTo,
+			settlement.Requirement.Asset,
+			settlement.Requirement.MaxAmountRequired,
+			string(settlement.Status),
+			strconv.FormatUint(settlement.Confirmations, 10),
+			settlement.Reference,
+		}
+		for _, currency := range currencies {
+			row = append(row, settlement.FiatValues[currency])
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("finality: failed to write CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("finality: failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// ExportJSON writes settlements as an indented JSON array.
+func ExportJSON(w io.Writer, settlements []Settlement) error {
+	if settlements == nil {
+		settlements = []Settlement{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(settlements); err != nil {
+		return fmt.Errorf("finality: failed to write JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package finality
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestTracker_FiatValues(t *testing.T) {
+	ctx := context.Background()
+	ledger := NewMemoryLedger()
+	tracker, err := NewTracker(ledger, WithFiatValues(x402.FixedRates{"USD": "1", "EUR": "0.92"}, "USD", "EUR", "GBP"))
+	if err != nil {
+		t.Fatalf("NewTracker: %v", err)
+	}
+
+	requirement := x402.PaymentRequirement{Network: "base", Asset: x402.BaseMainnet.USDCAddress, MaxAmountRequired: "1500000"}
+	requirement.SetReference("order-7")
+	if err := tracker.Track(ctx, x402.PaymentPayload{}, requirement, settled("0xtx")); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+
+	got, _, _ := ledger.Get(ctx, "0xtx")
+	if len(got.FiatValues) != 2 || got.FiatValues["USD"] != "1.500000" || got.FiatValues["EUR"] != "1.380000" {
+		t.Errorf("FiatValues = %v, want USD 1.500000 and EUR 1.380000", got.FiatValues)
+	}
+	if got.Reference != "order-7" {
+		t.Errorf("Reference = %q, want the requirement's order-7", got.Reference)
+	}
+}
+
+func TestExport(t *testing.T) {
+	settledAt := time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC)
+	settlements := []Settlement{
+		{
+			Transaction: "0xtx1",
+			Network:     "base",
+			Payer:       "0xpayer",
+			Requirement: x402.PaymentRequirement{PayTo: "0xmerchant", Asset: x402.BaseMainnet.USDCAddress, MaxAmountRequired: "1500000"},
+			Status:      StatusConfirmed,
+			Reference:   "order-7",
+			FiatValues:  map[string]string{"USD": "1.500000", "EUR": "1.380000"},
+			SettledAt:   settledAt,
+		},
+		{
+			Transaction: "0xtx2",
+			Network:     "base",
+			Status:      StatusPending,
+			SettledAt:   settledAt.Add(time.Minute),
+		},
+	}
+
+	var csvOut bytes.Buffer
+	if err := ExportCSV(&csvOut, settlements); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	wantLines := []string{
+		"settled_at,transaction,network,payer,pay_to,asset,amount,status,confirmations,reference,value_EUR,value_USD",
+		"2025-11-03T12:00:00Z,0xtx1,base,0xpayer,0xmerchant," + x402.BaseMainnet.USDCAddress + ",1500000,confirmed,0,order-7,1.380000,1.500000",
+		"2025-11-03T12:01:00Z,0xtx2,base,,,,,pending,0,,,",
+	}
+	if len(lines) != len(wantLines) {
+		t.Fatalf("CSV has %d lines, want %d:\n%s", len(lines), len(wantLines), csvOut.String())
+	}
+	for i := range wantLines {
+		if lines[i] != wantLines[i] {
+			t.Errorf("CSV line %d = %q, want %q", i, lines[i], wantLines[i])
+		}
+	}
+
+	var jsonOut bytes.Buffer
+	if err := ExportJSON(&jsonOut, settlements); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+	var decoded []Settlement
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON export: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0].FiatValues["USD"] != "1.500000" || decoded[1].Status != StatusPending {
+		t.Errorf("JSON export round-tripped to %+v", decoded)
+	}
+}
@@ -0,0 +1,492 @@
+// Package finality tracks settled payments until they are final on chain. A facilitator
+// reports a settlement as soon as its transaction is accepted, but the transaction can
+// still be dropped by a chain reorganization. A Tracker records each settlement in a
+// Ledger as pending, watches it until it is final (N confirmations on EVM chains, the
+// finalized commitment on Solana), and marks it confirmed, or reorged and then alerts
+// and optionally settles the payment again.
+//
+// Track settlements from the middleware's settle hook and run the tracker:
+//
+//	tracker, err := finality.NewTracker(finality.NewMemoryLedger(),
+//	    finality.WithChain("base", evmChain),
+//	    finality.WithAlert(func(ctx context.Context, s finality.Settlement) { ... }),
+//	)
+//	config.FacilitatorOnAfterSettle = tracker.OnAfterSettle
+//	go tracker.Run(ctx)
+//
+// Chains for EVM networks and Solana are in the finality/evm and finality/svm packages.
+//
+// With WithFiatValues, settlements also carry their fiat value at settlement time, and
+// ExportCSV and ExportJSON write them out for tax and revenue accounting. Report and
+// ReportHandler aggregate revenue by day, payer or resource.
+package finality
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+const (
+	// DefaultInterval is how often Run checks pending settlements by default.
+	DefaultInterval = 15 * time.Second
+
+	// DefaultTimeout is how long a settlement transaction may stay unseen on chain
+	// before it is considered dropped, by default.
+	DefaultTimeout = 10 * time.Minute
+)
+
+// ErrNoChain indicates no Chain is configured for a settlement's network.
+var ErrNoChain = errors.New("x402: no finality chain for network")
+
+// Status is the finality status of a settlement.
+type Status string
+
+const (
+	// StatusPending means the transaction is not final yet.
+	StatusPending Status = "pending"
+
+	// StatusConfirmed means the transaction is final.
+	StatusConfirmed Status = "confirmed"
+
+	// StatusReorged means the transaction was dropped from the chain, or never
+	// appeared on it within the timeout.
+	StatusReorged Status = "reorged"
+
+	// StatusFailed means the transaction was included but reverted.
+	StatusFailed Status = "failed"
+)
+
+// Settlement is a settled payment tracked until it is final.
+type Settlement struct {
+	Transaction string                  `json:"transaction"`
+	Network     string                  `json:"network"`
+	Payer       string                  `json:"payer"`
+	Payment     x402.PaymentPayload     `json:"payment"`
+	Requirement x402.PaymentRequirement `json:"requirement"`
+	Status      Status                  `json:"status"`
+
+	// Confirmations is the number of confirmations last observed.
+	Confirmations uint64 `json:"confirmations"`
+
+	// Replaces is the transaction of the reorged settlement this one re-settled.
+	Replaces string `json:"replaces,omitempty"`
+
+	// ResettledAs is the transaction that re-settled this payment after a reorg.
+	ResettledAs string `json:"resettledAs,omitempty"`
+
+	// Reference is the reference of the requirement paid, such as an order ID, to
+	// reconcile the settlement with. See x402.ExtraReference.
+	Reference string `json:"reference,omitempty"`
+
+	// FiatValues is the value of the payment at settlement time by currency code, e.g.
+	// {"USD": "1.500000"}, when the Tracker has a PriceOracle (see WithFiatValues).
+	FiatValues map[string]string `json:"fiatValues,omitempty"`
+
+	SettledAt time.Time `json:"settledAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TxState is the on-chain state of a transaction, as reported by a Chain.
+type TxState struct {
+	// Found reports whether the transaction is in the canonical chain.
+	Found bool
+
+	// Confirmations is the number of blocks confirming the transaction, counting the
+	// block that includes it, so a found transaction has at least one.
+	Confirmations uint64
+
+	// Final reports whether the transaction has reached the chain's finality
+	// requirement and can no longer be reorged.
+	Final bool
+
+	// Failed reports whether the transaction was included but reverted.
+	Failed bool
+}
+
+// Chain reports the state of transactions on a network.
+type Chain interface {
+	TxState(ctx context.Context, transaction string) (TxState, error)
+}
+
+// Ledger stores tracked settlements. Implementations must be safe for concurrent use.
+type Ledger interface {
+	// Save inserts or updates the settlement with the same Transaction.
+	Save(ctx context.Context, settlement Settlement) error
+
+	// Get returns the settlement for transaction, or false if it is not tracked.
+	Get(ctx context.Context, transaction string) (Settlement, bool, error)
+
+	// Pending returns the settlements with StatusPending.
+	Pending(ctx context.Context) ([]Settlement, error)
+}
+
+// ResettleFunc settles a reorged payment again, e.g. with http.FacilitatorClient.Settle.
+// It is only useful while the payment authorization is still valid.
+type ResettleFunc func(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement) (*x402.SettlementResponse, error)
+
+// Tracker watches settlements until they are final. Tracker is safe for concurrent use.
+type Tracker struct {
+	ledger   Ledger
+	chains   map[string]Chain
+	interval time.Duration
+	timeout  time.Duration
+	alert    func(context.Context, Settlement)
+	resettle ResettleFunc
+	logger   *slog.Logger
+	now      func() time.Time
+
+	oracle     x402.PriceOracle
+	currencies []string
+
+	// checkMu serializes Check so a reorged payment is re-settled once
+	checkMu sync.Mutex
+}
+
+// TrackerOption configures a Tracker.
+type TrackerOption func(*Tracker) error
+
+// NewTracker creates a Tracker recording settlements in ledger.
+func NewTracker(ledger Ledger, opts ...TrackerOption) (*Tracker, error) {
+	if ledger == nil {
+		return nil, errors.New("finality: ledger is nil")
+	}
+	t := &Tracker{
+		ledger:   ledger,
+		chains:   make(map[string]Chain),
+		interval: DefaultInterval,
+		timeout:  DefaultTimeout,
+		logger:   slog.Default(),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// WithChain sets the Chain that reports transaction state for network.
+func WithChain(network string, chain Chain) TrackerOption {
+	return func(t *Tracker) error {
+		if chain == nil {
+			return fmt.Errorf("finality: chain for %s is nil", network)
+		}
+		t.chains[network] = chain
+		return nil
+	}
+}
+
+// WithInterval sets how often Run checks pending settlements (default DefaultInterval).
+func WithInterval(d time.Duration) TrackerOption {
+	return func(t *Tracker) error {
+		if d <= 0 {
+			return fmt.Errorf("finality: interval must be positive, got %v", d)
+		}
+		t.interval = d
+		return nil
+	}
+}
+
+// WithTimeout sets how long a settlement transaction may stay unseen on chain before
+// it is considered dropped (default DefaultTimeout).
+func WithTimeout(d time.Duration) TrackerOption {
+	return func(t *Tracker) error {
+		if d <= 0 {
+			return fmt.Errorf("finality: timeout must be positive, got %v", d)
+		}
+		t.timeout = d
+		return nil
+	}
+}
+
+// WithAlert sets a function called when a settlement is reorged or failed, after any
+// re-settlement was attempted.
+func WithAlert(alert func(ctx context.Context, settlement Settlement)) TrackerOption {
+	return func(t *Tracker) error {
+		t.alert = alert
+		return nil
+	}
+}
+
+// WithResettle sets a function that settles reorged payments again. The new settlement
+// is tracked in place of the reorged one.
+func WithResettle(resettle ResettleFunc) TrackerOption {
+	return func(t *Tracker) error {
+		t.resettle = resettle
+		return nil
+	}
+}
+
+// WithFiatValues stamps each tracked settlement with the payment's value in currencies
+// (e.g., "USD", "EUR") at settlement time, for tax and revenue accounting. Values are
+// stamped for the built-in USDC assets; a rate the oracle cannot provide is logged and
+// left out.
+func WithFiatValues(oracle x402.PriceOracle, currencies ...string) TrackerOption {
+	return func(t *Tracker) error {
+		if oracle == nil {
+			return errors.New("finality: price oracle is nil")
+		}
+		if len(currencies) == 0 {
+			return errors.New("finality: at least one currency is required")
+		}
+		t.oracle = oracle
+		t.currencies = currencies
+		return nil
+	}
+}
+
+// WithLogger sets the logger (default slog.Default()).
+func WithLogger(logger *slog.Logger) TrackerOption {
+	return func(t *Tracker) error {
+		if logger == nil {
+			return errors.New("finality: logger is nil")
+		}
+		t.logger = logger
+		return nil
+	}
+}
+
+// Track records a successful settlement as pending. Simulated and failed settlements
+// are ignored.
+func (t *Tracker) Track(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement, settlement *x402.SettlementResponse) error {
+	if settlement == nil || !settlement.Success || settlement.Simulated || settlement.Transaction == "" {
+		return nil
+	}
+	network := settlement.Network
+	if network == "" {
+		network = requirement.Network
+	}
+	reference := settlement.Reference
+	if reference == "" {
+		reference = requirement.Reference()
+	}
+	now := t.now()
+	return t.ledger.Save(ctx, Settlement{
+		Transaction: settlement.Transaction,
+		Network:     network,
+		Payer:       settlement.Payer,
+		Payment:     payment,
+		Requirement: requirement,
+		Reference:   reference,
+		Status:      StatusPending,
+		FiatValues:  t.fiatValues(ctx, network, requirement),
+		SettledAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+// fiatValues returns the value of requirement's amount in the configured currencies,
+// or nil if no PriceOracle is configured.
+func (t *Tracker) fiatValues(ctx context.Context, network string, requirement x402.PaymentRequirement) map[string]string {
+	if t.oracle == nil {
+		return nil
+	}
+	chain, ok := x402.LookupUSDC(network, requirement.Asset)
+	if !ok {
+		t.logger.Warn("no fiat value for unknown asset", "network", network, "asset", requirement.Asset)
+		return nil
+	}
+	amount, err := x402.ParseAtomicAmount(requirement.MaxAmountRequired, int(chain.Decimals))
+	if err != nil {
+		t.logger.Warn("no fiat value for invalid amount", "amount", requirement.MaxAmountRequired, "error", err)
+		return nil
+	}
+
+	values := make(map[string]string, len(t.currencies))
+	for _, currency := range t.currencies {
+		value, err := x402.FiatValue(ctx, t.oracle, network, requirement.Asset, amount, currency)
+		if err != nil {
+			t.logger.Warn("failed to get fiat value", "currency", currency, "error", err)
+			continue
+		}
+		values[currency] = value
+	}
+	return values
+}
+
+// OnAfterSettle tracks settlements reported by a facilitator client. It matches
+// http.OnAfterSettleFunc, so it can be set as Config.FacilitatorOnAfterSettle.
+func (t *Tracker) OnAfterSettle(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement, settlement *x402.SettlementResponse, err error) {
+	if err != nil {
+		return
+	}
+	if err := t.Track(context.WithoutCancel(ctx), payment, requirement, settlement); err != nil {
+		t.logger.Error("failed to track settlement", "transaction", settlement.Transaction, "error", err)
+	}
+}
+
+// Run checks pending settlements every interval until ctx is done, and returns ctx.Err().
+func (t *Tracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := t.Check(ctx); err != nil && ctx.Err() == nil {
+				t.logger.Error("finality check failed", "error", err)
+			}
+		}
+	}
+}
+
+// Check updates every pending settlement from its chain once. Errors for individual
+// settlements are joined; the remaining settlements are still checked.
+func (t *Tracker) Check(ctx context.Context) error {
+	t.checkMu.Lock()
+	defer t.checkMu.Unlock()
+
+	pending, err := t.ledger.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("finality: failed to list pending settlements: %w", err)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].SettledAt.Before(pending[j].SettledAt) })
+
+	var errs []error
+	for _, settlement := range pending {
+		if err := t.check(ctx, settlement); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", settlement.Transaction, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check updates a single pending settlement.
+func (t *Tracker) check(ctx context.Context, settlement Settlement) error {
+	chain, ok := t.chains[settlement.Network]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoChain, settlement.Network)
+	}
+	state, err := chain.TxState(ctx, settlement.Transaction)
+	if err != nil {
+		return err
+	}
+
+	now := t.now()
+	switch {
+	case state.Found && state.Failed:
+		settlement.Status = StatusFailed
+	case state.Found && state.Final:
+		settlement.Status = StatusConfirmed
+	case state.Found:
+		// Still waiting for finality
+	case settlement.Confirmations > 0 || now.Sub(settlement.SettledAt) > t.timeout:
+		// Seen before and gone now, or never included
+		settlement.Status = StatusReorged
+	default:
+		// Not yet visible to the RPC node
+		return nil
+	}
+	if state.Found {
+		settlement.Confirmations = state.Confirmations
+	}
+	settlement.UpdatedAt = now
+
+	switch settlement.Status {
+	case StatusConfirmed:
+		t.logger.Info("settlement confirmed", "transaction", settlement.Transaction, "network", settlement.Network)
+	case StatusReorged:
+		t.logger.Warn("settlement reorged", "transaction", settlement.Transaction, "network", settlement.Network)
+		t.resettleReorged(ctx, &settlement)
+	case StatusFailed:
+		t.logger.Warn("settlement transaction failed", "transaction", settlement.Transaction, "network", settlement.Network)
+	}
+
+	if err := t.ledger.Save(ctx, settlement); err != nil {
+		return fmt.Errorf("failed to save settlement: %w", err)
+	}
+	if (settlement.Status == StatusReorged || settlement.Status == StatusFailed) && t.alert != nil {
+		t.alert(ctx, settlement)
+	}
+	return nil
+}
+
+// resettleReorged settles a reorged payment again, if configured, and tracks the new
+// settlement.
+func (t *Tracker) resettleReorged(ctx context.Context, settlement *Settlement) {
+	if t.resettle == nil {
+		return
+	}
+	resp, err := t.resettle(ctx, settlement.Payment, settlement.Requirement)
+	if err != nil || resp == nil || !resp.Success || resp.Transaction == "" {
+		t.logger.Error("re-settlement failed", "transaction", settlement.Transaction, "error", err)
+		return
+	}
+
+	now := t.now()
+	replacement := *settlement
+	replacement.Transaction = resp.Transaction
+	replacement.Status = StatusPending
+	replacement.Confirmations = 0
+	replacement.Replaces = settlement.Transaction
+	replacement.SettledAt = now
+	replacement.UpdatedAt = now
+	if err := t.ledger.Save(ctx, replacement); err != nil {
+		t.logger.Error("failed to track re-settlement", "transaction", resp.Transaction, "error", err)
+		return
+	}
+	settlement.ResettledAs = resp.Transaction
+	t.logger.Info("payment re-settled", "transaction", resp.Transaction, "replaces", settlement.Transaction)
+}
+
+// MemoryLedger is an in-memory Ledger, for tests and single-instance deployments.
+type MemoryLedger struct {
+	mu          sync.Mutex
+	settlements map[string]Settlement
+}
+
+// NewMemoryLedger creates an empty MemoryLedger.
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{settlements: make(map[string]Settlement)}
+}
+
+// Save implements Ledger.
+func (l *MemoryLedger) Save(_ context.Context, settlement Settlement) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.settlements[settlement.Transaction] = settlement
+	return nil
+}
+
+// Get implements Ledger.
+func (l *MemoryLedger) Get(_ context.Context, transaction string) (Settlement, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	settlement, ok := l.settlements[transaction]
+	return settlement, ok, nil
+}
+
+// Settlements returns every settlement in the ledger, oldest first, e.g. for export.
+func (l *MemoryLedger) Settlements(_ context.Context) ([]Settlement, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	settlements := make([]Settlement, 0, len(l.settlements))
+	for _, settlement := range l.settlements {
+		settlements = append(settlements, settlement)
+	}
+	sort.Slice(settlements, func(i, j int) bool { return settlements[i].SettledAt.Before(settlements[j].SettledAt) })
+	return settlements, nil
+}
+
+// Pending implements Ledger.
+func (l *MemoryLedger) Pending(_ context.Context) ([]Settlement, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var pending []Settlement
+	for _, settlement := range l.settlements {
+		if settlement.Status == StatusPending {
+			pending = append(pending, settlement)
+		}
+	}
+	return pending, nil
+}
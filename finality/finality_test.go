@@ -0,0 +1,143 @@
+package finality
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// fakeChain reports configured transaction states.
+type fakeChain struct {
+	mu     sync.Mutex
+	states map[string]TxState
+}
+
+func (c *fakeChain) set(transaction string, state TxState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[transaction] = state
+}
+
+func (c *fakeChain) TxState(_ context.Context, transaction string) (TxState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.states[transaction], nil
+}
+
+func settled(transaction string) *x402.SettlementResponse {
+	return &x402.SettlementResponse{Success: true, Transaction: transaction, Network: "base", Payer: "0xpayer"}
+}
+
+func TestTracker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		states     []TxState // observed on successive checks
+		age        time.Duration
+		wantStatus Status
+		wantAlert  bool
+	}{
+		{name: "not final yet", states: []TxState{{Found: true, Confirmations: 2}}, wantStatus: StatusPending},
+		{name: "final", states: []TxState{{Found: true, Confirmations: 2}, {Found: true, Confirmations: 12, Final: true}}, wantStatus: StatusConfirmed},
+		{name: "reverted", states: []TxState{{Found: true, Confirmations: 1, Failed: true}}, wantStatus: StatusFailed, wantAlert: true},
+		{name: "reorged", states: []TxState{{Found: true, Confirmations: 3}, {}}, wantStatus: StatusReorged, wantAlert: true},
+		{name: "not yet visible", states: []TxState{{}}, wantStatus: StatusPending},
+		{name: "never included", states: []TxState{{}}, age: DefaultTimeout + time.Second, wantStatus: StatusReorged, wantAlert: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			chain := &fakeChain{states: make(map[string]TxState)}
+			ledger := NewMemoryLedger()
+			var alerts []Settlement
+			tracker, err := NewTracker(ledger,
+				WithChain("base", chain),
+				WithAlert(func(_ context.Context, s Settlement) { alerts = append(alerts, s) }),
+			)
+			if err != nil {
+				t.Fatalf("NewTracker: %v", err)
+			}
+
+			start := time.Now()
+			tracker.now = func() time.Time { return start }
+			if err := tracker.Track(ctx, x402.PaymentPayload{}, x402.PaymentRequirement{}, settled("0xtx")); err != nil {
+				t.Fatalf("Track: %v", err)
+			}
+			tracker.now = func() time.Time { return start.Add(tt.age) }
+
+			for _, state := range tt.states {
+				chain.set("0xtx", state)
+				if err := tracker.Check(ctx); err != nil {
+					t.Fatalf("Check: %v", err)
+				}
+			}
+
+			got, ok, _ := ledger.Get(ctx, "0xtx")
+			if !ok || got.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if (len(alerts) == 1) != tt.wantAlert {
+				t.Errorf("alerts = %+v, want alert %v", alerts, tt.wantAlert)
+			}
+		})
+	}
+}
+
+func TestTracker_Resettle(t *testing.T) {
+	ctx := context.Background()
+	chain := &fakeChain{states: map[string]TxState{"0xtx": {Found: true, Confirmations: 1}}}
+	ledger := NewMemoryLedger()
+	payment := x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base"}
+
+	var resettled x402.PaymentPayload
+	tracker, _ := NewTracker(ledger,
+		WithChain("base", chain),
+		WithResettle(func(_ context.Context, p x402.PaymentPayload, _ x402.PaymentRequirement) (*x402.SettlementResponse, error) {
+			resettled = p
+			return settled("0xtx2"), nil
+		}),
+	)
+	_ = tracker.Track(ctx, payment, x402.PaymentRequirement{Network: "base"}, settled("0xtx"))
+	_ = tracker.Check(ctx)
+
+	chain.set("0xtx", TxState{})
+	if err := tracker.Check(ctx); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	reorged, _, _ := ledger.Get(ctx, "0xtx")
+	replacement, ok, _ := ledger.Get(ctx, "0xtx2")
+	if reorged.Status != StatusReorged || reorged.ResettledAs != "0xtx2" {
+		t.Errorf("reorged settlement = %+v", reorged)
+	}
+	if !ok || replacement.Status != StatusPending || replacement.Replaces != "0xtx" {
+		t.Errorf("replacement settlement = %+v", replacement)
+	}
+	if resettled.Network != payment.Network {
+		t.Errorf("re-settled payment %+v, want %+v", resettled, payment)
+	}
+}
+
+func TestTracker_Track(t *testing.T) {
+	ctx := context.Background()
+	ledger := NewMemoryLedger()
+	tracker, _ := NewTracker(ledger)
+
+	tracker.OnAfterSettle(ctx, x402.PaymentPayload{}, x402.PaymentRequirement{}, &x402.SettlementResponse{Success: false, Transaction: "0xfailed"}, nil)
+	tracker.OnAfterSettle(ctx, x402.PaymentPayload{}, x402.PaymentRequirement{}, &x402.SettlementResponse{Success: true, Transaction: "0xsim", Simulated: true}, nil)
+	tracker.OnAfterSettle(ctx, x402.PaymentPayload{}, x402.PaymentRequirement{}, nil, errors.New("facilitator down"))
+	tracker.OnAfterSettle(ctx, x402.PaymentPayload{}, x402.PaymentRequirement{Network: "base"}, &x402.SettlementResponse{Success: true, Transaction: "0xtx"}, nil)
+
+	pending, _ := ledger.Pending(ctx)
+	if len(pending) != 1 || pending[0].Transaction != "0xtx" || pending[0].Network != "base" {
+		t.Errorf("pending = %+v, want only 0xtx on base", pending)
+	}
+
+	if err := tracker.Check(ctx); !errors.Is(err, ErrNoChain) {
+		t.Errorf("Check() error = %v, want ErrNoChain", err)
+	}
+}
@@ -0,0 +1,277 @@
+package finality
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// Report groupings for ReportOptions.GroupBy.
+const (
+	GroupByDay      = "day"
+	GroupByPayer    = "payer"
+	GroupByResource = "resource"
+)
+
+// ErrInvalidReport indicates invalid ReportOptions.
+var ErrInvalidReport = errors.New("x402: invalid report options")
+
+// SettlementLister lists every settlement in a ledger. MemoryLedger implements it.
+type SettlementLister interface {
+	Settlements(ctx context.Context) ([]Settlement, error)
+}
+
+// TimeRange selects settlements by settlement time. From is inclusive and To exclusive;
+// a zero bound is open.
+type TimeRange struct {
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+// Contains reports whether t is within the range.
+func (r TimeRange) Contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || t.Before(r.To))
+}
+
+// ReportOptions configures a revenue report.
+type ReportOptions struct {
+	// GroupBy is GroupByDay (UTC), GroupByPayer or GroupByResource.
+	GroupBy string
+
+	// Range selects the settlements to report on (default: all).
+	Range TimeRange
+
+	// ConfirmedOnly leaves out settlements that are not final yet.
+	ConfirmedOnly bool
+}
+
+// ReportGroup aggregates the settlements of one group.
+type ReportGroup struct {
+	// Key is the day (YYYY-MM-DD), payer or resource of the group, or "total".
+	Key string `json:"key"`
+
+	// Calls is the number of paid requests.
+	Calls int `json:"calls"`
+
+	// Revenue and AveragePrice are human-readable USDC amounts (e.g., "1.5").
+	Revenue      string `json:"revenue"`
+	AveragePrice string `json:"averagePrice"`
+
+	// FiatRevenue sums the settlements' fiat values by currency.
+	FiatRevenue map[string]string `json:"fiatRevenue,omitempty"`
+
+	revenue *big.Int
+	fiat    map[string]*big.Rat
+}
+
+// RevenueReport is an aggregate revenue report.
+type RevenueReport struct {
+	GroupBy string        `json:"groupBy"`
+	Range   TimeRange     `json:"range"`
+	Groups  []ReportGroup `json:"groups"`
+	Total   ReportGroup   `json:"total"`
+
+	// Skipped counts settlements in a token other than the built-in USDC assets, which
+	// are left out of the report.
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// Report aggregates revenue, call counts and average price of the settlements in
+// ledger. Reorged and failed settlements are not revenue and are left out. Groups by
+// day are in date order; other groups are by revenue, highest first.
+func Report(ctx context.Context, ledger SettlementLister, opts ReportOptions) (*RevenueReport, error) {
+	key, err := groupKey(opts.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	settlements, err := ledger.Settlements(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finality: failed to list settlements: %w", err)
+	}
+
+	report := &RevenueReport{GroupBy: opts.GroupBy, Range: opts.Range, Total: newReportGroup("total")}
+	groups := make(map[string]*ReportGroup)
+	for _, settlement := range settlements {
+		switch {
+		case settlement.Status == StatusReorged || settlement.Status == StatusFailed:
+			continue
+		case opts.ConfirmedOnly && settlement.Status != StatusConfirmed:
+			continue
+		case !opts.Range.Contains(settlement.SettledAt):
+			continue
+		}
+		if _, ok := x402.LookupUSDC(settlement.Network, settlement.Requirement.Asset); !ok {
+			report.Skipped++
+			continue
+		}
+		amount, ok := new(big.Int).SetString(settlement.Requirement.MaxAmountRequired, 10)
+		if !ok {
+			report.Skipped++
+			continue
+		}
+
+		k := key(settlement)
+		group, ok := groups[k]
+		if !ok {
+			g := newReportGroup(k)
+			group = &g
+			groups[k] = group
+		}
+		group.add(amount, settlement.FiatValues)
+		report.Total.add(amount, settlement.FiatValues)
+	}
+
+	for _, group := range groups {
+		group.finish()
+		report.Groups = append(report.Groups, *group)
+	}
+	report.Total.finish()
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if opts.GroupBy != GroupByDay {
+			if c := a.revenue.Cmp(b.revenue); c != 0 {
+				return c > 0
+			}
+		}
+		return a.Key < b.Key
+	})
+	return report, nil
+}
+
+// Report aggregates the ledger's settlements; see Report.
+func (l *MemoryLedger) Report(ctx context.Context, opts ReportOptions) (*RevenueReport, error) {
+	return Report(ctx, l, opts)
+}
+
+// groupKey returns the function computing a settlement's group for groupBy.
+func groupKey(groupBy string) (func(Settlement) string, error) {
+	switch groupBy {
+	case GroupByDay:
+		return func(s Settlement) string { return s.SettledAt.UTC().Format(time.DateOnly) }, nil
+	case GroupByPayer:
+		return func(s Settlement) string { return s.Payer }, nil
+	case GroupByResource:
+		return func(s Settlement) string { return s.Requirement.Resource }, nil
+	default:
+		return nil, fmt.Errorf("%w: grouping %q (expected %s, %s or %s)", ErrInvalidReport, groupBy, GroupByDay, GroupByPayer, GroupByResource)
+	}
+}
+
+func newReportGroup(key string) ReportGroup {
+	return ReportGroup{Key: key, revenue: new(big.Int), fiat: make(map[string]*big.Rat)}
+}
+
+// add counts one settlement of amount atomic USDC units.
+func (g *ReportGroup) add(amount *big.Int, fiatValues map[string]string) {
+	g.Calls++
+	g.revenue.Add(g.revenue, amount)
+	for currency, value := range fiatValues {
+		v, ok := new(big.Rat).SetString(value)
+		if !ok {
+			continue
+		}
+		if g.fiat[currency] == nil {
+			g.fiat[currency] = new(big.Rat)
+		}
+		g.fiat[currency].Add(g.fiat[currency], v)
+	}
+}
+
+// finish fills in the exported fields from the running totals.
+func (g *ReportGroup) finish() {
+	// All built-in USDC assets have 6 decimals
+	g.Revenue = x402.NewAtomicAmount(g.revenue, 6).String()
+	average := new(big.Int)
+	if g.Calls > 0 {
+		average.Quo(g.revenue, big.NewInt(int64(g.Calls)))
+	}
+	g.AveragePrice = x402.NewAtomicAmount(average, 6).String()
+	if len(g.fiat) > 0 {
+		g.FiatRevenue = make(map[string]string, len(g.fiat))
+		for currency, value := range g.fiat {
+			g.FiatRevenue[currency] = value.FloatString(6)
+		}
+	}
+}
+
+// ReportHandler returns an HTTP handler serving revenue reports of ledger as JSON. It is
+// meant for operators, not payers, so every request must pass authorize (see
+// BearerAuth); a nil authorize rejects all requests.
+//
+// Query parameters: group_by (day, payer or resource; default day), from and to
+// (RFC 3339 times or YYYY-MM-DD dates) and confirmed_only (true or false).
+func ReportHandler(ledger SettlementLister, authorize func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorize == nil || !authorize(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		opts := ReportOptions{
+			GroupBy:       query.Get("group_by"),
+			ConfirmedOnly: query.Get("confirmed_only") == "true",
+		}
+		if opts.GroupBy == "" {
+			opts.GroupBy = GroupByDay
+		}
+		var err error
+		if opts.Range.From, err = parseReportTime(query.Get("from")); err != nil {
+			http.Error(w, "invalid from: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Range.To, err = parseReportTime(query.Get("to")); err != nil {
+			http.Error(w, "invalid to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		report, err := Report(r.Context(), ledger, opts)
+		if err != nil {
+			if errors.Is(err, ErrInvalidReport) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "failed to build report", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// BearerAuth returns an authorize function for ReportHandler accepting requests with
+// "Authorization: Bearer <token>". Tokens are compared in constant time; an empty token
+// rejects all requests.
+func BearerAuth(token string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return ok && token != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+	}
+}
+
+// parseReportTime parses an RFC 3339 time or a YYYY-MM-DD date (midnight UTC).
+// An empty string is the zero time.
+func parseReportTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,137 @@
+package finality
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// reportLedger returns a ledger with settlements over two days.
+func reportLedger(t *testing.T) *MemoryLedger {
+	t.Helper()
+	day := time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)
+	usdc := x402.BaseMainnet.USDCAddress
+	ledger := NewMemoryLedger()
+	for _, s := range []Settlement{
+		{Transaction: "0x1", Payer: "0xalice", Status: StatusConfirmed, SettledAt: day, Requirement: x402.PaymentRequirement{Resource: "/a", Asset: usdc, MaxAmountRequired: "1000000"}, FiatValues: map[string]string{"USD": "1.000000"}},
+		{Transaction: "0x2", Payer: "0xalice", Status: StatusPending, SettledAt: day.Add(time.Hour), Requirement: x402.PaymentRequirement{Resource: "/b", Asset: usdc, MaxAmountRequired: "500000"}, FiatValues: map[string]string{"USD": "0.500000"}},
+		{Transaction: "0x3", Payer: "0xbob", Status: StatusConfirmed, SettledAt: day.Add(24 * time.Hour), Requirement: x402.PaymentRequirement{Resource: "/a", Asset: usdc, MaxAmountRequired: "2000000"}},
+		{Transaction: "0x4", Payer: "0xbob", Status: StatusReorged, SettledAt: day, Requirement: x402.PaymentRequirement{Resource: "/a", Asset: usdc, MaxAmountRequired: "9000000"}},
+		{Transaction: "0x5", Payer: "0xbob", Status: StatusConfirmed, SettledAt: day, Requirement: x402.PaymentRequirement{Resource: "/a", Asset: "0xother", MaxAmountRequired: "1"}},
+	} {
+		s.Network = "base"
+		_ = ledger.Save(context.Background(), s)
+	}
+	return ledger
+}
+
+func TestReport(t *testing.T) {
+	ledger := reportLedger(t)
+
+	tests := []struct {
+		name      string
+		opts      ReportOptions
+		wantKeys  []string
+		wantCalls []int
+		wantTotal string
+	}{
+		{name: "by day", opts: ReportOptions{GroupBy: GroupByDay}, wantKeys: []string{"2025-11-03", "2025-11-04"}, wantCalls: []int{2, 1}, wantTotal: "3.5"},
+		{name: "by payer", opts: ReportOptions{GroupBy: GroupByPayer}, wantKeys: []string{"0xbob", "0xalice"}, wantCalls: []int{1, 2}, wantTotal: "3.5"},
+		{name: "by resource", opts: ReportOptions{GroupBy: GroupByResource}, wantKeys: []string{"/a", "/b"}, wantCalls: []int{2, 1}, wantTotal: "3.5"},
+		{name: "confirmed only", opts: ReportOptions{GroupBy: GroupByPayer, ConfirmedOnly: true}, wantKeys: []string{"0xbob", "0xalice"}, wantCalls: []int{1, 1}, wantTotal: "3"},
+		{
+			name:      "range",
+			opts:      ReportOptions{GroupBy: GroupByDay, Range: TimeRange{From: time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC)}},
+			wantKeys:  []string{"2025-11-04"},
+			wantCalls: []int{1},
+			wantTotal: "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ledger.Report(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Report: %v", err)
+			}
+			if len(report.Groups) != len(tt.wantKeys) {
+				t.Fatalf("groups = %+v, want keys %v", report.Groups, tt.wantKeys)
+			}
+			for i, group := range report.Groups {
+				if group.Key != tt.wantKeys[i] || group.Calls != tt.wantCalls[i] {
+					t.Errorf("group %d = %s with %d calls, want %s with %d", i, group.Key, group.Calls, tt.wantKeys[i], tt.wantCalls[i])
+				}
+			}
+			if report.Total.Revenue != tt.wantTotal {
+				t.Errorf("total revenue = %s, want %s", report.Total.Revenue, tt.wantTotal)
+			}
+		})
+	}
+
+	report, _ := ledger.Report(context.Background(), ReportOptions{GroupBy: GroupByPayer})
+	alice := report.Groups[1]
+	if alice.Revenue != "1.5" || alice.AveragePrice != "0.75" || alice.FiatRevenue["USD"] != "1.500000" {
+		t.Errorf("alice = %+v, want revenue 1.5, average 0.75 and USD 1.500000", alice)
+	}
+	if report.Skipped != 1 {
+		t.Errorf("skipped = %d, want 1", report.Skipped)
+	}
+
+	if _, err := ledger.Report(context.Background(), ReportOptions{GroupBy: "week"}); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("expected ErrInvalidReport, got %v", err)
+	}
+}
+
+func TestReportHandler(t *testing.T) {
+	handler := ReportHandler(reportLedger(t), BearerAuth("secret"))
+
+	tests := []struct {
+		name       string
+		target     string
+		token      string
+		wantStatus int
+	}{
+		{name: "default grouping", target: "/report", token: "secret", wantStatus: http.StatusOK},
+		{name: "grouping and range", target: "/report?group_by=payer&from=2025-11-03&to=2025-11-04T00:00:00Z", token: "secret", wantStatus: http.StatusOK},
+		{name: "missing token", target: "/report", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", target: "/report", token: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "invalid grouping", target: "/report?group_by=week", token: "secret", wantStatus: http.StatusBadRequest},
+		{name: "invalid date", target: "/report?from=yesterday", token: "secret", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var report RevenueReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("invalid report JSON: %v", err)
+			}
+			if report.Total.Calls == 0 {
+				t.Errorf("report = %+v, want settlements", report)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	ReportHandler(reportLedger(t), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("nil authorize: status = %d, want 401", rec.Code)
+	}
+}
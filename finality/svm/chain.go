@@ -0,0 +1,52 @@
+// Package svm provides a finality.Chain for Solana, which treats a transaction as final
+// once it reaches the finalized commitment.
+package svm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/mark3labs/x402-go/finality"
+)
+
+// Chain reports transaction state from a Solana RPC endpoint.
+type Chain struct {
+	client *rpc.Client
+}
+
+// NewChain creates a Chain for the RPC endpoint at rpcURL (e.g., rpc.MainNetBeta_RPC).
+func NewChain(rpcURL string) *Chain {
+	return &Chain{client: rpc.New(rpcURL)}
+}
+
+// TxState implements finality.Chain.
+func (c *Chain) TxState(ctx context.Context, transaction string) (finality.TxState, error) {
+	signature, err := solana.SignatureFromBase58(transaction)
+	if err != nil {
+		return finality.TxState{}, fmt.Errorf("svm: invalid transaction signature: %w", err)
+	}
+	// Search the transaction history too, so transactions older than the node's recent
+	// status cache are still found
+	result, err := c.client.GetSignatureStatuses(ctx, true, signature)
+	if err != nil {
+		return finality.TxState{}, fmt.Errorf("svm: failed to get signature status: %w", err)
+	}
+	if result == nil || len(result.Value) == 0 || result.Value[0] == nil {
+		return finality.TxState{}, nil
+	}
+
+	status := result.Value[0]
+	state := finality.TxState{
+		Found:         true,
+		Confirmations: 1,
+		Final:         status.ConfirmationStatus == rpc.ConfirmationStatusFinalized,
+		Failed:        status.Err != nil,
+	}
+	// Solana counts confirmations after the including block and stops once finalized
+	if status.Confirmations != nil {
+		state.Confirmations += *status.Confirmations
+	}
+	return state, nil
+}
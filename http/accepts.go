@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// AcceptsSorter orders the payment requirements offered to clients, most preferred
+// first. Clients that pick a requirement by position (such as this module's selector
+// when signer priorities tie) settle on the preferred option, so the server can steer
+// payments by facilitator health or treasury policy (e.g., prefer Base over Solana this
+// week) without changing the configured requirements.
+//
+// It runs on every request with the configured requirements (with Resource already set)
+// and may also omit requirements to stop accepting them for a while. Returning an empty
+// slice keeps accepts unchanged. The sorter must not modify accepts.
+type AcceptsSorter func(ctx context.Context, accepts []x402.PaymentRequirement) []x402.PaymentRequirement
+
+// PreferNetworks returns an AcceptsSorter that moves requirements on the given networks
+// to the front, in the given order. Other requirements keep their configured order.
+func PreferNetworks(networks ...string) AcceptsSorter {
+	rank := make(map[string]int, len(networks))
+	for i, network := range networks {
+		if _, ok := rank[network]; !ok {
+			rank[network] = i
+		}
+	}
+	return func(_ context.Context, accepts []x402.PaymentRequirement) []x402.PaymentRequirement {
+		sorted := append([]x402.PaymentRequirement(nil), accepts...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			ri, ok := rank[sorted[i].Network]
+			if !ok {
+				ri = len(networks)
+			}
+			rj, ok := rank[sorted[j].Network]
+			if !ok {
+				rj = len(networks)
+			}
+			return ri < rj
+		})
+		return sorted
+	}
+}
+
+// SortAccepts applies the configured AcceptsSorter to accepts.
+// It returns accepts unchanged when no sorter is configured.
+func (c *Config) SortAccepts(ctx context.Context, accepts []x402.PaymentRequirement) []x402.PaymentRequirement {
+	if c.AcceptsSorter == nil {
+		return accepts
+	}
+	sorted := c.AcceptsSorter(ctx, accepts)
+	if len(sorted) == 0 {
+		return accepts
+	}
+	return sorted
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestPreferNetworks(t *testing.T) {
+	accepts := []x402.PaymentRequirement{
+		{Network: "base"}, {Network: "solana"}, {Network: "polygon"}, {Network: "avalanche"},
+	}
+
+	tests := []struct {
+		name     string
+		networks []string
+		want     []string
+	}{
+		{name: "no preference", want: []string{"base", "solana", "polygon", "avalanche"}},
+		{name: "single network", networks: []string{"polygon"}, want: []string{"polygon", "base", "solana", "avalanche"}},
+		{name: "preference order", networks: []string{"avalanche", "solana"}, want: []string{"avalanche", "solana", "base", "polygon"}},
+		{name: "unknown network", networks: []string{"optimism"}, want: []string{"base", "solana", "polygon", "avalanche"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := PreferNetworks(tt.networks...)(context.Background(), accepts)
+			var got []string
+			for _, req := range sorted {
+				got = append(got, req.Network)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if accepts[0].Network != "base" || accepts[2].Network != "polygon" {
+		t.Error("PreferNetworks modified its input")
+	}
+}
+
+func TestConfigSortAccepts(t *testing.T) {
+	base := validTestConfig().PaymentRequirements
+
+	config := &Config{}
+	if got := config.SortAccepts(context.Background(), base); len(got) != 1 {
+		t.Errorf("Expected accepts unchanged without sorter, got %v", got)
+	}
+
+	config.AcceptsSorter = func(context.Context, []x402.PaymentRequirement) []x402.PaymentRequirement {
+		return nil
+	}
+	if got := config.SortAccepts(context.Background(), base); len(got) != 1 {
+		t.Errorf("Expected accepts unchanged for empty result, got %v", got)
+	}
+}
+
+func TestMiddleware_AcceptsSorter(t *testing.T) {
+	config := validTestConfig()
+	config.PaymentRequirements = append(config.PaymentRequirements, x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "solana-devnet",
+		MaxAmountRequired: "10000",
+		Asset:             x402.SolanaDevnet.USDCAddress,
+		PayTo:             "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+		MaxTimeoutSeconds: 60,
+	})
+	config.AcceptsSorter = PreferNetworks("solana-devnet")
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/test", nil))
+
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("Expected status 402, got %d", rec.Code)
+	}
+	var body x402.PaymentRequirementsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode 402 body: %v", err)
+	}
+	if len(body.Accepts) != 2 || body.Accepts[0].Network != "solana-devnet" || body.Accepts[1].Network != "base-sepolia" {
+		t.Errorf("Expected solana-devnet offered first, got %+v", body.Accepts)
+	}
+}
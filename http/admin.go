@@ -0,0 +1,75 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminHandler returns a read-only JSON API for inspecting a paid API configured with
+// config at runtime:
+//
+//	GET /requirements  the configured payment requirements
+//	GET /facilitators  the facilitators and their reachability (see HealthReport)
+//	GET /settlements   settlements in flight, the age of the last one and per-network
+//	                   success rates and latencies
+//	GET /payments      the last settlement attempts, newest first
+//	GET /quota         the free quota counters of each client
+//
+// Settlements and payments need config.Health, and quota counters a FreeQuota with an
+// in-memory store; otherwise those endpoints answer 404.
+//
+// The API is unauthenticated and reveals payers and traffic, so serve it apart from the
+// paid routes, on a listener bound to localhost:
+//
+//	go http.ListenAndServe("127.0.0.1:9402", x402http.AdminHandler(config))
+func AdminHandler(config *Config) http.Handler {
+	checker := newHealthChecker(config)
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /requirements", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, config.PaymentRequirements)
+	})
+	mux.HandleFunc("GET /facilitators", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, checker.report(r.Context()).Facilitators)
+	})
+	mux.HandleFunc("GET /settlements", func(w http.ResponseWriter, r *http.Request) {
+		if config.Health == nil {
+			writeAdminError(w, "settlements are not monitored; set Config.Health")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, config.Health.settlement())
+	})
+	mux.HandleFunc("GET /payments", func(w http.ResponseWriter, r *http.Request) {
+		if config.Health == nil {
+			writeAdminError(w, "payments are not monitored; set Config.Health")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, config.Health.RecentPayments())
+	})
+	mux.HandleFunc("GET /quota", func(w http.ResponseWriter, r *http.Request) {
+		if config.FreeQuota == nil {
+			writeAdminError(w, "no free quota is configured")
+			return
+		}
+		store, ok := config.FreeQuota.store().(*MemoryQuotaStore)
+		if !ok {
+			writeAdminError(w, "quota counters are held outside this process")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, store.Counts())
+	})
+	return mux
+}
+
+// writeAdminJSON writes v as an uncached JSON response.
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeAdminError answers 404 for information that is not available.
+func writeAdminError(w http.ResponseWriter, message string) {
+	writeAdminJSON(w, http.StatusNotFound, map[string]string{"error": message})
+}
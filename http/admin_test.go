@@ -0,0 +1,103 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestAdminHandler(t *testing.T) {
+	server := newSettlingFacilitator()
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.Health = NewHealthMonitor()
+	config.FreeQuota = &FreeQuota{Limit: 1}
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// One free request, then a paid one
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newPaidRequest(t, "/test"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	admin := AdminHandler(config)
+	get := func(path string, v any) int {
+		rec := httptest.NewRecorder()
+		admin.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+				t.Fatalf("GET %s: invalid body %s: %v", path, rec.Body.String(), err)
+			}
+		}
+		return rec.Code
+	}
+
+	var requirements []x402.PaymentRequirement
+	if get("/requirements", &requirements); len(requirements) != 1 || requirements[0].Network != "base-sepolia" {
+		t.Errorf("requirements = %+v", requirements)
+	}
+
+	var facilitators []FacilitatorHealth
+	if get("/facilitators", &facilitators); len(facilitators) != 1 || !facilitators[0].Reachable {
+		t.Errorf("facilitators = %+v", facilitators)
+	}
+
+	var settlement SettlementHealth
+	if get("/settlements", &settlement); settlement.Settled != 1 || settlement.InFlight != 0 {
+		t.Errorf("settlements = %+v", settlement)
+	}
+
+	var payments []PaymentRecord
+	get("/payments", &payments)
+	if len(payments) != 1 || !payments[0].Success || payments[0].Payer != testPayer || payments[0].Transaction != "0xtx" {
+		t.Errorf("payments = %+v", payments)
+	}
+
+	var quota map[string]int
+	if get("/quota", &quota); quota["192.0.2.1"] != 2 {
+		t.Errorf("quota = %v, want 2 requests from 192.0.2.1", quota)
+	}
+
+	// Unmonitored settlements are not reported
+	unmonitored := AdminHandler(validTestConfig())
+	for _, path := range []string{"/settlements", "/payments", "/quota"} {
+		rec := httptest.NewRecorder()
+		unmonitored.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s without monitoring = %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestHealthMonitor_RecentPayments(t *testing.T) {
+	m := NewHealthMonitor()
+	for i := 0; i < recentPaymentsKept+5; i++ {
+		d := &Decision{
+			Payment:     &facilitator.VerifyResponse{Payer: testPayer},
+			Requirement: x402.PaymentRequirement{MaxAmountRequired: "1"},
+		}
+		m.settleStarted(d)(&x402.SettlementResponse{Success: true, Transaction: string(rune('a' + i%26))}, nil)
+	}
+
+	payments := m.RecentPayments()
+	if len(payments) != recentPaymentsKept {
+		t.Fatalf("kept %d payments, want %d", len(payments), recentPaymentsKept)
+	}
+	newest := string(rune('a' + (recentPaymentsKept+4)%26))
+	if payments[0].Transaction != newest {
+		t.Errorf("newest payment = %q, want %q", payments[0].Transaction, newest)
+	}
+	if payments[0].Time.Before(payments[len(payments)-1].Time) {
+		t.Error("payments are not newest first")
+	}
+}
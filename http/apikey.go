@@ -0,0 +1,39 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// DefaultAPIKeyHeader is the header checked for API keys when Config.APIKeyHeader is empty.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// HasValidAPIKey reports whether r carries one of the keys in AcceptAPIKeys.
+// Keys are read from APIKeyHeader (default X-API-Key) or, failing that, from an
+// "Authorization: Bearer <key>" header. Keys are compared in constant time.
+func (c *Config) HasValidAPIKey(r *http.Request) bool {
+	if len(c.AcceptAPIKeys) == 0 {
+		return false
+	}
+
+	header := c.APIKeyHeader
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	key := r.Header.Get(header)
+	if key == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = token
+		}
+	}
+	if key == "" {
+		return false
+	}
+
+	valid := 0
+	for _, accepted := range c.AcceptAPIKeys {
+		valid |= subtle.ConstantTimeCompare([]byte(key), []byte(accepted))
+	}
+	return valid == 1
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// DefaultMaxBatchBodySize is the default limit on the size of a request body read to
+// count its operations.
+const DefaultMaxBatchBodySize = 1 << 20
+
+// errBodyTooLarge indicates a request body exceeded the size read for pricing.
+var errBodyTooLarge = errors.New("request body too large")
+
+// OperationCounter returns the number of operations in a request body, e.g. the calls
+// of a JSON-RPC batch or the operations of a GraphQL document.
+type OperationCounter func(ctx context.Context, req EngineRequest, body []byte) (int, error)
+
+// BatchPricing prices a request carrying a batch of operations by the number of
+// operations: the configured requirement amounts are the price of one operation. The
+// count is taken before the 402 is sent, so clients pay for the whole batch at once.
+type BatchPricing struct {
+	// Count counts the operations in a request body (required). Requests whose body it
+	// returns an error for are rejected with 400. CountJSONArray counts JSON-RPC batches.
+	Count OperationCounter
+
+	// MaxBodySize limits the size of the bodies read to count operations (default
+	// DefaultMaxBatchBodySize). Larger requests are rejected with 413.
+	MaxBodySize int64
+}
+
+// CountJSONArray is an OperationCounter for JSON batches such as JSON-RPC 2.0 or
+// batched GraphQL: a JSON array counts its elements, any other JSON value and an empty
+// body count as one operation, and anything else, such as trailing data after a JSON
+// value, is an error.
+func CountJSONArray(_ context.Context, _ EngineRequest, body []byte) (int, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return 1, nil
+	}
+	if body[0] != '[' {
+		if !json.Valid(body) {
+			return 0, errors.New("invalid JSON request")
+		}
+		return 1, nil
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return 0, fmt.Errorf("invalid JSON batch: %w", err)
+	}
+	return len(batch), nil
+}
+
+// priceBatch multiplies the requirement amounts by the number of operations in the
+// request body. It returns the counter's error for a body it cannot count, which is
+// rejected with 400 rather than priced as one operation the handler might run many of.
+func (c *Config) priceBatch(ctx context.Context, req EngineRequest, requirements []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
+	if c.BatchPricing == nil || c.BatchPricing.Count == nil || req.ReadBody == nil {
+		return requirements, nil
+	}
+	limit := c.BatchPricing.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBatchBodySize
+	}
+	body, err := req.ReadBody(limit)
+	if err != nil {
+		return nil, err
+	}
+	count, err := c.BatchPricing.Count(ctx, req, body)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 1 {
+		return requirements, nil
+	}
+
+	priced := make([]x402.PaymentRequirement, len(requirements))
+	for i, requirement := range requirements {
+		priced[i] = requirement
+		amount, ok := new(big.Int).SetString(requirement.MaxAmountRequired, 10)
+		if !ok {
+			continue
+		}
+		priced[i].MaxAmountRequired = amount.Mul(amount, big.NewInt(int64(count))).String()
+	}
+	return priced, nil
+}
+
+// bodyReader returns an EngineRequest.ReadBody function for r. The body read is put
+// back in front of the rest, so the handler still receives all of it.
+func bodyReader(r *http.Request) func(limit int64) ([]byte, error) {
+	return func(limit int64) ([]byte, error) {
+		if r.Body == nil || r.Body == http.NoBody {
+			return nil, nil
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if int64(len(body)) > limit {
+			return nil, errBodyTooLarge
+		}
+		return body, nil
+	}
+}
+
+// readCloser combines a Reader with the Closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestCountJSONArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{name: "single call", body: `{"jsonrpc":"2.0","method":"eth_blockNumber","id":1}`, want: 1},
+		{name: "batch", body: ` [{"id":1},{"id":2},{"id":3}]`, want: 3},
+		{name: "empty batch", body: `[]`, want: 0},
+		{name: "empty body", body: ``, want: 1},
+		{name: "invalid batch", body: `[{"id":1},`, wantErr: true},
+		{name: "trailing data after batch", body: `[{"id":1}] [{"id":2},{"id":3}]`, wantErr: true},
+		{name: "trailing data after call", body: `{"id":1} [{"id":2},{"id":3}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CountJSONArray(context.Background(), EngineRequest{}, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CountJSONArray() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("CountJSONArray() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware_BatchPricing(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.BatchPricing = &BatchPricing{Count: CountJSONArray, MaxBodySize: 64}
+
+	var handlerBody string
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		handlerBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	batch := `[{"id":1},{"id":2},{"id":3}]`
+
+	tests := []struct {
+		name       string
+		body       string
+		payment    bool
+		wantStatus int
+		wantAmount string
+	}{
+		{name: "402 prices the batch", body: batch, wantStatus: http.StatusPaymentRequired, wantAmount: "30000"},
+		{name: "payment verified against the batch price", body: batch, payment: true, wantStatus: http.StatusOK, wantAmount: "30000"},
+		{name: "single call", body: `{"id":1}`, wantStatus: http.StatusPaymentRequired, wantAmount: "10000"},
+		{name: "body too large", body: strings.Repeat(" ", 65), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "trailing data", body: `[{"id":1}][{"id":2},{"id":3}]`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/rpc", strings.NewReader(tt.body))
+			if tt.payment {
+				req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			switch tt.wantStatus {
+			case http.StatusPaymentRequired:
+				var body x402.PaymentRequirementsResponse
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || len(body.Accepts) != 1 || body.Accepts[0].MaxAmountRequired != tt.wantAmount {
+					t.Errorf("402 body = %+v, %v; want amount %s", body, err, tt.wantAmount)
+				}
+			case http.StatusOK:
+				if got := verifiedAmount.Load(); got != tt.wantAmount {
+					t.Errorf("verified amount = %v, want %s", got, tt.wantAmount)
+				}
+				if handlerBody != tt.body {
+					t.Errorf("handler received body %q, want %q", handlerBody, tt.body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package http
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/http/internal/helpers"
+)
+
+// DPoPHeader is the request header carrying the DPoP proof (RFC 9449) of a payment
+// bound with x402.BindingDPoP.
+const DPoPHeader = "DPoP"
+
+// DefaultDPoPProofMaxAge is the default BindingPolicy.ProofMaxAge.
+const DefaultDPoPProofMaxAge = time.Minute
+
+// ReasonBindingMismatch is the 402 reason for a payment that is bound to another DPoP key
+// than the request's, or that is unbound although BindingPolicy requires bindings.
+const ReasonBindingMismatch = "payment_binding_mismatch"
+
+// ErrPaymentBindingMismatch indicates a payment is not bound to the client presenting it.
+var ErrPaymentBindingMismatch = errors.New("x402: payment binding mismatch")
+
+// BindingPolicy checks payments bound to their client's DPoP key (see
+// x402.PaymentBinding), so an X-PAYMENT header stolen from a log or a compromised proxy
+// cannot be presented by another client while its authorization is still valid. Bound
+// payments must come with a DPoP header carrying a fresh proof, for the request's method
+// and URL, signed with the key they were bound to.
+//
+// EIP-3009 payments must be bound: the binding sits outside the signed authorization, so
+// a stolen payment could otherwise be presented with its binding removed. EIP-2612
+// permits and Solana payments cannot be bound and are not checked.
+//
+// Payments that do not match are answered with 402 and ReasonBindingMismatch.
+type BindingPolicy struct {
+	// Optional accepts unbound EIP-3009 payments and checks only bound ones, for
+	// migrating clients to DPoP keys. It is not a security control: a bound payment
+	// stripped of its binding is accepted.
+	Optional bool
+
+	// ProofMaxAge bounds how far the issue time of a DPoP proof may be from now
+	// (default DefaultDPoPProofMaxAge).
+	ProofMaxAge time.Duration
+}
+
+// checkBinding checks that payment is bound to the client of req, if the config has a
+// PaymentBinding policy. It returns an error wrapping ErrPaymentBindingMismatch if not.
+func (c *Config) checkBinding(req EngineRequest, payment x402.PaymentPayload) error {
+	policy := c.PaymentBinding
+	if policy == nil {
+		return nil
+	}
+	binding := payment.Binding
+	if binding == nil {
+		// Only EIP-3009 authorizations carry a nonce the payer can bind
+		if !policy.Optional && helpers.GetNonce(payment) != "" {
+			return fmt.Errorf("%w: payment is not bound", ErrPaymentBindingMismatch)
+		}
+		return nil
+	}
+
+	nonce := helpers.GetNonce(payment)
+	if nonce == "" {
+		return fmt.Errorf("%w: payment has no authorization nonce", ErrPaymentBindingMismatch)
+	}
+
+	if binding.Method != x402.BindingDPoP {
+		return fmt.Errorf("%w: unknown binding method %q", ErrPaymentBindingMismatch, binding.Method)
+	}
+	maxAge := policy.ProofMaxAge
+	if maxAge == 0 {
+		maxAge = DefaultDPoPProofMaxAge
+	}
+	thumbprint, err := verifyDPoPProof(req.Header.Get(DPoPHeader), req.Method, req.ResourceURL, time.Now(), maxAge)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPaymentBindingMismatch, err)
+	}
+
+	if !binding.Verify(nonce, thumbprint) {
+		return fmt.Errorf("%w: nonce is bound to another %s", ErrPaymentBindingMismatch, binding.Method)
+	}
+	return nil
+}
+
+// dpopJWK is the P-256 public key of a DPoP proof, as a JSON Web Key.
+type dpopJWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// dpopHeader is the JOSE header of a DPoP proof.
+type dpopHeader struct {
+	Typ string  `json:"typ"`
+	Alg string  `json:"alg"`
+	JWK dpopJWK `json:"jwk"`
+}
+
+// dpopClaims are the claims of a DPoP proof.
+type dpopClaims struct {
+	JTI string `json:"jti"`
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	IAT int64  `json:"iat"`
+}
+
+// DPoPThumbprint returns the JWK SHA-256 thumbprint (RFC 7638) of a P-256 key, the
+// material payments are bound to with x402.BindingDPoP.
+func DPoPThumbprint(key *ecdsa.PublicKey) ([]byte, error) {
+	jwk, err := newDPoPJWK(key)
+	if err != nil {
+		return nil, err
+	}
+	return jwk.thumbprint(), nil
+}
+
+// NewDPoPProof returns a DPoP proof, signed with key, for a request with method to
+// rawURL. Send it in the DPoPHeader of the request carrying the bound payment.
+func NewDPoPProof(key *ecdsa.PrivateKey, method, rawURL string) (string, error) {
+	jwk, err := newDPoPJWK(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	htu, err := dpopURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("failed to generate proof ID: %w", err)
+	}
+
+	header, err := json.Marshal(dpopHeader{Typ: "dpop+jwt", Alg: "ES256", JWK: jwk})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(dpopClaims{
+		JTI: base64.RawURLEncoding.EncodeToString(jti),
+		HTM: method,
+		HTU: htu,
+		IAT: time.Now().Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign DPoP proof: %w", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyDPoPProof verifies a DPoP proof for a request with method to rawURL, issued
+// within maxAge of now, and returns the thumbprint of its key.
+func verifyDPoPProof(proof, method, rawURL string, now time.Time, maxAge time.Duration) ([]byte, error) {
+	if proof == "" {
+		return nil, errors.New("missing DPoP proof")
+	}
+	parts := strings.Split(proof, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed DPoP proof")
+	}
+
+	var header dpopHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed DPoP proof header: %w", err)
+	}
+	if header.Typ != "dpop+jwt" || header.Alg != "ES256" {
+		return nil, fmt.Errorf("unsupported DPoP proof type %q or algorithm %q", header.Typ, header.Alg)
+	}
+	key, err := header.JWK.publicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return nil, errors.New("malformed DPoP proof signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(key, digest[:], r, s) {
+		return nil, errors.New("invalid DPoP proof signature")
+	}
+
+	var claims dpopClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed DPoP proof claims: %w", err)
+	}
+	htu, err := dpopURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if claims.HTM != method || claims.HTU != htu {
+		return nil, fmt.Errorf("DPoP proof is for %s %s", claims.HTM, claims.HTU)
+	}
+	if issued := time.Unix(claims.IAT, 0); issued.Before(now.Add(-maxAge)) || issued.After(now.Add(maxAge)) {
+		return nil, errors.New("DPoP proof expired")
+	}
+	return header.JWK.thumbprint(), nil
+}
+
+// newDPoPJWK returns the JWK of a P-256 public key.
+func newDPoPJWK(key *ecdsa.PublicKey) (dpopJWK, error) {
+	if key == nil || key.Curve != elliptic.P256() {
+		return dpopJWK{}, errors.New("DPoP key must be a P-256 key")
+	}
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return dpopJWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(x),
+		Y:   base64.RawURLEncoding.EncodeToString(y),
+	}, nil
+}
+
+// publicKey returns the key of the JWK, checking it is a point on P-256.
+func (k dpopJWK) publicKey() (*ecdsa.PublicKey, error) {
+	if k.Kty != "EC" || k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported DPoP key type %q or curve %q", k.Kty, k.Crv)
+	}
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("malformed DPoP key")
+	}
+	if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, errors.New("invalid DPoP key")
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// thumbprint returns the RFC 7638 thumbprint of the JWK: the SHA-256 of its required
+// members in lexicographic order.
+func (k dpopJWK) thumbprint() []byte {
+	canonical := `{"crv":"` + k.Crv + `","kty":"` + k.Kty + `","x":"` + k.X + `","y":"` + k.Y + `"}`
+	sum := sha256.Sum256([]byte(canonical))
+	return sum[:]
+}
+
+// dpopURL returns rawURL as the htu of a DPoP proof: without query and fragment, with
+// the scheme and host in lower case.
+func dpopURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid DPoP URL %q", rawURL)
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + u.EscapedPath(), nil
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a JWT into v.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+// boundSigner is a mockSigner that implements x402.BoundSigner.
+type boundSigner struct{ mockSigner }
+
+func (s *boundSigner) SignBound(_ *x402.PaymentRequirement, nonce [32]byte) (*x402.PaymentPayload, error) {
+	return &x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     s.network,
+		Payload: x402.EVMPayload{
+			Signature:     "0xsig",
+			Authorization: x402.EVMAuthorization{From: testPayer, Value: "10000", Nonce: "0x" + hex.EncodeToString(nonce[:])},
+		},
+	}, nil
+}
+
+func newDPoPKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func TestVerifyDPoPProof(t *testing.T) {
+	key := newDPoPKey(t)
+	const resource = "https://API.example.com/premium?city=berlin"
+	proof, err := NewDPoPProof(key, http.MethodGet, resource)
+	if err != nil {
+		t.Fatalf("NewDPoPProof: %v", err)
+	}
+	want, err := DPoPThumbprint(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("DPoPThumbprint: %v", err)
+	}
+
+	parts := strings.Split(proof, ".")
+	tampered := parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+
+	now := time.Now()
+	tests := []struct {
+		name    string
+		proof   string
+		method  string
+		url     string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", proof: proof, method: http.MethodGet, url: "https://api.example.com/premium"},
+		{name: "missing", method: http.MethodGet, url: "https://api.example.com/premium", wantErr: true},
+		{name: "other method", proof: proof, method: http.MethodPost, url: "https://api.example.com/premium", wantErr: true},
+		{name: "other URL", proof: proof, method: http.MethodGet, url: "https://api.example.com/other", wantErr: true},
+		{name: "expired", proof: proof, method: http.MethodGet, url: "https://api.example.com/premium", now: now.Add(2 * time.Minute), wantErr: true},
+		{name: "bad signature", proof: tampered, method: http.MethodGet, url: "https://api.example.com/premium", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.now
+			if at.IsZero() {
+				at = now
+			}
+			thumbprint, err := verifyDPoPProof(tt.proof, tt.method, tt.url, at, DefaultDPoPProofMaxAge)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyDPoPProof() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(thumbprint) != string(want) {
+				t.Errorf("thumbprint = %x, want %x", thumbprint, want)
+			}
+		})
+	}
+}
+
+func TestPaymentBinding_DPoP(t *testing.T) {
+	var verified atomic.Value
+	var settles atomic.Int32
+	facilitator := newPricingFacilitator(&verified, &settles)
+	defer facilitator.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = facilitator.URL
+	config.PaymentBinding = &BindingPolicy{}
+	var stolen atomic.Value
+	server := httptest.NewServer(NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stolen.Store(r.Header.Get("X-PAYMENT"))
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	// A client with a DPoP key binds its payment and proves the key
+	signer := &boundSigner{mockSigner{network: "base-sepolia", scheme: "exact", canSignValue: true}}
+	client, err := NewClient(WithSigner(signer), WithDPoPKey(newDPoPKey(t)))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := client.Get(server.URL + "/premium")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || settles.Load() != 1 {
+		t.Fatalf("bound payment: status %d, %d settlements; want 200 and 1", resp.StatusCode, settles.Load())
+	}
+
+	header := stolen.Load().(string)
+	payment, err := encoding.DecodePayment(header)
+	if err != nil || payment.Binding == nil || payment.Binding.Method != x402.BindingDPoP {
+		t.Fatalf("payment binding = %+v (%v), want DPoP", payment.Binding, err)
+	}
+
+	unbound, err := encoding.EncodePayment(x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia", Payload: payment.Payload})
+	if err != nil {
+		t.Fatalf("EncodePayment: %v", err)
+	}
+	attackerProof, err := NewDPoPProof(newDPoPKey(t), http.MethodGet, server.URL+"/premium")
+	if err != nil {
+		t.Fatalf("NewDPoPProof: %v", err)
+	}
+
+	// The stolen header is refused without the client's key, and unbound payments are
+	// refused outright
+	for _, tt := range []struct {
+		name    string
+		payment string
+		proof   string
+	}{
+		{name: "no proof", payment: header},
+		{name: "proof of another key", payment: header, proof: attackerProof},
+		{name: "unbound", payment: unbound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/premium", nil)
+			req.Header.Set("X-PAYMENT", tt.payment)
+			if tt.proof != "" {
+				req.Header.Set(DPoPHeader, tt.proof)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			defer resp.Body.Close()
+			var body x402.PaymentRequirementsResponse
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+			if resp.StatusCode != http.StatusPaymentRequired || body.Reason != ReasonBindingMismatch {
+				t.Errorf("status %d, reason %q; want 402 %s", resp.StatusCode, body.Reason, ReasonBindingMismatch)
+			}
+		})
+	}
+	if settles.Load() != 1 {
+		t.Errorf("%d settlements, want 1", settles.Load())
+	}
+}
+
+func TestBindingPolicy_Unbound(t *testing.T) {
+	authorization := x402.PaymentPayload{
+		Network: "base-sepolia",
+		Payload: x402.EVMPayload{Authorization: x402.EVMAuthorization{From: testPayer, Nonce: "0x01"}},
+	}
+	permit := x402.PaymentPayload{
+		Network: "base-sepolia",
+		Payload: x402.EVMPermitPayload{Permit: x402.EVMPermit{Owner: testPayer}},
+	}
+	otherMethod := authorization
+	otherMethod.Binding = &x402.PaymentBinding{Method: "tls-exporter", Salt: "00"}
+
+	tests := []struct {
+		name    string
+		policy  *BindingPolicy
+		payment x402.PaymentPayload
+		wantErr bool
+	}{
+		{name: "no policy", payment: authorization},
+		{name: "unbound authorization", policy: &BindingPolicy{}, payment: authorization, wantErr: true},
+		{name: "unbound authorization, optional", policy: &BindingPolicy{Optional: true}, payment: authorization},
+		{name: "permit", policy: &BindingPolicy{}, payment: permit},
+		{name: "unknown method", policy: &BindingPolicy{Optional: true}, payment: otherMethod, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{PaymentBinding: tt.policy}
+			err := config.checkBinding(engineRequest(http.MethodGet, http.Header{}), tt.payment)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkBinding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrPaymentBindingMismatch) {
+				t.Errorf("checkBinding() error = %v, want ErrPaymentBindingMismatch", err)
+			}
+		})
+	}
+}
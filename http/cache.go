@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// paidResponseHeader returns the caching headers for a response served for a payment,
+// or nil when DisableCacheHeaders is set. Paid responses vary on X-PAYMENT and are
+// either not stored at all or stored only by the paying client's private cache, so a
+// shared cache never serves them to clients that did not pay.
+func (c *Config) paidResponseHeader() http.Header {
+	if c.DisableCacheHeaders {
+		return nil
+	}
+	header := make(http.Header)
+	header.Set("Vary", "X-PAYMENT")
+	if seconds := int64(c.PrivateCacheMaxAge.Seconds()); seconds > 0 {
+		header.Set("Cache-Control", "private, max-age="+strconv.FormatInt(seconds, 10))
+	} else {
+		header.Set("Cache-Control", "no-store")
+	}
+	return header
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddleware_PaidResponseCacheHeaders(t *testing.T) {
+	tests := []struct {
+		name             string
+		privateMaxAge    time.Duration
+		disable          bool
+		wantCacheControl string
+		wantVary         string
+	}{
+		{name: "default", wantCacheControl: "no-store", wantVary: "X-PAYMENT"},
+		{name: "private cache", privateMaxAge: 5 * time.Minute, wantCacheControl: "private, max-age=300", wantVary: "X-PAYMENT"},
+		{name: "disabled", disable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifiedAmount atomic.Value
+			var settleCalls atomic.Int32
+			server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+			defer server.Close()
+
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.PrivateCacheMaxAge = tt.privateMaxAge
+			config.DisableCacheHeaders = tt.disable
+			handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheControl)
+			}
+			if got := rec.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf("Vary = %q, want %q", got, tt.wantVary)
+			}
+		})
+	}
+}
@@ -1,8 +1,17 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/mark3labs/x402-go"
 )
@@ -92,6 +101,240 @@ func WithSelector(selector x402.PaymentSelector) ClientOption {
 	}
 }
 
+// WithPreferredNetworks makes the client pay on the first of networks a server accepts,
+// most preferred first, rather than choosing by signer priority. It configures the
+// client's x402.DefaultPaymentSelector or x402.CachingPaymentSelector, so it must
+// follow WithSelector.
+func WithPreferredNetworks(networks ...string) ClientOption {
+	return func(c *Client) error {
+		selector, err := defaultSelector(c)
+		if err != nil {
+			return err
+		}
+		selector.PreferredNetworks = networks
+		return nil
+	}
+}
+
+// WithPreferredAssets makes the client pay with the first of assets a server accepts,
+// by token symbol (e.g. "USDC") or address, most preferred first. Network preferences
+// come first. Like WithPreferredNetworks, it must follow WithSelector.
+func WithPreferredAssets(assets ...string) ClientOption {
+	return func(c *Client) error {
+		selector, err := defaultSelector(c)
+		if err != nil {
+			return err
+		}
+		selector.PreferredAssets = assets
+		return nil
+	}
+}
+
+// WithCheapestPreferred makes the client pay the lowest of the amounts a server
+// accepts, after network and asset preferences. Like WithPreferredNetworks, it must
+// follow WithSelector.
+func WithCheapestPreferred() ClientOption {
+	return func(c *Client) error {
+		selector, err := defaultSelector(c)
+		if err != nil {
+			return err
+		}
+		selector.PreferCheapest = true
+		return nil
+	}
+}
+
+// defaultSelector returns the x402.DefaultPaymentSelector of the client's transport.
+func defaultSelector(c *Client) (*x402.DefaultPaymentSelector, error) {
+	switch selector := getOrCreateTransport(c).Selector.(type) {
+	case *x402.DefaultPaymentSelector:
+		return selector, nil
+	case *x402.CachingPaymentSelector:
+		return &selector.DefaultPaymentSelector, nil
+	default:
+		return nil, fmt.Errorf("selection preferences require the default payment selector, got %T", selector)
+	}
+}
+
+// WithSpendingLimit caps the total amount the client pays per time window.
+// Use a shared x402.BudgetStore in the limit to enforce one budget across processes.
+func WithSpendingLimit(limit *x402.SpendingLimit) ClientOption {
+	return func(c *Client) error {
+		if limit == nil || limit.Limit == nil || limit.Limit.Sign() <= 0 {
+			return fmt.Errorf("%w: spending limit must be positive", x402.ErrInvalidAmount)
+		}
+		getOrCreateTransport(c).SpendingLimit = limit
+		return nil
+	}
+}
+
+// WithPaymentDeadline bounds the whole payment flow of each request (the initial request,
+// signing, and the paid retry) by d. Timeouts fail with a PaymentError whose code names
+// the stage that ran out of time.
+func WithPaymentDeadline(d time.Duration) ClientOption {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("payment deadline must be positive, got %v", d)
+		}
+		getOrCreateTransport(c).PaymentDeadline = d
+		return nil
+	}
+}
+
+// WithMaxConcurrentPayments limits the payment flows (signing and the paid retry) in
+// flight per destination host to n. See X402Transport.MaxConcurrentPayments.
+func WithMaxConcurrentPayments(n int) ClientOption {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("max concurrent payments must be positive, got %d", n)
+		}
+		getOrCreateTransport(c).MaxConcurrentPayments = n
+		return nil
+	}
+}
+
+// WithSingleFlight makes identical concurrent GET and HEAD requests share one payment.
+// See X402Transport.SingleFlight.
+func WithSingleFlight() ClientOption {
+	return func(c *Client) error {
+		getOrCreateTransport(c).SingleFlight = true
+		return nil
+	}
+}
+
+// WithPaymentHints makes the client pay for resources whose payment requirements a
+// server advertised in Link headers or 103 Early Hints with its first request for them.
+// See X402Transport.PaymentHints.
+func WithPaymentHints() ClientOption {
+	return func(c *Client) error {
+		getOrCreateTransport(c).PaymentHints = true
+		return nil
+	}
+}
+
+// WithTransportObserver shows the raw x402 messages of each payment flow to observer,
+// e.g. DebugObserver(os.Stderr). See X402Transport.Observer.
+func WithTransportObserver(observer TransportObserver) ClientOption {
+	return func(c *Client) error {
+		getOrCreateTransport(c).Observer = observer
+		return nil
+	}
+}
+
+// WithExpectContinueThreshold sets the body size from which uploads wait for the server
+// to accept the request's headers, so they are only transmitted once, with the payment
+// attached. A negative size disables it. See X402Transport.ExpectContinueThreshold.
+func WithExpectContinueThreshold(size int64) ClientOption {
+	return func(c *Client) error {
+		getOrCreateTransport(c).ExpectContinueThreshold = size
+		return nil
+	}
+}
+
+// WithSimulation marks the client's payments as simulated and restricts them to
+// testnets, for end-to-end tests against servers with AcceptSimulatedPayments.
+// See X402Transport.Simulate.
+func WithSimulation() ClientOption {
+	return func(c *Client) error {
+		getOrCreateTransport(c).Simulate = true
+		return nil
+	}
+}
+
+// WithDPoPKey binds the client's payments to key, a P-256 key, so servers that check
+// bindings reject its X-PAYMENT headers when presented by anyone else.
+// See X402Transport.DPoPKey.
+func WithDPoPKey(key *ecdsa.PrivateKey) ClientOption {
+	return func(c *Client) error {
+		if key == nil {
+			return errors.New("DPoP key is nil")
+		}
+		if _, err := DPoPThumbprint(&key.PublicKey); err != nil {
+			return err
+		}
+		getOrCreateTransport(c).DPoPKey = key
+		return nil
+	}
+}
+
+// WithCompactPayments sends CBOR-encoded X-PAYMENT headers to servers that advertise
+// them. See X402Transport.CompactPayments.
+func WithCompactPayments() ClientOption {
+	return func(c *Client) error {
+		getOrCreateTransport(c).CompactPayments = true
+		return nil
+	}
+}
+
+// WithReceiptKey pins the Ed25519 public key that origin (e.g. "https://api.example.com")
+// signs its settlement receipts with. Paid responses from that origin whose
+// X-PAYMENT-RESPONSE header is not validly signed fail with x402.ErrInvalidReceipt.
+func WithReceiptKey(origin string, key ed25519.PublicKey) ClientOption {
+	return func(c *Client) error {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid receipt key origin %q: must be scheme://host[:port]", origin)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid receipt key for %s: must be %d bytes, got %d", origin, ed25519.PublicKeySize, len(key))
+		}
+
+		transport := getOrCreateTransport(c)
+		if transport.ReceiptKeys == nil {
+			transport.ReceiptKeys = make(map[string]ed25519.PublicKey)
+		}
+		transport.ReceiptKeys[originOf(u)] = key
+		return nil
+	}
+}
+
+// WithRequirementsKey pins the Ed25519 public key that origin (e.g.
+// "https://api.example.com") signs its payment requirements with (see
+// Config.RequirementsSigningKey). The client does not pay a 402 from that origin unless
+// its requirements are validly signed, so an on-path attacker cannot swap the payTo
+// address; such requests fail with x402.ErrInvalidRequirementsSignature.
+func WithRequirementsKey(origin string, key ed25519.PublicKey) ClientOption {
+	return func(c *Client) error {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid requirements key origin %q: must be scheme://host[:port]", origin)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid requirements key for %s: must be %d bytes, got %d", origin, ed25519.PublicKeySize, len(key))
+		}
+
+		transport := getOrCreateTransport(c)
+		if transport.RequirementsKeys == nil {
+			transport.RequirementsKeys = make(map[string]ed25519.PublicKey)
+		}
+		transport.RequirementsKeys[originOf(u)] = key
+		return nil
+	}
+}
+
+// WithPayToPinning makes the client pin the payTo address each origin is first paid at
+// and warn about, or with pinning.Block refuse, 402s that later change it.
+// See PayToPinning.
+func WithPayToPinning(pinning *PayToPinning) ClientOption {
+	return func(c *Client) error {
+		getOrCreateTransport(c).PayToPinning = pinning
+		return nil
+	}
+}
+
+// WithMinimumValidityWindow makes the client refuse payment requirements whose
+// MaxTimeoutSeconds is shorter than window, too short to realistically be verified and
+// settled. See X402Transport.MinimumValidityWindow.
+func WithMinimumValidityWindow(window time.Duration) ClientOption {
+	return func(c *Client) error {
+		if window < 0 {
+			return fmt.Errorf("invalid minimum validity window %v: must not be negative", window)
+		}
+		getOrCreateTransport(c).MinimumValidityWindow = window
+		return nil
+	}
+}
+
 // WithPaymentCallback sets a callback for a specific payment event type.
 func WithPaymentCallback(eventType x402.PaymentEventType, callback x402.PaymentCallback) ClientOption {
 	return func(c *Client) error {
@@ -149,6 +392,51 @@ func getOrCreateTransport(c *Client) *X402Transport {
 	return transport
 }
 
+// SetSigners atomically replaces the client's payment signers without recreating the
+// client, e.g. after rotating wallets. It is safe to call while requests are in flight.
+func (c *Client) SetSigners(signers ...x402.Signer) {
+	getOrCreateTransport(c).SetSigners(signers...)
+}
+
+// PostJSON sends v encoded as JSON in a POST request to url, paying for it if required.
+func (c *Client) PostJSON(ctx context.Context, url string, v any) (*http.Response, error) {
+	return c.sendJSON(ctx, http.MethodPost, url, v)
+}
+
+// PutJSON sends v encoded as JSON in a PUT request to url, paying for it if required.
+func (c *Client) PutJSON(ctx context.Context, url string, v any) (*http.Response, error) {
+	return c.sendJSON(ctx, http.MethodPut, url, v)
+}
+
+// sendJSON sends v as the JSON body of a method request to url.
+func (c *Client) sendJSON(ctx context.Context, method, url string, v any) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+	// A bytes.Reader body gets a GetBody, so it is resent with the payment
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.Do(req)
+}
+
+// DoWithBody sends req with body, paying for it if required. The body is buffered so
+// it can be resent with the payment after a 402, whatever kind of reader it is; req's
+// own body is ignored.
+func (c *Client) DoWithBody(req *http.Request, body io.Reader) (*http.Response, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	return c.Do(RequestWithBody(req, data))
+}
+
 // GetSettlement extracts settlement information from an HTTP response.
 // Returns nil if no settlement header is present or if parsing fails.
 // Errors during parsing are silently ignored for backward compatibility.
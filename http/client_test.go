@@ -1,13 +1,20 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"io"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
 )
 
 // mockSigner implements x402.Signer for testing
@@ -129,6 +136,90 @@ func TestClient_WithSigner(t *testing.T) {
 	}
 }
 
+func TestClient_WithSpendingLimit(t *testing.T) {
+	limit, err := x402.NewSpendingLimit("1000000", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("NewSpendingLimit failed: %v", err)
+	}
+
+	client, err := NewClient(WithSpendingLimit(limit), WithSigner(&mockSigner{network: "base", scheme: "exact"}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	transport, ok := client.Transport.(*X402Transport)
+	if !ok {
+		t.Fatal("expected X402Transport")
+	}
+	if transport.SpendingLimit != limit || len(transport.Signers) != 1 {
+		t.Error("expected spending limit and signer on the transport")
+	}
+
+	if _, err := NewClient(WithSpendingLimit(&x402.SpendingLimit{})); !errors.Is(err, x402.ErrInvalidAmount) {
+		t.Errorf("expected ErrInvalidAmount for missing limit, got %v", err)
+	}
+}
+
+func TestClient_SetSigners(t *testing.T) {
+	var gotSigner atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("X-PAYMENT")
+		if header == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "1000",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		payment, err := encoding.DecodePayment(header)
+		if err != nil {
+			t.Errorf("failed to decode payment: %v", err)
+		}
+		gotSigner.Store(payment.Payload.(map[string]any)["signer"])
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithSigner(&namedSigner{name: "old"}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	for _, name := range []string{"old", "new"} {
+		if name == "new" {
+			client.SetSigners(&namedSigner{name: "new"})
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if gotSigner.Load() != name {
+			t.Errorf("expected payment from %s signer, got %v", name, gotSigner.Load())
+		}
+	}
+}
+
+// namedSigner signs payloads tagged with its name.
+type namedSigner struct {
+	mockSigner
+	name string
+}
+
+func (s *namedSigner) CanSign(*x402.PaymentRequirement) bool { return true }
+func (s *namedSigner) Network() string                       { return "base" }
+func (s *namedSigner) Scheme() string                        { return "exact" }
+func (s *namedSigner) Sign(req *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	return &x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      req.Scheme,
+		Network:     req.Network,
+		Payload:     map[string]any{"signer": s.name},
+	}, nil
+}
+
 func TestClient_WithMultipleSigners(t *testing.T) {
 	signer1 := &mockSigner{network: "base", scheme: "exact", canSignValue: true, priority: 1}
 	signer2 := &mockSigner{network: "solana", scheme: "exact", canSignValue: true, priority: 2}
@@ -199,6 +290,27 @@ func TestClient_WithSelector(t *testing.T) {
 	}
 }
 
+func TestClient_SelectionPreferences(t *testing.T) {
+	client, err := NewClient(
+		WithSelector(x402.NewCachingPaymentSelector(0)),
+		WithPreferredNetworks("base", "solana"),
+		WithPreferredAssets("USDC"),
+		WithCheapestPreferred(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	selector := client.Transport.(*X402Transport).Selector.(*x402.CachingPaymentSelector)
+	if !reflect.DeepEqual(selector.PreferredNetworks, []string{"base", "solana"}) ||
+		!reflect.DeepEqual(selector.PreferredAssets, []string{"USDC"}) || !selector.PreferCheapest {
+		t.Errorf("unexpected preferences: %+v", selector.DefaultPaymentSelector)
+	}
+
+	if _, err := NewClient(WithSelector(struct{ x402.PaymentSelector }{}), WithCheapestPreferred()); err == nil {
+		t.Error("expected an error with a custom selector")
+	}
+}
+
 func TestClient_NonPaymentRequest(t *testing.T) {
 	// Create a test server that returns 200 OK
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -441,3 +553,77 @@ func TestClient_StdlibCompatibility_VariousScenarios(t *testing.T) {
 
 	t.Log("FR-014 passed: client maintains stdlib compatibility for all status codes")
 }
+
+func TestClient_UploadHelpers(t *testing.T) {
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		MaxTimeoutSeconds: 60,
+	}
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(requirement))
+			return
+		}
+		received = append(received, r.Method+" "+r.Header.Get("Content-Type")+" "+string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		send func() (*http.Response, error)
+		want string
+	}{
+		{
+			name: "PostJSON",
+			send: func() (*http.Response, error) {
+				return client.PostJSON(context.Background(), server.URL, map[string]int{"n": 1})
+			},
+			want: `POST application/json {"n":1}`,
+		},
+		{
+			name: "PutJSON",
+			send: func() (*http.Response, error) {
+				return client.PutJSON(context.Background(), server.URL, []string{"a"})
+			},
+			want: `PUT application/json ["a"]`,
+		},
+		{
+			name: "DoWithBody",
+			send: func() (*http.Response, error) {
+				req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+				req.Header.Set("Content-Type", "text/plain")
+				// A reader without GetBody support
+				return client.DoWithBody(req, io.MultiReader(strings.NewReader("hello")))
+			},
+			want: "POST text/plain hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			resp, err := tt.send()
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+			if len(received) != 1 || received[0] != tt.want {
+				t.Errorf("paid request = %q, want %q", received, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+// PaymentEncodingsHeader is the 402 response header listing the X-PAYMENT encodings the
+// server accepts besides the default base64-encoded JSON, e.g. "cbor" (see
+// encoding.PaymentEncodingCBOR).
+const PaymentEncodingsHeader = "X-PAYMENT-ENCODINGS"
+
+// PaymentEncodingHeader is the request header naming the encoding of the X-PAYMENT
+// header when it is not the default base64-encoded JSON: "cbor" or "application/cbor".
+// The middleware also accepts CBOR headers sent without it.
+const PaymentEncodingHeader = "X-PAYMENT-ENCODING"
+
+// minCompressedBodySize is the size from which 402 bodies are compressed for clients
+// that accept it; smaller bodies do not shrink enough to be worth it.
+const minCompressedBodySize = 1024
+
+// writeJSONBody writes v as the JSON body of a response with status, compressed with
+// gzip or deflate if it is large and r's Accept-Encoding allows it.
+func writeJSONBody(w http.ResponseWriter, r *http.Request, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	body = append(body, '\n')
+
+	header := w.Header()
+	header.Set("Content-Type", "application/json")
+	contentEncoding := ""
+	if len(body) >= minCompressedBodySize {
+		header.Add("Vary", "Accept-Encoding")
+		contentEncoding = acceptedContentEncoding(r.Header.Get("Accept-Encoding"))
+	}
+	if contentEncoding == "" {
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+		return
+	}
+
+	var compressed bytes.Buffer
+	var compressor io.WriteCloser
+	if contentEncoding == "gzip" {
+		compressor = gzip.NewWriter(&compressed)
+	} else {
+		compressor = zlib.NewWriter(&compressed)
+	}
+	_, _ = compressor.Write(body)
+	_ = compressor.Close()
+
+	header.Set("Content-Encoding", contentEncoding)
+	header.Del("Content-Length")
+	w.WriteHeader(status)
+	_, _ = w.Write(compressed.Bytes())
+}
+
+// acceptedContentEncoding returns the content encoding to compress a response with for
+// an Accept-Encoding header: "gzip", "deflate", or "" to send it uncompressed.
+func acceptedContentEncoding(acceptEncoding string) string {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		quality[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	for _, coding := range []string{"gzip", "deflate"} {
+		q, ok := quality[coding]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > 0 {
+			return coding
+		}
+	}
+	return ""
+}
+
+// decodeContentEncoding replaces resp's body with its decompressed content if it is
+// gzip- or deflate-encoded, as when the request set its own Accept-Encoding and
+// http.Transport did not decompress it.
+func decodeContentEncoding(resp *http.Response) error {
+	var reader io.ReadCloser
+	var err error
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip":
+		reader, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(resp.Body)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{reader, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// acceptsCBORPayments reports whether a 402 response advertises CBOR-encoded X-PAYMENT
+// headers.
+func acceptsCBORPayments(resp *http.Response) bool {
+	for _, value := range resp.Header.Values(PaymentEncodingsHeader) {
+		for _, name := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), encoding.PaymentEncodingCBOR) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+func TestAcceptedContentEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"br, deflate", "deflate"},
+		{"gzip;q=0, deflate;q=0.5", "deflate"},
+		{"GZIP;q=0.8", "gzip"},
+		{"*", "gzip"},
+		{"*;q=0", ""},
+		{"identity", ""},
+	}
+
+	for _, tt := range tests {
+		if got := acceptedContentEncoding(tt.acceptEncoding); got != tt.want {
+			t.Errorf("acceptedContentEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+// manyRequirementsConfig returns a config offering n payment options, for large 402s.
+func manyRequirementsConfig(facilitatorURL string, n int) *Config {
+	config := validTestConfig()
+	config.FacilitatorURL = facilitatorURL
+	base := config.PaymentRequirements[0]
+	config.PaymentRequirements = nil
+	for i := 0; i < n; i++ {
+		requirement := base
+		requirement.Description = fmt.Sprintf("Payment option %d", i)
+		config.PaymentRequirements = append(config.PaymentRequirements, requirement)
+	}
+	return config
+}
+
+func TestMiddleware_CompressesPaymentRequired(t *testing.T) {
+	handler := NewX402Middleware(manyRequirementsConfig("http://mock-facilitator.test", 20))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	small := NewX402Middleware(validTestConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{name: "gzip", handler: handler, acceptEncoding: "gzip, deflate", wantEncoding: "gzip"},
+		{name: "deflate", handler: handler, acceptEncoding: "deflate", wantEncoding: "deflate"},
+		{name: "not accepted", handler: handler},
+		{name: "small body", handler: small, acceptEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/premium", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, r)
+
+			if rec.Code != http.StatusPaymentRequired {
+				t.Fatalf("status = %d, want 402", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get(PaymentEncodingsHeader); got != encoding.PaymentEncodingCBOR {
+				t.Errorf("%s = %q, want cbor", PaymentEncodingsHeader, got)
+			}
+
+			var body io.Reader = rec.Body
+			switch tt.wantEncoding {
+			case "gzip":
+				reader, err := gzip.NewReader(body)
+				if err != nil {
+					t.Fatalf("gzip: %v", err)
+				}
+				body = reader
+			case "deflate":
+				reader, err := zlib.NewReader(body)
+				if err != nil {
+					t.Fatalf("zlib: %v", err)
+				}
+				body = reader
+			}
+			var response x402.PaymentRequirementsResponse
+			if err := json.NewDecoder(body).Decode(&response); err != nil || len(response.Accepts) == 0 {
+				t.Errorf("body decodes to %+v (%v)", response, err)
+			}
+		})
+	}
+}
+
+func TestX402Transport_CompactPayments(t *testing.T) {
+	var verified atomic.Value
+	var settles atomic.Int32
+	facilitator := newPricingFacilitator(&verified, &settles)
+	defer facilitator.Close()
+
+	var encodings []string
+	server := httptest.NewServer(NewX402Middleware(manyRequirementsConfig(facilitator.URL, 20))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get(PaymentEncodingHeader))
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	signer := &mockSigner{network: "base-sepolia", scheme: "exact", canSignValue: true}
+	transport := &X402Transport{
+		Base:            http.DefaultTransport,
+		Signers:         []x402.Signer{signer},
+		Selector:        x402.NewDefaultPaymentSelector(),
+		CompactPayments: true,
+	}
+	client := &http.Client{Transport: transport}
+
+	// Asking for deflate leaves decompression of the 402 to the transport
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/premium", nil)
+	req.Header.Set("Accept-Encoding", "deflate")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if len(encodings) != 1 || encodings[0] != encoding.PaymentEncodingCBOR {
+		t.Errorf("payment encodings = %q, want cbor", encodings)
+	}
+
+	// Without CompactPayments the default encoding is used
+	transport.CompactPayments = false
+	resp, err = client.Get(server.URL + "/premium")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if len(encodings) != 2 || encodings[1] != "" {
+		t.Errorf("payment encodings = %q, want JSON", encodings)
+	}
+}
+
+func TestX402Transport_CompactPaymentsFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		reject       func(w http.ResponseWriter)
+		wantStatus   int
+		wantAttempts string
+	}{
+		{
+			name:         "net/http middleware",
+			reject:       func(w http.ResponseWriter) { http.Error(w, malformedHeaderMessage, http.StatusBadRequest) },
+			wantStatus:   http.StatusOK,
+			wantAttempts: "[malformed json json]",
+		},
+		{
+			name: "error response",
+			reject: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(ErrorResponse{X402Version: 1, Error: "bad header", Code: x402.ErrCodeMalformedHeader})
+			},
+			wantStatus:   http.StatusOK,
+			wantAttempts: "[malformed json json]",
+		},
+		{
+			// Other 400s are the handler's answer to the request, paid or not
+			name:         "other bad request",
+			reject:       func(w http.ResponseWriter) { http.Error(w, "missing city", http.StatusBadRequest) },
+			wantStatus:   http.StatusBadRequest,
+			wantAttempts: "[malformed malformed]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A proxy strips the encoding header, so the server cannot read compact payments
+			var attempts []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				payment := r.Header.Get("X-PAYMENT")
+				if payment == "" {
+					w.Header().Set(PaymentEncodingsHeader, encoding.PaymentEncodingCBOR)
+					w.WriteHeader(http.StatusPaymentRequired)
+					_ = json.NewEncoder(w).Encode(x402.PaymentRequirementsResponse{X402Version: 1, Accepts: validTestConfig().PaymentRequirements})
+					return
+				}
+				if _, err := encoding.DecodePayment(payment); err != nil {
+					attempts = append(attempts, "malformed")
+					tt.reject(w)
+					return
+				}
+				attempts = append(attempts, "json")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var observed strings.Builder
+			transport := &X402Transport{
+				Base:            http.DefaultTransport,
+				Signers:         []x402.Signer{&mockSigner{network: "base-sepolia", scheme: "exact", canSignValue: true}},
+				Selector:        x402.NewDefaultPaymentSelector(),
+				CompactPayments: true,
+				Observer:        DebugObserver(&observed),
+			}
+			client := &http.Client{Transport: transport}
+
+			for i := 0; i < 2; i++ {
+				resp, err := client.Get(server.URL + "/premium")
+				if err != nil {
+					t.Fatalf("Get: %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+			if fmt.Sprint(attempts) != tt.wantAttempts {
+				t.Errorf("attempts = %v, want %s", attempts, tt.wantAttempts)
+			}
+			// The observer sees every payment header sent, including the JSON resend
+			if got, want := strings.Count(observed.String(), "=== x402 X-PAYMENT:"), len(attempts); got != want {
+				t.Errorf("observed %d payments, want %d:\n%s", got, want, observed.String())
+			}
+		})
+	}
+}
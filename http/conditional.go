@@ -0,0 +1,86 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Validators are the current cache validators of a resource.
+type Validators struct {
+	// ETag is the quoted entity tag, e.g. `"v42"` or `W/"v42"` (optional).
+	ETag string
+
+	// LastModified is when the resource last changed (optional).
+	LastModified time.Time
+}
+
+// Revalidator returns the current validators of the resource req targets, so the
+// middleware can answer cache revalidations of unchanged paid resources with 304 Not
+// Modified without charging. It returns false when the resource has no validators, in
+// which case the request is handled normally. It must be cheap: it runs before payment
+// on every conditional GET or HEAD request.
+type Revalidator func(ctx context.Context, req EngineRequest) (Validators, bool)
+
+// NotModified reports whether a request with header would receive 304 Not Modified,
+// following RFC 9110: If-None-Match is compared weakly against ETag, and
+// If-Modified-Since is only considered without If-None-Match.
+func (v Validators) NotModified(header http.Header) bool {
+	if inm := header.Get("If-None-Match"); inm != "" {
+		if v.ETag == "" {
+			return false
+		}
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || weakETag(tag) == weakETag(v.ETag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := header.Get("If-Modified-Since")
+	if ims == "" || v.LastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have second precision
+	return !v.LastModified.Truncate(time.Second).After(since)
+}
+
+// header returns the validator headers to send with a 304 response.
+func (v Validators) header() http.Header {
+	header := make(http.Header)
+	if v.ETag != "" {
+		header.Set("ETag", v.ETag)
+	}
+	if !v.LastModified.IsZero() {
+		header.Set("Last-Modified", v.LastModified.UTC().Format(http.TimeFormat))
+	}
+	return header
+}
+
+// weakETag returns tag without its weakness indicator, for weak comparison.
+func weakETag(tag string) string {
+	return strings.TrimPrefix(tag, "W/")
+}
+
+// notModified returns a 304 decision for a conditional request matching the resource's
+// current validators, or nil if the request must be handled normally.
+func (c *Config) notModified(ctx context.Context, req EngineRequest) *Decision {
+	if c.Revalidator == nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return nil
+	}
+	if req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == "" {
+		return nil
+	}
+	validators, ok := c.Revalidator(ctx, req)
+	if !ok || !validators.NotModified(req.Header) {
+		return nil
+	}
+	return &Decision{Status: http.StatusNotModified, Header: validators.header()}
+}
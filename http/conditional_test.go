@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidators_NotModified(t *testing.T) {
+	modified := time.Date(2025, 3, 1, 12, 0, 0, 500, time.UTC)
+	validators := Validators{ETag: `"v2"`, LastModified: modified}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   bool
+	}{
+		{name: "unconditional", header: http.Header{}, want: false},
+		{name: "matching etag", header: http.Header{"If-None-Match": {`"v2"`}}, want: true},
+		{name: "etag in list", header: http.Header{"If-None-Match": {`"v1", "v2"`}}, want: true},
+		{name: "weak etag", header: http.Header{"If-None-Match": {`W/"v2"`}}, want: true},
+		{name: "wildcard", header: http.Header{"If-None-Match": {"*"}}, want: true},
+		{name: "stale etag", header: http.Header{"If-None-Match": {`"v1"`}}, want: false},
+		{name: "not modified since", header: http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}, want: true},
+		{name: "modified since", header: http.Header{"If-Modified-Since": {modified.Add(-time.Hour).Format(http.TimeFormat)}}, want: false},
+		{name: "invalid date", header: http.Header{"If-Modified-Since": {"yesterday"}}, want: false},
+		{
+			name:   "etag takes precedence",
+			header: http.Header{"If-None-Match": {`"v1"`}, "If-Modified-Since": {modified.Format(http.TimeFormat)}},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validators.NotModified(tt.header); got != tt.want {
+				t.Errorf("NotModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Revalidator(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.Revalidator = func(_ context.Context, req EngineRequest) (Validators, bool) {
+		return Validators{ETag: `"v2"`}, req.Path == "/test"
+	}
+
+	var handlerCalls atomic.Int32
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		etag        string
+		payment     bool
+		wantStatus  int
+		wantHandler bool
+	}{
+		// Revalidations come first: none of them may reach the facilitator
+		{name: "unchanged", method: "GET", etag: `"v2"`, wantStatus: http.StatusNotModified},
+		{name: "unchanged with payment", method: "GET", etag: `"v2"`, payment: true, wantStatus: http.StatusNotModified},
+		{name: "changed", method: "GET", etag: `"v1"`, wantStatus: http.StatusPaymentRequired},
+		{name: "changed with payment", method: "GET", etag: `"v1"`, payment: true, wantStatus: http.StatusOK, wantHandler: true},
+		{name: "unsafe method", method: "POST", etag: `"v2"`, wantStatus: http.StatusPaymentRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerCalls.Store(0)
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			req.Header.Set("If-None-Match", tt.etag)
+			if tt.payment {
+				req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if (handlerCalls.Load() > 0) != tt.wantHandler {
+				t.Errorf("handler calls = %d, want handler run %v", handlerCalls.Load(), tt.wantHandler)
+			}
+			if tt.wantStatus == http.StatusNotModified {
+				if rec.Header().Get("ETag") != `"v2"` || rec.Body.Len() != 0 {
+					t.Errorf("304 response header = %v, body %q", rec.Header(), rec.Body.String())
+				}
+				if verifiedAmount.Load() != nil {
+					t.Error("revalidation verified the payment")
+				}
+			}
+		})
+	}
+
+	if settleCalls.Load() != 1 {
+		t.Errorf("settle calls = %d, want 1", settleCalls.Load())
+	}
+}
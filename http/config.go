@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sort"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// knownUSDCNetworks maps each built-in USDC asset address to the network it lives on.
+var knownUSDCNetworks = func() map[string]string {
+	chains := []x402.ChainConfig{
+		x402.SolanaMainnet, x402.SolanaDevnet,
+		x402.BaseMainnet, x402.BaseSepolia,
+		x402.PolygonMainnet, x402.PolygonAmoy,
+		x402.AvalancheMainnet, x402.AvalancheFuji,
+	}
+	networks := make(map[string]string, len(chains))
+	for _, chain := range chains {
+		networks[chain.USDCAddress] = chain.NetworkID
+	}
+	return networks
+}()
+
+// Validate checks the middleware configuration and returns every problem found,
+// joined into a single error. It checks that facilitator URLs are well-formed and that
+// each payment requirement is complete: a known network, a positive amount, a valid
+// payTo address, and an asset that belongs to the requirement's network.
+//
+// If CheckFacilitatorReachability is set, Validate also queries the /supported endpoint
+// of every configured facilitator.
+//
+// NewX402Middleware calls Validate and panics on error, so a bad configuration fails
+// at startup instead of on the first paid request.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if err := validateFacilitatorURL(c.FacilitatorURL); err != nil {
+		errs = append(errs, fmt.Errorf("facilitatorURL: %w", err))
+	}
+	if c.FallbackFacilitatorURL != "" {
+		if err := validateFacilitatorURL(c.FallbackFacilitatorURL); err != nil {
+			errs = append(errs, fmt.Errorf("fallbackFacilitatorURL: %w", err))
+		}
+	}
+	for network, facilitatorURL := range c.FacilitatorByNetwork {
+		if _, err := x402.ValidateNetwork(network); err != nil {
+			errs = append(errs, fmt.Errorf("facilitatorByNetwork[%s]: %w", network, x402.ErrInvalidNetwork))
+		}
+		if err := validateFacilitatorURL(facilitatorURL); err != nil {
+			errs = append(errs, fmt.Errorf("facilitatorByNetwork[%s]: %w", network, err))
+		}
+	}
+
+	if len(c.PaymentRequirements) == 0 {
+		errs = append(errs, fmt.Errorf("paymentRequirements: at least one requirement is required"))
+	}
+	for i, req := range c.PaymentRequirements {
+		for _, err := range validateRequirement(req) {
+			errs = append(errs, fmt.Errorf("paymentRequirements[%d]: %w", i, err))
+		}
+	}
+
+	if c.FreeQuota != nil && c.FreeQuota.Limit <= 0 {
+		errs = append(errs, fmt.Errorf("freeQuota: limit must be positive, got %d", c.FreeQuota.Limit))
+	}
+
+	if c.Sessions != nil && len(c.Sessions.Secret) == 0 {
+		errs = append(errs, fmt.Errorf("sessions: secret cannot be empty"))
+	}
+
+	if c.VerifyTimeout < 0 {
+		errs = append(errs, fmt.Errorf("verifyTimeout: must not be negative, got %v", c.VerifyTimeout))
+	}
+	if c.SettleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("settleTimeout: must not be negative, got %v", c.SettleTimeout))
+	}
+	if c.PaymentBinding != nil && c.PaymentBinding.ProofMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("paymentBinding.proofMaxAge: must not be negative, got %v", c.PaymentBinding.ProofMaxAge))
+	}
+	if c.SettleLatencyBudget < 0 {
+		errs = append(errs, fmt.Errorf("settleLatencyBudget: must not be negative, got %v", c.SettleLatencyBudget))
+	}
+
+	if c.ReceiptSigningKey != nil && len(c.ReceiptSigningKey) != ed25519.PrivateKeySize {
+		errs = append(errs, fmt.Errorf("receiptSigningKey: must be %d bytes, got %d", ed25519.PrivateKeySize, len(c.ReceiptSigningKey)))
+	}
+	if c.RequirementsSigningKey != nil && len(c.RequirementsSigningKey) != ed25519.PrivateKeySize {
+		errs = append(errs, fmt.Errorf("requirementsSigningKey: must be %d bytes, got %d", ed25519.PrivateKeySize, len(c.RequirementsSigningKey)))
+	}
+
+	// Only probe facilitators once the static configuration is sound
+	if len(errs) == 0 && c.CheckFacilitatorReachability {
+		errs = append(errs, c.checkFacilitators()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateFacilitatorURL checks that a facilitator URL is an absolute http(s) URL.
+func validateFacilitatorURL(rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("cannot be empty")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q (expected http:// or https:// with a host)", rawURL)
+	}
+	return nil
+}
+
+// validateRequirement returns every problem found in a single payment requirement.
+func validateRequirement(req x402.PaymentRequirement) []error {
+	var errs []error
+
+	if req.Scheme == "" {
+		errs = append(errs, fmt.Errorf("scheme: cannot be empty"))
+	}
+
+	amount, ok := new(big.Int).SetString(req.MaxAmountRequired, 10)
+	switch {
+	case req.MaxAmountRequired == "":
+		errs = append(errs, fmt.Errorf("maxAmountRequired: cannot be empty"))
+	case !ok:
+		errs = append(errs, fmt.Errorf("maxAmountRequired: %w: %q is not an integer in atomic units", x402.ErrInvalidAmount, req.MaxAmountRequired))
+	case amount.Sign() <= 0:
+		errs = append(errs, fmt.Errorf("maxAmountRequired: %w: must be greater than zero, got %s", x402.ErrInvalidAmount, req.MaxAmountRequired))
+	}
+
+	if req.MaxTimeoutSeconds <= 0 {
+		errs = append(errs, fmt.Errorf("maxTimeoutSeconds: must be positive, got %d", req.MaxTimeoutSeconds))
+	}
+
+	// Address checks depend on the network, so stop here if it is unusable
+	if _, err := x402.ValidateNetwork(req.Network); err != nil {
+		return append(errs, fmt.Errorf("network: %w: %q", x402.ErrInvalidNetwork, req.Network))
+	}
+
+	if err := x402.ValidateAddress(req.Network, req.PayTo); err != nil {
+		errs = append(errs, fmt.Errorf("payTo: %w", err))
+	}
+
+	if err := x402.ValidateAddress(req.Network, req.Asset); err != nil {
+		errs = append(errs, fmt.Errorf("asset: %w", err))
+	} else if network, ok := knownUSDCNetworks[req.Asset]; ok && network != req.Network {
+		errs = append(errs, fmt.Errorf("asset: %s is USDC on %s, not %s", req.Asset, network, req.Network))
+	}
+
+	return errs
+}
+
+// checkFacilitators queries /supported on every configured facilitator.
+func (c *Config) checkFacilitators() []error {
+	var errs []error
+	for _, client := range c.facilitatorClients() {
+		if _, err := client.Supported(context.Background()); err != nil {
+			errs = append(errs, fmt.Errorf("facilitator %s: %w", client.BaseURL, err))
+		}
+	}
+	return errs
+}
+
+// facilitatorClients returns a client for each distinct configured facilitator URL: the
+// primary, the fallback and the per-network ones, in that order.
+func (c *Config) facilitatorClients() []*FacilitatorClient {
+	urls := []string{c.FacilitatorURL}
+	if c.FallbackFacilitatorURL != "" {
+		urls = append(urls, c.FallbackFacilitatorURL)
+	}
+	var dedicated []string
+	for _, facilitatorURL := range c.FacilitatorByNetwork {
+		dedicated = append(dedicated, facilitatorURL)
+	}
+	sort.Strings(dedicated)
+	urls = append(urls, dedicated...)
+
+	var clients []*FacilitatorClient
+	checked := make(map[string]bool)
+	for _, facilitatorURL := range urls {
+		if checked[facilitatorURL] {
+			continue
+		}
+		checked[facilitatorURL] = true
+
+		client := &FacilitatorClient{
+			BaseURL:  facilitatorURL,
+			Client:   c.FacilitatorHTTPClient,
+			Timeouts: c.Timeouts(),
+		}
+		if facilitatorURL == c.FallbackFacilitatorURL {
+			client.Authorization = c.FallbackFacilitatorAuthorization
+			client.AuthorizationProvider = c.FallbackFacilitatorAuthorizationProvider
+			client.SigningSecret = c.FallbackFacilitatorSigningSecret
+		} else {
+			client.Authorization = c.FacilitatorAuthorization
+			client.AuthorizationProvider = c.FacilitatorAuthorizationProvider
+			client.SigningSecret = c.FacilitatorSigningSecret
+		}
+		clients = append(clients, client)
+	}
+	return clients
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func validTestConfig() *Config {
+	return &Config{
+		FacilitatorURL: "http://mock-facilitator.test",
+		PaymentRequirements: []x402.PaymentRequirement{
+			{
+				Scheme:            "exact",
+				Network:           "base-sepolia",
+				MaxAmountRequired: "10000",
+				Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+				PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				MaxTimeoutSeconds: 60,
+			},
+		},
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(c *Config)
+		wantError string
+	}{
+		{
+			name:   "valid config",
+			modify: func(c *Config) {},
+		},
+		{
+			name:      "missing facilitator URL",
+			modify:    func(c *Config) { c.FacilitatorURL = "" },
+			wantError: "facilitatorURL: cannot be empty",
+		},
+		{
+			name:      "facilitator URL without scheme",
+			modify:    func(c *Config) { c.FacilitatorURL = "facilitator.x402.rs" },
+			wantError: "facilitatorURL: invalid URL",
+		},
+		{
+			name:      "invalid fallback URL",
+			modify:    func(c *Config) { c.FallbackFacilitatorURL = "ftp://fallback.test" },
+			wantError: "fallbackFacilitatorURL: invalid URL",
+		},
+		{
+			name:      "unknown network in facilitator routing",
+			modify:    func(c *Config) { c.FacilitatorByNetwork = map[string]string{"bitcoin": "https://btc.test"} },
+			wantError: "facilitatorByNetwork[bitcoin]",
+		},
+		{
+			name:      "no requirements",
+			modify:    func(c *Config) { c.PaymentRequirements = nil },
+			wantError: "at least one requirement is required",
+		},
+		{
+			name:      "zero amount",
+			modify:    func(c *Config) { c.PaymentRequirements[0].MaxAmountRequired = "0" },
+			wantError: "paymentRequirements[0]: maxAmountRequired",
+		},
+		{
+			name:      "negative amount",
+			modify:    func(c *Config) { c.PaymentRequirements[0].MaxAmountRequired = "-5" },
+			wantError: "must be greater than zero",
+		},
+		{
+			name:      "decimal amount",
+			modify:    func(c *Config) { c.PaymentRequirements[0].MaxAmountRequired = "0.01" },
+			wantError: "not an integer in atomic units",
+		},
+		{
+			name:      "missing scheme",
+			modify:    func(c *Config) { c.PaymentRequirements[0].Scheme = "" },
+			wantError: "scheme: cannot be empty",
+		},
+		{
+			name:      "missing timeout",
+			modify:    func(c *Config) { c.PaymentRequirements[0].MaxTimeoutSeconds = 0 },
+			wantError: "maxTimeoutSeconds: must be positive",
+		},
+		{
+			name:      "unknown network",
+			modify:    func(c *Config) { c.PaymentRequirements[0].Network = "ethereum" },
+			wantError: "network: x402: invalid or unsupported network",
+		},
+		{
+			name:      "mistyped payTo",
+			modify:    func(c *Config) { c.PaymentRequirements[0].PayTo = "0x209693Bc6afc0C5328bA36FaF03C514EF312287c" },
+			wantError: "payTo: x402: invalid address",
+		},
+		{
+			name:      "asset from another network",
+			modify:    func(c *Config) { c.PaymentRequirements[0].Asset = x402.BaseMainnet.USDCAddress },
+			wantError: "is USDC on base, not base-sepolia",
+		},
+		{
+			name:      "EVM asset on Solana",
+			modify:    func(c *Config) { c.PaymentRequirements[0].Network = "solana-devnet" },
+			wantError: "asset: x402: invalid address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validTestConfig()
+			tt.modify(config)
+
+			err := config.Validate()
+			if tt.wantError == "" {
+				if err != nil {
+					t.Fatalf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() expected error containing %q, got nil", tt.wantError)
+			}
+			if !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Validate() error = %q, want it to contain %q", err.Error(), tt.wantError)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_AggregatesErrors(t *testing.T) {
+	config := validTestConfig()
+	config.FacilitatorURL = ""
+	config.PaymentRequirements[0].MaxAmountRequired = "0"
+	config.PaymentRequirements[0].PayTo = "not-an-address"
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error, got nil")
+	}
+	for _, want := range []string{"facilitatorURL", "maxAmountRequired", "payTo"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %q, want it to mention %s", err.Error(), want)
+		}
+	}
+	if !errors.Is(err, x402.ErrInvalidAddress) {
+		t.Errorf("Validate() error should wrap ErrInvalidAddress")
+	}
+}
+
+func TestConfigValidate_FacilitatorReachability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+	}))
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.CheckFacilitatorReachability = true
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+
+	config.FallbackFacilitatorURL = "http://127.0.0.1:1"
+	err := config.Validate()
+	if !errors.Is(err, x402.ErrFacilitatorUnavailable) {
+		t.Errorf("Validate() error = %v, want ErrFacilitatorUnavailable", err)
+	}
+}
+
+func TestNewX402Middleware_PanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected NewX402Middleware to panic on invalid config")
+		}
+	}()
+
+	config := validTestConfig()
+	config.PaymentRequirements[0].PayTo = ""
+	NewX402Middleware(config)
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/x402-go/facilitator"
+	"github.com/mark3labs/x402-go/http/internal/helpers"
+)
+
+// PaymentDeadline returns the time after which the verified payment of the request whose
+// context is ctx can no longer be settled: its EIP-3009 validBefore or EIP-2612 permit
+// deadline. The payment is settled after the handler responds, so long-running handlers
+// should stop work that would finish after it, leaving time for settlement (see
+// Config.SettleTimeout):
+//
+//	if deadline, ok := x402http.PaymentDeadline(r.Context()); ok {
+//	    ctx, cancel := context.WithDeadline(r.Context(), deadline.Add(-10*time.Second))
+//	    defer cancel()
+//	    ...
+//	}
+//
+// It returns false for requests without a verified payment and for payments that carry
+// no deadline, such as Solana transactions.
+func PaymentDeadline(ctx context.Context) (time.Time, bool) {
+	payment, ok := ctx.Value(PaymentContextKey).(*facilitator.VerifyResponse)
+	if !ok || payment == nil {
+		return time.Time{}, false
+	}
+	validBefore, ok := helpers.GetValidBefore(payment.PaymentPayload)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(validBefore, 0), true
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestPaymentDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    int64
+		wantOK  bool
+	}{
+		{
+			name:    "eip-3009 authorization",
+			payload: x402.EVMPayload{Authorization: x402.EVMAuthorization{ValidBefore: "1700000060"}},
+			want:    1700000060,
+			wantOK:  true,
+		},
+		{
+			name:    "decoded authorization",
+			payload: map[string]any{"authorization": map[string]any{"validBefore": "1700000060"}},
+			want:    1700000060,
+			wantOK:  true,
+		},
+		{
+			name:    "permit",
+			payload: &x402.EVMPermitPayload{Permit: x402.EVMPermit{Deadline: "1700000120"}},
+			want:    1700000120,
+			wantOK:  true,
+		},
+		{
+			name:    "solana transaction",
+			payload: map[string]any{"transaction": "AQID"},
+		},
+		{
+			name:    "malformed validBefore",
+			payload: map[string]any{"authorization": map[string]any{"validBefore": "soon"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verified := &facilitator.VerifyResponse{
+				IsValid:        true,
+				PaymentPayload: x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base", Payload: tt.payload},
+			}
+			ctx := context.WithValue(context.Background(), PaymentContextKey, verified)
+
+			got, ok := PaymentDeadline(ctx)
+			if ok != tt.wantOK {
+				t.Fatalf("PaymentDeadline() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !got.Equal(time.Unix(tt.want, 0)) {
+				t.Errorf("PaymentDeadline() = %v, want %v", got, time.Unix(tt.want, 0))
+			}
+		})
+	}
+
+	if _, ok := PaymentDeadline(context.Background()); ok {
+		t.Error("PaymentDeadline() ok = true without a payment")
+	}
+}
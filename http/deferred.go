@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// SettlementStatusHeader marks a paid response sent while its settlement is still in
+// progress, with the value "pending" (see Config.SettleLatencyBudget). Such responses
+// carry no X-PAYMENT-RESPONSE header.
+const SettlementStatusHeader = "X-PAYMENT-SETTLEMENT"
+
+// settle submits d's payment to its facilitator, and to the fallback facilitator if that
+// fails, recording the outcome in Config.Health.
+func (e *Engine) settle(ctx context.Context, d *Decision) (*x402.SettlementResponse, error) {
+	settled := e.config.Health.settleStarted(d)
+	settlementResp, err := d.facilitator.Settle(ctx, d.payment, d.Requirement)
+	if err != nil && e.fallback != nil {
+		slog.Default().Warn("primary facilitator settlement failed, trying fallback", "error", err)
+		settlementResp, err = e.fallback.Settle(ctx, d.payment, d.Requirement)
+	}
+	settled(settlementResp, err)
+	return settlementResp, err
+}
+
+// settleWithinBudget settles d's payment, detached from ctx's cancellation unless
+// Config.SettleDetached is false. With a SettleLatencyBudget, a settlement still running
+// when the budget runs out is left to complete in the background, and
+// settleWithinBudget reports it deferred. Its outcome then reaches the facilitator hooks
+// and Config.Health as usual, and is logged.
+func (e *Engine) settleWithinBudget(ctx context.Context, d *Decision) (*x402.SettlementResponse, bool, error) {
+	detached := e.config.SettleDetached == nil || *e.config.SettleDetached
+	budget := e.config.SettleLatencyBudget
+	if budget <= 0 {
+		if detached {
+			ctx = context.WithoutCancel(ctx)
+		}
+		settleCtx, cancel := e.config.SettleContext(ctx)
+		defer cancel()
+		settlementResp, err := e.settle(settleCtx, d)
+		return settlementResp, false, err
+	}
+
+	// The settlement may outlive the request, so it is canceled with the request only
+	// until it is deferred
+	settleCtx, cancel := e.config.SettleContext(context.WithoutCancel(ctx))
+	stop := func() bool { return true }
+	if !detached {
+		stop = context.AfterFunc(ctx, cancel)
+		defer stop()
+	}
+
+	type outcome struct {
+		settlementResp *x402.SettlementResponse
+		err            error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer cancel()
+		settlementResp, err := e.settle(settleCtx, d)
+		done <- outcome{settlementResp, err}
+	}()
+
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+	select {
+	case o := <-done:
+		return o.settlementResp, false, o.err
+	case <-timer.C:
+	}
+	if !stop() {
+		// The request was canceled first, and the settlement with it
+		o := <-done
+		return o.settlementResp, false, o.err
+	}
+
+	logger := slog.Default()
+	payer := d.Payment.Payer
+	logger.Warn("settlement exceeded latency budget, completing in the background", "payer", payer, "budget", budget)
+	go func() {
+		o := <-done
+		switch {
+		case o.err != nil:
+			logger.Error("deferred settlement failed", "payer", payer, "error", o.err)
+		case !o.settlementResp.Success:
+			logger.Error("deferred settlement unsuccessful", "payer", payer, "reason", o.settlementResp.ErrorReason)
+		default:
+			logger.Info("deferred payment settled", "payer", payer, "transaction", o.settlementResp.Transaction)
+		}
+	}()
+	return nil, true, nil
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestEngine_SettleLatencyBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		settleDelay  time.Duration
+		wantDeferred bool
+	}{
+		{name: "settled within budget"},
+		{name: "deferred past budget", settleDelay: 200 * time.Millisecond, wantDeferred: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/supported":
+					_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+				case "/verify":
+					_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+				case "/settle":
+					time.Sleep(tt.settleDelay)
+					_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+				}
+			}))
+			defer server.Close()
+
+			afterSettle := make(chan *x402.SettlementResponse, 1)
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.SettleLatencyBudget = 50 * time.Millisecond
+			config.Health = NewHealthMonitor()
+			config.FacilitatorOnAfterSettle = func(_ context.Context, _ x402.PaymentPayload, _ x402.PaymentRequirement, settlement *x402.SettlementResponse, err error) {
+				if err != nil {
+					t.Errorf("settlement failed: %v", err)
+				}
+				afterSettle <- settlement
+			}
+			engine := MustNewEngine(config)
+
+			header := http.Header{"X-Payment": {pricingPaymentHeader(t, testPayer)}}
+			decision := engine.Authorize(context.Background(), engineRequest(http.MethodGet, header))
+			if !decision.Proceed {
+				t.Fatalf("Authorize rejected payment: %d %+v", decision.Status, decision.Error)
+			}
+
+			// The request's context ends with the response; a deferred settlement outlives it
+			ctx, cancel := context.WithCancel(context.Background())
+			settled := engine.Settle(ctx, decision)
+			cancel()
+			if !settled.Proceed {
+				t.Fatalf("Settle failed: %d %+v", settled.Status, settled.Error)
+			}
+			if settled.SettlementDeferred != tt.wantDeferred {
+				t.Fatalf("SettlementDeferred = %v, want %v", settled.SettlementDeferred, tt.wantDeferred)
+			}
+
+			responseHeader := http.Header{}
+			settled.CopyHeader(responseHeader)
+			hasReceipt := responseHeader.Get("X-PAYMENT-RESPONSE") != ""
+			pending := responseHeader.Get(SettlementStatusHeader) == "pending"
+			if hasReceipt == tt.wantDeferred || pending != tt.wantDeferred || (settled.Settlement == nil) != tt.wantDeferred {
+				t.Errorf("receipt = %v, pending = %v, Settlement = %+v", hasReceipt, pending, settled.Settlement)
+			}
+
+			select {
+			case settlement := <-afterSettle:
+				if settlement == nil || !settlement.Success || settlement.Transaction != "0xtx" {
+					t.Errorf("hook settlement = %+v, want success in 0xtx", settlement)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("settlement never completed")
+			}
+			deadline := time.Now().Add(time.Second)
+			for config.Health.settlement().Settled != 1 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if settled := config.Health.settlement().Settled; settled != 1 {
+				t.Errorf("health recorded %d settlements, want 1", settled)
+			}
+		})
+	}
+}
+
+func TestEngine_SettleDetached(t *testing.T) {
+	detached, canceled := true, false
+	tests := []struct {
+		name        string
+		detached    *bool
+		budget      time.Duration
+		cancelAfter time.Duration // cancel the request this long into settlement, or after Settle returns if 0
+		wantSettled bool
+		wantStatus  int
+	}{
+		{name: "detached by default", cancelAfter: 20 * time.Millisecond, wantSettled: true},
+		{name: "detached", detached: &detached, cancelAfter: 20 * time.Millisecond, wantSettled: true},
+		{name: "canceled", detached: &canceled, cancelAfter: 20 * time.Millisecond, wantStatus: http.StatusServiceUnavailable},
+		{name: "canceled before the latency budget", detached: &canceled, budget: 100 * time.Millisecond, cancelAfter: 20 * time.Millisecond, wantStatus: http.StatusServiceUnavailable},
+		{name: "deferred past the latency budget", detached: &canceled, budget: 20 * time.Millisecond, wantSettled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settleCanceled := make(chan bool, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/supported":
+					_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+				case "/verify":
+					_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+				case "/settle":
+					// The server notices the client going away once the body is read
+					_, _ = io.Copy(io.Discard, r.Body)
+					select {
+					case <-time.After(150 * time.Millisecond):
+						settleCanceled <- false
+						_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+					case <-r.Context().Done():
+						settleCanceled <- true
+					}
+				}
+			}))
+			defer server.Close()
+
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.SettleDetached = tt.detached
+			config.SettleLatencyBudget = tt.budget
+			engine := MustNewEngine(config)
+
+			header := http.Header{"X-Payment": {pricingPaymentHeader(t, testPayer)}}
+			decision := engine.Authorize(context.Background(), engineRequest(http.MethodGet, header))
+			if !decision.Proceed {
+				t.Fatalf("Authorize rejected payment: %d %+v", decision.Status, decision.Error)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancelAfter > 0 {
+				time.AfterFunc(tt.cancelAfter, cancel)
+			}
+			settled := engine.Settle(ctx, decision)
+			cancel()
+			if settled.Proceed != tt.wantSettled || (!tt.wantSettled && settled.Status != tt.wantStatus) {
+				t.Fatalf("Settle: proceed %v, status %d; want proceed %v, status %d", settled.Proceed, settled.Status, tt.wantSettled, tt.wantStatus)
+			}
+
+			select {
+			case wasCanceled := <-settleCanceled:
+				if wasCanceled == tt.wantSettled {
+					t.Errorf("facilitator settle call canceled = %v, want %v", wasCanceled, !tt.wantSettled)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("facilitator never finished the settle call")
+			}
+		})
+	}
+}
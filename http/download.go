@@ -0,0 +1,366 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// ErrChecksumMismatch indicates a download does not match its expected checksum.
+var ErrChecksumMismatch = errors.New("x402: download checksum mismatch")
+
+// DefaultDownloadResumes is the default number of times Client.Download resumes an
+// interrupted download.
+const DefaultDownloadResumes = 3
+
+// DownloadProgress is the state of a download reported to OnProgress.
+type DownloadProgress struct {
+	// Written is the number of bytes downloaded, including those of a resumed download.
+	Written int64
+
+	// Total is the size of the resource, or -1 while it is unknown.
+	Total int64
+
+	// Spent is the total amount paid for the download so far, in atomic units.
+	Spent string
+
+	// Payments is the number of payments made for the download so far.
+	Payments int
+}
+
+// DownloadResult describes a completed download.
+type DownloadResult struct {
+	// Written is the size of the downloaded resource, including the bytes of a resumed
+	// download.
+	Written int64
+
+	// Spent is the total amount paid for the download, in atomic units.
+	Spent string
+
+	// Settlements are the settlements of the download's payments, in order.
+	Settlements []*x402.SettlementResponse
+}
+
+// DownloadOption configures Client.Download.
+type DownloadOption func(*downloadOptions) error
+
+type downloadOptions struct {
+	maxTotalCost *big.Int
+	resume       io.Reader
+	checksum     []byte
+	maxResumes   int
+	onProgress   func(DownloadProgress)
+	onPreview    func([]x402.PaymentRequirement) error
+}
+
+// MaxTotalCost caps the total amount paid for the download across all its requests,
+// resumed ones included, in atomic units. A payment that would exceed it fails the
+// download with x402.ErrBudgetExceeded before it is signed.
+func MaxTotalCost(amount string) DownloadOption {
+	return func(o *downloadOptions) error {
+		limit, ok := new(big.Int).SetString(amount, 10)
+		if !ok || limit.Sign() < 0 {
+			return fmt.Errorf("%w: max total cost %q", x402.ErrInvalidAmount, amount)
+		}
+		o.maxTotalCost = limit
+		return nil
+	}
+}
+
+// Resume continues a download whose first bytes were written by an earlier call, e.g.
+// into the same file. existing yields those bytes; it is read to find where to resume
+// and to include them in the checksum.
+func Resume(existing io.Reader) DownloadOption {
+	return func(o *downloadOptions) error {
+		o.resume = existing
+		return nil
+	}
+}
+
+// ExpectSHA256 fails the download with ErrChecksumMismatch unless the SHA-256 digest of
+// the whole resource is the hex-encoded digest.
+func ExpectSHA256(digest string) DownloadOption {
+	return func(o *downloadOptions) error {
+		sum, err := hex.DecodeString(digest)
+		if err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("invalid SHA-256 digest %q", digest)
+		}
+		o.checksum = sum
+		return nil
+	}
+}
+
+// MaxResumes sets how many times an interrupted download is resumed with a Range
+// request for the missing bytes (default DefaultDownloadResumes). Resumed requests are
+// paid for again, for the remaining bytes if the server prices Range requests.
+func MaxResumes(n int) DownloadOption {
+	return func(o *downloadOptions) error {
+		if n < 0 {
+			return fmt.Errorf("invalid max resumes %d: must not be negative", n)
+		}
+		o.maxResumes = n
+		return nil
+	}
+}
+
+// OnProgress calls fn as the download is written and paid for.
+func OnProgress(fn func(DownloadProgress)) DownloadOption {
+	return func(o *downloadOptions) error {
+		o.onProgress = fn
+		return nil
+	}
+}
+
+// OnCostPreview calls fn with the payment requirements of the download's first payment
+// before it is signed. An error from fn aborts the download and is returned.
+func OnCostPreview(fn func(requirements []x402.PaymentRequirement) error) DownloadOption {
+	return func(o *downloadOptions) error {
+		o.onPreview = fn
+		return nil
+	}
+}
+
+// Download fetches the resource at url into w, paying for it as needed. A download cut
+// off midway is resumed with Range requests for the missing bytes, which the server
+// must support (as http.ServeContent does). The download fails if the server ignores
+// the Range header, since w already holds the first bytes.
+func (c *Client) Download(ctx context.Context, url string, w io.Writer, opts ...DownloadOption) (*DownloadResult, error) {
+	o := downloadOptions{maxResumes: DefaultDownloadResumes}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	// Requests are first sent unpaid, to see the price before paying it
+	transport, _ := c.Transport.(*X402Transport)
+	base := c.Transport
+	if transport != nil {
+		base = transport.Base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	unpaid := &http.Client{Transport: base, CheckRedirect: c.CheckRedirect, Jar: c.Jar, Timeout: c.Timeout}
+
+	d := &download{w: w, total: -1, spent: new(big.Int), onProgress: o.onProgress}
+	if o.checksum != nil {
+		d.hash = sha256.New()
+	}
+	if o.resume != nil {
+		var err error
+		if d.hash != nil {
+			d.written, err = io.Copy(d.hash, o.resume)
+		} else {
+			d.written, err = io.Copy(io.Discard, o.resume)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the downloaded part: %w", err)
+		}
+	}
+
+	previewed := false
+	for resumes := 0; ; resumes++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if d.written > 0 {
+			req.Header.Set("Range", "bytes="+strconv.FormatInt(d.written, 10)+"-")
+		}
+
+		resp, err := unpaid.Do(req)
+		if err != nil {
+			if ctx.Err() == nil && resumes < o.maxResumes {
+				continue
+			}
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusPaymentRequired {
+			if transport == nil {
+				resp.Body.Close()
+				return nil, x402.NewPaymentError(x402.ErrCodeNoValidSigner, "download requires payment but the client has no signers", x402.ErrNoValidSigner)
+			}
+			requirements, err := transport.paymentRequirements(resp.Request, resp)
+			if err != nil {
+				return nil, err
+			}
+			if !previewed && o.onPreview != nil {
+				if err := o.onPreview(requirements); err != nil {
+					return nil, err
+				}
+			}
+			previewed = true
+			if resp, err = d.pay(ctx, transport, resp.Request, requirements, o.maxTotalCost); err != nil {
+				return nil, err
+			}
+		}
+
+		complete, err := d.receive(resp)
+		if err != nil {
+			return nil, err
+		}
+		if complete {
+			break
+		}
+		if ctx.Err() != nil || resumes >= o.maxResumes {
+			return nil, fmt.Errorf("download interrupted after %d bytes: %w", d.written, io.ErrUnexpectedEOF)
+		}
+	}
+
+	if d.hash != nil {
+		if sum := d.hash.Sum(nil); !bytes.Equal(sum, o.checksum) {
+			return nil, fmt.Errorf("%w: got %x, want %x", ErrChecksumMismatch, sum, o.checksum)
+		}
+	}
+	return &DownloadResult{Written: d.written, Spent: d.spent.String(), Settlements: d.settlements}, nil
+}
+
+// download is the state of a Client.Download.
+type download struct {
+	w           io.Writer
+	hash        hash.Hash
+	written     int64
+	total       int64
+	spent       *big.Int
+	payments    int
+	settlements []*x402.SettlementResponse
+	onProgress  func(DownloadProgress)
+
+	// writeErr is the error writing to w, as opposed to reading the response.
+	writeErr error
+}
+
+// pay pays for req with the requirements that fit in what remains of maxTotalCost,
+// including those a server offers when it changes the price.
+func (d *download) pay(ctx context.Context, t *X402Transport, req *http.Request, requirements []x402.PaymentRequirement, maxTotalCost *big.Int) (*http.Response, error) {
+	var afford func([]x402.PaymentRequirement) ([]x402.PaymentRequirement, error)
+	if maxTotalCost != nil {
+		afford = func(requirements []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
+			remaining := new(big.Int).Sub(maxTotalCost, d.spent)
+			var affordable []x402.PaymentRequirement
+			for _, requirement := range requirements {
+				if amount, ok := new(big.Int).SetString(requirement.MaxAmountRequired, 10); ok && amount.Cmp(remaining) <= 0 {
+					affordable = append(affordable, requirement)
+				}
+			}
+			if len(affordable) == 0 {
+				return nil, fmt.Errorf("%w: download would cost more than %s (%s spent)", x402.ErrBudgetExceeded, maxTotalCost, d.spent)
+			}
+			return affordable, nil
+		}
+	}
+
+	resp, paid, err := t.payRequirement(ctx, req, requirements, afford)
+	if err != nil {
+		return nil, err
+	}
+	d.payments++
+	if paid != nil {
+		if amount, ok := new(big.Int).SetString(paid.MaxAmountRequired, 10); ok {
+			d.spent.Add(d.spent, amount)
+		}
+	}
+	if settlement := GetSettlement(resp); settlement != nil {
+		d.settlements = append(d.settlements, settlement)
+	}
+	d.progress()
+	return resp, nil
+}
+
+// receive writes the body of resp, the response to a request for the bytes from
+// d.written on, and reports whether the download is complete.
+func (d *download) receive(resp *http.Response) (bool, error) {
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if d.written > 0 {
+			return false, fmt.Errorf("server does not support resuming downloads: %s", resp.Status)
+		}
+		d.total = resp.ContentLength
+	case http.StatusPartialContent:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != d.written {
+			return false, fmt.Errorf("unexpected Content-Range %q resuming at byte %d", resp.Header.Get("Content-Range"), d.written)
+		}
+		d.total = total
+	default:
+		return false, fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	_, err := io.Copy(d, resp.Body)
+	if d.writeErr != nil {
+		return false, d.writeErr
+	}
+	// A read error or a short body leaves the rest for a resumed request
+	return err == nil && (d.total < 0 || d.written >= d.total), nil
+}
+
+// Write implements io.Writer, writing to the destination and the checksum.
+func (d *download) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	if d.hash != nil {
+		d.hash.Write(p[:n])
+	}
+	d.written += int64(n)
+	if err != nil {
+		d.writeErr = err
+		return n, err
+	}
+	d.progress()
+	return n, nil
+}
+
+// progress reports the download's state to onProgress.
+func (d *download) progress() {
+	if d.onProgress != nil {
+		d.onProgress(DownloadProgress{
+			Written:  d.written,
+			Total:    d.total,
+			Spent:    d.spent.String(),
+			Payments: d.payments,
+		})
+	}
+}
+
+// parseContentRange parses a "bytes start-end/total" Content-Range header, returning
+// -1 for an unknown total.
+func parseContentRange(value string) (start, total int64, ok bool) {
+	spec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	span, size, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if size == "*" {
+		return start, -1, true
+	}
+	total, err = strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
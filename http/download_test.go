@@ -0,0 +1,214 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/http/internal/helpers"
+)
+
+// newDownloadServer serves content for one atomic unit per byte, prorating Range
+// requests. The first cut paid responses are cut off halfway through.
+func newDownloadServer(content []byte, cut int32) *httptest.Server {
+	var paid atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			remaining := len(content)
+			if start, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+				offset, _ := strconv.Atoi(strings.TrimSuffix(start, "-"))
+				remaining -= offset
+			}
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: strconv.Itoa(remaining),
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+
+		if paid.Add(1) <= cut {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func TestClient_Download(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name      string
+		cut       int32
+		existing  []byte
+		opts      []DownloadOption
+		wantErr   error
+		wantSpent string
+	}{
+		{name: "whole", wantSpent: "1000"},
+		{name: "resumed after a cut", cut: 1, wantSpent: "1500"},
+		{name: "resumes the earlier part", existing: content[:400], wantSpent: "600"},
+		{name: "within the ceiling", cut: 1, opts: []DownloadOption{MaxTotalCost("1500")}, wantSpent: "1500"},
+		{name: "over the ceiling", cut: 1, opts: []DownloadOption{MaxTotalCost("1499")}, wantErr: x402.ErrBudgetExceeded},
+		{name: "no resumes", cut: 1, opts: []DownloadOption{MaxResumes(0)}, wantErr: io.ErrUnexpectedEOF},
+		{name: "checksum mismatch", opts: []DownloadOption{ExpectSHA256(hex.EncodeToString(make([]byte, 32)))}, wantErr: ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newDownloadServer(content, tt.cut)
+			defer server.Close()
+
+			client, err := NewClient(WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			var previews int
+			var last DownloadProgress
+			opts := append([]DownloadOption{
+				ExpectSHA256(digest),
+				OnProgress(func(p DownloadProgress) { last = p }),
+				OnCostPreview(func([]x402.PaymentRequirement) error { previews++; return nil }),
+			}, tt.opts...)
+			var out bytes.Buffer
+			if tt.existing != nil {
+				out.Write(tt.existing)
+				opts = append(opts, Resume(bytes.NewReader(tt.existing)))
+			}
+
+			result, err := client.Download(context.Background(), server.URL+"/file.bin", &out, opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), content) {
+				t.Errorf("downloaded %d bytes, want the %d byte content", out.Len(), len(content))
+			}
+			if result.Spent != tt.wantSpent || last.Spent != tt.wantSpent {
+				t.Errorf("spent = %s (progress %s), want %s", result.Spent, last.Spent, tt.wantSpent)
+			}
+			if result.Written != int64(len(content)) || last.Written != result.Written || last.Total != result.Written {
+				t.Errorf("written = %d, progress = %+v, want %d", result.Written, last, len(content))
+			}
+			if previews != 1 {
+				t.Errorf("cost previewed %d times, want once", previews)
+			}
+		})
+	}
+}
+
+func TestClient_Download_PreviewAborts(t *testing.T) {
+	server := newDownloadServer([]byte("content"), 0)
+	defer server.Close()
+
+	client, err := NewClient(WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	tooExpensive := errors.New("too expensive")
+	_, err = client.Download(context.Background(), server.URL, &bytes.Buffer{},
+		OnCostPreview(func(requirements []x402.PaymentRequirement) error {
+			if requirements[0].MaxAmountRequired != "7" {
+				t.Errorf("previewed amount = %s, want 7", requirements[0].MaxAmountRequired)
+			}
+			return tooExpensive
+		}))
+	if !errors.Is(err, tooExpensive) {
+		t.Errorf("expected the preview's error, got %v", err)
+	}
+}
+
+func TestClient_Download_PriceChangeOverCeiling(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		MaxTimeoutSeconds: 60,
+	}
+
+	tests := []struct {
+		name      string
+		ceiling   string
+		wantErr   error
+		wantSpent string
+	}{
+		{name: "within the ceiling", ceiling: "2000", wantSpent: "2000"},
+		{name: "over the ceiling", ceiling: "1500", wantErr: x402.ErrBudgetExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The server advertises 1000 but prices this payer at 2000
+			var served atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-PAYMENT") == "" {
+					w.WriteHeader(http.StatusPaymentRequired)
+					_, _ = w.Write(makePaymentRequirementsResponse(requirement))
+					return
+				}
+				payment, err := helpers.ParsePaymentHeaderFromRequest(r)
+				if value, _ := helpers.GetValue(payment); err != nil || value != "2000" {
+					repriced := requirement
+					repriced.MaxAmountRequired = "2000"
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusPaymentRequired)
+					_ = json.NewEncoder(w).Encode(paymentRejected([]x402.PaymentRequirement{repriced}, ReasonPriceChanged, testPayer).Body())
+					return
+				}
+				served.Add(1)
+				http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+			}))
+			defer server.Close()
+
+			signer := &amountSigner{mockSigner: mockSigner{network: "base", scheme: "exact", canSignValue: true}}
+			client, err := NewClient(WithSigner(signer))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			var out bytes.Buffer
+			result, err := client.Download(context.Background(), server.URL+"/file.bin", &out, MaxTotalCost(tt.ceiling))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				if served.Load() != 0 || len(signer.signed) != 1 {
+					t.Errorf("served %d paid requests after signing %v, want none after [1000]", served.Load(), signer.signed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			if result.Spent != tt.wantSpent || !bytes.Equal(out.Bytes(), content) {
+				t.Errorf("spent %s for %d bytes, want %s for %d", result.Spent, out.Len(), tt.wantSpent, len(content))
+			}
+		})
+	}
+}
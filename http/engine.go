@@ -0,0 +1,744 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/coupons"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+	"github.com/mark3labs/x402-go/http/internal/helpers"
+	"github.com/mark3labs/x402-go/sanctions"
+)
+
+// ErrorResponse is the JSON body describing a paid request that failed for a reason
+// other than a missing or rejected payment (those receive a 402 with the payment
+// requirements).
+type ErrorResponse struct {
+	X402Version int            `json:"x402Version"`
+	Error       string         `json:"error"`
+	Code        x402.ErrorCode `json:"code,omitempty"`
+}
+
+// Engine implements the framework-independent part of the x402 middleware: bypass
+// checks (API keys, coupons, sessions, free quota), payment parsing, replay protection,
+// verification and settlement. Framework adapters translate their request into an
+// EngineRequest, call Authorize before the handler and Settle when the handler succeeds,
+// and render the returned Decision.
+//
+// NewX402Middleware and the gin and pocketbase adapters are built on Engine; use it
+// directly to support another framework.
+type Engine struct {
+	config       *Config
+	router       *FacilitatorRouter
+	fallback     *FacilitatorClient
+	requirements []x402.PaymentRequirement
+}
+
+// EngineRequest describes an incoming request to Engine.Authorize.
+type EngineRequest struct {
+	// Method is the HTTP method, e.g. "GET".
+	Method string
+
+	// Path is the URL path, used in default requirement descriptions and coupon scopes.
+	Path string
+
+	// ResourceURL is the absolute URL of the requested resource, reported to clients
+	// as the requirements' resource.
+	ResourceURL string
+
+	// Header holds the request headers.
+	Header http.Header
+
+	// ClientIP identifies the client for per-client free quotas.
+	ClientIP string
+
+	// ReadBody returns the request body, up to limit bytes, without consuming it, for
+	// pricing by content (see BatchPricing). It is nil when the body is not available.
+	ReadBody func(limit int64) ([]byte, error)
+}
+
+// NewEngineRequest describes r for Engine.Authorize, with the client IP taken from
+// r.RemoteAddr. Adapters whose framework resolves the client IP differently should
+// overwrite ClientIP. Its ReadBody puts what it reads back into r.Body, so the handler
+// still receives the whole body.
+func NewEngineRequest(r *http.Request) EngineRequest {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return EngineRequest{
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		ResourceURL: scheme + "://" + r.Host + r.RequestURI,
+		Header:      r.Header,
+		ClientIP:    ClientIP(r),
+		ReadBody:    bodyReader(r),
+	}
+}
+
+// malformedHeaderMessage is the error message of the 400 response to an X-PAYMENT header
+// that cannot be parsed. X402Transport recognizes it to fall back from compact headers.
+const malformedHeaderMessage = "Invalid payment header"
+
+// ReasonSimulatedPayment is the 402 reason for a simulated payment the server does not
+// accept: AcceptSimulatedPayments is off or the requirement is not on a testnet.
+const ReasonSimulatedPayment = "simulated_payment_not_accepted"
+
+// ReasonPaymentExpired is the 402 reason for a payment whose authorization expired before
+// it could be settled, e.g. because the handler ran past its validBefore. The 402 offers
+// the requirements again, and clients sign a fresh payment and retry.
+const ReasonPaymentExpired = "payment_expired"
+
+// ReasonPriceChanged is the 402 reason for a payment signed for the base price when the
+// PriceResolver or the payer's reputation surcharge prices the payer differently. The 402 offers the
+// payer's own requirements, and clients sign a fresh payment for them and retry.
+const ReasonPriceChanged = "price_changed"
+
+// Decision is the outcome of Engine.Authorize or Engine.Settle.
+type Decision struct {
+	// Proceed reports whether the handler should run (or, after Settle, whether its
+	// response may be sent). When false, the adapter responds with Status and Body, or
+	// with Replay, or with Status and Header alone for 304 Not Modified.
+	Proceed bool
+
+	// Status is the response status code when Proceed is false.
+	Status int
+
+	// Error describes the failure when Proceed is false and Status is not 402.
+	Error *ErrorResponse
+
+	// Requirements are the payment requirements returned with a 402.
+	Requirements []x402.PaymentRequirement
+
+	// Reason is the reason code when the request's payment was rejected, usually the
+	// facilitator's, returned with the 402 so clients can tell why their payment failed.
+	Reason string
+
+	// Header holds headers to add to the response, such as X-PAYMENT-RESPONSE after
+	// settlement or a session token.
+	Header http.Header
+
+	// Payment is the verified payment, or nil if the request was authorized without one.
+	Payment *facilitator.VerifyResponse
+
+	// Requirement is the payment requirement the verified payment satisfies.
+	Requirement x402.PaymentRequirement
+
+	// Session holds the session claims when the request was authorized by a session.
+	Session *SessionClaims
+
+	// Settlement is the settlement response once Settle succeeded.
+	Settlement *x402.SettlementResponse
+
+	// SettlementDeferred reports that Settle let the response proceed before settlement
+	// completed, because it exceeded Config.SettleLatencyBudget. Settlement is then nil.
+	SettlementDeferred bool
+
+	// Free reports whether the verified payer was granted free access and not charged.
+	Free bool
+
+	// Reputation is the ReputationProvider's assessment of the payer, if any.
+	Reputation *Reputation
+
+	// Replay is the stored response to send instead of running the handler when the
+	// request repeated the Idempotency-Key of an operation already paid for. Proceed is
+	// false and the request's payment is not settled.
+	Replay *IdempotentResponse
+
+	payment       x402.PaymentPayload
+	facilitator   *FacilitatorClient
+	release       func()
+	rejectedPayer string
+
+	idempotencyKey string
+	idempotency    *IdempotencyConfig
+
+	// fullPrice holds the unprorated requirements of a request priced by RangePricing.
+	fullPrice []x402.PaymentRequirement
+
+	// method, resourceURL and paymentHeader identify the request and its payment, to
+	// sign settlement receipts and the requirements of a 402 sent instead of the
+	// handler's response for.
+	method        string
+	resourceURL   string
+	paymentHeader string
+}
+
+// NewEngine creates an Engine for config. It returns an error if config.Validate fails.
+// It enriches the payment requirements with facilitator-specific data (like feePayer
+// for SVM chains) from the facilitator's /supported endpoint.
+func NewEngine(config *Config) (*Engine, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Create facilitator client
+	facilitator := &FacilitatorClient{
+		BaseURL:               config.FacilitatorURL,
+		Client:                config.FacilitatorHTTPClient,
+		Timeouts:              config.Timeouts(),
+		Authorization:         config.FacilitatorAuthorization,
+		AuthorizationProvider: config.FacilitatorAuthorizationProvider,
+		SigningSecret:         config.FacilitatorSigningSecret,
+		OnBeforeVerify:        config.FacilitatorOnBeforeVerify,
+		OnAfterVerify:         config.FacilitatorOnAfterVerify,
+		OnBeforeSettle:        config.FacilitatorOnBeforeSettle,
+		OnAfterSettle:         config.FacilitatorOnAfterSettle,
+	}
+
+	// Create fallback facilitator client if configured
+	var fallbackFacilitator *FacilitatorClient
+	if config.FallbackFacilitatorURL != "" {
+		fallbackFacilitator = &FacilitatorClient{
+			BaseURL:               config.FallbackFacilitatorURL,
+			Client:                config.FacilitatorHTTPClient,
+			Timeouts:              config.Timeouts(),
+			Authorization:         config.FallbackFacilitatorAuthorization,
+			AuthorizationProvider: config.FallbackFacilitatorAuthorizationProvider,
+			SigningSecret:         config.FallbackFacilitatorSigningSecret,
+			OnBeforeVerify:        config.FallbackFacilitatorOnBeforeVerify,
+			OnAfterVerify:         config.FallbackFacilitatorOnAfterVerify,
+			OnBeforeSettle:        config.FallbackFacilitatorOnBeforeSettle,
+			OnAfterSettle:         config.FallbackFacilitatorOnAfterSettle,
+		}
+	}
+
+	// Route payments to per-network facilitators when configured
+	router := NewFacilitatorRouter(facilitator, config)
+
+	// Enrich payment requirements with facilitator-specific data (like feePayer)
+	enrichedRequirements, err := router.EnrichRequirements(config.PaymentRequirements)
+	if err != nil {
+		// Log warning but continue with whatever could be enriched
+		slog.Default().Warn("failed to enrich payment requirements from facilitator", "error", err)
+	} else {
+		slog.Default().Info("payment requirements enriched from facilitator", "count", len(enrichedRequirements))
+	}
+
+	// Clients cannot pay requirements still missing the extra fields of their scheme
+	settleTimeout := config.Timeouts().SettleTimeout
+	for _, requirement := range enrichedRequirements {
+		if err := requirement.ValidateForScheme(); err != nil {
+			slog.Default().Warn("payment requirement cannot be paid", "network", requirement.Network, "asset", requirement.Asset, "error", err)
+		}
+		// Authorizations that expire before a slow settlement completes are not charged
+		if timeout := time.Duration(requirement.MaxTimeoutSeconds) * time.Second; timeout < settleTimeout {
+			slog.Default().Warn("payment requirement timeout is shorter than the settle timeout",
+				"network", requirement.Network, "maxTimeout", timeout, "settleTimeout", settleTimeout)
+		}
+	}
+
+	return &Engine{
+		config:       config,
+		router:       router,
+		fallback:     fallbackFacilitator,
+		requirements: enrichedRequirements,
+	}, nil
+}
+
+// MustNewEngine is like NewEngine but panics if config is invalid, matching the
+// middleware constructors.
+func MustNewEngine(config *Config) *Engine {
+	engine, err := NewEngine(config)
+	if err != nil {
+		panic(fmt.Sprintf("x402: invalid middleware config: %v", err))
+	}
+	return engine
+}
+
+// Authorize decides whether req may proceed to the handler. It runs the bypass checks,
+// then parses, claims and verifies the request's payment. A verified payment is not
+// charged until Settle is called.
+func (e *Engine) Authorize(ctx context.Context, req EngineRequest) *Decision {
+	d := e.authorize(ctx, req)
+	d.method, d.resourceURL, d.paymentHeader = req.Method, req.ResourceURL, req.Header.Get("X-PAYMENT")
+	return e.config.signRequirements(d, req.Method, req.ResourceURL)
+}
+
+// authorize implements Authorize, before the requirements of a 402 are signed.
+func (e *Engine) authorize(ctx context.Context, req EngineRequest) *Decision {
+	logger := slog.Default()
+	config := e.config
+
+	// Bypass payment verification for CORS preflight requests
+	if req.Method == http.MethodOptions {
+		logger.Debug("bypassing OPTIONS request")
+		return &Decision{Proceed: true}
+	}
+
+	// Populate resource field in requirements with the actual request URL
+	requirementsWithResource := make([]x402.PaymentRequirement, len(e.requirements))
+	for i, requirement := range e.requirements {
+		requirementsWithResource[i] = requirement
+		requirementsWithResource[i].Resource = req.ResourceURL
+		if requirementsWithResource[i].Description == "" {
+			requirementsWithResource[i].Description = "Payment required for " + req.Path
+		}
+	}
+	// Keep the full price of range-priced requests for responses that ignore the range
+	var fullPrice []x402.PaymentRequirement
+	rangePriced, prorated := config.priceRange(ctx, req, requirementsWithResource)
+	if prorated {
+		fullPrice = config.SortAccepts(ctx, requirementsWithResource)
+	}
+	requirementsWithResource = config.SortAccepts(ctx, rangePriced)
+
+	// The config's request checks read headers and context only
+	r := (&http.Request{Method: req.Method, Header: req.Header}).WithContext(ctx)
+
+	// Requests with a valid API key bypass payment
+	if config.HasValidAPIKey(r) {
+		logger.Info("request authorized by API key", "path", req.Path)
+		return &Decision{Proceed: true}
+	}
+
+	// Requests with a valid coupon bypass payment
+	if token := req.Header.Get(coupons.Header); token != "" && config.Coupons != nil {
+		coupon, err := config.Coupons.Redeem(ctx, token, req.Path)
+		if err != nil {
+			logger.Warn("coupon rejected", "error", err)
+		} else {
+			logger.Info("coupon redeemed", "coupon", coupon.ID)
+			return &Decision{Proceed: true}
+		}
+	}
+
+	// Requests within a paid session bypass payment
+	if config.Sessions != nil {
+		claims, err := config.Sessions.Authorize(r)
+		if err == nil {
+			logger.Info("request authorized by session", "payer", claims.Payer)
+			return &Decision{Proceed: true, Session: claims}
+		}
+		logger.Debug("no usable session", "error", err)
+	}
+
+	// Serve clients within their free quota without payment
+	if config.FreeQuota != nil && !config.FreeQuota.PerPayer {
+		allowed, err := config.FreeQuota.Allow(ctx, req.ClientIP)
+		if err != nil {
+			logger.Error("free quota check failed", "error", err)
+		} else if allowed {
+			logger.Info("serving request within free quota", "client", req.ClientIP)
+			return &Decision{Proceed: true}
+		}
+	}
+
+	// Cache revalidation of unchanged resources is answered without payment
+	if decision := config.notModified(ctx, req); decision != nil {
+		logger.Info("resource not modified, skipping payment", "path", req.Path)
+		return decision
+	}
+
+	// Price batches by their number of operations
+	requirementsWithResource, err := config.priceBatch(ctx, req, requirementsWithResource)
+	if err != nil {
+		logger.Warn("failed to read request body for pricing", "error", err)
+		if errors.Is(err, errBodyTooLarge) {
+			return failure(http.StatusRequestEntityTooLarge, "", "Request body too large")
+		}
+		return failure(http.StatusBadRequest, "", "Invalid request body")
+	}
+
+	// Price the request by its content
+	requirementsWithResource, err = config.RequirementsFor(ctx, req, requirementsWithResource)
+	if err != nil {
+		logger.Info("request rejected by requirements function", "error", err)
+		if errors.Is(err, errBodyTooLarge) {
+			return failure(http.StatusRequestEntityTooLarge, "", "Request body too large")
+		}
+		return failure(http.StatusBadRequest, "", err.Error())
+	}
+
+	// Check for X-PAYMENT header
+	if req.Header.Get("X-PAYMENT") == "" {
+		// No payment provided - return 402 with requirements
+		logger.Info("no payment header provided", "path", req.Path)
+		return paymentRequired(requirementsWithResource)
+	}
+
+	// Parse payment header
+	payment, err := helpers.ParsePaymentHeaderFromRequest(r)
+	if err != nil {
+		logger.Warn("invalid payment header", "error", err)
+		return failure(http.StatusBadRequest, x402.ErrCodeMalformedHeader, malformedHeaderMessage)
+	}
+
+	// Check the payer's reputation before anything is verified or charged
+	payer := helpers.GetPayer(payment)
+	reputation, err := config.PayerReputation(ctx, payer, payment.Network)
+	if err != nil {
+		logger.Error("reputation check failed", "payer", payer, "error", err)
+		reputation = nil
+	}
+	if reputation != nil && reputation.Deny {
+		logger.Warn("payer denied by reputation", "payer", payer, "signals", reputation.Signals)
+		return failure(http.StatusForbidden, x402.ErrCodePayerDenied, "Payer denied")
+	}
+
+	// Apply payer-specific pricing now that the payer is known
+	baseRequirements := requirementsWithResource
+	requirementsWithResource, free := config.RequirementsForPayer(payer, requirementsWithResource)
+	if !free {
+		requirementsWithResource = reputation.Surcharge(requirementsWithResource)
+	}
+
+	// Find matching requirement
+	requirement, err := helpers.FindMatchingRequirement(payment, requirementsWithResource)
+	if err != nil {
+		logger.Warn("no matching requirement", "error", err)
+		return paymentRequired(requirementsWithResource)
+	}
+
+	// The payment was signed for the advertised price, so a payer priced differently must
+	// sign again for its own price before anything is verified or settled
+	if repriced(payment, baseRequirements, requirement) {
+		logger.Info("payment signed for another price", "payer", payer, "price", requirement.MaxAmountRequired)
+		return paymentRejected(requirementsWithResource, ReasonPriceChanged, payer)
+	}
+
+	// Screen the payment's addresses against sanctions lists
+	if config.Sanctions != nil {
+		if err := config.Sanctions.Check(ctx, payment.Network, payer, requirement.PayTo); err != nil {
+			if errors.Is(err, sanctions.ErrBlocked) {
+				logger.Warn("payment blocked by sanctions screening", "error", err)
+				return failure(http.StatusForbidden, x402.ErrCodeAddressBlocked, "Payment address blocked")
+			}
+			logger.Error("sanctions screening failed", "error", err)
+			return failure(http.StatusServiceUnavailable, x402.ErrCodeVerificationFailed, "Payment verification failed")
+		}
+	}
+
+	// Serve simulated payments without the facilitator, on testnets only
+	if payment.Simulated {
+		if !config.AcceptSimulatedPayments || !x402.IsTestnet(requirement.Network) {
+			logger.Warn("simulated payment rejected", "network", payment.Network)
+			return paymentRejected(requirementsWithResource, ReasonSimulatedPayment, payer)
+		}
+		logger.Info("accepting simulated payment", "payer", payer, "network", payment.Network)
+		return &Decision{
+			Proceed:      true,
+			Requirements: requirementsWithResource,
+			Payment:      &facilitator.VerifyResponse{IsValid: true, Payer: payer},
+			Header:       config.paidResponseHeader(),
+			Free:         free,
+			Reputation:   reputation,
+			payment:      payment,
+			Requirement:  requirement,
+		}
+	}
+
+	// Reject payments bound to another connection or DPoP key
+	if err := config.checkBinding(req, payment); err != nil {
+		logger.Warn("payment binding rejected", "payer", payer, "error", err)
+		return paymentRejected(requirementsWithResource, ReasonBindingMismatch, payer)
+	}
+
+	// Reject authorizations already accepted by this or another replica
+	release, err := config.ClaimPayment(ctx, payment, requirement)
+	if errors.Is(err, ErrPaymentAlreadyUsed) {
+		logger.Warn("payment replay rejected", "payer", payer)
+		return paymentRequired(requirementsWithResource)
+	}
+	if err != nil {
+		logger.Error("payment claim failed", "error", err)
+		return failure(http.StatusServiceUnavailable, x402.ErrCodeVerificationFailed, "Payment verification failed")
+	}
+
+	// Verify payment with the facilitator responsible for this network
+	facilitator := e.router.ForNetwork(payment.Network)
+	logger.Info("verifying payment", "scheme", payment.Scheme, "network", payment.Network)
+	verifyCtx, cancelVerify := config.VerifyContext(ctx)
+	verifyResp, err := facilitator.Verify(verifyCtx, payment, requirement)
+	if err != nil && e.fallback != nil {
+		logger.Warn("primary facilitator failed, trying fallback", "error", err)
+		verifyResp, err = e.fallback.Verify(verifyCtx, payment, requirement)
+	}
+	cancelVerify()
+	if err != nil {
+		logger.Error("facilitator verification failed", "error", err)
+		release()
+		return facilitatorFailure(err, x402.ErrCodeVerificationFailed, "Payment verification failed")
+	}
+
+	if !verifyResp.IsValid {
+		logger.Warn("payment verification failed", "reason", verifyResp.InvalidReason)
+		release()
+		return paymentRejected(requirementsWithResource, verifyResp.InvalidReason, verifyResp.Payer)
+	}
+
+	// Payment verified successfully
+	logger.Info("payment verified", "payer", verifyResp.Payer)
+
+	// Replay the stored response of an operation already paid for instead of charging again
+	if verifyResp.Payer != "" {
+		payer = verifyResp.Payer
+	}
+	inflight := time.Duration(requirement.MaxTimeoutSeconds)*time.Second + time.Minute
+	idempotencyKey, replay, err := config.beginIdempotent(ctx, req, payer, inflight)
+	if errors.Is(err, ErrIdempotencyKeyInUse) {
+		logger.Warn("idempotency key in use", "payer", payer)
+		release()
+		return failure(http.StatusConflict, x402.ErrCodeRequestInProgress, "A request with this Idempotency-Key is in progress")
+	}
+	if err != nil {
+		logger.Error("idempotency lookup failed", "error", err)
+		release()
+		return failure(http.StatusServiceUnavailable, x402.ErrCodeVerificationFailed, "Payment verification failed")
+	}
+	if replay != nil {
+		logger.Info("replaying response for idempotency key, payment not settled", "payer", payer)
+		release()
+		return &Decision{Status: replay.Status, Replay: replay}
+	}
+
+	// Verified payers within their free quota are not charged
+	if !free && config.FreeQuota != nil && config.FreeQuota.PerPayer && verifyResp.Payer != "" {
+		allowed, err := config.FreeQuota.Allow(ctx, verifyResp.Payer)
+		if err != nil {
+			logger.Error("free quota check failed", "error", err)
+		}
+		free = allowed
+	}
+
+	return &Decision{
+		Proceed:        true,
+		Requirements:   requirementsWithResource,
+		Payment:        verifyResp,
+		Header:         config.paidResponseHeader(),
+		Free:           free,
+		Reputation:     reputation,
+		payment:        payment,
+		Requirement:    requirement,
+		facilitator:    facilitator,
+		release:        release,
+		idempotencyKey: idempotencyKey,
+		idempotency:    config.Idempotency,
+		fullPrice:      fullPrice,
+	}
+}
+
+// SettleResponse is Settle for a handler response with status, for adapters that settle
+// when the handler writes its status. A payment prorated by RangePricing is only settled
+// for 206 Partial Content; for any other status SettleResponse returns a 402 offering the
+// full price, with reason ReasonRangeNotServed, to send instead of the handler's response.
+func (e *Engine) SettleResponse(ctx context.Context, d *Decision, status int) *Decision {
+	if d.fullPrice != nil && d.Payment != nil && !d.Free && !e.config.VerifyOnly && status != http.StatusPartialContent {
+		slog.Default().Warn("range-priced request not answered with partial content", "status", status)
+		d.Release()
+		return e.config.signRequirements(paymentRejected(d.fullPrice, ReasonRangeNotServed, d.Payment.Payer), d.method, d.resourceURL)
+	}
+	return e.Settle(ctx, d)
+}
+
+// Settle charges the payment verified by Authorize, unless the engine is in verify-only
+// mode or the payer was granted free access. It must be called before the response
+// status is written, and only for a Decision that proceeded. On success it returns d
+// with Settlement and Header set; otherwise it returns a Decision describing the error
+// response to send instead of the handler's.
+func (e *Engine) Settle(ctx context.Context, d *Decision) *Decision {
+	if d.Payment == nil || e.config.VerifyOnly {
+		return d
+	}
+	logger := slog.Default()
+	if d.Free {
+		logger.Info("free access granted, skipping settlement", "payer", d.Payment.Payer)
+		return d
+	}
+
+	if d.payment.Simulated {
+		logger.Info("simulated payment, skipping settlement", "payer", d.Payment.Payer)
+		d.Settlement = &x402.SettlementResponse{
+			Success:   true,
+			Network:   d.Requirement.Network,
+			Payer:     d.Payment.Payer,
+			Simulated: true,
+			Reference: d.Requirement.Reference(),
+		}
+		w := headerWriter{header: d.header()}
+		if err := helpers.AddPaymentResponseHeader(w, d.Settlement); err != nil {
+			logger.Warn("failed to add payment response header", "error", err)
+		}
+		e.config.SignPaymentResponse(w.header, d.paymentHeader, d.resourceURL)
+		return d
+	}
+
+	// An authorization past its deadline cannot be settled; ask for a fresh one
+	if validBefore, ok := helpers.GetValidBefore(d.payment); ok && time.Now().Unix() >= validBefore {
+		logger.Warn("payment expired before settlement", "payer", d.Payment.Payer)
+		d.Release()
+		return e.config.signRequirements(paymentRejected(d.Requirements, ReasonPaymentExpired, d.Payment.Payer), d.method, d.resourceURL)
+	}
+
+	logger.Info("settling payment", "payer", d.Payment.Payer)
+	settlementResp, deferred, err := e.settleWithinBudget(ctx, d)
+	if deferred {
+		// The payment was verified and stays claimed, so the response can go out now
+		d.SettlementDeferred = true
+		d.header().Set(SettlementStatusHeader, "pending")
+		return d
+	}
+	if err != nil {
+		logger.Error("settlement failed", "error", err)
+		d.Release()
+		return facilitatorFailure(err, x402.ErrCodeSettlementFailed, "Payment settlement failed")
+	}
+
+	if !settlementResp.Success {
+		logger.Warn("settlement unsuccessful", "reason", settlementResp.ErrorReason)
+		d.Release()
+		reason := settlementResp.ErrorReason
+		if isExpiryReason(reason) {
+			reason = ReasonPaymentExpired
+		}
+		return e.config.signRequirements(paymentRejected(d.Requirements, reason, d.Payment.Payer), d.method, d.resourceURL)
+	}
+
+	logger.Info("payment settled", "transaction", settlementResp.Transaction)
+	if settlementResp.Reference == "" {
+		settlementResp.Reference = d.Requirement.Reference()
+	}
+	d.Settlement = settlementResp
+
+	// Add X-PAYMENT-RESPONSE header with settlement info
+	w := headerWriter{header: d.header()}
+	if err := helpers.AddPaymentResponseHeader(w, settlementResp); err != nil {
+		logger.Warn("failed to add payment response header", "error", err)
+		// Continue anyway - payment was successful
+	}
+	e.config.SignPaymentResponse(w.header, d.paymentHeader, d.resourceURL)
+
+	if e.config.Sessions != nil {
+		if err := e.config.Sessions.Grant(w, d.Payment.Payer); err != nil {
+			logger.Warn("failed to issue session", "error", err)
+		}
+	}
+	return d
+}
+
+// Release gives up the claim on the decision's payment authorization so it can be
+// retried, e.g. when the handler failed and the payment will not be settled.
+func (d *Decision) Release() {
+	if d.release != nil {
+		d.release()
+		d.release = nil
+	}
+	if d.idempotencyKey != "" {
+		_ = d.idempotency.store().Abort(context.Background(), d.idempotencyKey)
+		d.idempotencyKey = ""
+	}
+}
+
+// Body returns the JSON body to send when Proceed is false: the ErrorResponse, or the
+// payment requirements for a 402.
+func (d *Decision) Body() any {
+	if d.Error != nil {
+		return d.Error
+	}
+	message := "Payment required for this resource"
+	if d.Reason != "" {
+		message = "Payment rejected"
+	}
+	return x402.PaymentRequirementsResponse{
+		X402Version: 1,
+		Error:       message,
+		Accepts:     d.Requirements,
+		Reason:      d.Reason,
+		Payer:       d.rejectedPayer,
+	}
+}
+
+// CopyHeader adds the decision's response headers to h.
+func (d *Decision) CopyHeader(h http.Header) {
+	for key, values := range d.Header {
+		for _, value := range values {
+			h.Add(key, value)
+		}
+	}
+}
+
+// header returns the decision's response headers, creating them if needed.
+func (d *Decision) header() http.Header {
+	if d.Header == nil {
+		d.Header = make(http.Header)
+	}
+	return d.Header
+}
+
+// paymentRequired returns a 402 decision offering requirements, advertising the compact
+// X-PAYMENT encoding.
+func paymentRequired(requirements []x402.PaymentRequirement) *Decision {
+	d := &Decision{Status: http.StatusPaymentRequired, Requirements: requirements}
+	d.header().Set(PaymentEncodingsHeader, encoding.PaymentEncodingCBOR)
+	return d
+}
+
+// paymentRejected returns a 402 decision for a payment rejected with reason. Without a reason it is a plain paymentRequired.
+func paymentRejected(requirements []x402.PaymentRequirement, reason, payer string) *Decision {
+	d := paymentRequired(requirements)
+	if reason != "" {
+		d.Reason = reason
+		d.rejectedPayer = payer
+	}
+	return d
+}
+
+// repriced reports whether payment, which matched requirement, was signed for a different
+// amount than requirement asks of its payer while base, the advertised requirements,
+// priced it differently. Payments whose amount cannot be read are left to the facilitator.
+func repriced(payment x402.PaymentPayload, base []x402.PaymentRequirement, requirement x402.PaymentRequirement) bool {
+	value, ok := helpers.GetValue(payment)
+	if !ok || value == requirement.MaxAmountRequired {
+		return false
+	}
+	advertised, err := helpers.FindMatchingRequirement(payment, base)
+	return err == nil && advertised.MaxAmountRequired != requirement.MaxAmountRequired
+}
+
+// isExpiryReason reports whether a facilitator reason code means the payment's
+// authorization expired, e.g. "invalid_exact_evm_payload_authorization_valid_before".
+func isExpiryReason(reason string) bool {
+	return strings.Contains(reason, "valid_before") || strings.Contains(reason, "expired")
+}
+
+// failure returns a decision rejecting the request with an ErrorResponse.
+func failure(status int, code x402.ErrorCode, message string) *Decision {
+	return &Decision{
+		Status: status,
+		Error:  &ErrorResponse{X402Version: 1, Error: message, Code: code},
+	}
+}
+
+// facilitatorFailure returns the decision for a failed verify or settle call, mapping
+// err to a status code and error code. fallback is used when err carries no more
+// specific code.
+func facilitatorFailure(err error, fallback x402.ErrorCode, message string) *Decision {
+	status, message := FacilitatorFailure(err, message)
+
+	code := fallback
+	var paymentErr *x402.PaymentError
+	switch {
+	case errors.As(err, &paymentErr):
+		code = paymentErr.Code
+	case errors.Is(err, x402.ErrFacilitatorUnavailable):
+		code = x402.ErrCodeFacilitatorUnavailable
+	}
+	return failure(status, code, message)
+}
+
+// headerWriter is an http.ResponseWriter that only collects headers, for helpers that
+// write headers through a ResponseWriter.
+type headerWriter struct {
+	header http.Header
+}
+
+func (w headerWriter) Header() http.Header         { return w.header }
+func (w headerWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w headerWriter) WriteHeader(int)             {}
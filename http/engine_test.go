@@ -0,0 +1,349 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/sanctions"
+)
+
+func engineRequest(method string, header http.Header) EngineRequest {
+	return EngineRequest{
+		Method:      method,
+		Path:        "/premium",
+		ResourceURL: "https://api.example.com/premium",
+		Header:      header,
+		ClientIP:    "192.0.2.1",
+	}
+}
+
+func TestEngine_Authorize(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.AcceptAPIKeys = []string{"secret"}
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		header      http.Header
+		wantProceed bool
+		wantStatus  int
+		wantCode    x402.ErrorCode
+		wantPayment bool
+	}{
+		{name: "preflight", method: http.MethodOptions, wantProceed: true},
+		{name: "api key", method: http.MethodGet, header: http.Header{"X-Api-Key": {"secret"}}, wantProceed: true},
+		{name: "no payment", method: http.MethodGet, wantStatus: http.StatusPaymentRequired},
+		{
+			name:       "malformed payment",
+			method:     http.MethodGet,
+			header:     http.Header{"X-Payment": {"not-base64!"}},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   x402.ErrCodeMalformedHeader,
+		},
+		{
+			name:        "valid payment",
+			method:      http.MethodGet,
+			header:      http.Header{"X-Payment": {pricingPaymentHeader(t, testPayer)}},
+			wantProceed: true,
+			wantPayment: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			decision := engine.Authorize(context.Background(), engineRequest(tt.method, header))
+
+			if decision.Proceed != tt.wantProceed {
+				t.Fatalf("Proceed = %v, want %v", decision.Proceed, tt.wantProceed)
+			}
+			if !tt.wantProceed && decision.Status != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", decision.Status, tt.wantStatus)
+			}
+			if tt.wantCode != "" && (decision.Error == nil || decision.Error.Code != tt.wantCode) {
+				t.Errorf("Error = %+v, want code %s", decision.Error, tt.wantCode)
+			}
+			if (decision.Payment != nil) != tt.wantPayment {
+				t.Errorf("Payment = %+v, want payment %v", decision.Payment, tt.wantPayment)
+			}
+			if tt.wantStatus == http.StatusPaymentRequired {
+				body, ok := decision.Body().(x402.PaymentRequirementsResponse)
+				if !ok || len(body.Accepts) != 1 || body.Accepts[0].Resource != "https://api.example.com/premium" {
+					t.Errorf("Body = %+v, want requirements for the resource", decision.Body())
+				}
+			}
+		})
+	}
+
+	if settleCalls.Load() != 0 {
+		t.Errorf("Authorize settled %d payments, want 0", settleCalls.Load())
+	}
+}
+
+func TestEngine_Settle(t *testing.T) {
+	tests := []struct {
+		name            string
+		verifyOnly      bool
+		wantSettleCalls int32
+	}{
+		{name: "settles", wantSettleCalls: 1},
+		{name: "verify only", verifyOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifiedAmount atomic.Value
+			var settleCalls atomic.Int32
+			server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+			defer server.Close()
+
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.VerifyOnly = tt.verifyOnly
+			config.PaymentRequirements[0].SetReference("order-7")
+			engine := MustNewEngine(config)
+
+			header := http.Header{"X-Payment": {pricingPaymentHeader(t, testPayer)}}
+			decision := engine.Authorize(context.Background(), engineRequest(http.MethodGet, header))
+			if !decision.Proceed {
+				t.Fatalf("Authorize rejected payment: %d %+v", decision.Status, decision.Error)
+			}
+
+			settled := engine.Settle(context.Background(), decision)
+			if !settled.Proceed {
+				t.Fatalf("Settle failed: %d %+v", settled.Status, settled.Error)
+			}
+			if settleCalls.Load() != tt.wantSettleCalls {
+				t.Errorf("settle calls = %d, want %d", settleCalls.Load(), tt.wantSettleCalls)
+			}
+
+			responseHeader := http.Header{}
+			settled.CopyHeader(responseHeader)
+			hasReceipt := responseHeader.Get("X-PAYMENT-RESPONSE") != ""
+			if hasReceipt != (tt.wantSettleCalls > 0) || (settled.Settlement != nil) != hasReceipt {
+				t.Errorf("X-PAYMENT-RESPONSE present = %v, Settlement = %+v", hasReceipt, settled.Settlement)
+			}
+			if hasReceipt {
+				settlement, err := parseSettlement(responseHeader.Get("X-PAYMENT-RESPONSE"))
+				if err != nil || settlement.Reference != "order-7" {
+					t.Errorf("settlement = %+v (%v), want the requirement's reference order-7", settlement, err)
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_SimulatedPayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      bool
+		chain       x402.ChainConfig
+		wantProceed bool
+	}{
+		{name: "accepted on testnet", accept: true, chain: x402.BaseSepolia, wantProceed: true},
+		{name: "not accepted", chain: x402.BaseSepolia},
+		{name: "mainnet", accept: true, chain: x402.BaseMainnet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifiedAmount atomic.Value
+			var settleCalls atomic.Int32
+			server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+			defer server.Close()
+
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.PaymentRequirements[0].Network = tt.chain.NetworkID
+			config.PaymentRequirements[0].Asset = tt.chain.USDCAddress
+			config.AcceptSimulatedPayments = tt.accept
+			engine := MustNewEngine(config)
+
+			payment, err := encoding.EncodePayment(x402.PaymentPayload{
+				X402Version: 1,
+				Scheme:      "exact",
+				Network:     tt.chain.NetworkID,
+				Payload: x402.EVMPayload{
+					Signature:     "0xsig",
+					Authorization: x402.EVMAuthorization{From: testPayer, Value: "10000"},
+				},
+				Simulated: true,
+			})
+			if err != nil {
+				t.Fatalf("EncodePayment: %v", err)
+			}
+			decision := engine.Authorize(context.Background(), engineRequest(http.MethodGet, http.Header{"X-Payment": {payment}}))
+			if decision.Proceed != tt.wantProceed {
+				t.Fatalf("Proceed = %v, want %v", decision.Proceed, tt.wantProceed)
+			}
+			if !tt.wantProceed {
+				if decision.Status != http.StatusPaymentRequired || decision.Reason != ReasonSimulatedPayment {
+					t.Errorf("Status = %d, Reason = %q, want 402 %q", decision.Status, decision.Reason, ReasonSimulatedPayment)
+				}
+				return
+			}
+
+			settled := engine.Settle(context.Background(), decision)
+			if !settled.Proceed || settled.Settlement == nil || !settled.Settlement.Simulated {
+				t.Fatalf("Settle = %+v, want a simulated settlement", settled)
+			}
+			responseHeader := http.Header{}
+			settled.CopyHeader(responseHeader)
+			if responseHeader.Get("X-PAYMENT-RESPONSE") == "" {
+				t.Error("expected X-PAYMENT-RESPONSE header")
+			}
+			if verifiedAmount.Load() != nil || settleCalls.Load() != 0 {
+				t.Error("simulated payment reached the facilitator")
+			}
+		})
+	}
+}
+
+func TestEngine_Sanctions(t *testing.T) {
+	tests := []struct {
+		name        string
+		list        []string
+		wantProceed bool
+		wantStatus  int
+		wantCode    x402.ErrorCode
+	}{
+		{name: "clean payer", wantProceed: true},
+		{name: "sanctioned payer", list: []string{testPayer}, wantStatus: http.StatusForbidden, wantCode: x402.ErrCodeAddressBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifiedAmount atomic.Value
+			var settleCalls atomic.Int32
+			server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+			defer server.Close()
+
+			var audited []sanctions.AuditRecord
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.Sanctions = &sanctions.Policy{
+				Screener: sanctions.NewStaticList(tt.list...),
+				Audit: func(_ context.Context, record sanctions.AuditRecord) {
+					audited = append(audited, record)
+				},
+			}
+			engine, err := NewEngine(config)
+			if err != nil {
+				t.Fatalf("NewEngine: %v", err)
+			}
+
+			header := http.Header{"X-Payment": {pricingPaymentHeader(t, testPayer)}}
+			decision := engine.Authorize(context.Background(), engineRequest(http.MethodGet, header))
+			if decision.Proceed != tt.wantProceed {
+				t.Fatalf("Proceed = %v, want %v", decision.Proceed, tt.wantProceed)
+			}
+			if !tt.wantProceed {
+				if decision.Status != tt.wantStatus || decision.Error == nil || decision.Error.Code != tt.wantCode {
+					t.Errorf("decision = %d %+v, want %d %s", decision.Status, decision.Error, tt.wantStatus, tt.wantCode)
+				}
+				if verifiedAmount.Load() != nil {
+					t.Error("blocked payment reached the facilitator")
+				}
+			}
+			if len(audited) != 1 || audited[0].Address != testPayer || audited[0].Role != sanctions.RolePayer {
+				t.Errorf("audited = %+v, want one payer record", audited)
+			}
+		})
+	}
+}
+
+func TestNewEngine_InvalidConfig(t *testing.T) {
+	config := validTestConfig()
+	config.FacilitatorURL = ""
+	if _, err := NewEngine(config); err == nil {
+		t.Error("expected error for invalid config")
+	}
+}
+
+func TestNewEngine_WarnsIncompleteRequirements(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	// The facilitator advertises no feePayer, so the Solana requirement stays incomplete
+	config := validTestConfig()
+	config.PaymentRequirements = append(config.PaymentRequirements, x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "solana-devnet",
+		MaxAmountRequired: "10000",
+		Asset:             "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+		PayTo:             "DRpbCBMxVnDK7maPM5tGv6MvB3v1sRMC86PZ8okm21hy",
+		MaxTimeoutSeconds: 60,
+	})
+	config.PaymentRequirements[0].SetEIP3009Domain("USDC", "2")
+	config.FacilitatorHTTPClient = &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return stubResponse(http.StatusOK, []byte(`{"kinds":[]}`)), nil
+		}),
+	}
+
+	if _, err := NewEngine(config); err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	var warnings []string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, "payment requirement cannot be paid") {
+			warnings = append(warnings, line)
+		}
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "solana-devnet") || !strings.Contains(warnings[0], "extra.feePayer") {
+		t.Errorf("warnings = %q, want one for the Solana requirement's feePayer", warnings)
+	}
+}
+
+func TestNewEngine_WarnsShortTimeout(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	config := validTestConfig()
+	config.SettleTimeout = 90 * time.Second
+	config.PaymentRequirements = append(config.PaymentRequirements, config.PaymentRequirements[0])
+	config.PaymentRequirements[1].MaxTimeoutSeconds = 300
+	config.FacilitatorHTTPClient = &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return stubResponse(http.StatusOK, []byte(`{"kinds":[]}`)), nil
+		}),
+	}
+
+	if _, err := NewEngine(config); err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	var warnings []string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, "shorter than the settle timeout") {
+			warnings = append(warnings, line)
+		}
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "maxTimeout=1m0s") {
+		t.Errorf("warnings = %q, want one for the 60s requirement", warnings)
+	}
+}
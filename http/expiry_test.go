@@ -0,0 +1,157 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestMiddleware_ExpiredPayment(t *testing.T) {
+	tests := []struct {
+		name          string
+		validBefore   string
+		settleReason  string
+		wantSettleHit bool
+	}{
+		{
+			name:          "facilitator reports expiry",
+			validBefore:   strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10),
+			settleReason:  "invalid_exact_evm_payload_authorization_valid_before",
+			wantSettleHit: true,
+		},
+		{
+			name:        "expired before settlement",
+			validBefore: strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var settleCalls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/supported":
+					_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+				case "/verify":
+					_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+				case "/settle":
+					settleCalls.Add(1)
+					_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: false, ErrorReason: tt.settleReason})
+				}
+			}))
+			defer server.Close()
+
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			header, err := encoding.EncodePayment(x402.PaymentPayload{
+				X402Version: 1,
+				Scheme:      "exact",
+				Network:     "base-sepolia",
+				Payload: x402.EVMPayload{
+					Signature:     "0xsig",
+					Authorization: x402.EVMAuthorization{From: testPayer, Value: "10000", ValidBefore: tt.validBefore},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Failed to encode payment: %v", err)
+			}
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-PAYMENT", header)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusPaymentRequired {
+				t.Fatalf("expected status 402, got %d", rec.Code)
+			}
+			var body x402.PaymentRequirementsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid 402 body: %v", err)
+			}
+			if body.Reason != ReasonPaymentExpired || len(body.Accepts) == 0 {
+				t.Errorf("402 reason = %q with %d accepts, want %q with the requirements", body.Reason, len(body.Accepts), ReasonPaymentExpired)
+			}
+			if hit := settleCalls.Load() > 0; hit != tt.wantSettleHit {
+				t.Errorf("settle called = %v, want %v", hit, tt.wantSettleHit)
+			}
+		})
+	}
+}
+
+func TestClient_RenegotiatesExpiredPayment(t *testing.T) {
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		MaxTimeoutSeconds: 60,
+	}
+
+	tests := []struct {
+		name         string
+		expirations  int32
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{"expires once", 1, false, 2},
+		{"keeps expiring", 5, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-PAYMENT") == "" {
+					w.WriteHeader(http.StatusPaymentRequired)
+					_, _ = w.Write(makePaymentRequirementsResponse(requirement))
+					return
+				}
+				if attempts.Add(1) <= tt.expirations {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusPaymentRequired)
+					_ = json.NewEncoder(w).Encode(x402.PaymentRequirementsResponse{
+						X402Version: 1,
+						Error:       "Payment rejected",
+						Accepts:     []x402.PaymentRequirement{requirement},
+						Reason:      ReasonPaymentExpired,
+					})
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(server.URL)
+			if tt.wantErr {
+				var paymentErr *x402.PaymentError
+				if !errors.As(err, &paymentErr) || paymentErr.Details["reason"] != ReasonPaymentExpired {
+					t.Errorf("expected a payment_expired PaymentError, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("request failed: %v", err)
+			} else {
+				resp.Body.Close()
+			}
+			if attempts.Load() != tt.wantAttempts {
+				t.Errorf("paid attempts = %d, want %d", attempts.Load(), tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -37,7 +37,7 @@ type OnAfterSettleFunc func(context.Context, x402.PaymentPayload, x402.PaymentRe
 // FacilitatorClient is a client for communicating with x402 facilitator services.
 type FacilitatorClient struct {
 	BaseURL    string
-	Client     *http.Client
+	Client     *http.Client       // HTTP client used for requests (default http.DefaultClient)
 	Timeouts   x402.TimeoutConfig // Timeout configuration for payment operations
 	MaxRetries int                // Maximum number of retry attempts for failed requests (default: 0)
 	RetryDelay time.Duration      // Delay between retry attempts (default: 100ms)
@@ -51,6 +51,11 @@ type FacilitatorClient struct {
 	// If set, this takes precedence over the static Authorization field.
 	AuthorizationProvider AuthorizationProvider
 
+	// SigningSecret enables HMAC request signing for private facilitator deployments.
+	// When set, every request carries X-X402-Timestamp and X-X402-Signature headers
+	// that the facilitator can check with VerifyRequestSignature.
+	SigningSecret []byte
+
 	// OnBeforeVerify is called before the Verify operation starts.
 	// If it returns an error, the operation is aborted immediately.
 	OnBeforeVerify OnBeforeFunc
@@ -66,6 +71,14 @@ type FacilitatorClient struct {
 	OnAfterSettle OnAfterSettleFunc
 }
 
+// httpClient returns the configured HTTP client or http.DefaultClient.
+func (c *FacilitatorClient) httpClient() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
 // setAuthorizationHeader sets the Authorization header on the request if configured.
 // If AuthorizationProvider is set, it is called to get the current token value;
 // otherwise, the static Authorization string is used. This is called per-request.
@@ -142,11 +155,12 @@ func (c *FacilitatorClient) Verify(ctx context.Context, payment x402.PaymentPayl
 		}
 		httpReq.Header.Set("Content-Type", "application/json")
 		c.setAuthorizationHeader(httpReq)
+		signRequest(httpReq, c.SigningSecret, data)
 
 		// Send request
-		resp, err := c.Client.Do(httpReq)
+		resp, err := c.httpClient().Do(httpReq)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v", x402.ErrFacilitatorUnavailable, err)
+			return nil, fmt.Errorf("%w: %w", x402.ErrFacilitatorUnavailable, err)
 		}
 		defer resp.Body.Close()
 
@@ -183,6 +197,10 @@ func (c *FacilitatorClient) Verify(ctx context.Context, payment x402.PaymentPayl
 		return &verifyResp, nil
 	})
 
+	if resultErr != nil && errors.Is(resultErr, context.DeadlineExceeded) {
+		resultErr = x402.NewPaymentError(x402.ErrCodeVerifyTimeout, "facilitator verify timed out", resultErr)
+	}
+
 	if c.OnAfterVerify != nil {
 		c.OnAfterVerify(ctx, payment, requirement, resp, resultErr)
 	}
@@ -205,11 +223,12 @@ func (c *FacilitatorClient) Supported(ctx context.Context) (*facilitator.Support
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	c.setAuthorizationHeader(httpReq)
+	signRequest(httpReq, c.SigningSecret, nil)
 
 	// Send request
-	resp, err := c.Client.Do(httpReq)
+	resp, err := c.httpClient().Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", x402.ErrFacilitatorUnavailable, err)
+		return nil, fmt.Errorf("%w: %w", x402.ErrFacilitatorUnavailable, err)
 	}
 	defer resp.Body.Close()
 
@@ -280,11 +299,12 @@ func (c *FacilitatorClient) Settle(ctx context.Context, payment x402.PaymentPayl
 		}
 		httpReq.Header.Set("Content-Type", "application/json")
 		c.setAuthorizationHeader(httpReq)
+		signRequest(httpReq, c.SigningSecret, data)
 
 		// Send request
-		resp, err := c.Client.Do(httpReq)
+		resp, err := c.httpClient().Do(httpReq)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v", x402.ErrFacilitatorUnavailable, err)
+			return nil, fmt.Errorf("%w: %w", x402.ErrFacilitatorUnavailable, err)
 		}
 		defer resp.Body.Close()
 
@@ -313,6 +333,10 @@ func (c *FacilitatorClient) Settle(ctx context.Context, payment x402.PaymentPayl
 		return &settlementResp, nil
 	})
 
+	if resultErr != nil && errors.Is(resultErr, context.DeadlineExceeded) {
+		resultErr = x402.NewPaymentError(x402.ErrCodeSettleTimeout, "facilitator settle timed out", resultErr)
+	}
+
 	if c.OnAfterSettle != nil {
 		c.OnAfterSettle(ctx, payment, requirement, resp, resultErr)
 	}
@@ -320,43 +344,30 @@ func (c *FacilitatorClient) Settle(ctx context.Context, payment x402.PaymentPayl
 	return resp, resultErr
 }
 
+// SupportedKinds returns the payment kinds (scheme, network and extra data) the
+// facilitator supports.
+func (c *FacilitatorClient) SupportedKinds(ctx context.Context) ([]facilitator.SupportedKind, error) {
+	supported, err := c.Supported(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return supported.Kinds, nil
+}
+
 // EnrichRequirements fetches supported payment types from the facilitator and
 // enriches the provided payment requirements with network-specific data like feePayer.
 // This is particularly useful for SVM chains where the feePayer must be specified.
+// See facilitator.EnrichFrom.
 func (c *FacilitatorClient) EnrichRequirements(requirements []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
-	// Fetch supported payment types
-	supported, err := c.Supported(context.Background())
+	kinds, err := c.SupportedKinds(context.Background())
 	if err != nil {
 		return requirements, fmt.Errorf("failed to fetch supported payment types: %w", err)
 	}
 
-	// Create a lookup map for supported kinds by network
-	supportedMap := make(map[string]facilitator.SupportedKind)
-	for _, kind := range supported.Kinds {
-		key := kind.Network + "-" + kind.Scheme
-		supportedMap[key] = kind
-	}
-
-	// Enrich each requirement with extra data from the facilitator
 	enriched := make([]x402.PaymentRequirement, len(requirements))
 	for i, req := range requirements {
-		enriched[i] = req
-		key := req.Network + "-" + req.Scheme
-		if kind, ok := supportedMap[key]; ok && kind.Extra != nil {
-			// Initialize Extra map if it doesn't exist
-			if enriched[i].Extra == nil {
-				enriched[i].Extra = make(map[string]any)
-			}
-			// Merge facilitator's extra data into requirement
-			for k, v := range kind.Extra {
-				// Only set if not already present (user-specified values take precedence)
-				if _, exists := enriched[i].Extra[k]; !exists {
-					enriched[i].Extra[k] = v
-				}
-			}
-		}
+		enriched[i] = facilitator.EnrichFrom(req, kinds)
 	}
-
 	return enriched, nil
 }
 
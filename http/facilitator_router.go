@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// FacilitatorResolver returns the facilitator URL responsible for a network.
+// Returning an empty string routes the network to the primary facilitator.
+type FacilitatorResolver func(network string) string
+
+// FacilitatorRouter selects the facilitator client responsible for a payment's network.
+// Networks without a dedicated facilitator are handled by the primary client.
+//
+// Per-network clients are cloned from the primary client, so they share its HTTP client,
+// timeouts, authorization, signing secret and hooks; only BaseURL differs.
+//
+// FacilitatorRouter is safe for concurrent use.
+type FacilitatorRouter struct {
+	primary   *FacilitatorClient
+	byNetwork map[string]string
+	resolver  FacilitatorResolver
+
+	mu      sync.Mutex
+	clients map[string]*FacilitatorClient
+}
+
+// NewFacilitatorRouter creates a router around the primary facilitator client using the
+// FacilitatorByNetwork and FacilitatorResolver settings from config.
+func NewFacilitatorRouter(primary *FacilitatorClient, config *Config) *FacilitatorRouter {
+	return &FacilitatorRouter{
+		primary:   primary,
+		byNetwork: config.FacilitatorByNetwork,
+		resolver:  config.FacilitatorResolver,
+		clients:   make(map[string]*FacilitatorClient),
+	}
+}
+
+// Primary returns the default facilitator client.
+func (r *FacilitatorRouter) Primary() *FacilitatorClient {
+	return r.primary
+}
+
+// ForNetwork returns the facilitator client for the given network.
+// FacilitatorResolver takes precedence over FacilitatorByNetwork.
+func (r *FacilitatorRouter) ForNetwork(network string) *FacilitatorClient {
+	var url string
+	if r.resolver != nil {
+		url = r.resolver(network)
+	}
+	if url == "" {
+		url = r.byNetwork[network]
+	}
+	if url == "" || url == r.primary.BaseURL {
+		return r.primary
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client, ok := r.clients[url]; ok {
+		return client
+	}
+	client := *r.primary
+	client.BaseURL = url
+	r.clients[url] = &client
+	return &client
+}
+
+// EnrichRequirements enriches each requirement from the facilitator responsible for its network.
+// Requirements whose facilitator cannot be reached are returned unchanged; the returned error
+// joins every enrichment failure.
+func (r *FacilitatorRouter) EnrichRequirements(requirements []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
+	// Group requirement indexes by facilitator, preserving first-seen order
+	var order []*FacilitatorClient
+	groups := make(map[*FacilitatorClient][]int)
+	for i, req := range requirements {
+		client := r.ForNetwork(req.Network)
+		if _, ok := groups[client]; !ok {
+			order = append(order, client)
+		}
+		groups[client] = append(groups[client], i)
+	}
+
+	enriched := make([]x402.PaymentRequirement, len(requirements))
+	copy(enriched, requirements)
+
+	var errs []error
+	for _, client := range order {
+		indexes := groups[client]
+		subset := make([]x402.PaymentRequirement, len(indexes))
+		for j, idx := range indexes {
+			subset[j] = requirements[idx]
+		}
+
+		result, err := client.EnrichRequirements(subset)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for j, idx := range indexes {
+			enriched[idx] = result[j]
+		}
+	}
+
+	return enriched, errors.Join(errs...)
+}
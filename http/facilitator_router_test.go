@@ -0,0 +1,219 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestFacilitatorRouter_ForNetwork(t *testing.T) {
+	primary := &FacilitatorClient{
+		BaseURL:       "https://primary.example.com",
+		Client:        &http.Client{},
+		Timeouts:      x402.DefaultTimeouts,
+		Authorization: "Bearer shared",
+	}
+
+	tests := []struct {
+		name    string
+		config  *Config
+		network string
+		wantURL string
+	}{
+		{
+			name:    "no routing configured",
+			config:  &Config{},
+			network: "solana",
+			wantURL: "https://primary.example.com",
+		},
+		{
+			name: "network mapped",
+			config: &Config{
+				FacilitatorByNetwork: map[string]string{"solana": "https://svm.example.com"},
+			},
+			network: "solana",
+			wantURL: "https://svm.example.com",
+		},
+		{
+			name: "network not mapped",
+			config: &Config{
+				FacilitatorByNetwork: map[string]string{"solana": "https://svm.example.com"},
+			},
+			network: "base",
+			wantURL: "https://primary.example.com",
+		},
+		{
+			name: "resolver takes precedence",
+			config: &Config{
+				FacilitatorByNetwork: map[string]string{"solana": "https://svm.example.com"},
+				FacilitatorResolver: func(network string) string {
+					if network == "solana" {
+						return "https://resolved.example.com"
+					}
+					return ""
+				},
+			},
+			network: "solana",
+			wantURL: "https://resolved.example.com",
+		},
+		{
+			name: "resolver falls through to map",
+			config: &Config{
+				FacilitatorByNetwork: map[string]string{"solana": "https://svm.example.com"},
+				FacilitatorResolver:  func(string) string { return "" },
+			},
+			network: "solana",
+			wantURL: "https://svm.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewFacilitatorRouter(primary, tt.config)
+			client := router.ForNetwork(tt.network)
+			if client.BaseURL != tt.wantURL {
+				t.Errorf("Expected BaseURL %s, got %s", tt.wantURL, client.BaseURL)
+			}
+			if client.Authorization != primary.Authorization {
+				t.Errorf("Expected routed client to inherit authorization, got %q", client.Authorization)
+			}
+		})
+	}
+}
+
+func TestFacilitatorRouter_ReusesClients(t *testing.T) {
+	primary := &FacilitatorClient{BaseURL: "https://primary.example.com", Client: &http.Client{}}
+	router := NewFacilitatorRouter(primary, &Config{
+		FacilitatorByNetwork: map[string]string{
+			"solana":        "https://svm.example.com",
+			"solana-devnet": "https://svm.example.com",
+		},
+	})
+
+	if router.ForNetwork("solana") != router.ForNetwork("solana-devnet") {
+		t.Error("Expected networks sharing a facilitator URL to share a client")
+	}
+	if router.ForNetwork("solana") == primary {
+		t.Error("Expected routed client to differ from primary")
+	}
+}
+
+func TestFacilitatorRouter_EnrichRequirements(t *testing.T) {
+	newSupportedServer := func(network string, extra map[string]interface{}) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{
+				Kinds: []facilitator.SupportedKind{{X402Version: 1, Scheme: "exact", Network: network, Extra: extra}},
+			})
+		}))
+	}
+
+	evmServer := newSupportedServer("base", map[string]interface{}{"source": "evm"})
+	defer evmServer.Close()
+	svmServer := newSupportedServer("solana", map[string]interface{}{"feePayer": "FeePayer111"})
+	defer svmServer.Close()
+
+	primary := &FacilitatorClient{BaseURL: evmServer.URL, Client: &http.Client{}, Timeouts: x402.DefaultTimeouts}
+	router := NewFacilitatorRouter(primary, &Config{
+		FacilitatorByNetwork: map[string]string{"solana": svmServer.URL},
+	})
+
+	enriched, err := router.EnrichRequirements([]x402.PaymentRequirement{
+		{Scheme: "exact", Network: "solana"},
+		{Scheme: "exact", Network: "base"},
+	})
+	if err != nil {
+		t.Fatalf("EnrichRequirements failed: %v", err)
+	}
+
+	if enriched[0].Extra["feePayer"] != "FeePayer111" {
+		t.Errorf("Expected solana requirement enriched from SVM facilitator, got %v", enriched[0].Extra)
+	}
+	if enriched[1].Extra["source"] != "evm" {
+		t.Errorf("Expected base requirement enriched from primary facilitator, got %v", enriched[1].Extra)
+	}
+}
+
+func TestMiddleware_RoutesVerifyByNetwork(t *testing.T) {
+	var primaryCalls, svmCalls atomic.Int32
+
+	newVerifyServer := func(counter *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/supported":
+				_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+			case "/verify":
+				counter.Add(1)
+				_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: "payer"})
+			}
+		}))
+	}
+
+	primaryServer := newVerifyServer(&primaryCalls)
+	defer primaryServer.Close()
+	svmServer := newVerifyServer(&svmCalls)
+	defer svmServer.Close()
+
+	config := &Config{
+		FacilitatorURL:       primaryServer.URL,
+		FacilitatorByNetwork: map[string]string{"solana": svmServer.URL},
+		VerifyOnly:           true,
+		PaymentRequirements: []x402.PaymentRequirement{
+			{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "10000",
+				Asset:             x402.BaseMainnet.USDCAddress,
+				PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				MaxTimeoutSeconds: 60,
+			},
+			{
+				Scheme:            "exact",
+				Network:           "solana",
+				MaxAmountRequired: "10000",
+				Asset:             x402.SolanaMainnet.USDCAddress,
+				PayTo:             "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM",
+				MaxTimeoutSeconds: 60,
+			},
+		},
+	}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, network := range []string{"solana", "base"} {
+		header, err := encoding.EncodePayment(x402.PaymentPayload{
+			X402Version: 1,
+			Scheme:      "exact",
+			Network:     network,
+			Payload:     map[string]interface{}{},
+		})
+		if err != nil {
+			t.Fatalf("Failed to encode payment: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-PAYMENT", header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", network, rec.Code)
+		}
+	}
+
+	if svmCalls.Load() != 1 {
+		t.Errorf("Expected 1 verify call to SVM facilitator, got %d", svmCalls.Load())
+	}
+	if primaryCalls.Load() != 1 {
+		t.Errorf("Expected 1 verify call to primary facilitator, got %d", primaryCalls.Load())
+	}
+}
@@ -5,17 +5,14 @@ package gin
 
 import (
 	"context"
-	"log/slog"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mark3labs/x402-go"
 	httpx402 "github.com/mark3labs/x402-go/http"
-	"github.com/mark3labs/x402-go/http/internal/helpers"
 )
 
 // NewGinX402Middleware creates a new x402 payment middleware for Gin.
 // It returns a Gin-compatible middleware function that wraps handlers with payment gating.
+// It panics if config.Validate returns an error.
 //
 // The middleware:
 //   - Checks for X-PAYMENT header in requests
@@ -23,7 +20,8 @@ import (
 //   - Verifies payments with the facilitator
 //   - Settles payments (unless VerifyOnly=true)
 //   - Stores payment information in Gin context via c.Set("x402_payment", verifyResp)
-//   - Calls c.Abort() on payment failure to stop the handler chain
+//   - Calls c.AbortWithStatusJSON on payment failure to stop the handler chain,
+//     responding with an ErrorResponse for failures other than 402
 //   - Calls c.Next() on payment success to proceed to the protected handler
 //
 // Example usage:
@@ -35,7 +33,7 @@ import (
 //	        Network:           "base-sepolia",
 //	        MaxAmountRequired: "10000",
 //	        Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
-//	        PayTo:             "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+//	        PayTo:             "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 //	        MaxTimeoutSeconds: 300,
 //	    }},
 //	}
@@ -48,179 +46,73 @@ import (
 //	    }
 //	})
 func NewGinX402Middleware(config *httpx402.Config) gin.HandlerFunc {
-	// Create facilitator client
-	facilitator := &httpx402.FacilitatorClient{
-		BaseURL:               config.FacilitatorURL,
-		Client:                &http.Client{},
-		Timeouts:              x402.DefaultTimeouts,
-		Authorization:         config.FacilitatorAuthorization,
-		AuthorizationProvider: config.FacilitatorAuthorizationProvider,
-	}
-
-	// Create fallback facilitator client if configured
-	var fallbackFacilitator *httpx402.FacilitatorClient
-	if config.FallbackFacilitatorURL != "" {
-		fallbackFacilitator = &httpx402.FacilitatorClient{
-			BaseURL:               config.FallbackFacilitatorURL,
-			Client:                &http.Client{},
-			Timeouts:              x402.DefaultTimeouts,
-			Authorization:         config.FallbackFacilitatorAuthorization,
-			AuthorizationProvider: config.FallbackFacilitatorAuthorizationProvider,
-		}
-	}
-
-	// Enrich payment requirements with facilitator-specific data (like feePayer)
-	enrichedRequirements, err := facilitator.EnrichRequirements(config.PaymentRequirements)
-	if err != nil {
-		// Log warning but continue with original requirements
-		slog.Default().Warn("failed to enrich payment requirements from facilitator", "error", err)
-		enrichedRequirements = config.PaymentRequirements
-	} else {
-		slog.Default().Info("payment requirements enriched from facilitator", "count", len(enrichedRequirements))
-	}
+	engine := httpx402.MustNewEngine(config)
 
 	// Return Gin middleware function
 	return func(c *gin.Context) {
-		logger := slog.Default()
-
-		// Build absolute URL for the resource
-		scheme := "http"
-		if c.Request.TLS != nil {
-			scheme = "https"
-		}
-		resourceURL := scheme + "://" + c.Request.Host + c.Request.RequestURI
-
-		// Populate resource field in requirements with the actual request URL
-		requirementsWithResource := make([]x402.PaymentRequirement, len(enrichedRequirements))
-		for i, req := range enrichedRequirements {
-			requirementsWithResource[i] = req
-			requirementsWithResource[i].Resource = resourceURL
-			if requirementsWithResource[i].Description == "" {
-				requirementsWithResource[i].Description = "Payment required for " + c.Request.URL.Path
-			}
-		}
+		req := httpx402.NewEngineRequest(c.Request)
+		req.ClientIP = c.ClientIP()
 
-		// Check for X-PAYMENT header
-		paymentHeader := c.GetHeader("X-PAYMENT")
-		if paymentHeader == "" {
-			// No payment provided - return 402 with requirements
-			logger.Info("no payment header provided", "path", c.Request.URL.Path)
-			sendPaymentRequiredGin(c, requirementsWithResource)
+		decision := engine.Authorize(c.Request.Context(), req)
+		if !decision.Proceed {
+			abortWithDecision(c, decision)
 			return
 		}
 
-		// Parse payment header
-		payment, err := parsePaymentHeaderFromRequest(c.Request)
-		if err != nil {
-			logger.Warn("invalid payment header", "error", err)
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"x402Version": 1,
-				"error":       "Invalid payment header",
-			})
-			return
-		}
-
-		// Find matching requirement
-		requirement, err := findMatchingRequirementGin(payment, requirementsWithResource)
-		if err != nil {
-			logger.Warn("no matching requirement", "error", err)
-			sendPaymentRequiredGin(c, requirementsWithResource)
-			return
+		if decision.Session != nil {
+			c.Request = c.Request.WithContext(httpx402.WithSession(c.Request.Context(), decision.Session))
+			c.Set("x402_session", decision.Session)
 		}
-
-		// Verify payment with facilitator
-		logger.Info("verifying payment", "scheme", payment.Scheme, "network", payment.Network)
-		verifyResp, err := facilitator.Verify(c.Request.Context(), payment, requirement)
-		if err != nil && fallbackFacilitator != nil {
-			logger.Warn("primary facilitator failed, trying fallback", "error", err)
-			verifyResp, err = fallbackFacilitator.Verify(c.Request.Context(), payment, requirement)
+		if decision.Reputation != nil {
+			c.Request = c.Request.WithContext(httpx402.WithReputation(c.Request.Context(), decision.Reputation))
+			c.Set("x402_reputation", decision.Reputation)
 		}
-		if err != nil {
-			logger.Error("facilitator verification failed", "error", err)
-			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
-				"x402Version": 1,
-				"error":       "Payment verification failed",
-			})
+		if decision.Payment == nil {
+			c.Next()
 			return
 		}
 
-		if !verifyResp.IsValid {
-			logger.Warn("payment verification failed", "reason", verifyResp.InvalidReason)
-			sendPaymentRequiredGin(c, requirementsWithResource)
+		// Settle payment unless in verify-only mode or the payer was granted free access
+		decision = engine.Settle(c.Request.Context(), decision)
+		if !decision.Proceed {
+			abortWithDecision(c, decision)
 			return
 		}
-
-		// Payment verified successfully
-		logger.Info("payment verified", "payer", verifyResp.Payer)
-
-		// Settle payment if not verify-only mode
-		var settlementResp *x402.SettlementResponse
-		if !config.VerifyOnly {
-			logger.Info("settling payment", "payer", verifyResp.Payer)
-			settlementResp, err = facilitator.Settle(c.Request.Context(), payment, requirement)
-			if err != nil && fallbackFacilitator != nil {
-				logger.Warn("primary facilitator settlement failed, trying fallback", "error", err)
-				settlementResp, err = fallbackFacilitator.Settle(c.Request.Context(), payment, requirement)
-			}
-			if err != nil {
-				logger.Error("settlement failed", "error", err)
-				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
-					"x402Version": 1,
-					"error":       "Payment settlement failed",
-				})
-				return
-			}
-
-			if !settlementResp.Success {
-				logger.Warn("settlement unsuccessful", "reason", settlementResp.ErrorReason)
-				sendPaymentRequiredGin(c, requirementsWithResource)
-				return
-			}
-
-			logger.Info("payment settled", "transaction", settlementResp.Transaction)
-
-			// Add X-PAYMENT-RESPONSE header with settlement info
-			if err := addPaymentResponseHeaderGin(c, settlementResp); err != nil {
-				logger.Warn("failed to add payment response header", "error", err)
-				// Continue anyway - payment was successful
-			}
-		}
+		decision.CopyHeader(c.Writer.Header())
 
 		// Store payment info in Gin context for handler access
-		c.Set("x402_payment", verifyResp)
+		c.Set("x402_payment", decision.Payment)
 
 		// Also store in stdlib context for compatibility with http package helpers
-		ctx := context.WithValue(c.Request.Context(), httpx402.PaymentContextKey, verifyResp)
+		ctx := context.WithValue(c.Request.Context(), httpx402.PaymentContextKey, decision.Payment)
 		c.Request = c.Request.WithContext(ctx)
 
+		// Keep the response for replay of the request's Idempotency-Key
+		capture := engine.Capture(decision)
+		if capture != nil {
+			c.Writer = &capturingWriter{ResponseWriter: c.Writer, capture: capture}
+		}
+
 		// Payment successful - call next handler
 		c.Next()
-	}
-}
 
-// parsePaymentHeaderFromRequest parses the X-PAYMENT header from an http.Request.
-func parsePaymentHeaderFromRequest(r *http.Request) (x402.PaymentPayload, error) {
-	return helpers.ParsePaymentHeaderFromRequest(r)
-}
-
-// sendPaymentRequiredGin sends a 402 Payment Required response using Gin's JSON methods.
-// It aborts the request chain and returns the payment requirements to the client.
-func sendPaymentRequiredGin(c *gin.Context, requirements []x402.PaymentRequirement) {
-	response := x402.PaymentRequirementsResponse{
-		X402Version: 1,
-		Error:       "Payment required for this resource",
-		Accepts:     requirements,
+		// The payment settled before the handler ran, so even a failed response is kept
+		engine.Complete(c.Request.Context(), decision, capture.Response(c.Writer.Status(), c.Writer.Header()))
 	}
+}
 
-	c.AbortWithStatusJSON(http.StatusPaymentRequired, response)
+// capturingWriter records the response body for idempotent replay.
+type capturingWriter struct {
+	gin.ResponseWriter
+	capture *httpx402.ResponseCapture
 }
 
-// findMatchingRequirementGin finds a payment requirement that matches the provided payment.
-func findMatchingRequirementGin(payment x402.PaymentPayload, requirements []x402.PaymentRequirement) (x402.PaymentRequirement, error) {
-	return helpers.FindMatchingRequirement(payment, requirements)
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.capture.Write(b)
+	return w.ResponseWriter.Write(b)
 }
 
-// addPaymentResponseHeaderGin adds the X-PAYMENT-RESPONSE header with settlement information.
-func addPaymentResponseHeaderGin(c *gin.Context, settlement *x402.SettlementResponse) error {
-	return helpers.AddPaymentResponseHeader(c.Writer, settlement)
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.capture.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
 }
@@ -0,0 +1,112 @@
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+// ErrorResponse is the JSON body returned when a paid request fails for a reason other
+// than a missing or rejected payment (those receive a 402 with the payment requirements).
+type ErrorResponse = httpx402.ErrorResponse
+
+// RequirePayment returns Gin middleware that gates the handler chain behind payment of
+// config.PaymentRequirements. It panics if config.Validate returns an error.
+//
+// On failure the middleware calls c.AbortWithStatusJSON, so no later handler runs: with a
+// 402 and the payment requirements when payment is missing or rejected, and otherwise
+// with an ErrorResponse whose Code identifies the failure (e.g. MALFORMED_HEADER,
+// VERIFY_TIMEOUT or FACILITATOR_UNAVAILABLE). On success, Payer(c) returns the payer.
+//
+// Example usage:
+//
+//	r := gin.Default()
+//	r.GET("/premium", ginx402.RequirePayment(config), func(c *gin.Context) {
+//	    payer, _ := ginx402.Payer(c)
+//	    c.JSON(http.StatusOK, gin.H{"payer": payer})
+//	})
+func RequirePayment(config *httpx402.Config) gin.HandlerFunc {
+	return NewGinX402Middleware(config)
+}
+
+// Payer returns the address of the client that paid for the current request, or that
+// holds the session authorizing it. It reports false if the request was not paid for,
+// e.g. when it was authorized by an API key or coupon.
+func Payer(c *gin.Context) (string, bool) {
+	if value, ok := c.Get("x402_payment"); ok {
+		if verifyResp, ok := value.(*facilitator.VerifyResponse); ok && verifyResp.Payer != "" {
+			return verifyResp.Payer, true
+		}
+	}
+	if value, ok := c.Get("x402_session"); ok {
+		if claims, ok := value.(*httpx402.SessionClaims); ok && claims.Payer != "" {
+			return claims.Payer, true
+		}
+	}
+	return "", false
+}
+
+// Requirement prices a single route mounted by Group.
+type Requirement struct {
+	// PaymentRequirements are the payment options accepted for the route. They replace
+	// the config's PaymentRequirements; every other config setting is shared.
+	PaymentRequirements []x402.PaymentRequirement
+
+	// Handler serves the route once payment succeeds.
+	Handler gin.HandlerFunc
+}
+
+// Group mounts many priced routes in one call. Each key of prices is a method and path,
+// e.g. "GET /reports", and each route is protected by RequirePayment using config with
+// the route's PaymentRequirements. It panics if a key is malformed or a route's config
+// is invalid.
+//
+// Example usage:
+//
+//	ginx402.Group(r.Group("/api"), config, map[string]ginx402.Requirement{
+//	    "GET /reports":  {PaymentRequirements: []x402.PaymentRequirement{reportPrice}, Handler: reports},
+//	    "POST /analyze":  {PaymentRequirements: []x402.PaymentRequirement{analyzePrice}, Handler: analyze},
+//	})
+func Group(routes gin.IRoutes, config *httpx402.Config, prices map[string]Requirement) {
+	// Mount in a stable order so route conflicts are reported deterministically
+	keys := make([]string, 0, len(prices))
+	for key := range prices {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		method, path, ok := strings.Cut(key, " ")
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			panic(fmt.Sprintf("x402: invalid route %q, expected \"METHOD /path\"", key))
+		}
+
+		price := prices[key]
+		routeConfig := *config
+		routeConfig.PaymentRequirements = price.PaymentRequirements
+		routes.Handle(method, path, RequirePayment(&routeConfig), price.Handler)
+	}
+}
+
+// abortWithDecision stops the handler chain with the response for a decision that did
+// not proceed.
+func abortWithDecision(c *gin.Context, decision *httpx402.Decision) {
+	if decision.Replay != nil {
+		decision.Replay.Write(c.Writer)
+		c.Abort()
+		return
+	}
+	if decision.Status == http.StatusNotModified {
+		decision.CopyHeader(c.Writer.Header())
+		c.AbortWithStatus(decision.Status)
+		return
+	}
+	decision.CopyHeader(c.Writer.Header())
+	c.AbortWithStatusJSON(decision.Status, decision.Body())
+}
@@ -0,0 +1,227 @@
+package gin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+const testPayer = "0x857b06519E91e3A54538791bDbb0E22373e36b66"
+
+func testRequirement(amount string) x402.PaymentRequirement {
+	return x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base-sepolia",
+		MaxAmountRequired: amount,
+		Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	}
+}
+
+func testPaymentHeader(t *testing.T) string {
+	t.Helper()
+	header, err := encoding.EncodePayment(x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base-sepolia",
+		Payload: x402.EVMPayload{
+			Signature:     "0xsig",
+			Authorization: x402.EVMAuthorization{From: testPayer, Value: "10000"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode payment: %v", err)
+	}
+	return header
+}
+
+// newTestFacilitator returns a facilitator that accepts every payment after verifyDelay.
+func newTestFacilitator(verifyDelay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			time.Sleep(verifyDelay)
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+		case "/settle":
+			_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+		}
+	}))
+}
+
+func TestRequirePayment_ErrorEnvelope(t *testing.T) {
+	unavailable := httptest.NewServer(http.NotFoundHandler())
+	unavailable.Close()
+
+	slow := newTestFacilitator(200 * time.Millisecond)
+	defer slow.Close()
+
+	tests := []struct {
+		name           string
+		facilitatorURL string
+		header         string
+		wantStatus     int
+		wantCode       x402.ErrorCode
+	}{
+		{
+			name:           "malformed header",
+			facilitatorURL: slow.URL,
+			header:         "not-base64!",
+			wantStatus:     http.StatusBadRequest,
+			wantCode:       x402.ErrCodeMalformedHeader,
+		},
+		{
+			name:           "facilitator unavailable",
+			facilitatorURL: unavailable.URL,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantCode:       x402.ErrCodeFacilitatorUnavailable,
+		},
+		{
+			name:           "verify timeout",
+			facilitatorURL: slow.URL,
+			wantStatus:     http.StatusGatewayTimeout,
+			wantCode:       x402.ErrCodeVerifyTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &httpx402.Config{
+				FacilitatorURL:      tt.facilitatorURL,
+				PaymentRequirements: []x402.PaymentRequirement{testRequirement("10000")},
+				VerifyTimeout:       50 * time.Millisecond,
+			}
+
+			handlerCalled := false
+			r := gin.New()
+			r.GET("/test", RequirePayment(config), func(c *gin.Context) {
+				handlerCalled = true
+				c.Status(http.StatusOK)
+			})
+
+			header := tt.header
+			if header == "" {
+				header = testPaymentHeader(t)
+			}
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-PAYMENT", header)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if handlerCalled {
+				t.Error("Expected handler to NOT be called")
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			var body ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to parse error response: %v", err)
+			}
+			if body.X402Version != 1 || body.Error == "" || body.Code != tt.wantCode {
+				t.Errorf("Unexpected error response: %+v", body)
+			}
+		})
+	}
+}
+
+func TestPayer(t *testing.T) {
+	server := newTestFacilitator(0)
+	defer server.Close()
+
+	config := &httpx402.Config{
+		FacilitatorURL:      server.URL,
+		PaymentRequirements: []x402.PaymentRequirement{testRequirement("10000")},
+	}
+
+	var gotPayer string
+	var gotOK bool
+	r := gin.New()
+	r.GET("/free", func(c *gin.Context) {
+		_, ok := Payer(c)
+		c.JSON(http.StatusOK, gin.H{"paid": ok})
+	})
+	r.GET("/paid", RequirePayment(config), func(c *gin.Context) {
+		gotPayer, gotOK = Payer(c)
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/free", nil))
+	if rec.Body.String() != `{"paid":false}` {
+		t.Errorf("Expected unpaid request to have no payer, got %s", rec.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/paid", nil)
+	req.Header.Set("X-PAYMENT", testPaymentHeader(t))
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !gotOK || gotPayer != testPayer {
+		t.Errorf("Payer() = %q, %v; want %q, true", gotPayer, gotOK, testPayer)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	config := &httpx402.Config{
+		FacilitatorURL:      "http://mock-facilitator.test",
+		PaymentRequirements: []x402.PaymentRequirement{testRequirement("10000")},
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	r := gin.New()
+	Group(r.Group("/api"), config, map[string]Requirement{
+		"GET /reports":  {PaymentRequirements: []x402.PaymentRequirement{testRequirement("20000")}, Handler: ok},
+		"POST /analyze": {PaymentRequirements: []x402.PaymentRequirement{testRequirement("30000")}, Handler: ok},
+	})
+
+	tests := []struct {
+		method     string
+		path       string
+		wantAmount string
+	}{
+		{"GET", "/api/reports", "20000"},
+		{"POST", "/api/analyze", "30000"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != http.StatusPaymentRequired {
+			t.Fatalf("%s %s: expected status 402, got %d", tt.method, tt.path, rec.Code)
+		}
+		var body x402.PaymentRequirementsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse 402 response: %v", err)
+		}
+		if len(body.Accepts) != 1 || body.Accepts[0].MaxAmountRequired != tt.wantAmount {
+			t.Errorf("%s %s: expected amount %s, got %+v", tt.method, tt.path, tt.wantAmount, body.Accepts)
+		}
+	}
+
+	if config.PaymentRequirements[0].MaxAmountRequired != "10000" {
+		t.Error("Group modified the shared config")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for malformed route key")
+		}
+	}()
+	Group(r, config, map[string]Requirement{"/missing-method": {Handler: ok}})
+}
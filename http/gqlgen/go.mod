@@ -0,0 +1,10 @@
+module github.com/mark3labs/x402-go/http/gqlgen
+
+go 1.25.1
+
+replace github.com/mark3labs/x402-go => ../..
+
+require (
+	github.com/99designs/gqlgen v0.17.49
+	github.com/mark3labs/x402-go v0.0.0-00010101000000-000000000000
+)
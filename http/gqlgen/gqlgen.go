@@ -0,0 +1,112 @@
+// Package gqlgen integrates x402 payment gating with gqlgen servers. It is a separate
+// module so that the core module does not depend on gqlgen.
+//
+// NewHandler gates a server with the x402 middleware and prices each request by query
+// cost (see the graphql package). OperationMiddleware runs inside the server: it can
+// reject operations that arrive without a payment and reports the payer in the
+// response extensions. Resolvers read the payer with httpx402.PayerFromContext.
+//
+// Example usage:
+//
+//	srv := handler.NewDefaultServer(generated.NewExecutableSchema(resolvers))
+//	srv.AroundOperations(gqlgen.OperationMiddleware(gqlgen.RequirePayment()))
+//	http.Handle("/graphql", gqlgen.NewHandler(srv, config, graphql.Pricing{MaxCost: 500}))
+package gqlgen
+
+import (
+	"context"
+	"net/http"
+
+	gql "github.com/99designs/gqlgen/graphql"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+	"github.com/mark3labs/x402-go/http/graphql"
+)
+
+// ExtensionKey is the key of the Payment in the extensions of GraphQL responses.
+const ExtensionKey = "x402"
+
+// Payment describes how an operation was paid for.
+type Payment struct {
+	// Payer is the address of the payer.
+	Payer string `json:"payer"`
+
+	// Network is the network the payment was made on (empty for sessions).
+	Network string `json:"network,omitempty"`
+}
+
+// Option configures OperationMiddleware.
+type Option func(*options)
+
+// options holds the optional behavior of OperationMiddleware.
+type options struct {
+	requirePayment bool
+}
+
+// RequirePayment rejects operations that reach the server without a payment or session,
+// e.g. because the server is also mounted on a route without the x402 middleware.
+func RequirePayment() Option {
+	return func(o *options) {
+		o.requirePayment = true
+	}
+}
+
+// NewHandler wraps a gqlgen server with the x402 middleware, pricing each request by
+// its query cost with graphql.Requirements. The configured requirement amounts are the
+// price of one cost unit. config is not modified; its RequirementsFunc is ignored.
+// Requests whose query cannot be read, such as multipart uploads and WebSocket
+// subscriptions, are rejected with 400; mount those transports on a separate route.
+//
+// NewHandler panics if config.Validate returns an error.
+func NewHandler(srv http.Handler, config *httpx402.Config, pricing graphql.Pricing) http.Handler {
+	priced := *config
+	priced.RequirementsFunc = graphql.Requirements(pricing)
+	return httpx402.NewX402Middleware(&priced)(srv)
+}
+
+// OperationMiddleware returns a gqlgen operation middleware (see
+// handler.Server.AroundOperations) that adds the Payment of each operation to its
+// responses under extensions[ExtensionKey].
+func OperationMiddleware(opts ...Option) gql.OperationMiddleware {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx context.Context, next gql.OperationHandler) gql.ResponseHandler {
+		payment, ok := PaymentFromContext(ctx)
+		if !ok {
+			if o.requirePayment {
+				return gql.OneShot(gql.ErrorResponse(ctx, "payment required"))
+			}
+			return next(ctx)
+		}
+
+		responses := next(ctx)
+		return func(ctx context.Context) *gql.Response {
+			resp := responses(ctx)
+			if resp == nil {
+				return nil
+			}
+			if resp.Extensions == nil {
+				resp.Extensions = make(map[string]interface{})
+			}
+			resp.Extensions[ExtensionKey] = payment
+			return resp
+		}
+	}
+}
+
+// PaymentFromContext returns the Payment of the request whose context is ctx, or false
+// if it was neither paid for nor authorized by a session.
+func PaymentFromContext(ctx context.Context) (Payment, bool) {
+	payer, ok := httpx402.PayerFromContext(ctx)
+	if !ok {
+		return Payment{}, false
+	}
+	payment := Payment{Payer: payer}
+	if verified, ok := ctx.Value(httpx402.PaymentContextKey).(*facilitator.VerifyResponse); ok && verified != nil {
+		payment.Network = verified.PaymentPayload.Network
+	}
+	return payment, true
+}
@@ -0,0 +1,68 @@
+package gqlgen
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	gql "github.com/99designs/gqlgen/graphql"
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+func TestOperationMiddleware(t *testing.T) {
+	paid := context.WithValue(context.Background(), httpx402.PaymentContextKey, &facilitator.VerifyResponse{
+		IsValid:        true,
+		Payer:          "0xpayer",
+		PaymentPayload: x402.PaymentPayload{Network: "base-sepolia"},
+	})
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		opts        []Option
+		wantCalled  bool
+		wantPayment *Payment
+		wantError   bool
+	}{
+		{name: "paid", ctx: paid, wantCalled: true, wantPayment: &Payment{Payer: "0xpayer", Network: "base-sepolia"}},
+		{name: "unpaid", ctx: context.Background(), wantCalled: true},
+		{name: "unpaid with payment required", ctx: context.Background(), opts: []Option{RequirePayment()}, wantError: true},
+		{name: "paid with payment required", ctx: paid, opts: []Option{RequirePayment()}, wantCalled: true, wantPayment: &Payment{Payer: "0xpayer", Network: "base-sepolia"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			next := func(ctx context.Context) gql.ResponseHandler {
+				called = true
+				return gql.OneShot(&gql.Response{Data: json.RawMessage(`{"ok":true}`)})
+			}
+
+			resp := OperationMiddleware(tt.opts...)(tt.ctx, next)(tt.ctx)
+			if called != tt.wantCalled {
+				t.Errorf("operation executed = %v, want %v", called, tt.wantCalled)
+			}
+			if (len(resp.Errors) > 0) != tt.wantError {
+				t.Errorf("errors = %v, want error %v", resp.Errors, tt.wantError)
+			}
+			payment, ok := resp.Extensions[ExtensionKey].(Payment)
+			if tt.wantPayment == nil {
+				if ok {
+					t.Errorf("unexpected payment extension %+v", payment)
+				}
+			} else if !ok || payment != *tt.wantPayment {
+				t.Errorf("payment extension = %+v, want %+v", resp.Extensions[ExtensionKey], *tt.wantPayment)
+			}
+		})
+	}
+}
+
+func TestPaymentFromContext_Session(t *testing.T) {
+	ctx := httpx402.WithSession(context.Background(), &httpx402.SessionClaims{Payer: "0xsession"})
+	payment, ok := PaymentFromContext(ctx)
+	if !ok || payment != (Payment{Payer: "0xsession"}) {
+		t.Errorf("PaymentFromContext() = %+v, %v; want the session payer", payment, ok)
+	}
+}
@@ -0,0 +1,305 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// FieldAnalyzer is the default Analyzer. It prices a query at one cost unit per
+// selected field and reports the deepest nesting of selection sets, without a schema:
+// it cannot tell list fields from scalars. Fragment spreads count as their fragment's
+// fields and selection sets, nested where they are spread. Use a schema-aware Analyzer
+// where resolvers differ widely in cost.
+type FieldAnalyzer struct{}
+
+// definition is what FieldAnalyzer counts in an operation or fragment definition,
+// before its fragment spreads are expanded.
+type definition struct {
+	fields  int
+	depth   int
+	spreads []fragmentSpread
+}
+
+// fragmentSpread is a spread of the named fragment in a selection set at depth.
+type fragmentSpread struct {
+	name  string
+	depth int
+}
+
+// Analyze implements Analyzer.
+func (FieldAnalyzer) Analyze(_ context.Context, req Request) (Cost, error) {
+	operations, fragments, err := parseDocument(req.Query)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	e := expander{fragments: fragments, expanded: make(map[string]Cost), expanding: make(map[string]bool)}
+	var cost Cost
+	for _, operation := range operations {
+		c, err := e.expand(operation)
+		if err != nil {
+			return Cost{}, err
+		}
+		cost.Depth = max(cost.Depth, c.Depth)
+		cost.Fields = addFields(cost.Fields, c.Fields)
+	}
+	cost.Complexity = int64(cost.Fields)
+	return cost, nil
+}
+
+// parseDocument counts the fields, depth and fragment spreads of each operation and
+// fragment definition of a GraphQL document.
+func parseDocument(query string) (operations []*definition, fragments map[string]*definition, err error) {
+	fragments = make(map[string]*definition)
+	lex := lexer{src: query}
+	depth, parens := 0, 0
+	// current is the definition being read, and next the one whose selection set the
+	// next top-level "{" opens.
+	var current, next *definition
+	fragmentName := false
+	// skipName is set after tokens whose next name is not a field: "...", "on" and "@".
+	// spread is set after "...", whose next name, other than "on", is a fragment spread.
+	skipName, spread := false, false
+	for {
+		tok, err := lex.next()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch tok {
+		case "":
+			if depth != 0 || parens != 0 {
+				return nil, nil, errors.New("unbalanced brackets")
+			}
+			return operations, fragments, nil
+		case "{":
+			if parens == 0 {
+				if depth == 0 {
+					if next == nil {
+						// A query shorthand
+						next = &definition{}
+						operations = append(operations, next)
+					}
+					current, next = next, nil
+				}
+				depth++
+				current.depth = max(current.depth, depth)
+			}
+			skipName, spread = false, false
+		case "}":
+			if parens == 0 {
+				if depth--; depth < 0 {
+					return nil, nil, errors.New("unbalanced brackets")
+				}
+				if depth == 0 {
+					current = nil
+				}
+			}
+			skipName, spread = false, false
+		case "(":
+			parens++
+		case ")":
+			if parens--; parens < 0 {
+				return nil, nil, errors.New("unbalanced brackets")
+			}
+		case "...":
+			skipName, spread = true, true
+		case "@":
+			skipName, spread = true, false
+		default:
+			if parens > 0 {
+				continue
+			}
+			if depth == 0 {
+				switch {
+				case fragmentName:
+					if _, ok := fragments[tok]; ok {
+						return nil, nil, fmt.Errorf("duplicate fragment %q", tok)
+					}
+					next = &definition{}
+					fragments[tok] = next
+					fragmentName = false
+				case next != nil:
+					// The operation or fragment's name, type condition or directives
+				case tok == "fragment":
+					fragmentName = true
+				case tok == "query" || tok == "mutation" || tok == "subscription":
+					next = &definition{}
+					operations = append(operations, next)
+				}
+				continue
+			}
+			if skipName {
+				if spread && tok != "on" && isName(tok) {
+					current.spreads = append(current.spreads, fragmentSpread{name: tok, depth: depth})
+				}
+				// A fragment spread or directive name, or the "on" of an inline fragment
+				skipName, spread = tok == "on", false
+				continue
+			}
+			if !isName(tok) {
+				continue
+			}
+			if lex.peek() == ':' {
+				// An alias: the field name follows the colon
+				continue
+			}
+			current.fields = addFields(current.fields, 1)
+		}
+	}
+}
+
+// expander expands the fragment spreads of definitions, expanding each fragment once.
+type expander struct {
+	fragments map[string]*definition
+	expanded  map[string]Cost
+	expanding map[string]bool
+}
+
+// expand returns the fields and depth of d with its fragment spreads expanded.
+func (e *expander) expand(d *definition) (Cost, error) {
+	cost := Cost{Depth: d.depth, Fields: d.fields}
+	for _, s := range d.spreads {
+		fragment, err := e.expandFragment(s.name)
+		if err != nil {
+			return Cost{}, err
+		}
+		// A spread nests like an inline fragment: its selection set is one level deeper
+		cost.Depth = max(cost.Depth, s.depth+fragment.Depth)
+		cost.Fields = addFields(cost.Fields, fragment.Fields)
+	}
+	return cost, nil
+}
+
+// expandFragment returns the expanded cost of the named fragment.
+func (e *expander) expandFragment(name string) (Cost, error) {
+	if cost, ok := e.expanded[name]; ok {
+		return cost, nil
+	}
+	fragment, ok := e.fragments[name]
+	if !ok {
+		return Cost{}, fmt.Errorf("unknown fragment %q", name)
+	}
+	if e.expanding[name] {
+		return Cost{}, fmt.Errorf("fragment %q spreads itself", name)
+	}
+	e.expanding[name] = true
+	cost, err := e.expand(fragment)
+	delete(e.expanding, name)
+	if err != nil {
+		return Cost{}, err
+	}
+	e.expanded[name] = cost
+	return cost, nil
+}
+
+// addFields returns a + b, saturating instead of overflowing for documents whose
+// fragments spread each other many times over.
+func addFields(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// lexer splits a GraphQL document into punctuators and names, skipping whitespace,
+// commas, comments, strings and numbers.
+type lexer struct {
+	src string
+	pos int
+}
+
+// next returns the next token, or "" at the end of the document.
+func (l *lexer) next() (string, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '"':
+			if err := l.skipString(); err != nil {
+				return "", err
+			}
+		case c == '.':
+			if strings.HasPrefix(l.src[l.pos:], "...") {
+				l.pos += 3
+				return "...", nil
+			}
+			return "", errors.New("unexpected '.'")
+		case isNameStart(c):
+			start := l.pos
+			for l.pos < len(l.src) && isNameContinue(l.src[l.pos]) {
+				l.pos++
+			}
+			return l.src[start:l.pos], nil
+		case c == '-' || (c >= '0' && c <= '9'):
+			l.pos++
+			for l.pos < len(l.src) && (isNameContinue(l.src[l.pos]) || l.src[l.pos] == '.' || l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+				l.pos++
+			}
+		default:
+			l.pos++
+			return string(c), nil
+		}
+	}
+	return "", nil
+}
+
+// peek returns the next non-ignored byte without consuming it, or 0 at the end.
+func (l *lexer) peek() byte {
+	for i := l.pos; i < len(l.src); i++ {
+		switch l.src[i] {
+		case ' ', '\t', '\n', '\r', ',':
+		default:
+			return l.src[i]
+		}
+	}
+	return 0
+}
+
+// skipString skips a string or block string starting at l.pos.
+func (l *lexer) skipString() error {
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		for i := l.pos + 3; i < len(l.src); i++ {
+			if strings.HasPrefix(l.src[i:], `\"""`) {
+				i += 3
+				continue
+			}
+			if strings.HasPrefix(l.src[i:], `"""`) {
+				l.pos = i + 3
+				return nil
+			}
+		}
+		return errors.New("unterminated block string")
+	}
+	for i := l.pos + 1; i < len(l.src); i++ {
+		switch l.src[i] {
+		case '\\':
+			i++
+		case '"':
+			l.pos = i + 1
+			return nil
+		case '\n', '\r':
+			return errors.New("unterminated string")
+		}
+	}
+	return errors.New("unterminated string")
+}
+
+func isName(tok string) bool {
+	return tok != "" && isNameStart(tok[0])
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
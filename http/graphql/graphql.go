@@ -0,0 +1,205 @@
+// Package graphql prices GraphQL requests by query cost for the x402 middleware. An
+// Analyzer computes each query's cost and Requirements multiplies the configured price
+// per cost unit by it, so simple queries are cheap and expensive ones pay their way.
+// The computed cost is returned in the 402 body (in each requirement's extra.cost) so
+// clients can simplify queries that are too expensive.
+//
+// Example usage:
+//
+//	config.PaymentRequirements = []x402.PaymentRequirement{requirementPerField}
+//	config.RequirementsFunc = graphql.Requirements(graphql.Pricing{MaxCost: 500})
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+
+	"github.com/mark3labs/x402-go"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+// DefaultMaxBodySize is the default limit on the size of request bodies read to price
+// queries.
+const DefaultMaxBodySize = 1 << 20
+
+// CostExtraKey is the key of the Cost in the extra data of priced requirements.
+const CostExtraKey = "cost"
+
+// ErrTooComplex indicates a query exceeds Pricing.MaxCost or Pricing.MaxDepth.
+var ErrTooComplex = errors.New("x402: GraphQL query too complex")
+
+// ErrUnpriceable indicates a request carries no GraphQL query Requirements can read, so
+// it cannot be priced.
+var ErrUnpriceable = errors.New("x402: GraphQL request cannot be priced")
+
+// Request is a GraphQL-over-HTTP request.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Cost is the analyzed cost of a query.
+type Cost struct {
+	// Depth is the deepest nesting of selection sets.
+	Depth int `json:"depth"`
+
+	// Fields is the number of fields selected.
+	Fields int `json:"fields"`
+
+	// Complexity is the number of cost units the query is priced at.
+	Complexity int64 `json:"complexity"`
+}
+
+// Analyzer computes the cost of a GraphQL request. Plug in a schema-aware analyzer to
+// account for list sizes or expensive resolvers.
+type Analyzer interface {
+	Analyze(ctx context.Context, req Request) (Cost, error)
+}
+
+// AnalyzerFunc adapts a function to Analyzer.
+type AnalyzerFunc func(ctx context.Context, req Request) (Cost, error)
+
+// Analyze implements Analyzer.
+func (f AnalyzerFunc) Analyze(ctx context.Context, req Request) (Cost, error) {
+	return f(ctx, req)
+}
+
+// Pricing configures Requirements.
+type Pricing struct {
+	// Analyzer computes query costs (default FieldAnalyzer).
+	Analyzer Analyzer
+
+	// MaxCost rejects queries whose complexity exceeds it (0 = unlimited).
+	MaxCost int64
+
+	// MaxDepth rejects queries nested deeper than it (0 = unlimited).
+	MaxDepth int
+
+	// MaxBodySize limits the size of request bodies read to price queries (default
+	// DefaultMaxBodySize).
+	MaxBodySize int64
+}
+
+// Requirements returns an http.RequirementsFunc pricing GraphQL requests by cost: the
+// configured requirement amounts are the price of one cost unit, and each priced
+// requirement carries the Cost under extra[CostExtraKey]. Queries are read from the
+// JSON body of POST requests (a single request or a batch, whose costs add up) or from
+// the query parameters of GET requests. Any other request, such as a multipart upload,
+// a WebSocket upgrade or a body that is not exactly one JSON document, is rejected with
+// ErrUnpriceable (400 Bad Request), as its cost is unknown: serve such transports from
+// a route priced otherwise.
+func Requirements(pricing Pricing) httpx402.RequirementsFunc {
+	analyzer := pricing.Analyzer
+	if analyzer == nil {
+		analyzer = FieldAnalyzer{}
+	}
+	limit := pricing.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+
+	return func(ctx context.Context, req httpx402.EngineRequest, base []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
+		requests, err := readRequests(req, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		var total Cost
+		for _, request := range requests {
+			cost, err := analyzer.Analyze(ctx, request)
+			if err != nil {
+				return nil, fmt.Errorf("invalid GraphQL query: %w", err)
+			}
+			total.Depth = max(total.Depth, cost.Depth)
+			total.Fields += cost.Fields
+			total.Complexity += cost.Complexity
+		}
+		if pricing.MaxDepth > 0 && total.Depth > pricing.MaxDepth {
+			return nil, fmt.Errorf("%w: depth %d exceeds %d", ErrTooComplex, total.Depth, pricing.MaxDepth)
+		}
+		if pricing.MaxCost > 0 && total.Complexity > pricing.MaxCost {
+			return nil, fmt.Errorf("%w: cost %d exceeds %d", ErrTooComplex, total.Complexity, pricing.MaxCost)
+		}
+		return price(base, total), nil
+	}
+}
+
+// price returns base with amounts multiplied by cost.Complexity (at least one unit) and
+// the cost added to each requirement's extra data.
+func price(base []x402.PaymentRequirement, cost Cost) []x402.PaymentRequirement {
+	units := big.NewInt(max(cost.Complexity, 1))
+	priced := make([]x402.PaymentRequirement, len(base))
+	for i, requirement := range base {
+		priced[i] = requirement
+		if amount, ok := new(big.Int).SetString(requirement.MaxAmountRequired, 10); ok {
+			priced[i].MaxAmountRequired = amount.Mul(amount, units).String()
+		}
+		extra := make(map[string]interface{}, len(requirement.Extra)+1)
+		for key, value := range requirement.Extra {
+			extra[key] = value
+		}
+		extra[CostExtraKey] = cost
+		priced[i].Extra = extra
+	}
+	return priced
+}
+
+// readRequests returns the GraphQL requests carried by req. It returns an error
+// wrapping ErrUnpriceable if req is not valid GraphQL-over-HTTP with a query in every
+// request, or the error reading its body.
+func readRequests(req httpx402.EngineRequest, limit int64) ([]Request, error) {
+	if req.Method == "GET" {
+		u, err := url.Parse(req.ResourceURL)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnpriceable, err)
+		}
+		query := u.Query()
+		request := Request{Query: query.Get("query"), OperationName: query.Get("operationName")}
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return nil, fmt.Errorf("%w: invalid variables: %v", ErrUnpriceable, err)
+			}
+		}
+		return checkQueries([]Request{request})
+	}
+
+	if req.ReadBody == nil {
+		return nil, fmt.Errorf("%w: request body is not available", ErrUnpriceable)
+	}
+	body, err := req.ReadBody(limit)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var requests []Request
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnpriceable, err)
+		}
+		return checkQueries(requests)
+	}
+	var request Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnpriceable, err)
+	}
+	return checkQueries([]Request{request})
+}
+
+// checkQueries returns requests if there is at least one and each has a query.
+func checkQueries(requests []Request) ([]Request, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: empty batch", ErrUnpriceable)
+	}
+	for _, request := range requests {
+		if request.Query == "" {
+			return nil, fmt.Errorf("%w: missing query", ErrUnpriceable)
+		}
+	}
+	return requests, nil
+}
@@ -0,0 +1,270 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+func TestFieldAnalyzer(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantDepth  int
+		wantFields int
+		wantErr    bool
+	}{
+		{name: "shorthand", query: `{ me { name } }`, wantDepth: 2, wantFields: 2},
+		{
+			name:       "named operation with variables",
+			query:      `query Q($id: ID = "x{") { user(id: $id, filter: {a: 1}) { name friends(first: 10) { name } } }`,
+			wantDepth:  3,
+			wantFields: 4,
+		},
+		{name: "aliases", query: `{ a: user(id: 1) { name } b: user(id: 2) { name } }`, wantDepth: 2, wantFields: 4},
+		{name: "directives", query: `{ user @include(if: true) { name @skip(if: false) } }`, wantDepth: 2, wantFields: 2},
+		{
+			name:       "fragments",
+			query:      "{ user { ...F ... on Admin { level } } }\nfragment F on User { name email }",
+			wantDepth:  3,
+			wantFields: 4,
+		},
+		{
+			name:       "fragment spreads nest their depth",
+			query:      "{ a { ...Deep } }\nfragment Deep on A { b { c { d } } }",
+			wantDepth:  5,
+			wantFields: 4,
+		},
+		{
+			name:       "fragments count per spread",
+			query:      "query Q { x: a { ...F } y: a { ...F } }\nfragment F on A { b ...G }\nfragment G on A { c { d } }",
+			wantDepth:  5,
+			wantFields: 8,
+		},
+		{name: "unknown fragment", query: `{ a { ...Missing } }`, wantErr: true},
+		{name: "fragment cycle", query: "{ a { ...F } }\nfragment F on A { b { ...G } }\nfragment G on B { c { ...F } }", wantErr: true},
+		{name: "comments and block strings", query: "# { ignored }\n{ search(q: \"\"\"{ not } \\\"\"\" a field\"\"\") { id } }", wantDepth: 2, wantFields: 2},
+		{name: "unbalanced", query: `{ user { name }`, wantErr: true},
+		{name: "unterminated string", query: `{ user(id: "1) { name } }`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, err := FieldAnalyzer{}.Analyze(context.Background(), Request{Query: tt.query})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Analyze() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cost.Depth != tt.wantDepth || cost.Fields != tt.wantFields || cost.Complexity != int64(tt.wantFields) {
+				t.Errorf("Analyze() = %+v, want depth %d and %d fields", cost, tt.wantDepth, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	base := []x402.PaymentRequirement{{
+		MaxAmountRequired: "100",
+		Extra:             map[string]interface{}{"name": "USD Coin"},
+	}}
+
+	tests := []struct {
+		name       string
+		pricing    Pricing
+		method     string
+		target     string
+		body       string
+		wantAmount string
+		wantCost   *Cost
+		wantErr    error
+	}{
+		{
+			name:       "post",
+			method:     "POST",
+			body:       `{"query":"{ me { name email } }"}`,
+			wantAmount: "300",
+			wantCost:   &Cost{Depth: 2, Fields: 3, Complexity: 3},
+		},
+		{
+			name:       "get",
+			method:     "GET",
+			target:     "/graphql?query=" + url.QueryEscape("{ me { name } }"),
+			wantAmount: "200",
+			wantCost:   &Cost{Depth: 2, Fields: 2, Complexity: 2},
+		},
+		{
+			name:       "batch adds up",
+			method:     "POST",
+			body:       `[{"query":"{ me { name } }"},{"query":"{ a { b { c } } }"}]`,
+			wantAmount: "500",
+			wantCost:   &Cost{Depth: 3, Fields: 5, Complexity: 5},
+		},
+		{name: "not graphql", method: "POST", body: `{"jsonrpc":"2.0"}`, wantErr: ErrUnpriceable},
+		{name: "trailing data", method: "POST", body: `{"query":"{ a }"} {"query":"{ a { b c d e f } }"}`, wantErr: ErrUnpriceable},
+		{name: "multipart", method: "POST", body: "--x\r\nContent-Disposition: form-data; name=\"operations\"\r\n\r\n{\"query\":\"{ a }\"}\r\n--x--", wantErr: ErrUnpriceable},
+		{name: "empty batch", method: "POST", body: `[]`, wantErr: ErrUnpriceable},
+		{name: "get without query", method: "GET", target: "/graphql", wantErr: ErrUnpriceable},
+		{name: "max cost", pricing: Pricing{MaxCost: 2}, method: "POST", body: `{"query":"{ me { name email } }"}`, wantErr: ErrTooComplex},
+		{name: "max depth", pricing: Pricing{MaxDepth: 1}, method: "POST", body: `{"query":"{ me { name } }"}`, wantErr: ErrTooComplex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/graphql"
+			}
+			r := httptest.NewRequest(tt.method, target, strings.NewReader(tt.body))
+			req := httpx402.NewEngineRequest(r)
+
+			got, err := Requirements(tt.pricing)(context.Background(), req, base)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got[0].MaxAmountRequired != tt.wantAmount {
+				t.Errorf("amount = %s, want %s", got[0].MaxAmountRequired, tt.wantAmount)
+			}
+			cost, ok := got[0].Extra[CostExtraKey].(Cost)
+			if tt.wantCost == nil {
+				if ok {
+					t.Errorf("unexpected cost %+v", cost)
+				}
+			} else if !ok || cost != *tt.wantCost {
+				t.Errorf("cost = %+v, want %+v", got[0].Extra[CostExtraKey], *tt.wantCost)
+			}
+			if base[0].MaxAmountRequired != "100" || len(base[0].Extra) != 1 {
+				t.Error("Requirements modified its input")
+			}
+		})
+	}
+}
+
+func TestRequirements_BodyTooLarge(t *testing.T) {
+	r := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ me { name } }"}`))
+	req := httpx402.NewEngineRequest(r)
+
+	_, err := Requirements(Pricing{MaxBodySize: 8})(context.Background(), req, []x402.PaymentRequirement{{MaxAmountRequired: "1"}})
+	if err == nil {
+		t.Fatal("expected an error for an oversized body")
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		t.Fatal("request body was lost")
+	}
+}
+
+func TestMiddleware_GraphQLPricing(t *testing.T) {
+	config := &httpx402.Config{
+		FacilitatorURL: "http://mock-facilitator.test",
+		PaymentRequirements: []x402.PaymentRequirement{{
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: "100",
+			Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
+		}},
+		RequirementsFunc: Requirements(Pricing{MaxCost: 10}),
+	}
+	handler := httpx402.NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// The 402 advertises the price of the query and its cost
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ me { name email } }"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want 402", rec.Code)
+	}
+	var body struct {
+		Accepts []struct {
+			MaxAmountRequired string `json:"maxAmountRequired"`
+			Extra             struct {
+				Cost Cost `json:"cost"`
+			} `json:"extra"`
+		} `json:"accepts"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || len(body.Accepts) != 1 {
+		t.Fatalf("402 body = %+v, %v", body, err)
+	}
+	if got := body.Accepts[0]; got.MaxAmountRequired != "300" || got.Extra.Cost.Complexity != 3 {
+		t.Errorf("accepts = %+v, want amount 300 and complexity 3", got)
+	}
+
+	// Queries over the limit are rejected with an explanation
+	query := `{"query":"{ a { b c d e f g h i j k } }"}`
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(query))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "cost 11 exceeds 10") {
+		t.Errorf("response = %d %q, want 400 explaining the cost", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMiddleware_GraphQLUnpriceable(t *testing.T) {
+	config := &httpx402.Config{
+		FacilitatorURL: "http://mock-facilitator.test",
+		PaymentRequirements: []x402.PaymentRequirement{{
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: "100",
+			Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
+		}},
+		RequirementsFunc: Requirements(Pricing{MaxCost: 10, MaxDepth: 3}),
+	}
+	handler := httpx402.NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantBody    string
+	}{
+		{
+			// A multipart upload hides an expensive query from the pricer
+			name:        "multipart",
+			contentType: "multipart/form-data; boundary=x",
+			body:        "--x\r\nContent-Disposition: form-data; name=\"operations\"\r\n\r\n{\"query\":\"{ a { b c d e f g h i j k } }\"}\r\n--x--\r\n",
+			wantBody:    "cannot be priced",
+		},
+		{
+			// A fragment hides the depth of the query
+			name:        "fragment depth",
+			contentType: "application/json",
+			body:        `{"query":"{ a { ...F } } fragment F on A { b { c { d } } }"}`,
+			wantBody:    "depth 5 exceeds 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/graphql", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("response = %d %q, want 400 containing %q", rec.Code, rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
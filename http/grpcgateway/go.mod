@@ -0,0 +1,11 @@
+module github.com/mark3labs/x402-go/http/grpcgateway
+
+go 1.25.1
+
+replace github.com/mark3labs/x402-go => ../..
+
+require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.20.0
+	github.com/mark3labs/x402-go v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.64.0
+)
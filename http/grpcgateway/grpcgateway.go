@@ -0,0 +1,84 @@
+// Package grpcgateway integrates x402 payment gating with grpc-gateway. It is a separate
+// module so that the core module does not depend on grpc-gateway and gRPC.
+//
+// WithX402 gates every method of a runtime.ServeMux with the x402 middleware and
+// forwards the payer to the gRPC server in request metadata, where handlers read it
+// with PayerFromIncomingContext.
+//
+// Example usage:
+//
+//	mux := runtime.NewServeMux(grpcgateway.WithX402(config))
+//	if err := pb.RegisterDataServiceHandlerFromEndpoint(ctx, mux, endpoint, dialOpts); err != nil {
+//	    log.Fatal(err)
+//	}
+//	http.ListenAndServe(":8080", mux)
+package grpcgateway
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys of the payment information forwarded to the gRPC server.
+const (
+	PayerMetadataKey   = "x402-payer"
+	NetworkMetadataKey = "x402-network"
+)
+
+// WithX402 returns a ServeMuxOption that gates every method of the mux with the x402
+// middleware and forwards the payer (and the network of its payment) to the gRPC server
+// in the PayerMetadataKey and NetworkMetadataKey metadata. The same metadata sent by
+// clients as Grpc-Metadata- headers is dropped, so it cannot be spoofed through the
+// gateway; the gRPC server should not trust it from callers that bypass the gateway.
+//
+// WithX402 panics if config.Validate returns an error.
+func WithX402(config *httpx402.Config) runtime.ServeMuxOption {
+	middleware := httpx402.NewX402Middleware(config)
+
+	gate := func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			r.Header.Del(runtime.MetadataHeaderPrefix + PayerMetadataKey)
+			r.Header.Del(runtime.MetadataHeaderPrefix + NetworkMetadataKey)
+			middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next(w, r, pathParams)
+			})).ServeHTTP(w, r)
+		}
+	}
+
+	return func(mux *runtime.ServeMux) {
+		runtime.WithMiddlewares(gate)(mux)
+		runtime.WithMetadata(payerMetadata)(mux)
+	}
+}
+
+// payerMetadata is a metadata annotator forwarding the payer of a gated request.
+func payerMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	payer, ok := httpx402.PayerFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+	md := metadata.Pairs(PayerMetadataKey, payer)
+	if verified, ok := r.Context().Value(httpx402.PaymentContextKey).(*facilitator.VerifyResponse); ok && verified != nil {
+		md.Set(NetworkMetadataKey, verified.PaymentPayload.Network)
+	}
+	return md
+}
+
+// PayerFromIncomingContext returns the payer forwarded by a gateway configured with
+// WithX402, in a gRPC server handler.
+func PayerFromIncomingContext(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(PayerMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+	return values[0], true
+}
@@ -0,0 +1,80 @@
+package grpcgateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+	"google.golang.org/grpc/metadata"
+)
+
+func testConfig() *httpx402.Config {
+	return &httpx402.Config{
+		FacilitatorURL: "http://mock-facilitator.test",
+		PaymentRequirements: []x402.PaymentRequirement{{
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: "10000",
+			Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
+		}},
+	}
+}
+
+func TestWithX402_RequiresPayment(t *testing.T) {
+	mux := runtime.NewServeMux(WithX402(testConfig()))
+	called := false
+	if err := mux.HandlePath("GET", "/v1/data", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		called = true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/data", nil))
+	if rec.Code != http.StatusPaymentRequired {
+		t.Errorf("status = %d, want 402", rec.Code)
+	}
+	if called {
+		t.Error("handler called without payment")
+	}
+}
+
+func TestPayerMetadata(t *testing.T) {
+	payment := &facilitator.VerifyResponse{
+		IsValid:        true,
+		Payer:          "0xpayer",
+		PaymentPayload: x402.PaymentPayload{Network: "base-sepolia"},
+	}
+	r := httptest.NewRequest("GET", "/v1/data", nil)
+	r = r.WithContext(context.WithValue(r.Context(), httpx402.PaymentContextKey, payment))
+
+	md := payerMetadata(r.Context(), r)
+	if got := md.Get(PayerMetadataKey); len(got) != 1 || got[0] != "0xpayer" {
+		t.Errorf("payer metadata = %v, want [0xpayer]", got)
+	}
+	if got := md.Get(NetworkMetadataKey); len(got) != 1 || got[0] != "base-sepolia" {
+		t.Errorf("network metadata = %v, want [base-sepolia]", got)
+	}
+
+	unpaid := httptest.NewRequest("GET", "/v1/data", nil)
+	if md := payerMetadata(unpaid.Context(), unpaid); md != nil {
+		t.Errorf("metadata for unpaid request = %v, want none", md)
+	}
+}
+
+func TestPayerFromIncomingContext(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(PayerMetadataKey, "0xpayer"))
+	if payer, ok := PayerFromIncomingContext(ctx); !ok || payer != "0xpayer" {
+		t.Errorf("PayerFromIncomingContext() = %q, %v; want 0xpayer, true", payer, ok)
+	}
+	if _, ok := PayerFromIncomingContext(context.Background()); ok {
+		t.Error("PayerFromIncomingContext() found a payer without metadata")
+	}
+}
@@ -0,0 +1,302 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// healthCheckInterval is how long HealthHandler reuses a facilitator's /supported
+// result, so frequent probes from several replicas do not load the facilitators.
+const healthCheckInterval = 10 * time.Second
+
+// recentPaymentsKept is the number of settlements a HealthMonitor remembers.
+const recentPaymentsKept = 100
+
+// HealthMonitor records the settlements of a middleware for HealthHandler and
+// AdminHandler. Set Config.Health to one to report settlement activity, including the
+// success rate and latency of each network (see NetworkStats and HealthyNetworksFirst).
+// It is safe for concurrent use.
+type HealthMonitor struct {
+	settling     atomic.Int64
+	lastSettled  atomic.Int64
+	lastFailed   atomic.Int64
+	settledCount atomic.Int64
+
+	mu       sync.Mutex
+	recent   []PaymentRecord
+	next     int
+	networks map[string]*networkSamples
+}
+
+// PaymentRecord is a settlement attempt remembered by a HealthMonitor.
+type PaymentRecord struct {
+	Time        time.Time `json:"time"`
+	Payer       string    `json:"payer"`
+	Network     string    `json:"network"`
+	Asset       string    `json:"asset"`
+	Amount      string    `json:"amount"`
+	PayTo       string    `json:"payTo"`
+	Success     bool      `json:"success"`
+	Transaction string    `json:"transaction,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// NewHealthMonitor creates a HealthMonitor.
+func NewHealthMonitor() *HealthMonitor {
+	return &HealthMonitor{}
+}
+
+// settleStarted records the settlement of d being submitted. The returned function
+// records its outcome.
+func (m *HealthMonitor) settleStarted(d *Decision) func(*x402.SettlementResponse, error) {
+	if m == nil {
+		return func(*x402.SettlementResponse, error) {}
+	}
+	m.settling.Add(1)
+	started := time.Now()
+	return func(resp *x402.SettlementResponse, err error) {
+		m.settling.Add(-1)
+		record := PaymentRecord{
+			Time:    time.Now(),
+			Payer:   d.Payment.Payer,
+			Network: d.Requirement.Network,
+			Asset:   d.Requirement.Asset,
+			Amount:  d.Requirement.MaxAmountRequired,
+			PayTo:   d.Requirement.PayTo,
+		}
+		switch {
+		case err != nil:
+			record.Error = err.Error()
+		case !resp.Success:
+			record.Error = resp.ErrorReason
+		default:
+			record.Success = true
+			record.Transaction = resp.Transaction
+		}
+
+		if record.Success {
+			m.settledCount.Add(1)
+			m.lastSettled.Store(record.Time.UnixNano())
+		} else {
+			m.lastFailed.Store(record.Time.UnixNano())
+		}
+		m.mu.Lock()
+		if len(m.recent) < recentPaymentsKept {
+			m.recent = append(m.recent, record)
+		} else {
+			m.recent[m.next] = record
+		}
+		m.next = (m.next + 1) % recentPaymentsKept
+		m.recordNetwork(record.Network, settlementSample{at: record.Time, success: record.Success, latency: record.Time.Sub(started)})
+		m.mu.Unlock()
+	}
+}
+
+// RecentPayments returns the last settlement attempts, newest first.
+func (m *HealthMonitor) RecentPayments() []PaymentRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	payments := make([]PaymentRecord, 0, len(m.recent))
+	for i := 1; i <= len(m.recent); i++ {
+		payments = append(payments, m.recent[(m.next-i+len(m.recent))%len(m.recent)])
+	}
+	return payments
+}
+
+// settlement returns the settlement activity, or nil for a nil monitor.
+func (m *HealthMonitor) settlement() *SettlementHealth {
+	if m == nil {
+		return nil
+	}
+	return &SettlementHealth{
+		InFlight:                 m.settling.Load(),
+		Settled:                  m.settledCount.Load(),
+		LastSettlementAgeSeconds: ageSeconds(unixNano(m.lastSettled.Load())),
+		LastFailureAgeSeconds:    ageSeconds(unixNano(m.lastFailed.Load())),
+		Networks:                 m.NetworkStats(),
+	}
+}
+
+// HealthReport is the JSON body served by HealthHandler.
+type HealthReport struct {
+	// Status is "ok" when every network of the payment requirements has a reachable
+	// facilitator, and "unavailable" otherwise.
+	Status string `json:"status"`
+
+	// Facilitators describes each configured facilitator.
+	Facilitators []FacilitatorHealth `json:"facilitators"`
+
+	// Settlement describes the middleware's settlements, when Config.Health is set.
+	Settlement *SettlementHealth `json:"settlement,omitempty"`
+}
+
+// FacilitatorHealth is the state of one facilitator in a HealthReport.
+type FacilitatorHealth struct {
+	// URL is the facilitator's base URL.
+	URL string `json:"url"`
+
+	// Reachable reports whether the facilitator's last /supported request succeeded.
+	Reachable bool `json:"reachable"`
+
+	// Error is the reason the facilitator is unreachable.
+	Error string `json:"error,omitempty"`
+
+	// Kinds is the number of payment kinds the facilitator last reported.
+	Kinds int `json:"kinds"`
+
+	// KindsAgeSeconds is how long ago the supported kinds were last fetched
+	// successfully, or -1 if they never were.
+	KindsAgeSeconds float64 `json:"kindsAgeSeconds"`
+}
+
+// SettlementHealth is the settlement activity in a HealthReport.
+type SettlementHealth struct {
+	// InFlight is the number of settlements currently submitted to facilitators.
+	InFlight int64 `json:"inFlight"`
+
+	// Settled is the number of successful settlements since startup.
+	Settled int64 `json:"settled"`
+
+	// LastSettlementAgeSeconds is how long ago the last settlement succeeded, or -1 if
+	// none has.
+	LastSettlementAgeSeconds float64 `json:"lastSettlementAgeSeconds"`
+
+	// LastFailureAgeSeconds is how long ago the last settlement failed, or -1 if none has.
+	LastFailureAgeSeconds float64 `json:"lastFailureAgeSeconds"`
+
+	// Networks holds the recent settlement statistics of each network.
+	Networks []NetworkStats `json:"networks,omitempty"`
+}
+
+// facilitatorCheck is the cached /supported result of one facilitator.
+type facilitatorCheck struct {
+	checkedAt time.Time
+	err       error
+	kinds     int
+	kindsAt   time.Time
+}
+
+// HealthHandler returns a handler reporting the health of a paid API configured with
+// config, suitable for Kubernetes readiness probes. It answers 200 OK when the
+// facilitator of every network in config.PaymentRequirements (or the fallback
+// facilitator) answers /supported, and 503 Service Unavailable otherwise, with a
+// HealthReport body.
+//
+// Facilitator results are reused for a few seconds between probes. Set config.Health
+// before creating the middleware to also report in-flight settlements and the age of
+// the last settlement.
+func HealthHandler(config *Config) http.Handler {
+	checker := newHealthChecker(config)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := checker.report(r.Context())
+		status := http.StatusOK
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		writeAdminJSON(w, status, report)
+	})
+}
+
+// healthChecker builds HealthReports, caching facilitator results.
+type healthChecker struct {
+	config *Config
+
+	mu     sync.Mutex
+	checks map[string]*facilitatorCheck
+}
+
+func newHealthChecker(config *Config) *healthChecker {
+	return &healthChecker{config: config, checks: make(map[string]*facilitatorCheck)}
+}
+
+// check returns the /supported result of client, querying it when the cached one is stale.
+func (h *healthChecker) check(ctx context.Context, client *FacilitatorClient) facilitatorCheck {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.checks[client.BaseURL]
+	if !ok {
+		c = &facilitatorCheck{}
+		h.checks[client.BaseURL] = c
+	}
+	if time.Since(c.checkedAt) < healthCheckInterval {
+		return *c
+	}
+	kinds, err := client.SupportedKinds(ctx)
+	c.checkedAt = time.Now()
+	c.err = err
+	if err == nil {
+		c.kinds = len(kinds)
+		c.kindsAt = c.checkedAt
+	}
+	return *c
+}
+
+// report checks the facilitators and settlements of the configuration.
+func (h *healthChecker) report(ctx context.Context) HealthReport {
+	config := h.config
+	ctx, cancel := context.WithTimeout(ctx, config.Timeouts().VerifyTimeout)
+	defer cancel()
+
+	// Probe the configured facilitators, and any a FacilitatorResolver picks
+	clients := config.facilitatorClients()
+	router := NewFacilitatorRouter(clients[0], config)
+	probed := make(map[string]bool)
+	for _, client := range clients {
+		probed[client.BaseURL] = true
+	}
+	for _, requirement := range config.PaymentRequirements {
+		if client := router.ForNetwork(requirement.Network); !probed[client.BaseURL] {
+			probed[client.BaseURL] = true
+			clients = append(clients, client)
+		}
+	}
+
+	report := HealthReport{Status: "ok"}
+	reachable := make(map[string]bool)
+	for _, client := range clients {
+		c := h.check(ctx, client)
+		health := FacilitatorHealth{
+			URL:             client.BaseURL,
+			Reachable:       c.err == nil,
+			Kinds:           c.kinds,
+			KindsAgeSeconds: ageSeconds(c.kindsAt),
+		}
+		if c.err != nil {
+			health.Error = c.err.Error()
+		}
+		reachable[client.BaseURL] = health.Reachable
+		report.Facilitators = append(report.Facilitators, health)
+	}
+
+	// Each network needs its facilitator, or the fallback, to take payments
+	for _, requirement := range config.PaymentRequirements {
+		url := router.ForNetwork(requirement.Network).BaseURL
+		if !reachable[url] && !reachable[config.FallbackFacilitatorURL] {
+			report.Status = "unavailable"
+		}
+	}
+
+	report.Settlement = config.Health.settlement()
+	return report
+}
+
+// ageSeconds returns the seconds elapsed since t, or -1 for the zero time.
+func ageSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return -1
+	}
+	return time.Since(t).Seconds()
+}
+
+// unixNano converts a recorded UnixNano timestamp to a time, keeping 0 as the zero time.
+func unixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
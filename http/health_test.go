@@ -0,0 +1,149 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestHealthHandler(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{
+			Kinds: []facilitator.SupportedKind{{X402Version: 1, Scheme: "exact", Network: "base-sepolia"}},
+		})
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	tests := []struct {
+		name       string
+		primary    string
+		fallback   string
+		wantStatus int
+	}{
+		{"reachable", up.URL, "", http.StatusOK},
+		{"unreachable", down.URL, "", http.StatusServiceUnavailable},
+		{"fallback reachable", down.URL, up.URL, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validTestConfig()
+			config.FacilitatorURL = tt.primary
+			config.FallbackFacilitatorURL = tt.fallback
+
+			rec := httptest.NewRecorder()
+			HealthHandler(config).ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			var report HealthReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("invalid report: %v", err)
+			}
+			primary := report.Facilitators[0]
+			if primary.URL != tt.primary || primary.Reachable != (tt.primary == up.URL) {
+				t.Errorf("primary facilitator = %+v", primary)
+			}
+			if primary.Reachable && (primary.Kinds != 1 || primary.KindsAgeSeconds < 0) {
+				t.Errorf("primary kinds = %d aged %v, want 1 fetched now", primary.Kinds, primary.KindsAgeSeconds)
+			}
+			if report.Settlement != nil {
+				t.Error("expected no settlement section without Config.Health")
+			}
+		})
+	}
+}
+
+func TestHealthHandler_Settlement(t *testing.T) {
+	server := newSettlingFacilitator()
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.Health = NewHealthMonitor()
+	health := HealthHandler(config)
+
+	report := func() *SettlementHealth {
+		rec := httptest.NewRecorder()
+		health.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+		var report HealthReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("invalid report: %v", err)
+		}
+		if report.Settlement == nil {
+			t.Fatal("expected a settlement section")
+		}
+		return report.Settlement
+	}
+
+	if s := report(); s.Settled != 0 || s.LastSettlementAgeSeconds != -1 {
+		t.Errorf("settlement before any payment = %+v", s)
+	}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := newPaidRequest(t, "/test")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	s := report()
+	if s.InFlight != 0 || s.Settled != 1 || s.LastSettlementAgeSeconds < 0 || s.LastFailureAgeSeconds != -1 {
+		t.Errorf("settlement after a payment = %+v", s)
+	}
+}
+
+// newSettlingFacilitator serves a facilitator that verifies and settles every payment.
+func newSettlingFacilitator() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+		case "/settle":
+			_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia"})
+		}
+	}))
+}
+
+// newPaidRequest returns a request to target paying validTestConfig's requirement.
+func newPaidRequest(t *testing.T, target string) *http.Request {
+	t.Helper()
+	header, err := encoding.EncodePayment(x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base-sepolia",
+		Payload: x402.EVMPayload{
+			Signature: "0xsig",
+			Authorization: x402.EVMAuthorization{
+				From:        testPayer,
+				Value:       "10000",
+				ValidBefore: strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode payment: %v", err)
+	}
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("X-PAYMENT", header)
+	return req
+}
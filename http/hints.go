@@ -0,0 +1,274 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+const (
+	// PaymentHintRel is the relation type of Link header entries advertising the payment
+	// requirements of a linked resource, e.g. an asset the page will load next. The link's
+	// "accepts" parameter holds the requirements as base64url-encoded JSON.
+	PaymentHintRel = "x402-accepts"
+
+	// DefaultPaymentHintTTL is how long X402Transport.PaymentHints remembers advertised
+	// payment requirements.
+	DefaultPaymentHintTTL = time.Minute
+
+	// maxPaymentHints bounds the payment requirements remembered by a transport.
+	maxPaymentHints = 256
+)
+
+// PaymentHintLink returns a Link header value advertising the payment requirements of
+// the resource at target, a URL relative to the response it is sent with, so clients
+// with X402Transport.PaymentHints pay for it with their first request instead of
+// waiting for a 402. Send it with the page linking the resource, or ahead of it in a
+// 103 Early Hints response:
+//
+//	w.Header().Add("Link", link)
+//	w.WriteHeader(http.StatusEarlyHints)
+func PaymentHintLink(target string, requirements []x402.PaymentRequirement) (string, error) {
+	if strings.ContainsAny(target, "<>") {
+		return "", fmt.Errorf("invalid link target %q", target)
+	}
+	data, err := json.Marshal(requirements)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`<%s>; rel="%s"; accepts="%s"`, target, PaymentHintRel, base64.RawURLEncoding.EncodeToString(data)), nil
+}
+
+// paymentHints remembers the payment requirements advertised for resources, for
+// X402Transport.PaymentHints. The zero value is ready to use.
+type paymentHints struct {
+	mu    sync.Mutex
+	hints map[string]paymentHint
+}
+
+// paymentHint is the requirements advertised for one resource.
+type paymentHint struct {
+	requirements []x402.PaymentRequirement
+	expires      time.Time
+}
+
+// collect remembers the requirements advertised in the Link headers of a response to a
+// request for base. Only links to base's origin are kept: the origin can only price its
+// own resources.
+func (h *paymentHints) collect(base *url.URL, header http.Header) {
+	for _, link := range parseLinks(header.Values("Link")) {
+		if !hasRel(link.params["rel"], PaymentHintRel) || link.params["accepts"] == "" {
+			continue
+		}
+		target, err := base.Parse(link.target)
+		if err != nil || originOf(target) != originOf(base) {
+			continue
+		}
+		data, err := base64.RawURLEncoding.DecodeString(link.params["accepts"])
+		if err != nil {
+			continue
+		}
+		var requirements []x402.PaymentRequirement
+		if err := json.Unmarshal(data, &requirements); err != nil || len(requirements) == 0 {
+			continue
+		}
+		h.add(hintKey(target), requirements)
+	}
+}
+
+// add remembers requirements for the resource key.
+func (h *paymentHints) add(key string, requirements []x402.PaymentRequirement) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.hints == nil {
+		h.hints = make(map[string]paymentHint)
+	}
+	if len(h.hints) >= maxPaymentHints {
+		for k, hint := range h.hints {
+			if now.After(hint.expires) {
+				delete(h.hints, k)
+			}
+		}
+		// Still full: drop an arbitrary hint
+		for k := range h.hints {
+			if len(h.hints) < maxPaymentHints {
+				break
+			}
+			delete(h.hints, k)
+		}
+	}
+	h.hints[key] = paymentHint{requirements: requirements, expires: now.Add(DefaultPaymentHintTTL)}
+}
+
+// take returns and forgets the requirements advertised for u, if any are current.
+func (h *paymentHints) take(u *url.URL) ([]x402.PaymentRequirement, bool) {
+	key := hintKey(u)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hint, ok := h.hints[key]
+	if !ok {
+		return nil, false
+	}
+	delete(h.hints, key)
+	if time.Now().After(hint.expires) {
+		return nil, false
+	}
+	return hint.requirements, true
+}
+
+// hintKey identifies the resource at u, ignoring its fragment.
+func hintKey(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	key := originOf(u) + path
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// withEarlyHints returns req with a trace collecting the payment hints of 103 Early
+// Hints responses.
+func (t *X402Transport) withEarlyHints(req *http.Request) *http.Request {
+	base := req.URL
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				t.hints.collect(base, http.Header(header))
+			}
+			return nil
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// payHinted pays for req up front with the requirements advertised for it, if any. It
+// reports false when there are none, or the server turned the payment down, e.g.
+// because the hint was stale; the request then goes through the usual 402 flow.
+func (t *X402Transport) payHinted(ctx context.Context, req *http.Request) (*http.Response, bool, error) {
+	if !singleFlightable(req) {
+		return nil, false, nil
+	}
+	requirements, ok := t.hints.take(req.URL)
+	if !ok {
+		return nil, false, nil
+	}
+
+	resp, err := t.pay(ctx, req, requirements)
+	if err == nil && resp.StatusCode != http.StatusPaymentRequired {
+		return resp, true, nil
+	}
+	if err == nil {
+		resp.Body.Close()
+		return nil, false, nil
+	}
+
+	// The paid request may have been served when it failed in transit; do not pay again
+	var paymentErr *x402.PaymentError
+	if ctx.Err() != nil || !errors.As(err, &paymentErr) || paymentErr.Code == x402.ErrCodePaymentTimeout {
+		return nil, true, err
+	}
+	return nil, false, nil
+}
+
+// link is one entry of a Link header (RFC 8288).
+type link struct {
+	target string
+	params map[string]string
+}
+
+// parseLinks parses Link header values. Parameter names are lowercased; malformed
+// entries are skipped.
+func parseLinks(values []string) []link {
+	var links []link
+	for _, value := range values {
+		for s := value; ; {
+			s = strings.TrimLeft(s, " \t,")
+			if !strings.HasPrefix(s, "<") {
+				break
+			}
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				break
+			}
+			l := link{target: s[1:end], params: make(map[string]string)}
+			s = s[end+1:]
+
+			// Parameters up to the next link
+			for {
+				s = strings.TrimLeft(s, " \t")
+				if !strings.HasPrefix(s, ";") {
+					break
+				}
+				s = strings.TrimLeft(s[1:], " \t")
+				i := strings.IndexAny(s, "=;,")
+				if i < 0 {
+					i = len(s)
+				}
+				name := strings.ToLower(strings.TrimSpace(s[:i]))
+				s = s[i:]
+				var v string
+				if strings.HasPrefix(s, "=") {
+					v, s = parseParamValue(strings.TrimLeft(s[1:], " \t"))
+				}
+				if _, seen := l.params[name]; !seen && name != "" {
+					l.params[name] = v
+				}
+			}
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// parseParamValue parses a token or quoted-string parameter value at the start of s,
+// returning it and the rest of s.
+func parseParamValue(s string) (value, rest string) {
+	if !strings.HasPrefix(s, `"`) {
+		i := strings.IndexAny(s, ";,")
+		if i < 0 {
+			i = len(s)
+		}
+		return strings.TrimSpace(s[:i]), s[i:]
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), ""
+}
+
+// hasRel reports whether the space-separated relation types rels include rel.
+func hasRel(rels, rel string) bool {
+	for _, r := range strings.Fields(rels) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
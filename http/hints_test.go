@@ -0,0 +1,151 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestClient_WithPaymentHints(t *testing.T) {
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	}
+	link := func(target string) string {
+		l, err := PaymentHintLink(target, []x402.PaymentRequirement{requirement})
+		if err != nil {
+			t.Fatalf("PaymentHintLink() error = %v", err)
+		}
+		return l
+	}
+
+	var unpaid, paid, staleRejected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/page":
+			w.Header().Add("Link", link("/early.png"))
+			w.WriteHeader(http.StatusEarlyHints)
+			w.Header().Del("Link")
+			w.Header().Add("Link", `</style.css>; rel=preload, `+link("asset.png")+`, `+link("/stale.png"))
+			w.Header().Add("Link", link("http://other.example/asset.png"))
+			w.WriteHeader(http.StatusOK)
+		case r.Header.Get("X-PAYMENT") == "":
+			unpaid.Add(1)
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(requirement))
+		case r.URL.Path == "/stale.png" && staleRejected.Add(1) == 1:
+			// The advertised price changed: the hinted payment is turned down
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(requirement))
+		default:
+			paid.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}),
+		WithPaymentHints(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	get := func(path string) {
+		t.Helper()
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+	}
+
+	get("/page")
+	for _, path := range []string{"/early.png", "/asset.png"} {
+		get(path)
+		if unpaid.Load() != 0 {
+			t.Errorf("GET %s was not paid up front", path)
+		}
+	}
+
+	// A stale hint falls back to the 402 flow
+	get("/stale.png")
+	if unpaid.Load() != 1 || paid.Load() != 3 {
+		t.Errorf("unpaid = %d, paid = %d after the stale hint, want 1 and 3", unpaid.Load(), paid.Load())
+	}
+
+	// Hints are used once
+	get("/asset.png")
+	if unpaid.Load() != 2 {
+		t.Errorf("unpaid = %d, want a hint to be used once", unpaid.Load())
+	}
+}
+
+func TestPaymentHints_Collect(t *testing.T) {
+	base, _ := url.Parse("https://api.example.com/pages/index.html")
+	requirements := []x402.PaymentRequirement{{Scheme: "exact", Network: "base", MaxAmountRequired: "1"}}
+	hint, _ := PaymentHintLink("../assets/a.png?size=2#top", requirements)
+	other, _ := PaymentHintLink("https://evil.example/a.png", requirements)
+
+	header := http.Header{}
+	header.Add("Link", `<https://api.example.com/b.png>; rel="preload x402-accepts"; accepts="!!"`)
+	header.Add("Link", hint+", "+other)
+
+	var hints paymentHints
+	hints.collect(base, header)
+
+	target, _ := url.Parse("https://API.example.com/assets/a.png?size=2")
+	got, ok := hints.take(target)
+	if !ok || !reflect.DeepEqual(got, requirements) {
+		t.Errorf("take(%s) = %v, %v; want %v", target, got, ok, requirements)
+	}
+	if len(hints.hints) != 0 {
+		t.Errorf("unexpected hints left: %v", hints.hints)
+	}
+}
+
+func TestParseLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []link
+	}{
+		{
+			name:  "quoted and token values",
+			value: `</a>; rel="next prev"; title="a, \"b\"", </b>;rel=preload;crossorigin`,
+			want: []link{
+				{target: "/a", params: map[string]string{"rel": "next prev", "title": `a, "b"`}},
+				{target: "/b", params: map[string]string{"rel": "preload", "crossorigin": ""}},
+			},
+		},
+		{
+			name:  "first parameter wins",
+			value: `</a>; REL=one; rel=two`,
+			want:  []link{{target: "/a", params: map[string]string{"rel": "one"}}},
+		},
+		{
+			name:  "malformed",
+			value: `/a; rel=next`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLinks([]string{tt.value}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLinks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader carries a client-chosen key identifying an application
+	// operation, so a retried paid request is not charged twice.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayHeader is set to "true" on a response replayed for a repeated
+	// Idempotency-Key. The retry's payment was verified but not settled, so the client
+	// should cancel or discard its authorization.
+	IdempotentReplayHeader = "Idempotent-Replayed"
+)
+
+// DefaultIdempotencyTTL is how long responses are kept for replay by default.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultMaxIdempotentResponseSize is the default limit on the size of a response body
+// kept for replay.
+const DefaultMaxIdempotentResponseSize = 1 << 20
+
+// ErrIdempotencyKeyInUse indicates another request with the same Idempotency-Key is
+// still being processed.
+var ErrIdempotencyKeyInUse = errors.New("x402: idempotency key in use")
+
+// IdempotentResponse is a handler response kept for replay.
+type IdempotentResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Write replays the response to w, marked with IdempotentReplayHeader.
+func (r *IdempotentResponse) Write(w http.ResponseWriter) {
+	for key, values := range r.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.Header().Set(IdempotentReplayHeader, "true")
+	w.WriteHeader(r.Status)
+	_, _ = w.Write(r.Body)
+}
+
+// IdempotencyStore keeps the responses of paid requests by idempotency key. Share one
+// store between replicas so a retry reaching another replica is replayed too.
+//
+// Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Begin returns the response stored for key. If there is none, it atomically claims
+	// key for a request in flight for ttl and returns nil, or returns
+	// ErrIdempotencyKeyInUse if another request holds the claim.
+	Begin(ctx context.Context, key string, ttl time.Duration) (*IdempotentResponse, error)
+
+	// Complete stores response for key for ttl, replacing the claim.
+	Complete(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error
+
+	// Abort removes the claim on key without storing a response, so the operation can
+	// be retried with a payment.
+	Abort(ctx context.Context, key string) error
+}
+
+// IdempotencyConfig enables Idempotency-Key support for paid requests: when a verified
+// payer repeats the key of an operation that was already paid for, the stored response
+// is replayed and the new payment is not settled. Keys are scoped to the payer, method
+// and resource, so one client cannot read another's responses.
+//
+// Only responses of settled requests are stored; if the handler fails, the payment is
+// not charged and the operation can be retried.
+type IdempotencyConfig struct {
+	// Store keeps responses (default: an in-memory store).
+	Store IdempotencyStore
+
+	// TTL is how long responses are kept for replay (default DefaultIdempotencyTTL).
+	TTL time.Duration
+
+	// MaxResponseSize limits the size of the response bodies kept for replay (default
+	// DefaultMaxIdempotentResponseSize). Larger responses are not kept, so retries of
+	// their operations are charged again.
+	MaxResponseSize int64
+
+	once         sync.Once
+	defaultStore IdempotencyStore
+}
+
+// store returns the configured store or a lazily created in-memory one.
+func (c *IdempotencyConfig) store() IdempotencyStore {
+	if c.Store != nil {
+		return c.Store
+	}
+	c.once.Do(func() {
+		c.defaultStore = NewMemoryIdempotencyStore()
+	})
+	return c.defaultStore
+}
+
+// ttl returns the configured TTL or the default.
+func (c *IdempotencyConfig) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return DefaultIdempotencyTTL
+}
+
+// maxResponseSize returns the configured limit or the default.
+func (c *IdempotencyConfig) maxResponseSize() int64 {
+	if c.MaxResponseSize > 0 {
+		return c.MaxResponseSize
+	}
+	return DefaultMaxIdempotentResponseSize
+}
+
+// idempotencyKey returns the store key for the request's Idempotency-Key, scoped to the
+// payer, method and resource, or "" if the request has none.
+func idempotencyKey(req EngineRequest, payer string) string {
+	key := strings.TrimSpace(req.Header.Get(IdempotencyKeyHeader))
+	if key == "" {
+		return ""
+	}
+	return "idempotency:" + strings.ToLower(payer) + ":" + req.Method + ":" + req.ResourceURL + ":" + key
+}
+
+// ResponseCapture collects the body a handler writes, for Engine.Complete. Adapters
+// feed it every write of a paid request whose Decision is Idempotent.
+type ResponseCapture struct {
+	limit    int64
+	body     bytes.Buffer
+	overflow bool
+}
+
+// Capture returns a ResponseCapture for d, or nil if d needs none. A nil
+// ResponseCapture ignores writes.
+func (e *Engine) Capture(d *Decision) *ResponseCapture {
+	if !d.Idempotent() {
+		return nil
+	}
+	return &ResponseCapture{limit: e.config.Idempotency.maxResponseSize()}
+}
+
+// Write records b.
+func (c *ResponseCapture) Write(b []byte) {
+	if c == nil || c.overflow {
+		return
+	}
+	if int64(c.body.Len()+len(b)) > c.limit {
+		c.overflow = true
+		c.body.Reset()
+		return
+	}
+	c.body.Write(b)
+}
+
+// Response returns the captured response with status and header, or nil if the body
+// exceeded the size limit. Payment and session headers are left out, as a replay
+// neither settles a payment nor grants a session.
+func (c *ResponseCapture) Response(status int, header http.Header) *IdempotentResponse {
+	if c == nil || c.overflow {
+		return nil
+	}
+	kept := header.Clone()
+	for _, name := range []string{"X-PAYMENT-RESPONSE", ReceiptSignatureHeader, SessionHeader, "Set-Cookie"} {
+		kept.Del(name)
+	}
+	return &IdempotentResponse{Status: status, Header: kept, Body: bytes.Clone(c.body.Bytes())}
+}
+
+// Idempotent reports whether the decision's request carries an Idempotency-Key whose
+// response is to be stored with Engine.Complete.
+func (d *Decision) Idempotent() bool {
+	return d.idempotencyKey != ""
+}
+
+// Complete stores the response of an Idempotent decision's request once the handler
+// ran and the payment settled, so retries with the same Idempotency-Key are replayed.
+// A nil response (see ResponseCapture.Response) releases the key instead. Complete is a
+// no-op for other decisions.
+func (e *Engine) Complete(ctx context.Context, d *Decision, response *IdempotentResponse) {
+	if !d.Idempotent() {
+		return
+	}
+	key := d.idempotencyKey
+	d.idempotencyKey = ""
+
+	// Store even if the request context was cancelled
+	ctx = context.WithoutCancel(ctx)
+	store := e.config.Idempotency.store()
+	if response == nil {
+		_ = store.Abort(ctx, key)
+		return
+	}
+	if err := store.Complete(ctx, key, response, e.config.Idempotency.ttl()); err != nil {
+		_ = store.Abort(ctx, key)
+	}
+}
+
+// beginIdempotent looks up the request's Idempotency-Key for payer. It returns the
+// scoped key claimed for this request, or the stored response to replay. The claim is
+// held for inflight, after which an unfinished request's key can be retried.
+func (c *Config) beginIdempotent(ctx context.Context, req EngineRequest, payer string, inflight time.Duration) (key string, replay *IdempotentResponse, err error) {
+	if c.Idempotency == nil {
+		return "", nil, nil
+	}
+	key = idempotencyKey(req, payer)
+	if key == "" {
+		return "", nil, nil
+	}
+	replay, err = c.Idempotency.store().Begin(ctx, key, inflight)
+	if err != nil {
+		if errors.Is(err, ErrIdempotencyKeyInUse) {
+			return "", nil, err
+		}
+		return "", nil, fmt.Errorf("idempotency store: %w", err)
+	}
+	if replay != nil {
+		return "", replay, nil
+	}
+	return key, nil, nil
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore for single-instance
+// deployments.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]idempotencyEntry
+	lastSweep time.Time
+}
+
+// idempotencyEntry is a claim (nil response) or a stored response.
+type idempotencyEntry struct {
+	response  *IdempotentResponse
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory IdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry)}
+}
+
+// Begin implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key string, ttl time.Duration) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		if entry.response == nil {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return entry.response, nil
+	}
+	s.entries[key] = idempotencyEntry{expiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, response *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = idempotencyEntry{response: response, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Abort implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Abort(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddleware_Idempotency(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.Idempotency = &IdempotencyConfig{}
+
+	var handlerCalls atomic.Int32
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := handlerCalls.Add(1)
+		if r.URL.Query().Get("fail") == "true" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Order", fmt.Sprint(n))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, "order %d", n)
+	}))
+
+	send := func(path, key, payer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, nil)
+		req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, payer))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("/orders", "op-1", testPayer)
+	if first.Code != http.StatusCreated || first.Body.String() != "order 1" {
+		t.Fatalf("first response = %d %q", first.Code, first.Body.String())
+	}
+
+	retry := send("/orders", "op-1", testPayer)
+	if retry.Code != http.StatusCreated || retry.Body.String() != "order 1" || retry.Header().Get("X-Order") != "1" {
+		t.Errorf("retry response = %d %q, want replay of the first", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Error("retry response not marked as replayed")
+	}
+	if retry.Header().Get("X-PAYMENT-RESPONSE") != "" {
+		t.Error("replay carries the original settlement receipt")
+	}
+	if settleCalls.Load() != 1 || handlerCalls.Load() != 1 {
+		t.Errorf("settle calls = %d, handler calls = %d after retry, want 1 and 1", settleCalls.Load(), handlerCalls.Load())
+	}
+
+	// Other keys are new operations
+	if rec := send("/orders", "op-2", testPayer); rec.Body.String() != "order 2" {
+		t.Errorf("new key response = %q, want a new order", rec.Body.String())
+	}
+	if rec := send("/orders", "", testPayer); rec.Body.String() != "order 3" {
+		t.Errorf("response without key = %q, want a new order", rec.Body.String())
+	}
+
+	// Failed operations are not charged and can be retried
+	if rec := send("/orders?fail=true", "op-3", testPayer); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("failing response = %d", rec.Code)
+	}
+	if rec := send("/orders?fail=true", "op-3", testPayer); rec.Header().Get(IdempotentReplayHeader) != "" {
+		t.Error("failed operation was replayed")
+	}
+	if settleCalls.Load() != 3 {
+		t.Errorf("settle calls = %d, want 3", settleCalls.Load())
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore()
+
+	if replay, err := store.Begin(ctx, "key", time.Minute); replay != nil || err != nil {
+		t.Fatalf("Begin() = %v, %v; want claim", replay, err)
+	}
+	if _, err := store.Begin(ctx, "key", time.Minute); !errors.Is(err, ErrIdempotencyKeyInUse) {
+		t.Errorf("Begin() while in flight error = %v, want ErrIdempotencyKeyInUse", err)
+	}
+
+	response := &IdempotentResponse{Status: http.StatusOK, Body: []byte("done")}
+	if err := store.Complete(ctx, "key", response, time.Minute); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if replay, err := store.Begin(ctx, "key", time.Minute); err != nil || replay == nil || string(replay.Body) != "done" {
+		t.Errorf("Begin() after Complete = %v, %v; want stored response", replay, err)
+	}
+
+	_, _ = store.Begin(ctx, "aborted", time.Minute)
+	_ = store.Abort(ctx, "aborted")
+	if replay, err := store.Begin(ctx, "aborted", time.Minute); replay != nil || err != nil {
+		t.Errorf("Begin() after Abort = %v, %v; want claim", replay, err)
+	}
+}
+
+func TestIdempotencyKey_ScopedToPayer(t *testing.T) {
+	req := engineRequest(http.MethodPost, http.Header{IdempotencyKeyHeader: {"op-1"}})
+	if idempotencyKey(req, "0xA") == idempotencyKey(req, "0xB") {
+		t.Error("payers share idempotency keys")
+	}
+	if idempotencyKey(req, "0xAbC") != idempotencyKey(req, "0xaBc") {
+		t.Error("payer address case changes the key")
+	}
+	if idempotencyKey(engineRequest(http.MethodPost, http.Header{}), "0xA") != "" {
+		t.Error("request without Idempotency-Key has a key")
+	}
+}
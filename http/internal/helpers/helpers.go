@@ -6,13 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/mark3labs/x402-go"
 	"github.com/mark3labs/x402-go/encoding"
 )
 
 // ParsePaymentHeaderFromRequest parses the X-PAYMENT header from an http.Request and returns the payment payload.
-// It decodes the base64-encoded JSON and validates the x402 protocol version.
+// It decodes the base64-encoded JSON, or CBOR when the X-PAYMENT-ENCODING header says
+// so or the header is not JSON, and validates the x402 protocol version.
 //
 // Returns x402.ErrMalformedHeader if the header is missing, invalid base64, or invalid JSON.
 // Returns x402.ErrUnsupportedVersion if X402Version != 1.
@@ -24,8 +26,24 @@ func ParsePaymentHeaderFromRequest(r *http.Request) (x402.PaymentPayload, error)
 		return payment, x402.ErrMalformedHeader
 	}
 
-	// Decode base64-encoded JSON
-	payment, err := encoding.DecodePayment(headerValue)
+	// Decode base64-encoded JSON, or CBOR if the client says so
+	var err error
+	switch paymentEncoding := r.Header.Get("X-PAYMENT-ENCODING"); {
+	case paymentEncoding == "":
+		payment, err = encoding.DecodePayment(headerValue)
+		if err != nil {
+			// The encoding header may have been dropped by a proxy
+			if cborPayment, cborErr := encoding.DecodePaymentCBOR(headerValue); cborErr == nil {
+				payment, err = cborPayment, nil
+			}
+		}
+	case strings.EqualFold(paymentEncoding, "json"):
+		payment, err = encoding.DecodePayment(headerValue)
+	case strings.EqualFold(paymentEncoding, encoding.PaymentEncodingCBOR), strings.EqualFold(paymentEncoding, encoding.PaymentMediaTypeCBOR):
+		payment, err = encoding.DecodePaymentCBOR(headerValue)
+	default:
+		err = fmt.Errorf("unsupported payment encoding %q", paymentEncoding)
+	}
 	if err != nil {
 		return payment, fmt.Errorf("%w: %v", x402.ErrMalformedHeader, err)
 	}
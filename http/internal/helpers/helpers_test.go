@@ -9,13 +9,25 @@ import (
 	"testing"
 
 	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
 )
 
 // TestParsePaymentHeaderFromRequest tests payment header parsing logic
 func TestParsePaymentHeaderFromRequest(t *testing.T) {
+	cborPayment, err := encoding.EncodePaymentCBOR(x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base-sepolia",
+		Payload:     map[string]any{"signature": "0xabcdef"},
+	})
+	if err != nil {
+		t.Fatalf("EncodePaymentCBOR: %v", err)
+	}
+
 	tests := []struct {
 		name        string
 		header      string
+		encoding    string
 		wantErr     bool
 		errContains string
 		validate    func(*testing.T, x402.PaymentPayload)
@@ -76,6 +88,39 @@ func TestParsePaymentHeaderFromRequest(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "CBOR payment header",
+			header:   cborPayment,
+			encoding: "cbor",
+			validate: func(t *testing.T, p x402.PaymentPayload) {
+				if p.Network != "base-sepolia" || p.Payload == nil {
+					t.Errorf("Expected base-sepolia payment with payload, got %+v", p)
+				}
+			},
+		},
+		{
+			name:     "CBOR media type",
+			header:   cborPayment,
+			encoding: "application/cbor",
+		},
+		{
+			name:   "CBOR without encoding header",
+			header: cborPayment,
+		},
+		{
+			name:        "JSON declared as CBOR",
+			header:      base64.StdEncoding.EncodeToString([]byte(`{"x402Version": 1}`)),
+			encoding:    "cbor",
+			wantErr:     true,
+			errContains: "malformed",
+		},
+		{
+			name:        "unsupported encoding",
+			header:      cborPayment,
+			encoding:    "protobuf",
+			wantErr:     true,
+			errContains: "unsupported payment encoding",
+		},
 	}
 
 	for _, tt := range tests {
@@ -84,6 +129,9 @@ func TestParsePaymentHeaderFromRequest(t *testing.T) {
 			if tt.header != "" {
 				req.Header.Set("X-PAYMENT", tt.header)
 			}
+			if tt.encoding != "" {
+				req.Header.Set("X-PAYMENT-ENCODING", tt.encoding)
+			}
 
 			payment, err := ParsePaymentHeaderFromRequest(req)
 
@@ -291,3 +339,39 @@ func TestAddPaymentResponseHeader(t *testing.T) {
 		t.Errorf("Expected Transaction=0xabc123, got %s", decodedSettlement.Transaction)
 	}
 }
+
+// TestGetPayer_Permit tests payer extraction from EIP-2612 permit payloads
+func TestGetPayer_Permit(t *testing.T) {
+	owner := "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+	payload := x402.EVMPermitPayload{
+		Signature: "0xsig",
+		Permit:    x402.EVMPermit{Owner: owner, Spender: "0xspender", Value: "1000", Nonce: "0", Deadline: "1700000000"},
+	}
+
+	// Decoded from a header, the payload is a map
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, p := range []interface{}{payload, &payload, decoded} {
+		payment := x402.PaymentPayload{X402Version: 1, Scheme: "permit", Network: "base", Payload: p}
+		if got := GetPayer(payment); got != owner {
+			t.Errorf("GetPayer(%T) = %q, want %q", p, got, owner)
+		}
+		key := PaymentKey(payment)
+		if strings.Contains(key, strings.ToLower(owner)) {
+			t.Errorf("PaymentKey(%T) = %q, want a payload hash", p, key)
+		}
+		keys = append(keys, key)
+	}
+	// The key does not depend on how the payload was built
+	if keys[0] != keys[1] || keys[0] != keys[2] {
+		t.Errorf("PaymentKey() = %v, want the same key for every form", keys)
+	}
+}
@@ -1,11 +1,18 @@
 package helpers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 
 	"github.com/mark3labs/x402-go"
 )
 
+// GetPayer extracts the payer address from a payment payload without verifying it.
+// Returns an empty string if the payer cannot be determined.
 func GetPayer(payment x402.PaymentPayload) string {
 	logger := slog.Default()
 	switch payment.Network {
@@ -17,6 +24,118 @@ func GetPayer(payment x402.PaymentPayload) string {
 		}
 		return payer
 	default:
-		return ""
+		from, _ := getAuthorization(payment)
+		return from
+	}
+}
+
+// PaymentKey returns a stable identifier for the authorization carried by a payment,
+// used to detect the same authorization being presented more than once.
+// EVM payments are keyed by payer and EIP-3009 nonce; other payments by a hash of
+// their canonical payload.
+func PaymentKey(payment x402.PaymentPayload) string {
+	if from, nonce := getAuthorization(payment); from != "" && nonce != "" {
+		return payment.Network + ":" + strings.ToLower(from) + ":" + strings.ToLower(nonce)
+	}
+
+	hash, err := x402.CanonicalHash(payment.Payload)
+	if err != nil {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%v", payment.Payload)))
+		hash = hex.EncodeToString(sum[:])
+	}
+	return payment.Network + ":" + hash
+}
+
+// GetNonce returns the nonce of a payment's EIP-3009 authorization, or "" for payments
+// without one, such as permits and Solana transactions.
+func GetNonce(payment x402.PaymentPayload) string {
+	_, nonce := getAuthorization(payment)
+	return nonce
+}
+
+// GetValue returns the amount a payment's EVM authorization transfers: the EIP-3009
+// value or the EIP-2612 permit value. It returns false for payments that carry neither,
+// such as Solana transactions.
+func GetValue(payment x402.PaymentPayload) (string, bool) {
+	var value string
+	switch payload := payment.Payload.(type) {
+	case x402.EVMPayload:
+		value = payload.Authorization.Value
+	case *x402.EVMPayload:
+		value = payload.Authorization.Value
+	case x402.EVMPermitPayload:
+		value = payload.Permit.Value
+	case *x402.EVMPermitPayload:
+		value = payload.Permit.Value
+	case map[string]any:
+		if permit, ok := payload["permit"].(map[string]any); ok {
+			value, _ = permit["value"].(string)
+		} else if authorization, ok := payload["authorization"].(map[string]any); ok {
+			value, _ = authorization["value"].(string)
+		}
+	}
+	return value, value != ""
+}
+
+// getAuthorization reads the payer and nonce of an EIP-3009 authorization. For EIP-2612
+// permits it returns the owner only: permit nonces are sequential per token, so they do
+// not identify a permit across assets.
+func getAuthorization(payment x402.PaymentPayload) (from, nonce string) {
+	switch payload := payment.Payload.(type) {
+	case x402.EVMPayload:
+		return payload.Authorization.From, payload.Authorization.Nonce
+	case *x402.EVMPayload:
+		return payload.Authorization.From, payload.Authorization.Nonce
+	case x402.EVMPermitPayload:
+		return payload.Permit.Owner, ""
+	case *x402.EVMPermitPayload:
+		return payload.Permit.Owner, ""
+	case map[string]any:
+		if permit, ok := payload["permit"].(map[string]any); ok {
+			from, _ = permit["owner"].(string)
+			return from, ""
+		}
+		authorization, ok := payload["authorization"].(map[string]any)
+		if !ok {
+			return "", ""
+		}
+		from, _ = authorization["from"].(string)
+		nonce, _ = authorization["nonce"].(string)
+		return from, nonce
+	default:
+		return "", ""
+	}
+}
+
+// GetValidBefore returns the unix time after which a payment's EVM authorization can no
+// longer be settled: the EIP-3009 validBefore or the EIP-2612 permit deadline. It
+// returns false for payments that carry neither, such as Solana transactions.
+func GetValidBefore(payment x402.PaymentPayload) (int64, bool) {
+	var raw any
+	switch payload := payment.Payload.(type) {
+	case x402.EVMPayload:
+		raw = payload.Authorization.ValidBefore
+	case *x402.EVMPayload:
+		raw = payload.Authorization.ValidBefore
+	case x402.EVMPermitPayload:
+		raw = payload.Permit.Deadline
+	case *x402.EVMPermitPayload:
+		raw = payload.Permit.Deadline
+	case map[string]any:
+		if permit, ok := payload["permit"].(map[string]any); ok {
+			raw = permit["deadline"]
+		} else if authorization, ok := payload["authorization"].(map[string]any); ok {
+			raw = authorization["validBefore"]
+		}
+	}
+
+	switch v := raw.(type) {
+	case string:
+		unix, err := strconv.ParseInt(v, 10, 64)
+		return unix, err == nil
+	case float64:
+		return int64(v), true
+	default:
+		return 0, false
 	}
 }
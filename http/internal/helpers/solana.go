@@ -1,3 +1,5 @@
+//go:build !(js && wasm) && !x402lite && !tinygo
+
 package helpers
 
 import (
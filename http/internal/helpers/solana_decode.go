@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+// Program IDs recognized by decodeSolanaPayer.
+var (
+	solanaSystemProgramID = make([]byte, 32)
+	solanaTokenProgramID  = mustDecodeBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+)
+
+// Instruction discriminators for payer-carrying instructions.
+const (
+	systemTransferInstruction       = 2  // u32 little-endian
+	tokenTransferInstruction        = 3  // u8
+	tokenTransferCheckedInstruction = 12 // u8
+)
+
+// errShortTransaction indicates the transaction ended before a complete message was read.
+var errShortTransaction = errors.New("transaction too short")
+
+// decodeSolanaPayer returns the account funding the first system or SPL token transfer in
+// a serialized Solana transaction, without depending on solana-go. It understands
+// legacy and versioned (v0) messages; accounts loaded from address lookup tables are
+// not resolved. It returns "" if the transaction contains no transfer.
+func decodeSolanaPayer(tx []byte) (string, error) {
+	r := &solanaReader{data: tx}
+
+	// Skip the signatures
+	signatures, err := r.compactU16()
+	if err != nil {
+		return "", err
+	}
+	if err := r.skip(signatures * 64); err != nil {
+		return "", err
+	}
+
+	// Versioned messages set the high bit of the first byte
+	prefix, err := r.peek()
+	if err != nil {
+		return "", err
+	}
+	if prefix&0x80 != 0 {
+		if version := prefix & 0x7f; version != 0 {
+			return "", fmt.Errorf("unsupported message version %d", version)
+		}
+		_ = r.skip(1)
+	}
+
+	// Skip the message header: required signatures, readonly signed and unsigned accounts
+	if err := r.skip(3); err != nil {
+		return "", err
+	}
+
+	accountCount, err := r.compactU16()
+	if err != nil {
+		return "", err
+	}
+	accounts := make([][]byte, accountCount)
+	for i := range accounts {
+		if accounts[i], err = r.next(32); err != nil {
+			return "", err
+		}
+	}
+
+	// Skip the recent blockhash
+	if err := r.skip(32); err != nil {
+		return "", err
+	}
+
+	instructionCount, err := r.compactU16()
+	if err != nil {
+		return "", err
+	}
+	for range instructionCount {
+		programIndex, err := r.byte()
+		if err != nil {
+			return "", err
+		}
+		indexCount, err := r.compactU16()
+		if err != nil {
+			return "", err
+		}
+		indexes, err := r.next(indexCount)
+		if err != nil {
+			return "", err
+		}
+		dataLen, err := r.compactU16()
+		if err != nil {
+			return "", err
+		}
+		data, err := r.next(dataLen)
+		if err != nil {
+			return "", err
+		}
+
+		if int(programIndex) >= len(accounts) {
+			continue
+		}
+		program := accounts[programIndex]
+
+		// The funding account is the first account of a system transfer, the owner is the
+		// third of a token transfer and the fourth of a checked token transfer
+		payerIndex := -1
+		switch {
+		case bytes.Equal(program, solanaSystemProgramID):
+			if len(data) >= 4 && data[0] == systemTransferInstruction && data[1] == 0 && data[2] == 0 && data[3] == 0 {
+				payerIndex = 0
+			}
+		case bytes.Equal(program, solanaTokenProgramID):
+			if len(data) > 0 && data[0] == tokenTransferInstruction {
+				payerIndex = 2
+			} else if len(data) > 0 && data[0] == tokenTransferCheckedInstruction {
+				payerIndex = 3
+			}
+		}
+		if payerIndex < 0 || payerIndex >= len(indexes) || int(indexes[payerIndex]) >= len(accounts) {
+			continue
+		}
+		return base58.Encode(accounts[indexes[payerIndex]]), nil
+	}
+	return "", nil
+}
+
+// solanaReader reads the wire format of a Solana transaction.
+type solanaReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *solanaReader) next(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errShortTransaction
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *solanaReader) skip(n int) error {
+	_, err := r.next(n)
+	return err
+}
+
+func (r *solanaReader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *solanaReader) peek() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errShortTransaction
+	}
+	return r.data[r.pos], nil
+}
+
+// compactU16 reads a compact-u16 length: up to three bytes, seven bits each.
+func (r *solanaReader) compactU16() (int, error) {
+	value := 0
+	for i := 0; i < 3; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		value |= int(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, errors.New("invalid compact-u16 length")
+}
+
+func mustDecodeBase58(s string) []byte {
+	b, err := base58.Decode(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mr-tron/base58"
+)
+
+// testInstruction is an instruction in a transaction built by buildSolanaTransaction.
+type testInstruction struct {
+	program  byte
+	accounts []byte
+	data     []byte
+}
+
+// buildSolanaTransaction serializes a single-signature transaction over accounts.
+func buildSolanaTransaction(versioned bool, accounts [][]byte, instructions []testInstruction) []byte {
+	var b bytes.Buffer
+	b.WriteByte(1)
+	b.Write(make([]byte, 64))
+	if versioned {
+		b.WriteByte(0x80)
+	}
+	b.Write([]byte{1, 0, 1})
+	b.WriteByte(byte(len(accounts)))
+	for _, account := range accounts {
+		b.Write(account)
+	}
+	b.Write(bytes.Repeat([]byte{7}, 32))
+	b.WriteByte(byte(len(instructions)))
+	for _, ix := range instructions {
+		b.WriteByte(ix.program)
+		b.WriteByte(byte(len(ix.accounts)))
+		b.Write(ix.accounts)
+		b.WriteByte(byte(len(ix.data)))
+		b.Write(ix.data)
+	}
+	if versioned {
+		b.WriteByte(0) // no address table lookups
+	}
+	return b.Bytes()
+}
+
+func testAccount(fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, 32)
+}
+
+func TestDecodeSolanaPayer(t *testing.T) {
+	payer := testAccount(1)
+	wantPayer := base58.Encode(payer)
+	computeBudget := mustDecodeBase58("ComputeBudget111111111111111111111111111111")
+
+	tests := []struct {
+		name    string
+		tx      []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "system transfer",
+			tx: buildSolanaTransaction(false, [][]byte{payer, testAccount(2), solanaSystemProgramID}, []testInstruction{
+				{program: 2, accounts: []byte{0, 1}, data: []byte{2, 0, 0, 0, 0x10, 0x27, 0, 0, 0, 0, 0, 0}},
+			}),
+			want: wantPayer,
+		},
+		{
+			name: "token transfer checked after compute budget",
+			tx: buildSolanaTransaction(true, [][]byte{testAccount(9), payer, testAccount(3), testAccount(4), testAccount(5), computeBudget, solanaTokenProgramID}, []testInstruction{
+				{program: 5, data: []byte{2, 0x40, 0x0d, 0x03, 0}},
+				{program: 6, accounts: []byte{2, 3, 4, 1}, data: []byte{12, 0x10, 0x27, 0, 0, 0, 0, 0, 0, 6}},
+			}),
+			want: wantPayer,
+		},
+		{
+			name: "token transfer",
+			tx: buildSolanaTransaction(false, [][]byte{testAccount(9), testAccount(3), testAccount(4), payer, solanaTokenProgramID}, []testInstruction{
+				{program: 4, accounts: []byte{1, 2, 3}, data: []byte{3, 0x10, 0x27, 0, 0, 0, 0, 0, 0}},
+			}),
+			want: wantPayer,
+		},
+		{
+			name: "no transfer",
+			tx: buildSolanaTransaction(false, [][]byte{payer, computeBudget}, []testInstruction{
+				{program: 1, data: []byte{2, 0x40, 0x0d, 0x03, 0}},
+			}),
+		},
+		{
+			name:    "truncated",
+			tx:      buildSolanaTransaction(false, [][]byte{payer, solanaSystemProgramID}, nil)[:100],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSolanaPayer(tt.tx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeSolanaPayer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("decodeSolanaPayer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+//go:build (js && wasm) || x402lite || tinygo
+
+package helpers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// getPayerWithSolana decodes the payer with the dependency-free decoder, since
+// solana-go does not build for js/wasm or TinyGo and x402lite builds leave it out.
+func getPayerWithSolana(payment x402.PaymentPayload, logger *slog.Logger) (string, error) {
+	payload, ok := payment.Payload.(map[string]any)
+	if !ok {
+		logger.Error("invalid payload type")
+		return "", fmt.Errorf("invalid payload type")
+	}
+	transaction, ok := payload["transaction"].(string)
+	if !ok {
+		logger.Error("transaction not found in payload")
+		return "", fmt.Errorf("transaction not found in payload")
+	}
+
+	tx, err := base64.StdEncoding.DecodeString(transaction)
+	if err != nil {
+		logger.Error("failed to decode transaction", "error", err)
+		return "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	return decodeSolanaPayer(tx)
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// paymentSlots limits the payment flows in flight per destination host.
+// The zero value is ready to use.
+type paymentSlots struct {
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+// hostSlots are the slots of one host. Entries are dropped once no flow holds or waits
+// for a slot, so the map only grows with the hosts being paid concurrently.
+type hostSlots struct {
+	sem   chan struct{}
+	users int
+}
+
+// acquire waits for one of the n slots of host and returns the function releasing it.
+// It fails with ctx's error if ctx ends first.
+func (s *paymentSlots) acquire(ctx context.Context, host string, n int) (func(), error) {
+	host = strings.ToLower(host)
+
+	s.mu.Lock()
+	if s.hosts == nil {
+		s.hosts = make(map[string]*hostSlots)
+	}
+	slots, ok := s.hosts[host]
+	if !ok {
+		slots = &hostSlots{sem: make(chan struct{}, n)}
+		s.hosts[host] = slots
+	}
+	slots.users++
+	s.mu.Unlock()
+
+	select {
+	case slots.sem <- struct{}{}:
+		return func() {
+			<-slots.sem
+			s.leave(host, slots)
+		}, nil
+	case <-ctx.Done():
+		s.leave(host, slots)
+		return nil, ctx.Err()
+	}
+}
+
+// leave drops a flow from host's slots, and the slots once unused.
+func (s *paymentSlots) leave(host string, slots *hostSlots) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slots.users--
+	if slots.users == 0 {
+		delete(s.hosts, host)
+	}
+}
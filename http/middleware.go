@@ -1,15 +1,28 @@
 // Package http provides HTTP middleware for x402 payment gating.
+//
+// The package, including the client transport, also builds for GOOS=js GOARCH=wasm
+// (Cloudflare Workers, browsers). There, outgoing requests use the runtime's fetch API
+// and Solana payers are decoded without solana-go.
+//
+// Built with the x402lite tag, or with TinyGo, the package does not depend on solana-go
+// on any platform. Together with signers/evmlite, which does not depend on go-ethereum,
+// this keeps clients small enough for routers and sensors.
 package http
 
 import (
 	"bufio"
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"log/slog"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/coupons"
+	"github.com/mark3labs/x402-go/facilitator"
+	"github.com/mark3labs/x402-go/sanctions"
 )
 
 // Config holds the configuration for the x402 middleware.
@@ -20,12 +33,165 @@ type Config struct {
 	// FallbackFacilitatorURL is the optional backup facilitator
 	FallbackFacilitatorURL string
 
+	// FacilitatorByNetwork routes payments for specific networks to dedicated facilitators,
+	// e.g. {"solana": "https://svm-facilitator.example.com"}. Networks not listed use
+	// FacilitatorURL. Dedicated facilitators share the primary facilitator's
+	// authorization, signing secret and hooks.
+	FacilitatorByNetwork map[string]string
+
+	// FacilitatorResolver optionally returns the facilitator URL for a network.
+	// It takes precedence over FacilitatorByNetwork; returning "" falls through to it.
+	FacilitatorResolver FacilitatorResolver
+
 	// PaymentRequirements defines the accepted payment methods
 	PaymentRequirements []x402.PaymentRequirement
 
+	// AcceptsSorter optionally reorders the payment requirements offered on each request,
+	// e.g. to prefer a network while its facilitator is healthy. See AcceptsSorter.
+	AcceptsSorter AcceptsSorter
+
+	// PriceResolver optionally adjusts the payment requirements per payer, e.g. to give
+	// allowlisted wallets a discount or the owner free access. See PriceResolver.
+	PriceResolver PriceResolver
+
+	// RangePricing optionally charges Range requests for part of a download in
+	// proportion to the bytes requested. See RangePricing.
+	RangePricing *RangePricing
+
+	// BatchPricing optionally prices requests carrying a batch of operations, such as a
+	// JSON-RPC batch, by their number of operations. See BatchPricing.
+	BatchPricing *BatchPricing
+
+	// RequirementsFunc optionally computes each request's payment requirements, e.g. to
+	// price a GraphQL query by its cost (see the graphql package). See RequirementsFunc.
+	RequirementsFunc RequirementsFunc
+
+	// ReputationProvider optionally assesses each payer before verification, to deny,
+	// surcharge or degrade service for risky payers. A provider error is logged and the
+	// payer treated normally. See ReputationProvider.
+	ReputationProvider ReputationProvider
+
+	// Sanctions optionally screens the payer (and payTo) addresses of each payment
+	// against sanctions lists before it is verified. See sanctions.Policy.
+	Sanctions *sanctions.Policy
+
+	// FreeQuota optionally serves each client a number of requests for free before
+	// payment is required. See FreeQuota.
+	FreeQuota *FreeQuota
+
+	// Health optionally records the middleware's settlements for HealthHandler and
+	// AdminHandler. See HealthMonitor.
+	Health *HealthMonitor
+
+	// Coupons optionally lets requests carrying a valid coupon in the X-X402-Coupon
+	// header bypass payment. Mint coupons with the same coupons.Issuer.
+	Coupons *coupons.Issuer
+
+	// AcceptAPIKeys lists API keys that grant access without payment, easing migration
+	// from key-based billing. Callers without a valid key still receive 402.
+	AcceptAPIKeys []string
+
+	// APIKeyHeader is the header carrying the API key (default X-API-Key).
+	// An "Authorization: Bearer <key>" header is also accepted.
+	APIKeyHeader string
+
+	// Sessions optionally issues a signed session after each settled payment that covers
+	// the payer's following requests. See SessionConfig.
+	Sessions *SessionConfig
+
+	// NonceStore optionally records accepted payment authorizations so a replayed
+	// X-PAYMENT header is rejected with 402. Use a shared store when running several
+	// replicas. See NonceStore.
+	NonceStore NonceStore
+
+	// PaymentBinding optionally requires EIP-3009 payments to be bound to the client's
+	// DPoP key (see x402.PaymentBinding) and checks they are presented by that client.
+	// See BindingPolicy.
+	PaymentBinding *BindingPolicy
+
+	// Idempotency optionally replays the stored response when a verified payer repeats
+	// the Idempotency-Key of an operation already paid for, without settling the new
+	// payment. See IdempotencyConfig.
+	Idempotency *IdempotencyConfig
+
+	// Revalidator optionally keeps cache revalidation of paid resources free: GET and
+	// HEAD requests whose If-None-Match or If-Modified-Since match the resource's
+	// current validators receive 304 Not Modified without payment. See Revalidator.
+	Revalidator Revalidator
+
+	// PrivateCacheMaxAge lets the paying client's private cache keep paid responses for
+	// this long ("Cache-Control: private, max-age=N"). By default paid responses are sent
+	// with "Cache-Control: no-store". Either way they carry "Vary: X-PAYMENT", so shared
+	// caches do not serve paid content to clients that did not pay.
+	PrivateCacheMaxAge time.Duration
+
+	// DisableCacheHeaders leaves the Cache-Control and Vary headers of paid responses to
+	// the handler.
+	DisableCacheHeaders bool
+
+	// ReceiptSigningKey optionally signs each X-PAYMENT-RESPONSE header with Ed25519 so
+	// clients that pin the matching public key can reject forged settlement receipts.
+	// The signature also covers the request's X-PAYMENT header and URL (see
+	// RequirementsSigningKey), so a receipt cannot be replayed for another payment.
+	ReceiptSigningKey ed25519.PrivateKey
+
+	// RequirementsSigningKey optionally signs the payment requirements of each 402
+	// response with Ed25519 (see RequirementsSignatureHeader), so clients that pin the
+	// matching public key can reject requirements altered in transit, such as a swapped
+	// payTo address. The signature covers the request's method and URL as the server
+	// sees them (EngineRequest.ResourceURL), so it cannot be replayed on a cheaper
+	// resource; behind a TLS-terminating proxy the URL must still use the scheme and host
+	// clients request. Publish the public key with RequirementsKeysHandler.
+	RequirementsSigningKey ed25519.PrivateKey
+
+	// VerifyTimeout bounds payment verification, including any fallback facilitator
+	// attempt (default x402.DefaultTimeouts.VerifyTimeout). A timed-out verification
+	// is answered with 504 Gateway Timeout.
+	VerifyTimeout time.Duration
+
+	// SettleTimeout bounds payment settlement, including any fallback facilitator
+	// attempt (default x402.DefaultTimeouts.SettleTimeout). A timed-out settlement
+	// is answered with 504 Gateway Timeout.
+	SettleTimeout time.Duration
+
+	// SettleLatencyBudget optionally bounds how long a paid response waits for
+	// settlement. A settlement still running after this long completes in the
+	// background, up to SettleTimeout, and the response is sent at once, since its
+	// payment was already verified. The response then carries SettlementStatusHeader
+	// instead of X-PAYMENT-RESPONSE and grants no session. The outcome reaches the
+	// FacilitatorOnAfterSettle hooks (e.g. a finality ledger or invoice webhooks) and
+	// Health as usual. If the deferred settlement fails, the response was served unpaid;
+	// the payment stays claimed so it cannot be reused.
+	SettleLatencyBudget time.Duration
+
+	// SettleDetached says what happens to a settlement in progress when its request is
+	// canceled, e.g. because the client disconnected. If nil (the default) or true, the
+	// settlement is detached from the request and runs to completion, up to
+	// SettleTimeout, so a verified payment is not abandoned half-submitted. If false,
+	// the facilitator call is canceled with the request; the payment is then released
+	// and may be presented again. A settlement deferred by SettleLatencyBudget is
+	// detached once deferred either way, since it outlives the request by design.
+	SettleDetached *bool
+
 	// VerifyOnly skips settlement if true (only verifies payments)
 	VerifyOnly bool
 
+	// AcceptSimulatedPayments serves requests paying with a simulated payment (see
+	// x402.PaymentPayload.Simulated) for testnet requirements without verifying or
+	// settling it, for end-to-end tests that spend no funds. Simulated payments are
+	// otherwise rejected with a 402 whose reason is ReasonSimulatedPayment.
+	AcceptSimulatedPayments bool
+
+	// FacilitatorHTTPClient is the HTTP client used for every facilitator request,
+	// including the fallback and per-network facilitators. Use facilitator.NewHTTPClient
+	// to build one that goes through a proxy or presents a client certificate.
+	// Defaults to http.DefaultClient.
+	FacilitatorHTTPClient *http.Client
+
+	// CheckFacilitatorReachability makes Validate query each facilitator's /supported
+	// endpoint, so an unreachable facilitator fails at startup.
+	CheckFacilitatorReachability bool
+
 	// FacilitatorAuthorization is a static Authorization header value for the primary facilitator.
 	// Example: "Bearer your-api-key" or "Basic base64-encoded-credentials"
 	FacilitatorAuthorization string
@@ -35,6 +201,10 @@ type Config struct {
 	// If set, this takes precedence over FacilitatorAuthorization.
 	FacilitatorAuthorizationProvider AuthorizationProvider
 
+	// FacilitatorSigningSecret enables HMAC signing of verify/settle requests sent to the
+	// primary facilitator. Use this with self-hosted facilitators that check signatures.
+	FacilitatorSigningSecret []byte
+
 	// Facilitator hooks for custom logic before/after verify and settle operations
 	FacilitatorOnBeforeVerify OnBeforeFunc
 	FacilitatorOnAfterVerify  OnAfterVerifyFunc
@@ -48,6 +218,9 @@ type Config struct {
 	// for the fallback facilitator. If set, this takes precedence over FallbackFacilitatorAuthorization.
 	FallbackFacilitatorAuthorizationProvider AuthorizationProvider
 
+	// FallbackFacilitatorSigningSecret enables HMAC request signing for the fallback facilitator.
+	FallbackFacilitatorSigningSecret []byte
+
 	// FallbackFacilitator hooks for custom logic before/after verify and settle operations
 	FallbackFacilitatorOnBeforeVerify OnBeforeFunc
 	FallbackFacilitatorOnAfterVerify  OnAfterVerifyFunc
@@ -61,174 +234,126 @@ type contextKey string
 // PaymentContextKey is the context key for storing verified payment information.
 const PaymentContextKey = contextKey("x402_payment")
 
+// PayerFromContext returns the payer of the request whose context is ctx: the verified
+// payer of its payment, or the payer of the session it was authorized by. It returns
+// false for requests that reached the handler without either.
+func PayerFromContext(ctx context.Context) (string, bool) {
+	if payment, ok := ctx.Value(PaymentContextKey).(*facilitator.VerifyResponse); ok && payment != nil {
+		if payment.Payer != "" {
+			return payment.Payer, true
+		}
+		if payer := PaymentPayer(payment.PaymentPayload); payer != "" {
+			return payer, true
+		}
+	}
+	if claims, ok := ctx.Value(SessionContextKey).(*SessionClaims); ok && claims != nil && claims.Payer != "" {
+		return claims.Payer, true
+	}
+	return "", false
+}
+
 // NewX402Middleware creates a new x402 payment middleware.
 // It returns a middleware function that wraps HTTP handlers with payment gating.
 // The middleware automatically fetches network-specific configuration (like feePayer for SVM chains)
 // from the facilitator's /supported endpoint.
+//
+// Payment is settled when the handler writes a successful response status; if the handler
+// fails (status >= 400), the payment is not charged.
+//
+// NewX402Middleware panics if config.Validate returns an error.
 func NewX402Middleware(config *Config) func(http.Handler) http.Handler {
-	// Create facilitator client
-	facilitator := &FacilitatorClient{
-		BaseURL:               config.FacilitatorURL,
-		Client:                &http.Client{},
-		Timeouts:              x402.DefaultTimeouts,
-		Authorization:         config.FacilitatorAuthorization,
-		AuthorizationProvider: config.FacilitatorAuthorizationProvider,
-		OnBeforeVerify:        config.FacilitatorOnBeforeVerify,
-		OnAfterVerify:         config.FacilitatorOnAfterVerify,
-		OnBeforeSettle:        config.FacilitatorOnBeforeSettle,
-		OnAfterSettle:         config.FacilitatorOnAfterSettle,
-	}
-
-	// Create fallback facilitator client if configured
-	var fallbackFacilitator *FacilitatorClient
-	if config.FallbackFacilitatorURL != "" {
-		fallbackFacilitator = &FacilitatorClient{
-			BaseURL:               config.FallbackFacilitatorURL,
-			Client:                &http.Client{},
-			Timeouts:              x402.DefaultTimeouts,
-			Authorization:         config.FallbackFacilitatorAuthorization,
-			AuthorizationProvider: config.FallbackFacilitatorAuthorizationProvider,
-			OnBeforeVerify:        config.FallbackFacilitatorOnBeforeVerify,
-			OnAfterVerify:         config.FallbackFacilitatorOnAfterVerify,
-			OnBeforeSettle:        config.FallbackFacilitatorOnBeforeSettle,
-			OnAfterSettle:         config.FallbackFacilitatorOnAfterSettle,
-		}
-	}
-
-	// Enrich payment requirements with facilitator-specific data (like feePayer)
-	enrichedRequirements, err := facilitator.EnrichRequirements(config.PaymentRequirements)
-	if err != nil {
-		// Log warning but continue with original requirements
-		slog.Default().Warn("failed to enrich payment requirements from facilitator", "error", err)
-		enrichedRequirements = config.PaymentRequirements
-	} else {
-		slog.Default().Info("payment requirements enriched from facilitator", "count", len(enrichedRequirements))
-	}
+	engine := MustNewEngine(config)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger := slog.Default()
-
-			// Build absolute URL for the resource
-			scheme := "http"
-			if r.TLS != nil {
-				scheme = "https"
-			}
-			resourceURL := scheme + "://" + r.Host + r.RequestURI
-
-			// Populate resource field in requirements with the actual request URL
-			requirementsWithResource := make([]x402.PaymentRequirement, len(enrichedRequirements))
-			for i, req := range enrichedRequirements {
-				requirementsWithResource[i] = req
-				requirementsWithResource[i].Resource = resourceURL
-				if requirementsWithResource[i].Description == "" {
-					requirementsWithResource[i].Description = "Payment required for " + r.URL.Path
-				}
-			}
-
-			// Check for X-PAYMENT header
-			paymentHeader := r.Header.Get("X-PAYMENT")
-			if paymentHeader == "" {
-				// No payment provided - return 402 with requirements
-				logger.Info("no payment header provided", "path", r.URL.Path)
-				sendPaymentRequiredWithRequirements(w, requirementsWithResource)
-				return
-			}
-
-			// Parse payment header
-			payment, err := parsePaymentHeader(r)
-			if err != nil {
-				logger.Warn("invalid payment header", "error", err)
-				http.Error(w, "Invalid payment header", http.StatusBadRequest)
-				return
-			}
+			serveEngine(engine, next, w, r)
+		})
+	}
+}
 
-			// Find matching requirement
-			requirement, err := findMatchingRequirement(payment, requirementsWithResource)
-			if err != nil {
-				logger.Warn("no matching requirement", "error", err)
-				sendPaymentRequiredWithRequirements(w, requirementsWithResource)
-				return
-			}
+// serveEngine gates next behind engine's payment checks for one request, settling the
+// payment when next writes a successful status.
+func serveEngine(engine *Engine, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	decision := engine.Authorize(r.Context(), NewEngineRequest(r))
+	if !decision.Proceed {
+		writeDecision(w, r, decision)
+		return
+	}
+	if decision.Session != nil {
+		r = r.WithContext(WithSession(r.Context(), decision.Session))
+	}
+	if decision.Reputation != nil {
+		r = r.WithContext(WithReputation(r.Context(), decision.Reputation))
+	}
+	if decision.Payment == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
 
-			// Verify payment with facilitator
-			logger.Info("verifying payment", "scheme", payment.Scheme, "network", payment.Network)
-			verifyResp, err := facilitator.Verify(r.Context(), payment, requirement)
-			if err != nil && fallbackFacilitator != nil {
-				logger.Warn("primary facilitator failed, trying fallback", "error", err)
-				verifyResp, err = fallbackFacilitator.Verify(r.Context(), payment, requirement)
-			}
-			if err != nil {
-				logger.Error("facilitator verification failed", "error", err)
-				http.Error(w, "Payment verification failed", http.StatusServiceUnavailable)
-				return
+	// Store payment info in context for handler access
+	ctx := context.WithValue(r.Context(), PaymentContextKey, decision.Payment)
+	r = r.WithContext(ctx)
+
+	interceptor := &settlementInterceptor{
+		w:       w,
+		capture: engine.Capture(decision),
+		settleFunc: func(statusCode int) bool {
+			settled := engine.SettleResponse(r.Context(), decision, statusCode)
+			if !settled.Proceed {
+				writeDecision(w, r, settled)
+				return false
 			}
+			settled.CopyHeader(w.Header())
+			return true
+		},
+		onFailure: func(statusCode int) {
+			slog.Default().Warn("handler returned non-success, skipping payment settlement", "status", statusCode)
+			decision.Release()
+		},
+	}
+	next.ServeHTTP(interceptor, r)
 
-			if !verifyResp.IsValid {
-				logger.Warn("payment verification failed", "reason", verifyResp.InvalidReason)
-				sendPaymentRequiredWithRequirements(w, requirementsWithResource)
-				return
-			}
+	// Keep the response of a settled request for replay of its Idempotency-Key
+	var response *IdempotentResponse
+	if interceptor.settled {
+		response = interceptor.capture.Response(interceptor.status, w.Header())
+	}
+	engine.Complete(r.Context(), decision, response)
+}
 
-			// Payment verified successfully
-			logger.Info("payment verified", "payer", verifyResp.Payer)
-
-			// Store payment info in context for handler access
-			ctx := context.WithValue(r.Context(), PaymentContextKey, verifyResp)
-			r = r.WithContext(ctx)
-
-			interceptor := &settlementInterceptor{
-				w: w,
-				settleFunc: func() bool {
-					if config.VerifyOnly {
-						return true
-					}
-
-					logger.Info("settling payment", "payer", verifyResp.Payer)
-					settlementResp, err := facilitator.Settle(r.Context(), payment, requirement)
-					if err != nil && fallbackFacilitator != nil {
-						logger.Warn("primary facilitator settlement failed, trying fallback", "error", err)
-						settlementResp, err = fallbackFacilitator.Settle(r.Context(), payment, requirement)
-					}
-					if err != nil {
-						logger.Error("settlement failed", "error", err)
-						http.Error(w, "Payment settlement failed", http.StatusServiceUnavailable)
-						return false
-					}
-
-					if !settlementResp.Success {
-						logger.Warn("settlement unsuccessful", "reason", settlementResp.ErrorReason)
-						sendPaymentRequiredWithRequirements(w, requirementsWithResource)
-						return false
-					}
-
-					logger.Info("payment settled", "transaction", settlementResp.Transaction)
-
-					// Add X-PAYMENT-RESPONSE header with settlement info
-					if err := addPaymentResponseHeader(w, settlementResp); err != nil {
-						logger.Warn("failed to add payment response header", "error", err)
-						// Continue anyway - payment was successful
-					}
-					return true
-				},
-				onFailure: func(statusCode int) {
-					logger.Warn("handler returned non-success, skipping payment settlement", "status", statusCode)
-				},
-			}
-			next.ServeHTTP(interceptor, r)
-		})
+// writeDecision writes the response for a decision that did not proceed to the request
+// r, compressing large 402 bodies if r accepts it.
+func writeDecision(w http.ResponseWriter, r *http.Request, decision *Decision) {
+	if decision.Replay != nil {
+		decision.Replay.Write(w)
+		return
+	}
+	if decision.Status == http.StatusNotModified {
+		decision.CopyHeader(w.Header())
+		w.WriteHeader(decision.Status)
+		return
+	}
+	if decision.Error != nil {
+		http.Error(w, decision.Error.Error, decision.Status)
+		return
 	}
+	decision.CopyHeader(w.Header())
+	writeJSONBody(w, r, decision.Status, decision.Body())
 }
 
 // settlementInterceptor wraps the ResponseWriter to intercept the moment of commitment.
 type settlementInterceptor struct {
 	w http.ResponseWriter
 	// settleFunc is the callback that performs the actual settlement logic
-	settleFunc func() bool
+	settleFunc func(statusCode int) bool
 	// onFailure is an internal logging callback
 	onFailure func(statusCode int)
+	// capture records the response body for idempotent replay (nil when not needed)
+	capture   *ResponseCapture
 	committed bool
 	hijacked  bool
+	settled   bool
+	status    int
 }
 
 func (i *settlementInterceptor) Header() http.Header {
@@ -248,6 +373,7 @@ func (i *settlementInterceptor) Write(b []byte) (int, error) {
 		return len(b), nil
 	}
 
+	i.capture.Write(b)
 	return i.w.Write(b)
 }
 
@@ -269,7 +395,7 @@ func (i *settlementInterceptor) WriteHeader(statusCode int) {
 
 	// Case 2: Handler wants to succeed. STOP!
 	// We run the settlement logic now.
-	if !i.settleFunc() {
+	if !i.settleFunc(statusCode) {
 		// Settlement failed. We mark as hijacked.
 		// The settleFunc has already written the 402/503 error to the underlying writer.
 		i.hijacked = true
@@ -279,6 +405,8 @@ func (i *settlementInterceptor) WriteHeader(statusCode int) {
 	// Case 3: Settlement succeeded.
 	// The settleFunc has already added the X-PAYMENT-RESPONSE headers.
 	// We now allow the original status code to proceed.
+	i.settled = true
+	i.status = statusCode
 	i.w.WriteHeader(statusCode)
 }
 
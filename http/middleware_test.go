@@ -1,11 +1,18 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/coupons"
+	"github.com/mark3labs/x402-go/facilitator"
 )
 
 func TestMiddleware_NoPaymentReturns402(t *testing.T) {
@@ -55,6 +62,33 @@ func TestMiddleware_NoPaymentReturns402(t *testing.T) {
 	}
 }
 
+// unreadBody fails the test if read.
+type unreadBody struct {
+	t *testing.T
+}
+
+func (b unreadBody) Read([]byte) (int, error) {
+	b.t.Error("request body read before payment was required")
+	return 0, io.EOF
+}
+
+func TestMiddleware_NoPaymentDoesNotReadBody(t *testing.T) {
+	handler := NewX402Middleware(validTestConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called without payment")
+	}))
+
+	// The 402 is decided from the headers, so clients sending Expect: 100-continue
+	// never upload the body of unpaid requests
+	req := httptest.NewRequest("POST", "/upload", io.NopCloser(unreadBody{t}))
+	req.Header.Set("Expect", "100-continue")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPaymentRequired {
+		t.Errorf("expected status 402, got %d", rec.Code)
+	}
+}
+
 func TestMiddleware_ValidPaymentSucceeds(t *testing.T) {
 	// This test will fail until we implement the middleware
 	// It requires a mock facilitator
@@ -176,3 +210,222 @@ func TestMiddleware_Integration_VerifyWithoutSettle(t *testing.T) {
 
 	t.Skip("Integration test - requires mock facilitator implementation")
 }
+
+// TestMiddleware_CouponBypassesPayment tests that a valid coupon skips the 402 flow
+func TestMiddleware_CouponBypassesPayment(t *testing.T) {
+	issuer := coupons.NewIssuer([]byte("coupon-secret"), nil)
+	token, err := issuer.Mint(coupons.Coupon{ID: "promo", MaxRedemptions: 1})
+	if err != nil {
+		t.Fatalf("Failed to mint coupon: %v", err)
+	}
+
+	config := validTestConfig()
+	config.Coupons = issuer
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// The first request redeems the coupon, the second exceeds its limit
+	for i, want := range []int{http.StatusOK, http.StatusPaymentRequired} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set(coupons.Header, token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, want, rec.Code)
+		}
+	}
+}
+
+// TestMiddleware_APIKeyBypassesPayment tests hybrid API key / payment access
+func TestMiddleware_APIKeyBypassesPayment(t *testing.T) {
+	config := validTestConfig()
+	config.AcceptAPIKeys = []string{"key-one", "key-two"}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"api key header", "X-API-Key", "key-two", http.StatusOK},
+		{"bearer token", "Authorization", "Bearer key-one", http.StatusOK},
+		{"unknown key", "X-API-Key", "key-three", http.StatusPaymentRequired},
+		{"no key", "", "", http.StatusPaymentRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+// countingTransport counts the requests it forwards.
+type countingTransport struct {
+	calls atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.calls.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// TestMiddleware_FacilitatorHTTPClient tests that facilitator requests use the configured client
+func TestMiddleware_FacilitatorHTTPClient(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	transport := &countingTransport{}
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.FacilitatorHTTPClient = &http.Client{Transport: transport}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	startup := transport.calls.Load()
+	if startup == 0 {
+		t.Error("Expected /supported to use the configured client")
+	}
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	// Verify and settle
+	if got := transport.calls.Load() - startup; got != 2 {
+		t.Errorf("Expected 2 facilitator requests through the client, got %d", got)
+	}
+}
+
+func TestMiddleware_FacilitatorRejectionReason(t *testing.T) {
+	const reason = "invalid_exact_evm_payload_authorization_valid_before"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: false, InvalidReason: reason, Payer: testPayer})
+		}
+	}))
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called for a rejected payment")
+	}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("Expected status 402, got %d", rec.Code)
+	}
+	var body x402.PaymentRequirementsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode 402 body: %v", err)
+	}
+	if body.Reason != reason || body.Payer != testPayer || len(body.Accepts) == 0 {
+		t.Errorf("Expected reason %q, payer and requirements, got %+v", reason, body)
+	}
+}
+
+// BenchmarkMiddleware_VerifyPath measures a paid request through the middleware, with
+// verification and settlement answered by an in-memory facilitator (target: <100µs/op).
+func BenchmarkMiddleware_VerifyPath(b *testing.B) {
+	verified, _ := json.Marshal(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+	settled, _ := json.Marshal(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+	supported, _ := json.Marshal(facilitator.SupportedResponse{})
+
+	// Keep request logging out of the measurement
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	config := validTestConfig()
+	config.FacilitatorHTTPClient = &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch r.URL.Path {
+			case "/verify":
+				return stubResponse(http.StatusOK, verified), nil
+			case "/settle":
+				return stubResponse(http.StatusOK, settled), nil
+			default:
+				return stubResponse(http.StatusOK, supported), nil
+			}
+		}),
+	}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	paymentHeader := pricingPaymentHeader(b, testPayer)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-PAYMENT", paymentHeader)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+	}
+}
+
+func TestPayerFromContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantPayer string
+		wantOK    bool
+	}{
+		{name: "none", ctx: context.Background()},
+		{
+			name:      "payment",
+			ctx:       context.WithValue(context.Background(), PaymentContextKey, &facilitator.VerifyResponse{Payer: "0xpayer"}),
+			wantPayer: "0xpayer",
+			wantOK:    true,
+		},
+		{
+			name:      "session",
+			ctx:       WithSession(context.Background(), &SessionClaims{Payer: "0xsession"}),
+			wantPayer: "0xsession",
+			wantOK:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payer, ok := PayerFromContext(tt.ctx)
+			if payer != tt.wantPayer || ok != tt.wantOK {
+				t.Errorf("PayerFromContext() = %q, %v; want %q, %v", payer, ok, tt.wantPayer, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// networkStatsWindow is how far back a HealthMonitor looks when computing network
+// statistics. Older settlements are forgotten, so a network that clients were steered
+// away from counts as healthy again once its failures age out.
+const networkStatsWindow = 15 * time.Minute
+
+// minNetworkSamples is the number of recent settlements a network needs before
+// HealthyNetworksFirst judges it; networks with fewer count as healthy.
+const minNetworkSamples = 5
+
+// NetworkStats summarizes a network's recent settlements, those of the last 15 minutes
+// up to the last 100.
+type NetworkStats struct {
+	Network string `json:"network"`
+
+	// Settlements is the number of recent settlement attempts and Failures the number
+	// that failed.
+	Settlements int `json:"settlements"`
+	Failures    int `json:"failures"`
+
+	// SuccessRate is the fraction of recent settlements that succeeded, from 0 to 1.
+	SuccessRate float64 `json:"successRate"`
+
+	// AverageLatencySeconds and MaxLatencySeconds describe how long recent settlements
+	// took, successful or not.
+	AverageLatencySeconds float64 `json:"averageLatencySeconds"`
+	MaxLatencySeconds     float64 `json:"maxLatencySeconds"`
+}
+
+// settlementSample is the outcome of one settlement on a network.
+type settlementSample struct {
+	at      time.Time
+	success bool
+	latency time.Duration
+}
+
+// networkSamples is the ring of a network's last settlements.
+type networkSamples struct {
+	samples []settlementSample
+	next    int
+}
+
+// recordNetwork records a settlement on network. The caller holds m.mu.
+func (m *HealthMonitor) recordNetwork(network string, sample settlementSample) {
+	if m.networks == nil {
+		m.networks = make(map[string]*networkSamples)
+	}
+	n, ok := m.networks[network]
+	if !ok {
+		n = &networkSamples{}
+		m.networks[network] = n
+	}
+	if len(n.samples) < recentPaymentsKept {
+		n.samples = append(n.samples, sample)
+	} else {
+		n.samples[n.next] = sample
+	}
+	n.next = (n.next + 1) % recentPaymentsKept
+}
+
+// NetworkStats returns the settlement statistics of each network settled on in the
+// last 15 minutes, sorted by network.
+func (m *HealthMonitor) NetworkStats() []NetworkStats {
+	if m == nil {
+		return nil
+	}
+	stats := m.networkStats(time.Now())
+	result := make([]NetworkStats, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Network < result[j].Network })
+	return result
+}
+
+// networkStats computes the statistics of each network from the samples within the
+// window before now.
+func (m *HealthMonitor) networkStats(now time.Time) map[string]NetworkStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]NetworkStats, len(m.networks))
+	for network, n := range m.networks {
+		s := NetworkStats{Network: network}
+		var total, longest time.Duration
+		for _, sample := range n.samples {
+			if now.Sub(sample.at) > networkStatsWindow {
+				continue
+			}
+			s.Settlements++
+			if !sample.success {
+				s.Failures++
+			}
+			total += sample.latency
+			longest = max(longest, sample.latency)
+		}
+		if s.Settlements == 0 {
+			continue
+		}
+		s.SuccessRate = float64(s.Settlements-s.Failures) / float64(s.Settlements)
+		s.AverageLatencySeconds = (total / time.Duration(s.Settlements)).Seconds()
+		s.MaxLatencySeconds = longest.Seconds()
+		stats[network] = s
+	}
+	return stats
+}
+
+// HealthyNetworksFirst returns an AcceptsSorter that lists requirements on currently
+// healthy networks first, nudging clients away from congested chains or failing
+// facilitators. A network is unhealthy when at least 5 recent settlements on it
+// succeeded less often than minSuccessRate (e.g. 0.9) or, if maxLatency is positive,
+// took longer than maxLatency on average. Healthy networks keep their configured order;
+// unhealthy ones follow, most successful first. Nothing is omitted, so clients that can
+// only pay on an unhealthy network still can.
+//
+//	config.Health = x402http.NewHealthMonitor()
+//	config.AcceptsSorter = config.Health.HealthyNetworksFirst(0.9, 10*time.Second)
+func (m *HealthMonitor) HealthyNetworksFirst(minSuccessRate float64, maxLatency time.Duration) AcceptsSorter {
+	return func(_ context.Context, accepts []x402.PaymentRequirement) []x402.PaymentRequirement {
+		stats := m.networkStats(time.Now())
+		healthy := func(network string) bool {
+			s, ok := stats[network]
+			if !ok || s.Settlements < minNetworkSamples {
+				return true
+			}
+			if s.SuccessRate < minSuccessRate {
+				return false
+			}
+			return maxLatency <= 0 || s.AverageLatencySeconds <= maxLatency.Seconds()
+		}
+
+		sorted := append([]x402.PaymentRequirement(nil), accepts...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			hi, hj := healthy(sorted[i].Network), healthy(sorted[j].Network)
+			if hi != hj {
+				return hi
+			}
+			if hi {
+				return false
+			}
+			return stats[sorted[i].Network].SuccessRate > stats[sorted[j].Network].SuccessRate
+		})
+		return sorted
+	}
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+// recordSamples records n settlements on network, failed ones first, each taking latency.
+func recordSamples(m *HealthMonitor, network string, n, failures int, latency time.Duration, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 0; i < n; i++ {
+		m.recordNetwork(network, settlementSample{at: at, success: i >= failures, latency: latency})
+	}
+}
+
+func TestHealthMonitor_NetworkStats(t *testing.T) {
+	m := NewHealthMonitor()
+	settle := func(network string, resp *x402.SettlementResponse, err error) {
+		d := &Decision{
+			Payment:     &facilitator.VerifyResponse{Payer: testPayer},
+			Requirement: x402.PaymentRequirement{Network: network, MaxAmountRequired: "1"},
+		}
+		m.settleStarted(d)(resp, err)
+	}
+	settle("base", &x402.SettlementResponse{Success: true}, nil)
+	settle("base", &x402.SettlementResponse{Success: true}, nil)
+	settle("base", nil, errors.New("facilitator unavailable"))
+	settle("solana", &x402.SettlementResponse{Success: false, ErrorReason: "blockhash_not_found"}, nil)
+
+	stats := m.NetworkStats()
+	if len(stats) != 2 || stats[0].Network != "base" || stats[1].Network != "solana" {
+		t.Fatalf("NetworkStats() = %+v, want base and solana", stats)
+	}
+	if stats[0].Settlements != 3 || stats[0].Failures != 1 || stats[0].SuccessRate < 0.66 || stats[0].SuccessRate > 0.67 {
+		t.Errorf("base stats = %+v, want 1 of 3 failed", stats[0])
+	}
+	if stats[1].Settlements != 1 || stats[1].SuccessRate != 0 {
+		t.Errorf("solana stats = %+v, want 1 failed", stats[1])
+	}
+	if settlement := m.settlement(); len(settlement.Networks) != 2 {
+		t.Errorf("settlement health networks = %+v, want both networks", settlement.Networks)
+	}
+
+	// Statistics cover recent settlements only
+	old := NewHealthMonitor()
+	now := time.Now()
+	recordSamples(old, "base", 10, 10, time.Second, now.Add(-networkStatsWindow-time.Minute))
+	recordSamples(old, "base", 2, 0, 3*time.Second, now)
+	got := old.networkStats(now)["base"]
+	if got.Settlements != 2 || got.Failures != 0 || got.AverageLatencySeconds != 3 || got.MaxLatencySeconds != 3 {
+		t.Errorf("stats = %+v, want only the 2 recent settlements", got)
+	}
+}
+
+func TestHealthMonitor_HealthyNetworksFirst(t *testing.T) {
+	accepts := []x402.PaymentRequirement{
+		{Network: "base"}, {Network: "solana"}, {Network: "polygon"}, {Network: "avalanche"},
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		record  func(m *HealthMonitor)
+		latency time.Duration
+		want    []string
+	}{
+		{
+			name:   "no settlements",
+			record: func(*HealthMonitor) {},
+			want:   []string{"base", "solana", "polygon", "avalanche"},
+		},
+		{
+			name: "failing network moves last",
+			record: func(m *HealthMonitor) {
+				recordSamples(m, "base", 10, 5, time.Second, now)
+				recordSamples(m, "solana", 10, 0, time.Second, now)
+			},
+			want: []string{"solana", "polygon", "avalanche", "base"},
+		},
+		{
+			name: "too few settlements to judge",
+			record: func(m *HealthMonitor) {
+				recordSamples(m, "base", minNetworkSamples-1, minNetworkSamples-1, time.Second, now)
+			},
+			want: []string{"base", "solana", "polygon", "avalanche"},
+		},
+		{
+			name: "unhealthy networks by success rate",
+			record: func(m *HealthMonitor) {
+				recordSamples(m, "base", 10, 8, time.Second, now)
+				recordSamples(m, "solana", 10, 3, time.Second, now)
+			},
+			want: []string{"polygon", "avalanche", "solana", "base"},
+		},
+		{
+			name: "slow network moves last",
+			record: func(m *HealthMonitor) {
+				recordSamples(m, "solana", 10, 0, 30*time.Second, now)
+				recordSamples(m, "base", 10, 0, time.Second, now)
+			},
+			latency: 10 * time.Second,
+			want:    []string{"base", "polygon", "avalanche", "solana"},
+		},
+		{
+			name: "failures age out",
+			record: func(m *HealthMonitor) {
+				recordSamples(m, "base", 10, 10, time.Second, now.Add(-networkStatsWindow-time.Minute))
+			},
+			want: []string{"base", "solana", "polygon", "avalanche"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewHealthMonitor()
+			tt.record(m)
+			sorted := m.HealthyNetworksFirst(0.9, tt.latency)(context.Background(), accepts)
+			var got []string
+			for _, req := range sorted {
+				got = append(got, req.Network)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if accepts[0].Network != "base" || accepts[3].Network != "avalanche" {
+		t.Error("HealthyNetworksFirst modified its input")
+	}
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/http/internal/helpers"
+)
+
+// ErrPaymentAlreadyUsed indicates a payment authorization has already been accepted.
+var ErrPaymentAlreadyUsed = errors.New("x402: payment authorization already used")
+
+// NonceStore records which payment authorizations are in use, so a replayed X-PAYMENT
+// header is rejected before it reaches the facilitator. Share one store (see the
+// redisstore package) between replicas so an authorization accepted by one pod cannot be
+// settled again by another.
+//
+// Implementations must be safe for concurrent use.
+type NonceStore interface {
+	// Claim atomically records key for ttl and reports whether this caller claimed it
+	// first (SETNX semantics).
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Release removes a claim so the authorization can be presented again, e.g. after
+	// verification or settlement failed.
+	Release(ctx context.Context, key string) error
+}
+
+// ClaimPayment claims the payment's authorization in the configured NonceStore.
+// It returns ErrPaymentAlreadyUsed if another request already claimed it. The returned
+// release function gives the claim back when the payment was not consumed; it is a
+// no-op when no NonceStore is configured.
+//
+// Claims last for the requirement's MaxTimeoutSeconds plus a minute of clock-skew
+// margin, after which the authorization can no longer be settled anyway.
+func (c *Config) ClaimPayment(ctx context.Context, payment x402.PaymentPayload, requirement x402.PaymentRequirement) (release func(), err error) {
+	if c.NonceStore == nil {
+		return func() {}, nil
+	}
+
+	key := helpers.PaymentKey(payment)
+	ttl := time.Duration(requirement.MaxTimeoutSeconds)*time.Second + time.Minute
+	claimed, err := c.NonceStore.Claim(ctx, key, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("nonce store: %w", err)
+	}
+	if !claimed {
+		return nil, ErrPaymentAlreadyUsed
+	}
+
+	return func() {
+		// Release even if the request context was cancelled
+		_ = c.NonceStore.Release(context.WithoutCancel(ctx), key)
+	}, nil
+}
+
+// MemoryNonceStore is an in-memory NonceStore for single-instance deployments.
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	claims    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryNonceStore creates an empty in-memory NonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{claims: make(map[string]time.Time)}
+}
+
+// Claim implements NonceStore.
+func (s *MemoryNonceStore) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for k, expiresAt := range s.claims {
+			if !now.Before(expiresAt) {
+				delete(s.claims, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if expiresAt, ok := s.claims[key]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.claims[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements NonceStore.
+func (s *MemoryNonceStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claims, key)
+	return nil
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryNonceStore(t *testing.T) {
+	store := NewMemoryNonceStore()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func() (bool, error)
+		want bool
+	}{
+		{"first claim", func() (bool, error) { return store.Claim(ctx, "a", time.Minute) }, true},
+		{"replayed claim", func() (bool, error) { return store.Claim(ctx, "a", time.Minute) }, false},
+		{"other key", func() (bool, error) { return store.Claim(ctx, "b", time.Minute) }, true},
+		{"claim after release", func() (bool, error) {
+			_ = store.Release(ctx, "a")
+			return store.Claim(ctx, "a", time.Minute)
+		}, true},
+		{"claim after expiry", func() (bool, error) {
+			_, _ = store.Claim(ctx, "c", time.Nanosecond)
+			time.Sleep(time.Millisecond)
+			return store.Claim(ctx, "c", time.Minute)
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run()
+			if err != nil {
+				t.Fatalf("Claim failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Claim = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware_RejectsReplayedPayment(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	// Two middleware instances sharing a store act as two replicas
+	store := NewMemoryNonceStore()
+	newReplica := func(status int) http.Handler {
+		config := validTestConfig()
+		config.FacilitatorURL = server.URL
+		config.NonceStore = store
+		return NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+	}
+	failing, replicaA, replicaB := newReplica(http.StatusInternalServerError), newReplica(http.StatusOK), newReplica(http.StatusOK)
+
+	header := pricingPaymentHeader(t, testPayer)
+	steps := []struct {
+		name    string
+		handler http.Handler
+		want    int
+	}{
+		// A handler failure releases the claim so the payment can be retried
+		{"handler failure", failing, http.StatusInternalServerError},
+		{"first use", replicaA, http.StatusOK},
+		{"replay on same replica", replicaA, http.StatusPaymentRequired},
+		{"replay on other replica", replicaB, http.StatusPaymentRequired},
+	}
+
+	for _, step := range steps {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-PAYMENT", header)
+		rec := httptest.NewRecorder()
+		step.handler.ServeHTTP(rec, req)
+		if rec.Code != step.want {
+			t.Errorf("%s: expected status %d, got %d", step.name, step.want, rec.Code)
+		}
+	}
+
+	if settleCalls.Load() != 1 {
+		t.Errorf("Expected 1 settle call, got %d", settleCalls.Load())
+	}
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+// TransportObserver receives the raw x402 messages of each payment flow of an
+// X402Transport, e.g. to debug a server or a signer. Methods are called synchronously
+// from RoundTrip, possibly concurrently for different requests, and must not modify
+// their arguments.
+type TransportObserver interface {
+	// ObservePaymentRequired receives the body of a 402 response to req.
+	ObservePaymentRequired(req *http.Request, body []byte)
+
+	// ObservePayment receives the X-PAYMENT header sent with the paid retry of req. It
+	// is called again, after ObserveSettlement, when a compact header is rejected and the
+	// payment is resent as JSON.
+	ObservePayment(req *http.Request, header string)
+
+	// ObserveSettlement receives the status and X-PAYMENT-RESPONSE header ("" if none) of
+	// the response to the paid retry of req.
+	ObserveSettlement(req *http.Request, status int, header string)
+}
+
+// DebugOption configures DebugObserver.
+type DebugOption func(*debugObserver)
+
+// RedactSignatures hides the signatures (and signed Solana transactions) of payments
+// printed by DebugObserver, so its output can be shared.
+func RedactSignatures() DebugOption {
+	return func(o *debugObserver) {
+		o.redact = true
+	}
+}
+
+// DebugObserver returns a TransportObserver printing each payment flow to w: the 402
+// body, the decoded X-PAYMENT header and the decoded settlement, as indented JSON.
+func DebugObserver(w io.Writer, opts ...DebugOption) TransportObserver {
+	o := &debugObserver{w: w}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// debugObserver is the TransportObserver returned by DebugObserver.
+type debugObserver struct {
+	// mu keeps the output of concurrent requests apart.
+	mu     sync.Mutex
+	w      io.Writer
+	redact bool
+}
+
+// redactedFields are the payload fields hidden by RedactSignatures.
+var redactedFields = []string{"signature", "transaction"}
+
+// ObservePaymentRequired implements TransportObserver.
+func (o *debugObserver) ObservePaymentRequired(req *http.Request, body []byte) {
+	o.print(fmt.Sprintf("402 Payment Required: %s %s", req.Method, req.URL), indentJSON(body))
+}
+
+// ObservePayment implements TransportObserver.
+func (o *debugObserver) ObservePayment(req *http.Request, header string) {
+	decoded, err := decodePaymentHeader(header)
+	if err != nil {
+		if o.redact {
+			header = "[REDACTED]"
+		}
+		o.print(fmt.Sprintf("X-PAYMENT: %s %s (undecodable: %v)", req.Method, req.URL, err), header)
+		return
+	}
+	if o.redact {
+		decoded = redactPayment(decoded)
+	}
+	o.print(fmt.Sprintf("X-PAYMENT: %s %s", req.Method, req.URL), indentJSON(decoded))
+}
+
+// ObserveSettlement implements TransportObserver.
+func (o *debugObserver) ObserveSettlement(req *http.Request, status int, header string) {
+	title := fmt.Sprintf("X-PAYMENT-RESPONSE: %s %s (status %d)", req.Method, req.URL, status)
+	if header == "" {
+		o.print(title, "(none)")
+		return
+	}
+	decoded, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		o.print(title+fmt.Sprintf(" (undecodable: %v)", err), header)
+		return
+	}
+	o.print(title, indentJSON(decoded))
+}
+
+// print writes one titled block.
+func (o *debugObserver) print(title, content string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintf(o.w, "=== x402 %s ===\n%s\n", title, content)
+}
+
+// indentJSON returns data indented if it is JSON, and as is otherwise.
+func indentJSON(data []byte) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return out.String()
+}
+
+// decodePaymentHeader returns the JSON of an X-PAYMENT header in either encoding.
+func decodePaymentHeader(header string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(header)
+	if err != nil || json.Valid(decoded) {
+		return decoded, err
+	}
+	// Compact headers are CBOR, shown as the JSON they stand for
+	payment, err := encoding.DecodePaymentCBOR(header)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(payment)
+}
+
+// redactPayment hides the signature fields of an encoded payment payload.
+func redactPayment(data []byte) []byte {
+	var payment map[string]interface{}
+	if err := json.Unmarshal(data, &payment); err != nil {
+		return data
+	}
+	payload, ok := payment["payload"].(map[string]interface{})
+	if !ok {
+		return data
+	}
+	for _, field := range redactedFields {
+		if _, ok := payload[field]; ok {
+			payload[field] = "[REDACTED]"
+		}
+	}
+	redacted, err := json.Marshal(payment)
+	if err != nil {
+		return data
+	}
+	return redacted
+}
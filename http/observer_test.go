@@ -0,0 +1,92 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+func TestRoundTrip_DebugObserver(t *testing.T) {
+	settlement, err := encoding.EncodeSettlement(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "100000",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		w.Header().Set("X-PAYMENT-RESPONSE", settlement)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var out strings.Builder
+	transport := &X402Transport{
+		Base:     http.DefaultTransport,
+		Signers:  []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+		Selector: x402.NewDefaultPaymentSelector(),
+		Observer: DebugObserver(&out),
+	}
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+	resp.Body.Close()
+
+	for _, want := range []string{
+		"=== x402 402 Payment Required: GET",
+		`"maxAmountRequired": "100000"`,
+		`"test": "payload"`,
+		`"transaction": "0xtx"`,
+		"(status 200)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRedactPayment(t *testing.T) {
+	payment := `{"x402Version":1,"scheme":"exact","network":"base","payload":{"signature":"0xsig","authorization":{"from":"0xpayer"}}}`
+	got := string(redactPayment([]byte(payment)))
+	if strings.Contains(got, "0xsig") || !strings.Contains(got, `"signature":"[REDACTED]"`) || !strings.Contains(got, "0xpayer") {
+		t.Errorf("redactPayment() = %s", got)
+	}
+}
+
+func TestDebugObserver_RedactsCBORPayments(t *testing.T) {
+	header, err := encoding.EncodePaymentCBOR(x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base",
+		Payload: x402.EVMPayload{
+			Signature:     "0x5151",
+			Authorization: x402.EVMAuthorization{From: "0x857b06519E91e3A54538791bDbb0E22373e36b66", Value: "100000"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	req, _ := http.NewRequest("GET", "https://api.example.com/premium", nil)
+	DebugObserver(&out, RedactSignatures()).ObservePayment(req, header)
+
+	got := out.String()
+	if strings.Contains(got, "5151") || !strings.Contains(got, `"signature": "[REDACTED]"`) || !strings.Contains(got, `"value": "100000"`) {
+		t.Errorf("output does not show the redacted CBOR payment:\n%s", got)
+	}
+}
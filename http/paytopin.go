@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// PayToKey identifies the payments a payTo address is pinned for: those an origin
+// (scheme://host[:port]) requests in one asset on one network.
+type PayToKey struct {
+	Origin  string
+	Network string
+	Asset   string
+}
+
+// PayToStore remembers the payTo address each origin was first paid at, for
+// PayToPinning. Implementations must be safe for concurrent use.
+type PayToStore interface {
+	// Load returns the payTo addresses pinned for origin (scheme://host[:port]), by key.
+	Load(ctx context.Context, origin string) (map[PayToKey]string, error)
+
+	// Store pins payTo for key, replacing any previous pin.
+	Store(ctx context.Context, key PayToKey, payTo string) error
+}
+
+// PayToChange describes a 402 whose payTo address differs from the pinned one.
+type PayToChange struct {
+	PayToKey
+
+	// Pinned is the address the origin was paid at before. For a network or asset the
+	// origin was not paid in before, it is one of the addresses it was paid at in others.
+	Pinned string
+
+	// Offered is the address the 402 asks to be paid at.
+	Offered string
+}
+
+// PayToPinning makes a client trust the payTo address an origin is first paid at (trust
+// on first use) and notice when a later 402 from the origin asks to be paid elsewhere,
+// as it would if an on-path attacker swapped the address. It needs no cooperation from
+// the server; with servers that sign their requirements, prefer WithRequirementsKey.
+//
+// A changed address is logged and reported to OnChange. Unless Block is set, the
+// payment proceeds and the new address is pinned once paid.
+type PayToPinning struct {
+	// Store holds the pins (default: an in-memory store for the transport's lifetime).
+	// Use a persistent store to keep pins across restarts.
+	Store PayToStore
+
+	// Block refuses to pay requirements whose payTo differs from the pinned address.
+	// Once an origin is paid, requirements on a network or asset it was not paid in yet
+	// must also be paid at one of its pinned addresses, so a compromised origin cannot
+	// redirect payments by offering another network. A 402 offering no other option
+	// fails with x402.ErrPayToChanged. Remove the origin's pins from the Store to accept
+	// a legitimate change of address or a new chain with its own addresses.
+	Block bool
+
+	// OnChange is optionally called for each changed address.
+	OnChange func(PayToChange)
+
+	// defaultStore backs a nil Store.
+	defaultStore     *MemoryPayToStore
+	defaultStoreOnce sync.Once
+}
+
+// store returns the configured store, or the default in-memory one.
+func (p *PayToPinning) store() PayToStore {
+	if p.Store != nil {
+		return p.Store
+	}
+	p.defaultStoreOnce.Do(func() { p.defaultStore = NewMemoryPayToStore() })
+	return p.defaultStore
+}
+
+// check compares the payTo addresses of requirements offered by req's origin against
+// their pins. It returns the requirements that may be paid: all of them, or with Block
+// those whose address is unchanged, or for a new network or asset, already pinned.
+func (p *PayToPinning) check(req *http.Request, requirements []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
+	pins, err := p.store().Load(req.Context(), originOf(req.URL))
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := requirements[:0:0]
+	for _, requirement := range requirements {
+		key := payToKey(req, requirement)
+		pinned, ok := pins[key]
+		if !ok {
+			if !p.Block {
+				allowed = append(allowed, requirement)
+				continue
+			}
+			pinned = pinnedElsewhere(pins, requirement.PayTo)
+		}
+		if pinned == "" || sameAddress(pinned, requirement.PayTo) {
+			allowed = append(allowed, requirement)
+			continue
+		}
+
+		change := PayToChange{PayToKey: key, Pinned: pinned, Offered: requirement.PayTo}
+		slog.Default().Warn("payment recipient changed",
+			"origin", key.Origin, "network", key.Network, "asset", key.Asset,
+			"pinned", pinned, "offered", requirement.PayTo, "blocked", p.Block)
+		if p.OnChange != nil {
+			p.OnChange(change)
+		}
+		if !p.Block {
+			allowed = append(allowed, requirement)
+		}
+	}
+	return allowed, nil
+}
+
+// pinnedElsewhere returns payTo if it is one of the addresses in pins, and otherwise
+// the first of them in key order, or "" if there are none.
+func pinnedElsewhere(pins map[PayToKey]string, payTo string) string {
+	var first string
+	var firstKey PayToKey
+	for key, pinned := range pins {
+		if sameAddress(pinned, payTo) {
+			return payTo
+		}
+		if first == "" || key.Network < firstKey.Network ||
+			key.Network == firstKey.Network && key.Asset < firstKey.Asset {
+			first, firstKey = pinned, key
+		}
+	}
+	return first
+}
+
+// pin records the payTo address of a requirement req's origin was paid at.
+func (p *PayToPinning) pin(req *http.Request, requirement x402.PaymentRequirement) {
+	if err := p.store().Store(context.WithoutCancel(req.Context()), payToKey(req, requirement), requirement.PayTo); err != nil {
+		slog.Default().Warn("failed to pin payment recipient", "error", err)
+	}
+}
+
+// payToKey returns the pin key of a requirement offered by req's origin.
+func payToKey(req *http.Request, requirement x402.PaymentRequirement) PayToKey {
+	return PayToKey{
+		Origin:  originOf(req.URL),
+		Network: requirement.Network,
+		Asset:   normalizeAddress(requirement.Asset),
+	}
+}
+
+// sameAddress reports whether two payTo addresses are the same account.
+func sameAddress(a, b string) bool {
+	return normalizeAddress(a) == normalizeAddress(b)
+}
+
+// normalizeAddress lowercases EVM addresses, whose case only carries a checksum.
+// Other addresses, such as base58 Solana ones, are case-sensitive.
+func normalizeAddress(address string) string {
+	if len(address) == 42 && strings.HasPrefix(address, "0x") {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// MemoryPayToStore is an in-memory PayToStore.
+type MemoryPayToStore struct {
+	mu   sync.Mutex
+	pins map[PayToKey]string
+}
+
+// NewMemoryPayToStore creates an empty in-memory PayToStore.
+func NewMemoryPayToStore() *MemoryPayToStore {
+	return &MemoryPayToStore{pins: make(map[PayToKey]string)}
+}
+
+// Load implements PayToStore.
+func (s *MemoryPayToStore) Load(_ context.Context, origin string) (map[PayToKey]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pins := make(map[PayToKey]string)
+	for key, payTo := range s.pins {
+		if key.Origin == origin {
+			pins[key] = payTo
+		}
+	}
+	return pins, nil
+}
+
+// Store implements PayToStore.
+func (s *MemoryPayToStore) Store(_ context.Context, key PayToKey, payTo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pins[key] = payTo
+	return nil
+}
+
+// Forget removes the pins of origin (scheme://host[:port]), so the next address it is
+// paid at is trusted again.
+func (s *MemoryPayToStore) Forget(origin string) {
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.pins {
+		if key.Origin == origin {
+			delete(s.pins, key)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestClient_WithPayToPinning(t *testing.T) {
+	const (
+		original = "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+		swapped  = "0x1111111111111111111111111111111111111111"
+	)
+
+	newServer := func(payTo *atomic.Value, paid *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-PAYMENT") == "" {
+				w.WriteHeader(http.StatusPaymentRequired)
+				_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+					Scheme:            "exact",
+					Network:           "base",
+					MaxAmountRequired: "1000",
+					Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+					PayTo:             payTo.Load().(string),
+					MaxTimeoutSeconds: 60,
+				}))
+				return
+			}
+			paid.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	tests := []struct {
+		name     string
+		block    bool
+		wantErr  error
+		wantPaid int32
+	}{
+		{"warn", false, nil, 1},
+		{"block", true, x402.ErrPayToChanged, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payTo atomic.Value
+			var paid atomic.Int32
+			payTo.Store(original)
+			server := newServer(&payTo, &paid)
+			defer server.Close()
+
+			var changes []PayToChange
+			store := NewMemoryPayToStore()
+			client, err := NewClient(
+				WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}),
+				WithPayToPinning(&PayToPinning{
+					Store:    store,
+					Block:    tt.block,
+					OnChange: func(change PayToChange) { changes = append(changes, change) },
+				}),
+			)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			// The first payment pins the address; a differently cased one is the same
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("first request failed: %v", err)
+			}
+			resp.Body.Close()
+			payTo.Store("0x209693bc6afc0c5328ba36faf03c514ef312287c")
+			resp, err = client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("second request failed: %v", err)
+			}
+			resp.Body.Close()
+			if len(changes) != 0 {
+				t.Fatalf("changes = %v, want none", changes)
+			}
+
+			// A different address is reported, and refused when blocking
+			paid.Store(0)
+			payTo.Store(swapped)
+			resp, err = client.Get(server.URL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Errorf("request failed: %v", err)
+			} else {
+				resp.Body.Close()
+			}
+			if len(changes) != 1 || !sameAddress(changes[0].Pinned, original) || changes[0].Offered != swapped {
+				t.Errorf("changes = %+v, want one change from %s to %s", changes, original, swapped)
+			}
+			if paid.Load() != tt.wantPaid {
+				t.Errorf("paid = %d after the change, want %d", paid.Load(), tt.wantPaid)
+			}
+
+			// Forgetting the origin trusts the next address again
+			store.Forget(server.URL)
+			resp, err = client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("request after Forget failed: %v", err)
+			}
+			resp.Body.Close()
+			if paid.Load() != tt.wantPaid+1 {
+				t.Errorf("paid = %d after Forget, want %d", paid.Load(), tt.wantPaid+1)
+			}
+		})
+	}
+}
+
+func TestClient_WithPayToPinningOtherNetwork(t *testing.T) {
+	const (
+		original = "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+		swapped  = "0x1111111111111111111111111111111111111111"
+	)
+
+	tests := []struct {
+		name       string
+		block      bool
+		payTo      string
+		wantErr    error
+		wantPaid   int32
+		wantChange bool
+	}{
+		{"warn", false, swapped, nil, 1, false},
+		{"block pinned address", true, original, nil, 1, false},
+		{"block other address", true, swapped, x402.ErrPayToChanged, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var network, payTo atomic.Value
+			var paid atomic.Int32
+			network.Store("base")
+			payTo.Store(original)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-PAYMENT") == "" {
+					w.WriteHeader(http.StatusPaymentRequired)
+					_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+						Scheme:            "exact",
+						Network:           network.Load().(string),
+						MaxAmountRequired: "1000",
+						Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+						PayTo:             payTo.Load().(string),
+						MaxTimeoutSeconds: 60,
+					}))
+					return
+				}
+				paid.Add(1)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var changes []PayToChange
+			client, err := NewClient(
+				WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}),
+				WithSigner(&mockSigner{network: "base-sepolia", scheme: "exact", canSignValue: true}),
+				WithPayToPinning(&PayToPinning{
+					Block:    tt.block,
+					OnChange: func(change PayToChange) { changes = append(changes, change) },
+				}),
+			)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			// Pin the address on base, then offer another network
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("first request failed: %v", err)
+			}
+			resp.Body.Close()
+			paid.Store(0)
+			network.Store("base-sepolia")
+			payTo.Store(tt.payTo)
+
+			resp, err = client.Get(server.URL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Errorf("request failed: %v", err)
+			} else {
+				resp.Body.Close()
+			}
+			if paid.Load() != tt.wantPaid {
+				t.Errorf("paid = %d on the other network, want %d", paid.Load(), tt.wantPaid)
+			}
+			if tt.wantChange {
+				if len(changes) != 1 || changes[0].Network != "base-sepolia" ||
+					!sameAddress(changes[0].Pinned, original) || changes[0].Offered != swapped {
+					t.Errorf("changes = %+v, want one change from %s to %s on base-sepolia", changes, original, swapped)
+				}
+			} else if len(changes) != 0 {
+				t.Errorf("changes = %+v, want none", changes)
+			}
+		})
+	}
+}
@@ -4,19 +4,16 @@
 package pocketbase
 
 import (
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
 	"log/slog"
 	"net/http"
 
-	"github.com/mark3labs/x402-go"
 	httpx402 "github.com/mark3labs/x402-go/http"
 	"github.com/pocketbase/pocketbase/core"
 )
 
 // NewPocketBaseX402Middleware creates a new x402 payment middleware for PocketBase.
 // It returns a PocketBase-compatible middleware function that wraps handlers with payment gating.
+// It panics if config.Validate returns an error.
 //
 // The middleware:
 //   - Checks for X-PAYMENT header in requests
@@ -32,6 +29,9 @@ import (
 //
 //	verifyResp := e.Get("x402_payment").(*httpx402.VerifyResponse)
 //
+// Pass WithPaymentRecorder to also persist each accepted payment in the
+// x402_payments collection (see PaymentRecorder).
+//
 // Example usage:
 //
 //	config := &httpx402.Config{
@@ -41,7 +41,7 @@ import (
 //	        Network:           "base-sepolia",
 //	        MaxAmountRequired: "10000",
 //	        Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
-//	        PayTo:             "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0",
+//	        PayTo:             "0x742D35CC6634c0532925A3b844BC9E7595F0BEb0",
 //	        MaxTimeoutSeconds: 300,
 //	    }},
 //	}
@@ -51,216 +51,109 @@ import (
 //	    se.Router.GET("/api/premium/data", handler).BindFunc(middleware)
 //	    return se.Next()
 //	})
-func NewPocketBaseX402Middleware(config *httpx402.Config) func(*core.RequestEvent) error {
-	// Create facilitator client
-	facilitator := &httpx402.FacilitatorClient{
-		BaseURL:               config.FacilitatorURL,
-		Client:                &http.Client{},
-		Timeouts:              x402.DefaultTimeouts,
-		Authorization:         config.FacilitatorAuthorization,
-		AuthorizationProvider: config.FacilitatorAuthorizationProvider,
-	}
-
-	// Create fallback facilitator client if configured
-	var fallbackFacilitator *httpx402.FacilitatorClient
-	if config.FallbackFacilitatorURL != "" {
-		fallbackFacilitator = &httpx402.FacilitatorClient{
-			BaseURL:               config.FallbackFacilitatorURL,
-			Client:                &http.Client{},
-			Timeouts:              x402.DefaultTimeouts,
-			Authorization:         config.FallbackFacilitatorAuthorization,
-			AuthorizationProvider: config.FallbackFacilitatorAuthorizationProvider,
-		}
-	}
+func NewPocketBaseX402Middleware(config *httpx402.Config, opts ...Option) func(*core.RequestEvent) error {
+	engine := httpx402.MustNewEngine(config)
 
-	// Enrich payment requirements with facilitator-specific data (like feePayer)
-	enrichedRequirements, err := facilitator.EnrichRequirements(config.PaymentRequirements)
-	if err != nil {
-		// Log warning but continue with original requirements
-		slog.Default().Warn("failed to enrich payment requirements from facilitator", "error", err)
-		enrichedRequirements = config.PaymentRequirements
-	} else {
-		slog.Default().Info("payment requirements enriched from facilitator", "count", len(enrichedRequirements))
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
 
 	// Return PocketBase middleware function
 	return func(e *core.RequestEvent) error {
-		logger := slog.Default()
-
-		// Bypass payment verification for OPTIONS requests (CORS preflight)
-		if e.Request.Method == "OPTIONS" {
-			logger.Debug("bypassing OPTIONS request")
-			return e.Next()
-		}
-
-		// Build absolute URL for the resource
-		scheme := "http"
-		if e.Request.TLS != nil {
-			scheme = "https"
-		}
-		resourceURL := scheme + "://" + e.Request.Host + e.Request.RequestURI
-
-		// Populate resource field in requirements with the actual request URL
-		requirementsWithResource := make([]x402.PaymentRequirement, len(enrichedRequirements))
-		for i, req := range enrichedRequirements {
-			requirementsWithResource[i] = req
-			requirementsWithResource[i].Resource = resourceURL
-			if requirementsWithResource[i].Description == "" {
-				requirementsWithResource[i].Description = "Payment required for " + e.Request.URL.Path
-			}
-		}
-
-		// Check for X-PAYMENT header
-		paymentHeader := e.Request.Header.Get("X-PAYMENT")
-		if paymentHeader == "" {
-			// No payment provided - return 402 with requirements
-			logger.Info("no payment header provided", "path", e.Request.URL.Path)
-			return sendPaymentRequiredPocketBase(e, requirementsWithResource)
-		}
-
-		// Parse payment header
-		payment, err := parsePaymentHeaderFromRequest(e.Request)
-		if err != nil {
-			logger.Warn("invalid payment header", "error", err)
-			return e.JSON(http.StatusBadRequest, map[string]any{
-				"x402Version": 1,
-				"error":       "Invalid payment header",
-			})
-		}
+		req := httpx402.NewEngineRequest(e.Request)
+		req.ClientIP = e.RealIP()
 
-		// Find matching requirement
-		requirement, err := findMatchingRequirementPocketBase(payment, requirementsWithResource)
-		if err != nil {
-			logger.Warn("no matching requirement", "error", err)
-			return sendPaymentRequiredPocketBase(e, requirementsWithResource)
+		decision := engine.Authorize(e.Request.Context(), req)
+		if !decision.Proceed {
+			return sendDecisionPocketBase(e, decision)
 		}
 
-		// Verify payment with facilitator
-		logger.Info("verifying payment", "scheme", payment.Scheme, "network", payment.Network)
-		verifyResp, err := facilitator.Verify(e.Request.Context(), payment, requirement)
-		if err != nil && fallbackFacilitator != nil {
-			logger.Warn("primary facilitator failed, trying fallback", "error", err)
-			verifyResp, err = fallbackFacilitator.Verify(e.Request.Context(), payment, requirement)
+		if decision.Session != nil {
+			e.Request = e.Request.WithContext(httpx402.WithSession(e.Request.Context(), decision.Session))
+			e.Set("x402_session", decision.Session)
 		}
-		if err != nil {
-			logger.Error("facilitator verification failed", "error", err)
-			return e.JSON(http.StatusServiceUnavailable, map[string]any{
-				"x402Version": 1,
-				"error":       "Payment verification failed",
-			})
+		if decision.Reputation != nil {
+			e.Request = e.Request.WithContext(httpx402.WithReputation(e.Request.Context(), decision.Reputation))
+			e.Set("x402_reputation", decision.Reputation)
 		}
-
-		if !verifyResp.IsValid {
-			logger.Warn("payment verification failed", "reason", verifyResp.InvalidReason)
-			return sendPaymentRequiredPocketBase(e, requirementsWithResource)
+		if decision.Payment == nil {
+			return e.Next()
 		}
 
-		// Payment verified successfully
-		logger.Info("payment verified", "payer", verifyResp.Payer)
-
 		// Store payment info in PocketBase request store for handler access
-		e.Set("x402_payment", verifyResp)
+		e.Set("x402_payment", decision.Payment)
 
-		// Settle payment if not verify-only mode
-		if !config.VerifyOnly {
-			logger.Info("settling payment", "payer", verifyResp.Payer)
-			settlementResp, err := facilitator.Settle(e.Request.Context(), payment, requirement)
-			if err != nil && fallbackFacilitator != nil {
-				logger.Warn("primary facilitator settlement failed, trying fallback", "error", err)
-				settlementResp, err = fallbackFacilitator.Settle(e.Request.Context(), payment, requirement)
+		// Settle payment unless in verify-only mode or the payer was granted free access
+		decision = engine.Settle(e.Request.Context(), decision)
+		if !decision.Proceed {
+			return sendDecisionPocketBase(e, decision)
+		}
+		decision.CopyHeader(e.Response.Header())
+
+		// Record the payment for the admin UI; a failure does not affect the request
+		if o.recorder != nil {
+			status := PaymentStatusVerified
+			switch {
+			case decision.Free:
+				status = PaymentStatusFree
+			case decision.Settlement != nil:
+				status = PaymentStatusSettled
 			}
-			if err != nil {
-				logger.Error("settlement failed", "error", err)
-				return e.JSON(http.StatusServiceUnavailable, map[string]any{
-					"x402Version": 1,
-					"error":       "Payment settlement failed",
-				})
+			if err := o.recorder.Record(e, decision.Payment.PaymentPayload, decision.Requirement, decision.Payment, decision.Settlement, status); err != nil {
+				slog.Default().Warn("failed to record payment", "error", err)
 			}
+		}
 
-			if !settlementResp.Success {
-				logger.Warn("settlement unsuccessful", "reason", settlementResp.ErrorReason)
-				return sendPaymentRequiredPocketBase(e, requirementsWithResource)
-			}
-
-			logger.Info("payment settled", "transaction", settlementResp.Transaction)
-
-			// Add X-PAYMENT-RESPONSE header with settlement info
-			if err := addPaymentResponseHeaderPocketBase(e, settlementResp); err != nil {
-				logger.Warn("failed to add payment response header", "error", err)
-				// Continue anyway - payment was successful
-			}
+		// Keep the response for replay of the request's Idempotency-Key
+		capture := engine.Capture(decision)
+		if capture == nil {
+			// Payment successful - call next handler
+			return e.Next()
 		}
+		writer := &capturingWriter{ResponseWriter: e.Response, capture: capture, status: http.StatusOK}
+		e.Response = writer
+		err := e.Next()
 
-		// Payment successful - call next handler
-		return e.Next()
+		// The payment settled before the handler ran, so even a failed response is kept
+		engine.Complete(e.Request.Context(), decision, capture.Response(writer.status, writer.Header()))
+		return err
 	}
 }
 
-// parsePaymentHeaderFromRequest parses the X-PAYMENT header from an http.Request.
-// It decodes the base64-encoded JSON, unmarshals it, and validates the protocol version.
-func parsePaymentHeaderFromRequest(r *http.Request) (x402.PaymentPayload, error) {
-	var payment x402.PaymentPayload
-
-	headerValue := r.Header.Get("X-PAYMENT")
-	if headerValue == "" {
-		return payment, x402.ErrMalformedHeader
+// sendDecisionPocketBase sends the response for a decision that did not proceed.
+// Returns the error from e.JSON() to stop the handler chain.
+func sendDecisionPocketBase(e *core.RequestEvent, decision *httpx402.Decision) error {
+	if decision.Replay != nil {
+		decision.Replay.Write(e.Response)
+		return nil
 	}
-
-	// Decode base64
-	decoded, err := base64.StdEncoding.DecodeString(headerValue)
-	if err != nil {
-		return payment, fmt.Errorf("%w: invalid base64 encoding", x402.ErrMalformedHeader)
+	if decision.Status == http.StatusNotModified {
+		decision.CopyHeader(e.Response.Header())
+		return e.NoContent(decision.Status)
 	}
-
-	// Parse JSON
-	if err := json.Unmarshal(decoded, &payment); err != nil {
-		return payment, fmt.Errorf("%w: invalid JSON", x402.ErrMalformedHeader)
-	}
-
-	// Validate version
-	if payment.X402Version != 1 {
-		return payment, x402.ErrUnsupportedVersion
-	}
-
-	return payment, nil
+	decision.CopyHeader(e.Response.Header())
+	return e.JSON(decision.Status, decision.Body())
 }
 
-// sendPaymentRequiredPocketBase sends a 402 Payment Required response for PocketBase.
-// Returns the error from e.JSON() to stop the handler chain.
-func sendPaymentRequiredPocketBase(e *core.RequestEvent, requirements []x402.PaymentRequirement) error {
-	response := x402.PaymentRequirementsResponse{
-		X402Version: 1,
-		Error:       "Payment required for this resource",
-		Accepts:     requirements,
-	}
-
-	return e.JSON(http.StatusPaymentRequired, response)
+// capturingWriter records the response status and body for idempotent replay.
+type capturingWriter struct {
+	http.ResponseWriter
+	capture *httpx402.ResponseCapture
+	status  int
 }
 
-// findMatchingRequirementPocketBase finds a payment requirement matching the payment's scheme and network.
-// It returns an error if no matching requirement is found.
-func findMatchingRequirementPocketBase(payment x402.PaymentPayload, requirements []x402.PaymentRequirement) (x402.PaymentRequirement, error) {
-	for _, req := range requirements {
-		if req.Scheme == payment.Scheme && req.Network == payment.Network {
-			return req, nil
-		}
-	}
-	return x402.PaymentRequirement{}, x402.ErrUnsupportedScheme
+func (w *capturingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
 }
 
-// addPaymentResponseHeaderPocketBase adds the X-PAYMENT-RESPONSE header with settlement information.
-// It marshals the settlement response to JSON, encodes it as base64, and sets the header.
-func addPaymentResponseHeaderPocketBase(e *core.RequestEvent, settlement *x402.SettlementResponse) error {
-	// Marshal settlement response to JSON
-	data, err := json.Marshal(settlement)
-	if err != nil {
-		return fmt.Errorf("failed to marshal settlement response: %w", err)
-	}
-
-	// Encode as base64
-	encoded := base64.StdEncoding.EncodeToString(data)
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.capture.Write(b)
+	return w.ResponseWriter.Write(b)
+}
 
-	// Set header using PocketBase's Response.Header().Set() method
-	e.Response.Header().Set("X-PAYMENT-RESPONSE", encoded)
-	return nil
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *capturingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
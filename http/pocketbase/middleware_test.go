@@ -3,10 +3,12 @@ package pocketbase
 import (
 	"encoding/base64"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
 	httpx402 "github.com/mark3labs/x402-go/http"
 )
 
@@ -15,14 +17,13 @@ import (
 // because PocketBase's core.RequestEvent has unexported fields and cannot be easily mocked.
 // Instead, we test:
 // - Middleware construction and configuration
-// - Individual helper functions (parsing, matching, encoding)
-// - Error handling (400, 402 response scenarios)
+// - Error handling (400, 402 response scenarios), through the decisions of the
+//   http.Engine the middleware delegates to
 // - Data structure validation (PaymentRequirementsResponse, SettlementResponse)
 //
 // The middleware logic is validated through:
-// - Unit tests of each helper function
+// - The decision tests in this file and the http.Engine tests
 // - Integration tests in examples/pocketbase/
-// - The Gin middleware tests (which use identical helper logic)
 
 // TestPocketBaseMiddleware_Creation tests that middleware can be created
 func TestPocketBaseMiddleware_Creation(t *testing.T) {
@@ -108,173 +109,6 @@ func TestPocketBaseMiddleware_VerifyOnlyMode(t *testing.T) {
 	}
 }
 
-// TestHelperFunctions tests the four duplicated helper functions
-func TestHelperFunctions(t *testing.T) {
-	t.Run("parsePaymentHeaderFromRequest", func(t *testing.T) {
-		// Test with valid header
-		req := httptest.NewRequest("GET", "/test", nil)
-		req.Header.Set("X-PAYMENT", "eyJ4NDAyVmVyc2lvbiI6MSwic2NoZW1lIjoiZXhhY3QiLCJuZXR3b3JrIjoiYmFzZS1zZXBvbGlhIiwicGF5bG9hZCI6e319")
-
-		payment, err := parsePaymentHeaderFromRequest(req)
-		if err != nil {
-			t.Errorf("Expected no error, got %v", err)
-		}
-
-		if payment.X402Version != 1 {
-			t.Errorf("Expected X402Version 1, got %d", payment.X402Version)
-		}
-
-		// Test with missing header
-		req2 := httptest.NewRequest("GET", "/test", nil)
-		_, err = parsePaymentHeaderFromRequest(req2)
-		if err == nil {
-			t.Error("Expected error for missing header")
-		}
-
-		// Test with invalid base64
-		req3 := httptest.NewRequest("GET", "/test", nil)
-		req3.Header.Set("X-PAYMENT", "not-valid-base64!!!")
-		_, err = parsePaymentHeaderFromRequest(req3)
-		if err == nil {
-			t.Error("Expected error for invalid base64")
-		}
-	})
-
-	t.Run("findMatchingRequirementPocketBase", func(t *testing.T) {
-		requirements := []x402.PaymentRequirement{
-			{
-				Scheme:  "exact",
-				Network: "base-sepolia",
-			},
-			{
-				Scheme:  "exact",
-				Network: "solana-devnet",
-			},
-		}
-
-		// Test matching requirement
-		payment := x402.PaymentPayload{
-			Scheme:  "exact",
-			Network: "base-sepolia",
-		}
-
-		req, err := findMatchingRequirementPocketBase(payment, requirements)
-		if err != nil {
-			t.Errorf("Expected no error, got %v", err)
-		}
-
-		if req.Network != "base-sepolia" {
-			t.Errorf("Expected network base-sepolia, got %s", req.Network)
-		}
-
-		// Test non-matching requirement
-		payment2 := x402.PaymentPayload{
-			Scheme:  "exact",
-			Network: "unknown-network",
-		}
-
-		_, err = findMatchingRequirementPocketBase(payment2, requirements)
-		if err == nil {
-			t.Error("Expected error for non-matching requirement")
-		}
-	})
-
-	t.Run("sendPaymentRequiredPocketBase", func(t *testing.T) {
-		// We test the logic by verifying the PaymentRequirementsResponse structure
-		// rather than testing the full PocketBase event flow which requires unexported fields
-
-		requirements := []x402.PaymentRequirement{
-			{
-				Scheme:            "exact",
-				Network:           "base-sepolia",
-				MaxAmountRequired: "10000",
-				Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
-				PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
-				Resource:          "https://api.example.com/test",
-				Description:       "Test payment",
-				MaxTimeoutSeconds: 300,
-			},
-		}
-
-		// Verify the response structure that would be sent
-		response := x402.PaymentRequirementsResponse{
-			X402Version: 1,
-			Error:       "Payment required for this resource",
-			Accepts:     requirements,
-		}
-
-		// Verify response marshals to valid JSON
-		data, err := json.Marshal(response)
-		if err != nil {
-			t.Errorf("Failed to marshal PaymentRequirementsResponse: %v", err)
-		}
-
-		// Verify we can unmarshal it back
-		var decoded x402.PaymentRequirementsResponse
-		if err := json.Unmarshal(data, &decoded); err != nil {
-			t.Errorf("Failed to unmarshal response: %v", err)
-		}
-
-		// Verify response fields
-		if decoded.X402Version != 1 {
-			t.Errorf("Expected X402Version 1, got %d", decoded.X402Version)
-		}
-
-		if decoded.Error == "" {
-			t.Error("Expected error message in response")
-		}
-
-		if len(decoded.Accepts) != 1 {
-			t.Errorf("Expected 1 payment requirement, got %d", len(decoded.Accepts))
-		}
-
-		if decoded.Accepts[0].Network != "base-sepolia" {
-			t.Errorf("Expected network base-sepolia, got %s", decoded.Accepts[0].Network)
-		}
-	})
-
-	t.Run("addPaymentResponseHeaderPocketBase", func(t *testing.T) {
-		// Test the header encoding logic directly
-		settlement := &x402.SettlementResponse{
-			Success:     true,
-			Transaction: "0xabcdef123456789",
-		}
-
-		// Marshal settlement response to JSON (same logic as the function)
-		data, err := json.Marshal(settlement)
-		if err != nil {
-			t.Errorf("Failed to marshal settlement response: %v", err)
-		}
-
-		// Encode as base64 (same logic as the function)
-		encoded := base64.StdEncoding.EncodeToString(data)
-
-		if encoded == "" {
-			t.Error("Expected non-empty base64 encoded string")
-		}
-
-		// Verify we can decode it back
-		decoded, err := base64.StdEncoding.DecodeString(encoded)
-		if err != nil {
-			t.Errorf("Failed to decode base64: %v", err)
-		}
-
-		var decodedSettlement x402.SettlementResponse
-		if err := json.Unmarshal(decoded, &decodedSettlement); err != nil {
-			t.Errorf("Failed to unmarshal settlement JSON: %v", err)
-		}
-
-		// Verify the decoded values match the original
-		if decodedSettlement.Success != settlement.Success {
-			t.Errorf("Expected Success %v, got %v", settlement.Success, decodedSettlement.Success)
-		}
-
-		if decodedSettlement.Transaction != settlement.Transaction {
-			t.Errorf("Expected Transaction %s, got %s", settlement.Transaction, decodedSettlement.Transaction)
-		}
-	})
-}
-
 // TestPocketBaseMiddleware_MultiplePaymentRequirements tests multiple payment options
 func TestPocketBaseMiddleware_MultiplePaymentRequirements(t *testing.T) {
 	config := &httpx402.Config{
@@ -293,7 +127,7 @@ func TestPocketBaseMiddleware_MultiplePaymentRequirements(t *testing.T) {
 				Network:           "solana-devnet",
 				MaxAmountRequired: "10000",
 				Asset:             "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
-				PayTo:             "YourSolanaWallet",
+				PayTo:             "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM",
 				MaxTimeoutSeconds: 60,
 			},
 		},
@@ -307,21 +141,60 @@ func TestPocketBaseMiddleware_MultiplePaymentRequirements(t *testing.T) {
 	}
 }
 
+// newTestFacilitator returns a facilitator that accepts every payment.
+func newTestFacilitator() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true})
+		}
+	}))
+}
+
+// authorize returns the decision the middleware sends for req under config.
+func authorize(t *testing.T, config *httpx402.Config, req *http.Request) *httpx402.Decision {
+	t.Helper()
+	engine, err := httpx402.NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	return engine.Authorize(req.Context(), httpx402.NewEngineRequest(req))
+}
+
+// testConfig returns a verify-only config for requirements with a facilitator that
+// accepts every payment.
+func testConfig(t *testing.T, requirements ...x402.PaymentRequirement) *httpx402.Config {
+	server := newTestFacilitator()
+	t.Cleanup(server.Close)
+	if len(requirements) == 0 {
+		requirements = []x402.PaymentRequirement{{
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: "10000",
+			Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
+		}}
+	}
+	return &httpx402.Config{
+		FacilitatorURL:      server.URL,
+		VerifyOnly:          true,
+		PaymentRequirements: requirements,
+	}
+}
+
 // TestPocketBaseMiddleware_InvalidBase64Returns400 tests malformed payment header handling
 func TestPocketBaseMiddleware_InvalidBase64Returns400(t *testing.T) {
 	// Test that invalid base64 in X-PAYMENT header is properly rejected
 	req := httptest.NewRequest("GET", "/test", nil)
 	req.Header.Set("X-PAYMENT", "not-valid-base64!!!")
 
-	_, err := parsePaymentHeaderFromRequest(req)
-	if err == nil {
-		t.Error("Expected error for invalid base64, got nil")
-	}
-
-	// Verify error is related to malformed header
-	if !json.Valid([]byte(err.Error())) && err.Error() == "" {
-		// Error message should be meaningful
-		t.Logf("Error message: %v", err)
+	decision := authorize(t, testConfig(t), req)
+	if decision.Proceed || decision.Status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid base64, got proceed=%v status=%d", decision.Proceed, decision.Status)
 	}
 }
 
@@ -333,9 +206,9 @@ func TestPocketBaseMiddleware_InvalidJSONReturns400(t *testing.T) {
 	invalidJSON := base64.StdEncoding.EncodeToString([]byte("{invalid json"))
 	req.Header.Set("X-PAYMENT", invalidJSON)
 
-	_, err := parsePaymentHeaderFromRequest(req)
-	if err == nil {
-		t.Error("Expected error for invalid JSON, got nil")
+	decision := authorize(t, testConfig(t), req)
+	if decision.Proceed || decision.Status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid JSON, got proceed=%v status=%d", decision.Proceed, decision.Status)
 	}
 }
 
@@ -354,21 +227,25 @@ func TestPocketBaseMiddleware_UnsupportedVersionReturns400(t *testing.T) {
 	encoded := base64.StdEncoding.EncodeToString(paymentJSON)
 	req.Header.Set("X-PAYMENT", encoded)
 
-	_, err := parsePaymentHeaderFromRequest(req)
-	if err == nil {
-		t.Error("Expected error for unsupported version, got nil")
+	decision := authorize(t, testConfig(t), req)
+	if decision.Proceed || decision.Status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unsupported version, got proceed=%v status=%d", decision.Proceed, decision.Status)
 	}
 }
 
 // TestPocketBaseMiddleware_MissingHeaderReturns402 tests missing X-PAYMENT header
 func TestPocketBaseMiddleware_MissingHeaderReturns402(t *testing.T) {
-	// Test that missing X-PAYMENT header is properly detected
+	// Test that missing X-PAYMENT header is answered with the payment requirements
 	req := httptest.NewRequest("GET", "/test", nil)
 	// Don't set X-PAYMENT header
 
-	_, err := parsePaymentHeaderFromRequest(req)
-	if err == nil {
-		t.Error("Expected error for missing header, got nil")
+	decision := authorize(t, testConfig(t), req)
+	if decision.Proceed || decision.Status != http.StatusPaymentRequired {
+		t.Fatalf("Expected 402 for missing header, got proceed=%v status=%d", decision.Proceed, decision.Status)
+	}
+	response, ok := decision.Body().(x402.PaymentRequirementsResponse)
+	if !ok || len(response.Accepts) != 1 {
+		t.Errorf("Expected payment requirements in the 402 body, got %#v", decision.Body())
 	}
 }
 
@@ -376,18 +253,23 @@ func TestPocketBaseMiddleware_MissingHeaderReturns402(t *testing.T) {
 func TestPocketBaseMiddleware_SchemeNetworkMatching(t *testing.T) {
 	requirements := []x402.PaymentRequirement{
 		{
-			Scheme:  "exact",
-			Network: "base-sepolia",
-		},
-		{
-			Scheme:  "exact",
-			Network: "base",
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: "10000",
+			Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
 		},
 		{
-			Scheme:  "signature",
-			Network: "solana-devnet",
+			Scheme:            "exact",
+			Network:           "base",
+			MaxAmountRequired: "10000",
+			Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
 		},
 	}
+	config := testConfig(t, requirements...)
 
 	tests := []struct {
 		name        string
@@ -397,48 +279,46 @@ func TestPocketBaseMiddleware_SchemeNetworkMatching(t *testing.T) {
 	}{
 		{
 			name:        "exact match base-sepolia",
-			payment:     x402.PaymentPayload{Scheme: "exact", Network: "base-sepolia"},
+			payment:     x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia"},
 			shouldMatch: true,
 			expectNet:   "base-sepolia",
 		},
 		{
 			name:        "exact match base",
-			payment:     x402.PaymentPayload{Scheme: "exact", Network: "base"},
+			payment:     x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base"},
 			shouldMatch: true,
 			expectNet:   "base",
 		},
-		{
-			name:        "signature match solana-devnet",
-			payment:     x402.PaymentPayload{Scheme: "signature", Network: "solana-devnet"},
-			shouldMatch: true,
-			expectNet:   "solana-devnet",
-		},
 		{
 			name:        "no match - unknown network",
-			payment:     x402.PaymentPayload{Scheme: "exact", Network: "unknown"},
+			payment:     x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "unknown"},
 			shouldMatch: false,
 		},
 		{
 			name:        "no match - wrong scheme",
-			payment:     x402.PaymentPayload{Scheme: "signature", Network: "base-sepolia"},
+			payment:     x402.PaymentPayload{X402Version: 1, Scheme: "signature", Network: "base-sepolia"},
 			shouldMatch: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req, err := findMatchingRequirementPocketBase(tt.payment, requirements)
+			paymentJSON, _ := json.Marshal(tt.payment)
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-PAYMENT", base64.StdEncoding.EncodeToString(paymentJSON))
+
+			decision := authorize(t, config, req)
 
 			if tt.shouldMatch {
-				if err != nil {
-					t.Errorf("Expected match but got error: %v", err)
+				if !decision.Proceed {
+					t.Fatalf("Expected match but got status %d", decision.Status)
 				}
-				if req.Network != tt.expectNet {
-					t.Errorf("Expected network %s, got %s", tt.expectNet, req.Network)
+				if decision.Requirement.Network != tt.expectNet {
+					t.Errorf("Expected network %s, got %s", tt.expectNet, decision.Requirement.Network)
 				}
 			} else {
-				if err == nil {
-					t.Error("Expected no match but got success")
+				if decision.Proceed || decision.Status != http.StatusPaymentRequired {
+					t.Errorf("Expected 402 without a match, got proceed=%v status=%d", decision.Proceed, decision.Status)
 				}
 			}
 		})
@@ -516,7 +396,7 @@ func TestPocketBaseMiddleware_SettlementResponseHeaderEncoding(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Marshal and encode (simulating addPaymentResponseHeaderPocketBase)
+			// Marshal and encode as the X-PAYMENT-RESPONSE header
 			data, err := json.Marshal(&tt.settlement)
 			if err != nil {
 				t.Fatalf("Failed to marshal settlement: %v", err)
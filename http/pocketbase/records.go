@@ -0,0 +1,177 @@
+package pocketbase
+
+import (
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// PaymentsCollection is the name of the collection payments are recorded in.
+const PaymentsCollection = "x402_payments"
+
+// Payment record statuses.
+const (
+	// PaymentStatusVerified marks a payment verified but not settled (VerifyOnly mode).
+	PaymentStatusVerified = "verified"
+	// PaymentStatusSettled marks a payment settled on-chain.
+	PaymentStatusSettled = "settled"
+	// PaymentStatusFree marks a verified payment the payer was not charged for.
+	PaymentStatusFree = "free"
+)
+
+// Option configures optional behavior of NewPocketBaseX402Middleware.
+type Option func(*options)
+
+// options holds the settings applied by Options.
+type options struct {
+	recorder *PaymentRecorder
+}
+
+// WithPaymentRecorder records every verified or settled payment in the
+// x402_payments collection using recorder.
+func WithPaymentRecorder(recorder *PaymentRecorder) Option {
+	return func(o *options) {
+		o.recorder = recorder
+	}
+}
+
+// PaymentRecordEvent is passed to OnPaymentRecord handlers before a payment record is saved.
+// Handlers may modify Record (e.g. to link it to the authenticated user) and must call
+// e.Next() to continue; returning an error without calling Next skips saving the record.
+type PaymentRecordEvent struct {
+	hook.Event
+
+	App         core.App
+	Request     *core.RequestEvent
+	Record      *core.Record
+	Payment     x402.PaymentPayload
+	Requirement x402.PaymentRequirement
+	Verify      *facilitator.VerifyResponse
+	Settlement  *x402.SettlementResponse // nil unless the payment was settled
+}
+
+// PaymentRecorder persists payments accepted by the middleware as records in the
+// x402_payments collection, so payment history is browsable from the PocketBase admin UI.
+// The collection is created by the migration registered with RegisterPaymentsMigration.
+//
+// Example usage:
+//
+//	pbx402.RegisterPaymentsMigration()
+//	recorder := pbx402.NewPaymentRecorder()
+//	recorder.OnPaymentRecord().BindFunc(func(e *pbx402.PaymentRecordEvent) error {
+//	    if e.Request.Auth != nil {
+//	        e.Record.Set("user", e.Request.Auth.Id)
+//	    }
+//	    return e.Next()
+//	})
+//
+//	middleware := pbx402.NewPocketBaseX402Middleware(config, pbx402.WithPaymentRecorder(recorder))
+type PaymentRecorder struct {
+	onPaymentRecord *hook.Hook[*PaymentRecordEvent]
+}
+
+// NewPaymentRecorder creates a PaymentRecorder.
+func NewPaymentRecorder() *PaymentRecorder {
+	return &PaymentRecorder{onPaymentRecord: &hook.Hook[*PaymentRecordEvent]{}}
+}
+
+// OnPaymentRecord returns the hook triggered before each payment record is saved.
+func (r *PaymentRecorder) OnPaymentRecord() *hook.Hook[*PaymentRecordEvent] {
+	return r.onPaymentRecord
+}
+
+// Record saves a payment record for the request after triggering OnPaymentRecord.
+func (r *PaymentRecorder) Record(e *core.RequestEvent, payment x402.PaymentPayload, requirement x402.PaymentRequirement, verifyResp *facilitator.VerifyResponse, settlement *x402.SettlementResponse, status string) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(PaymentsCollection)
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	record.Load(paymentRecordFields(payment, requirement, verifyResp, settlement, status))
+
+	event := &PaymentRecordEvent{
+		App:         e.App,
+		Request:     e,
+		Record:      record,
+		Payment:     payment,
+		Requirement: requirement,
+		Verify:      verifyResp,
+		Settlement:  settlement,
+	}
+	return r.onPaymentRecord.Trigger(event, func(pe *PaymentRecordEvent) error {
+		return pe.App.Save(pe.Record)
+	})
+}
+
+// paymentRecordFields returns the x402_payments field values for a payment.
+func paymentRecordFields(payment x402.PaymentPayload, requirement x402.PaymentRequirement, verifyResp *facilitator.VerifyResponse, settlement *x402.SettlementResponse, status string) map[string]any {
+	fields := map[string]any{
+		"status":   status,
+		"scheme":   payment.Scheme,
+		"network":  payment.Network,
+		"asset":    requirement.Asset,
+		"amount":   requirement.MaxAmountRequired,
+		"pay_to":   requirement.PayTo,
+		"resource": requirement.Resource,
+	}
+	if verifyResp != nil {
+		fields["payer"] = verifyResp.Payer
+	}
+	if settlement != nil {
+		fields["transaction"] = settlement.Transaction
+		if settlement.Payer != "" {
+			fields["payer"] = settlement.Payer
+		}
+	}
+	return fields
+}
+
+// RegisterPaymentsMigration registers an app migration creating the x402_payments
+// collection. Call it before app.Start(); the collection is then created the next
+// time migrations run (automatically on serve). Only superusers can access its records.
+func RegisterPaymentsMigration() {
+	migrations.Register(createPaymentsCollection, deletePaymentsCollection, "1760572800_create_x402_payments.go")
+}
+
+// createPaymentsCollection creates the x402_payments collection if it does not exist.
+func createPaymentsCollection(app core.App) error {
+	if _, err := app.FindCollectionByNameOrId(PaymentsCollection); err == nil {
+		return nil
+	}
+
+	collection := core.NewBaseCollection(PaymentsCollection)
+	collection.Fields.Add(
+		&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{PaymentStatusVerified, PaymentStatusSettled, PaymentStatusFree},
+		},
+		&core.TextField{Name: "payer"},
+		&core.TextField{Name: "scheme", Required: true},
+		&core.TextField{Name: "network", Required: true},
+		&core.TextField{Name: "asset"},
+		&core.TextField{Name: "amount"},
+		&core.TextField{Name: "pay_to"},
+		&core.TextField{Name: "resource"},
+		&core.TextField{Name: "transaction"},
+		&core.AutodateField{Name: "created", OnCreate: true},
+		&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+	)
+	collection.AddIndex("idx_x402_payments_payer", false, "payer", "")
+	collection.AddIndex("idx_x402_payments_transaction", false, "transaction", "")
+
+	return app.Save(collection)
+}
+
+// deletePaymentsCollection removes the x402_payments collection.
+func deletePaymentsCollection(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId(PaymentsCollection)
+	if err != nil {
+		return nil
+	}
+	return app.Delete(collection)
+}
@@ -0,0 +1,88 @@
+package pocketbase
+
+import (
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+func TestPaymentRecordFields(t *testing.T) {
+	payment := x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia"}
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base-sepolia",
+		MaxAmountRequired: "10000",
+		Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		Resource:          "https://api.example.com/premium",
+	}
+	verifyResp := &facilitator.VerifyResponse{IsValid: true, Payer: "0xPayer"}
+
+	tests := []struct {
+		name            string
+		settlement      *x402.SettlementResponse
+		status          string
+		wantTransaction any
+	}{
+		{
+			name:   "verified only",
+			status: PaymentStatusVerified,
+		},
+		{
+			name:            "settled",
+			settlement:      &x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: "0xPayer"},
+			status:          PaymentStatusSettled,
+			wantTransaction: "0xtx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := paymentRecordFields(payment, requirement, verifyResp, tt.settlement, tt.status)
+
+			want := map[string]any{
+				"status":   tt.status,
+				"payer":    "0xPayer",
+				"scheme":   "exact",
+				"network":  "base-sepolia",
+				"asset":    requirement.Asset,
+				"amount":   "10000",
+				"pay_to":   requirement.PayTo,
+				"resource": requirement.Resource,
+			}
+			for key, value := range want {
+				if fields[key] != value {
+					t.Errorf("%s = %v, want %v", key, fields[key], value)
+				}
+			}
+			if fields["transaction"] != tt.wantTransaction {
+				t.Errorf("transaction = %v, want %v", fields["transaction"], tt.wantTransaction)
+			}
+		})
+	}
+}
+
+func TestPocketBaseMiddleware_WithPaymentRecorder(t *testing.T) {
+	config := &httpx402.Config{
+		FacilitatorURL: "http://mock-facilitator.test",
+		PaymentRequirements: []x402.PaymentRequirement{{
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: "10000",
+			Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
+		}},
+	}
+
+	recorder := NewPaymentRecorder()
+	if recorder.OnPaymentRecord() == nil {
+		t.Fatal("expected OnPaymentRecord hook")
+	}
+
+	if middleware := NewPocketBaseX402Middleware(config, WithPaymentRecorder(recorder)); middleware == nil {
+		t.Error("Expected middleware function to be created with payment recorder")
+	}
+}
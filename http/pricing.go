@@ -0,0 +1,35 @@
+package http
+
+import (
+	"github.com/mark3labs/x402-go"
+)
+
+// PriceResolver returns the payment requirements that apply to a specific payer.
+// It receives the payer address taken from the X-PAYMENT header and the configured
+// requirements (with Resource already set), and can return them unchanged, discounted,
+// surcharged or filtered.
+//
+// Returning an empty slice grants the payer free access: the payment is still verified
+// against the base requirement, which proves the payer controls the address, but it is
+// never settled.
+//
+// The payer is only known once a client retries with a payment, so the initial 402
+// response always advertises the base requirements. A payment signed for the base price
+// by a payer the resolver prices differently is answered with a 402 whose reason is
+// ReasonPriceChanged and which offers the payer's requirements; X402Transport signs
+// again for them. The resolver must not modify base.
+type PriceResolver func(payer string, base []x402.PaymentRequirement) []x402.PaymentRequirement
+
+// RequirementsForPayer applies the configured PriceResolver to base.
+// It returns base unchanged when no resolver is configured. free reports whether the
+// resolver granted free access, in which case base is returned for verification.
+func (c *Config) RequirementsForPayer(payer string, base []x402.PaymentRequirement) (requirements []x402.PaymentRequirement, free bool) {
+	if c.PriceResolver == nil {
+		return base, false
+	}
+	resolved := c.PriceResolver(payer, base)
+	if len(resolved) == 0 {
+		return base, true
+	}
+	return resolved, false
+}
@@ -0,0 +1,268 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+	"github.com/mark3labs/x402-go/http/internal/helpers"
+)
+
+const testPayer = "0x857b06519E91e3A54538791bDbb0E22373e36b66"
+
+// newPricingFacilitator returns a facilitator that records the amount it was asked to verify.
+func newPricingFacilitator(verifiedAmount *atomic.Value, settleCalls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			var req FacilitatorRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			verifiedAmount.Store(req.PaymentRequirements.MaxAmountRequired)
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+		case "/settle":
+			settleCalls.Add(1)
+			_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+		}
+	}))
+}
+
+func pricingPaymentHeader(t testing.TB, from string) string {
+	t.Helper()
+	header, err := encoding.EncodePayment(x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base-sepolia",
+		Payload: x402.EVMPayload{
+			Signature:     "0xsig",
+			Authorization: x402.EVMAuthorization{From: from, Value: "10000"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode payment: %v", err)
+	}
+	return header
+}
+
+func TestConfigRequirementsForPayer(t *testing.T) {
+	base := validTestConfig().PaymentRequirements
+
+	t.Run("no resolver", func(t *testing.T) {
+		config := &Config{}
+		got, free := config.RequirementsForPayer(testPayer, base)
+		if free || len(got) != 1 || got[0].MaxAmountRequired != "10000" {
+			t.Errorf("Expected base requirements unchanged, got %v (free=%v)", got, free)
+		}
+	})
+
+	t.Run("resolver receives payer", func(t *testing.T) {
+		var gotPayer string
+		config := &Config{PriceResolver: func(payer string, base []x402.PaymentRequirement) []x402.PaymentRequirement {
+			gotPayer = payer
+			return base
+		}}
+		config.RequirementsForPayer(testPayer, base)
+		if gotPayer != testPayer {
+			t.Errorf("Expected payer %s, got %s", testPayer, gotPayer)
+		}
+	})
+
+	t.Run("empty result grants free access", func(t *testing.T) {
+		config := &Config{PriceResolver: func(string, []x402.PaymentRequirement) []x402.PaymentRequirement {
+			return nil
+		}}
+		got, free := config.RequirementsForPayer(testPayer, base)
+		if !free {
+			t.Error("Expected free access")
+		}
+		if len(got) != 1 {
+			t.Errorf("Expected base requirements for verification, got %v", got)
+		}
+	})
+}
+
+// newSigningFacilitator returns a facilitator that records the amounts signed by the
+// payments it settles.
+func newSigningFacilitator(settled *[]string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+		case "/settle":
+			var req FacilitatorRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			value, _ := helpers.GetValue(req.PaymentPayload)
+			mu.Lock()
+			*settled = append(*settled, value)
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+		}
+	}))
+}
+
+// amountSigner signs EIP-3009 payments from testPayer for the amount required, recording
+// each amount signed.
+type amountSigner struct {
+	mockSigner
+	signed []string
+}
+
+func (s *amountSigner) Sign(req *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	s.signed = append(s.signed, req.MaxAmountRequired)
+	return &x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     req.Network,
+		Payload: x402.EVMPayload{
+			Signature: "0xsig",
+			Authorization: x402.EVMAuthorization{
+				From:  testPayer,
+				To:    req.PayTo,
+				Value: req.MaxAmountRequired,
+				Nonce: fmt.Sprintf("0x%064x", len(s.signed)),
+			},
+		},
+	}, nil
+}
+
+// payForPrice fetches a paid resource from a server running config through a transport
+// paying as testPayer, and returns the amounts signed and settled.
+func payForPrice(t *testing.T, config *Config) (status int, signed, settled []string) {
+	t.Helper()
+	facilitatorServer := newSigningFacilitator(&settled)
+	defer facilitatorServer.Close()
+	config.FacilitatorURL = facilitatorServer.URL
+
+	server := httptest.NewServer(NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	signer := &amountSigner{mockSigner: mockSigner{network: "base-sepolia", scheme: "exact", canSignValue: true}}
+	client := &http.Client{Transport: &X402Transport{
+		Base:     http.DefaultTransport,
+		Signers:  []x402.Signer{signer},
+		Selector: x402.NewDefaultPaymentSelector(),
+	}}
+	resp, err := client.Get(server.URL + "/test")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, signer.signed, settled
+}
+
+func TestMiddleware_PriceResolverDiscount(t *testing.T) {
+	config := validTestConfig()
+	config.PriceResolver = func(payer string, base []x402.PaymentRequirement) []x402.PaymentRequirement {
+		if payer != testPayer {
+			return base
+		}
+		discounted := make([]x402.PaymentRequirement, len(base))
+		copy(discounted, base)
+		for i := range discounted {
+			discounted[i].MaxAmountRequired = "5000"
+		}
+		return discounted
+	}
+
+	status, signed, settled := payForPrice(t, config)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	// The first payment is signed for the advertised price and answered with the discount
+	if !reflect.DeepEqual(signed, []string{"10000", "5000"}) {
+		t.Errorf("Expected payments signed for 10000 then 5000, got %v", signed)
+	}
+	if !reflect.DeepEqual(settled, []string{"5000"}) {
+		t.Errorf("Expected one settlement of the discounted 5000, got %v", settled)
+	}
+}
+
+func TestMiddleware_PriceResolverRejectsBasePrice(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.PriceResolver = func(payer string, base []x402.PaymentRequirement) []x402.PaymentRequirement {
+		discounted := make([]x402.PaymentRequirement, len(base))
+		copy(discounted, base)
+		discounted[0].MaxAmountRequired = "5000"
+		return discounted
+	}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("Expected status 402, got %d", rec.Code)
+	}
+	var body x402.PaymentRequirementsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode 402 body: %v", err)
+	}
+	if body.Reason != ReasonPriceChanged || len(body.Accepts) != 1 || body.Accepts[0].MaxAmountRequired != "5000" {
+		t.Errorf("Expected %s offering 5000, got %+v", ReasonPriceChanged, body)
+	}
+	if verifiedAmount.Load() != nil || settleCalls.Load() != 0 {
+		t.Errorf("Expected the base-price payment never verified or settled, got %v verified, %d settled", verifiedAmount.Load(), settleCalls.Load())
+	}
+}
+
+func TestMiddleware_PriceResolverFreeAccess(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.PriceResolver = func(payer string, base []x402.PaymentRequirement) []x402.PaymentRequirement {
+		if payer == testPayer {
+			return nil
+		}
+		return base
+	}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if got := verifiedAmount.Load(); got != "10000" {
+		t.Errorf("Expected payment verified against base amount, got %v", got)
+	}
+	if settleCalls.Load() != 0 {
+		t.Errorf("Expected no settlement for free access, got %d calls", settleCalls.Load())
+	}
+}
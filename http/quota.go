@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// QuotaStore counts requests per client for FreeQuota.
+// Implementations must be safe for concurrent use; share one store (e.g. backed by Redis)
+// between replicas to enforce a single quota across a deployment.
+type QuotaStore interface {
+	// Increment adds one to the counter for key and returns the new count.
+	Increment(ctx context.Context, key string) (int, error)
+}
+
+// FreeQuota grants each client a number of free requests before payment is required.
+//
+// By default clients are identified by IP address, and requests within the quota are
+// served without any payment header. With PerPayer set, clients are identified by payer
+// address instead: they must still send a signed payment, which is verified to prove the
+// address but not settled while the payer is within quota.
+type FreeQuota struct {
+	// Limit is the number of free requests each client gets.
+	Limit int
+
+	// PerPayer counts requests per verified payer address instead of per client IP.
+	PerPayer bool
+
+	// Store holds the request counters. Defaults to an in-memory store that never resets.
+	Store QuotaStore
+
+	once         sync.Once
+	defaultStore QuotaStore
+}
+
+// Allow records a request for key and reports whether it is within the free quota.
+func (q *FreeQuota) Allow(ctx context.Context, key string) (bool, error) {
+	count, err := q.store().Increment(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("quota store: %w", err)
+	}
+	return count <= q.Limit, nil
+}
+
+// store returns the configured store, or the default in-memory one.
+func (q *FreeQuota) store() QuotaStore {
+	if q.Store != nil {
+		return q.Store
+	}
+	q.once.Do(func() { q.defaultStore = NewMemoryQuotaStore(0) })
+	return q.defaultStore
+}
+
+// ClientIP returns the IP address of the client that sent r, taken from RemoteAddr.
+// Deployments behind a proxy should wrap the handler to set RemoteAddr from a trusted header.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// MemoryQuotaStore is an in-memory QuotaStore for single-instance deployments.
+type MemoryQuotaStore struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	counters  map[string]*quotaCounter
+	lastSweep time.Time
+}
+
+type quotaCounter struct {
+	count   int
+	resetAt time.Time
+}
+
+// NewMemoryQuotaStore creates an in-memory QuotaStore.
+// Counters reset once window has elapsed since a client's first request;
+// a zero window means counters never reset.
+func NewMemoryQuotaStore(window time.Duration) *MemoryQuotaStore {
+	return &MemoryQuotaStore{
+		window:   window,
+		counters: make(map[string]*quotaCounter),
+	}
+}
+
+// Increment implements QuotaStore.
+func (s *MemoryQuotaStore) Increment(_ context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.window > 0 && now.Sub(s.lastSweep) >= s.window {
+		// Drop expired counters so the map does not grow without bound
+		for k, c := range s.counters {
+			if !now.Before(c.resetAt) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	counter, ok := s.counters[key]
+	if !ok || (s.window > 0 && !now.Before(counter.resetAt)) {
+		counter = &quotaCounter{resetAt: now.Add(s.window)}
+		s.counters[key] = counter
+	}
+	counter.count++
+	return counter.count, nil
+}
+
+// Counts returns the current request count of each client.
+func (s *MemoryQuotaStore) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	counts := make(map[string]int, len(s.counters))
+	for key, counter := range s.counters {
+		if s.window > 0 && !now.Before(counter.resetAt) {
+			continue
+		}
+		counts[key] = counter.count
+	}
+	return counts
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryQuotaStore(t *testing.T) {
+	store := NewMemoryQuotaStore(0)
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		got, err := store.Increment(ctx, "client-a")
+		if err != nil {
+			t.Fatalf("Increment failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("Expected count %d, got %d", want, got)
+		}
+	}
+
+	if got, _ := store.Increment(ctx, "client-b"); got != 1 {
+		t.Errorf("Expected independent counter for client-b, got %d", got)
+	}
+}
+
+func TestMemoryQuotaStore_WindowReset(t *testing.T) {
+	store := NewMemoryQuotaStore(20 * time.Millisecond)
+	ctx := context.Background()
+
+	_, _ = store.Increment(ctx, "client")
+	if got, _ := store.Increment(ctx, "client"); got != 2 {
+		t.Fatalf("Expected count 2 within window, got %d", got)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if got, _ := store.Increment(ctx, "client"); got != 1 {
+		t.Errorf("Expected count to reset after window, got %d", got)
+	}
+}
+
+func TestMiddleware_FreeQuotaPerIP(t *testing.T) {
+	config := validTestConfig()
+	config.FreeQuota = &FreeQuota{Limit: 2}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	wantCodes := []int{http.StatusOK, http.StatusOK, http.StatusPaymentRequired}
+	for i, want := range wantCodes {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, want, rec.Code)
+		}
+	}
+
+	// A different client still has its own quota
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "198.51.100.1:40000"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for new client, got %d", rec.Code)
+	}
+}
+
+func TestMiddleware_FreeQuotaPerPayer(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.FreeQuota = &FreeQuota{Limit: 1, PerPayer: true}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Requests without payment are not covered by a per-payer quota
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/test", nil))
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("Expected status 402 without payment, got %d", rec.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, got %d", i+1, rec.Code)
+		}
+	}
+
+	if settleCalls.Load() != 1 {
+		t.Errorf("Expected only the request beyond the quota to settle, got %d settle calls", settleCalls.Load())
+	}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// RangePricing charges Range requests for part of a paid download in proportion to the
+// bytes requested, so a resumed download does not pay for the whole file again. The
+// configured requirement amounts are the price of the whole resource.
+//
+// The handler must honor the Range header (as http.ServeContent does). The net/http
+// middleware only settles a prorated payment for a 206 Partial Content response; any
+// other success is replaced with a 402, with reason ReasonRangeNotServed, offering the
+// full price. Adapters that settle before the handler runs cannot check the status, so
+// with them a handler ignoring Range serves the whole resource for the price of the
+// range.
+type RangePricing struct {
+	// Size returns the size in bytes of the resource req targets (required). It returns
+	// false for resources that are not priced by range, which are charged in full.
+	Size func(ctx context.Context, req EngineRequest) (int64, bool)
+
+	// ChunkSize bills requested bytes in whole chunks of this many bytes, e.g. 1 MiB
+	// (default 1: per-byte pricing).
+	ChunkSize int64
+}
+
+// ReasonRangeNotServed is the 402 reason for a request priced by RangePricing whose
+// handler did not answer with 206 Partial Content, e.g. because it served the whole
+// resource. The prorated payment is not settled; the 402 offers the full price, which
+// clients pay by requesting the resource without a Range header.
+const ReasonRangeNotServed = "range_not_served"
+
+// byteRange is an inclusive range of byte offsets.
+type byteRange struct {
+	start, end int64
+}
+
+// Price returns requirements with their amounts prorated to the bytes the request's
+// Range header selects out of size, rounded up to whole chunks and atomic units. Bytes
+// selected by overlapping ranges are billed once per range, as a multipart response
+// repeats them. It returns requirements unchanged for requests that are not a
+// satisfiable byte Range request, that select at least size bytes, or that carry
+// If-Range (which may turn into a full response).
+func (p *RangePricing) Price(header http.Header, size int64, requirements []x402.PaymentRequirement) []x402.PaymentRequirement {
+	priced, _ := p.price(header, size, requirements)
+	return priced
+}
+
+// price implements Price, also reporting whether the amounts were prorated.
+func (p *RangePricing) price(header http.Header, size int64, requirements []x402.PaymentRequirement) ([]x402.PaymentRequirement, bool) {
+	if size <= 0 || header.Get("If-Range") != "" {
+		return requirements, false
+	}
+	ranges, ok := parseRanges(header.Get("Range"), size)
+	if !ok {
+		return requirements, false
+	}
+
+	chunk := p.ChunkSize
+	if chunk <= 0 {
+		chunk = 1
+	}
+	var billed int64
+	for _, r := range ranges {
+		length := r.end - r.start + 1
+		billed += (length + chunk - 1) / chunk * chunk
+		if billed >= size {
+			// http.ServeContent serves the whole resource when the ranges add up to it
+			return requirements, false
+		}
+	}
+
+	priced := make([]x402.PaymentRequirement, len(requirements))
+	for i, requirement := range requirements {
+		priced[i] = requirement
+		amount, ok := new(big.Int).SetString(requirement.MaxAmountRequired, 10)
+		if !ok {
+			continue
+		}
+		// amount * billed / size, rounded up and at least one atomic unit
+		prorated, rem := new(big.Int).QuoRem(amount.Mul(amount, big.NewInt(billed)), big.NewInt(size), new(big.Int))
+		if rem.Sign() > 0 || prorated.Sign() == 0 {
+			prorated.Add(prorated, big.NewInt(1))
+		}
+		priced[i].MaxAmountRequired = prorated.String()
+	}
+	return priced, true
+}
+
+// priceRange applies the configured RangePricing to the requirements of a GET request.
+// It reports whether their amounts were prorated.
+func (c *Config) priceRange(ctx context.Context, req EngineRequest, requirements []x402.PaymentRequirement) ([]x402.PaymentRequirement, bool) {
+	if c.RangePricing == nil || c.RangePricing.Size == nil || req.Method != http.MethodGet || req.Header.Get("Range") == "" {
+		return requirements, false
+	}
+	size, ok := c.RangePricing.Size(ctx, req)
+	if !ok {
+		return requirements, false
+	}
+	return c.RangePricing.price(req.Header, size, requirements)
+}
+
+// parseRanges parses a "bytes=" Range header value into the byte ranges it selects out
+// of size, in the order requested. It reports false if the header is not a valid byte
+// range set or no range is satisfiable.
+func parseRanges(value string, size int64) ([]byteRange, bool) {
+	spec, ok := strings.CutPrefix(value, "bytes=")
+	if !ok {
+		return nil, false
+	}
+
+	var ranges []byteRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, false
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var r byteRange
+		if first == "" {
+			// Suffix range: the last n bytes
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n <= 0 {
+				return nil, false
+			}
+			r = byteRange{start: max(size-n, 0), end: size - 1}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, false
+			}
+			if start >= size {
+				// Unsatisfiable on its own
+				continue
+			}
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, false
+				}
+				end = min(end, size-1)
+			}
+			r = byteRange{start: start, end: end}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, false
+	}
+
+	return ranges, true
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestRangePricing_Price(t *testing.T) {
+	base := []x402.PaymentRequirement{{MaxAmountRequired: "1000000"}}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		chunk   int64
+		wantAmt string
+	}{
+		{name: "no range", header: http.Header{}, wantAmt: "1000000"},
+		{name: "first half", header: http.Header{"Range": {"bytes=0-499"}}, wantAmt: "500000"},
+		{name: "open-ended", header: http.Header{"Range": {"bytes=900-"}}, wantAmt: "100000"},
+		{name: "suffix", header: http.Header{"Range": {"bytes=-250"}}, wantAmt: "250000"},
+		{name: "overlapping ranges billed per range", header: http.Header{"Range": {"bytes=0-99, 50-199"}}, wantAmt: "250000"},
+		{name: "overlapping ranges adding up to the file", header: http.Header{"Range": {"bytes=0-599, 100-999"}}, wantAmt: "1000000"},
+		{name: "rounds up", header: http.Header{"Range": {"bytes=0-0"}}, chunk: 3, wantAmt: "3000"},
+		{name: "chunks", header: http.Header{"Range": {"bytes=0-100"}}, chunk: 100, wantAmt: "200000"},
+		{name: "whole file", header: http.Header{"Range": {"bytes=0-"}}, wantAmt: "1000000"},
+		{name: "if-range", header: http.Header{"Range": {"bytes=0-99"}, "If-Range": {`"v1"`}}, wantAmt: "1000000"},
+		{name: "unsatisfiable", header: http.Header{"Range": {"bytes=5000-"}}, wantAmt: "1000000"},
+		{name: "invalid", header: http.Header{"Range": {"bytes=9-1"}}, wantAmt: "1000000"},
+		{name: "other unit", header: http.Header{"Range": {"items=0-9"}}, wantAmt: "1000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pricing := &RangePricing{ChunkSize: tt.chunk}
+			got := pricing.Price(tt.header, 1000, base)
+			if got[0].MaxAmountRequired != tt.wantAmt {
+				t.Errorf("amount = %s, want %s", got[0].MaxAmountRequired, tt.wantAmt)
+			}
+			if base[0].MaxAmountRequired != "1000000" {
+				t.Error("Price modified its input")
+			}
+		})
+	}
+}
+
+func TestMiddleware_RangePricing(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.RangePricing = &RangePricing{
+		Size: func(context.Context, EngineRequest) (int64, bool) { return 4000, true },
+	}
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPartialContent)
+	}))
+
+	// The 402 advertises the price of the range
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Range", "bytes=1000-1999")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var body x402.PaymentRequirementsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || len(body.Accepts) != 1 {
+		t.Fatalf("402 body = %v, %v", body, err)
+	}
+	if got := body.Accepts[0].MaxAmountRequired; got != "2500" {
+		t.Errorf("advertised amount = %s, want 2500", got)
+	}
+
+	// The payment is verified against the same price
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", rec.Code)
+	}
+	if got := verifiedAmount.Load(); got != "2500" {
+		t.Errorf("verified amount = %v, want 2500", got)
+	}
+	if got := settleCalls.Load(); got != 1 {
+		t.Errorf("settle calls = %d, want 1", got)
+	}
+}
+
+func TestMiddleware_RangePricingFullResponse(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.RangePricing = &RangePricing{
+		Size: func(context.Context, EngineRequest) (int64, bool) { return 4000, true },
+	}
+	// The handler ignores Range and serves the whole resource
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("the whole resource"))
+	}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Range", "bytes=1000-1999")
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want 402", rec.Code)
+	}
+	var body x402.PaymentRequirementsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || len(body.Accepts) != 1 {
+		t.Fatalf("402 body = %v, %v", body, err)
+	}
+	if body.Reason != ReasonRangeNotServed || body.Accepts[0].MaxAmountRequired != "10000" {
+		t.Errorf("402 = reason %q, amount %s; want %s and the full price 10000", body.Reason, body.Accepts[0].MaxAmountRequired, ReasonRangeNotServed)
+	}
+	if got := settleCalls.Load(); got != 0 {
+		t.Errorf("settle calls = %d, want 0", got)
+	}
+}
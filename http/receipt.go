@@ -0,0 +1,108 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// ReceiptSignatureHeader carries the server's Ed25519 signature over the
+// X-PAYMENT-RESPONSE header value, so clients can detect forged settlement receipts.
+// The signature also covers the X-PAYMENT header the receipt settles and the resource
+// it was paid for, so a receipt cannot be replayed for another payment or resource.
+const ReceiptSignatureHeader = "X-PAYMENT-RESPONSE-SIGNATURE"
+
+// SignReceipt returns the base64-encoded Ed25519 signature of an X-PAYMENT-RESPONSE
+// header value settling the X-PAYMENT header paymentHeader of a request for
+// resourceURL.
+func SignReceipt(key ed25519.PrivateKey, paymentHeader, resourceURL, headerValue string) (string, error) {
+	message, err := receiptMessage(paymentHeader, resourceURL, headerValue)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, message)), nil
+}
+
+// VerifyReceipt checks the signature of an X-PAYMENT-RESPONSE header value settling the
+// X-PAYMENT header paymentHeader of a request for rawURL. It returns an error wrapping
+// x402.ErrInvalidReceipt if the signature is missing or does not match, including when
+// it was made for another payment or resource.
+func VerifyReceipt(key ed25519.PublicKey, paymentHeader, rawURL, headerValue, signature string) error {
+	if signature == "" {
+		return fmt.Errorf("%w: missing %s header", x402.ErrInvalidReceipt, ReceiptSignatureHeader)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", x402.ErrInvalidReceipt)
+	}
+	message, err := receiptMessage(paymentHeader, rawURL, headerValue)
+	if err != nil {
+		return fmt.Errorf("%w: %v", x402.ErrInvalidReceipt, err)
+	}
+	if !ed25519.Verify(key, message, sig) {
+		return fmt.Errorf("%w: signature mismatch", x402.ErrInvalidReceipt)
+	}
+	return nil
+}
+
+// receiptMessage returns the message a receipt signature signs: the resource URL, the
+// SHA-256 hash of the payment header, whose authorization nonce makes it unique, and
+// the receipt, after a label separating it from other signed messages.
+func receiptMessage(paymentHeader, resourceURL, headerValue string) ([]byte, error) {
+	resource, err := signedURL(resourceURL)
+	if err != nil {
+		return nil, err
+	}
+	if paymentHeader == "" {
+		return nil, fmt.Errorf("no payment header to sign the receipt of")
+	}
+	sum := sha256.Sum256([]byte(paymentHeader))
+	return []byte("x402-receipt\n" + resource + "\n" + base64.RawURLEncoding.EncodeToString(sum[:]) + "\n" + headerValue), nil
+}
+
+// SignPaymentResponse signs the X-PAYMENT-RESPONSE header in h with the configured
+// ReceiptSigningKey, for the X-PAYMENT header paymentHeader of a request for
+// resourceURL. It does nothing if no key is configured or the header is absent.
+func (c *Config) SignPaymentResponse(h http.Header, paymentHeader, resourceURL string) {
+	if len(c.ReceiptSigningKey) == 0 {
+		return
+	}
+	if receipt := h.Get("X-PAYMENT-RESPONSE"); receipt != "" {
+		signature, err := SignReceipt(c.ReceiptSigningKey, paymentHeader, resourceURL, receipt)
+		if err != nil {
+			// The receipt is still sent; clients pinning the key reject it
+			slog.Default().Warn("failed to sign payment receipt", "error", err)
+			return
+		}
+		h.Set(ReceiptSignatureHeader, signature)
+	}
+}
+
+// VerifySettlement extracts the settlement from resp after checking its receipt
+// signature against key. resp.Request must be the paid request, carrying the X-PAYMENT
+// header the receipt settles, as it is for responses of an http.Client.
+func VerifySettlement(resp *http.Response, key ed25519.PublicKey) (*x402.SettlementResponse, error) {
+	receipt := resp.Header.Get("X-PAYMENT-RESPONSE")
+	if receipt == "" {
+		return nil, fmt.Errorf("%w: missing X-PAYMENT-RESPONSE header", x402.ErrInvalidReceipt)
+	}
+	if resp.Request == nil || resp.Request.URL == nil {
+		return nil, fmt.Errorf("%w: no request to check the receipt against", x402.ErrInvalidReceipt)
+	}
+	if err := VerifyReceipt(key, resp.Request.Header.Get("X-PAYMENT"), resp.Request.URL.String(), receipt, resp.Header.Get(ReceiptSignatureHeader)); err != nil {
+		return nil, err
+	}
+	return parseSettlement(receipt)
+}
+
+// originOf returns the scheme://host[:port] origin of u, used to look up pinned keys.
+func originOf(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+func TestVerifyReceipt(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	pub := key.Public().(ed25519.PublicKey)
+
+	const (
+		receipt  = "eyJzdWNjZXNzIjp0cnVlfQ=="
+		payment  = "eyJ4NDAyVmVyc2lvbiI6MX0="
+		resource = "https://api.example.com/report"
+	)
+	signature, err := SignReceipt(key, payment, resource, receipt)
+	if err != nil {
+		t.Fatalf("SignReceipt() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		key       ed25519.PublicKey
+		payment   string
+		url       string
+		receipt   string
+		signature string
+		wantErr   bool
+	}{
+		{"valid", pub, payment, resource, receipt, signature, false},
+		{"host case", pub, payment, "https://API.example.com/report", receipt, signature, false},
+		{"missing signature", pub, payment, resource, receipt, "", true},
+		{"malformed signature", pub, payment, resource, receipt, "!!!", true},
+		{"tampered receipt", pub, payment, resource, receipt + "x", signature, true},
+		{"wrong key", otherPub, payment, resource, receipt, signature, true},
+		{"other payment", pub, payment + "x", resource, receipt, signature, true},
+		{"no payment", pub, "", resource, receipt, signature, true},
+		{"other resource", pub, payment, "https://api.example.com/ping", receipt, signature, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyReceipt(tt.key, tt.payment, tt.url, tt.receipt, tt.signature)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyReceipt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, x402.ErrInvalidReceipt) {
+				t.Errorf("expected ErrInvalidReceipt, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMiddleware_SignsReceipt(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	pub, key, _ := ed25519.GenerateKey(nil)
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.ReceiptSigningKey = key
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	resp := rec.Result()
+	resp.Request = req
+	req.URL, _ = url.Parse("http://example.com/test")
+	if _, err := VerifySettlement(resp, pub); err != nil {
+		t.Errorf("VerifySettlement failed: %v", err)
+	}
+
+	// The receipt does not verify for another payment
+	req.Header.Set("X-PAYMENT", "eyJ4NDAyVmVyc2lvbiI6MX0=")
+	if _, err := VerifySettlement(resp, pub); !errors.Is(err, x402.ErrInvalidReceipt) {
+		t.Errorf("expected ErrInvalidReceipt for another payment, got %v", err)
+	}
+}
+
+func TestClient_WithReceiptKey(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	_, forgerKey, _ := ed25519.GenerateKey(nil)
+
+	receipt, err := encoding.EncodeSettlement(x402.SettlementResponse{Success: true, Transaction: "0xabc", Network: "base"})
+	if err != nil {
+		t.Fatalf("EncodeSettlement failed: %v", err)
+	}
+
+	var sign atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "1000",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		w.Header().Set("X-PAYMENT-RESPONSE", receipt)
+		if sig := sign.Load().(func(r *http.Request) string)(r); sig != "" {
+			w.Header().Set(ReceiptSignatureHeader, sig)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}),
+		WithReceiptKey(server.URL, pub),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	signer := func(key ed25519.PrivateKey, payment func(r *http.Request) string) func(r *http.Request) string {
+		return func(r *http.Request) string {
+			signature, err := SignReceipt(key, payment(r), "http://"+r.Host+r.RequestURI, receipt)
+			if err != nil {
+				t.Errorf("SignReceipt() error = %v", err)
+			}
+			return signature
+		}
+	}
+	paid := func(r *http.Request) string { return r.Header.Get("X-PAYMENT") }
+	earlier := func(*http.Request) string { return "eyJ4NDAyVmVyc2lvbiI6MX0=" }
+
+	tests := []struct {
+		name    string
+		sign    func(r *http.Request) string
+		wantErr bool
+	}{
+		{"signed by origin", signer(key, paid), false},
+		{"unsigned", func(*http.Request) string { return "" }, true},
+		{"signed by intermediary", signer(forgerKey, paid), true},
+		{"replayed from another payment", signer(key, earlier), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sign.Store(tt.sign)
+			resp, err := client.Get(server.URL)
+			if tt.wantErr {
+				if !errors.Is(err, x402.ErrInvalidReceipt) {
+					t.Errorf("expected ErrInvalidReceipt, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+		})
+	}
+
+	if _, err := NewClient(WithReceiptKey("not a url", pub)); err == nil {
+		t.Error("expected error for invalid origin")
+	}
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/http/internal/helpers"
+)
+
+// ReputationContextKey is the context key for the Reputation of a paying request's payer.
+const ReputationContextKey = contextKey("x402_reputation")
+
+// ReputationProvider assesses payers before their payment is verified or settled, e.g.
+// from the server's own chargeback and refund history or from on-chain risk signals.
+// Implementations must be safe for concurrent use.
+type ReputationProvider interface {
+	// Reputation returns the assessment of payer (as claimed by the payment, on network).
+	// A nil Reputation means the payer is unknown and is treated normally.
+	Reputation(ctx context.Context, payer, network string) (*Reputation, error)
+}
+
+// ReputationFunc adapts a function to ReputationProvider.
+type ReputationFunc func(ctx context.Context, payer, network string) (*Reputation, error)
+
+// Reputation implements ReputationProvider.
+func (f ReputationFunc) Reputation(ctx context.Context, payer, network string) (*Reputation, error) {
+	return f(ctx, payer, network)
+}
+
+// Reputation is a ReputationProvider's assessment of a payer and the action to take.
+type Reputation struct {
+	// Score rates the payer from 0 (untrusted) to 1 (trusted). The middleware does not
+	// interpret it; handlers read it from the request context (see ReputationContextKey).
+	Score float64 `json:"score"`
+
+	// Signals lists the findings behind the score, e.g. "chargeback" or "sanctioned".
+	Signals []string `json:"signals,omitempty"`
+
+	// Deny rejects the payer's payments with 403 Forbidden.
+	Deny bool `json:"deny,omitempty"`
+
+	// PriceMultiplier scales the payer's required amounts, e.g. 1.5 for a 50% surcharge.
+	// Values of 1 or less leave prices unchanged. The payer learns its price from the 402
+	// answering its first payment, with reason ReasonPriceChanged, and pays it on retry.
+	PriceMultiplier float64 `json:"priceMultiplier,omitempty"`
+
+	// Degrade asks the handler to serve a reduced response, such as lower rate limits or
+	// fewer results.
+	Degrade bool `json:"degrade,omitempty"`
+}
+
+// Surcharge returns requirements with their amounts multiplied by r.PriceMultiplier,
+// rounded up to whole atomic units. It returns requirements unchanged when r is nil or
+// its multiplier is 1 or less, and never modifies requirements.
+func (r *Reputation) Surcharge(requirements []x402.PaymentRequirement) []x402.PaymentRequirement {
+	if r == nil || r.PriceMultiplier <= 1 {
+		return requirements
+	}
+	multiplier := new(big.Rat).SetFloat64(r.PriceMultiplier)
+	if multiplier == nil {
+		return requirements
+	}
+
+	surcharged := make([]x402.PaymentRequirement, len(requirements))
+	for i, requirement := range requirements {
+		surcharged[i] = requirement
+		amount, ok := new(big.Rat).SetString(requirement.MaxAmountRequired)
+		if !ok {
+			continue
+		}
+		amount.Mul(amount, multiplier)
+		// Round up so a surcharge is never lost to truncation
+		quo, rem := new(big.Int).QuoRem(amount.Num(), amount.Denom(), new(big.Int))
+		if rem.Sign() > 0 {
+			quo.Add(quo, big.NewInt(1))
+		}
+		surcharged[i].MaxAmountRequired = quo.String()
+	}
+	return surcharged
+}
+
+// PayerReputation consults the configured ReputationProvider about payer on network.
+// It returns nil when no provider is configured or the payer is unknown.
+func (c *Config) PayerReputation(ctx context.Context, payer, network string) (*Reputation, error) {
+	if c.ReputationProvider == nil || payer == "" {
+		return nil, nil
+	}
+	return c.ReputationProvider.Reputation(ctx, payer, network)
+}
+
+// PaymentPayer returns the payer address claimed by payment, or "" if it cannot be
+// determined. The claim is only proven once the facilitator verifies the payment.
+func PaymentPayer(payment x402.PaymentPayload) string {
+	return helpers.GetPayer(payment)
+}
+
+// PaymentValue returns the amount in atomic units payment was signed for, or "" if it
+// cannot be read from the payment's scheme.
+func PaymentValue(payment x402.PaymentPayload) string {
+	value, _ := helpers.GetValue(payment)
+	return value
+}
+
+// WithReputation returns a copy of ctx carrying the payer's reputation.
+func WithReputation(ctx context.Context, reputation *Reputation) context.Context {
+	return context.WithValue(ctx, ReputationContextKey, reputation)
+}
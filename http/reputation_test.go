@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestReputation_Surcharge(t *testing.T) {
+	base := []x402.PaymentRequirement{{MaxAmountRequired: "10001"}, {MaxAmountRequired: "invalid"}}
+
+	tests := []struct {
+		name       string
+		reputation *Reputation
+		want       string
+	}{
+		{name: "nil reputation", reputation: nil, want: "10001"},
+		{name: "no multiplier", reputation: &Reputation{Score: 0.9}, want: "10001"},
+		{name: "discount ignored", reputation: &Reputation{PriceMultiplier: 0.5}, want: "10001"},
+		{name: "surcharge rounds up", reputation: &Reputation{PriceMultiplier: 1.5}, want: "15002"},
+		{name: "double", reputation: &Reputation{PriceMultiplier: 2}, want: "20002"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.reputation.Surcharge(base)
+			if got[0].MaxAmountRequired != tt.want {
+				t.Errorf("amount = %s, want %s", got[0].MaxAmountRequired, tt.want)
+			}
+			if got[1].MaxAmountRequired != "invalid" {
+				t.Errorf("invalid amount changed to %s", got[1].MaxAmountRequired)
+			}
+			if base[0].MaxAmountRequired != "10001" {
+				t.Error("Surcharge modified its input")
+			}
+		})
+	}
+}
+
+func TestMiddleware_ReputationProvider(t *testing.T) {
+	tests := []struct {
+		name           string
+		reputation     *Reputation
+		err            error
+		wantStatus     int
+		wantVerified   any
+		wantSettled    int32
+		wantReputation bool
+	}{
+		{name: "unknown payer", wantStatus: http.StatusOK, wantVerified: "10000", wantSettled: 1},
+		{name: "provider error", err: errors.New("risk API down"), wantStatus: http.StatusOK, wantVerified: "10000", wantSettled: 1},
+		{name: "denied", reputation: &Reputation{Score: 0.1, Deny: true}, wantStatus: http.StatusForbidden},
+		{name: "surcharged", reputation: &Reputation{Score: 0.4, PriceMultiplier: 1.5}, wantStatus: http.StatusPaymentRequired},
+		{name: "degraded", reputation: &Reputation{Score: 0.5, Degrade: true}, wantStatus: http.StatusOK, wantVerified: "10000", wantSettled: 1, wantReputation: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifiedAmount atomic.Value
+			var settleCalls atomic.Int32
+			server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+			defer server.Close()
+
+			var gotPayer, gotNetwork string
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.ReputationProvider = ReputationFunc(func(_ context.Context, payer, network string) (*Reputation, error) {
+				gotPayer, gotNetwork = payer, network
+				return tt.reputation, tt.err
+			})
+
+			var handlerReputation *Reputation
+			handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerReputation, _ = r.Context().Value(ReputationContextKey).(*Reputation)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotPayer != testPayer || gotNetwork != "base-sepolia" {
+				t.Errorf("provider consulted for %q on %q", gotPayer, gotNetwork)
+			}
+			if got := verifiedAmount.Load(); got != tt.wantVerified {
+				t.Errorf("verified amount = %v, want %v", got, tt.wantVerified)
+			}
+			if got := settleCalls.Load(); got != tt.wantSettled {
+				t.Errorf("settle calls = %d, want %d", got, tt.wantSettled)
+			}
+			if (handlerReputation != nil) != tt.wantReputation {
+				t.Errorf("handler reputation = %+v, want present %v", handlerReputation, tt.wantReputation)
+			}
+		})
+	}
+}
+
+func TestMiddleware_ReputationSurchargeCollected(t *testing.T) {
+	config := validTestConfig()
+	config.ReputationProvider = ReputationFunc(func(context.Context, string, string) (*Reputation, error) {
+		return &Reputation{Score: 0.4, PriceMultiplier: 1.5}, nil
+	})
+
+	status, signed, settled := payForPrice(t, config)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	// The payment signed for the advertised price is answered with the surcharged one
+	if !reflect.DeepEqual(signed, []string{"10000", "15000"}) {
+		t.Errorf("signed amounts = %v, want [10000 15000]", signed)
+	}
+	if !reflect.DeepEqual(settled, []string{"15000"}) {
+		t.Errorf("settled amounts = %v, want [15000]", settled)
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"context"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// RequirementsFunc computes the payment requirements of a request from the configured
+// ones (with Resource already set), e.g. to price it by its content. It runs before
+// the 402 is sent, so clients are asked for the computed price, and again when they
+// retry with payment, which is verified against it. It must not modify base.
+//
+// An error rejects the request with 400 Bad Request; its message is sent to the
+// client, so it should explain what to change (e.g. "query cost 120 exceeds 100").
+type RequirementsFunc func(ctx context.Context, req EngineRequest, base []x402.PaymentRequirement) ([]x402.PaymentRequirement, error)
+
+// RequirementsFor applies the configured RequirementsFunc to base.
+// It returns base unchanged when no function is configured or it returns none.
+func (c *Config) RequirementsFor(ctx context.Context, req EngineRequest, base []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
+	if c.RequirementsFunc == nil {
+		return base, nil
+	}
+	requirements, err := c.RequirementsFunc(ctx, req, base)
+	if err != nil {
+		return nil, err
+	}
+	if len(requirements) == 0 {
+		return base, nil
+	}
+	return requirements, nil
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/x402-go"
+)
+
+const (
+	// RequirementsSignatureHeader carries the server's detached JWS (RFC 7515, Appendix F)
+	// over the payment requirements of a 402 response, so clients can detect requirements
+	// altered on the way, such as a swapped payTo address.
+	RequirementsSignatureHeader = "X-PAYMENT-REQUIREMENTS-SIGNATURE"
+
+	// RequirementsKeysPath is the conventional path to publish the keys signing a server's
+	// payment requirements at, with RequirementsKeysHandler.
+	RequirementsKeysPath = "/.well-known/x402-keys.json"
+
+	// requirementsJWSType is the typ of the requirements signature's protected header.
+	requirementsJWSType = "x402-accepts+jws"
+)
+
+// jwsHeader is the protected header of a requirements signature. HTM and HTU bind the
+// signature to the request the 402 answered, as in DPoP (RFC 9449).
+type jwsHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+	HTM string `json:"htm,omitempty"`
+	HTU string `json:"htu,omitempty"`
+}
+
+// jwk is an Ed25519 public key in JSON Web Key form (RFC 8037).
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+}
+
+// SignRequirements returns the detached JWS signing accepts with key, for the
+// RequirementsSignatureHeader of a 402 response to a method request for resourceURL.
+// The signed payload is the canonical JSON of accepts (see x402.CanonicalJSON), so the
+// signature holds however the 402 body is encoded; the method and URL are signed in
+// the protected header, so the signature cannot be replayed on another resource.
+func SignRequirements(key ed25519.PrivateKey, method, resourceURL string, accepts []x402.PaymentRequirement) (string, error) {
+	htu, err := signedURL(resourceURL)
+	if err != nil {
+		return "", err
+	}
+	payload, err := x402.CanonicalJSON(accepts)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(jwsHeader{
+		Alg: "EdDSA",
+		Kid: RequirementsKeyID(key.Public().(ed25519.PublicKey)),
+		Typ: requirementsJWSType,
+		HTM: method,
+		HTU: htu,
+	})
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(header)
+	signature := ed25519.Sign(key, []byte(protected+"."+base64.RawURLEncoding.EncodeToString(payload)))
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyRequirements checks the signature of the payment requirements in a 402 response
+// body to a method request for rawURL against key. It returns an error wrapping
+// x402.ErrInvalidRequirementsSignature if the signature is missing, made with another
+// key, made for another request, or does not match the body's accepts.
+func VerifyRequirements(key ed25519.PublicKey, method, rawURL string, body []byte, signature string) error {
+	if signature == "" {
+		return fmt.Errorf("%w: missing %s header", x402.ErrInvalidRequirementsSignature, RequirementsSignatureHeader)
+	}
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return fmt.Errorf("%w: not a detached JWS", x402.ErrInvalidRequirementsSignature)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: malformed header", x402.ErrInvalidRequirementsSignature)
+	}
+	var header jwsHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return fmt.Errorf("%w: malformed header", x402.ErrInvalidRequirementsSignature)
+	}
+	if header.Alg != "EdDSA" {
+		return fmt.Errorf("%w: unsupported algorithm %q", x402.ErrInvalidRequirementsSignature, header.Alg)
+	}
+	if header.Kid != "" && header.Kid != RequirementsKeyID(key) {
+		return fmt.Errorf("%w: signed with unknown key %q", x402.ErrInvalidRequirementsSignature, header.Kid)
+	}
+	htu, err := signedURL(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", x402.ErrInvalidRequirementsSignature, err)
+	}
+	if header.HTM != method || header.HTU != htu {
+		return fmt.Errorf("%w: signed for %s %s", x402.ErrInvalidRequirementsSignature, header.HTM, header.HTU)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", x402.ErrInvalidRequirementsSignature)
+	}
+
+	// Verify over the accepts as received, including fields this client does not know
+	var response struct {
+		Accepts json.RawMessage `json:"accepts"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Accepts) == 0 {
+		return fmt.Errorf("%w: no payment requirements in body", x402.ErrInvalidRequirementsSignature)
+	}
+	payload, err := x402.CanonicalJSON(response.Accepts)
+	if err != nil {
+		return fmt.Errorf("%w: %v", x402.ErrInvalidRequirementsSignature, err)
+	}
+	if !ed25519.Verify(key, []byte(parts[0]+"."+base64.RawURLEncoding.EncodeToString(payload)), sig) {
+		return fmt.Errorf("%w: signature mismatch", x402.ErrInvalidRequirementsSignature)
+	}
+	return nil
+}
+
+// signedURL returns rawURL as signed in requirements signatures and receipts: without
+// fragment, with the scheme and host in lower case and an empty path as "/". Unlike a
+// DPoP htu it keeps the query, which can change the price.
+func signedURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid resource URL %q", rawURL)
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	htu := strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + path
+	if u.RawQuery != "" {
+		htu += "?" + u.RawQuery
+	}
+	return htu, nil
+}
+
+// RequirementsKeyID returns the JWK thumbprint (RFC 7638) of key, the kid its
+// requirements signatures and published JWK carry.
+func RequirementsKeyID(key ed25519.PublicKey) string {
+	// The thumbprint hashes the required members in lexicographic order
+	thumbprint := `{"crv":"Ed25519","kty":"OKP","x":"` + base64.RawURLEncoding.EncodeToString(key) + `"}`
+	sum := sha256.Sum256([]byte(thumbprint))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RequirementsKeysHandler serves keys as a JSON Web Key Set, to publish the keys a
+// server signs its payment requirements with (conventionally at RequirementsKeysPath).
+// Clients should pin a published key once, e.g. with WithRequirementsKey, rather than
+// fetch it alongside each 402: a key fetched over the same connection protects nothing.
+func RequirementsKeysHandler(keys ...ed25519.PublicKey) http.Handler {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: make([]jwk, len(keys))}
+	for i, key := range keys {
+		set.Keys[i] = jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+			Kid: RequirementsKeyID(key),
+			Use: "sig",
+			Alg: "EdDSA",
+		}
+	}
+	body, _ := json.Marshal(set)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		_, _ = w.Write(body)
+	})
+}
+
+// ParseRequirementsKeys returns the Ed25519 keys of a JSON Web Key Set served by
+// RequirementsKeysHandler. Keys of other types are skipped.
+func ParseRequirementsKeys(data []byte) ([]ed25519.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+	var keys []ed25519.PublicKey
+	for _, k := range set.Keys {
+		if k.Kty != "OKP" || k.Crv != "Ed25519" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid key set: malformed Ed25519 key %q", k.Kid)
+		}
+		keys = append(keys, ed25519.PublicKey(x))
+	}
+	return keys, nil
+}
+
+// signRequirements adds the requirements signature to a 402 decision for a method
+// request for resourceURL when a RequirementsSigningKey is configured.
+func (c *Config) signRequirements(d *Decision, method, resourceURL string) *Decision {
+	if len(c.RequirementsSigningKey) == 0 || d.Status != http.StatusPaymentRequired || len(d.Requirements) == 0 {
+		return d
+	}
+	signature, err := SignRequirements(c.RequirementsSigningKey, method, resourceURL, d.Requirements)
+	if err != nil {
+		// The 402 is still sent; clients pinning the key reject it
+		slog.Default().Warn("failed to sign payment requirements", "error", err)
+		return d
+	}
+	d.header().Set(RequirementsSignatureHeader, signature)
+	return d
+}
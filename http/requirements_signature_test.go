@@ -0,0 +1,203 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestVerifyRequirements(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	otherPub, otherKey, _ := ed25519.GenerateKey(nil)
+
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+		Extra:             map[string]interface{}{"name": "USD Coin", "version": "2"},
+	}
+	const resource = "https://api.example.com/report?range=year"
+	signature, err := SignRequirements(key, "GET", resource, []x402.PaymentRequirement{requirement})
+	if err != nil {
+		t.Fatalf("SignRequirements() error = %v", err)
+	}
+	body := makePaymentRequirementsResponse(requirement)
+
+	swapped := requirement
+	swapped.PayTo = "0x1111111111111111111111111111111111111111"
+
+	// The same requirements encoded differently, as a proxy might re-encode them
+	reencoded := []byte(`{"accepts": [{"extra": {"version": "2", "name": "USD Coin"}, "payTo": "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		"network": "base", "scheme": "exact", "asset": "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		"maxAmountRequired": "1000", "maxTimeoutSeconds": 60, "resource": ""}], "error": "Payment required", "x402Version": 1}`)
+
+	otherSignature, _ := SignRequirements(otherKey, "GET", resource, []x402.PaymentRequirement{requirement})
+
+	tests := []struct {
+		name      string
+		key       ed25519.PublicKey
+		method    string
+		url       string
+		body      []byte
+		signature string
+		wantErr   bool
+	}{
+		{"valid", pub, "GET", resource, body, signature, false},
+		{"re-encoded body", pub, "GET", resource, reencoded, signature, false},
+		{"host case", pub, "GET", "HTTPS://API.example.com/report?range=year#top", body, signature, false},
+		{"missing signature", pub, "GET", resource, body, "", true},
+		{"not detached", pub, "GET", resource, body, "a.b.c", true},
+		{"swapped payTo", pub, "GET", resource, makePaymentRequirementsResponse(swapped), signature, true},
+		{"wrong key", otherPub, "GET", resource, body, signature, true},
+		{"signed by another key", pub, "GET", resource, body, otherSignature, true},
+		{"no accepts", pub, "GET", resource, []byte(`{"x402Version":1}`), signature, true},
+		{"other method", pub, "POST", resource, body, signature, true},
+		{"other path", pub, "GET", "https://api.example.com/ping", body, signature, true},
+		{"other query", pub, "GET", "https://api.example.com/report?range=day", body, signature, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyRequirements(tt.key, tt.method, tt.url, tt.body, tt.signature)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyRequirements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, x402.ErrInvalidRequirementsSignature) {
+				t.Errorf("expected ErrInvalidRequirementsSignature, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMiddleware_SignsRequirements(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	config := validTestConfig()
+	config.RequirementsSigningKey = key
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected status 402, got %d", rec.Code)
+	}
+	signature := rec.Header().Get(RequirementsSignatureHeader)
+	if signature == "" {
+		t.Fatalf("expected %s header", RequirementsSignatureHeader)
+	}
+	if err := VerifyRequirements(pub, "GET", "http://example.com/test", rec.Body.Bytes(), signature); err != nil {
+		t.Errorf("VerifyRequirements() error = %v", err)
+	}
+}
+
+func TestClient_WithRequirementsKey(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	}
+	swapped := requirement
+	swapped.PayTo = "0x1111111111111111111111111111111111111111"
+
+	// The server signs the requirements of /report, and replays them on other paths
+	var offered atomic.Value
+	var paid atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			signature, err := SignRequirements(key, "GET", "http://"+r.Host+"/report", []x402.PaymentRequirement{requirement})
+			if err != nil {
+				t.Errorf("SignRequirements() error = %v", err)
+			}
+			w.Header().Set(RequirementsSignatureHeader, signature)
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(offered.Load().(x402.PaymentRequirement)))
+			return
+		}
+		paid.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}),
+		WithRequirementsKey(server.URL, pub),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	// Requirements altered in transit are not paid
+	offered.Store(swapped)
+	if _, err := client.Get(server.URL + "/report"); !errors.Is(err, x402.ErrInvalidRequirementsSignature) {
+		t.Errorf("expected ErrInvalidRequirementsSignature, got %v", err)
+	}
+	if paid.Load() != 0 {
+		t.Error("client paid requirements with an invalid signature")
+	}
+
+	// Requirements signed for another resource are not paid
+	offered.Store(requirement)
+	if _, err := client.Get(server.URL + "/ping"); !errors.Is(err, x402.ErrInvalidRequirementsSignature) {
+		t.Errorf("expected ErrInvalidRequirementsSignature for a replayed signature, got %v", err)
+	}
+	if paid.Load() != 0 {
+		t.Error("client paid requirements signed for another resource")
+	}
+
+	resp, err := client.Get(server.URL + "/report")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if paid.Load() != 1 {
+		t.Errorf("expected 1 paid request, got %d", paid.Load())
+	}
+
+	if _, err := NewClient(WithRequirementsKey("not a url", pub)); err == nil {
+		t.Error("expected error for invalid origin")
+	}
+}
+
+func TestRequirementsKeysHandler(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+
+	rec := httptest.NewRecorder()
+	RequirementsKeysHandler(pub).ServeHTTP(rec, httptest.NewRequest("GET", RequirementsKeysPath, nil))
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("invalid key set: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].Kid != RequirementsKeyID(pub) {
+		t.Errorf("key set = %s, want the key with kid %s", rec.Body.String(), RequirementsKeyID(pub))
+	}
+
+	keys, err := ParseRequirementsKeys(rec.Body.Bytes())
+	if err != nil {
+		t.Fatalf("ParseRequirementsKeys() error = %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Equal(pub) {
+		t.Errorf("ParseRequirementsKeys() = %v, want [%v]", keys, pub)
+	}
+}
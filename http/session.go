@@ -0,0 +1,213 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SessionHeader carries a session token, both in the response that issues it and in
+	// subsequent requests that use it.
+	SessionHeader = "X-X402-Session"
+
+	// DefaultSessionTTL is the session lifetime used when SessionConfig.TTL is zero.
+	DefaultSessionTTL = time.Hour
+)
+
+// SessionContextKey is the context key for the SessionClaims of a request authorized by a session.
+const SessionContextKey = contextKey("x402_session")
+
+// ErrInvalidSession indicates a session token is malformed, forged, expired or used up.
+var ErrInvalidSession = errors.New("x402: invalid session")
+
+// sessionJWTHeader is the fixed, pre-encoded JOSE header of every session token.
+var sessionJWTHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SessionConfig enables payer-scoped sessions: after a successful payment the middleware
+// issues a signed HS256 JWT that lets the payer make further requests without paying,
+// for a time window and optionally a limited number of requests.
+//
+// The token is returned in the X-X402-Session response header and, if CookieName is set,
+// as a cookie. Clients send it back in the X-X402-Session header or the cookie. A session
+// is accepted by every middleware configured with the same Secret.
+type SessionConfig struct {
+	// Secret signs session tokens (required).
+	Secret []byte
+
+	// TTL is how long a session stays valid (default DefaultSessionTTL).
+	TTL time.Duration
+
+	// MaxRequests limits the number of requests a session covers. Zero means unlimited
+	// within TTL.
+	MaxRequests int
+
+	// CookieName also sets the session as an HttpOnly cookie with this name (optional).
+	CookieName string
+
+	// Store counts requests per session when MaxRequests is set. Defaults to an in-memory
+	// store; share a store between replicas to enforce the limit across a deployment.
+	Store QuotaStore
+
+	once         sync.Once
+	defaultStore QuotaStore
+}
+
+// SessionClaims are the claims carried by a session token.
+type SessionClaims struct {
+	// ID uniquely identifies the session.
+	ID string `json:"jti"`
+
+	// Payer is the address whose payment created the session.
+	Payer string `json:"sub"`
+
+	// IssuedAt and ExpiresAt are unix timestamps.
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+
+	// MaxRequests is the request limit for the session (0 = unlimited).
+	MaxRequests int `json:"max,omitempty"`
+}
+
+// Issue creates a signed session token for payer.
+func (s *SessionConfig) Issue(payer string) (string, *SessionClaims, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate session id: %w", err)
+	}
+
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	now := time.Now()
+	claims := &SessionClaims{
+		ID:          hex.EncodeToString(id),
+		Payer:       payer,
+		IssuedAt:    now.Unix(),
+		ExpiresAt:   now.Add(ttl).Unix(),
+		MaxRequests: s.MaxRequests,
+	}
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal session claims: %w", err)
+	}
+	signingInput := sessionJWTHeader + "." + base64.RawURLEncoding.EncodeToString(data)
+	return signingInput + "." + s.sign(signingInput), claims, nil
+}
+
+// Grant issues a session for payer and writes it to the response headers.
+// It must be called before the response status is written.
+func (s *SessionConfig) Grant(w http.ResponseWriter, payer string) error {
+	token, claims, err := s.Issue(payer)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(SessionHeader, token)
+	if s.CookieName != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     s.CookieName,
+			Value:    token,
+			Path:     "/",
+			Expires:  time.Unix(claims.ExpiresAt, 0),
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return nil
+}
+
+// Parse checks a session token's signature and expiry and returns its claims.
+// It does not count a request against the session.
+func (s *SessionConfig) Parse(token string) (*SessionClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != sessionJWTHeader {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidSession)
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1]))) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidSession)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidSession)
+	}
+	var claims SessionClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidSession)
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidSession)
+	}
+	return &claims, nil
+}
+
+// Authorize reads the session token from r and, if it is valid and within its request
+// limit, returns its claims. It returns ErrInvalidSession if r carries no usable session.
+func (s *SessionConfig) Authorize(r *http.Request) (*SessionClaims, error) {
+	token := r.Header.Get(SessionHeader)
+	if token == "" && s.CookieName != "" {
+		if cookie, err := r.Cookie(s.CookieName); err == nil {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("%w: no session token", ErrInvalidSession)
+	}
+
+	claims, err := s.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.MaxRequests > 0 {
+		count, err := s.store().Increment(r.Context(), "session:"+claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("session store: %w", err)
+		}
+		if count > claims.MaxRequests {
+			return nil, fmt.Errorf("%w: request limit reached", ErrInvalidSession)
+		}
+	}
+	return claims, nil
+}
+
+// WithSession returns a copy of ctx carrying the session claims.
+func WithSession(ctx context.Context, claims *SessionClaims) context.Context {
+	return context.WithValue(ctx, SessionContextKey, claims)
+}
+
+// store returns the configured store or a lazily created in-memory one.
+func (s *SessionConfig) store() QuotaStore {
+	if s.Store != nil {
+		return s.Store
+	}
+	s.once.Do(func() {
+		ttl := s.TTL
+		if ttl <= 0 {
+			ttl = DefaultSessionTTL
+		}
+		s.defaultStore = NewMemoryQuotaStore(ttl)
+	})
+	return s.defaultStore
+}
+
+// sign returns the base64url-encoded HMAC-SHA256 of the JWT signing input.
+func (s *SessionConfig) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSessionConfig_IssueAndParse(t *testing.T) {
+	sessions := &SessionConfig{Secret: []byte("session-secret"), TTL: time.Minute}
+
+	token, issued, err := sessions.Issue(testPayer)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	claims, err := sessions.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if claims.Payer != testPayer || claims.ID != issued.ID {
+		t.Errorf("Parsed claims %+v do not match issued claims %+v", claims, issued)
+	}
+
+	other := &SessionConfig{Secret: []byte("other-secret")}
+	if _, err := other.Parse(token); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for wrong secret, got %v", err)
+	}
+	if _, err := sessions.Parse(token + "x"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for tampered token, got %v", err)
+	}
+	if _, err := sessions.Parse("not.a.token"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for malformed token, got %v", err)
+	}
+}
+
+func TestSessionConfig_AuthorizeRequestLimit(t *testing.T) {
+	sessions := &SessionConfig{Secret: []byte("session-secret"), MaxRequests: 2, CookieName: "x402_session"}
+	token, _, err := sessions.Issue(testPayer)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.AddCookie(&http.Cookie{Name: "x402_session", Value: token})
+		if _, err := sessions.Authorize(req); err != nil {
+			t.Fatalf("Authorize %d failed: %v", i+1, err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set(SessionHeader, token)
+	if _, err := sessions.Authorize(req); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession after request limit, got %v", err)
+	}
+}
+
+func TestMiddleware_SessionAfterPayment(t *testing.T) {
+	var verifiedAmount atomic.Value
+	var settleCalls atomic.Int32
+	server := newPricingFacilitator(&verifiedAmount, &settleCalls)
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	config.Sessions = &SessionConfig{Secret: []byte("session-secret"), MaxRequests: 1}
+
+	handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Pay once to obtain a session
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for paid request, got %d", rec.Code)
+	}
+	token := rec.Header().Get(SessionHeader)
+	if token == "" {
+		t.Fatal("Expected session token in response")
+	}
+
+	// The session covers exactly one further request
+	for i, want := range []int{http.StatusOK, http.StatusPaymentRequired} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set(SessionHeader, token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Session request %d: expected status %d, got %d", i+1, want, rec.Code)
+		}
+	}
+
+	if settleCalls.Load() != 1 {
+		t.Errorf("Expected 1 settle call, got %d", settleCalls.Load())
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the hex-encoded HMAC-SHA256 signature of a facilitator request.
+	SignatureHeader = "X-X402-Signature"
+
+	// SignatureTimestampHeader carries the unix timestamp covered by the request signature.
+	SignatureTimestampHeader = "X-X402-Timestamp"
+
+	// DefaultSignatureWindow is the maximum clock skew accepted by VerifyRequestSignature
+	// when no explicit window is given.
+	DefaultSignatureWindow = 5 * time.Minute
+)
+
+// ErrInvalidRequestSignature indicates a facilitator request signature is missing, stale, or wrong.
+var ErrInvalidRequestSignature = errors.New("x402: invalid request signature")
+
+// signRequest adds the timestamp and HMAC signature headers to a facilitator request.
+// The signature covers the timestamp, method, path and body so a captured X-PAYMENT
+// header cannot be replayed against the facilitator without the shared secret.
+func signRequest(req *http.Request, secret []byte, body []byte) {
+	if len(secret) == 0 {
+		return
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(SignatureTimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, computeRequestSignature(secret, timestamp, req.Method, req.URL.Path, body))
+}
+
+// computeRequestSignature returns the hex-encoded HMAC-SHA256 over the canonical request string.
+func computeRequestSignature(secret []byte, timestamp, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + path + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequestSignature checks the HMAC signature added by a FacilitatorClient configured
+// with a SigningSecret. Self-hosted facilitators call this with the raw request body before
+// processing /verify or /settle.
+//
+// Requests whose timestamp differs from the local clock by more than window are rejected,
+// which bounds how long a captured request can be replayed. A window <= 0 uses
+// DefaultSignatureWindow.
+//
+// Returns ErrInvalidRequestSignature if the headers are missing, stale, or do not match.
+func VerifyRequestSignature(r *http.Request, body []byte, secret []byte, window time.Duration) error {
+	if window <= 0 {
+		window = DefaultSignatureWindow
+	}
+
+	timestamp := r.Header.Get(SignatureTimestampHeader)
+	signature := r.Header.Get(SignatureHeader)
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("%w: missing signature headers", ErrInvalidRequestSignature)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidRequestSignature)
+	}
+	skew := time.Since(time.Unix(unix, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > window {
+		return fmt.Errorf("%w: timestamp outside replay window", ErrInvalidRequestSignature)
+	}
+
+	expected := computeRequestSignature(secret, timestamp, r.Method, r.URL.Path, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidRequestSignature)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestFacilitatorClient_SignsRequests(t *testing.T) {
+	secret := []byte("shared-secret")
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := VerifyRequestSignature(r, body, secret, 0); err != nil {
+			t.Errorf("Expected valid signature, got %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/verify":
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: "0xpayer"})
+		case "/settle":
+			_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx"})
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		}
+	}))
+	defer mockServer.Close()
+
+	client := &FacilitatorClient{
+		BaseURL:       mockServer.URL,
+		Client:        &http.Client{},
+		Timeouts:      x402.DefaultTimeouts,
+		SigningSecret: secret,
+	}
+
+	payload := x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia"}
+	requirement := x402.PaymentRequirement{Scheme: "exact", Network: "base-sepolia", MaxAmountRequired: "10000"}
+
+	if _, err := client.Verify(context.Background(), payload, requirement); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if _, err := client.Settle(context.Background(), payload, requirement); err != nil {
+		t.Fatalf("Settle failed: %v", err)
+	}
+	if _, err := client.Supported(context.Background()); err != nil {
+		t.Fatalf("Supported failed: %v", err)
+	}
+}
+
+func TestFacilitatorClient_NoSigningSecret(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(SignatureHeader) != "" || r.Header.Get(SignatureTimestampHeader) != "" {
+			t.Error("Expected no signature headers when SigningSecret is unset")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: "0xpayer"})
+	}))
+	defer mockServer.Close()
+
+	client := &FacilitatorClient{
+		BaseURL:  mockServer.URL,
+		Client:   &http.Client{},
+		Timeouts: x402.DefaultTimeouts,
+	}
+
+	payload := x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia"}
+	requirement := x402.PaymentRequirement{Scheme: "exact", Network: "base-sepolia", MaxAmountRequired: "10000"}
+
+	if _, err := client.Verify(context.Background(), payload, requirement); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+}
+
+func TestVerifyRequestSignature(t *testing.T) {
+	secret := []byte("shared-secret")
+	body := []byte(`{"x402Version":1}`)
+
+	newSignedRequest := func(ts time.Time, reqSecret []byte, reqBody []byte) *http.Request {
+		req := httptest.NewRequest("POST", "/settle", nil)
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		req.Header.Set(SignatureTimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, computeRequestSignature(reqSecret, timestamp, req.Method, req.URL.Path, reqBody))
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		wantErr bool
+	}{
+		{
+			name:    "valid signature",
+			req:     newSignedRequest(time.Now(), secret, body),
+			wantErr: false,
+		},
+		{
+			name:    "missing headers",
+			req:     httptest.NewRequest("POST", "/settle", nil),
+			wantErr: true,
+		},
+		{
+			name:    "wrong secret",
+			req:     newSignedRequest(time.Now(), []byte("other-secret"), body),
+			wantErr: true,
+		},
+		{
+			name:    "tampered body",
+			req:     newSignedRequest(time.Now(), secret, []byte(`{"x402Version":2}`)),
+			wantErr: true,
+		},
+		{
+			name:    "stale timestamp",
+			req:     newSignedRequest(time.Now().Add(-10*time.Minute), secret, body),
+			wantErr: true,
+		},
+		{
+			name:    "future timestamp",
+			req:     newSignedRequest(time.Now().Add(10*time.Minute), secret, body),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyRequestSignature(tt.req, body, secret, 0)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRequestSignature) {
+					t.Errorf("Expected ErrInvalidRequestSignature, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
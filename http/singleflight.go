@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// DefaultMaxSharedResponseSize is the largest paid response body X402Transport.SingleFlight
+// buffers to share between identical requests.
+const DefaultMaxSharedResponseSize = 1 << 20
+
+// paymentFlights tracks the payment flows in flight per request, for SingleFlight.
+// The zero value is ready to use.
+type paymentFlights struct {
+	mu      sync.Mutex
+	flights map[string]*paymentFlight
+}
+
+// paymentFlight is the outcome of one payment flow, available once done is closed.
+type paymentFlight struct {
+	done chan struct{}
+
+	// session is the session token issued by the paid response, if any.
+	session string
+
+	// response is the paid response, if it can be shared.
+	response *IdempotentResponse
+}
+
+// join returns the flight for key, starting it if none is in flight. The caller that
+// starts it leads the flight and must finish it.
+func (f *paymentFlights) join(key string) (flight *paymentFlight, leader bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if flight, ok := f.flights[key]; ok {
+		return flight, false
+	}
+	if f.flights == nil {
+		f.flights = make(map[string]*paymentFlight)
+	}
+	flight = &paymentFlight{done: make(chan struct{})}
+	f.flights[key] = flight
+	return flight, true
+}
+
+// finish ends the flight for key and releases its followers.
+func (f *paymentFlights) finish(key string, flight *paymentFlight) {
+	f.mu.Lock()
+	delete(f.flights, key)
+	f.mu.Unlock()
+	close(flight.done)
+}
+
+// singleFlightable reports whether req may share a payment with identical requests:
+// bodiless GET and HEAD requests, which are idempotent.
+func singleFlightable(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) &&
+		(req.Body == nil || req.Body == http.NoBody)
+}
+
+// payOnce pays for req like pay, unless an identical request is already paying. Then
+// it waits for that payment and retries with the session it was issued, or shares its
+// response. If the other payment fails, or its outcome cannot be reused, req pays for
+// itself.
+func (t *X402Transport) payOnce(ctx context.Context, req *http.Request, requirements []x402.PaymentRequirement) (*http.Response, error) {
+	key := req.Method + " " + req.URL.String()
+	for {
+		flight, leader := t.flights.join(key)
+		if leader {
+			resp, err := t.pay(ctx, req, requirements)
+			if err == nil {
+				resp = flight.record(resp)
+			}
+			t.flights.finish(key, flight)
+			return resp, err
+		}
+
+		select {
+		case <-flight.done:
+		case <-ctx.Done():
+			return nil, timeoutError(ctx.Err(), x402.ErrCodeSigningTimeout, "payment deadline exceeded while waiting for a concurrent payment")
+		}
+
+		if flight.response != nil {
+			return flight.response.toResponse(req), nil
+		}
+		if flight.session != "" {
+			resp, err := t.withSession(ctx, req, flight.session)
+			if err != nil || resp != nil {
+				return resp, err
+			}
+		}
+		// The flight failed: pay, or follow the next flight for the same request
+	}
+}
+
+// record keeps what followers of the flight can reuse of resp, the successful paid
+// response, and returns resp for the leader.
+func (flight *paymentFlight) record(resp *http.Response) *http.Response {
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp
+	}
+	if session := resp.Header.Get(SessionHeader); session != "" {
+		flight.session = session
+		return resp
+	}
+
+	// Only share bodies of known, bounded size: reading a streamed body to its end
+	// would hold the leader's response back until the stream finished
+	if !shareable(resp) {
+		return resp
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxSharedResponseSize+1))
+	if err != nil || len(body) > DefaultMaxSharedResponseSize {
+		// Too large (or failing) to share: hand the leader what was read and the rest
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp
+	}
+	resp.Body.Close()
+	// The settlement receipt is bound to the leader's payment: followers did not pay
+	header := resp.Header.Clone()
+	header.Del("X-PAYMENT-RESPONSE")
+	header.Del(ReceiptSignatureHeader)
+	flight.response = &IdempotentResponse{Status: resp.StatusCode, Header: header, Body: body}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp
+}
+
+// shareable reports whether the body of resp may be buffered to share with the
+// flight's followers: it has a Content-Length of at most DefaultMaxSharedResponseSize
+// and is not an event stream.
+func shareable(resp *http.Response) bool {
+	if resp.ContentLength < 0 || resp.ContentLength > DefaultMaxSharedResponseSize {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType != "text/event-stream"
+}
+
+// toResponse returns a copy of the shared response for req.
+func (r *IdempotentResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// withSession retries req with a session token. It returns no response if the session
+// did not cover the request.
+func (t *X402Transport) withSession(ctx context.Context, req *http.Request, session string) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	sessionReq := req.Clone(ctx)
+	sessionReq.Header.Set(SessionHeader, session)
+	resp, err := base.RoundTrip(sessionReq)
+	if err != nil {
+		return nil, timeoutError(err, x402.ErrCodeRequestTimeout, "request timed out")
+	}
+	if resp.StatusCode == http.StatusPaymentRequired {
+		resp.Body.Close()
+		return nil, nil
+	}
+	return resp, nil
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestRoundTrip_SingleFlight(t *testing.T) {
+	const concurrent = 5
+
+	tests := []struct {
+		name         string
+		issueSession bool
+	}{
+		{name: "shares the paid response"},
+		{name: "reuses the issued session", issueSession: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var challenges, payments, sessionRequests, receipts atomic.Int32
+			unblock := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Header.Get(SessionHeader) == "session-token":
+					sessionRequests.Add(1)
+				case r.Header.Get("X-PAYMENT") != "":
+					payments.Add(1)
+					<-unblock
+					w.Header().Set("X-PAYMENT-RESPONSE", "receipt")
+					w.Header().Set(ReceiptSignatureHeader, "signature")
+					if tt.issueSession {
+						w.Header().Set(SessionHeader, "session-token")
+					}
+				default:
+					challenges.Add(1)
+					w.WriteHeader(http.StatusPaymentRequired)
+					_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+						Scheme:            "exact",
+						Network:           "base",
+						MaxAmountRequired: "100000",
+						MaxTimeoutSeconds: 60,
+					}))
+					return
+				}
+				_, _ = w.Write([]byte("paid content"))
+			}))
+			defer server.Close()
+
+			transport := &X402Transport{
+				Base:         http.DefaultTransport,
+				Signers:      []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+				Selector:     x402.NewDefaultPaymentSelector(),
+				SingleFlight: true,
+			}
+
+			var wg sync.WaitGroup
+			for range concurrent {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req, _ := http.NewRequest("GET", server.URL+"/data", nil)
+					resp, err := transport.RoundTrip(req)
+					if err != nil {
+						t.Errorf("RoundTrip failed: %v", err)
+						return
+					}
+					defer resp.Body.Close()
+					body, _ := io.ReadAll(resp.Body)
+					if resp.StatusCode != http.StatusOK || string(body) != "paid content" {
+						t.Errorf("response = %d %q, want 200 %q", resp.StatusCode, body, "paid content")
+					}
+					if resp.Header.Get("X-PAYMENT-RESPONSE") != "" || resp.Header.Get(ReceiptSignatureHeader) != "" {
+						receipts.Add(1)
+					}
+				}()
+			}
+
+			// Let every request receive its 402 and wait for the first payment
+			for challenges.Load() < concurrent {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(unblock)
+			wg.Wait()
+
+			if got := payments.Load(); got != 1 {
+				t.Errorf("payments = %d, want 1", got)
+			}
+			// Only the request that paid receives the receipt of the payment
+			if got := receipts.Load(); got != 1 {
+				t.Errorf("responses with a receipt = %d, want 1", got)
+			}
+			wantSessionRequests := int32(0)
+			if tt.issueSession {
+				wantSessionRequests = concurrent - 1
+			}
+			if got := sessionRequests.Load(); got != wantSessionRequests {
+				t.Errorf("session requests = %d, want %d", got, wantSessionRequests)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_SingleFlightFailedPayment(t *testing.T) {
+	var payments atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "100000",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		// The first payment's request fails, so it is not settled
+		if payments.Add(1) == 1 {
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := &X402Transport{
+		Base:         http.DefaultTransport,
+		Signers:      []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+		Selector:     x402.NewDefaultPaymentSelector(),
+		SingleFlight: true,
+	}
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Errorf("RoundTrip failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The failed payment is not shared: the other requests pay for themselves
+	if got := succeeded.Load(); got < 2 {
+		t.Errorf("successful requests = %d, want at least 2", got)
+	}
+}
+
+func TestRoundTrip_SingleFlightStreamedResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"event stream", "text/event-stream; charset=utf-8"},
+		{"chunked", "application/x-ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finish := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-PAYMENT") == "" {
+					w.WriteHeader(http.StatusPaymentRequired)
+					_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+						Scheme:            "exact",
+						Network:           "base",
+						MaxAmountRequired: "100000",
+						MaxTimeoutSeconds: 60,
+					}))
+					return
+				}
+				// Stream the first event, then hold the response open
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte("data: first\n\n"))
+				w.(http.Flusher).Flush()
+				<-finish
+			}))
+			defer server.Close()
+			defer close(finish)
+
+			transport := &X402Transport{
+				Base:         http.DefaultTransport,
+				Signers:      []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+				Selector:     x402.NewDefaultPaymentSelector(),
+				SingleFlight: true,
+			}
+
+			type result struct {
+				resp *http.Response
+				err  error
+			}
+			done := make(chan result, 1)
+			go func() {
+				req, _ := http.NewRequest("GET", server.URL+"/events", nil)
+				resp, err := transport.RoundTrip(req)
+				done <- result{resp, err}
+			}()
+
+			// The response is returned while the stream is still open
+			select {
+			case r := <-done:
+				if r.err != nil {
+					t.Fatalf("RoundTrip failed: %v", r.err)
+				}
+				defer r.resp.Body.Close()
+				first := make([]byte, len("data: first\n\n"))
+				if _, err := io.ReadFull(r.resp.Body, first); err != nil || string(first) != "data: first\n\n" {
+					t.Errorf("first event = %q (%v), want %q", first, err, "data: first\n\n")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("RoundTrip did not return until the stream ended")
+			}
+		})
+	}
+}
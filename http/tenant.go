@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TenantContextKey is the context key for the ID of the tenant serving a request.
+const TenantContextKey = contextKey("x402_tenant")
+
+// Tenant is one merchant of a multi-tenant deployment, with its own payment
+// configuration.
+type Tenant struct {
+	// ID identifies the tenant, e.g. its hostname or API slug.
+	ID string
+
+	// Config is the tenant's middleware configuration: its payTo addresses and prices
+	// (PaymentRequirements and any pricing options), its facilitator and the rest.
+	// Replace a tenant's Config rather than modifying it in place; the middleware
+	// rebuilds the tenant's engine when its store returns a different Config.
+	Config *Config
+}
+
+// TenantStore looks up tenants. Implementations must be safe for concurrent use and
+// should cache lookups that are expensive, since the middleware calls Tenant on every
+// request.
+type TenantStore interface {
+	// Tenant returns the tenant with id, or nil if there is none.
+	Tenant(ctx context.Context, id string) (*Tenant, error)
+}
+
+// TenantResolver returns the ID of the tenant a request is for, or "" if it names none.
+type TenantResolver func(r *http.Request) string
+
+// TenantByHost resolves tenants by the request's hostname, without port, in lower case,
+// e.g. "weather.example.com".
+func TenantByHost() TenantResolver {
+	return func(r *http.Request) string {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		return strings.ToLower(host)
+	}
+}
+
+// TenantByHeader resolves tenants by a request header, e.g. "X-Tenant-ID" set by a
+// gateway in front of the deployment. Only trust headers the gateway overwrites.
+func TenantByHeader(name string) TenantResolver {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// TenantFromContext returns the ID of the tenant serving the request whose context is
+// ctx.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(TenantContextKey).(string)
+	return id, ok && id != ""
+}
+
+// NewTenantMiddleware creates a payment middleware for many merchants behind one
+// deployment. Each request's tenant is resolved with resolve and looked up in store, and
+// the request is gated by the tenant's Config, so each tenant charges its own prices
+// through its own facilitator and settles to its own wallet. Handlers find the tenant
+// with TenantFromContext.
+//
+// Requests naming no tenant or an unknown one are answered with 404 Not Found, and
+// tenants whose Config fails validation with 500 Internal Server Error. Each tenant's
+// engine is built once, on its first request, which fetches its facilitator's
+// /supported networks like NewX402Middleware; concurrent first requests wait for that
+// build. A failed build is retried after a few seconds, and the engine of a tenant the
+// store no longer returns is dropped.
+func NewTenantMiddleware(store TenantStore, resolve TenantResolver) func(http.Handler) http.Handler {
+	engines := &tenantEngines{engines: make(map[string]*tenantEngine)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := slog.Default()
+			id := resolve(r)
+			if id == "" {
+				http.NotFound(w, r)
+				return
+			}
+			tenant, err := store.Tenant(r.Context(), id)
+			if err != nil {
+				logger.Error("failed to look up tenant", "tenant", id, "error", err)
+				http.Error(w, "Failed to look up tenant", http.StatusInternalServerError)
+				return
+			}
+			if tenant == nil || tenant.Config == nil {
+				engines.evict(id)
+				http.NotFound(w, r)
+				return
+			}
+
+			engine, err := engines.get(id, tenant.Config)
+			if err != nil {
+				logger.Error("invalid tenant payment configuration", "tenant", id, "error", err)
+				http.Error(w, "Invalid payment configuration", http.StatusInternalServerError)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), TenantContextKey, id))
+			serveEngine(engine, next, w, r)
+		})
+	}
+}
+
+// tenantEngineErrorTTL is how long a tenant's failure to build an engine is remembered
+// before a request retries the build, so a broken Config or an unreachable facilitator
+// does not cost every request a build.
+const tenantEngineErrorTTL = 10 * time.Second
+
+// tenantEngines caches the engine of each tenant.
+type tenantEngines struct {
+	mu      sync.Mutex
+	engines map[string]*tenantEngine
+}
+
+// tenantEngine is a tenant's engine, or the error building it, and the Config it was
+// built from. engine, err and built are set once done is closed.
+type tenantEngine struct {
+	config *Config
+	done   chan struct{}
+	engine *Engine
+	err    error
+	built  time.Time
+}
+
+// get returns the engine for the tenant id with config, building it if the tenant is
+// new, its Config was replaced or its last build failed more than tenantEngineErrorTTL
+// ago. Concurrent requests for the same tenant wait for a single build.
+func (t *tenantEngines) get(id string, config *Config) (*Engine, error) {
+	t.mu.Lock()
+	cached, ok := t.engines[id]
+	if ok && cached.config == config && !cached.expired() {
+		t.mu.Unlock()
+		<-cached.done
+		return cached.engine, cached.err
+	}
+	// Replacing the entry drops the engine of a replaced Config
+	building := &tenantEngine{config: config, done: make(chan struct{})}
+	t.engines[id] = building
+	t.mu.Unlock()
+
+	// Build outside the lock: enrichment queries the tenant's facilitator
+	building.engine, building.err = NewEngine(config)
+	building.built = time.Now()
+	close(building.done)
+	return building.engine, building.err
+}
+
+// expired reports whether the entry holds a build error older than
+// tenantEngineErrorTTL. An entry still building has not expired.
+func (e *tenantEngine) expired() bool {
+	select {
+	case <-e.done:
+		return e.err != nil && time.Since(e.built) >= tenantEngineErrorTTL
+	default:
+		return false
+	}
+}
+
+// evict drops the engine of the tenant id, e.g. once the store no longer has it.
+func (t *tenantEngines) evict(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.engines, id)
+}
+
+// MemoryTenantStore is an in-memory TenantStore, for tests and deployments that load
+// their tenants at startup.
+type MemoryTenantStore struct {
+	mu      sync.RWMutex
+	tenants map[string]*Tenant
+}
+
+// NewMemoryTenantStore creates a MemoryTenantStore holding tenants.
+func NewMemoryTenantStore(tenants ...*Tenant) *MemoryTenantStore {
+	s := &MemoryTenantStore{tenants: make(map[string]*Tenant)}
+	for _, tenant := range tenants {
+		s.Put(tenant)
+	}
+	return s
+}
+
+// Put adds tenant, replacing any tenant with the same ID.
+func (s *MemoryTenantStore) Put(tenant *Tenant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants[tenant.ID] = tenant
+}
+
+// Delete removes the tenant with id.
+func (s *MemoryTenantStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tenants, id)
+}
+
+// Tenant implements TenantStore.
+func (s *MemoryTenantStore) Tenant(_ context.Context, id string) (*Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tenants[id], nil
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+func TestTenantResolvers(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://Weather.Example.com:8080/forecast", nil)
+	r.Header.Set("X-Tenant-ID", "acme")
+
+	if got := TenantByHost()(r); got != "weather.example.com" {
+		t.Errorf("TenantByHost() = %q, want weather.example.com", got)
+	}
+	if got := TenantByHeader("X-Tenant-ID")(r); got != "acme" {
+		t.Errorf("TenantByHeader() = %q, want acme", got)
+	}
+}
+
+func TestNewTenantMiddleware(t *testing.T) {
+	var acmeVerified, globexVerified atomic.Value
+	var acmeSettles, globexSettles atomic.Int32
+	acmeFacilitator := newPricingFacilitator(&acmeVerified, &acmeSettles)
+	defer acmeFacilitator.Close()
+	globexFacilitator := newPricingFacilitator(&globexVerified, &globexSettles)
+	defer globexFacilitator.Close()
+
+	tenantConfig := func(facilitatorURL, payTo, amount string) *Config {
+		config := validTestConfig()
+		config.FacilitatorURL = facilitatorURL
+		config.PaymentRequirements[0].PayTo = payTo
+		config.PaymentRequirements[0].MaxAmountRequired = amount
+		return config
+	}
+	const acmePayTo = "0x1111111111111111111111111111111111111111"
+	const globexPayTo = "0x2222222222222222222222222222222222222222"
+	store := NewMemoryTenantStore(
+		&Tenant{ID: "acme", Config: tenantConfig(acmeFacilitator.URL, acmePayTo, "10000")},
+		&Tenant{ID: "globex", Config: tenantConfig(globexFacilitator.URL, globexPayTo, "20000")},
+		&Tenant{ID: "broken", Config: &Config{}},
+	)
+
+	var servedTenant string
+	handler := NewTenantMiddleware(store, TenantByHeader("X-Tenant-ID"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		servedTenant, _ = TenantFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(tenant string, payment bool) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/forecast", nil)
+		if tenant != "" {
+			r.Header.Set("X-Tenant-ID", tenant)
+		}
+		if payment {
+			r.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+	accepts := func(rec *httptest.ResponseRecorder) x402.PaymentRequirement {
+		t.Helper()
+		var body x402.PaymentRequirementsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body.Accepts) != 1 {
+			t.Fatalf("402 body = %s (%v), want one requirement", rec.Body.String(), err)
+		}
+		return body.Accepts[0]
+	}
+
+	// Each tenant asks for its own price, paid to its own wallet
+	rec := serve("acme", false)
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("acme status = %d, want 402", rec.Code)
+	}
+	if got := accepts(rec); got.PayTo != acmePayTo || got.MaxAmountRequired != "10000" {
+		t.Errorf("acme requirement = %+v", got)
+	}
+	rec = serve("globex", false)
+	if got := accepts(rec); got.PayTo != globexPayTo || got.MaxAmountRequired != "20000" {
+		t.Errorf("globex requirement = %+v", got)
+	}
+
+	// Payments settle through the tenant's facilitator
+	rec = serve("globex", true)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-PAYMENT-RESPONSE") == "" {
+		t.Fatalf("paid globex status = %d, want 200 with a receipt", rec.Code)
+	}
+	if servedTenant != "globex" {
+		t.Errorf("handler saw tenant %q, want globex", servedTenant)
+	}
+	if globexSettles.Load() != 1 || acmeSettles.Load() != 0 {
+		t.Errorf("settles: globex %d, acme %d; want globex 1", globexSettles.Load(), acmeSettles.Load())
+	}
+
+	// Replacing a tenant's Config takes effect on its next request
+	store.Put(&Tenant{ID: "acme", Config: tenantConfig(acmeFacilitator.URL, acmePayTo, "15000")})
+	if got := accepts(serve("acme", false)); got.MaxAmountRequired != "15000" {
+		t.Errorf("acme amount after update = %s, want 15000", got.MaxAmountRequired)
+	}
+
+	for _, tt := range []struct {
+		tenant string
+		want   int
+	}{
+		{"", http.StatusNotFound},
+		{"initech", http.StatusNotFound},
+		{"broken", http.StatusInternalServerError},
+	} {
+		if rec := serve(tt.tenant, false); rec.Code != tt.want {
+			t.Errorf("tenant %q status = %d, want %d", tt.tenant, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestTenantEngines(t *testing.T) {
+	var supported atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/supported" {
+			supported.Add(1)
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+	}))
+	defer server.Close()
+
+	config := validTestConfig()
+	config.FacilitatorURL = server.URL
+	engines := &tenantEngines{engines: make(map[string]*tenantEngine)}
+
+	// Concurrent first requests share one build
+	const concurrent = 10
+	built := make(chan *Engine, concurrent)
+	for range concurrent {
+		go func() {
+			engine, err := engines.get("acme", config)
+			if err != nil {
+				t.Errorf("get() error = %v", err)
+			}
+			built <- engine
+		}()
+	}
+	for supported.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	first := <-built
+	for range concurrent - 1 {
+		if engine := <-built; engine != first {
+			t.Error("concurrent requests got different engines")
+		}
+	}
+	if got := supported.Load(); got != 1 {
+		t.Errorf("built %d engines, want 1", got)
+	}
+
+	// An evicted tenant, or one with a new Config, is built again
+	engines.evict("acme")
+	if engine, _ := engines.get("acme", config); engine == first {
+		t.Error("evicted tenant kept its engine")
+	}
+	replaced := validTestConfig()
+	replaced.FacilitatorURL = server.URL
+	engine, _ := engines.get("acme", replaced)
+	if got := supported.Load(); got != 3 {
+		t.Errorf("built %d engines after eviction and replacement, want 3", got)
+	}
+	if again, _ := engines.get("acme", replaced); again != engine {
+		t.Error("unchanged tenant was rebuilt")
+	}
+
+	// A build error is cached for tenantEngineErrorTTL
+	broken := &Config{}
+	_, err := engines.get("broken", broken)
+	if err == nil {
+		t.Fatal("expected an error for an invalid config")
+	}
+	if _, again := engines.get("broken", broken); again != err {
+		t.Errorf("get() = %v, want the cached error %v", again, err)
+	}
+	engines.engines["broken"].built = time.Now().Add(-tenantEngineErrorTTL)
+	if _, again := engines.get("broken", broken); again == nil || again == err {
+		t.Errorf("get() = %v after the TTL, want a new error", again)
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// Timeouts returns the facilitator timeouts for the config: x402.DefaultTimeouts with
+// VerifyTimeout and SettleTimeout applied when set.
+func (c *Config) Timeouts() x402.TimeoutConfig {
+	timeouts := x402.DefaultTimeouts
+	if c.VerifyTimeout > 0 {
+		timeouts = timeouts.WithVerifyTimeout(c.VerifyTimeout)
+	}
+	if c.SettleTimeout > 0 {
+		timeouts = timeouts.WithSettleTimeout(c.SettleTimeout)
+	}
+	return timeouts
+}
+
+// VerifyContext returns a context bounding the verify stage of a request, covering
+// both the primary and the fallback facilitator.
+func (c *Config) VerifyContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.Timeouts().VerifyTimeout)
+}
+
+// SettleContext returns a context bounding the settle stage of a request, covering
+// both the primary and the fallback facilitator.
+func (c *Config) SettleContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.Timeouts().SettleTimeout)
+}
+
+// FacilitatorFailure returns the status code and message for a failed verify or settle
+// call: 504 Gateway Timeout if the stage timed out, otherwise 503 Service Unavailable
+// with message.
+func FacilitatorFailure(err error, message string) (int, string) {
+	var paymentErr *x402.PaymentError
+	if errors.As(err, &paymentErr) {
+		switch paymentErr.Code {
+		case x402.ErrCodeVerifyTimeout:
+			return http.StatusGatewayTimeout, "Payment verification timed out"
+		case x402.ErrCodeSettleTimeout:
+			return http.StatusGatewayTimeout, "Payment settlement timed out"
+		}
+	}
+	return http.StatusServiceUnavailable, message
+}
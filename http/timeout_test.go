@@ -0,0 +1,192 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+)
+
+// slowSigner delays signing.
+type slowSigner struct {
+	mockSigner
+	delay time.Duration
+}
+
+func (s *slowSigner) Sign(req *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	time.Sleep(s.delay)
+	return s.mockSigner.Sign(req)
+}
+
+func TestRoundTrip_PaymentDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		initialDelay time.Duration
+		signDelay    time.Duration
+		paidDelay    time.Duration
+		wantCode     x402.ErrorCode
+	}{
+		{name: "within deadline"},
+		{name: "initial request", initialDelay: 200 * time.Millisecond, wantCode: x402.ErrCodeRequestTimeout},
+		{name: "signing", signDelay: 200 * time.Millisecond, wantCode: x402.ErrCodeSigningTimeout},
+		{name: "paid request", paidDelay: 200 * time.Millisecond, wantCode: x402.ErrCodePaymentTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				delay := tt.initialDelay
+				if r.Header.Get("X-PAYMENT") != "" {
+					delay = tt.paidDelay
+				}
+				select {
+				case <-time.After(delay):
+				case <-r.Context().Done():
+					return
+				}
+
+				if r.Header.Get("X-PAYMENT") == "" {
+					w.WriteHeader(http.StatusPaymentRequired)
+					_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+						Scheme:            "exact",
+						Network:           "base",
+						Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+						MaxAmountRequired: "100000",
+						PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+						MaxTimeoutSeconds: 60,
+					}))
+					return
+				}
+				_, _ = w.Write([]byte("success"))
+			}))
+			defer server.Close()
+
+			transport := &X402Transport{
+				Base: http.DefaultTransport,
+				Signers: []x402.Signer{&slowSigner{
+					mockSigner: mockSigner{network: "base", scheme: "exact", canSignValue: true},
+					delay:      tt.signDelay,
+				}},
+				Selector:        x402.NewDefaultPaymentSelector(),
+				PaymentDeadline: 100 * time.Millisecond,
+			}
+
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("RoundTrip failed: %v", err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("expected status 200, got %d", resp.StatusCode)
+				}
+				return
+			}
+
+			var paymentErr *x402.PaymentError
+			if !errors.As(err, &paymentErr) {
+				t.Fatalf("expected PaymentError, got %v", err)
+			}
+			if paymentErr.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s", tt.wantCode, paymentErr.Code)
+			}
+		})
+	}
+}
+
+func TestWithPaymentDeadline(t *testing.T) {
+	if _, err := NewClient(WithPaymentDeadline(0)); err == nil {
+		t.Error("expected error for zero deadline")
+	}
+
+	client, err := NewClient(WithPaymentDeadline(5 * time.Second))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	transport, ok := client.Transport.(*X402Transport)
+	if !ok {
+		t.Fatalf("expected *X402Transport, got %T", client.Transport)
+	}
+	if transport.PaymentDeadline != 5*time.Second {
+		t.Errorf("expected deadline 5s, got %v", transport.PaymentDeadline)
+	}
+}
+
+func TestMiddleware_FacilitatorTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		verifyDelay time.Duration
+		settleDelay time.Duration
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "within timeouts", wantStatus: http.StatusOK},
+		{name: "verify", verifyDelay: 200 * time.Millisecond, wantStatus: http.StatusGatewayTimeout, wantMessage: "Payment verification timed out"},
+		{name: "settle", settleDelay: 200 * time.Millisecond, wantStatus: http.StatusGatewayTimeout, wantMessage: "Payment settlement timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/supported":
+					_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+				case "/verify":
+					time.Sleep(tt.verifyDelay)
+					_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+				case "/settle":
+					time.Sleep(tt.settleDelay)
+					_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+				}
+			}))
+			defer server.Close()
+
+			config := validTestConfig()
+			config.FacilitatorURL = server.URL
+			config.VerifyTimeout = 50 * time.Millisecond
+			config.SettleTimeout = 50 * time.Millisecond
+
+			handler := NewX402Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-PAYMENT", pricingPaymentHeader(t, testPayer))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantMessage != "" && rec.Body.String() != tt.wantMessage+"\n" {
+				t.Errorf("expected body %q, got %q", tt.wantMessage, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestConfig_Timeouts(t *testing.T) {
+	config := validTestConfig()
+	if got := config.Timeouts(); got != x402.DefaultTimeouts {
+		t.Errorf("expected default timeouts, got %+v", got)
+	}
+
+	config.VerifyTimeout = 2 * time.Second
+	config.SettleTimeout = 30 * time.Second
+	got := config.Timeouts()
+	if got.VerifyTimeout != 2*time.Second || got.SettleTimeout != 30*time.Second {
+		t.Errorf("expected overridden timeouts, got %+v", got)
+	}
+
+	config.VerifyTimeout = -time.Second
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for negative verify timeout")
+	}
+}
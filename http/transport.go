@@ -2,16 +2,25 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mark3labs/x402-go"
 	"github.com/mark3labs/x402-go/encoding"
 )
 
+// DefaultExpectContinueThreshold is the default X402Transport.ExpectContinueThreshold.
+const DefaultExpectContinueThreshold = 1 << 20
+
 // X402Transport is a custom RoundTripper that handles x402 payment flows.
 // It wraps an existing http.RoundTripper and automatically handles 402 Payment Required responses.
 type X402Transport struct {
@@ -19,11 +28,107 @@ type X402Transport struct {
 	Base http.RoundTripper
 
 	// Signers is the list of available payment signers.
+	// Use SetSigners to replace it while the transport is in use.
 	Signers []x402.Signer
 
 	// Selector is used to choose the appropriate signer and create payments.
 	Selector x402.PaymentSelector
 
+	// SpendingLimit optionally caps the total amount paid per time window. The amount is
+	// reserved before signing and released if the paid request fails.
+	SpendingLimit *x402.SpendingLimit
+
+	// PaymentDeadline optionally bounds the whole payment flow: the initial request,
+	// signing, and the paid retry including the server's verification and settlement.
+	// A flow that runs out of time fails with a PaymentError whose code names the stage:
+	// x402.ErrCodeRequestTimeout, x402.ErrCodeSigningTimeout or x402.ErrCodePaymentTimeout.
+	PaymentDeadline time.Duration
+
+	// ReceiptKeys pins the Ed25519 public key each origin (scheme://host[:port]) signs
+	// its settlement receipts with. Receipts from a pinned origin that are unsigned,
+	// carry an invalid signature or were signed for another payment or resource are
+	// rejected with x402.ErrInvalidReceipt.
+	ReceiptKeys map[string]ed25519.PublicKey
+
+	// RequirementsKeys pins the Ed25519 public key each origin (scheme://host[:port])
+	// signs its payment requirements with (see RequirementsSignatureHeader). A 402 from a
+	// pinned origin whose requirements are unsigned, carry an invalid signature or were
+	// signed for another method or URL is not paid; the request fails with
+	// x402.ErrInvalidRequirementsSignature.
+	RequirementsKeys map[string]ed25519.PublicKey
+
+	// PayToPinning optionally remembers the payTo address each origin is first paid at
+	// and warns about, or refuses, 402s that later ask to be paid elsewhere.
+	// See PayToPinning.
+	PayToPinning *PayToPinning
+
+	// MinimumValidityWindow optionally refuses requirements whose MaxTimeoutSeconds is
+	// shorter than this: an authorization that expires before the server has verified
+	// it, run the handler and settled it is rejected after the request was served.
+	// A 402 offering no longer option fails with x402.ErrValidityWindowTooShort.
+	MinimumValidityWindow time.Duration
+
+	// ExpectContinueThreshold is the body size from which the first attempt of a request
+	// is sent with "Expect: 100-continue", so the body is not uploaded to a server that
+	// answers 402 from the headers alone; it is sent once, with the payment attached.
+	// Bodies of unknown length count as large. Zero uses DefaultExpectContinueThreshold;
+	// a negative value disables it. Only rewindable bodies (GetBody set, as by
+	// http.NewRequest for bytes, strings and buffers) qualify, and Base must wait for
+	// 100 Continue, as an http.Transport with ExpectContinueTimeout set does.
+	ExpectContinueThreshold int64
+
+	// MaxConcurrentPayments optionally limits the payment flows (signing and the paid
+	// retry) in flight per destination host. Requests answered with 402 while the limit
+	// is reached wait for a flow to finish, so bursts of requests do not trip a remote
+	// signer's rate limits or sign many authorizations at once. Waiting counts against
+	// the PaymentDeadline. Zero means unlimited.
+	MaxConcurrentPayments int
+
+	// SingleFlight makes identical concurrent GET and HEAD requests (same method and URL)
+	// answered with 402 share one payment: one request pays while the others wait. If
+	// the paid response issues a session (see SessionHeader), the others retry with it;
+	// otherwise they receive a copy of the paid response, without its settlement headers,
+	// which belong to the payment that was made, when it succeeded and has a
+	// Content-Length of at most DefaultMaxSharedResponseSize.
+	// Streamed responses, without a Content-Length or of type text/event-stream, are
+	// returned as they arrive and not shared. If the payment fails, or its outcome
+	// cannot be reused, the others pay for themselves.
+	// Only enable it for URLs whose responses do not depend on other request headers.
+	SingleFlight bool
+
+	// PaymentHints makes the transport remember the payment requirements servers
+	// advertise for linked resources, in Link headers of responses and 103 Early Hints
+	// (see PaymentHintLink), and pay for such a resource with its first GET or HEAD
+	// request, saving the 402 round trip. Only an origin's links to its own resources are
+	// trusted, for DefaultPaymentHintTTL, and not from origins in RequirementsKeys since
+	// hints are unsigned. If the server turns the payment down, the request goes through
+	// the usual 402 flow.
+	PaymentHints bool
+
+	// CompactPayments sends X-PAYMENT headers CBOR-encoded (see
+	// encoding.PaymentEncodingCBOR) to origins whose 402 responses advertise it in
+	// PaymentEncodingsHeader, and as base64-encoded JSON to others. If the server rejects
+	// the compact header as malformed (x402.ErrCodeMalformedHeader), the paid request is
+	// sent again with the JSON header and the origin is no longer sent compact headers.
+	CompactPayments bool
+
+	// Observer optionally receives the raw x402 messages of each payment flow, for
+	// debugging. See TransportObserver and DebugObserver.
+	Observer TransportObserver
+
+	// Simulate marks every payment as simulated (see x402.PaymentPayload.Simulated) and
+	// only pays testnet requirements. Servers with AcceptSimulatedPayments serve such
+	// requests without verifying or settling the payment, so nothing is spent.
+	Simulate bool
+
+	// DPoPKey optionally binds payments to this P-256 key (see x402.BindingDPoP): they
+	// are signed with a nonce committing to the key's thumbprint, and the paid request
+	// carries a DPoP proof signed with the key, so servers with a BindingPolicy reject
+	// the X-PAYMENT header if it is presented by anyone else. Only EIP-3009 payments
+	// from signers implementing x402.BoundSigner are bound; others are sent unbound, and
+	// servers with a BindingPolicy reject unbound EIP-3009 payments.
+	DPoPKey *ecdsa.PrivateKey
+
 	// OnPaymentAttempt is called when a payment attempt is made.
 	OnPaymentAttempt x402.PaymentCallback
 
@@ -32,45 +137,269 @@ type X402Transport struct {
 
 	// OnPaymentFailure is called when a payment fails.
 	OnPaymentFailure x402.PaymentCallback
+
+	// signersMu guards Signers against SetSigners during RoundTrip.
+	signersMu sync.RWMutex
+
+	// paymentSlots enforces MaxConcurrentPayments.
+	paymentSlots paymentSlots
+
+	// flights tracks the payments in flight for SingleFlight.
+	flights paymentFlights
+
+	// hints holds the requirements advertised for PaymentHints.
+	hints paymentHints
+
+	// compactOrigins records whether origins accept CompactPayments: true once they
+	// advertise it, false once a compact header was refused.
+	compactOrigins sync.Map
+}
+
+// SetSigners atomically replaces the transport's signers. Requests already signing
+// finish with the previous signers; later requests use the new ones.
+func (t *X402Transport) SetSigners(signers ...x402.Signer) {
+	t.signersMu.Lock()
+	defer t.signersMu.Unlock()
+	t.Signers = signers
+	t.invalidateSelection()
+}
+
+// invalidateSelection drops cached signer choices if the selector caches them,
+// e.g. x402.CachingPaymentSelector.
+func (t *X402Transport) invalidateSelection() {
+	if cache, ok := t.Selector.(interface{ Invalidate() }); ok {
+		cache.Invalidate()
+	}
+}
+
+// currentSigners returns a snapshot of the transport's signers.
+func (t *X402Transport) currentSigners() []x402.Signer {
+	t.signersMu.RLock()
+	defer t.signersMu.RUnlock()
+	return t.Signers
 }
 
 // RoundTrip implements http.RoundTripper.
 // It makes the initial request, and if a 402 Payment Required response is received,
 // it automatically signs a payment and retries the request.
+//
+// Responses other than 402 are returned as received: without a PaymentDeadline the
+// initial request adds no allocations and the body is never buffered.
 func (t *X402Transport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Ensure we have a base transport
-	if t.Base == nil {
-		t.Base = http.DefaultTransport
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// Bound the whole flow by the payment deadline. The returned response body releases
+	// the deadline when closed; every other exit releases it here.
+	ctx := req.Context()
+	var cancel context.CancelFunc
+	if t.PaymentDeadline > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.PaymentDeadline)
 	}
+	returned := false
+	defer func() {
+		if cancel != nil && !returned {
+			cancel()
+		}
+	}()
 
-	// Clone the request to avoid modifying the original
-	reqCopy := req.Clone(req.Context())
+	// The first attempt is sent unmodified; only the retry needs a copy with a header added
+	first := req
+	if cancel != nil {
+		first = req.WithContext(ctx)
+	}
+	if t.expectContinue(req) {
+		first = first.Clone(first.Context())
+		first.Header.Set("Expect", "100-continue")
+	}
+
+	// Pay up front for resources whose requirements were advertised, and look out for
+	// advertised requirements in the response
+	_, pinned := t.RequirementsKeys[originOf(req.URL)]
+	hints := t.PaymentHints && !pinned
+	if hints {
+		resp, handled, err := t.payHinted(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if handled {
+			t.hints.collect(req.URL, resp.Header)
+			returned = true
+			return withCancel(resp, cancel), nil
+		}
+		first = t.withEarlyHints(first)
+	}
 
 	// Make the first attempt
-	resp, err := t.Base.RoundTrip(reqCopy)
+	resp, err := base.RoundTrip(first)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(err, x402.ErrCodeRequestTimeout, "request timed out")
 	}
 
 	// Check if payment is required
 	if resp.StatusCode != http.StatusPaymentRequired {
-		return resp, nil
+		if hints {
+			t.hints.collect(req.URL, resp.Header)
+		}
+		returned = true
+		return withCancel(resp, cancel), nil
 	}
 
-	// Parse payment requirements from 402 response
-	requirements, err := parsePaymentRequirements(resp)
+	requirements, err := t.paymentRequirements(req, resp)
 	if err != nil {
-		resp.Body.Close()
-		return nil, x402.NewPaymentError(x402.ErrCodeInvalidRequirements, "failed to parse payment requirements", err)
+		return nil, err
 	}
 
-	// Close the 402 response body
-	resp.Body.Close()
+	// Pay and retry, sharing one payment between identical concurrent requests if enabled
+	var respRetry *http.Response
+	if t.SingleFlight && singleFlightable(req) {
+		respRetry, err = t.payOnce(ctx, req, requirements)
+	} else {
+		respRetry, err = t.pay(ctx, req, requirements)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hints {
+		t.hints.collect(req.URL, respRetry.Header)
+	}
+
+	returned = true
+	return withCancel(respRetry, cancel), nil
+}
+
+// pay signs a payment for requirements and retries req with it. ctx bounds the flow.
+// A payment the server rejects with ReasonPaymentExpired, because its authorization
+// expired before settlement, is signed again and retried once. The new payment is for
+// the requirements already accepted, not for the ones offered with the rejection.
+// A payment rejected with ReasonPriceChanged is signed again, once, for the requirements
+// offered with the rejection, which are checked like those of the first 402.
+func (t *X402Transport) pay(ctx context.Context, req *http.Request, requirements []x402.PaymentRequirement) (*http.Response, error) {
+	resp, _, err := t.payRequirement(ctx, req, requirements, nil)
+	return resp, err
+}
+
+// payRequirement is pay, also returning the requirement paid, if known. If afford is
+// set, it narrows the requirements of the first 402 and of a price change to those the
+// caller can pay, or returns an error if there are none.
+func (t *X402Transport) payRequirement(ctx context.Context, req *http.Request, requirements []x402.PaymentRequirement, afford func([]x402.PaymentRequirement) ([]x402.PaymentRequirement, error)) (*http.Response, *x402.PaymentRequirement, error) {
+	if afford != nil {
+		var err error
+		if requirements, err = afford(requirements); err != nil {
+			return nil, nil, err
+		}
+	}
+	resp, paid, err := t.payAttempt(ctx, req, requirements)
+	if err == nil && priceChanged(resp) {
+		// The server prices this payer differently: pay its price, once
+		requirements, err = t.paymentRequirements(req, resp)
+		if err == nil && afford != nil {
+			requirements, err = afford(requirements)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, paid, err = t.payAttempt(ctx, req, requirements)
+		if err == nil && priceChanged(resp) {
+			resp.Body.Close()
+			return nil, nil, x402.NewPaymentError(x402.ErrCodeVerificationFailed, "payment rejected: "+ReasonPriceChanged, x402.ErrVerificationFailed).
+				WithDetails("reason", ReasonPriceChanged)
+		}
+	}
+	var paymentErr *x402.PaymentError
+	if errors.As(err, &paymentErr) && paymentErr.Details["reason"] == ReasonPaymentExpired {
+		return t.payAttempt(ctx, req, requirements)
+	}
+	return resp, paid, err
+}
+
+// payAttempt makes one payment attempt for pay. It also returns the requirement paid.
+func (t *X402Transport) payAttempt(ctx context.Context, req *http.Request, requirements []x402.PaymentRequirement) (*http.Response, *x402.PaymentRequirement, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// Simulated payments are only accepted on testnets
+	if t.Simulate {
+		requirements = testnetRequirements(requirements)
+		if len(requirements) == 0 {
+			return nil, nil, x402.NewPaymentError(x402.ErrCodeNoValidSigner, "no testnet payment option to simulate", x402.ErrNoValidSigner)
+		}
+	}
+
+	// Skip options that expire too soon to be settled
+	if t.MinimumValidityWindow > 0 {
+		requirements = longEnough(requirements, t.MinimumValidityWindow)
+		if len(requirements) == 0 {
+			return nil, nil, x402.NewPaymentError(x402.ErrCodeInvalidRequirements, "payment validity window too short", x402.ErrValidityWindowTooShort).
+				WithDetails("minimumValidityWindow", t.MinimumValidityWindow.String())
+		}
+	}
+
+	// Hold the payment addresses to the ones the origin was paid at before
+	if t.PayToPinning != nil {
+		pinned, err := t.PayToPinning.check(req, requirements)
+		if err != nil {
+			return nil, nil, x402.NewPaymentError(x402.ErrCodeInvalidRequirements, "failed to check payment recipient", err)
+		}
+		if len(pinned) == 0 {
+			return nil, nil, x402.NewPaymentError(x402.ErrCodePayToChanged, "payment recipient changed", x402.ErrPayToChanged)
+		}
+		requirements = pinned
+	}
+
+	// Wait for a payment slot for the destination host
+	if t.MaxConcurrentPayments > 0 {
+		release, err := t.paymentSlots.acquire(ctx, req.URL.Host, t.MaxConcurrentPayments)
+		if err != nil {
+			return nil, nil, timeoutError(err, x402.ErrCodeSigningTimeout, "payment deadline exceeded while waiting to pay")
+		}
+		defer release()
+	}
+
+	// Bind the payment to the DPoP key before the spending limit wraps the signers
+	signers := t.currentSigners()
+	if t.DPoPKey != nil {
+		thumbprint, err := DPoPThumbprint(&t.DPoPKey.PublicKey)
+		if err != nil {
+			return nil, nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "invalid DPoP key", err)
+		}
+		signers = x402.BindSigners(signers, x402.BindingDPoP, thumbprint)
+	}
+
+	// Reserve the payment amount against the spending limit before signing
+	releaseBudget := func() {}
+	if t.SpendingLimit != nil {
+		signers, releaseBudget = t.SpendingLimit.Signers(ctx, signers)
+	}
 
 	// Select signer and create payment
-	payment, err := t.Selector.SelectAndSign(requirements, t.Signers)
+	payment, err := t.Selector.SelectAndSign(requirements, signers)
 	if err != nil {
-		return nil, err
+		// A selector may fail after a signer reserved its amount
+		releaseBudget()
+
+		// Report failures to pay, e.g. an exhausted spending limit
+		if t.OnPaymentFailure != nil {
+			t.OnPaymentFailure(x402.PaymentEvent{
+				Type:      x402.PaymentEventFailure,
+				Timestamp: time.Now(),
+				Method:    "HTTP",
+				URL:       req.URL.String(),
+				Error:     err,
+			})
+		}
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		releaseBudget()
+		return nil, nil, timeoutError(err, x402.ErrCodeSigningTimeout, "payment deadline exceeded while signing")
+	}
+	if t.Simulate {
+		payment.Simulated = true
 	}
 
 	// Get the selected requirement for callback data
@@ -103,9 +432,14 @@ func (t *X402Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		t.OnPaymentAttempt(event)
 	}
 
-	// Build payment header
-	paymentHeader, err := buildPaymentHeader(payment)
+	// Build payment header, compact if the server accepts it
+	origin := originOf(req.URL)
+	accepted, _ := t.compactOrigins.Load(origin)
+	compact := t.CompactPayments && accepted == true
+	paymentHeader, err := buildPaymentHeader(payment, compact)
 	if err != nil {
+		releaseBudget()
+
 		// Trigger failure callback
 		if t.OnPaymentFailure != nil {
 			event := x402.PaymentEvent{
@@ -118,20 +452,77 @@ func (t *X402Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 			}
 			t.OnPaymentFailure(event)
 		}
-		return nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to build payment header", err)
+		return nil, nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to build payment header", err)
+	}
+
+	if t.Observer != nil {
+		t.Observer.ObservePayment(req, paymentHeader)
 	}
 
-	// Clone the request again for the retry
-	reqRetry := req.Clone(req.Context())
+	// paidRequest clones the request again for the retry, with the payment header
+	paidRequest := func(paymentHeader string, compact bool) (*http.Request, error) {
+		reqRetry := req.Clone(ctx)
 
-	// Add payment header
-	reqRetry.Header.Set("X-PAYMENT", paymentHeader)
+		// Resend the request body, which the first attempt consumed
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, x402.NewPaymentError(x402.ErrCodeNetworkError, "failed to rewind request body", err)
+			}
+			reqRetry.Body = body
+		}
+
+		// Add payment header
+		reqRetry.Header.Set("X-PAYMENT", paymentHeader)
+		if compact {
+			reqRetry.Header.Set(PaymentEncodingHeader, encoding.PaymentEncodingCBOR)
+		}
+		if payment.Binding != nil && payment.Binding.Method == x402.BindingDPoP {
+			proof, err := NewDPoPProof(t.DPoPKey, reqRetry.Method, reqRetry.URL.String())
+			if err != nil {
+				return nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to create DPoP proof", err)
+			}
+			reqRetry.Header.Set(DPoPHeader, proof)
+		}
+		return reqRetry, nil
+	}
+	reqRetry, err := paidRequest(paymentHeader, compact)
+	if err != nil {
+		releaseBudget()
+		return nil, nil, err
+	}
 
 	// Retry the request with payment
-	respRetry, err := t.Base.RoundTrip(reqRetry)
+	respRetry, err := base.RoundTrip(reqRetry)
+
+	// A server that cannot read the compact header, e.g. because a proxy dropped the
+	// encoding header, rejects it as malformed before verifying it; send the same payment
+	// as JSON instead
+	if err == nil && compact && malformedPayment(respRetry) && (req.Body == nil || req.GetBody != nil) {
+		t.compactOrigins.Store(origin, false)
+		respRetry.Body.Close()
+		if t.Observer != nil {
+			t.Observer.ObserveSettlement(req, respRetry.StatusCode, "")
+		}
+		paymentHeader, err = buildPaymentHeader(payment, false)
+		if err == nil {
+			reqRetry, err = paidRequest(paymentHeader, false)
+		}
+		if err != nil {
+			releaseBudget()
+			return nil, nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to build payment header", err)
+		}
+		if t.Observer != nil {
+			t.Observer.ObservePayment(req, paymentHeader)
+		}
+		respRetry, err = base.RoundTrip(reqRetry)
+	}
 	duration := time.Since(startTime)
 
 	if err != nil {
+		releaseBudget()
+		t.invalidateSelection()
+
 		// Trigger failure callback
 		if t.OnPaymentFailure != nil {
 			event := x402.PaymentEvent{
@@ -144,7 +535,70 @@ func (t *X402Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 			}
 			t.OnPaymentFailure(event)
 		}
-		return nil, err
+		return nil, nil, timeoutError(err, x402.ErrCodePaymentTimeout, "paid request timed out")
+	}
+
+	if t.Observer != nil {
+		t.Observer.ObserveSettlement(req, respRetry.StatusCode, respRetry.Header.Get("X-PAYMENT-RESPONSE"))
+	}
+
+	// The server does not settle payments for failed requests
+	if respRetry.StatusCode >= http.StatusBadRequest {
+		releaseBudget()
+		t.invalidateSelection()
+	}
+
+	// A replayed idempotent response means the server did not settle this payment
+	if respRetry.Header.Get(IdempotentReplayHeader) == "true" && respRetry.StatusCode < http.StatusBadRequest {
+		releaseBudget()
+	}
+
+	// Surface the facilitator's reason for rejecting the payment
+	if respRetry.StatusCode == http.StatusPaymentRequired {
+		if rejection := paymentRejection(respRetry); rejection != nil && rejection.Reason != ReasonPriceChanged {
+			respRetry.Body.Close()
+			paymentErr := x402.NewPaymentError(x402.ErrCodeVerificationFailed, "payment rejected: "+rejection.Reason, x402.ErrVerificationFailed).
+				WithDetails("reason", rejection.Reason)
+			if rejection.Payer != "" {
+				paymentErr = paymentErr.WithDetails("payer", rejection.Payer)
+			}
+			if t.OnPaymentFailure != nil {
+				t.OnPaymentFailure(x402.PaymentEvent{
+					Type:      x402.PaymentEventFailure,
+					Timestamp: time.Now(),
+					Method:    "HTTP",
+					URL:       req.URL.String(),
+					Error:     paymentErr,
+					Duration:  duration,
+				})
+			}
+			return nil, nil, paymentErr
+		}
+	}
+
+	// Reject settlement receipts not signed by the origin's pinned key
+	if key, ok := t.ReceiptKeys[originOf(req.URL)]; ok {
+		if receipt := respRetry.Header.Get("X-PAYMENT-RESPONSE"); receipt != "" {
+			if err := VerifyReceipt(key, paymentHeader, req.URL.String(), receipt, respRetry.Header.Get(ReceiptSignatureHeader)); err != nil {
+				respRetry.Body.Close()
+				if t.OnPaymentFailure != nil {
+					t.OnPaymentFailure(x402.PaymentEvent{
+						Type:      x402.PaymentEventFailure,
+						Timestamp: time.Now(),
+						Method:    "HTTP",
+						URL:       req.URL.String(),
+						Error:     err,
+						Duration:  duration,
+					})
+				}
+				return nil, nil, x402.NewPaymentError(x402.ErrCodeInvalidReceipt, "settlement receipt failed verification", err)
+			}
+		}
+	}
+
+	// Trust the address the origin was paid at
+	if t.PayToPinning != nil && selectedRequirement != nil && respRetry.StatusCode < http.StatusBadRequest {
+		t.PayToPinning.pin(req, *selectedRequirement)
 	}
 
 	// Parse settlement response
@@ -171,7 +625,92 @@ func (t *X402Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		t.OnPaymentSuccess(event)
 	}
 
-	return respRetry, nil
+	return respRetry, selectedRequirement, nil
+}
+
+// paymentRequirements reads the payment requirements of resp, a 402 response to req,
+// and closes its body. The raw body is shown to the Observer, and its signature checked
+// if req's origin is in RequirementsKeys.
+func (t *X402Transport) paymentRequirements(req *http.Request, resp *http.Response) ([]x402.PaymentRequirement, error) {
+	// Large 402 bodies may be compressed
+	if err := decodeContentEncoding(resp); err != nil {
+		resp.Body.Close()
+		return nil, x402.NewPaymentError(x402.ErrCodeInvalidRequirements, "failed to parse payment requirements", fmt.Errorf("failed to decompress response body: %w", err))
+	}
+	if t.CompactPayments && acceptsCBORPayments(resp) {
+		t.compactOrigins.LoadOrStore(originOf(req.URL), true)
+	}
+
+	// Show the raw 402 body to the observer and check its signature before parsing it
+	key, pinned := t.RequirementsKeys[originOf(req.URL)]
+	if t.Observer != nil || pinned {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, x402.NewPaymentError(x402.ErrCodeInvalidRequirements, "failed to parse payment requirements", fmt.Errorf("failed to read response body: %w", err))
+		}
+		if t.Observer != nil {
+			t.Observer.ObservePaymentRequired(req, body)
+		}
+		if pinned {
+			if err := VerifyRequirements(key, req.Method, req.URL.String(), body, resp.Header.Get(RequirementsSignatureHeader)); err != nil {
+				return nil, x402.NewPaymentError(x402.ErrCodeInvalidRequirementsSignature, "payment requirements failed verification", err)
+			}
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// Parse payment requirements from 402 response
+	requirements, err := parsePaymentRequirements(resp)
+	resp.Body.Close()
+	if err != nil {
+		return nil, x402.NewPaymentError(x402.ErrCodeInvalidRequirements, "failed to parse payment requirements", err)
+	}
+	return requirements, nil
+}
+
+// expectContinue reports whether the first attempt of req should wait for 100 Continue
+// before sending its body.
+func (t *X402Transport) expectContinue(req *http.Request) bool {
+	threshold := t.ExpectContinueThreshold
+	if threshold == 0 {
+		threshold = DefaultExpectContinueThreshold
+	}
+	if threshold < 0 || req.GetBody == nil || req.Body == nil || req.Body == http.NoBody || req.Header.Get("Expect") != "" {
+		return false
+	}
+	return req.ContentLength < 0 || req.ContentLength >= threshold
+}
+
+// timeoutError wraps err in a PaymentError with code if it is a deadline error,
+// and returns it unchanged otherwise.
+func timeoutError(err error, code x402.ErrorCode, message string) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return x402.NewPaymentError(code, message, err)
+	}
+	return err
+}
+
+// withCancel makes closing resp's body call cancel, if set.
+func withCancel(resp *http.Response, cancel context.CancelFunc) *http.Response {
+	if cancel == nil {
+		return resp
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp
+}
+
+// cancelBody releases a context when the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 // parsePaymentRequirements extracts payment requirements from a 402 response.
@@ -229,8 +768,33 @@ func parsePaymentRequirements(resp *http.Response) ([]x402.PaymentRequirement, e
 	return requirements, nil
 }
 
+// longEnough returns the requirements whose validity window is at least window.
+func longEnough(requirements []x402.PaymentRequirement, window time.Duration) []x402.PaymentRequirement {
+	var long []x402.PaymentRequirement
+	for _, requirement := range requirements {
+		if time.Duration(requirement.MaxTimeoutSeconds)*time.Second >= window {
+			long = append(long, requirement)
+		}
+	}
+	return long
+}
+
+// testnetRequirements returns the requirements on testnets.
+func testnetRequirements(requirements []x402.PaymentRequirement) []x402.PaymentRequirement {
+	var testnet []x402.PaymentRequirement
+	for _, requirement := range requirements {
+		if x402.IsTestnet(requirement.Network) {
+			testnet = append(testnet, requirement)
+		}
+	}
+	return testnet
+}
+
 // buildPaymentHeader creates the X-PAYMENT header value from a payment payload.
-func buildPaymentHeader(payment *x402.PaymentPayload) (string, error) {
+func buildPaymentHeader(payment *x402.PaymentPayload, compact bool) (string, error) {
+	if compact {
+		return encoding.EncodePaymentCBOR(*payment)
+	}
 	return encoding.EncodePayment(*payment)
 }
 
@@ -245,10 +809,76 @@ func parseSettlement(headerValue string) (*x402.SettlementResponse, error) {
 }
 
 // RequestWithBody clones an HTTP request with a new body.
-// This is needed because request bodies can only be read once.
+// This is needed because request bodies can only be read once. The clone's GetBody
+// returns the body again, so it can be resent with a payment after a 402.
 func RequestWithBody(req *http.Request, body []byte) *http.Request {
 	clone := req.Clone(req.Context())
 	clone.Body = io.NopCloser(bytes.NewReader(body))
 	clone.ContentLength = int64(len(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
 	return clone
 }
+
+// priceChanged reports whether resp is a 402 offering the payer's own requirements for a
+// payment signed at another price. It leaves resp.Body readable.
+func priceChanged(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusPaymentRequired {
+		return false
+	}
+	rejection := paymentRejection(resp)
+	return rejection != nil && rejection.Reason == ReasonPriceChanged
+}
+
+// malformedPayment reports whether resp is the middleware's 400 for an X-PAYMENT header
+// it could not parse: an ErrorResponse with x402.ErrCodeMalformedHeader, or the plain
+// text error of the net/http middleware. It leaves resp.Body readable.
+func malformedPayment(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	data, err := peekBody(resp)
+	if err != nil {
+		return false
+	}
+
+	var body ErrorResponse
+	if json.Unmarshal(data, &body) == nil {
+		return body.Code == x402.ErrCodeMalformedHeader
+	}
+	return strings.TrimSpace(string(data)) == malformedHeaderMessage
+}
+
+// maxRejectionBodySize bounds how much of the error response to a paid request is read
+// for the facilitator's rejection reason or the middleware's malformed header error.
+const maxRejectionBodySize = 64 << 10
+
+// peekBody reads up to maxRejectionBodySize bytes of resp.Body and puts them back in
+// front of the rest.
+func peekBody(resp *http.Response) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRejectionBodySize))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	return data, err
+}
+
+// paymentRejection returns the 402 body of a paid request if it carries the reason the
+// facilitator rejected the payment. Otherwise it restores resp.Body and returns nil.
+func paymentRejection(resp *http.Response) *x402.PaymentRequirementsResponse {
+	if decodeContentEncoding(resp) != nil {
+		return nil
+	}
+	data, err := peekBody(resp)
+	if err != nil {
+		return nil
+	}
+
+	var body x402.PaymentRequirementsResponse
+	if json.Unmarshal(data, &body) != nil || body.Reason == "" {
+		return nil
+	}
+	return &body
+}
@@ -1,20 +1,25 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
 )
 
 // Helper function to create a proper PaymentRequirementsResponse as per x402 spec
@@ -114,6 +119,289 @@ func TestRoundTrip_PaymentRequired(t *testing.T) {
 	}
 }
 
+func TestRoundTrip_PaymentRequiredResendsBody(t *testing.T) {
+	var paidBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+				MaxAmountRequired: "100000",
+				PayTo:             "0x1234567890123456789012345678901234567890",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		paidBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := &X402Transport{
+		Base:     http.DefaultTransport,
+		Signers:  []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+		Selector: x402.NewDefaultPaymentSelector(),
+	}
+
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader(`{"model":"gpt-4o"}`))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if paidBody != `{"model":"gpt-4o"}` {
+		t.Errorf("expected paid request to carry the body, got %q", paidBody)
+	}
+}
+
+// countingConn counts the bytes written to a connection.
+type countingConn struct {
+	net.Conn
+	sent *atomic.Int64
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.sent.Add(int64(n))
+	return n, err
+}
+
+func TestRoundTrip_ExpectContinueUploadsBodyOnce(t *testing.T) {
+	const size = 1 << 20
+	var sent atomic.Int64
+	var paidBody int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Like the middleware, answer unpaid requests from the headers alone
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+				MaxAmountRequired: "100000",
+				PayTo:             "0x1234567890123456789012345678901234567890",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		paidBody = len(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	base := &http.Transport{
+		ExpectContinueTimeout: 5 * time.Second,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &countingConn{Conn: conn, sent: &sent}, nil
+		},
+	}
+	transport := &X402Transport{
+		Base:     base,
+		Signers:  []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+		Selector: x402.NewDefaultPaymentSelector(),
+	}
+
+	req, _ := http.NewRequest("POST", server.URL, bytes.NewReader(make([]byte, size)))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || paidBody != size {
+		t.Fatalf("expected paid request with %d byte body, got status %d and %d bytes", size, resp.StatusCode, paidBody)
+	}
+	if got := sent.Load(); got > size+16<<10 {
+		t.Errorf("client sent %d bytes for a %d byte body sent once", got, size)
+	}
+}
+
+func TestRoundTrip_ExpectContinueThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int64
+		body      io.Reader
+		want      bool
+	}{
+		{name: "small body", body: strings.NewReader("small")},
+		{name: "body at default threshold", body: bytes.NewReader(make([]byte, DefaultExpectContinueThreshold)), want: true},
+		{name: "body above custom threshold", threshold: 4, body: strings.NewReader("small"), want: true},
+		{name: "disabled", threshold: -1, body: bytes.NewReader(make([]byte, DefaultExpectContinueThreshold))},
+		{name: "no body", threshold: 1},
+		{name: "body that cannot be rewound", threshold: 1, body: io.MultiReader(strings.NewReader("small"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			transport := &X402Transport{
+				Base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+					got = append(got, r.Header.Get("Expect"))
+					return stubResponse(http.StatusOK, nil), nil
+				}),
+				ExpectContinueThreshold: tt.threshold,
+			}
+
+			req, _ := http.NewRequest("POST", "http://api.example.com/upload", tt.body)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if (got[0] == "100-continue") != tt.want {
+				t.Errorf("Expect header = %q, want 100-continue: %v", got[0], tt.want)
+			}
+			if req.Header.Get("Expect") != "" {
+				t.Error("the caller's request was modified")
+			}
+		})
+	}
+}
+
+func TestRoundTrip_PaymentRejected(t *testing.T) {
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		MaxAmountRequired: "100000",
+		PayTo:             "0x1234567890123456789012345678901234567890",
+		MaxTimeoutSeconds: 60,
+	}
+
+	tests := []struct {
+		name       string
+		reason     string
+		wantReason string
+	}{
+		{name: "facilitator reason", reason: "invalid_exact_evm_payload_authorization_valid_before", wantReason: "invalid_exact_evm_payload_authorization_valid_before"},
+		{name: "no reason returns the 402", reason: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusPaymentRequired)
+				body := x402.PaymentRequirementsResponse{X402Version: 1, Accepts: []x402.PaymentRequirement{requirement}}
+				if r.Header.Get("X-PAYMENT") != "" {
+					body.Reason = tt.reason
+					body.Payer = "0xpayer"
+				}
+				_ = json.NewEncoder(w).Encode(body)
+			}))
+			defer server.Close()
+
+			var failures int
+			transport := &X402Transport{
+				Base:             http.DefaultTransport,
+				Signers:          []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+				Selector:         x402.NewDefaultPaymentSelector(),
+				OnPaymentFailure: func(x402.PaymentEvent) { failures++ },
+			}
+
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("RoundTrip failed: %v", err)
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				if resp.StatusCode != http.StatusPaymentRequired || !json.Valid(body) {
+					t.Errorf("expected the 402 response with its body, got %d %q", resp.StatusCode, body)
+				}
+				return
+			}
+
+			var paymentErr *x402.PaymentError
+			if !errors.As(err, &paymentErr) {
+				t.Fatalf("expected PaymentError, got %v", err)
+			}
+			if paymentErr.Details["reason"] != tt.wantReason || paymentErr.Details["payer"] != "0xpayer" {
+				t.Errorf("unexpected details: %v", paymentErr.Details)
+			}
+			if !errors.Is(err, x402.ErrVerificationFailed) {
+				t.Errorf("expected ErrVerificationFailed, got %v", err)
+			}
+			if failures != 1 {
+				t.Errorf("expected 1 failure callback, got %d", failures)
+			}
+		})
+	}
+}
+
+// networkSigner is a mockSigner that only signs requirements on its network.
+type networkSigner struct{ mockSigner }
+
+func (s *networkSigner) CanSign(req *x402.PaymentRequirement) bool { return req.Network == s.network }
+
+func TestRoundTrip_Simulate(t *testing.T) {
+	requirements := []x402.PaymentRequirement{
+		{Scheme: "exact", Network: "base", Asset: "0xmainnet", MaxAmountRequired: "100", PayTo: "0xpayto", MaxTimeoutSeconds: 60},
+		{Scheme: "exact", Network: "base-sepolia", Asset: "0xtestnet", MaxAmountRequired: "100", PayTo: "0xpayto", MaxTimeoutSeconds: 60},
+	}
+
+	tests := []struct {
+		name         string
+		requirements []x402.PaymentRequirement
+		wantErr      bool
+	}{
+		{name: "pays the testnet option", requirements: requirements},
+		{name: "no testnet option", requirements: requirements[:1], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paid x402.PaymentPayload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if header := r.Header.Get("X-PAYMENT"); header != "" {
+					paid, _ = encoding.DecodePayment(header)
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(http.StatusPaymentRequired)
+				_ = json.NewEncoder(w).Encode(x402.PaymentRequirementsResponse{X402Version: 1, Accepts: tt.requirements})
+			}))
+			defer server.Close()
+
+			transport := &X402Transport{
+				Base: http.DefaultTransport,
+				Signers: []x402.Signer{
+					&networkSigner{mockSigner{network: "base", scheme: "exact"}},
+					&networkSigner{mockSigner{network: "base-sepolia", scheme: "exact"}},
+				},
+				Selector: x402.NewDefaultPaymentSelector(),
+				Simulate: true,
+			}
+
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if tt.wantErr {
+				if !errors.Is(err, x402.ErrNoValidSigner) {
+					t.Fatalf("expected ErrNoValidSigner, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RoundTrip failed: %v", err)
+			}
+			resp.Body.Close()
+			if !paid.Simulated || paid.Network != "base-sepolia" {
+				t.Errorf("expected a simulated base-sepolia payment, got %+v", paid)
+			}
+		})
+	}
+}
+
 func TestRoundTrip_NoValidSigner(t *testing.T) {
 	// Server returns 402 requiring payment
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -224,7 +512,7 @@ func TestBuildPaymentHeader(t *testing.T) {
 		},
 	}
 
-	header, err := buildPaymentHeader(payment)
+	header, err := buildPaymentHeader(payment, false)
 	if err != nil {
 		t.Fatalf("buildPaymentHeader failed: %v", err)
 	}
@@ -1732,3 +2020,383 @@ func TestWithPaymentCallback(t *testing.T) {
 		}
 	})
 }
+
+func TestRoundTrip_SpendingLimit(t *testing.T) {
+	var handlerStatus atomic.Int32
+	handlerStatus.Store(http.StatusOK)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "100000",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		w.WriteHeader(int(handlerStatus.Load()))
+	}))
+	defer server.Close()
+
+	limit, err := x402.NewSpendingLimit("200000", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("NewSpendingLimit failed: %v", err)
+	}
+	var budgetFailures int
+	transport := &X402Transport{
+		Base:          http.DefaultTransport,
+		Signers:       []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+		Selector:      x402.NewDefaultPaymentSelector(),
+		SpendingLimit: limit,
+		OnPaymentFailure: func(event x402.PaymentEvent) {
+			if errors.Is(event.Error, x402.ErrBudgetExceeded) {
+				budgetFailures++
+			}
+		},
+	}
+
+	steps := []struct {
+		name          string
+		handlerStatus int
+		wantErr       error
+	}{
+		{"first payment", http.StatusOK, nil},
+		// Failed requests are not settled, so their reservation is released
+		{"failed request", http.StatusInternalServerError, nil},
+		{"second payment", http.StatusOK, nil},
+		{"over budget", http.StatusOK, x402.ErrBudgetExceeded},
+	}
+
+	for _, step := range steps {
+		handlerStatus.Store(int32(step.handlerStatus))
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := transport.RoundTrip(req)
+		if step.wantErr != nil {
+			if !errors.Is(err, step.wantErr) {
+				t.Errorf("%s: expected %v, got %v", step.name, step.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: RoundTrip failed: %v", step.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != step.handlerStatus {
+			t.Errorf("%s: expected status %d, got %d", step.name, step.handlerStatus, resp.StatusCode)
+		}
+	}
+	if budgetFailures != 1 {
+		t.Errorf("expected 1 budget failure callback, got %d", budgetFailures)
+	}
+}
+
+// signThenFailSelector signs with the first signer, then fails.
+type signThenFailSelector struct{}
+
+func (signThenFailSelector) SelectAndSign(requirements []x402.PaymentRequirement, signers []x402.Signer) (*x402.PaymentPayload, error) {
+	if _, err := signers[0].Sign(&requirements[0]); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("selection failed")
+}
+
+func TestRoundTrip_SpendingLimitSelectorFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "100000",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limit, err := x402.NewSpendingLimit("100000", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("NewSpendingLimit failed: %v", err)
+	}
+	transport := &X402Transport{
+		Base:          http.DefaultTransport,
+		Signers:       []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+		Selector:      signThenFailSelector{},
+		SpendingLimit: limit,
+	}
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Fatal("expected the selector failure")
+	}
+
+	// The failed attempt gave its reservation back, so the whole budget is left
+	transport.Selector = x402.NewDefaultPaymentSelector()
+	req, _ = http.NewRequest("GET", server.URL, nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestRoundTrip_MaxConcurrentPayments(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "100000",
+				MaxTimeoutSeconds: 60,
+			}))
+			return
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := &X402Transport{
+		Base:                  http.DefaultTransport,
+		Signers:               []x402.Signer{&mockSigner{network: "base", scheme: "exact", canSignValue: true}},
+		Selector:              x402.NewDefaultPaymentSelector(),
+		MaxConcurrentPayments: 2,
+	}
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Errorf("RoundTrip failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	for inFlight.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A request waiting for a slot fails when its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	_, err := transport.RoundTrip(req)
+	var paymentErr *x402.PaymentError
+	if !errors.As(err, &paymentErr) || paymentErr.Code != x402.ErrCodeSigningTimeout {
+		t.Errorf("expected a signing timeout while waiting for a slot, got %v", err)
+	}
+
+	// Further requests queue behind the two in flight
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Errorf("RoundTrip failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	close(unblock)
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got != 2 {
+		t.Errorf("max paid requests in flight = %d, want 2", got)
+	}
+	if len(transport.paymentSlots.hosts) != 0 {
+		t.Errorf("payment slots not released: %v", transport.paymentSlots.hosts)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// stubResponse returns an in-memory response, so benchmarks measure x402 overhead
+// rather than the network stack.
+func stubResponse(status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// BenchmarkRoundTrip_PaymentRequired measures a full 402 -> sign -> retry cycle with a
+// mock signer and an in-memory server (target: <50µs/op).
+func BenchmarkRoundTrip_PaymentRequired(b *testing.B) {
+	requirements := makePaymentRequirementsResponse(x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		MaxAmountRequired: "100000",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	})
+
+	transport := &X402Transport{
+		Base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Header.Get("X-PAYMENT") == "" {
+				return stubResponse(http.StatusPaymentRequired, requirements), nil
+			}
+			return stubResponse(http.StatusOK, []byte("success")), nil
+		}),
+		Signers: []x402.Signer{
+			&mockSigner{network: "base", scheme: "exact", canSignValue: true},
+		},
+		Selector: x402.NewDefaultPaymentSelector(),
+	}
+
+	req, _ := http.NewRequest("GET", "http://api.example.com/data", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			b.Fatalf("RoundTrip failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+}
+
+// TestRoundTrip_NonPaymentZeroAlloc tests that unpaid responses pass through without
+// allocations on top of the base transport.
+func TestRoundTrip_NonPaymentZeroAlloc(t *testing.T) {
+	ok := &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}
+	transport := &X402Transport{
+		Base:     roundTripFunc(func(*http.Request) (*http.Response, error) { return ok, nil }),
+		Selector: x402.NewDefaultPaymentSelector(),
+	}
+	req, _ := http.NewRequest("GET", "http://api.example.com/data", nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		resp, err := transport.RoundTrip(req)
+		if err != nil || resp != ok {
+			t.Fatalf("unexpected response %v, %v", resp, err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations, got %v", allocs)
+	}
+}
+
+// BenchmarkRoundTrip_NonPayment compares an unpaid request through X402Transport with
+// the same request sent straight to the base transport (target: <1µs/op overhead,
+// 0 extra allocs).
+func BenchmarkRoundTrip_NonPayment(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("success"))
+	}))
+	defer server.Close()
+
+	base := &http.Transport{}
+	defer base.CloseIdleConnections()
+	transport := &X402Transport{
+		Base:     base,
+		Selector: x402.NewDefaultPaymentSelector(),
+	}
+	req, _ := http.NewRequest("GET", server.URL, nil)
+
+	for _, bc := range []struct {
+		name string
+		rt   http.RoundTripper
+	}{
+		{"raw", base},
+		{"x402", transport},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				resp, err := bc.rt.RoundTrip(req)
+				if err != nil {
+					b.Fatalf("RoundTrip failed: %v", err)
+				}
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestRoundTrip_MinimumValidityWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxTimeout int
+		wantErr    error
+	}{
+		{"long enough", 120, nil},
+		{"too short", 30, x402.ErrValidityWindowTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paid atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-PAYMENT") == "" {
+					w.WriteHeader(http.StatusPaymentRequired)
+					_, _ = w.Write(makePaymentRequirementsResponse(x402.PaymentRequirement{
+						Scheme:            "exact",
+						Network:           "base",
+						MaxAmountRequired: "1000",
+						MaxTimeoutSeconds: tt.maxTimeout,
+					}))
+					return
+				}
+				paid.Add(1)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(
+				WithSigner(&mockSigner{network: "base", scheme: "exact", canSignValue: true}),
+				WithMinimumValidityWindow(time.Minute),
+			)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(server.URL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				if paid.Load() != 0 {
+					t.Error("client paid a requirement with a too short window")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if paid.Load() != 1 {
+				t.Errorf("expected 1 paid request, got %d", paid.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,574 @@
+// Package invoice lets servers bill for something paid later rather than in the request
+// that needs it. A Manager mints invoices, each holding an ID, a payment requirement and
+// an expiry, and hands out their URL or ID. Clients pay an invoice at its URL (or on
+// any protected route, naming it in the X-X402-Invoice header) through the x402
+// middleware, which asks for the invoice's requirement and matches the settled payment
+// back to the invoice. Each invoice goes from created to paid or expired, and every
+// state change is posted to a webhook.
+//
+//	invoices, err := invoice.New(invoice.NewMemoryStore(),
+//	    invoice.WithBaseURL("https://api.example.com/invoices/"),
+//	    invoice.WithWebhook("https://shop.example.com/hooks/invoices"),
+//	)
+//	config.RequirementsFunc = invoices.Requirements
+//	config.FacilitatorOnAfterSettle = invoices.OnAfterSettle
+//	mux.Handle("/invoices/", x402http.NewX402Middleware(config)(invoices.Handler()))
+//	go invoices.Run(ctx)
+//
+//	inv, err := invoices.Create(ctx, requirement, time.Hour)
+//	// send invoices.URL(inv) to the customer
+package invoice
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	x402http "github.com/mark3labs/x402-go/http"
+)
+
+// Header is the request header naming the invoice a payment is for.
+const Header = "X-X402-Invoice"
+
+const (
+	// DefaultTTL is how long invoices can be paid by default.
+	DefaultTTL = 24 * time.Hour
+
+	// DefaultInterval is how often Run expires overdue invoices by default.
+	DefaultInterval = time.Minute
+
+	// DefaultBasePath is the URL path under which invoices are served by default.
+	DefaultBasePath = "/invoices/"
+)
+
+var (
+	// ErrNotFound indicates no invoice has the given ID.
+	ErrNotFound = errors.New("x402: invoice not found")
+
+	// ErrInvoicePaid indicates an invoice was already paid.
+	ErrInvoicePaid = errors.New("x402: invoice already paid")
+
+	// ErrInvoiceExpired indicates an invoice expired before it was paid.
+	ErrInvoiceExpired = errors.New("x402: invoice expired")
+)
+
+// Status is the lifecycle state of an invoice.
+type Status string
+
+const (
+	// StatusCreated means the invoice is waiting for payment.
+	StatusCreated Status = "created"
+
+	// StatusPaid means the invoice was paid.
+	StatusPaid Status = "paid"
+
+	// StatusExpired means the invoice expired before it was paid.
+	StatusExpired Status = "expired"
+)
+
+// Invoice is a payment requested from a client, to be paid before it expires. It is the
+// body of webhook notifications.
+type Invoice struct {
+	ID string `json:"id"`
+
+	// Requirement is the payment requirement of the invoice. Its reference is the
+	// invoice ID (see x402.ExtraReference).
+	Requirement x402.PaymentRequirement `json:"requirement"`
+
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// PaidAt, Payer and Transaction describe the payment of a paid invoice.
+	PaidAt      time.Time `json:"paidAt,omitempty"`
+	Payer       string    `json:"payer,omitempty"`
+	Transaction string    `json:"transaction,omitempty"`
+}
+
+// Store stores invoices. Implementations must be safe for concurrent use; share one
+// store between replicas so any of them can take an invoice's payment.
+type Store interface {
+	// Create stores a new invoice.
+	Create(ctx context.Context, invoice Invoice) error
+
+	// Get returns the invoice with id, or false if there is none.
+	Get(ctx context.Context, id string) (Invoice, bool, error)
+
+	// Update replaces the invoice with the same ID if its status is still from, and
+	// reports whether it did, so each invoice changes state once.
+	Update(ctx context.Context, invoice Invoice, from Status) (bool, error)
+
+	// Open returns the invoices with StatusCreated.
+	Open(ctx context.Context) ([]Invoice, error)
+}
+
+// Manager mints invoices and tracks them from creation to payment or expiry. Manager
+// is safe for concurrent use.
+type Manager struct {
+	store      Store
+	baseURL    string
+	basePath   string
+	ttl        time.Duration
+	interval   time.Duration
+	webhookURL string
+	httpClient *http.Client
+	logger     *slog.Logger
+	now        func() time.Time
+}
+
+// Option configures a Manager.
+type Option func(*Manager) error
+
+// New creates a Manager keeping invoices in store.
+func New(store Store, opts ...Option) (*Manager, error) {
+	if store == nil {
+		return nil, errors.New("invoice: store is nil")
+	}
+	m := &Manager{
+		store:      store,
+		basePath:   DefaultBasePath,
+		ttl:        DefaultTTL,
+		interval:   DefaultInterval,
+		httpClient: http.DefaultClient,
+		logger:     slog.Default(),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// WithBaseURL sets the URL invoices are served under, e.g.
+// "https://api.example.com/invoices/", which URL prefixes to invoice IDs. Its path
+// replaces DefaultBasePath as the path under which Requirements finds invoice IDs.
+func WithBaseURL(baseURL string) Option {
+	return func(m *Manager) error {
+		u, err := url.Parse(baseURL)
+		if err != nil || !u.IsAbs() {
+			return fmt.Errorf("invoice: base URL %q is not an absolute URL", baseURL)
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		m.baseURL = u.String()
+		m.basePath = u.Path
+		return nil
+	}
+}
+
+// WithTTL sets how long invoices created without a TTL can be paid (default DefaultTTL).
+func WithTTL(d time.Duration) Option {
+	return func(m *Manager) error {
+		if d <= 0 {
+			return fmt.Errorf("invoice: TTL must be positive, got %v", d)
+		}
+		m.ttl = d
+		return nil
+	}
+}
+
+// WithInterval sets how often Run expires overdue invoices (default DefaultInterval).
+func WithInterval(d time.Duration) Option {
+	return func(m *Manager) error {
+		if d <= 0 {
+			return fmt.Errorf("invoice: interval must be positive, got %v", d)
+		}
+		m.interval = d
+		return nil
+	}
+}
+
+// WithWebhook sets a URL that receives each invoice as a JSON POST request when it is
+// created, paid or expired.
+func WithWebhook(webhookURL string) Option {
+	return func(m *Manager) error {
+		m.webhookURL = webhookURL
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for webhook notifications
+// (default http.DefaultClient).
+func WithHTTPClient(client *http.Client) Option {
+	return func(m *Manager) error {
+		if client == nil {
+			return errors.New("invoice: HTTP client is nil")
+		}
+		m.httpClient = client
+		return nil
+	}
+}
+
+// WithLogger sets the logger (default slog.Default()).
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Manager) error {
+		if logger == nil {
+			return errors.New("invoice: logger is nil")
+		}
+		m.logger = logger
+		return nil
+	}
+}
+
+// Create mints an invoice for requirement that can be paid for ttl, or the Manager's
+// TTL if ttl is zero. The requirement's reference is set to the invoice ID.
+func (m *Manager) Create(ctx context.Context, requirement x402.PaymentRequirement, ttl time.Duration) (*Invoice, error) {
+	if amount, ok := new(big.Int).SetString(requirement.MaxAmountRequired, 10); !ok || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: invoice amount %q", x402.ErrInvalidAmount, requirement.MaxAmountRequired)
+	}
+	if ttl <= 0 {
+		ttl = m.ttl
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	requirement.SetReference(id)
+
+	now := m.now()
+	invoice := Invoice{
+		ID:          id,
+		Requirement: requirement,
+		Status:      StatusCreated,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	if err := m.store.Create(ctx, invoice); err != nil {
+		return nil, fmt.Errorf("invoice: failed to store invoice: %w", err)
+	}
+	m.notify(ctx, invoice)
+	return &invoice, nil
+}
+
+// URL returns the URL of invoice under the base URL, or its ID if no base URL is set.
+func (m *Manager) URL(invoice *Invoice) string {
+	return m.baseURL + invoice.ID
+}
+
+// Get returns the invoice with id, expiring it first if it is overdue. It returns an
+// error wrapping ErrNotFound if there is none.
+func (m *Manager) Get(ctx context.Context, id string) (*Invoice, error) {
+	invoice, ok, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("invoice: failed to get invoice: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if invoice.Status == StatusCreated && !m.now().Before(invoice.ExpiresAt) {
+		if err := m.expire(ctx, &invoice); err != nil {
+			return nil, err
+		}
+	}
+	return &invoice, nil
+}
+
+// MarkPaid records the payment of the invoice with id by payer in transaction. It
+// returns an error wrapping ErrInvoicePaid or ErrInvoiceExpired if the invoice is no
+// longer open.
+func (m *Manager) MarkPaid(ctx context.Context, id, payer, transaction string) (*Invoice, error) {
+	invoice, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := invoice.open(); err != nil {
+		return nil, err
+	}
+
+	paid := *invoice
+	paid.Status = StatusPaid
+	paid.PaidAt = m.now()
+	paid.Payer = payer
+	paid.Transaction = transaction
+	updated, err := m.store.Update(ctx, paid, StatusCreated)
+	if err != nil {
+		return nil, fmt.Errorf("invoice: failed to update invoice: %w", err)
+	}
+	if !updated {
+		// Paid or expired concurrently; report the state that won
+		if current, err := m.Get(ctx, id); err == nil {
+			return nil, current.open()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvoicePaid, id)
+	}
+	m.notify(ctx, paid)
+	return &paid, nil
+}
+
+// Requirements matches requests to invoices. It matches RequirementsFunc, so it can be
+// set as the middleware's Config.RequirementsFunc. A request for an invoice, named by
+// the last segment of a path under the base path or by the X-X402-Invoice header, must
+// pay the invoice's requirement; an unknown, paid or expired invoice is rejected.
+// Other requests keep base.
+func (m *Manager) Requirements(ctx context.Context, req x402http.EngineRequest, base []x402.PaymentRequirement) ([]x402.PaymentRequirement, error) {
+	id := m.requestID(req)
+	if id == "" {
+		return base, nil
+	}
+	invoice, err := m.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("unknown invoice %s", id)
+		}
+		return nil, err
+	}
+	if err := invoice.open(); err != nil {
+		return nil, fmt.Errorf("invoice %s is %s", id, invoice.Status)
+	}
+
+	requirement := invoice.Requirement
+	requirement.Resource = req.ResourceURL
+	if requirement.Description == "" {
+		requirement.Description = "Payment of invoice " + id
+	}
+	return []x402.PaymentRequirement{requirement}, nil
+}
+
+// OnAfterSettle marks the invoices of settled payments paid. It matches
+// http.OnAfterSettleFunc, so it can be set as Config.FacilitatorOnAfterSettle.
+// Settlements of requirements that are not an invoice's are ignored.
+func (m *Manager) OnAfterSettle(ctx context.Context, _ x402.PaymentPayload, requirement x402.PaymentRequirement, settlement *x402.SettlementResponse, err error) {
+	id := requirement.Reference()
+	if err != nil || settlement == nil || !settlement.Success || settlement.Simulated || id == "" {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if _, err := m.MarkPaid(ctx, id, settlement.Payer, settlement.Transaction); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return
+		}
+		// The payment is settled but the invoice was no longer open
+		m.logger.Error("settled payment for closed invoice", "invoice", id, "transaction", settlement.Transaction, "error", err)
+	}
+}
+
+// Handler serves invoices as JSON at the base path followed by their ID. Behind the
+// x402 middleware with Requirements, a request for an open invoice must pay it first;
+// without it, the handler reports the status of invoices to clients polling them.
+func (m *Manager) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := m.requestID(x402http.EngineRequest{Path: r.URL.Path, Header: r.Header})
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := m.Get(r.Context(), id); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			m.logger.Error("failed to get invoice", "invoice", id, "error", err)
+			http.Error(w, "failed to get invoice", http.StatusInternalServerError)
+			return
+		}
+
+		// Behind the middleware, writing the status settles the payment and marks the
+		// invoice paid, so the invoice is read again after it
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		invoice, err := m.Get(r.Context(), id)
+		if err != nil {
+			m.logger.Error("failed to get invoice", "invoice", id, "error", err)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(invoice)
+	})
+}
+
+// Run expires overdue invoices every interval until ctx is done, and returns ctx.Err().
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := m.ExpireOverdue(ctx); err != nil && ctx.Err() == nil {
+				m.logger.Error("failed to expire invoices", "error", err)
+			}
+		}
+	}
+}
+
+// ExpireOverdue expires every open invoice past its expiry and returns how many it
+// expired.
+func (m *Manager) ExpireOverdue(ctx context.Context) (int, error) {
+	open, err := m.store.Open(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("invoice: failed to list open invoices: %w", err)
+	}
+	now := m.now()
+	expired := 0
+	var errs []error
+	for i := range open {
+		if now.Before(open[i].ExpiresAt) {
+			continue
+		}
+		if err := m.expire(ctx, &open[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if open[i].Status == StatusExpired {
+			expired++
+		}
+	}
+	return expired, errors.Join(errs...)
+}
+
+// expire moves the open invoice to StatusExpired, or to the state it reached
+// concurrently.
+func (m *Manager) expire(ctx context.Context, invoice *Invoice) error {
+	expired := *invoice
+	expired.Status = StatusExpired
+	updated, err := m.store.Update(ctx, expired, StatusCreated)
+	if err != nil {
+		return fmt.Errorf("invoice: failed to update invoice: %w", err)
+	}
+	if !updated {
+		current, ok, err := m.store.Get(ctx, invoice.ID)
+		if err != nil || !ok {
+			return fmt.Errorf("invoice: failed to get invoice %s: %v", invoice.ID, err)
+		}
+		*invoice = current
+		return nil
+	}
+	*invoice = expired
+	m.notify(ctx, expired)
+	return nil
+}
+
+// requestID returns the invoice ID named by req, or "".
+func (m *Manager) requestID(req x402http.EngineRequest) string {
+	if id := req.Header.Get(Header); id != "" {
+		return id
+	}
+	if id, ok := strings.CutPrefix(req.Path, m.basePath); ok && id != "" && !strings.Contains(id, "/") {
+		return id
+	}
+	return ""
+}
+
+// open returns nil if the invoice can be paid, or why it cannot.
+func (i *Invoice) open() error {
+	switch i.Status {
+	case StatusPaid:
+		return fmt.Errorf("%w: %s", ErrInvoicePaid, i.ID)
+	case StatusExpired:
+		return fmt.Errorf("%w: %s", ErrInvoiceExpired, i.ID)
+	}
+	return nil
+}
+
+// notify posts invoice to the webhook, if one is configured. Notification failures are
+// logged; they do not fail the state change.
+func (m *Manager) notify(ctx context.Context, invoice Invoice) {
+	if m.webhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(invoice)
+	if err != nil {
+		m.logger.Error("failed to marshal invoice webhook", "error", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		m.logger.Error("failed to create invoice webhook request", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		m.logger.Warn("invoice webhook failed", "invoice", invoice.ID, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		m.logger.Warn("invoice webhook rejected", "invoice", invoice.ID, "status", resp.StatusCode)
+	}
+}
+
+// newID returns a random invoice ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("invoice: failed to generate ID: %w", err)
+	}
+	return "inv_" + hex.EncodeToString(b), nil
+}
+
+// MemoryStore is an in-memory Store, for tests and single-instance deployments.
+type MemoryStore struct {
+	mu       sync.Mutex
+	invoices map[string]Invoice
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{invoices: make(map[string]Invoice)}
+}
+
+// Create implements Store.
+func (s *MemoryStore) Create(_ context.Context, invoice Invoice) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.invoices[invoice.ID]; exists {
+		return fmt.Errorf("invoice %s already exists", invoice.ID)
+	}
+	s.invoices[invoice.ID] = invoice
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (Invoice, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invoice, ok := s.invoices[id]
+	return invoice, ok, nil
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(_ context.Context, invoice Invoice, from Status) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.invoices[invoice.ID]
+	if !ok || current.Status != from {
+		return false, nil
+	}
+	s.invoices[invoice.ID] = invoice
+	return true, nil
+}
+
+// Open implements Store, returning the open invoices oldest first.
+func (s *MemoryStore) Open(_ context.Context) ([]Invoice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var open []Invoice
+	for _, invoice := range s.invoices {
+		if invoice.Status == StatusCreated {
+			open = append(open, invoice)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].CreatedAt.Before(open[j].CreatedAt) })
+	return open, nil
+}
@@ -0,0 +1,360 @@
+package invoice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+	"github.com/mark3labs/x402-go/facilitator"
+	x402http "github.com/mark3labs/x402-go/http"
+)
+
+const testPayer = "0x857b06519E91e3A54538791bDbb0E22373e36b66"
+
+func testRequirement() x402.PaymentRequirement {
+	return x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base-sepolia",
+		MaxAmountRequired: "250000",
+		Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	}
+}
+
+// webhookRecorder records the statuses of the invoices posted to it.
+type webhookRecorder struct {
+	mu       sync.Mutex
+	statuses []Status
+}
+
+func (rec *webhookRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var invoice Invoice
+	_ = json.NewDecoder(r.Body).Decode(&invoice)
+	rec.mu.Lock()
+	rec.statuses = append(rec.statuses, invoice.Status)
+	rec.mu.Unlock()
+}
+
+func (rec *webhookRecorder) Statuses() []Status {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Status(nil), rec.statuses...)
+}
+
+func newTestManager(t *testing.T, opts ...Option) (*Manager, *webhookRecorder, *time.Time) {
+	t.Helper()
+	rec := &webhookRecorder{}
+	webhook := httptest.NewServer(rec)
+	t.Cleanup(webhook.Close)
+
+	m, err := New(NewMemoryStore(), append([]Option{WithWebhook(webhook.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, rec, &now
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   Store
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "defaults", store: NewMemoryStore()},
+		{name: "nil store", wantErr: true},
+		{name: "base URL", store: NewMemoryStore(), opts: []Option{WithBaseURL("https://api.example.com/pay")}},
+		{name: "relative base URL", store: NewMemoryStore(), opts: []Option{WithBaseURL("/invoices/")}, wantErr: true},
+		{name: "zero TTL", store: NewMemoryStore(), opts: []Option{WithTTL(0)}, wantErr: true},
+		{name: "negative interval", store: NewMemoryStore(), opts: []Option{WithInterval(-time.Second)}, wantErr: true},
+		{name: "nil HTTP client", store: NewMemoryStore(), opts: []Option{WithHTTPClient(nil)}, wantErr: true},
+		{name: "nil logger", store: NewMemoryStore(), opts: []Option{WithLogger(nil)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.store, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManager_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	m, rec, now := newTestManager(t, WithBaseURL("https://api.example.com/pay"))
+
+	if _, err := m.Create(ctx, x402.PaymentRequirement{MaxAmountRequired: "0"}, 0); !errors.Is(err, x402.ErrInvalidAmount) {
+		t.Fatalf("Create() with a zero amount error = %v, want ErrInvalidAmount", err)
+	}
+
+	inv, err := m.Create(ctx, testRequirement(), 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if inv.Status != StatusCreated || !inv.ExpiresAt.Equal(now.Add(DefaultTTL)) {
+		t.Errorf("created invoice = %+v, want created and expiring after DefaultTTL", inv)
+	}
+	if inv.Requirement.Reference() != inv.ID {
+		t.Errorf("requirement reference = %q, want the invoice ID %q", inv.Requirement.Reference(), inv.ID)
+	}
+	if got, want := m.URL(inv), "https://api.example.com/pay/"+inv.ID; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+
+	paid, err := m.MarkPaid(ctx, inv.ID, testPayer, "0xtx")
+	if err != nil {
+		t.Fatalf("MarkPaid: %v", err)
+	}
+	if paid.Status != StatusPaid || paid.Payer != testPayer || paid.Transaction != "0xtx" || !paid.PaidAt.Equal(*now) {
+		t.Errorf("paid invoice = %+v", paid)
+	}
+	if _, err := m.MarkPaid(ctx, inv.ID, testPayer, "0xtx2"); !errors.Is(err, ErrInvoicePaid) {
+		t.Errorf("second MarkPaid() error = %v, want ErrInvoicePaid", err)
+	}
+
+	// A paid invoice does not expire
+	*now = now.Add(2 * DefaultTTL)
+	if got, err := m.Get(ctx, inv.ID); err != nil || got.Status != StatusPaid {
+		t.Errorf("Get() after expiry = %+v, %v, want still paid", got, err)
+	}
+	if _, err := m.Get(ctx, "inv_unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of an unknown invoice error = %v, want ErrNotFound", err)
+	}
+
+	want := []Status{StatusCreated, StatusPaid}
+	if got := rec.Statuses(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("webhook statuses = %v, want %v", got, want)
+	}
+}
+
+func TestManager_Expiry(t *testing.T) {
+	ctx := context.Background()
+	m, rec, now := newTestManager(t)
+
+	short, err := m.Create(ctx, testRequirement(), time.Minute)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	long, err := m.Create(ctx, testRequirement(), time.Hour)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	*now = now.Add(time.Minute)
+	expired, err := m.ExpireOverdue(ctx)
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireOverdue() = %d, %v, want 1 invoice expired", expired, err)
+	}
+	if _, err := m.MarkPaid(ctx, short.ID, testPayer, "0xtx"); !errors.Is(err, ErrInvoiceExpired) {
+		t.Errorf("MarkPaid() of an expired invoice error = %v, want ErrInvoiceExpired", err)
+	}
+
+	// Get expires overdue invoices before Run gets to them
+	*now = now.Add(time.Hour)
+	got, err := m.Get(ctx, long.ID)
+	if err != nil || got.Status != StatusExpired {
+		t.Errorf("Get() of an overdue invoice = %+v, %v, want expired", got, err)
+	}
+	if expired, _ := m.ExpireOverdue(ctx); expired != 0 {
+		t.Errorf("ExpireOverdue() expired %d invoices again", expired)
+	}
+
+	want := []Status{StatusCreated, StatusCreated, StatusExpired, StatusExpired}
+	statuses := rec.Statuses()
+	if len(statuses) != len(want) {
+		t.Fatalf("webhook statuses = %v, want %v", statuses, want)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("webhook statuses = %v, want %v", statuses, want)
+			break
+		}
+	}
+}
+
+func TestManager_Requirements(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newTestManager(t)
+
+	open, err := m.Create(ctx, testRequirement(), 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	paid, err := m.Create(ctx, testRequirement(), 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := m.MarkPaid(ctx, paid.ID, testPayer, "0xtx"); err != nil {
+		t.Fatalf("MarkPaid: %v", err)
+	}
+
+	base := []x402.PaymentRequirement{{MaxAmountRequired: "1"}}
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		wantBase bool
+		wantErr  bool
+	}{
+		{name: "other path", path: "/weather", wantBase: true},
+		{name: "invoice list", path: "/invoices/", wantBase: true},
+		{name: "nested path", path: "/invoices/" + open.ID + "/pdf", wantBase: true},
+		{name: "invoice path", path: "/invoices/" + open.ID},
+		{name: "invoice header", path: "/weather", header: open.ID},
+		{name: "unknown invoice", path: "/invoices/inv_unknown", wantErr: true},
+		{name: "paid invoice", path: "/invoices/" + paid.ID, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := x402http.EngineRequest{Path: tt.path, Header: http.Header{}, ResourceURL: "https://api.example.com" + tt.path}
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			got, err := m.Requirements(ctx, req, base)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Requirements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantBase {
+				if len(got) != 1 || got[0].MaxAmountRequired != "1" {
+					t.Errorf("Requirements() = %v, want base", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].MaxAmountRequired != "250000" || got[0].Reference() != open.ID {
+				t.Fatalf("Requirements() = %v, want the invoice's requirement", got)
+			}
+			if got[0].Resource != req.ResourceURL || got[0].Description == "" {
+				t.Errorf("requirement resource = %q, description = %q", got[0].Resource, got[0].Description)
+			}
+		})
+	}
+}
+
+func TestManager_OnAfterSettle(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newTestManager(t)
+
+	inv, err := m.Create(ctx, testRequirement(), 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	settled := &x402.SettlementResponse{Success: true, Transaction: "0xtx", Payer: testPayer}
+
+	// Failed, simulated and unrelated settlements are ignored
+	m.OnAfterSettle(ctx, x402.PaymentPayload{}, inv.Requirement, nil, errors.New("settle failed"))
+	m.OnAfterSettle(ctx, x402.PaymentPayload{}, inv.Requirement, &x402.SettlementResponse{Success: true, Simulated: true}, nil)
+	m.OnAfterSettle(ctx, x402.PaymentPayload{}, testRequirement(), settled, nil)
+	if got, _ := m.Get(ctx, inv.ID); got.Status != StatusCreated {
+		t.Fatalf("invoice status = %s, want still created", got.Status)
+	}
+
+	m.OnAfterSettle(ctx, x402.PaymentPayload{}, inv.Requirement, settled, nil)
+	got, _ := m.Get(ctx, inv.ID)
+	if got.Status != StatusPaid || got.Transaction != "0xtx" || got.Payer != testPayer {
+		t.Errorf("invoice = %+v, want paid in 0xtx", got)
+	}
+}
+
+func TestManager_Handler(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newTestManager(t)
+
+	var settleCalls int
+	facilitatorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/supported":
+			_ = json.NewEncoder(w).Encode(facilitator.SupportedResponse{})
+		case "/verify":
+			_ = json.NewEncoder(w).Encode(facilitator.VerifyResponse{IsValid: true, Payer: testPayer})
+		case "/settle":
+			settleCalls++
+			_ = json.NewEncoder(w).Encode(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia", Payer: testPayer})
+		}
+	}))
+	defer facilitatorServer.Close()
+
+	config := &x402http.Config{
+		FacilitatorURL:           facilitatorServer.URL,
+		PaymentRequirements:      []x402.PaymentRequirement{testRequirement()},
+		RequirementsFunc:         m.Requirements,
+		FacilitatorOnAfterSettle: m.OnAfterSettle,
+	}
+	server := httptest.NewServer(x402http.NewX402Middleware(config)(m.Handler()))
+	defer server.Close()
+
+	inv, err := m.Create(ctx, testRequirement(), 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	header, err := encoding.EncodePayment(x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base-sepolia",
+		Payload: x402.EVMPayload{
+			Signature:     "0xsig",
+			Authorization: x402.EVMAuthorization{From: testPayer, Value: "250000"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("EncodePayment: %v", err)
+	}
+
+	get := func(payment string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/invoices/"+inv.ID, nil)
+		if payment != "" {
+			req.Header.Set("X-PAYMENT", payment)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET invoice: %v", err)
+		}
+		return resp
+	}
+
+	resp := get("")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("unpaid status = %d, want 402", resp.StatusCode)
+	}
+
+	resp = get(header)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("paid status = %d, want 200", resp.StatusCode)
+	}
+	var got Invoice
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode invoice: %v", err)
+	}
+	if got.ID != inv.ID || got.Status != StatusPaid || got.Transaction != "0xtx" {
+		t.Errorf("served invoice = %+v, want it paid in 0xtx", got)
+	}
+	if settleCalls != 1 {
+		t.Errorf("settled %d times, want once", settleCalls)
+	}
+
+	// A paid invoice cannot be paid again
+	resp2 := get(header)
+	resp2.Body.Close()
+	if resp2.StatusCode == http.StatusOK {
+		t.Errorf("paying a paid invoice again returned 200")
+	}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// toolPayments coalesces payment negotiation across concurrent calls to the same tool.
+// The first call to a tool negotiates (sends the unpaid request and receives the 402);
+// concurrent calls wait for it and then pay up front with the negotiated requirements,
+// which are reused until they expire.
+type toolPayments struct {
+	ttl         time.Duration
+	maxInFlight int
+
+	mu    sync.Mutex
+	tools map[string]*toolState
+}
+
+// toolState is the negotiation state of one tool.
+type toolState struct {
+	requirements []x402.PaymentRequirement
+	expires      time.Time
+
+	// negotiating is closed when the in-progress negotiation finishes
+	negotiating chan struct{}
+
+	// inFlight holds a token per payment in flight, when capped
+	inFlight chan struct{}
+}
+
+func newToolPayments(ttl time.Duration, maxInFlight int) *toolPayments {
+	return &toolPayments{
+		ttl:         ttl,
+		maxInFlight: maxInFlight,
+		tools:       make(map[string]*toolState),
+	}
+}
+
+// state returns the state of tool. The caller must hold p.mu.
+func (p *toolPayments) state(tool string) *toolState {
+	s, ok := p.tools[tool]
+	if !ok {
+		s = &toolState{}
+		if p.maxInFlight > 0 {
+			s.inFlight = make(chan struct{}, p.maxInFlight)
+		}
+		p.tools[tool] = s
+	}
+	return s
+}
+
+// requirements returns the negotiated requirements of tool, waiting for a negotiation
+// in progress. If none are known and no negotiation is in progress, the caller becomes
+// the negotiator (leader is true) and must report the outcome with negotiated. It
+// returns neither requirements nor leadership for free tools, or when a negotiation the
+// caller waited for found no requirements.
+func (p *toolPayments) requirements(ctx context.Context, tool string) (requirements []x402.PaymentRequirement, leader bool, err error) {
+	p.mu.Lock()
+	s := p.state(tool)
+	if time.Now().Before(s.expires) {
+		defer p.mu.Unlock()
+		return s.requirements, false, nil
+	}
+	if s.negotiating == nil {
+		s.negotiating = make(chan struct{})
+		p.mu.Unlock()
+		return nil, true, nil
+	}
+	negotiating := s.negotiating
+	p.mu.Unlock()
+
+	select {
+	case <-negotiating:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Now().Before(s.expires) {
+		return s.requirements, false, nil
+	}
+	return nil, false, nil
+}
+
+// negotiated ends the negotiation of tool. requirements are those of the server's 402
+// response; free marks a tool that answered without asking for payment. Neither is
+// recorded if the negotiation failed.
+func (p *toolPayments) negotiated(tool string, requirements []x402.PaymentRequirement, free bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.state(tool)
+	if len(requirements) > 0 || free {
+		s.requirements = requirements
+		s.expires = time.Now().Add(p.ttl)
+	}
+	if s.negotiating != nil {
+		close(s.negotiating)
+		s.negotiating = nil
+	}
+}
+
+// invalidate forgets the negotiated requirements of tool, e.g. after the server rejected
+// a payment made with them.
+func (p *toolPayments) invalidate(tool string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.state(tool)
+	s.requirements = nil
+	s.expires = time.Time{}
+}
+
+// acquire waits for a free in-flight payment slot of tool. The returned function
+// releases the slot. Payments for other requests than tool calls are not capped.
+func (p *toolPayments) acquire(ctx context.Context, tool string) (release func(), err error) {
+	if tool == "" {
+		return func() {}, nil
+	}
+
+	p.mu.Lock()
+	inFlight := p.state(tool).inFlight
+	p.mu.Unlock()
+
+	if inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case inFlight <- struct{}{}:
+		return func() { <-inFlight }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	mcpproto "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/x402-go"
+)
+
+type testSigner struct{}
+
+func (testSigner) Network() string                       { return "base-sepolia" }
+func (testSigner) Scheme() string                        { return "exact" }
+func (testSigner) CanSign(*x402.PaymentRequirement) bool { return true }
+func (testSigner) GetPriority() int                      { return 0 }
+func (testSigner) GetTokens() []x402.TokenConfig         { return nil }
+func (testSigner) GetMaxAmount() *big.Int                { return nil }
+func (testSigner) Sign(*x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	return &x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia", Payload: map[string]interface{}{"signature": "0xsig"}}, nil
+}
+
+var testRequirement = x402.PaymentRequirement{
+	Scheme:            "exact",
+	Network:           "base-sepolia",
+	MaxAmountRequired: "1000",
+	Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+	PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+	MaxTimeoutSeconds: 60,
+}
+
+// paidToolServer is an MCP transport answering tools/call requests with a 402 error
+// unless they carry a payment. It records how many calls negotiated and paid, and the
+// highest number of paid calls in flight at once.
+type paidToolServer struct {
+	unpaid      atomic.Int32
+	paid        atomic.Int32
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (s *paidToolServer) Start(context.Context) error { return nil }
+func (s *paidToolServer) SendNotification(context.Context, mcpproto.JSONRPCNotification) error {
+	return nil
+}
+func (s *paidToolServer) SetNotificationHandler(func(mcpproto.JSONRPCNotification)) {}
+func (s *paidToolServer) Close() error                                              { return nil }
+func (s *paidToolServer) GetSessionId() string                                      { return "" }
+
+func (s *paidToolServer) SendRequest(ctx context.Context, req transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	params, _ := req.Params.(map[string]interface{})
+	meta, _ := params["_meta"].(map[string]interface{})
+	if meta["x402/payment"] == nil {
+		s.unpaid.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		data, _ := json.Marshal(map[string]interface{}{
+			"x402Version": 1,
+			"error":       "Payment required",
+			"accepts":     []x402.PaymentRequirement{testRequirement},
+		})
+		return response(`{"jsonrpc":"2.0","id":1,"error":{"code":402,"message":"Payment required","data":` + string(data) + `}}`), nil
+	}
+
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		highest := s.maxInFlight.Load()
+		if n <= highest || s.maxInFlight.CompareAndSwap(highest, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	s.paid.Add(1)
+	return response(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"ok"}]}}`), nil
+}
+
+func response(raw string) *transport.JSONRPCResponse {
+	var resp transport.JSONRPCResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		panic(err)
+	}
+	return &resp
+}
+
+func newTestTransport(server *paidToolServer, opts ...Option) *Transport {
+	config := DefaultConfig("http://mcp.example")
+	config.Signers = []x402.Signer{testSigner{}}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &Transport{
+		baseTransport: server,
+		config:        config,
+		payments:      newToolPayments(config.RequirementTTL, config.MaxInFlightPayments),
+	}
+}
+
+func callTool(name string) transport.JSONRPCRequest {
+	return transport.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": name, "arguments": map[string]interface{}{}},
+	}
+}
+
+func TestTransport_ConcurrentCallsShareNegotiation(t *testing.T) {
+	const calls = 100
+	server := &paidToolServer{}
+	tr := newTestTransport(server, WithMaxInFlightPayments(5))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := tr.SendRequest(context.Background(), callTool("search"))
+			if err == nil && resp.Error != nil {
+				err = fmt.Errorf("error response: %s", resp.Error.Message)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	if got := server.unpaid.Load(); got != 1 {
+		t.Errorf("expected 1 negotiation, got %d", got)
+	}
+	if got := server.paid.Load(); got != calls {
+		t.Errorf("expected %d paid calls, got %d", calls, got)
+	}
+	if got := server.maxInFlight.Load(); got > 5 {
+		t.Errorf("expected at most 5 payments in flight, got %d", got)
+	}
+}
+
+func TestTransport_WithoutCoalescing(t *testing.T) {
+	server := &paidToolServer{}
+	tr := newTestTransport(server, WithRequirementTTL(0))
+
+	for range 3 {
+		if _, err := tr.SendRequest(context.Background(), callTool("search")); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	if got := server.unpaid.Load(); got != 3 {
+		t.Errorf("expected 3 negotiations, got %d", got)
+	}
+}
+
+func TestToolPayments(t *testing.T) {
+	const callers = 100
+	p := newToolPayments(time.Minute, 0)
+
+	var leaders atomic.Int32
+	var withRequirements atomic.Int32
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			requirements, leader, err := p.requirements(context.Background(), "search")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if leader {
+				leaders.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				p.negotiated("search", []x402.PaymentRequirement{testRequirement}, false)
+				return
+			}
+			if len(requirements) == 1 {
+				withRequirements.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if leaders.Load() != 1 || withRequirements.Load() != callers-1 {
+		t.Errorf("expected 1 leader and %d callers reusing its requirements, got %d and %d", callers-1, leaders.Load(), withRequirements.Load())
+	}
+
+	// Free tools and failed negotiations do not block later calls
+	if _, leader, _ := p.requirements(context.Background(), "echo"); !leader {
+		t.Fatal("expected to negotiate echo")
+	}
+	p.negotiated("echo", nil, true)
+	if requirements, leader, _ := p.requirements(context.Background(), "echo"); leader || requirements != nil {
+		t.Errorf("expected free tool to skip negotiation, got leader=%v requirements=%v", leader, requirements)
+	}
+
+	p.invalidate("search")
+	if _, leader, _ := p.requirements(context.Background(), "search"); !leader {
+		t.Error("expected invalidated tool to negotiate again")
+	}
+}
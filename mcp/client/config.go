@@ -2,6 +2,7 @@ package client
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/mark3labs/x402-go"
 )
@@ -29,6 +30,21 @@ type Config struct {
 	// Selector is the payment selector for choosing which signer to use (optional, uses default if nil)
 	Selector x402.PaymentSelector
 
+	// RequirementTTL is how long the payment requirements negotiated for a tool are
+	// reused. Calls to a tool with known requirements pay up front instead of waiting
+	// for a 402 error, and concurrent calls to a tool share one negotiation. Zero
+	// negotiates every call separately.
+	RequirementTTL time.Duration
+
+	// MaxInFlightPayments caps the number of concurrent paid calls per tool. Zero means
+	// no cap.
+	MaxInFlightPayments int
+
+	// Simulate marks every payment as simulated (see x402.PaymentPayload.Simulated) and
+	// only pays testnet requirements. Servers with AcceptSimulatedPayments serve such
+	// calls without verifying or settling the payment.
+	Simulate bool
+
 	// Verbose enables detailed logging
 	Verbose bool
 }
@@ -87,6 +103,27 @@ func WithSelector(selector x402.PaymentSelector) Option {
 	}
 }
 
+// WithRequirementTTL sets how long negotiated payment requirements are reused per tool
+func WithRequirementTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.RequirementTTL = ttl
+	}
+}
+
+// WithMaxInFlightPayments caps the number of concurrent paid calls per tool
+func WithMaxInFlightPayments(n int) Option {
+	return func(c *Config) {
+		c.MaxInFlightPayments = n
+	}
+}
+
+// WithSimulation marks payments as simulated and restricts them to testnets
+func WithSimulation() Option {
+	return func(c *Config) {
+		c.Simulate = true
+	}
+}
+
 // WithVerbose enables verbose logging
 func WithVerbose() Option {
 	return func(c *Config) {
@@ -94,12 +131,16 @@ func WithVerbose() Option {
 	}
 }
 
+// DefaultRequirementTTL is the default RequirementTTL.
+const DefaultRequirementTTL = 5 * time.Minute
+
 // DefaultConfig returns a Config with default settings
 func DefaultConfig(serverURL string) *Config {
 	return &Config{
-		ServerURL:  serverURL,
-		HTTPClient: http.DefaultClient,
-		Selector:   &x402.DefaultPaymentSelector{},
-		Signers:    make([]x402.Signer, 0),
+		ServerURL:      serverURL,
+		HTTPClient:     http.DefaultClient,
+		Selector:       &x402.DefaultPaymentSelector{},
+		Signers:        make([]x402.Signer, 0),
+		RequirementTTL: DefaultRequirementTTL,
 	}
 }
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"encoding/json"
+
+	mcpproto "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/x402-go/mcp"
+)
+
+// SettlementFromResult returns the settlement of a paid tool call from the result's
+// _meta, including the transaction, network and amount paid. It returns false if the
+// call was free or the server did not report a settlement.
+func SettlementFromResult(result *mcpproto.CallToolResult) (*mcp.PaymentResponse, bool) {
+	if result == nil || result.Meta == nil {
+		return nil, false
+	}
+	return decodePaymentResponse(result.Meta.AdditionalFields[mcp.PaymentResponseMetaKey])
+}
+
+// settlementFromRawResult returns the settlement reported in a raw JSON-RPC result.
+func settlementFromRawResult(raw json.RawMessage) (*mcp.PaymentResponse, bool) {
+	var result struct {
+		Meta map[string]interface{} `json:"_meta"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &result) != nil {
+		return nil, false
+	}
+	return decodePaymentResponse(result.Meta[mcp.PaymentResponseMetaKey])
+}
+
+func decodePaymentResponse(value interface{}) (*mcp.PaymentResponse, bool) {
+	if value == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var response mcp.PaymentResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+	return &response, true
+}
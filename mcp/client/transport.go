@@ -16,6 +16,7 @@ import (
 type Transport struct {
 	baseTransport transport.Interface
 	config        *Config
+	payments      *toolPayments
 }
 
 // NewTransport creates a new x402-enabled MCP transport
@@ -39,6 +40,7 @@ func NewTransport(serverURL string, opts ...Option) (*Transport, error) {
 	return &Transport{
 		baseTransport: baseTransport,
 		config:        config,
+		payments:      newToolPayments(config.RequirementTTL, config.MaxInFlightPayments),
 	}, nil
 }
 
@@ -49,6 +51,44 @@ func (t *Transport) Start(ctx context.Context) error {
 
 // SendRequest implements transport.Interface by intercepting requests and handling 402 errors
 func (t *Transport) SendRequest(ctx context.Context, req transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	tool := toolName(req)
+	if tool == "" || t.config.RequirementTTL <= 0 {
+		return t.sendAndPay(ctx, req, "")
+	}
+
+	// Pay up front with requirements negotiated by an earlier or concurrent call
+	requirements, leader, err := t.payments.requirements(ctx, tool)
+	if err != nil {
+		return nil, err
+	}
+	if len(requirements) > 0 {
+		resp, err := t.pay(ctx, req, tool, requirements)
+		if err != nil || resp.Error == nil || resp.Error.Code != 402 {
+			return resp, err
+		}
+		// The requirements changed; negotiate again
+		t.payments.invalidate(tool)
+		return t.sendAndPay(ctx, req, "")
+	}
+	if !leader {
+		return t.sendAndPay(ctx, req, "")
+	}
+	return t.sendAndPay(ctx, req, tool)
+}
+
+// sendAndPay sends req and pays if the server responds with a 402 error. If negotiating
+// names a tool, the outcome of the negotiation is recorded for concurrent calls.
+func (t *Transport) sendAndPay(ctx context.Context, req transport.JSONRPCRequest, negotiating string) (*transport.JSONRPCResponse, error) {
+	var requirements []x402.PaymentRequirement
+	free, ended := false, false
+	endNegotiation := func() {
+		if negotiating != "" && !ended {
+			ended = true
+			t.payments.negotiated(negotiating, requirements, free)
+		}
+	}
+	defer endNegotiation()
+
 	// Send initial request
 	resp, err := t.baseTransport.SendRequest(ctx, req)
 	if err != nil {
@@ -67,30 +107,103 @@ func (t *Transport) SendRequest(ctx context.Context, req transport.JSONRPCReques
 			data = dataBytes
 		}
 
-		requirements, err := t.extractPaymentRequirements(data)
+		requirements, err = t.extractPaymentRequirements(data)
 		if err != nil {
 			return resp, fmt.Errorf("failed to extract payment requirements: %w", err)
 		}
 
-		// Create payment
-		payment, startTime, err := t.createPayment(ctx, requirements)
-		if err != nil {
-			return resp, mcp.WrapX402Error(err, req.Method)
-		}
+		// Let concurrent calls pay with the requirements while this one does
+		endNegotiation()
 
-		// Inject payment and retry
-		modifiedReq, err := t.injectPaymentMeta(req, payment)
-		if err != nil {
-			return resp, fmt.Errorf("failed to inject payment: %w", err)
+		paidResp, err := t.pay(ctx, req, toolName(req), requirements)
+		if err != nil && paidResp == nil {
+			return resp, err
 		}
-
-		// Retry with payment
-		return t.retryWithPayment(ctx, modifiedReq, payment, startTime)
+		return paidResp, err
 	}
 
+	free = resp.Error == nil
 	return resp, nil
 }
 
+// pay signs a payment for requirements and sends req with it, holding one of the
+// tool's in-flight payment slots.
+func (t *Transport) pay(ctx context.Context, req transport.JSONRPCRequest, tool string, requirements []x402.PaymentRequirement) (*transport.JSONRPCResponse, error) {
+	release, err := t.payments.acquire(ctx, tool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Create payment
+	payment, startTime, err := t.createPayment(ctx, requirements)
+	if err != nil {
+		return nil, mcp.WrapX402Error(err, req.Method)
+	}
+
+	// Inject payment and retry
+	modifiedReq, err := t.injectPaymentMeta(req, payment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inject payment: %w", err)
+	}
+
+	// Retry with payment
+	resp, err := t.retryWithPayment(ctx, modifiedReq, payment, startTime)
+	if err != nil {
+		return resp, err
+	}
+
+	// The server prices this payer differently: sign once more for the offered price
+	repriced, ok := t.priceChanged(resp)
+	if !ok {
+		return resp, nil
+	}
+	payment, startTime, err = t.createPayment(ctx, repriced)
+	if err != nil {
+		return nil, mcp.WrapX402Error(err, req.Method)
+	}
+	modifiedReq, err = t.injectPaymentMeta(req, payment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inject payment: %w", err)
+	}
+	return t.retryWithPayment(ctx, modifiedReq, payment, startTime)
+}
+
+// priceChanged returns the requirements offered by a 402 error rejecting a payment with
+// reason mcp.ReasonPriceChanged, and whether resp is one.
+func (t *Transport) priceChanged(resp *transport.JSONRPCResponse) ([]x402.PaymentRequirement, bool) {
+	if resp == nil || resp.Error == nil || resp.Error.Code != 402 || resp.Error.Data == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(resp.Error.Data)
+	if err != nil {
+		return nil, false
+	}
+	var rejection mcp.PaymentRequirements
+	if json.Unmarshal(data, &rejection) != nil || rejection.Reason != mcp.ReasonPriceChanged || len(rejection.Accepts) == 0 {
+		return nil, false
+	}
+	return rejection.Accepts, true
+}
+
+// toolName returns the name of the tool called by a tools/call request, or "".
+func toolName(req transport.JSONRPCRequest) string {
+	if req.Method != "tools/call" || req.Params == nil {
+		return ""
+	}
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		return ""
+	}
+	var params struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return ""
+	}
+	return params.Name
+}
+
 // SendNotification sends a notification to the server
 func (t *Transport) SendNotification(ctx context.Context, notif mcpproto.JSONRPCNotification) error {
 	return t.baseTransport.SendNotification(ctx, notif)
@@ -138,6 +251,17 @@ func (t *Transport) createPayment(ctx context.Context, requirements []x402.Payme
 		return nil, startTime, x402.ErrNoValidSigner
 	}
 
+	// Simulated payments are only accepted on testnets
+	if t.config.Simulate {
+		var testnet []x402.PaymentRequirement
+		for _, requirement := range requirements {
+			if x402.IsTestnet(requirement.Network) {
+				testnet = append(testnet, requirement)
+			}
+		}
+		requirements = testnet
+	}
+
 	// Use selector to choose signer and create payment
 	payment, err := t.config.Selector.SelectAndSign(requirements, t.config.Signers)
 	if err != nil {
@@ -152,6 +276,9 @@ func (t *Transport) createPayment(ctx context.Context, requirements []x402.Payme
 		}
 		return nil, startTime, err
 	}
+	if t.config.Simulate {
+		payment.Simulated = true
+	}
 
 	// Find the requirement that was actually selected by matching the payment's network and scheme
 	// This ensures the payment attempt event reflects the actual requirement that was chosen
@@ -236,9 +363,9 @@ func (t *Transport) retryWithPayment(ctx context.Context, req transport.JSONRPCR
 		return resp, err
 	}
 
-	// Check if payment succeeded
+	// Check if payment succeeded; a price change is retried, not failed
 	if resp.Error != nil {
-		if resp.Error.Code == 402 && t.config.OnPaymentFailure != nil {
+		if _, repriced := t.priceChanged(resp); resp.Error.Code == 402 && !repriced && t.config.OnPaymentFailure != nil {
 			t.config.OnPaymentFailure(x402.PaymentEvent{
 				Type:      x402.PaymentEventFailure,
 				Timestamp: time.Now(),
@@ -256,7 +383,7 @@ func (t *Transport) retryWithPayment(ctx context.Context, req transport.JSONRPCR
 	if t.config.OnPaymentSuccess != nil {
 		// Extract tool name from request method
 		toolName := req.Method
-		t.config.OnPaymentSuccess(x402.PaymentEvent{
+		event := x402.PaymentEvent{
 			Type:      x402.PaymentEventSuccess,
 			Timestamp: time.Now(),
 			Method:    "MCP",
@@ -264,7 +391,14 @@ func (t *Transport) retryWithPayment(ctx context.Context, req transport.JSONRPCR
 			Network:   payment.Network,
 			Scheme:    payment.Scheme,
 			Duration:  duration,
-		})
+		}
+		if settlement, ok := settlementFromRawResult(resp.Result); ok {
+			event.Transaction = settlement.Transaction
+			event.Payer = settlement.Payer
+			event.Amount = settlement.Amount
+			event.Asset = settlement.Asset
+		}
+		t.config.OnPaymentSuccess(event)
 	}
 
 	return resp, nil
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/mcp"
+)
+
+// amountSigner signs payments for the amount each requirement asks.
+type amountSigner struct{ testSigner }
+
+func (amountSigner) Sign(requirement *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	return &x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia", Payload: map[string]interface{}{
+		"signature":     "0xsig",
+		"authorization": map[string]interface{}{"value": requirement.MaxAmountRequired},
+	}}, nil
+}
+
+// surchargingServer is an MCP transport pricing its payer at 1500 instead of the
+// advertised 1000, like a server applying a reputation surcharge.
+type surchargingServer struct {
+	paidToolServer
+	signed []string
+	served atomic.Int32
+}
+
+func (s *surchargingServer) SendRequest(_ context.Context, req transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	params, _ := req.Params.(map[string]interface{})
+	meta, _ := params["_meta"].(map[string]interface{})
+	if meta["x402/payment"] == nil {
+		data, _ := json.Marshal(mcp.PaymentRequirements{X402Version: 1, Error: "Payment required", Accepts: []x402.PaymentRequirement{testRequirement}})
+		return response(`{"jsonrpc":"2.0","id":1,"error":{"code":402,"message":"Payment required","data":` + string(data) + `}}`), nil
+	}
+
+	raw, _ := json.Marshal(meta["x402/payment"])
+	var payment struct {
+		Payload struct {
+			Authorization struct {
+				Value string `json:"value"`
+			} `json:"authorization"`
+		} `json:"payload"`
+	}
+	_ = json.Unmarshal(raw, &payment)
+	s.signed = append(s.signed, payment.Payload.Authorization.Value)
+	if payment.Payload.Authorization.Value != "1500" {
+		surcharged := testRequirement
+		surcharged.MaxAmountRequired = "1500"
+		data, _ := json.Marshal(mcp.PaymentRequirements{X402Version: 1, Error: "Payment rejected", Accepts: []x402.PaymentRequirement{surcharged}, Reason: mcp.ReasonPriceChanged})
+		return response(`{"jsonrpc":"2.0","id":1,"error":{"code":402,"message":"Payment rejected","data":` + string(data) + `}}`), nil
+	}
+	s.served.Add(1)
+	return response(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"ok"}]}}`), nil
+}
+
+func TestTransport_PriceChanged(t *testing.T) {
+	server := &surchargingServer{}
+	config := DefaultConfig("http://mcp.example")
+	config.Signers = []x402.Signer{amountSigner{}}
+	config.RequirementTTL = 0
+	var failures atomic.Int32
+	config.OnPaymentFailure = func(x402.PaymentEvent) { failures.Add(1) }
+	tr := &Transport{
+		baseTransport: server,
+		config:        config,
+		payments:      newToolPayments(0, 0),
+	}
+
+	resp, err := tr.SendRequest(context.Background(), callTool("search"))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("call rejected: %s", resp.Error.Message)
+	}
+	if len(server.signed) != 2 || server.signed[0] != "1000" || server.signed[1] != "1500" {
+		t.Errorf("signed %v, want [1000 1500]", server.signed)
+	}
+	if server.served.Load() != 1 || failures.Load() != 0 {
+		t.Errorf("served %d calls with %d failures, want 1 and 0", server.served.Load(), failures.Load())
+	}
+}
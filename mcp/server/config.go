@@ -1,10 +1,12 @@
 package server
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/mark3labs/x402-go"
 	"github.com/mark3labs/x402-go/http"
+	"github.com/mark3labs/x402-go/sanctions"
 )
 
 // Config holds configuration for the MCP server with x402 payment support
@@ -12,9 +14,22 @@ type Config struct {
 	// FacilitatorURL is the URL of the x402 facilitator service
 	FacilitatorURL string
 
+	// Facilitator optionally verifies and settles payments in place of the HTTP
+	// facilitator at FacilitatorURL, e.g. a self-hosted or test implementation.
+	Facilitator Facilitator
+
+	// FallbackFacilitator is optionally tried when Facilitator fails. It takes precedence
+	// over HTTPConfig.FallbackFacilitatorURL.
+	FallbackFacilitator Facilitator
+
 	// VerifyOnly when true, skips payment settlement (useful for testing)
 	VerifyOnly bool
 
+	// AcceptSimulatedPayments serves tool calls paying with a simulated payment (see
+	// x402.PaymentPayload.Simulated) for testnet requirements without verifying or
+	// settling it. Simulated payments are otherwise rejected.
+	AcceptSimulatedPayments bool
+
 	// Verbose enables detailed logging
 	Verbose bool
 
@@ -22,6 +37,21 @@ type Config struct {
 	// Key: tool name, Value: list of acceptable payment options
 	PaymentTools map[string][]x402.PaymentRequirement
 
+	// AcceptsSorter optionally reorders a tool's payment requirements on each call,
+	// e.g. to prefer a network while its facilitator is healthy. See http.AcceptsSorter.
+	AcceptsSorter http.AcceptsSorter
+
+	// ReputationProvider optionally assesses each payer before verification, to deny,
+	// surcharge or degrade service for risky payers. A provider error is logged and the
+	// payer treated normally. A surcharged payer's first payment is answered with a 402
+	// error offering its price, with reason mcp.ReasonPriceChanged, and the client signs
+	// again. See http.ReputationProvider.
+	ReputationProvider http.ReputationProvider
+
+	// Sanctions optionally screens the payer (and payTo) addresses of each payment
+	// against sanctions lists before it is verified. See sanctions.Policy.
+	Sanctions *sanctions.Policy
+
 	// FacilitatorAuthorization is a static Authorization header value for the primary facilitator.
 	// Example: "Bearer your-api-key" or "Basic base64-encoded-credentials"
 	FacilitatorAuthorization string
@@ -31,12 +61,27 @@ type Config struct {
 	// If set, this takes precedence over FacilitatorAuthorization.
 	FacilitatorAuthorizationProvider http.AuthorizationProvider
 
+	// FacilitatorSigningSecret enables HMAC signing of verify/settle requests
+	// sent to the primary facilitator.
+	FacilitatorSigningSecret []byte
+
 	// Facilitator hooks for custom logic before/after verify and settle operations
 	FacilitatorOnBeforeVerify http.OnBeforeFunc
 	FacilitatorOnAfterVerify  http.OnAfterVerifyFunc
 	FacilitatorOnBeforeSettle http.OnBeforeFunc
 	FacilitatorOnAfterSettle  http.OnAfterSettleFunc
 
+	// AdvertisePricing adds the payment options of each payable tool to its entry in
+	// tools/list responses, under _meta["x402/pricing"], so clients can see prices
+	// before calling.
+	AdvertisePricing bool
+
+	// RefundOnToolError is called when a paid tool call returns an error result (isError)
+	// after its payment settled, to queue the payment for refund or flag it for manual
+	// review. The result is returned to the client with its payment response marked
+	// refundPending. When nil, such payments are kept.
+	RefundOnToolError func(ctx context.Context, refund RefundRequest)
+
 	// HTTPConfig to generate facilitator and fallback facilitator clients
 	// HTTPConfig.VerifyOnly and HTTPConfig.PaymentRequirements are ignored
 	HTTPConfig *http.Config
@@ -46,14 +91,129 @@ type Config struct {
 	Logger *slog.Logger
 }
 
+// Option is a functional option for configuring the X402Server and X402Handler
+type Option func(*Config)
+
+// WithFacilitator sets the facilitator verifying and settling payments, e.g. one
+// created with NewHTTPFacilitator to authenticate to it or hook its calls
+func WithFacilitator(facilitator Facilitator) Option {
+	return func(c *Config) {
+		c.Facilitator = facilitator
+	}
+}
+
+// WithFacilitatorURL sets the URL of the x402 facilitator service
+func WithFacilitatorURL(url string) Option {
+	return func(c *Config) {
+		c.FacilitatorURL = url
+	}
+}
+
+// WithFallbackFacilitator sets the facilitator tried when the primary one fails
+func WithFallbackFacilitator(facilitator Facilitator) Option {
+	return func(c *Config) {
+		c.FallbackFacilitator = facilitator
+	}
+}
+
+// WithHTTPConfig configures the facilitators from the http middleware's Config
+func WithHTTPConfig(config *http.Config) Option {
+	return func(c *Config) {
+		c.HTTPConfig = config
+	}
+}
+
+// WithVerifyOnly skips payment settlement (useful for testing)
+func WithVerifyOnly() Option {
+	return func(c *Config) {
+		c.VerifyOnly = true
+	}
+}
+
+// WithSimulatedPayments serves calls paying with simulated payments on testnets
+func WithSimulatedPayments() Option {
+	return func(c *Config) {
+		c.AcceptSimulatedPayments = true
+	}
+}
+
+// WithLogger sets the logger for the server
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithVerbose enables verbose logging
+func WithVerbose() Option {
+	return func(c *Config) {
+		c.Verbose = true
+	}
+}
+
+// WithPaymentTool sets the payment requirements of a tool
+func WithPaymentTool(toolName string, requirements ...x402.PaymentRequirement) Option {
+	return func(c *Config) {
+		c.AddPaymentTool(toolName, requirements...)
+	}
+}
+
+// WithAcceptsSorter sets the sorter reordering each tool's payment requirements
+func WithAcceptsSorter(sorter http.AcceptsSorter) Option {
+	return func(c *Config) {
+		c.AcceptsSorter = sorter
+	}
+}
+
+// WithReputationProvider sets the provider assessing each payer before verification
+func WithReputationProvider(provider http.ReputationProvider) Option {
+	return func(c *Config) {
+		c.ReputationProvider = provider
+	}
+}
+
+// WithSanctions screens payer and payTo addresses against sanctions lists
+func WithSanctions(policy *sanctions.Policy) Option {
+	return func(c *Config) {
+		c.Sanctions = policy
+	}
+}
+
+// WithPricing sets whether tools/list responses advertise each tool's payment options
+func WithPricing(advertise bool) Option {
+	return func(c *Config) {
+		c.AdvertisePricing = advertise
+	}
+}
+
+// WithRefundOnToolError sets the callback for settled payments of failed tool calls
+func WithRefundOnToolError(refund func(ctx context.Context, refund RefundRequest)) Option {
+	return func(c *Config) {
+		c.RefundOnToolError = refund
+	}
+}
+
+// newConfig applies opts to the default configuration.
+func newConfig(opts []Option) *Config {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.PaymentTools == nil {
+		config.PaymentTools = make(map[string][]x402.PaymentRequirement)
+	}
+	return config
+}
+
 // DefaultConfig returns a Config with default settings
 func DefaultConfig() *Config {
 	return &Config{
-		FacilitatorURL: "https://facilitator.x402.rs",
-		VerifyOnly:     false,
-		Verbose:        false,
-		PaymentTools:   make(map[string][]x402.PaymentRequirement),
-		Logger:         slog.Default(),
+		FacilitatorURL:   "https://facilitator.x402.rs",
+		VerifyOnly:       false,
+		Verbose:          false,
+		AdvertisePricing: true,
+		PaymentTools:     make(map[string][]x402.PaymentRequirement),
+		Logger:           slog.Default(),
 	}
 }
 
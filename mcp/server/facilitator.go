@@ -50,6 +50,24 @@ func WithAuthorizationProvider(provider http.AuthorizationProvider) HTTPFacilita
 	}
 }
 
+// WithSigningSecret enables HMAC request signing for a self-hosted facilitator.
+// The facilitator can check the signature with http.VerifyRequestSignature.
+func WithSigningSecret(secret []byte) HTTPFacilitatorOption {
+	return func(c *http.FacilitatorClient) {
+		c.SigningSecret = secret
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to reach the facilitator, e.g. one built with
+// facilitator.NewHTTPClient for proxies or client certificates. A nil client keeps the default.
+func WithHTTPClient(client *nethttp.Client) HTTPFacilitatorOption {
+	return func(c *http.FacilitatorClient) {
+		if client != nil {
+			c.Client = client
+		}
+	}
+}
+
 // WithOnBeforeVerify sets a hook function to be called before verifying a payment.
 func WithOnBeforeVerify(f http.OnBeforeFunc) HTTPFacilitatorOption {
 	return func(c *http.FacilitatorClient) {
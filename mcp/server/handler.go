@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,6 +13,8 @@ import (
 	"github.com/mark3labs/x402-go"
 	"github.com/mark3labs/x402-go/facilitator"
 	x402http "github.com/mark3labs/x402-go/http"
+	"github.com/mark3labs/x402-go/mcp"
+	"github.com/mark3labs/x402-go/sanctions"
 )
 
 // X402Handler wraps an MCP HTTP handler and adds x402 payment verification
@@ -23,11 +26,12 @@ type X402Handler struct {
 }
 
 // NewX402Handler creates a new x402 payment handler
-func NewX402Handler(mcpHandler http.Handler, config *Config) *X402Handler {
-	if config == nil {
-		config = DefaultConfig()
-	}
+func NewX402Handler(mcpHandler http.Handler, opts ...Option) *X402Handler {
+	return newX402Handler(mcpHandler, newConfig(opts))
+}
 
+// newX402Handler creates a payment handler with a resolved configuration.
+func newX402Handler(mcpHandler http.Handler, config *Config) *X402Handler {
 	facilitator, fallbackFacilitator := initializeFacilitators(config)
 
 	return &X402Handler{
@@ -40,8 +44,10 @@ func NewX402Handler(mcpHandler http.Handler, config *Config) *X402Handler {
 
 type facilitatorConfig struct {
 	url            string
+	httpClient     *http.Client
 	auth           string
 	authProvider   x402http.AuthorizationProvider
+	signingSecret  []byte
 	onBeforeVerify x402http.OnBeforeFunc
 	onAfterVerify  x402http.OnAfterVerifyFunc
 	onBeforeSettle x402http.OnBeforeFunc
@@ -51,8 +57,10 @@ type facilitatorConfig struct {
 // Helper to create facilitator with given URL and options
 func createFacilitator(cfg facilitatorConfig) Facilitator {
 	return NewHTTPFacilitator(cfg.url,
+		WithHTTPClient(cfg.httpClient),
 		WithAuthorization(cfg.auth),
 		WithAuthorizationProvider(cfg.authProvider),
+		WithSigningSecret(cfg.signingSecret),
 		WithOnBeforeVerify(cfg.onBeforeVerify),
 		WithOnAfterVerify(cfg.onAfterVerify),
 		WithOnBeforeSettle(cfg.onBeforeSettle),
@@ -66,6 +74,7 @@ func initializeFacilitators(config *Config) (Facilitator, Facilitator) {
 	primaryURL := config.FacilitatorURL
 	auth := config.FacilitatorAuthorization
 	authProvider := config.FacilitatorAuthorizationProvider
+	signingSecret := config.FacilitatorSigningSecret
 	onBeforeVerify := config.FacilitatorOnBeforeVerify
 	onAfterVerify := config.FacilitatorOnAfterVerify
 	onBeforeSettle := config.FacilitatorOnBeforeSettle
@@ -75,32 +84,48 @@ func initializeFacilitators(config *Config) (Facilitator, Facilitator) {
 		primaryURL = config.HTTPConfig.FacilitatorURL
 		auth = config.HTTPConfig.FacilitatorAuthorization
 		authProvider = config.HTTPConfig.FacilitatorAuthorizationProvider
+		signingSecret = config.HTTPConfig.FacilitatorSigningSecret
 		onBeforeVerify = config.HTTPConfig.FacilitatorOnBeforeVerify
 		onAfterVerify = config.HTTPConfig.FacilitatorOnAfterVerify
 		onBeforeSettle = config.HTTPConfig.FacilitatorOnBeforeSettle
 		onAfterSettle = config.HTTPConfig.FacilitatorOnAfterSettle
 	}
 
-	if primaryURL == "" {
-		panic("x402: at least one facilitator URL must be provided")
+	var httpClient *http.Client
+	if config.HTTPConfig != nil {
+		httpClient = config.HTTPConfig.FacilitatorHTTPClient
 	}
 
-	facilitator = createFacilitator(facilitatorConfig{
-		url:            primaryURL,
-		auth:           auth,
-		authProvider:   authProvider,
-		onBeforeVerify: onBeforeVerify,
-		onAfterVerify:  onAfterVerify,
-		onBeforeSettle: onBeforeSettle,
-		onAfterSettle:  onAfterSettle,
-	})
+	if config.Facilitator != nil {
+		facilitator = config.Facilitator
+	} else {
+		if primaryURL == "" {
+			panic("x402: at least one facilitator URL must be provided")
+		}
+
+		facilitator = createFacilitator(facilitatorConfig{
+			url:            primaryURL,
+			httpClient:     httpClient,
+			auth:           auth,
+			authProvider:   authProvider,
+			signingSecret:  signingSecret,
+			onBeforeVerify: onBeforeVerify,
+			onAfterVerify:  onAfterVerify,
+			onBeforeSettle: onBeforeSettle,
+			onAfterSettle:  onAfterSettle,
+		})
+	}
 
 	// Initialize fallback if configured
-	if config.HTTPConfig != nil && config.HTTPConfig.FallbackFacilitatorURL != "" {
+	if config.FallbackFacilitator != nil {
+		fallbackFacilitator = config.FallbackFacilitator
+	} else if config.HTTPConfig != nil && config.HTTPConfig.FallbackFacilitatorURL != "" {
 		fallbackFacilitator = createFacilitator(facilitatorConfig{
 			url:            config.HTTPConfig.FallbackFacilitatorURL,
+			httpClient:     httpClient,
 			auth:           config.HTTPConfig.FallbackFacilitatorAuthorization,
 			authProvider:   config.HTTPConfig.FallbackFacilitatorAuthorizationProvider,
+			signingSecret:  config.HTTPConfig.FallbackFacilitatorSigningSecret,
 			onBeforeVerify: config.HTTPConfig.FallbackFacilitatorOnBeforeVerify,
 			onAfterVerify:  config.HTTPConfig.FallbackFacilitatorOnAfterVerify,
 			onBeforeSettle: config.HTTPConfig.FallbackFacilitatorOnBeforeSettle,
@@ -143,6 +168,12 @@ func (h *X402Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Advertise the prices of payable tools in tools/list
+	if jsonrpcReq.Method == "tools/list" && h.config.AdvertisePricing && len(h.config.PaymentTools) > 0 {
+		h.forwardWithPricing(w, r)
+		return
+	}
+
 	// Only intercept tools/call methods
 	if jsonrpcReq.Method != "tools/call" {
 		h.mcpHandler.ServeHTTP(w, r)
@@ -179,7 +210,7 @@ func (h *X402Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if tool requires payment
-	requirements, needsPayment := h.checkPaymentRequired(toolParams.Name)
+	requirements, needsPayment := h.checkPaymentRequired(r.Context(), toolParams.Name)
 	if !needsPayment {
 		// Free tool - pass through
 		h.mcpHandler.ServeHTTP(w, r)
@@ -194,6 +225,17 @@ func (h *X402Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check the payer's reputation before anything is verified or charged
+	baseRequirements := requirements
+	if reputation := h.payerReputation(r.Context(), payment, logger); reputation != nil {
+		if reputation.Deny {
+			h.writeError(w, jsonrpcReq.ID, 403, "Payer denied", nil)
+			return
+		}
+		requirements = reputation.Surcharge(requirements)
+		r = r.WithContext(x402http.WithReputation(r.Context(), reputation))
+	}
+
 	// Find matching requirement
 	requirement, err := h.findMatchingRequirement(payment, requirements)
 	if err != nil {
@@ -201,6 +243,39 @@ func (h *X402Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A payment signed for the advertised price when the payer is surcharged must be
+	// signed again for the payer's price before anything is verified or settled
+	if repriced(payment, baseRequirements, requirement) {
+		logger.InfoContext(r.Context(), "payment signed for another price", "price", requirement.MaxAmountRequired)
+		h.sendPaymentRejectedError(w, jsonrpcReq.ID, requirements, mcp.ReasonPriceChanged)
+		return
+	}
+
+	// Screen the payment's addresses against sanctions lists
+	if h.config.Sanctions != nil {
+		payer := x402http.PaymentPayer(*payment)
+		if err := h.config.Sanctions.Check(r.Context(), payment.Network, payer, requirement.PayTo); err != nil {
+			if errors.Is(err, sanctions.ErrBlocked) {
+				logger.WarnContext(r.Context(), "payment blocked by sanctions screening", "error", err)
+				h.writeError(w, jsonrpcReq.ID, 403, "Payment address blocked", nil)
+				return
+			}
+			logger.ErrorContext(r.Context(), "sanctions screening failed", "error", err)
+			h.writeError(w, jsonrpcReq.ID, -32603, "Verification failed: sanctions screening unavailable", nil)
+			return
+		}
+	}
+
+	// Serve simulated payments without the facilitator, on testnets only
+	if payment.Simulated {
+		if !h.config.AcceptSimulatedPayments || !x402.IsTestnet(requirement.Network) {
+			h.writeError(w, jsonrpcReq.ID, 402, "Payment invalid: "+x402http.ReasonSimulatedPayment, nil)
+			return
+		}
+		h.forwardAndSettle(w, r, bodyBytes, jsonrpcReq.ID, toolParams.Name, payment, requirement, &facilitator.VerifyResponse{IsValid: true}, logger)
+		return
+	}
+
 	// Verify payment with facilitator
 	ctx, cancel := context.WithTimeout(r.Context(), x402.DefaultTimeouts.VerifyTimeout)
 	defer cancel()
@@ -226,11 +301,33 @@ func (h *X402Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.forwardAndSettle(w, r, bodyBytes, jsonrpcReq.ID, payment, requirement, verifyResp, logger)
+	h.forwardAndSettle(w, r, bodyBytes, jsonrpcReq.ID, toolParams.Name, payment, requirement, verifyResp, logger)
+}
+
+// payerReputation consults the configured ReputationProvider about the payer claimed by
+// payment. It returns nil when no provider is configured, the payer is unknown or the
+// provider fails.
+func (h *X402Handler) payerReputation(ctx context.Context, payment *x402.PaymentPayload, logger *slog.Logger) *x402http.Reputation {
+	if h.config.ReputationProvider == nil {
+		return nil
+	}
+	payer := x402http.PaymentPayer(*payment)
+	if payer == "" {
+		return nil
+	}
+	reputation, err := h.config.ReputationProvider.Reputation(ctx, payer, payment.Network)
+	if err != nil {
+		logger.ErrorContext(ctx, "reputation check failed", "payer", payer, "error", err)
+		return nil
+	}
+	if reputation != nil && reputation.Deny {
+		logger.WarnContext(ctx, "payer denied by reputation", "payer", payer, "signals", reputation.Signals)
+	}
+	return reputation
 }
 
 // checkPaymentRequired checks if a tool requires payment
-func (h *X402Handler) checkPaymentRequired(toolName string) ([]x402.PaymentRequirement, bool) {
+func (h *X402Handler) checkPaymentRequired(ctx context.Context, toolName string) ([]x402.PaymentRequirement, bool) {
 	requirements, exists := h.config.PaymentTools[toolName]
 	if !exists || len(requirements) == 0 {
 		return nil, false
@@ -246,6 +343,12 @@ func (h *X402Handler) checkPaymentRequired(toolName string) ([]x402.PaymentRequi
 		}
 	}
 
+	if h.config.AcceptsSorter != nil {
+		if sorted := h.config.AcceptsSorter(ctx, reqCopy); len(sorted) > 0 {
+			reqCopy = sorted
+		}
+	}
+
 	return reqCopy, true
 }
 
@@ -293,8 +396,33 @@ func (h *X402Handler) sendPaymentRequiredError(w http.ResponseWriter, id interfa
 	h.writeError(w, id, 402, "Payment required", errorData)
 }
 
+// sendPaymentRejectedError sends a 402 error rejecting a call's payment for reason and
+// offering requirements to sign a new payment for.
+func (h *X402Handler) sendPaymentRejectedError(w http.ResponseWriter, id interface{}, requirements []x402.PaymentRequirement, reason string) {
+	errorData := mcp.PaymentRequirements{
+		X402Version: 1,
+		Error:       "Payment rejected",
+		Accepts:     requirements,
+		Reason:      reason,
+	}
+
+	h.writeError(w, id, 402, "Payment rejected: "+reason, errorData)
+}
+
+// repriced reports whether payment, which matched requirement, was signed for a different
+// amount than requirement asks of its payer while base, the advertised requirements,
+// priced it differently. Payments whose amount cannot be read are left to the facilitator.
+func repriced(payment *x402.PaymentPayload, base []x402.PaymentRequirement, requirement *x402.PaymentRequirement) bool {
+	value := x402http.PaymentValue(*payment)
+	if value == "" || value == requirement.MaxAmountRequired {
+		return false
+	}
+	advertised, err := x402.FindMatchingRequirement(*payment, base)
+	return err == nil && advertised.MaxAmountRequired != requirement.MaxAmountRequired
+}
+
 // forwardAndSettle executes the mcpHandler and on success, settles the payment and injects settlement response in result._meta
-func (h *X402Handler) forwardAndSettle(w http.ResponseWriter, r *http.Request, requestBody []byte, requestID interface{}, payment *x402.PaymentPayload, requirement *x402.PaymentRequirement, verifyResp *facilitator.VerifyResponse, logger *slog.Logger) {
+func (h *X402Handler) forwardAndSettle(w http.ResponseWriter, r *http.Request, requestBody []byte, requestID interface{}, toolName string, payment *x402.PaymentPayload, requirement *x402.PaymentRequirement, verifyResp *facilitator.VerifyResponse, logger *slog.Logger) {
 	// Create a response recorder to capture the MCP handler's response
 	recorder := &responseRecorder{
 		headerMap:  make(http.Header),
@@ -341,8 +469,10 @@ func (h *X402Handler) forwardAndSettle(w http.ResponseWriter, r *http.Request, r
 	}
 
 	var settleResp *x402.SettlementResponse
-	// Settle if not verify-only mode
-	if !h.config.VerifyOnly {
+	// Settle if not verify-only mode; simulated payments are never settled
+	if payment.Simulated {
+		settleResp = &x402.SettlementResponse{Success: true, Network: payment.Network, Simulated: true, Reference: requirement.Reference()}
+	} else if !h.config.VerifyOnly {
 		if h.config.Verbose {
 			logger.InfoContext(r.Context(), "Execution successful. Settling payment.")
 		}
@@ -371,11 +501,15 @@ func (h *X402Handler) forwardAndSettle(w http.ResponseWriter, r *http.Request, r
 				payer = verifyResp.Payer
 			}
 			errorData := map[string]interface{}{
-				"x402/payment-response": x402.SettlementResponse{
-					Success:     false,
-					Network:     payment.Network,
-					Payer:       payer,
-					ErrorReason: reason,
+				mcp.PaymentResponseMetaKey: mcp.PaymentResponse{
+					SettlementResponse: x402.SettlementResponse{
+						Success:     false,
+						Network:     payment.Network,
+						Payer:       payer,
+						ErrorReason: reason,
+					},
+					Amount: requirement.MaxAmountRequired,
+					Asset:  requirement.Asset,
 				},
 			}
 			h.writeError(w, requestID, -32603, fmt.Sprintf("Settlement failed: %v", reason), errorData)
@@ -383,6 +517,9 @@ func (h *X402Handler) forwardAndSettle(w http.ResponseWriter, r *http.Request, r
 		} else if h.config.Verbose {
 			logger.InfoContext(settleCtx, "Payment successful", "transaction", settleResp.Transaction)
 		}
+		if settleResp.Reference == "" {
+			settleResp.Reference = requirement.Reference()
+		}
 	}
 
 	if jsonrpcResp.Result != nil {
@@ -393,21 +530,40 @@ func (h *X402Handler) forwardAndSettle(w http.ResponseWriter, r *http.Request, r
 				meta = make(map[string]interface{})
 			}
 
-			// Add settlement response
+			// Add settlement response with the amount paid
+			paymentResponse := mcp.PaymentResponse{
+				Amount: requirement.MaxAmountRequired,
+				Asset:  requirement.Asset,
+			}
 			if settleResp != nil {
-				meta["x402/payment-response"] = settleResp
+				paymentResponse.SettlementResponse = *settleResp
 			} else {
 				payer := ""
 				if verifyResp != nil {
 					payer = verifyResp.Payer
 				}
 				// In verify-only mode: Success=false indicates settlement was skipped (not attempted), not that it failed.
-				meta["x402/payment-response"] = x402.SettlementResponse{
+				paymentResponse.SettlementResponse = x402.SettlementResponse{
 					Success: false,
 					Network: payment.Network,
 					Payer:   payer,
 				}
 			}
+
+			// Queue settled payments of failed tool calls for refund
+			if reason, failed := toolError(result); failed && settleResp != nil && !settleResp.Simulated && h.config.RefundOnToolError != nil {
+				if h.config.Verbose {
+					logger.InfoContext(r.Context(), "Tool returned an error after settlement. Queuing refund.", "transaction", settleResp.Transaction)
+				}
+				h.config.RefundOnToolError(r.Context(), RefundRequest{
+					Tool:        toolName,
+					Requirement: *requirement,
+					Settlement:  *settleResp,
+					Reason:      reason,
+				})
+				paymentResponse.RefundPending = true
+			}
+			meta[mcp.PaymentResponseMetaKey] = paymentResponse
 			result["_meta"] = meta
 
 			// Re-marshal result
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/facilitator"
+	x402http "github.com/mark3labs/x402-go/http"
+	"github.com/mark3labs/x402-go/mcp"
+)
+
+// recordingFacilitator accepts every payment and records the amounts it verified and
+// settled.
+type recordingFacilitator struct {
+	mu       sync.Mutex
+	verified []string
+	settled  []string
+}
+
+func (f *recordingFacilitator) Verify(_ context.Context, _ *x402.PaymentPayload, requirement x402.PaymentRequirement) (*facilitator.VerifyResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.verified = append(f.verified, requirement.MaxAmountRequired)
+	return &facilitator.VerifyResponse{IsValid: true, Payer: "0x857b06519E91e3A54538791bDbb0E22373e36b66"}, nil
+}
+
+func (f *recordingFacilitator) Settle(_ context.Context, _ *x402.PaymentPayload, requirement x402.PaymentRequirement) (*x402.SettlementResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.settled = append(f.settled, requirement.MaxAmountRequired)
+	return &x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: requirement.Network}, nil
+}
+
+func TestX402Handler_ReputationSurcharge(t *testing.T) {
+	requirement := x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base-sepolia",
+		MaxAmountRequired: "1000",
+		Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	}
+	fac := &recordingFacilitator{}
+	tool := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"ok"}]}}`))
+	})
+	handler := NewX402Handler(tool,
+		WithFacilitator(fac),
+		WithPaymentTool("search", requirement),
+		WithReputationProvider(x402http.ReputationFunc(func(context.Context, string, string) (*x402http.Reputation, error) {
+			return &x402http.Reputation{PriceMultiplier: 1.5}, nil
+		})),
+	)
+
+	call := func(value string) map[string]json.RawMessage {
+		t.Helper()
+		payment := x402.PaymentPayload{
+			X402Version: 1,
+			Scheme:      "exact",
+			Network:     "base-sepolia",
+			Payload: x402.EVMPayload{
+				Signature: "0xsig",
+				Authorization: x402.EVMAuthorization{
+					From:  "0x857b06519E91e3A54538791bDbb0E22373e36b66",
+					To:    requirement.PayTo,
+					Value: value,
+				},
+			},
+		}
+		body, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params": map[string]interface{}{
+				"name":      "search",
+				"arguments": map[string]interface{}{},
+				"_meta":     map[string]interface{}{"x402/payment": payment},
+			},
+		})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(string(body))))
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %s: %v", rec.Body.String(), err)
+		}
+		return resp
+	}
+
+	// A payment signed at the advertised price is offered the surcharged price
+	resp := call("1000")
+	var rpcErr struct {
+		Code int                     `json:"code"`
+		Data mcp.PaymentRequirements `json:"data"`
+	}
+	if err := json.Unmarshal(resp["error"], &rpcErr); err != nil || rpcErr.Code != 402 {
+		t.Fatalf("response = %s, want a 402 error", resp["error"])
+	}
+	if rpcErr.Data.Reason != mcp.ReasonPriceChanged || len(rpcErr.Data.Accepts) != 1 || rpcErr.Data.Accepts[0].MaxAmountRequired != "1500" {
+		t.Errorf("402 data = %+v, want reason %s offering 1500", rpcErr.Data, mcp.ReasonPriceChanged)
+	}
+	if len(fac.verified) != 0 {
+		t.Errorf("verified %v for a payment at the wrong price, want nothing", fac.verified)
+	}
+
+	// The payment re-signed at the surcharged price is served and settled
+	resp = call("1500")
+	if resp["error"] != nil {
+		t.Fatalf("re-signed payment rejected: %s", resp["error"])
+	}
+	if len(fac.settled) != 1 || fac.settled[0] != "1500" {
+		t.Errorf("settled %v, want [1500]", fac.settled)
+	}
+}
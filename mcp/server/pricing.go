@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/mcp"
+)
+
+// forwardWithPricing forwards a tools/list request and adds the prices of payable
+// tools to their _meta in the response.
+func (h *X402Handler) forwardWithPricing(w http.ResponseWriter, r *http.Request) {
+	recorder := &responseRecorder{
+		headerMap:  make(http.Header),
+		statusCode: http.StatusOK,
+	}
+	h.mcpHandler.ServeHTTP(recorder, r)
+
+	body := recorder.body.Bytes()
+	if priced, ok := h.addPricing(body); ok {
+		body = priced
+		recorder.headerMap.Del("Content-Length")
+	}
+
+	for k, v := range recorder.headerMap {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(recorder.statusCode)
+	_, _ = w.Write(body)
+}
+
+// addPricing returns the tools/list response body with pricing added to payable tools.
+// It returns false if the body is not a tools/list result, e.g. an error response.
+func (h *X402Handler) addPricing(body []byte) ([]byte, bool) {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil || resp["result"] == nil {
+		return nil, false
+	}
+	// Keep numbers in tool schemas as written
+	var result map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(resp["result"]))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, false
+	}
+	tools, ok := result["tools"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	for _, item := range tools {
+		tool, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := tool["name"].(string)
+		requirements := h.config.PaymentTools[name]
+		if len(requirements) == 0 {
+			continue
+		}
+
+		meta, ok := tool["_meta"].(map[string]interface{})
+		if !ok {
+			meta = make(map[string]interface{})
+		}
+		meta[mcp.PricingMetaKey] = toolPrices(requirements)
+		tool["_meta"] = meta
+	}
+
+	modified, err := json.Marshal(result)
+	if err != nil {
+		return nil, false
+	}
+	resp["result"] = modified
+	priced, err := json.Marshal(resp)
+	if err != nil {
+		return nil, false
+	}
+	return priced, true
+}
+
+// toolPrices returns the advertised prices for a tool's payment requirements.
+func toolPrices(requirements []x402.PaymentRequirement) []mcp.ToolPrice {
+	prices := make([]mcp.ToolPrice, len(requirements))
+	for i, req := range requirements {
+		prices[i] = mcp.ToolPrice{
+			Amount:      req.MaxAmountRequired,
+			Asset:       req.Asset,
+			Network:     req.Network,
+			Scheme:      req.Scheme,
+			PayTo:       req.PayTo,
+			Description: req.Description,
+		}
+	}
+	return prices
+}
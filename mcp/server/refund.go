@@ -0,0 +1,41 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// RefundRequest describes a settled payment for a tool call that returned an error.
+type RefundRequest struct {
+	// Tool is the name of the tool that failed.
+	Tool string
+
+	// Requirement is the payment requirement the payment satisfied.
+	Requirement x402.PaymentRequirement
+
+	// Settlement is the settlement of the payment to refund.
+	Settlement x402.SettlementResponse
+
+	// Reason is the text content of the tool's error result.
+	Reason string
+}
+
+// toolError reports whether a tools/call result is an error result, and returns its
+// text content.
+func toolError(result map[string]interface{}) (string, bool) {
+	if isError, _ := result["isError"].(bool); !isError {
+		return "", false
+	}
+
+	var reason []string
+	content, _ := result["content"].([]interface{})
+	for _, item := range content {
+		if c, ok := item.(map[string]interface{}); ok && c["type"] == "text" {
+			if text, ok := c["text"].(string); ok {
+				reason = append(reason, text)
+			}
+		}
+	}
+	return strings.Join(reason, "\n"), true
+}
@@ -15,23 +15,21 @@ type X402Server struct {
 	config    *Config
 }
 
-// NewX402Server creates a new MCP server with x402 payment support
-func NewX402Server(name, version string, config *Config) *X402Server {
-	if config == nil {
-		config = DefaultConfig()
-	}
-
-	// Initialize PaymentTools map if nil
-	if config.PaymentTools == nil {
-		config.PaymentTools = make(map[string][]x402.PaymentRequirement)
-	}
-
+// NewX402Server creates a new MCP server with x402 payment support.
+//
+// Example:
+//
+//	srv := server.NewX402Server("my-tools", "1.0.0",
+//	    server.WithFacilitatorURL("https://facilitator.x402.rs"),
+//	    server.WithVerifyOnly(),
+//	)
+func NewX402Server(name, version string, opts ...Option) *X402Server {
 	// Create base MCP server
 	mcpServer := mcpserver.NewMCPServer(name, version)
 
 	return &X402Server{
 		mcpServer: mcpServer,
-		config:    config,
+		config:    newConfig(opts),
 	}
 }
 
@@ -71,7 +69,7 @@ func (s *X402Server) Handler() http.Handler {
 	httpServer := mcpserver.NewStreamableHTTPServer(s.mcpServer)
 
 	// Wrap with x402 payment handler
-	return NewX402Handler(httpServer, s.config)
+	return newX402Handler(httpServer, s.config)
 }
 
 // Start starts the MCP server on the given address
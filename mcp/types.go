@@ -1,6 +1,8 @@
 package mcp
 
 import (
+	"encoding/json"
+
 	"github.com/mark3labs/x402-go"
 )
 
@@ -10,4 +12,109 @@ type PaymentRequirements struct {
 	X402Version int                       `json:"x402Version"`
 	Error       string                    `json:"error"`
 	Accepts     []x402.PaymentRequirement `json:"accepts"`
+
+	// Reason is why the call's payment was rejected, if it carried one, e.g.
+	// ReasonPriceChanged.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReasonPriceChanged is the 402 reason for a tool call paid at the advertised price when
+// the server prices the payer differently, e.g. with a reputation surcharge. Accepts
+// holds the payer's own requirements; clients sign a fresh payment for them and retry.
+// It has the value of http.ReasonPriceChanged.
+const ReasonPriceChanged = "price_changed"
+
+// PaymentResponseMetaKey is the _meta key under which the server returns the
+// PaymentResponse of a paid tool call.
+const PaymentResponseMetaKey = "x402/payment-response"
+
+// PaymentResponse is the settlement of a paid tool call, returned in the CallToolResult
+// _meta. It extends the settlement response with the amount and asset paid, so clients
+// can display and record the cost of each call.
+type PaymentResponse struct {
+	x402.SettlementResponse
+
+	// Amount is the amount paid in atomic units.
+	Amount string `json:"amount,omitempty"`
+
+	// Asset is the token address the payment was made in.
+	Asset string `json:"asset,omitempty"`
+
+	// RefundPending indicates the tool call failed after the payment settled and the
+	// payment was queued for refund.
+	RefundPending bool `json:"refundPending,omitempty"`
+}
+
+// paymentResponseFields are the fields PaymentResponse adds to the settlement response.
+type paymentResponseFields struct {
+	Amount        string `json:"amount,omitempty"`
+	Asset         string `json:"asset,omitempty"`
+	RefundPending bool   `json:"refundPending,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. It is needed because the embedded
+// SettlementResponse's MarshalJSON would otherwise drop the fields PaymentResponse adds.
+func (p PaymentResponse) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(paymentResponseFields{Amount: p.Amount, Asset: p.Asset, RefundPending: p.RefundPending})
+	if err != nil {
+		return nil, err
+	}
+	var own map[string]json.RawMessage
+	if err := json.Unmarshal(data, &own); err != nil {
+		return nil, err
+	}
+
+	settlement := p.SettlementResponse
+	settlement.Extra = make(map[string]json.RawMessage, len(p.Extra)+len(own))
+	for key, value := range p.Extra {
+		settlement.Extra[key] = value
+	}
+	for key, value := range own {
+		settlement.Extra[key] = value
+	}
+	return settlement.MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the counterpart of MarshalJSON.
+func (p *PaymentResponse) UnmarshalJSON(data []byte) error {
+	if err := p.SettlementResponse.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	var own paymentResponseFields
+	if err := json.Unmarshal(data, &own); err != nil {
+		return err
+	}
+	p.Amount, p.Asset, p.RefundPending = own.Amount, own.Asset, own.RefundPending
+	for _, key := range []string{"amount", "asset", "refundPending"} {
+		delete(p.Extra, key)
+	}
+	if len(p.Extra) == 0 {
+		p.Extra = nil
+	}
+	return nil
+}
+
+// PricingMetaKey is the _meta key under which the server advertises the ToolPrices of a
+// payable tool in tools/list responses.
+const PricingMetaKey = "x402/pricing"
+
+// ToolPrice is one accepted way to pay for a tool, as advertised in tools/list.
+type ToolPrice struct {
+	// Amount is the price in atomic units of Asset.
+	Amount string `json:"amount"`
+
+	// Asset is the token address the price is paid in.
+	Asset string `json:"asset"`
+
+	// Network is the blockchain network the price is paid on.
+	Network string `json:"network"`
+
+	// Scheme is the payment scheme (e.g., "exact").
+	Scheme string `json:"scheme"`
+
+	// PayTo is the recipient of the payment.
+	PayTo string `json:"payTo"`
+
+	// Description describes what the payment is for.
+	Description string `json:"description,omitempty"`
 }
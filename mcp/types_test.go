@@ -0,0 +1,45 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+func TestPaymentResponse_JSON(t *testing.T) {
+	response := PaymentResponse{
+		SettlementResponse: x402.SettlementResponse{
+			Success:     true,
+			Transaction: "0xtx",
+			Network:     "base",
+			Payer:       "0xpayer",
+			Extra:       map[string]json.RawMessage{"confirmations": json.RawMessage("3")},
+		},
+		Amount:        "10000",
+		Asset:         "0xasset",
+		RefundPending: true,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, want := range []string{`"amount":"10000"`, `"asset":"0xasset"`, `"refundPending":true`, `"transaction":"0xtx"`, `"confirmations":3`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("encoded response %s missing %s", data, want)
+		}
+	}
+
+	var decoded PaymentResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Amount != "10000" || decoded.Asset != "0xasset" || !decoded.RefundPending || decoded.Transaction != "0xtx" {
+		t.Errorf("decoded = %+v", decoded)
+	}
+	if len(decoded.Extra) != 1 || string(decoded.Extra["confirmations"]) != "3" {
+		t.Errorf("decoded Extra = %v, want only confirmations", decoded.Extra)
+	}
+}
@@ -0,0 +1,45 @@
+package x402
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// PriceOracle reports exchange rates of payment tokens, for converting payment amounts
+// to fiat values. Implementations must be safe for concurrent use.
+type PriceOracle interface {
+	// Rate returns the value in currency (an ISO 4217 code such as "USD") of one whole
+	// token of asset on network.
+	Rate(ctx context.Context, network, asset, currency string) (*big.Rat, error)
+}
+
+// FixedRates is a PriceOracle with a fixed rate per currency for every asset, keyed by
+// uppercase currency code, e.g. FixedRates{"USD": "1", "EUR": "0.92"} for USDC. Rates
+// are decimal strings.
+type FixedRates map[string]string
+
+// Rate implements PriceOracle.
+func (r FixedRates) Rate(_ context.Context, _, _, currency string) (*big.Rat, error) {
+	rate, ok := r[strings.ToUpper(currency)]
+	if !ok {
+		return nil, fmt.Errorf("x402: no rate for currency %q", currency)
+	}
+	value, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return nil, fmt.Errorf("x402: invalid rate %q for currency %s", rate, currency)
+	}
+	return value, nil
+}
+
+// FiatValue returns the value of amount of asset on network in currency, using the
+// oracle's current rate, as a decimal string with 6 fractional digits.
+func FiatValue(ctx context.Context, oracle PriceOracle, network, asset string, amount Amount, currency string) (string, error) {
+	rate, err := oracle.Rate(ctx, network, asset, currency)
+	if err != nil {
+		return "", err
+	}
+	value := new(big.Rat).SetFrac(amount.Atomic(), pow10(amount.Decimals()))
+	return value.Mul(value, rate).FloatString(6), nil
+}
@@ -0,0 +1,46 @@
+package x402
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFiatValue(t *testing.T) {
+	oracle := FixedRates{"USD": "1", "EUR": "0.92"}
+
+	tests := []struct {
+		name     string
+		atomic   string
+		currency string
+		want     string
+		wantErr  bool
+	}{
+		{name: "USD", atomic: "1500000", currency: "USD", want: "1.500000"},
+		{name: "EUR", atomic: "1500000", currency: "EUR", want: "1.380000"},
+		{name: "lowercase currency", atomic: "1000", currency: "usd", want: "0.001000"},
+		{name: "unknown currency", atomic: "1000", currency: "GBP", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, _ := ParseAtomicAmount(tt.atomic, 6)
+			got, err := FiatValue(context.Background(), oracle, "base", BaseMainnet.USDCAddress, amount, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FiatValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FiatValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupUSDC(t *testing.T) {
+	chain, ok := LookupUSDC("base", "0x833589FCD6EDB6E08F4C7C32D4F71B54BDA02913")
+	if !ok || chain.Decimals != 6 {
+		t.Errorf("LookupUSDC(base) = %+v, %v", chain, ok)
+	}
+	if _, ok := LookupUSDC("polygon", BaseMainnet.USDCAddress); ok {
+		t.Error("expected no match for USDC address on another network")
+	}
+}
@@ -0,0 +1,111 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxTxRetries bounds optimistic transaction retries under contention.
+const maxTxRetries = 16
+
+// BudgetStore keeps x402.SpendingLimit budgets in Redis so a fleet of clients paying
+// from one wallet shares a single limit. Amounts are stored as decimal strings and
+// updated in WATCH/MULTI transactions, so arbitrarily large token amounts are exact.
+// BudgetStore is safe for concurrent use.
+type BudgetStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewBudgetStore creates a BudgetStore using client. Every key is prefixed with prefix.
+func NewBudgetStore(client redis.UniversalClient, prefix string) *BudgetStore {
+	return &BudgetStore{client: client, prefix: prefix}
+}
+
+// Reserve implements x402.BudgetStore.
+func (s *BudgetStore) Reserve(ctx context.Context, key string, amount, limit *big.Int, window time.Duration) (bool, error) {
+	budgetKey := s.prefix + "budget:" + key
+	var reserved bool
+
+	err := s.update(ctx, budgetKey, func(tx *redis.Tx, spent *big.Int, exists bool) error {
+		total := new(big.Int).Add(spent, amount)
+		if total.Cmp(limit) > 0 {
+			reserved = false
+			return nil
+		}
+
+		// A new budget starts its window; an existing one keeps its expiry
+		ttl := time.Duration(redis.KeepTTL)
+		if !exists {
+			ttl = window
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, budgetKey, total.String(), ttl)
+			return nil
+		})
+		reserved = err == nil
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("redis reserve %s: %w", key, err)
+	}
+	return reserved, nil
+}
+
+// Release implements x402.BudgetStore.
+func (s *BudgetStore) Release(ctx context.Context, key string, amount *big.Int) error {
+	budgetKey := s.prefix + "budget:" + key
+
+	err := s.update(ctx, budgetKey, func(tx *redis.Tx, spent *big.Int, exists bool) error {
+		if !exists {
+			// The window has already reset
+			return nil
+		}
+		remaining := new(big.Int).Sub(spent, amount)
+		if remaining.Sign() < 0 {
+			remaining.SetInt64(0)
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, budgetKey, remaining.String(), redis.KeepTTL)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("redis release %s: %w", key, err)
+	}
+	return nil
+}
+
+// update runs fn in an optimistic transaction on budgetKey with the current spend,
+// retrying when another client modified the key concurrently.
+func (s *BudgetStore) update(ctx context.Context, budgetKey string, fn func(tx *redis.Tx, spent *big.Int, exists bool) error) error {
+	txf := func(tx *redis.Tx) error {
+		value, err := tx.Get(ctx, budgetKey).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		exists := err == nil
+
+		spent := new(big.Int)
+		if exists {
+			if _, ok := spent.SetString(value, 10); !ok {
+				return fmt.Errorf("malformed budget value %q", value)
+			}
+		}
+		return fn(tx, spent, exists)
+	}
+
+	for i := 0; i < maxTxRetries; i++ {
+		err := s.client.Watch(ctx, txf, budgetKey)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("too much contention on %s", budgetKey)
+}
@@ -0,0 +1,87 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+var _ x402.BudgetStore = (*BudgetStore)(nil)
+
+func TestBudgetStore_ReserveAndRelease(t *testing.T) {
+	store, mr := newTestStore(t, 0)
+	budgets := NewBudgetStore(store.client, "x402:")
+	ctx := context.Background()
+	limit := big.NewInt(100)
+
+	steps := []struct {
+		name    string
+		amount  int64
+		release bool
+		want    bool
+	}{
+		{"within limit", 60, false, true},
+		{"exceeds remaining", 50, false, false},
+		{"fills remaining", 40, false, true},
+		{"release returns budget", 40, true, true},
+		{"reserve released amount", 40, false, true},
+	}
+
+	for _, step := range steps {
+		if step.release {
+			if err := budgets.Release(ctx, "fleet", big.NewInt(step.amount)); err != nil {
+				t.Fatalf("%s: Release failed: %v", step.name, err)
+			}
+			continue
+		}
+		got, err := budgets.Reserve(ctx, "fleet", big.NewInt(step.amount), limit, time.Hour)
+		if err != nil {
+			t.Fatalf("%s: Reserve failed: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: Reserve = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// The window starts with the first reservation and is not extended by later ones
+	mr.FastForward(time.Hour)
+	got, err := budgets.Reserve(ctx, "fleet", big.NewInt(100), limit, time.Hour)
+	if err != nil || !got {
+		t.Errorf("Reserve after window = %v, %v; want true, nil", got, err)
+	}
+}
+
+func TestBudgetStore_ConcurrentClients(t *testing.T) {
+	store, _ := newTestStore(t, 0)
+	limit, err := x402.NewSpendingLimit("10", 0, NewBudgetStore(store.client, "x402:"))
+	if err != nil {
+		t.Fatalf("NewSpendingLimit failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var reserved atomic.Int32
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := limit.Reserve(context.Background(), big.NewInt(1))
+			switch {
+			case err == nil:
+				reserved.Add(1)
+			case !errors.Is(err, x402.ErrBudgetExceeded):
+				t.Errorf("Reserve failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved.Load() != 10 {
+		t.Errorf("Expected exactly 10 reservations, got %d", reserved.Load())
+	}
+}
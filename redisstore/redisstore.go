@@ -0,0 +1,69 @@
+// Package redisstore provides Redis-backed implementations of the x402 stores, so that
+// several server replicas behind a load balancer share replay protection, free quotas,
+// session limits and coupon redemptions, and a fleet of clients shares one spending limit.
+//
+// A single Store satisfies http.NonceStore, http.QuotaStore and coupons.Store:
+//
+//	store := redisstore.New(redis.NewClient(&redis.Options{Addr: "localhost:6379"}), "x402:", 24*time.Hour)
+//	config.NonceStore = store
+//	config.FreeQuota = &x402http.FreeQuota{Limit: 10, Store: store}
+//
+// BudgetStore backs x402.SpendingLimit on the client side.
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps claims and counters in Redis.
+// Store is safe for concurrent use.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+	window time.Duration
+}
+
+// New creates a Store using client. Every key is prefixed with prefix, so several
+// services can share one Redis database. Counters created by Increment expire after
+// window; a zero window keeps them forever.
+func New(client redis.UniversalClient, prefix string, window time.Duration) *Store {
+	return &Store{client: client, prefix: prefix, window: window}
+}
+
+// Claim atomically records key for ttl with SET NX and reports whether this call
+// created it. It implements http.NonceStore.
+func (s *Store) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	claimed, err := s.client.SetNX(ctx, s.prefix+"claim:"+key, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis claim %s: %w", key, err)
+	}
+	return claimed, nil
+}
+
+// Release deletes a claim. It implements http.NonceStore.
+func (s *Store) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+"claim:"+key).Err(); err != nil {
+		return fmt.Errorf("redis release %s: %w", key, err)
+	}
+	return nil
+}
+
+// Increment adds one to the counter for key and returns the new count. The counter's
+// window starts with its first increment. It implements http.QuotaStore and coupons.Store.
+func (s *Store) Increment(ctx context.Context, key string) (int, error) {
+	counterKey := s.prefix + "count:" + key
+	count, err := s.client.Incr(ctx, counterKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("redis increment %s: %w", key, err)
+	}
+	if count == 1 && s.window > 0 {
+		if err := s.client.Expire(ctx, counterKey, s.window).Err(); err != nil {
+			return 0, fmt.Errorf("redis expire %s: %w", key, err)
+		}
+	}
+	return int(count), nil
+}
@@ -0,0 +1,92 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mark3labs/x402-go/coupons"
+	x402http "github.com/mark3labs/x402-go/http"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_ x402http.NonceStore = (*Store)(nil)
+	_ x402http.QuotaStore = (*Store)(nil)
+	_ coupons.Store       = (*Store)(nil)
+)
+
+func newTestStore(t *testing.T, window time.Duration) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return New(client, "x402:", window), mr
+}
+
+func TestStore_Claim(t *testing.T) {
+	store, mr := newTestStore(t, 0)
+	ctx := context.Background()
+
+	// Two stores on the same Redis behave like two replicas
+	replicaClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer replicaClient.Close()
+	replica := New(replicaClient, "x402:", 0)
+
+	claimed, err := store.Claim(ctx, "base-sepolia:0xabc:0x01", time.Minute)
+	if err != nil || !claimed {
+		t.Fatalf("First claim = %v, %v; want true, nil", claimed, err)
+	}
+	claimed, err = replica.Claim(ctx, "base-sepolia:0xabc:0x01", time.Minute)
+	if err != nil || claimed {
+		t.Fatalf("Replayed claim = %v, %v; want false, nil", claimed, err)
+	}
+
+	if err := store.Release(ctx, "base-sepolia:0xabc:0x01"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	claimed, err = replica.Claim(ctx, "base-sepolia:0xabc:0x01", time.Minute)
+	if err != nil || !claimed {
+		t.Fatalf("Claim after release = %v, %v; want true, nil", claimed, err)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	claimed, err = store.Claim(ctx, "base-sepolia:0xabc:0x01", time.Minute)
+	if err != nil || !claimed {
+		t.Errorf("Claim after expiry = %v, %v; want true, nil", claimed, err)
+	}
+}
+
+func TestStore_Increment(t *testing.T) {
+	store, mr := newTestStore(t, time.Hour)
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		count, err := store.Increment(ctx, "1.2.3.4")
+		if err != nil {
+			t.Fatalf("Increment failed: %v", err)
+		}
+		if count != want {
+			t.Errorf("Increment = %d, want %d", count, want)
+		}
+	}
+
+	mr.FastForward(2 * time.Hour)
+	count, err := store.Increment(ctx, "1.2.3.4")
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Increment after window = %d, want 1", count)
+	}
+}
+
+func TestStore_ClientError(t *testing.T) {
+	store, mr := newTestStore(t, 0)
+	mr.Close()
+
+	if _, err := store.Claim(context.Background(), "key", time.Minute); err == nil {
+		t.Error("Expected error when Redis is unavailable")
+	}
+}
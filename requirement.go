@@ -0,0 +1,150 @@
+package x402
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Payment schemes.
+const (
+	// SchemeExact is the "exact" payment scheme, supported on EVM and SVM networks. On EVM
+	// networks the payer signs an EIP-3009 transferWithAuthorization.
+	SchemeExact = "exact"
+
+	// SchemePermit is the "permit" payment scheme, supported on EVM networks for tokens
+	// implementing EIP-2612 but not EIP-3009. The payer signs a permit allowing the
+	// facilitator's spender address to move the amount, and the facilitator settles with
+	// permit and transferFrom.
+	SchemePermit = "permit"
+)
+
+// Extra keys used by the exact and permit schemes.
+const (
+	// ExtraName is the EIP-712 domain name of the asset on EVM networks.
+	ExtraName = "name"
+
+	// ExtraVersion is the EIP-712 domain version of the asset on EVM networks.
+	ExtraVersion = "version"
+
+	// ExtraFeePayer is the address paying the transaction fees on SVM networks,
+	// usually the facilitator's.
+	ExtraFeePayer = "feePayer"
+
+	// ExtraSpender is the address the permit scheme authorizes to transfer the payment,
+	// usually the facilitator's.
+	ExtraSpender = "spender"
+
+	// ExtraReceiveWithAuthorization, set to true on an exact requirement on an EVM
+	// network, asks the payer to sign an EIP-3009 receiveWithAuthorization rather than a
+	// transferWithAuthorization. Only the payee can submit it, so the payment cannot be
+	// front-run, but the payTo account must settle it, e.g. with a self-settling
+	// facilitator relaying from that account.
+	ExtraReceiveWithAuthorization = "receiveWithAuthorization"
+
+	// ExtraReference is an opaque reference, such as an order ID, that the payee
+	// attaches to a requirement to reconcile the payment with. Clients copy it into the
+	// PaymentPayload, the SVM signer records it in a Memo instruction, and servers return
+	// it in the SettlementResponse. It may be set on a requirement of any scheme.
+	ExtraReference = "reference"
+)
+
+// SetFeePayer sets the fee payer of an exact requirement on an SVM network. The Extra
+// map is copied, so requirements sharing it are not modified.
+func (r *PaymentRequirement) SetFeePayer(address string) {
+	r.setExtra(map[string]interface{}{ExtraFeePayer: address})
+}
+
+// SetEIP3009Domain sets the EIP-3009 domain name and version of the asset of an exact
+// requirement on an EVM network (e.g. "USD Coin" and "2" for USDC on Base). The Extra
+// map is copied, so requirements sharing it are not modified.
+func (r *PaymentRequirement) SetEIP3009Domain(name, version string) {
+	r.setExtra(map[string]interface{}{ExtraName: name, ExtraVersion: version})
+}
+
+// SetReceiveWithAuthorization asks for an EIP-3009 receiveWithAuthorization on an exact
+// requirement on an EVM network. The Extra map is copied, so requirements sharing it are
+// not modified.
+func (r *PaymentRequirement) SetReceiveWithAuthorization() {
+	r.setExtra(map[string]interface{}{ExtraReceiveWithAuthorization: true})
+}
+
+// ReceiveWithAuthorization reports whether the requirement asks for an EIP-3009
+// receiveWithAuthorization. See ExtraReceiveWithAuthorization.
+func (r PaymentRequirement) ReceiveWithAuthorization() bool {
+	receive, _ := r.Extra[ExtraReceiveWithAuthorization].(bool)
+	return receive
+}
+
+// SetReference sets the reference of the requirement, see ExtraReference. The Extra map
+// is copied, so requirements sharing it are not modified.
+func (r *PaymentRequirement) SetReference(reference string) {
+	r.setExtra(map[string]interface{}{ExtraReference: reference})
+}
+
+// Reference returns the reference of the requirement, or "" if it has none. See
+// ExtraReference.
+func (r PaymentRequirement) Reference() string {
+	reference, _ := r.Extra[ExtraReference].(string)
+	return reference
+}
+
+// setPaymentReference copies the reference of requirement into payment, unless the
+// signer set one.
+func setPaymentReference(payment *PaymentPayload, requirement *PaymentRequirement) {
+	if payment != nil && payment.Reference == "" {
+		payment.Reference = requirement.Reference()
+	}
+}
+
+// setExtra replaces Extra with a copy holding values.
+func (r *PaymentRequirement) setExtra(values map[string]interface{}) {
+	extra := make(map[string]interface{}, len(r.Extra)+len(values))
+	for k, v := range r.Extra {
+		extra[k] = v
+	}
+	for k, v := range values {
+		extra[k] = v
+	}
+	r.Extra = extra
+}
+
+// SetSpender sets the spender of a permit requirement. The Extra map is copied, so
+// requirements sharing it are not modified.
+func (r *PaymentRequirement) SetSpender(address string) {
+	r.setExtra(map[string]interface{}{ExtraSpender: address})
+}
+
+// ValidateForScheme checks that Extra holds the fields the requirement's scheme needs
+// on its network: for the exact scheme, the EIP-3009 name and version on EVM networks
+// and the feePayer on SVM networks; for the permit scheme, the EIP-712 name and version
+// and the spender. Without them clients cannot sign a payment. Requirements for other
+// schemes or unknown networks are not checked.
+//
+// It returns an error wrapping ErrInvalidRequirements that names the missing fields.
+func (r PaymentRequirement) ValidateForScheme() error {
+	var required []string
+	networkType, _ := ValidateNetwork(r.Network)
+	switch {
+	case r.Scheme == SchemeExact && networkType == NetworkTypeEVM:
+		required = []string{ExtraName, ExtraVersion}
+	case r.Scheme == SchemeExact && networkType == NetworkTypeSVM:
+		required = []string{ExtraFeePayer}
+	case r.Scheme == SchemePermit && networkType == NetworkTypeEVM:
+		required = []string{ExtraName, ExtraVersion, ExtraSpender}
+	case r.Scheme == SchemePermit && networkType == NetworkTypeSVM:
+		return fmt.Errorf("%w: %s scheme is not supported on %s", ErrInvalidRequirements, r.Scheme, r.Network)
+	default:
+		return nil
+	}
+
+	var missing []string
+	for _, key := range required {
+		if value, ok := r.Extra[key].(string); !ok || value == "" {
+			missing = append(missing, "extra."+key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s scheme on %s requires %s", ErrInvalidRequirements, r.Scheme, r.Network, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package x402
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPaymentRequirement_ValidateForScheme(t *testing.T) {
+	tests := []struct {
+		name        string
+		requirement PaymentRequirement
+		wantMissing []string
+	}{
+		{
+			name:        "evm with domain",
+			requirement: PaymentRequirement{Scheme: "exact", Network: "base", Extra: map[string]interface{}{"name": "USD Coin", "version": "2"}},
+		},
+		{
+			name:        "evm without extra",
+			requirement: PaymentRequirement{Scheme: "exact", Network: "base"},
+			wantMissing: []string{"extra.name", "extra.version"},
+		},
+		{
+			name:        "evm with empty version",
+			requirement: PaymentRequirement{Scheme: "exact", Network: "base-sepolia", Extra: map[string]interface{}{"name": "USDC", "version": ""}},
+			wantMissing: []string{"extra.version"},
+		},
+		{
+			name:        "svm with fee payer",
+			requirement: PaymentRequirement{Scheme: "exact", Network: "solana", Extra: map[string]interface{}{"feePayer": "2wKupLR9q6wXYppw8Gr2NvWxKBUqm4PPJKkQfoxHDBg4"}},
+		},
+		{
+			name:        "svm without fee payer",
+			requirement: PaymentRequirement{Scheme: "exact", Network: "solana-devnet"},
+			wantMissing: []string{"extra.feePayer"},
+		},
+		{
+			name:        "svm with non-string fee payer",
+			requirement: PaymentRequirement{Scheme: "exact", Network: "solana", Extra: map[string]interface{}{"feePayer": 42}},
+			wantMissing: []string{"extra.feePayer"},
+		},
+		{
+			name:        "permit without spender",
+			requirement: PaymentRequirement{Scheme: "permit", Network: "base", Extra: map[string]interface{}{"name": "Dai Stablecoin", "version": "1"}},
+			wantMissing: []string{"extra.spender"},
+		},
+		{
+			name:        "permit on svm",
+			requirement: PaymentRequirement{Scheme: "permit", Network: "solana", Extra: map[string]interface{}{"feePayer": "2wKupLR9q6wXYppw8Gr2NvWxKBUqm4PPJKkQfoxHDBg4"}},
+			wantMissing: []string{"not supported"},
+		},
+		{
+			name:        "other scheme",
+			requirement: PaymentRequirement{Scheme: "upto", Network: "solana"},
+		},
+		{
+			name:        "unknown network",
+			requirement: PaymentRequirement{Scheme: "exact", Network: "unknown-chain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.requirement.ValidateForScheme()
+			if len(tt.wantMissing) == 0 {
+				if err != nil {
+					t.Errorf("ValidateForScheme() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidRequirements) {
+				t.Fatalf("ValidateForScheme() = %v, want ErrInvalidRequirements", err)
+			}
+			for _, field := range tt.wantMissing {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("ValidateForScheme() = %q, want it to name %s", err, field)
+				}
+			}
+		})
+	}
+}
+
+func TestPaymentRequirement_SetExtra(t *testing.T) {
+	shared := map[string]interface{}{"custom": "value"}
+	requirement := PaymentRequirement{Scheme: "exact", Network: "solana", Extra: shared}
+
+	requirement.SetFeePayer("2wKupLR9q6wXYppw8Gr2NvWxKBUqm4PPJKkQfoxHDBg4")
+	if err := requirement.ValidateForScheme(); err != nil {
+		t.Errorf("ValidateForScheme() after SetFeePayer = %v", err)
+	}
+	if requirement.Extra["custom"] != "value" {
+		t.Errorf("Extra[custom] = %v, want value", requirement.Extra["custom"])
+	}
+	if _, ok := shared["feePayer"]; ok {
+		t.Error("SetFeePayer modified the shared Extra map")
+	}
+
+	var evm PaymentRequirement
+	evm.Scheme, evm.Network = "exact", "base"
+	evm.SetEIP3009Domain("USD Coin", "2")
+	if evm.Extra["name"] != "USD Coin" || evm.Extra["version"] != "2" {
+		t.Errorf("Extra = %v, want the EIP-3009 domain", evm.Extra)
+	}
+	if err := evm.ValidateForScheme(); err != nil {
+		t.Errorf("ValidateForScheme() after SetEIP3009Domain = %v", err)
+	}
+
+	if evm.ReceiveWithAuthorization() {
+		t.Error("ReceiveWithAuthorization() = true without the flag")
+	}
+	evm.SetReceiveWithAuthorization()
+	if !evm.ReceiveWithAuthorization() || evm.Extra["name"] != "USD Coin" {
+		t.Errorf("Extra = %v, want the receiveWithAuthorization flag added to the domain", evm.Extra)
+	}
+
+	if evm.Reference() != "" {
+		t.Errorf("Reference() = %q without a reference", evm.Reference())
+	}
+	evm.SetReference("order-7")
+	if evm.Reference() != "order-7" || !evm.ReceiveWithAuthorization() {
+		t.Errorf("Extra = %v, want the reference added to the flags", evm.Extra)
+	}
+}
@@ -0,0 +1,486 @@
+// Package revshare splits the payments of a multi-tenant deployment (see
+// http.NewTenantMiddleware) between the platform and its tenants. Tenants' requirements
+// pay the platform's hot wallet; a Splitter records each settled payment as an Entry
+// with the platform's fee and the tenant's share, and periodically pays each tenant's
+// accrued share out to the tenant's wallet with a treasury.Wallet.
+//
+// Example usage:
+//
+//	shares := revshare.NewMemoryShareStore(map[string]*revshare.Share{
+//	    "acme": {FeeBasisPoints: 250, Wallets: map[string]string{"base": "0xAcme..."}},
+//	})
+//	splitter, err := revshare.New(revshare.NewMemoryLedger(), shares, []treasury.Wallet{baseWallet},
+//	    revshare.WithMinimumPayout("1000000"), // 1 USDC
+//	)
+//	tenantConfig.FacilitatorOnAfterSettle = splitter.OnAfterSettle
+//	go splitter.Run(ctx)
+package revshare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+	x402http "github.com/mark3labs/x402-go/http"
+	"github.com/mark3labs/x402-go/treasury"
+)
+
+// DefaultInterval is how often Run pays out tenant shares by default.
+const DefaultInterval = time.Hour
+
+// maxBasisPoints is a fee of 100%.
+const maxBasisPoints = 10_000
+
+// ErrPayoutFailed indicates the transfer of a tenant's share failed.
+var ErrPayoutFailed = errors.New("x402: revenue share payout failed")
+
+// Share is a tenant's revenue share configuration.
+type Share struct {
+	// FeeBasisPoints is the platform's fee in hundredths of a percent of each payment,
+	// e.g. 250 for 2.5%.
+	FeeBasisPoints int
+
+	// Wallets are the tenant's payout addresses by network. Shares of payments on a
+	// network without one accrue until it is added.
+	Wallets map[string]string
+}
+
+// ShareStore looks up the Share of each tenant. Implementations must be safe for
+// concurrent use.
+type ShareStore interface {
+	// Share returns the share of tenant, or nil to apply the Splitter's default fee
+	// with no payout wallets.
+	Share(ctx context.Context, tenant string) (*Share, error)
+}
+
+// EntryStatus is whether a tenant's share of a payment was paid out.
+type EntryStatus string
+
+const (
+	// EntryUnpaid means the tenant's share is owed.
+	EntryUnpaid EntryStatus = "unpaid"
+
+	// EntryPaid means the tenant's share was transferred.
+	EntryPaid EntryStatus = "paid"
+)
+
+// Entry is the accounting entry of one settled payment, split between the platform and
+// a tenant. Amounts are atomic units of the payment's asset.
+type Entry struct {
+	// Transaction is the payment's settlement transaction, which identifies the entry.
+	Transaction string    `json:"transaction"`
+	Tenant      string    `json:"tenant"`
+	Network     string    `json:"network"`
+	Asset       string    `json:"asset"`
+	Payer       string    `json:"payer"`
+	Gross       string    `json:"gross"`
+	Fee         string    `json:"fee"`
+	TenantShare string    `json:"tenantShare"`
+	SettledAt   time.Time `json:"settledAt"`
+
+	Status EntryStatus `json:"status"`
+
+	// Payout is the transaction paying out the tenant's share, once paid.
+	Payout string    `json:"payout,omitempty"`
+	PaidAt time.Time `json:"paidAt,omitempty"`
+}
+
+// Ledger stores accounting entries. Implementations must be safe for concurrent use.
+type Ledger interface {
+	// Add stores a new entry and reports whether it did; an entry for the same
+	// transaction is kept and false returned.
+	Add(ctx context.Context, entry Entry) (bool, error)
+
+	// Unpaid returns the entries with EntryUnpaid.
+	Unpaid(ctx context.Context) ([]Entry, error)
+
+	// MarkPaid records that the entries of transactions were paid out in payout.
+	MarkPaid(ctx context.Context, transactions []string, payout string, paidAt time.Time) error
+
+	// Entries returns the entries of tenant, oldest first.
+	Entries(ctx context.Context, tenant string) ([]Entry, error)
+}
+
+// Payout is the transfer of a tenant's accrued share on one network.
+type Payout struct {
+	Tenant      string   `json:"tenant"`
+	Network     string   `json:"network"`
+	To          string   `json:"to"`
+	Amount      string   `json:"amount"`
+	Entries     []string `json:"entries"`
+	Transaction string   `json:"transaction,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// Splitter splits settled payments between the platform and tenants and pays tenants
+// their shares. It is safe for concurrent use, but payouts should not overlap; use a
+// single Run loop.
+type Splitter struct {
+	ledger        Ledger
+	shares        ShareStore
+	wallets       map[string]treasury.Wallet
+	defaultFee    int
+	minimumPayout *big.Int
+	interval      time.Duration
+	logger        *slog.Logger
+	now           func() time.Time
+}
+
+// Option configures a Splitter.
+type Option func(*Splitter) error
+
+// New creates a Splitter recording entries in ledger, with tenants' fees from shares,
+// paying out from wallets: the platform's hot wallets, one per network, that tenants'
+// requirements pay to.
+func New(ledger Ledger, shares ShareStore, wallets []treasury.Wallet, opts ...Option) (*Splitter, error) {
+	if ledger == nil {
+		return nil, errors.New("revshare: ledger is nil")
+	}
+	if shares == nil {
+		return nil, errors.New("revshare: share store is nil")
+	}
+	s := &Splitter{
+		ledger:        ledger,
+		shares:        shares,
+		wallets:       make(map[string]treasury.Wallet, len(wallets)),
+		minimumPayout: new(big.Int),
+		interval:      DefaultInterval,
+		logger:        slog.Default(),
+		now:           time.Now,
+	}
+	for _, wallet := range wallets {
+		if wallet == nil {
+			return nil, errors.New("revshare: wallet is nil")
+		}
+		if _, dup := s.wallets[wallet.Network()]; dup {
+			return nil, fmt.Errorf("revshare: more than one wallet for network %s", wallet.Network())
+		}
+		s.wallets[wallet.Network()] = wallet
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// WithDefaultFee sets the platform's fee, in basis points, for tenants without a Share
+// (default 0).
+func WithDefaultFee(basisPoints int) Option {
+	return func(s *Splitter) error {
+		if err := validateFee(basisPoints); err != nil {
+			return err
+		}
+		s.defaultFee = basisPoints
+		return nil
+	}
+}
+
+// WithMinimumPayout sets the share, in atomic units, a tenant must accrue on a network
+// before it is paid out (default 0), so payouts are not eaten by transaction fees.
+func WithMinimumPayout(atomic string) Option {
+	return func(s *Splitter) error {
+		amount, ok := new(big.Int).SetString(atomic, 10)
+		if !ok || amount.Sign() < 0 {
+			return fmt.Errorf("revshare: minimum payout: %w: %q", x402.ErrInvalidAmount, atomic)
+		}
+		s.minimumPayout = amount
+		return nil
+	}
+}
+
+// WithInterval sets how often Run pays out tenant shares (default DefaultInterval).
+func WithInterval(d time.Duration) Option {
+	return func(s *Splitter) error {
+		if d <= 0 {
+			return fmt.Errorf("revshare: interval must be positive, got %v", d)
+		}
+		s.interval = d
+		return nil
+	}
+}
+
+// WithLogger sets the logger (default slog.Default()).
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Splitter) error {
+		if logger == nil {
+			return errors.New("revshare: logger is nil")
+		}
+		s.logger = logger
+		return nil
+	}
+}
+
+// OnAfterSettle records the split of payments settled for the tenant of the request
+// (see http.TenantFromContext). It matches http.OnAfterSettleFunc, so it can be set as
+// a tenant Config's FacilitatorOnAfterSettle. Failed and simulated settlements, and
+// payments outside a tenant's request, are ignored.
+func (s *Splitter) OnAfterSettle(ctx context.Context, _ x402.PaymentPayload, requirement x402.PaymentRequirement, settlement *x402.SettlementResponse, err error) {
+	if err != nil || settlement == nil || !settlement.Success || settlement.Simulated {
+		return
+	}
+	tenant, ok := x402http.TenantFromContext(ctx)
+	if !ok {
+		return
+	}
+	if _, err := s.Split(context.WithoutCancel(ctx), tenant, requirement, *settlement); err != nil {
+		s.logger.Error("failed to record revenue share", "tenant", tenant, "transaction", settlement.Transaction, "error", err)
+	}
+}
+
+// Split records the split of a settled payment for tenant and returns its entry. The fee
+// is rounded down, so rounding favors the tenant. Payments that did not pay the
+// platform's wallet on their network cannot be split and return an error.
+func (s *Splitter) Split(ctx context.Context, tenant string, requirement x402.PaymentRequirement, settlement x402.SettlementResponse) (*Entry, error) {
+	wallet, ok := s.wallets[requirement.Network]
+	if !ok || !strings.EqualFold(requirement.PayTo, wallet.Address()) {
+		return nil, fmt.Errorf("revshare: payment to %s on %s was not made to a platform wallet", requirement.PayTo, requirement.Network)
+	}
+	gross, ok := new(big.Int).SetString(requirement.MaxAmountRequired, 10)
+	if !ok || gross.Sign() < 0 {
+		return nil, fmt.Errorf("revshare: %w: %q", x402.ErrInvalidAmount, requirement.MaxAmountRequired)
+	}
+	basisPoints, err := s.feeOf(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	fee := new(big.Int).Mul(gross, big.NewInt(int64(basisPoints)))
+	fee.Quo(fee, big.NewInt(maxBasisPoints))
+
+	settledAt := s.now().UTC()
+	if settlement.SettledAt > 0 {
+		settledAt = time.Unix(settlement.SettledAt, 0).UTC()
+	}
+	entry := Entry{
+		Transaction: settlement.Transaction,
+		Tenant:      tenant,
+		Network:     requirement.Network,
+		Asset:       requirement.Asset,
+		Payer:       settlement.Payer,
+		Gross:       gross.String(),
+		Fee:         fee.String(),
+		TenantShare: new(big.Int).Sub(gross, fee).String(),
+		SettledAt:   settledAt,
+		Status:      EntryUnpaid,
+	}
+	if _, err := s.ledger.Add(ctx, entry); err != nil {
+		return nil, fmt.Errorf("revshare: failed to add entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// Run pays out tenant shares every interval until ctx is done, and returns ctx.Err().
+// Failed payouts are logged and retried on the next tick.
+func (s *Splitter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := s.PayOut(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("revenue share payout failed", "error", err)
+			}
+		}
+	}
+}
+
+// PayOut transfers each tenant's unpaid share on each network to the tenant's wallet
+// there, once it reaches the minimum payout, and marks the entries paid. It returns the
+// payouts attempted; failed ones keep their entries unpaid and are reported in the
+// error, wrapping ErrPayoutFailed.
+func (s *Splitter) PayOut(ctx context.Context) ([]Payout, error) {
+	unpaid, err := s.ledger.Unpaid(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("revshare: failed to list unpaid entries: %w", err)
+	}
+
+	// Accrue each tenant's share per network
+	type account struct{ tenant, network string }
+	owed := make(map[account]*Payout)
+	var accounts []account
+	for _, entry := range unpaid {
+		key := account{entry.Tenant, entry.Network}
+		payout, ok := owed[key]
+		if !ok {
+			payout = &Payout{Tenant: entry.Tenant, Network: entry.Network, Amount: "0"}
+			owed[key] = payout
+			accounts = append(accounts, key)
+		}
+		amount, _ := new(big.Int).SetString(payout.Amount, 10)
+		share, ok := new(big.Int).SetString(entry.TenantShare, 10)
+		if !ok {
+			continue
+		}
+		payout.Amount = amount.Add(amount, share).String()
+		payout.Entries = append(payout.Entries, entry.Transaction)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].tenant != accounts[j].tenant {
+			return accounts[i].tenant < accounts[j].tenant
+		}
+		return accounts[i].network < accounts[j].network
+	})
+
+	var payouts []Payout
+	var errs []error
+	for _, key := range accounts {
+		payout := owed[key]
+		amount, _ := new(big.Int).SetString(payout.Amount, 10)
+		if amount.Sign() == 0 || amount.Cmp(s.minimumPayout) < 0 {
+			continue
+		}
+		share, err := s.shares.Share(ctx, key.tenant)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("revshare: failed to get share of %s: %w", key.tenant, err))
+			continue
+		}
+		if share == nil || share.Wallets[key.network] == "" {
+			s.logger.Warn("tenant has no payout wallet", "tenant", key.tenant, "network", key.network, "owed", payout.Amount)
+			continue
+		}
+		payout.To = share.Wallets[key.network]
+
+		tx, err := s.wallets[key.network].Transfer(ctx, payout.To, amount)
+		if err != nil {
+			payout.Error = err.Error()
+			payouts = append(payouts, *payout)
+			errs = append(errs, fmt.Errorf("%w: %s on %s: %v", ErrPayoutFailed, key.tenant, key.network, err))
+			continue
+		}
+		payout.Transaction = tx
+		payouts = append(payouts, *payout)
+		s.logger.Info("revenue share paid out", "tenant", key.tenant, "network", key.network, "amount", payout.Amount, "transaction", tx)
+		if err := s.ledger.MarkPaid(ctx, payout.Entries, tx, s.now().UTC()); err != nil {
+			// The share was transferred; the entries must not be paid out again
+			errs = append(errs, fmt.Errorf("revshare: paid out %s to %s in %s but failed to record it: %w", payout.Amount, key.tenant, tx, err))
+		}
+	}
+	return payouts, errors.Join(errs...)
+}
+
+// feeOf returns the fee of tenant in basis points.
+func (s *Splitter) feeOf(ctx context.Context, tenant string) (int, error) {
+	share, err := s.shares.Share(ctx, tenant)
+	if err != nil {
+		return 0, fmt.Errorf("revshare: failed to get share of %s: %w", tenant, err)
+	}
+	if share == nil {
+		return s.defaultFee, nil
+	}
+	if err := validateFee(share.FeeBasisPoints); err != nil {
+		return 0, fmt.Errorf("revshare: tenant %s: %w", tenant, err)
+	}
+	return share.FeeBasisPoints, nil
+}
+
+// validateFee checks that a fee is between 0 and 100%.
+func validateFee(basisPoints int) error {
+	if basisPoints < 0 || basisPoints > maxBasisPoints {
+		return fmt.Errorf("revshare: fee must be between 0 and %d basis points, got %d", maxBasisPoints, basisPoints)
+	}
+	return nil
+}
+
+// MemoryShareStore is an in-memory ShareStore.
+type MemoryShareStore struct {
+	mu     sync.RWMutex
+	shares map[string]*Share
+}
+
+// NewMemoryShareStore creates a MemoryShareStore holding shares by tenant ID.
+func NewMemoryShareStore(shares map[string]*Share) *MemoryShareStore {
+	s := &MemoryShareStore{shares: make(map[string]*Share, len(shares))}
+	for tenant, share := range shares {
+		s.shares[tenant] = share
+	}
+	return s
+}
+
+// Put sets the share of tenant.
+func (s *MemoryShareStore) Put(tenant string, share *Share) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shares[tenant] = share
+}
+
+// Share implements ShareStore.
+func (s *MemoryShareStore) Share(_ context.Context, tenant string) (*Share, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shares[tenant], nil
+}
+
+// MemoryLedger is an in-memory Ledger, for tests and single-instance deployments.
+type MemoryLedger struct {
+	mu      sync.Mutex
+	entries []Entry
+	index   map[string]int
+}
+
+// NewMemoryLedger creates an empty MemoryLedger.
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{index: make(map[string]int)}
+}
+
+// Add implements Ledger.
+func (l *MemoryLedger) Add(_ context.Context, entry Entry) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, exists := l.index[entry.Transaction]; exists {
+		return false, nil
+	}
+	l.index[entry.Transaction] = len(l.entries)
+	l.entries = append(l.entries, entry)
+	return true, nil
+}
+
+// Unpaid implements Ledger, returning the entries oldest first.
+func (l *MemoryLedger) Unpaid(_ context.Context) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var unpaid []Entry
+	for _, entry := range l.entries {
+		if entry.Status == EntryUnpaid {
+			unpaid = append(unpaid, entry)
+		}
+	}
+	return unpaid, nil
+}
+
+// MarkPaid implements Ledger.
+func (l *MemoryLedger) MarkPaid(_ context.Context, transactions []string, payout string, paidAt time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, tx := range transactions {
+		if i, ok := l.index[tx]; ok {
+			l.entries[i].Status = EntryPaid
+			l.entries[i].Payout = payout
+			l.entries[i].PaidAt = paidAt
+		}
+	}
+	return nil
+}
+
+// Entries implements Ledger.
+func (l *MemoryLedger) Entries(_ context.Context, tenant string) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entries []Entry
+	for _, entry := range l.entries {
+		if entry.Tenant == tenant {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
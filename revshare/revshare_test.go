@@ -0,0 +1,192 @@
+package revshare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	x402http "github.com/mark3labs/x402-go/http"
+	"github.com/mark3labs/x402-go/treasury"
+)
+
+const platformAddress = "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+
+type fakeWallet struct {
+	mu          sync.Mutex
+	transferErr error
+	transfers   []string
+}
+
+func (w *fakeWallet) Network() string { return "base" }
+func (w *fakeWallet) Address() string { return platformAddress }
+
+func (w *fakeWallet) Balance(context.Context) (*big.Int, error) { return new(big.Int), nil }
+
+func (w *fakeWallet) Transfer(_ context.Context, to string, amount *big.Int) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.transferErr != nil {
+		return "", w.transferErr
+	}
+	w.transfers = append(w.transfers, to+":"+amount.String())
+	return fmt.Sprintf("0xpayout%d", len(w.transfers)), nil
+}
+
+func payment(amount string) x402.PaymentRequirement {
+	return x402.PaymentRequirement{Network: "base", Asset: x402.BaseMainnet.USDCAddress, PayTo: platformAddress, MaxAmountRequired: amount}
+}
+
+func settled(tx string) *x402.SettlementResponse {
+	return &x402.SettlementResponse{Success: true, Transaction: tx, Network: "base", Payer: "0xpayer"}
+}
+
+func newTestSplitter(t *testing.T, wallet *fakeWallet, opts ...Option) (*Splitter, *MemoryLedger) {
+	t.Helper()
+	shares := NewMemoryShareStore(map[string]*Share{
+		"acme":   {FeeBasisPoints: 250, Wallets: map[string]string{"base": "0xacme"}},
+		"globex": {FeeBasisPoints: 1000},
+	})
+	ledger := NewMemoryLedger()
+	s, err := New(ledger, shares, []treasury.Wallet{wallet}, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s, ledger
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		wallets []treasury.Wallet
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "defaults", wallets: []treasury.Wallet{&fakeWallet{}}},
+		{name: "duplicate network", wallets: []treasury.Wallet{&fakeWallet{}, &fakeWallet{}}, wantErr: true},
+		{name: "default fee", opts: []Option{WithDefaultFee(500)}},
+		{name: "fee over 100%", opts: []Option{WithDefaultFee(10_001)}, wantErr: true},
+		{name: "negative minimum payout", opts: []Option{WithMinimumPayout("-1")}, wantErr: true},
+		{name: "zero interval", opts: []Option{WithInterval(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(NewMemoryLedger(), NewMemoryShareStore(nil), tt.wallets, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitter_Split(t *testing.T) {
+	ctx := context.Background()
+	s, ledger := newTestSplitter(t, &fakeWallet{}, WithDefaultFee(500))
+
+	tests := []struct {
+		name        string
+		tenant      string
+		requirement x402.PaymentRequirement
+		wantFee     string
+		wantShare   string
+		wantErr     bool
+	}{
+		{name: "tenant fee", tenant: "acme", requirement: payment("1000000"), wantFee: "25000", wantShare: "975000"},
+		{name: "fee rounds down", tenant: "acme", requirement: payment("39"), wantFee: "0", wantShare: "39"},
+		{name: "default fee", tenant: "initech", requirement: payment("1000"), wantFee: "50", wantShare: "950"},
+		{name: "paid to the tenant", tenant: "acme", requirement: x402.PaymentRequirement{Network: "base", PayTo: "0xacme", MaxAmountRequired: "1000"}, wantErr: true},
+		{name: "no platform wallet", tenant: "acme", requirement: x402.PaymentRequirement{Network: "polygon", PayTo: platformAddress, MaxAmountRequired: "1000"}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := s.Split(ctx, tt.tenant, tt.requirement, *settled(fmt.Sprintf("0xtx%d", i)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Split() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if entry.Fee != tt.wantFee || entry.TenantShare != tt.wantShare || entry.Status != EntryUnpaid {
+				t.Errorf("entry = %+v, want fee %s and share %s", entry, tt.wantFee, tt.wantShare)
+			}
+		})
+	}
+
+	// A settlement is only split once
+	if _, err := s.Split(ctx, "acme", payment("1000000"), *settled("0xtx0")); err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	if entries, _ := ledger.Entries(ctx, "acme"); len(entries) != 2 {
+		t.Errorf("acme has %d entries, want 2", len(entries))
+	}
+}
+
+func TestSplitter_OnAfterSettle(t *testing.T) {
+	s, ledger := newTestSplitter(t, &fakeWallet{})
+	tenantCtx := context.WithValue(context.Background(), x402http.TenantContextKey, "acme")
+
+	s.OnAfterSettle(context.Background(), x402.PaymentPayload{}, payment("1000"), settled("0xnotenant"), nil)
+	s.OnAfterSettle(tenantCtx, x402.PaymentPayload{}, payment("1000"), nil, errors.New("settle failed"))
+	s.OnAfterSettle(tenantCtx, x402.PaymentPayload{}, payment("1000"), &x402.SettlementResponse{Success: true, Simulated: true}, nil)
+	s.OnAfterSettle(tenantCtx, x402.PaymentPayload{}, payment("1000"), settled("0xtx"), nil)
+
+	unpaid, _ := ledger.Unpaid(context.Background())
+	if len(unpaid) != 1 || unpaid[0].Transaction != "0xtx" || unpaid[0].Tenant != "acme" {
+		t.Errorf("unpaid entries = %+v, want the tenant's settled payment", unpaid)
+	}
+}
+
+func TestSplitter_PayOut(t *testing.T) {
+	ctx := context.Background()
+	wallet := &fakeWallet{}
+	s, ledger := newTestSplitter(t, wallet, WithMinimumPayout("1000"))
+
+	mustSplit := func(tenant, amount, tx string) {
+		t.Helper()
+		if _, err := s.Split(ctx, tenant, payment(amount), *settled(tx)); err != nil {
+			t.Fatalf("Split: %v", err)
+		}
+	}
+	mustSplit("acme", "800", "0xa1")
+	mustSplit("globex", "5000", "0xg1") // no payout wallet
+
+	// Below the minimum payout, shares accrue
+	payouts, err := s.PayOut(ctx)
+	if err != nil || len(payouts) != 0 {
+		t.Fatalf("PayOut() = %+v, %v, want no payouts", payouts, err)
+	}
+
+	mustSplit("acme", "400", "0xa2")
+	wallet.transferErr = errors.New("insufficient funds")
+	if _, err := s.PayOut(ctx); !errors.Is(err, ErrPayoutFailed) {
+		t.Fatalf("PayOut() error = %v, want ErrPayoutFailed", err)
+	}
+
+	wallet.transferErr = nil
+	payouts, err = s.PayOut(ctx)
+	if err != nil {
+		t.Fatalf("PayOut: %v", err)
+	}
+	// 780 + 390 after the 2.5% fee
+	if len(payouts) != 1 || payouts[0].Amount != "1170" || payouts[0].To != "0xacme" || len(payouts[0].Entries) != 2 {
+		t.Fatalf("payouts = %+v, want acme's 1170", payouts)
+	}
+	if len(wallet.transfers) != 1 || wallet.transfers[0] != "0xacme:1170" {
+		t.Errorf("transfers = %v", wallet.transfers)
+	}
+
+	entries, _ := ledger.Entries(ctx, "acme")
+	for _, entry := range entries {
+		if entry.Status != EntryPaid || entry.Payout != "0xpayout1" || entry.PaidAt.IsZero() {
+			t.Errorf("entry = %+v, want paid in 0xpayout1", entry)
+		}
+	}
+	if payouts, _ := s.PayOut(ctx); len(payouts) != 0 {
+		t.Errorf("paid out again: %+v", payouts)
+	}
+}
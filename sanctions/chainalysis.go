@@ -0,0 +1,113 @@
+package sanctions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ChainalysisURL is the default endpoint of Chainalysis' free sanctions screening API.
+const ChainalysisURL = "https://public.chainalysis.com/api/v1/address/"
+
+// Chainalysis is a Screener using Chainalysis' sanctions screening API, or a compatible
+// service answering GET <endpoint><address> with a list of identifications. Chainalysis
+// is safe for concurrent use.
+type Chainalysis struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Option configures a Chainalysis screener.
+type Option func(*Chainalysis) error
+
+// NewChainalysis creates a Chainalysis screener authenticating with apiKey.
+func NewChainalysis(apiKey string, opts ...Option) (*Chainalysis, error) {
+	if apiKey == "" {
+		return nil, errors.New("sanctions: API key cannot be empty")
+	}
+	c := &Chainalysis{
+		endpoint:   ChainalysisURL,
+		apiKey:     apiKey,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// WithEndpoint sets the API endpoint the address is appended to, for a compatible
+// service or a proxy.
+func WithEndpoint(endpoint string) Option {
+	return func(c *Chainalysis) error {
+		if endpoint == "" {
+			return errors.New("sanctions: endpoint cannot be empty")
+		}
+		if !strings.HasSuffix(endpoint, "/") {
+			endpoint += "/"
+		}
+		c.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests (default http.DefaultClient).
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Chainalysis) error {
+		if client == nil {
+			return errors.New("sanctions: HTTP client is nil")
+		}
+		c.httpClient = client
+		return nil
+	}
+}
+
+// identification is one sanctions list entry in an API response.
+type identification struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+}
+
+// Screen implements Screener. The API screens addresses of all chains, so network is
+// not sent.
+func (c *Chainalysis) Screen(ctx context.Context, _, address string) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+url.PathEscape(address), nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("sanctions: failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("sanctions: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Result{}, fmt.Errorf("sanctions: request failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var body struct {
+		Identifications []identification `json:"identifications"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Result{}, fmt.Errorf("sanctions: failed to decode response: %w", err)
+	}
+
+	result := Result{Sanctioned: len(body.Identifications) > 0, Source: "chainalysis"}
+	for _, id := range body.Identifications {
+		result.Details = append(result.Details, id.Name)
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package sanctions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChainalysis_Screen(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		body           string
+		wantSanctioned bool
+		wantDetails    int
+		wantErr        bool
+	}{
+		{name: "clean", status: http.StatusOK, body: `{"identifications":[]}`},
+		{name: "sanctioned", status: http.StatusOK, body: `{"identifications":[{"category":"sanctions","name":"SANCTIONS: OFAC SDN Example","description":"","url":""}]}`, wantSanctioned: true, wantDetails: 1},
+		{name: "unauthorized", status: http.StatusForbidden, body: `{"message":"forbidden"}`, wantErr: true},
+		{name: "invalid response", status: http.StatusOK, body: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, apiKey string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				apiKey = r.Header.Get("X-API-Key")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			screener, err := NewChainalysis("key", WithEndpoint(server.URL+"/api/v1/address"))
+			if err != nil {
+				t.Fatalf("NewChainalysis: %v", err)
+			}
+			result, err := screener.Screen(context.Background(), "base", sanctionedAddress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Screen() error = %v, want error %v", err, tt.wantErr)
+			}
+			if path != "/api/v1/address/"+sanctionedAddress || apiKey != "key" {
+				t.Errorf("request to %s with key %q", path, apiKey)
+			}
+			if result.Sanctioned != tt.wantSanctioned || len(result.Details) != tt.wantDetails {
+				t.Errorf("Screen() = %+v", result)
+			}
+		})
+	}
+}
+
+func TestNewChainalysis_RequiresAPIKey(t *testing.T) {
+	if _, err := NewChainalysis(""); err == nil {
+		t.Error("NewChainalysis(\"\") succeeded, want error")
+	}
+}
@@ -0,0 +1,317 @@
+// Package sanctions screens payer and payTo addresses against sanctions lists, such as
+// OFAC's SDN list, before a payment is accepted. Screeners check single addresses; a
+// Policy decides what happens to a payment and writes an audit record of every check.
+//
+// Example usage:
+//
+//	screener, err := sanctions.NewChainalysis(os.Getenv("CHAINALYSIS_API_KEY"))
+//	config.Sanctions = &sanctions.Policy{
+//	    Screener:    sanctions.NewCache(screener, time.Hour),
+//	    ScreenPayTo: true,
+//	}
+package sanctions
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBlocked indicates a payment involves a sanctioned address.
+	ErrBlocked = errors.New("x402: address is sanctioned")
+
+	// ErrScreeningFailed indicates an address could not be screened.
+	ErrScreeningFailed = errors.New("x402: sanctions screening failed")
+)
+
+// Result is the outcome of screening one address.
+type Result struct {
+	// Sanctioned reports whether the address is on a sanctions list.
+	Sanctioned bool `json:"sanctioned"`
+
+	// Source names the list or service that screened the address, e.g. "static".
+	Source string `json:"source,omitempty"`
+
+	// Details describes the matching list entries, if any.
+	Details []string `json:"details,omitempty"`
+}
+
+// Screener checks addresses against a sanctions list. Implementations must be safe for
+// concurrent use.
+type Screener interface {
+	Screen(ctx context.Context, network, address string) (Result, error)
+}
+
+// StaticList is a Screener backed by a fixed set of addresses, e.g. the digital currency
+// addresses published with OFAC's SDN list. EVM addresses match case-insensitively.
+// StaticList is safe for concurrent use.
+type StaticList struct {
+	mu        sync.RWMutex
+	addresses map[string]bool
+}
+
+// NewStaticList returns a StaticList of addresses.
+func NewStaticList(addresses ...string) *StaticList {
+	l := &StaticList{addresses: make(map[string]bool, len(addresses))}
+	l.Add(addresses...)
+	return l
+}
+
+// LoadList reads a StaticList from r, one address per line. Blank lines and lines
+// starting with # are ignored.
+func LoadList(r io.Reader) (*StaticList, error) {
+	l := NewStaticList()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		l.Add(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("sanctions: failed to read list: %w", err)
+	}
+	return l, nil
+}
+
+// Add adds addresses to the list.
+func (l *StaticList) Add(addresses ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, address := range addresses {
+		l.addresses[normalize(address)] = true
+	}
+}
+
+// Screen implements Screener.
+func (l *StaticList) Screen(_ context.Context, _, address string) (Result, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return Result{Sanctioned: l.addresses[normalize(address)], Source: "static"}, nil
+}
+
+// Cache is a Screener remembering the results of another Screener for a while, so the
+// same payer or payTo address is not looked up on every payment. Errors are not cached.
+// Cache is safe for concurrent use.
+type Cache struct {
+	screener Screener
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	results map[string]cachedResult
+}
+
+type cachedResult struct {
+	result  Result
+	expires time.Time
+}
+
+// NewCache returns a Cache of screener's results, kept for ttl.
+func NewCache(screener Screener, ttl time.Duration) *Cache {
+	return &Cache{
+		screener: screener,
+		ttl:      ttl,
+		now:      time.Now,
+		results:  make(map[string]cachedResult),
+	}
+}
+
+// Screen implements Screener.
+func (c *Cache) Screen(ctx context.Context, network, address string) (Result, error) {
+	key := network + ":" + normalize(address)
+	now := c.now()
+
+	c.mu.Lock()
+	cached, ok := c.results[key]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.result, nil
+	}
+
+	result, err := c.screener.Screen(ctx, network, address)
+	if err != nil {
+		return Result{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop expired results so the cache does not grow without bound
+	for k, r := range c.results {
+		if !now.Before(r.expires) {
+			delete(c.results, k)
+		}
+	}
+	c.results[key] = cachedResult{result: result, expires: now.Add(c.ttl)}
+	return result, nil
+}
+
+// Mode selects what a Policy does with a sanctioned address.
+type Mode int
+
+const (
+	// ModeBlock rejects payments involving a sanctioned address.
+	ModeBlock Mode = iota
+
+	// ModeMonitor accepts payments involving a sanctioned address and only flags them
+	// in the audit log, e.g. while evaluating a screening provider.
+	ModeMonitor
+)
+
+// Audit actions.
+const (
+	ActionAllowed = "allowed"
+	ActionBlocked = "blocked"
+	ActionFlagged = "flagged"
+)
+
+// Address roles in audit records.
+const (
+	RolePayer = "payer"
+	RolePayTo = "payTo"
+)
+
+// AuditRecord records the screening of one address.
+type AuditRecord struct {
+	Time    time.Time `json:"time"`
+	Network string    `json:"network"`
+	Address string    `json:"address"`
+
+	// Role is RolePayer or RolePayTo.
+	Role string `json:"role"`
+
+	// Action is ActionAllowed, ActionBlocked or ActionFlagged (sanctioned but allowed in
+	// ModeMonitor).
+	Action string `json:"action"`
+
+	Result Result `json:"result"`
+
+	// Error describes a screening failure.
+	Error string `json:"error,omitempty"`
+}
+
+// Policy screens the addresses of a payment and decides whether to accept it.
+type Policy struct {
+	// Screener checks each address.
+	Screener Screener
+
+	// Mode selects whether sanctioned addresses are blocked (default) or only flagged.
+	Mode Mode
+
+	// ScreenPayTo also screens the payTo address of the requirement being paid, to catch
+	// misconfigured or tampered requirements.
+	ScreenPayTo bool
+
+	// Allow lists addresses that are never blocked, e.g. after a false positive was
+	// reviewed. Their screening is still audited.
+	Allow []string
+
+	// FailOpen accepts payments whose addresses could not be screened. By default they
+	// are rejected.
+	FailOpen bool
+
+	// Audit receives a record of every screening (default: logged with slog.Default()
+	// at info level, or warn level for sanctioned addresses and failures).
+	Audit func(ctx context.Context, record AuditRecord)
+}
+
+// Check screens payer, and payTo if ScreenPayTo is set, on network. It returns an error
+// wrapping ErrBlocked for a blocked address or ErrScreeningFailed when an address could
+// not be screened and FailOpen is not set. An empty payer cannot be screened.
+func (p *Policy) Check(ctx context.Context, network, payer, payTo string) error {
+	if err := p.check(ctx, network, payer, RolePayer); err != nil {
+		return err
+	}
+	if p.ScreenPayTo {
+		return p.check(ctx, network, payTo, RolePayTo)
+	}
+	return nil
+}
+
+// check screens one address and audits the outcome.
+func (p *Policy) check(ctx context.Context, network, address, role string) error {
+	record := AuditRecord{Time: time.Now(), Network: network, Address: address, Role: role, Action: ActionAllowed}
+
+	var err error
+	switch {
+	case address == "":
+		err = fmt.Errorf("%w: %s address unknown", ErrScreeningFailed, role)
+	case p.Screener == nil:
+		err = fmt.Errorf("%w: no screener configured", ErrScreeningFailed)
+	default:
+		record.Result, err = p.Screener.Screen(ctx, network, address)
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrScreeningFailed, err)
+		}
+	}
+
+	switch {
+	case err != nil:
+		record.Error = err.Error()
+		if !p.FailOpen {
+			record.Action = ActionBlocked
+		} else {
+			err = nil
+		}
+	case record.Result.Sanctioned && p.allowed(address):
+		// Reviewed and allowed
+	case record.Result.Sanctioned && p.Mode == ModeMonitor:
+		record.Action = ActionFlagged
+	case record.Result.Sanctioned:
+		record.Action = ActionBlocked
+		err = fmt.Errorf("%w: %s %s", ErrBlocked, role, address)
+	}
+
+	p.audit(ctx, record)
+	return err
+}
+
+// allowed reports whether address is on the allow list.
+func (p *Policy) allowed(address string) bool {
+	address = normalize(address)
+	for _, allowed := range p.Allow {
+		if normalize(allowed) == address {
+			return true
+		}
+	}
+	return false
+}
+
+// audit passes record to the Audit hook, or logs it.
+func (p *Policy) audit(ctx context.Context, record AuditRecord) {
+	if p.Audit != nil {
+		p.Audit(ctx, record)
+		return
+	}
+	level := slog.LevelInfo
+	if record.Result.Sanctioned || record.Error != "" {
+		level = slog.LevelWarn
+	}
+	slog.Default().Log(ctx, level, "sanctions screening",
+		"network", record.Network,
+		"address", record.Address,
+		"role", record.Role,
+		"action", record.Action,
+		"sanctioned", record.Result.Sanctioned,
+		"source", record.Result.Source,
+		"error", record.Error,
+	)
+}
+
+// normalize returns the canonical form of address for comparison. EVM addresses are
+// case-insensitive; other addresses (such as Solana's base58) are not.
+func normalize(address string) string {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		return strings.ToLower(address)
+	}
+	return address
+}
@@ -0,0 +1,115 @@
+package sanctions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	sanctionedAddress = "0x8589427373D6D84E98730D7795D8f6f8731FDA16"
+	cleanAddress      = "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+	payToAddress      = "0x857b06519E91e3A54538791bDbb0E22373e36b66"
+)
+
+// failingScreener fails every screening.
+type failingScreener struct{}
+
+func (failingScreener) Screen(context.Context, string, string) (Result, error) {
+	return Result{}, errors.New("service unavailable")
+}
+
+func TestLoadList(t *testing.T) {
+	list, err := LoadList(strings.NewReader("# OFAC SDN\n\n" + strings.ToLower(sanctionedAddress) + "\n  7FHEjc2vS4pD9hKUeY4hBXmUcXoFyZP4VLs6kWLkNsyv  \n"))
+	if err != nil {
+		t.Fatalf("LoadList: %v", err)
+	}
+
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{address: sanctionedAddress, want: true},
+		{address: cleanAddress, want: false},
+		{address: "7FHEjc2vS4pD9hKUeY4hBXmUcXoFyZP4VLs6kWLkNsyv", want: true},
+		{address: "7fhejc2vs4pd9hkuey4hbxmucxofyzp4vls6kwlknsyv", want: false},
+	}
+	for _, tt := range tests {
+		result, err := list.Screen(context.Background(), "base", tt.address)
+		if err != nil || result.Sanctioned != tt.want {
+			t.Errorf("Screen(%s) = %+v, %v; want sanctioned %v", tt.address, result, err, tt.want)
+		}
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      Policy
+		payer       string
+		wantErr     error
+		wantActions []string
+	}{
+		{name: "clean payer", policy: Policy{}, payer: cleanAddress, wantActions: []string{ActionAllowed}},
+		{name: "sanctioned payer", policy: Policy{}, payer: sanctionedAddress, wantErr: ErrBlocked, wantActions: []string{ActionBlocked}},
+		{name: "monitor mode", policy: Policy{Mode: ModeMonitor}, payer: sanctionedAddress, wantActions: []string{ActionFlagged}},
+		{name: "allow list", policy: Policy{Allow: []string{strings.ToLower(sanctionedAddress)}}, payer: sanctionedAddress, wantActions: []string{ActionAllowed}},
+		{name: "sanctioned payTo", policy: Policy{ScreenPayTo: true}, payer: cleanAddress, wantErr: ErrBlocked, wantActions: []string{ActionAllowed, ActionBlocked}},
+		{name: "unknown payer", policy: Policy{}, payer: "", wantErr: ErrScreeningFailed, wantActions: []string{ActionBlocked}},
+		{name: "screener failure", policy: Policy{Screener: failingScreener{}}, payer: cleanAddress, wantErr: ErrScreeningFailed, wantActions: []string{ActionBlocked}},
+		{name: "screener failure fails open", policy: Policy{Screener: failingScreener{}, FailOpen: true}, payer: cleanAddress, wantActions: []string{ActionAllowed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			if policy.Screener == nil {
+				policy.Screener = NewStaticList(sanctionedAddress, payToAddress)
+			}
+			var actions []string
+			policy.Audit = func(_ context.Context, record AuditRecord) {
+				actions = append(actions, record.Action)
+			}
+
+			err := policy.Check(context.Background(), "base", tt.payer, payToAddress)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if strings.Join(actions, ",") != strings.Join(tt.wantActions, ",") {
+				t.Errorf("audited actions = %v, want %v", actions, tt.wantActions)
+			}
+		})
+	}
+}
+
+// countingScreener counts screenings.
+type countingScreener struct {
+	calls int
+}
+
+func (s *countingScreener) Screen(context.Context, string, string) (Result, error) {
+	s.calls++
+	return Result{Source: "counting"}, nil
+}
+
+func TestCache(t *testing.T) {
+	screener := &countingScreener{}
+	cache := NewCache(screener, time.Minute)
+	start := time.Now()
+	cache.now = func() time.Time { return start }
+
+	ctx := context.Background()
+	_, _ = cache.Screen(ctx, "base", cleanAddress)
+	_, _ = cache.Screen(ctx, "base", strings.ToLower(cleanAddress))
+	if screener.calls != 1 {
+		t.Errorf("calls = %d after repeated screening, want 1", screener.calls)
+	}
+
+	cache.now = func() time.Time { return start.Add(time.Minute) }
+	_, _ = cache.Screen(ctx, "base", cleanAddress)
+	if screener.calls != 2 {
+		t.Errorf("calls = %d after expiry, want 2", screener.calls)
+	}
+}
@@ -0,0 +1,142 @@
+package x402
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RejectionReason is why DefaultPaymentSelector ruled out a requirement and signer pair.
+type RejectionReason string
+
+const (
+	// RejectInvalidAmount means the requirement's amount is not an integer.
+	RejectInvalidAmount RejectionReason = "invalid_amount"
+
+	// RejectNetworkMismatch means the signer pays on a different network.
+	RejectNetworkMismatch RejectionReason = "network_mismatch"
+
+	// RejectSchemeMismatch means the signer pays with a different scheme.
+	RejectSchemeMismatch RejectionReason = "scheme_mismatch"
+
+	// RejectAssetUnsupported means the signer has no token for the requirement's asset.
+	RejectAssetUnsupported RejectionReason = "asset_unsupported"
+
+	// RejectSignerDeclined means the signer's CanSign turned the requirement down for
+	// another reason.
+	RejectSignerDeclined RejectionReason = "signer_declined"
+
+	// RejectOverMaxAmount means the amount exceeds the signer's maximum amount.
+	RejectOverMaxAmount RejectionReason = "over_max_amount"
+
+	// RejectBudgetExhausted means the selected pair failed to sign because the amount
+	// did not fit in the spending limit.
+	RejectBudgetExhausted RejectionReason = "budget_exhausted"
+
+	// RejectSigningFailed means the selected pair failed to sign for another reason.
+	RejectSigningFailed RejectionReason = "signing_failed"
+)
+
+// SelectionTrace explains how DefaultPaymentSelector chose among the pairs of payment
+// requirements and signers, e.g. to find out why a payment failed with
+// ErrNoValidSigner. The errors of DefaultPaymentSelector and CachingPaymentSelector
+// carry it; see SelectionTraceOf.
+type SelectionTrace struct {
+	// Candidates are the requirement and signer pairs considered, in requirement then
+	// signer order. A requirement with an invalid amount has one candidate, with
+	// SignerIndex -1.
+	Candidates []SelectionCandidate
+}
+
+// SelectionCandidate is one requirement and signer pair of a SelectionTrace.
+type SelectionCandidate struct {
+	RequirementIndex int
+	SignerIndex      int
+
+	// Network, Scheme, Asset and Amount describe the requirement.
+	Network string
+	Scheme  string
+	Asset   string
+	Amount  string
+
+	// Rejected is why the pair was ruled out; empty if it was eligible.
+	Rejected RejectionReason
+
+	// Selected reports whether the pair was chosen to pay.
+	Selected bool
+}
+
+// String summarizes the trace, one candidate per line.
+func (t *SelectionTrace) String() string {
+	var b strings.Builder
+	for _, c := range t.Candidates {
+		status := "eligible"
+		switch {
+		case c.Rejected != "":
+			status = "rejected: " + string(c.Rejected)
+		case c.Selected:
+			status = "selected"
+		}
+		fmt.Fprintf(&b, "requirement %d (%s %s %s %s) signer %d: %s\n",
+			c.RequirementIndex, c.Scheme, c.Network, c.Asset, c.Amount, c.SignerIndex, status)
+	}
+	return b.String()
+}
+
+// selectionTraceDetail is the PaymentError detail holding a SelectionTrace.
+const selectionTraceDetail = "selectionTrace"
+
+// SelectionTraceOf returns the SelectionTrace carried by a selection error, or nil.
+func SelectionTraceOf(err error) *SelectionTrace {
+	var paymentErr *PaymentError
+	if !errors.As(err, &paymentErr) {
+		return nil
+	}
+	trace, _ := paymentErr.Details[selectionTraceDetail].(*SelectionTrace)
+	return trace
+}
+
+// Explain returns how the selector would choose among requirements and signers,
+// without signing.
+func (s *DefaultPaymentSelector) Explain(requirements []PaymentRequirement, signers []Signer) *SelectionTrace {
+	_, _, trace, _ := s.selectSigner(requirements, signers)
+	return trace
+}
+
+// add records a candidate for requirement i and returns its index in the trace.
+func (t *SelectionTrace) add(i int, req *PaymentRequirement, signerIndex int, reason RejectionReason) int {
+	t.Candidates = append(t.Candidates, SelectionCandidate{
+		RequirementIndex: i,
+		SignerIndex:      signerIndex,
+		Network:          req.Network,
+		Scheme:           req.Scheme,
+		Asset:            req.Asset,
+		Amount:           req.MaxAmountRequired,
+		Rejected:         reason,
+	})
+	return len(t.Candidates) - 1
+}
+
+// declined returns why signer cannot sign req.
+func declined(signer Signer, req *PaymentRequirement) RejectionReason {
+	if signer.Network() != req.Network {
+		return RejectNetworkMismatch
+	}
+	if signer.Scheme() != req.Scheme {
+		return RejectSchemeMismatch
+	}
+	for _, token := range signer.GetTokens() {
+		if strings.EqualFold(token.Address, req.Asset) {
+			return RejectSignerDeclined
+		}
+	}
+	return RejectAssetUnsupported
+}
+
+// signingRejection returns the rejection reason for a signing error.
+func signingRejection(err error) RejectionReason {
+	if errors.Is(err, ErrBudgetExceeded) {
+		return RejectBudgetExhausted
+	}
+	return RejectSigningFailed
+}
@@ -17,10 +17,30 @@ type PaymentSelector interface {
 // DefaultPaymentSelector implements the standard payment selection algorithm.
 // It selects signers based on:
 // 1. Ability to satisfy requirements (network and token match)
-// 2. Signer priority (lower number = higher priority)
-// 3. Token priority within the signer
-// 4. Configuration order (for ties)
-type DefaultPaymentSelector struct{}
+// 2. The preferences set on the selector, if any (network, asset, then cost)
+// 3. Signer priority (lower number = higher priority)
+// 4. Token priority within the signer
+// 5. Configuration order (for ties)
+type DefaultPaymentSelector struct {
+	// PreferredNetworks lists networks to pay on, most preferred first. Requirements on
+	// a listed network are chosen over those on other networks, whatever the priority of
+	// the signers paying them.
+	PreferredNetworks []string
+
+	// PreferredAssets lists assets to pay with, most preferred first, by token symbol
+	// (e.g. "USDC") or address. Symbols are those of the signer's TokenConfig for the
+	// requirement's asset, or its logical asset in Assets. Matching is case-insensitive.
+	PreferredAssets []string
+
+	// PreferCheapest chooses the requirement with the lowest amount, in whole tokens,
+	// among those not told apart by the network and asset preferences. Token decimals
+	// are those registered in Assets, or else those of the signer's TokenConfig.
+	PreferCheapest bool
+
+	// Assets maps tokens on different networks to logical assets, so the preferences
+	// treat e.g. USDC on Base and on Polygon alike (default DefaultAssetRegistry).
+	Assets *AssetRegistry
+}
 
 // NewDefaultPaymentSelector creates a new DefaultPaymentSelector.
 func NewDefaultPaymentSelector() *DefaultPaymentSelector {
@@ -29,27 +49,53 @@ func NewDefaultPaymentSelector() *DefaultPaymentSelector {
 
 // SelectAndSign implements PaymentSelector.
 func (s *DefaultPaymentSelector) SelectAndSign(requirements []PaymentRequirement, signers []Signer) (*PaymentPayload, error) {
+	requirementIndex, signerIndex, trace, err := s.selectSigner(requirements, signers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign the payment
+	payment, err := signers[signerIndex].Sign(&requirements[requirementIndex])
+	if err != nil {
+		return nil, signingError(err, trace)
+	}
+	setPaymentReference(payment, &requirements[requirementIndex])
+
+	return payment, nil
+}
+
+// selectSigner returns the indexes of the best requirement and signer combination,
+// and the trace of the selection. Errors carry the trace.
+func (s *DefaultPaymentSelector) selectSigner(requirements []PaymentRequirement, signers []Signer) (int, int, *SelectionTrace, error) {
+	trace := &SelectionTrace{}
 	if len(signers) == 0 {
-		return nil, NewPaymentError(ErrCodeNoValidSigner, "no signers configured", ErrNoValidSigner)
+		return 0, 0, trace, NewPaymentError(ErrCodeNoValidSigner, "no signers configured", ErrNoValidSigner)
 	}
 
 	if len(requirements) == 0 {
-		return nil, NewPaymentError(ErrCodeInvalidRequirements, "no payment requirements provided", ErrInvalidRequirements)
+		return 0, 0, trace, NewPaymentError(ErrCodeInvalidRequirements, "no payment requirements provided", ErrInvalidRequirements)
 	}
 
 	// Try each requirement option and find the best signer match
 	type requirementCandidate struct {
-		requirement      *PaymentRequirement
-		signer           Signer
+		networkRank      int
+		assetRank        int
+		cost             *big.Rat // Amount in whole tokens, when PreferCheapest is set
 		signerPriority   int
 		tokenPriority    int
 		signerIndex      int // Index of signer in configuration (for deterministic tie-breaking)
 		requirementIndex int // Index of requirement option (for deterministic tie-breaking)
+		traceIndex       int
 	}
 
 	var allCandidates []requirementCandidate
 	hasValidRequirement := false
 
+	assets := s.Assets
+	if assets == nil {
+		assets = DefaultAssetRegistry
+	}
+
 	for i := range requirements {
 		req := &requirements[i]
 
@@ -58,6 +104,7 @@ func (s *DefaultPaymentSelector) SelectAndSign(requirements []PaymentRequirement
 		if _, ok := requiredAmount.SetString(req.MaxAmountRequired, 10); !ok {
 			// If all requirements are invalid, we should return an error
 			// But continue checking other requirements first
+			trace.add(i, req, -1, RejectInvalidAmount)
 			continue
 		}
 
@@ -66,38 +113,53 @@ func (s *DefaultPaymentSelector) SelectAndSign(requirements []PaymentRequirement
 		// Find all signers that can satisfy this requirement
 		for signerIndex, signer := range signers {
 			if !signer.CanSign(req) {
+				trace.add(i, req, signerIndex, declined(signer, req))
 				continue
 			}
 
 			// Check max amount limit
 			maxAmount := signer.GetMaxAmount()
 			if maxAmount != nil && requiredAmount.Cmp(maxAmount) > 0 {
+				trace.add(i, req, signerIndex, RejectOverMaxAmount)
 				continue
 			}
 
 			// Find matching token and its priority
-			tokenPriority := 0
-			for _, token := range signer.GetTokens() {
-				if strings.EqualFold(token.Address, req.Asset) {
-					tokenPriority = token.Priority
+			var token TokenConfig
+			for _, t := range signer.GetTokens() {
+				if strings.EqualFold(t.Address, req.Asset) {
+					token = t
 					break
 				}
 			}
 
+			symbol, decimals, registered := assets.Lookup(req.Network, req.Asset)
+			if !registered {
+				symbol, decimals = token.Symbol, token.Decimals
+			}
+
+			var cost *big.Rat
+			if s.PreferCheapest {
+				cost = new(big.Rat).SetFrac(requiredAmount, pow10(max(decimals, 0)))
+			}
+
 			allCandidates = append(allCandidates, requirementCandidate{
-				requirement:      req,
-				signer:           signer,
+				networkRank:      preferenceRank(s.PreferredNetworks, req.Network),
+				assetRank:        preferenceRank(s.PreferredAssets, symbol, token.Symbol, req.Asset),
+				cost:             cost,
 				signerPriority:   signer.GetPriority(),
-				tokenPriority:    tokenPriority,
+				tokenPriority:    token.Priority,
 				signerIndex:      signerIndex,
 				requirementIndex: i,
+				traceIndex:       trace.add(i, req, signerIndex, ""),
 			})
 		}
 	}
 
 	// If no valid requirements were found, return an error
 	if !hasValidRequirement {
-		return nil, NewPaymentError(ErrCodeInvalidRequirements, "invalid amount in requirements", ErrInvalidRequirements)
+		return 0, 0, trace, NewPaymentError(ErrCodeInvalidRequirements, "invalid amount in requirements", ErrInvalidRequirements).
+			WithDetails(selectionTraceDetail, trace)
 	}
 
 	if len(allCandidates) == 0 {
@@ -106,14 +168,27 @@ func (s *DefaultPaymentSelector) SelectAndSign(requirements []PaymentRequirement
 		for _, req := range requirements {
 			errorDetails = append(errorDetails, req.Network+":"+req.Asset)
 		}
-		return nil, NewPaymentError(ErrCodeNoValidSigner, "no signer can satisfy any payment requirement", ErrNoValidSigner).
-			WithDetails("options", strings.Join(errorDetails, ", "))
+		return 0, 0, trace, NewPaymentError(ErrCodeNoValidSigner, "no signer can satisfy any payment requirement", ErrNoValidSigner).
+			WithDetails("options", strings.Join(errorDetails, ", ")).
+			WithDetails(selectionTraceDetail, trace)
 	}
 
-	// Sort by priority (signer first, then token, then configuration order)
+	// Sort by preference (network, asset, then cost), then priority (signer first, then
+	// token, then configuration order)
 	// Lower priority numbers come first (1 > 2 > 3)
 	// For ties, use configuration order (signer index, then requirement index)
 	sort.Slice(allCandidates, func(i, j int) bool {
+		if allCandidates[i].networkRank != allCandidates[j].networkRank {
+			return allCandidates[i].networkRank < allCandidates[j].networkRank
+		}
+		if allCandidates[i].assetRank != allCandidates[j].assetRank {
+			return allCandidates[i].assetRank < allCandidates[j].assetRank
+		}
+		if allCandidates[i].cost != nil {
+			if c := allCandidates[i].cost.Cmp(allCandidates[j].cost); c != 0 {
+				return c < 0
+			}
+		}
 		if allCandidates[i].signerPriority != allCandidates[j].signerPriority {
 			return allCandidates[i].signerPriority < allCandidates[j].signerPriority
 		}
@@ -127,15 +202,37 @@ func (s *DefaultPaymentSelector) SelectAndSign(requirements []PaymentRequirement
 	})
 
 	// Use the highest priority signer and requirement combination
-	selectedCandidate := allCandidates[0]
+	best := allCandidates[0]
+	trace.Candidates[best.traceIndex].Selected = true
+	return best.requirementIndex, best.signerIndex, trace, nil
+}
 
-	// Sign the payment
-	payment, err := selectedCandidate.signer.Sign(selectedCandidate.requirement)
-	if err != nil {
-		return nil, NewPaymentError(ErrCodeSigningFailed, "failed to sign payment", err)
+// signingError wraps a signing failure of the selected candidate of trace, recording
+// why it failed.
+func signingError(err error, trace *SelectionTrace) *PaymentError {
+	paymentErr := NewPaymentError(ErrCodeSigningFailed, "failed to sign payment", err)
+	if trace == nil {
+		return paymentErr
+	}
+	for i := range trace.Candidates {
+		if trace.Candidates[i].Selected {
+			trace.Candidates[i].Rejected = signingRejection(err)
+		}
 	}
+	return paymentErr.WithDetails(selectionTraceDetail, trace)
+}
 
-	return payment, nil
+// preferenceRank returns the position in preferences of the first of values it lists,
+// or len(preferences) if it lists none of them.
+func preferenceRank(preferences []string, values ...string) int {
+	for rank, preference := range preferences {
+		for _, value := range values {
+			if value != "" && strings.EqualFold(preference, value) {
+				return rank
+			}
+		}
+	}
+	return len(preferences)
 }
 
 // FindMatchingRequirement finds a payment requirement that matches the given payment's scheme and network.
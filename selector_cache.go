@@ -0,0 +1,139 @@
+package x402
+
+import (
+	"hash/fnv"
+	"math/big"
+	"strconv"
+	"sync"
+)
+
+// DefaultSelectorCacheSize is the number of accepts lists a CachingPaymentSelector
+// remembers when no size is given.
+const DefaultSelectorCacheSize = 1024
+
+// CachingPaymentSelector is a DefaultPaymentSelector that remembers the signer it chose
+// for each accepts list. An agent that calls the same endpoint repeatedly then skips
+// evaluating every signer and reuses the previous choice while that signer remains
+// eligible for the requirement.
+//
+// Cached choices are keyed by a hash of the requirements (which include the resource
+// URL) and the number of signers. A choice is dropped when signing with it fails, for
+// example when a spending limit is exhausted; Invalidate drops every choice, e.g. after
+// the signer set changes or a paid request fails.
+//
+// CachingPaymentSelector is safe for concurrent use.
+type CachingPaymentSelector struct {
+	DefaultPaymentSelector
+
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[uint64]cachedSelection
+}
+
+// cachedSelection is a remembered requirement and signer choice.
+type cachedSelection struct {
+	requirementIndex int
+	signerIndex      int
+}
+
+// NewCachingPaymentSelector creates a CachingPaymentSelector remembering up to
+// maxEntries accepts lists (DefaultSelectorCacheSize if maxEntries <= 0).
+func NewCachingPaymentSelector(maxEntries int) *CachingPaymentSelector {
+	if maxEntries <= 0 {
+		maxEntries = DefaultSelectorCacheSize
+	}
+	return &CachingPaymentSelector{
+		maxEntries: maxEntries,
+		entries:    make(map[uint64]cachedSelection),
+	}
+}
+
+// SelectAndSign implements PaymentSelector.
+func (s *CachingPaymentSelector) SelectAndSign(requirements []PaymentRequirement, signers []Signer) (*PaymentPayload, error) {
+	key := selectionKey(requirements, len(signers))
+
+	s.mu.Lock()
+	choice, ok := s.entries[key]
+	s.mu.Unlock()
+
+	var trace *SelectionTrace
+	if !ok || !choice.eligible(requirements, signers) {
+		requirementIndex, signerIndex, t, err := s.selectSigner(requirements, signers)
+		if err != nil {
+			return nil, err
+		}
+		choice = cachedSelection{requirementIndex: requirementIndex, signerIndex: signerIndex}
+		trace = t
+	}
+
+	payment, err := signers[choice.signerIndex].Sign(&requirements[choice.requirementIndex])
+	if err != nil {
+		s.mu.Lock()
+		delete(s.entries, key)
+		s.mu.Unlock()
+		return nil, signingError(err, trace)
+	}
+	setPaymentReference(payment, &requirements[choice.requirementIndex])
+
+	s.mu.Lock()
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= s.maxEntries {
+		// Evict an arbitrary entry to stay within bounds
+		for k := range s.entries {
+			delete(s.entries, k)
+			break
+		}
+	}
+	s.entries[key] = choice
+	s.mu.Unlock()
+
+	return payment, nil
+}
+
+// Invalidate forgets every cached choice.
+func (s *CachingPaymentSelector) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.entries)
+}
+
+// eligible reports whether the cached signer can still pay the cached requirement.
+func (c cachedSelection) eligible(requirements []PaymentRequirement, signers []Signer) bool {
+	if c.requirementIndex >= len(requirements) || c.signerIndex >= len(signers) {
+		return false
+	}
+	req := &requirements[c.requirementIndex]
+	signer := signers[c.signerIndex]
+	if !signer.CanSign(req) {
+		return false
+	}
+
+	amount, ok := new(big.Int).SetString(req.MaxAmountRequired, 10)
+	if !ok {
+		return false
+	}
+	maxAmount := signer.GetMaxAmount()
+	return maxAmount == nil || amount.Cmp(maxAmount) <= 0
+}
+
+// selectionKey hashes the fields of requirements that affect signer selection,
+// together with the number of signers.
+func selectionKey(requirements []PaymentRequirement, signerCount int) uint64 {
+	h := fnv.New64a()
+	write := func(v string) {
+		_, _ = h.Write([]byte(v))
+		_, _ = h.Write([]byte{0})
+	}
+
+	write(strconv.Itoa(signerCount))
+	for i := range requirements {
+		req := &requirements[i]
+		write(req.Scheme)
+		write(req.Network)
+		write(req.Asset)
+		write(req.PayTo)
+		write(req.MaxAmountRequired)
+		write(req.Resource)
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,177 @@
+package x402
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// countingSigner counts eligibility checks.
+type countingSigner struct {
+	mockSignerForSelector
+	canSignCalls int
+}
+
+func (s *countingSigner) CanSign(req *PaymentRequirement) bool {
+	s.canSignCalls++
+	return s.mockSignerForSelector.CanSign(req)
+}
+
+func newCountingSigners(priorities ...int) []*countingSigner {
+	signers := make([]*countingSigner, len(priorities))
+	for i, priority := range priorities {
+		signers[i] = &countingSigner{mockSignerForSelector: mockSignerForSelector{
+			network:      "base",
+			scheme:       "exact",
+			priority:     priority,
+			canSignValue: true,
+			tokens:       []TokenConfig{{Address: "0xUSDC", Symbol: "USDC", Decimals: 6}},
+		}}
+	}
+	return signers
+}
+
+func asSigners(counting []*countingSigner) []Signer {
+	signers := make([]Signer, len(counting))
+	for i, s := range counting {
+		signers[i] = s
+	}
+	return signers
+}
+
+func cacheTestRequirements(amount string) []PaymentRequirement {
+	return []PaymentRequirement{{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             "0xUSDC",
+		MaxAmountRequired: amount,
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		Resource:          "https://api.example.com/data",
+	}}
+}
+
+func TestCachingPaymentSelector_ReusesChoice(t *testing.T) {
+	counting := newCountingSigners(3, 1, 2)
+	signers := asSigners(counting)
+	selector := NewCachingPaymentSelector(0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := selector.SelectAndSign(cacheTestRequirements("1000"), signers); err != nil {
+			t.Fatalf("SelectAndSign failed: %v", err)
+		}
+	}
+
+	// The first call evaluates every signer; later calls only re-check the chosen one
+	for i, s := range counting {
+		want := 1
+		if i == 1 {
+			want = 3
+		}
+		if s.canSignCalls != want {
+			t.Errorf("signer %d: expected %d CanSign calls, got %d", i, want, s.canSignCalls)
+		}
+	}
+	if !counting[1].signCalled || counting[0].signCalled || counting[2].signCalled {
+		t.Error("expected only the priority 1 signer to sign")
+	}
+}
+
+func TestCachingPaymentSelector_Invalidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(selector *CachingPaymentSelector, signers []*countingSigner)
+	}{
+		{
+			name: "chosen signer no longer eligible",
+			change: func(_ *CachingPaymentSelector, signers []*countingSigner) {
+				signers[1].maxAmount = big.NewInt(10)
+			},
+		},
+		{
+			name: "signing failure",
+			change: func(selector *CachingPaymentSelector, signers []*countingSigner) {
+				signers[1].signError = ErrBudgetExceeded
+				_, err := selector.SelectAndSign(cacheTestRequirements("1000"), asSigners(signers))
+				if !errors.Is(err, ErrBudgetExceeded) {
+					t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+				}
+				signers[1].signError = nil
+			},
+		},
+		{
+			name: "explicit invalidate",
+			change: func(selector *CachingPaymentSelector, _ []*countingSigner) {
+				selector.Invalidate()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counting := newCountingSigners(3, 1, 2)
+			selector := NewCachingPaymentSelector(0)
+			if _, err := selector.SelectAndSign(cacheTestRequirements("1000"), asSigners(counting)); err != nil {
+				t.Fatalf("SelectAndSign failed: %v", err)
+			}
+
+			tt.change(selector, counting)
+			before := counting[0].canSignCalls
+
+			if _, err := selector.SelectAndSign(cacheTestRequirements("1000"), asSigners(counting)); err != nil {
+				t.Fatalf("SelectAndSign failed: %v", err)
+			}
+			if counting[0].canSignCalls == before {
+				t.Error("expected a full re-selection")
+			}
+		})
+	}
+}
+
+func TestCachingPaymentSelector_KeyedByRequirements(t *testing.T) {
+	counting := newCountingSigners(1, 2)
+	signers := asSigners(counting)
+	selector := NewCachingPaymentSelector(0)
+
+	for _, amount := range []string{"1000", "2000", "1000"} {
+		if _, err := selector.SelectAndSign(cacheTestRequirements(amount), signers); err != nil {
+			t.Fatalf("SelectAndSign failed: %v", err)
+		}
+	}
+
+	// Two distinct accepts lists, each evaluated once
+	if counting[1].canSignCalls != 2 {
+		t.Errorf("expected 2 full selections, got %d", counting[1].canSignCalls)
+	}
+}
+
+func TestCachingPaymentSelector_Bounded(t *testing.T) {
+	signers := asSigners(newCountingSigners(1))
+	selector := NewCachingPaymentSelector(2)
+
+	for _, amount := range []string{"1", "2", "3", "4"} {
+		if _, err := selector.SelectAndSign(cacheTestRequirements(amount), signers); err != nil {
+			t.Fatalf("SelectAndSign failed: %v", err)
+		}
+	}
+	if len(selector.entries) != 2 {
+		t.Errorf("expected 2 cached entries, got %d", len(selector.entries))
+	}
+}
+
+func BenchmarkCachingPaymentSelector_SelectAndSign_100Signers(b *testing.B) {
+	priorities := make([]int, 100)
+	for i := range priorities {
+		priorities[i] = i % 10
+	}
+	signers := asSigners(newCountingSigners(priorities...))
+	requirements := cacheTestRequirements("1000000")
+	selector := NewCachingPaymentSelector(0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := selector.SelectAndSign(requirements, signers); err != nil {
+			b.Fatalf("SelectAndSign failed: %v", err)
+		}
+	}
+}
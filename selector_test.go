@@ -2,6 +2,7 @@ package x402
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"strings"
 	"testing"
@@ -599,6 +600,41 @@ func BenchmarkDefaultPaymentSelector_SelectAndSign_10Signers(b *testing.B) {
 	}
 }
 
+// BenchmarkDefaultPaymentSelector_SelectAndSign_100Signers measures selection across
+// 100 signers and 100 requirements, of which only the last is payable (target: <1ms/op).
+func BenchmarkDefaultPaymentSelector_SelectAndSign_100Signers(b *testing.B) {
+	signers := make([]Signer, 100)
+	for i := range signers {
+		signers[i] = &mockSignerForSelector{
+			network:      "base",
+			scheme:       "exact",
+			priority:     i % 10,
+			canSignValue: true,
+			tokens:       []TokenConfig{{Address: fmt.Sprintf("0xToken%d", i), Symbol: "TKN", Decimals: 6}},
+		}
+	}
+
+	requirements := make([]PaymentRequirement, 100)
+	for i := range requirements {
+		requirements[i] = PaymentRequirement{
+			Network:           "polygon",
+			Asset:             fmt.Sprintf("0xToken%d", i),
+			MaxAmountRequired: "1000000",
+		}
+	}
+	requirements[99].Network = "base"
+
+	selector := NewDefaultPaymentSelector()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := selector.SelectAndSign(requirements, signers); err != nil {
+			b.Fatalf("SelectAndSign failed: %v", err)
+		}
+	}
+}
+
 // T067 [P]: Test for priority ordering convention (1 > 2 > 3)
 func TestDefaultPaymentSelector_PriorityOrderingConvention(t *testing.T) {
 	tests := []struct {
@@ -1067,3 +1103,176 @@ func TestFindMatchingRequirement(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultPaymentSelector_Preferences(t *testing.T) {
+	requirements := []PaymentRequirement{
+		{Scheme: "exact", Network: "base", MaxAmountRequired: "2000000", Asset: "0xUSDC"},
+		{Scheme: "exact", Network: "base", MaxAmountRequired: "1500000000000000000", Asset: "0xDAI"},
+		{Scheme: "exact", Network: "solana", MaxAmountRequired: "1000000", Asset: "SolUSDC"},
+	}
+	signers := []Signer{
+		&mockSignerForSelector{
+			network:      "base",
+			scheme:       "exact",
+			canSignValue: true,
+			priority:     1,
+			tokens: []TokenConfig{
+				{Address: "0xUSDC", Symbol: "USDC", Decimals: 6, Priority: 1},
+				{Address: "0xDAI", Symbol: "DAI", Decimals: 18, Priority: 2},
+			},
+		},
+		&mockSignerForSelector{
+			network:      "solana",
+			scheme:       "exact",
+			canSignValue: true,
+			priority:     2,
+			tokens:       []TokenConfig{{Address: "SolUSDC", Symbol: "USDC", Decimals: 6}},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		selector            DefaultPaymentSelector
+		expectedRequirement int
+	}{
+		{name: "signer priority without preferences", expectedRequirement: 0},
+		{name: "preferred network", selector: DefaultPaymentSelector{PreferredNetworks: []string{"solana", "base"}}, expectedRequirement: 2},
+		{name: "unlisted network preference", selector: DefaultPaymentSelector{PreferredNetworks: []string{"polygon"}}, expectedRequirement: 0},
+		{name: "preferred asset by symbol", selector: DefaultPaymentSelector{PreferredAssets: []string{"dai"}}, expectedRequirement: 1},
+		{name: "preferred asset by address", selector: DefaultPaymentSelector{PreferredAssets: []string{"0xdai"}}, expectedRequirement: 1},
+		{name: "cheapest in whole tokens", selector: DefaultPaymentSelector{PreferCheapest: true}, expectedRequirement: 2},
+		{name: "network before cost", selector: DefaultPaymentSelector{PreferredNetworks: []string{"base"}, PreferCheapest: true}, expectedRequirement: 1},
+		{name: "asset before cost", selector: DefaultPaymentSelector{PreferredAssets: []string{"USDC"}, PreferredNetworks: []string{"base"}, PreferCheapest: true}, expectedRequirement: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requirementIndex, _, _, err := tt.selector.selectSigner(requirements, signers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requirementIndex != tt.expectedRequirement {
+				t.Errorf("selected requirement %d, want %d", requirementIndex, tt.expectedRequirement)
+			}
+		})
+	}
+}
+
+func TestDefaultPaymentSelector_SelectionTrace(t *testing.T) {
+	usdc := []TokenConfig{{Address: "0xUSDC", Symbol: "USDC", Decimals: 6}}
+	requirements := []PaymentRequirement{
+		{Scheme: "exact", Network: "base", MaxAmountRequired: "lots", Asset: "0xUSDC"},
+		{Scheme: "exact", Network: "base", MaxAmountRequired: "2000000", Asset: "0xUSDC"},
+		{Scheme: "exact", Network: "base", MaxAmountRequired: "1000", Asset: "0xDAI"},
+	}
+	signers := []Signer{
+		&mockSignerForSelector{network: "solana", scheme: "exact", canSignValue: true, tokens: usdc},
+		&mockSignerForSelector{network: "base", scheme: "exact", canSignValue: true, tokens: usdc, maxAmount: big.NewInt(1000000)},
+	}
+
+	_, err := NewDefaultPaymentSelector().SelectAndSign(requirements, signers)
+	if !errors.Is(err, ErrNoValidSigner) {
+		t.Fatalf("expected ErrNoValidSigner, got %v", err)
+	}
+	trace := SelectionTraceOf(err)
+	if trace == nil {
+		t.Fatal("expected the error to carry a selection trace")
+	}
+
+	want := []struct {
+		requirement, signer int
+		reason              RejectionReason
+	}{
+		{0, -1, RejectInvalidAmount},
+		{1, 0, RejectNetworkMismatch},
+		{1, 1, RejectOverMaxAmount},
+		{2, 0, RejectNetworkMismatch},
+		{2, 1, RejectAssetUnsupported},
+	}
+	if len(trace.Candidates) != len(want) {
+		t.Fatalf("trace has %d candidates, want %d:\n%s", len(trace.Candidates), len(want), trace)
+	}
+	for i, w := range want {
+		c := trace.Candidates[i]
+		if c.RequirementIndex != w.requirement || c.SignerIndex != w.signer || c.Rejected != w.reason || c.Selected {
+			t.Errorf("candidate %d = %+v, want requirement %d signer %d rejected %s", i, c, w.requirement, w.signer, w.reason)
+		}
+	}
+
+	// The selected pair is marked, and so is its signing failure
+	signers[1].(*mockSignerForSelector).maxAmount = nil
+	trace = NewDefaultPaymentSelector().Explain(requirements, signers)
+	if c := trace.Candidates[2]; !c.Selected || c.Rejected != "" {
+		t.Errorf("expected requirement 1 to be selected, got %+v", c)
+	}
+	signers[1].(*mockSignerForSelector).signError = fmt.Errorf("%w: over the daily limit", ErrBudgetExceeded)
+	_, err = NewDefaultPaymentSelector().SelectAndSign(requirements, signers)
+	if trace := SelectionTraceOf(err); trace == nil || trace.Candidates[2].Rejected != RejectBudgetExhausted {
+		t.Errorf("expected the selected pair to be rejected for the budget, got %v", trace)
+	}
+}
+
+func TestDefaultPaymentSelector_Assets(t *testing.T) {
+	assets := NewAssetRegistry()
+	assets.Register("USDC", 6,
+		AssetToken{Network: "base", Address: "0xBaseUSDC", Decimals: 6},
+		AssetToken{Network: "bsc", Address: "0xBscUSDC", Decimals: 18},
+	)
+	requirements := []PaymentRequirement{
+		{Scheme: "exact", Network: "base", MaxAmountRequired: "100", Asset: "0xDAI"},
+		{Scheme: "exact", Network: "bsc", MaxAmountRequired: "1000000000000000000", Asset: "0xBscUSDC"},
+		{Scheme: "exact", Network: "base", MaxAmountRequired: "2000000", Asset: "0xBaseUSDC"},
+	}
+	// The signers' tokens carry neither symbols nor decimals
+	signers := []Signer{
+		&mockSignerForSelector{network: "base", scheme: "exact", canSignValue: true, tokens: []TokenConfig{{Address: "0xDAI"}, {Address: "0xBaseUSDC"}}},
+		&mockSignerForSelector{network: "bsc", scheme: "exact", canSignValue: true, priority: 1, tokens: []TokenConfig{{Address: "0xBscUSDC"}}},
+	}
+
+	tests := []struct {
+		name                string
+		selector            DefaultPaymentSelector
+		expectedRequirement int
+	}{
+		{name: "preferred logical asset", selector: DefaultPaymentSelector{PreferredAssets: []string{"usdc"}, Assets: assets}, expectedRequirement: 2},
+		{name: "cheapest across chains", selector: DefaultPaymentSelector{PreferredAssets: []string{"USDC"}, PreferCheapest: true, Assets: assets}, expectedRequirement: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requirementIndex, _, _, err := tt.selector.selectSigner(requirements, signers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requirementIndex != tt.expectedRequirement {
+				t.Errorf("selected requirement %d, want %d", requirementIndex, tt.expectedRequirement)
+			}
+		})
+	}
+}
+
+func TestPaymentSelectors_Reference(t *testing.T) {
+	requirement := PaymentRequirement{Scheme: "exact", Network: "base", MaxAmountRequired: "1000", Asset: "0xUSDC"}
+	requirement.SetReference("order-7")
+	signer := &mockSignerForSelector{
+		network:      "base",
+		scheme:       "exact",
+		canSignValue: true,
+		tokens:       []TokenConfig{{Address: "0xUSDC", Symbol: "USDC", Decimals: 6}},
+	}
+
+	for name, selector := range map[string]PaymentSelector{
+		"default": NewDefaultPaymentSelector(),
+		"caching": NewCachingPaymentSelector(10),
+	} {
+		t.Run(name, func(t *testing.T) {
+			payment, err := selector.SelectAndSign([]PaymentRequirement{requirement}, []Signer{signer})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if payment.Reference != "order-7" {
+				t.Errorf("payment reference = %q, want the requirement's order-7", payment.Reference)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package x402
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// settlementJSON has the fields of SettlementResponse without its JSON methods.
+type settlementJSON SettlementResponse
+
+// settlementFields are the JSON names of the fields of SettlementResponse, which are
+// not kept in Extra. They are listed rather than read from the struct tags, so the
+// package does not need reflection beyond encoding/json and stays small under TinyGo.
+var settlementFields = map[string]bool{
+	"success":     true,
+	"errorReason": true,
+	"transaction": true,
+	"network":     true,
+	"payer":       true,
+	"simulated":   true,
+	"feePaid":     true,
+	"blockNumber": true,
+	"slot":        true,
+	"settledAt":   true,
+	"reference":   true,
+}
+
+// MarshalJSON implements json.Marshaler, writing the Extra fields after the known ones.
+func (s SettlementResponse) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(settlementJSON(s))
+	if err != nil || len(s.Extra) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(s.Extra))
+	for key := range s.Extra {
+		if !settlementFields[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, key := range keys {
+		value := s.Extra[key]
+		if !json.Valid(value) {
+			return nil, fmt.Errorf("x402: invalid JSON in settlement extra field %q", key)
+		}
+		name, _ := json.Marshal(key)
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown fields in Extra.
+func (s *SettlementResponse) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*settlementJSON)(s)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	s.Extra = nil
+	for key, value := range fields {
+		if settlementFields[key] {
+			continue
+		}
+		if s.Extra == nil {
+			s.Extra = make(map[string]json.RawMessage)
+		}
+		s.Extra[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package x402
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSettlementResponse_JSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantExtra map[string]string
+	}{
+		{
+			name:  "known fields only",
+			input: `{"success":true,"transaction":"0xtx","network":"base","payer":"0xpayer","feePaid":"21000","blockNumber":123,"settledAt":1700000000}`,
+		},
+		{
+			name:      "unknown fields kept",
+			input:     `{"success":true,"transaction":"0xtx","network":"solana","payer":"payer","slot":42,"confirmations":3,"receipt":{"id":"r1"}}`,
+			wantExtra: map[string]string{"confirmations": `3`, "receipt": `{"id":"r1"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var settlement SettlementResponse
+			if err := json.Unmarshal([]byte(tt.input), &settlement); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !settlement.Success || settlement.Transaction != "0xtx" {
+				t.Errorf("known fields not decoded: %+v", settlement)
+			}
+			if len(settlement.Extra) != len(tt.wantExtra) {
+				t.Fatalf("Extra = %v, want %v", settlement.Extra, tt.wantExtra)
+			}
+			for key, want := range tt.wantExtra {
+				if got := string(settlement.Extra[key]); got != want {
+					t.Errorf("Extra[%q] = %s, want %s", key, got, want)
+				}
+			}
+
+			// Encoding writes back every field
+			data, err := json.Marshal(settlement)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var got, want map[string]interface{}
+			_ = json.Unmarshal(data, &got)
+			_ = json.Unmarshal([]byte(tt.input), &want)
+			if len(got) != len(want) {
+				t.Errorf("round trip = %s, want the fields of %s", data, tt.input)
+			}
+			for key := range want {
+				if _, ok := got[key]; !ok {
+					t.Errorf("round trip dropped %q: %s", key, data)
+				}
+			}
+		})
+	}
+}
+
+func TestSettlementResponse_MarshalInvalidExtra(t *testing.T) {
+	settlement := SettlementResponse{Extra: map[string]json.RawMessage{"bad": json.RawMessage("{")}}
+	if _, err := json.Marshal(settlement); err == nil {
+		t.Error("expected an error for invalid extra JSON")
+	}
+}
+
+func TestSettlementFields(t *testing.T) {
+	// settlementFields must name exactly the JSON fields of SettlementResponse
+	typ := reflect.TypeOf(SettlementResponse{})
+	want := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			want[name] = true
+		}
+	}
+	if !reflect.DeepEqual(settlementFields, want) {
+		t.Errorf("settlementFields = %v, want %v", settlementFields, want)
+	}
+}
@@ -5,6 +5,12 @@ import "math/big"
 // Signer represents a payment signer for a specific blockchain.
 // Implementations handle blockchain-specific signing for EVM (Ethereum-compatible chains)
 // and SVM (Solana) networks.
+//
+// Implementations must be safe for concurrent use: clients share one signer across
+// requests and call Sign from many goroutines at once. The signers in this module keep
+// their configuration immutable after construction, so their methods take no locks.
+// The slice returned by GetTokens and the value returned by GetMaxAmount are shared
+// with the signer and must not be modified.
 type Signer interface {
 	// Network returns the blockchain network identifier (e.g., "base", "solana").
 	Network() string
@@ -30,3 +36,15 @@ type Signer interface {
 	// GetMaxAmount returns the per-call spending limit, or nil if no limit is set.
 	GetMaxAmount() *big.Int
 }
+
+// KeyRotator is implemented by signers whose private key can be replaced at runtime,
+// so long-running clients can rotate wallets without being recreated.
+type KeyRotator interface {
+	// Reload re-reads the key from the source it was originally loaded from,
+	// such as a keystore file or a secret provider.
+	Reload() error
+
+	// SwapKey replaces the key. The encoding is signer-specific: hex for EVM
+	// signers, base58 for Solana signers.
+	SwapKey(newKey string) error
+}
@@ -11,6 +11,7 @@ import (
 	"math/big"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,6 +21,10 @@ import (
 
 // Signer implements the x402.Signer interface using Coinbase Developer Platform (CDP) wallets.
 // It provides secure transaction signing without managing private keys locally.
+//
+// Signer is safe for concurrent use. Its tokens, limits and CDP client are set by
+// NewSigner and never modified afterwards, and each Sign call keeps its state (nonce,
+// authorization, typed data) local, so concurrent calls share nothing mutable.
 type Signer struct {
 	cdpClient      *CDPClient
 	auth           *CDPAuth
@@ -33,8 +38,31 @@ type Signer struct {
 	maxAmount      *big.Int
 	eip3009Name    string // EIP-3009 domain name for EVM chains
 	eip3009Version string // EIP-3009 domain version for EVM chains
+	httpClient     *http.Client
+	validAfter     func(now time.Time) *big.Int // dates EIP-3009 authorizations, per WithValidityBuffer
+
+	computeUnitLimit uint32 // compute unit limit of Solana transactions
+	priorityFee      uint64 // microlamports per compute unit, or the floor in auto mode
+	maxPriorityFee   uint64 // cap of the automatic priority fee; 0 disables auto mode
 }
 
+// DefaultValidityBuffer is how long before signing EIP-3009 authorizations become valid
+// by default, to account for clock drift between client and server.
+const DefaultValidityBuffer = 10 * time.Second
+
+const (
+	// DefaultComputeUnitLimit is the compute unit limit of Solana payment transactions by
+	// default.
+	DefaultComputeUnitLimit uint32 = 200_000
+
+	// DefaultPriorityFee is the compute unit price of Solana payment transactions by
+	// default, in microlamports per compute unit.
+	DefaultPriorityFee uint64 = 10_000
+
+	// priorityFeePercentile is the percentile of recent prioritization fees paid in auto mode.
+	priorityFeePercentile = 75
+)
+
 // SignerOption is a functional option for configuring a Signer.
 type SignerOption func(*Signer) error
 
@@ -51,6 +79,10 @@ func NewSigner(accountName string, opts ...SignerOption) (*Signer, error) {
 	s := &Signer{
 		priority:    0,
 		accountName: accountName,
+		validAfter:  validAfterBuffer(DefaultValidityBuffer),
+
+		computeUnitLimit: DefaultComputeUnitLimit,
+		priorityFee:      DefaultPriorityFee,
 	}
 
 	// Apply all options
@@ -91,6 +123,9 @@ func NewSigner(accountName string, opts ...SignerOption) (*Signer, error) {
 	// Initialize CDP client if not already set
 	if s.cdpClient == nil {
 		s.cdpClient = NewCDPClient(s.auth)
+		if s.httpClient != nil {
+			s.cdpClient.httpClient = s.httpClient
+		}
 	}
 
 	// Create or retrieve account for this network with the given name
@@ -231,6 +266,90 @@ func WithMaxAmountPerCall(amount string) SignerOption {
 	}
 }
 
+// WithValidityBuffer sets how long before signing EIP-3009 authorizations become valid
+// (default DefaultValidityBuffer), to allow for clock drift between the payer and the
+// verifier. Devices with unreliable clocks or high latency may need a larger buffer.
+func WithValidityBuffer(d time.Duration) SignerOption {
+	return func(s *Signer) error {
+		if d < 0 {
+			return fmt.Errorf("validity buffer must not be negative, got %v", d)
+		}
+		s.validAfter = validAfterBuffer(d)
+		return nil
+	}
+}
+
+// WithValidAfterNow makes EIP-3009 authorizations valid from the moment they are signed,
+// for verifiers that reject a validAfter in the past.
+func WithValidAfterNow() SignerOption {
+	return WithValidityBuffer(0)
+}
+
+// WithValidAfterZero makes EIP-3009 authorizations valid from the Unix epoch, so clock
+// drift can never make them not yet valid. They still expire MaxTimeoutSeconds after
+// signing.
+func WithValidAfterZero() SignerOption {
+	return func(s *Signer) error {
+		s.validAfter = func(time.Time) *big.Int { return new(big.Int) }
+		return nil
+	}
+}
+
+// validAfterBuffer dates authorizations d before they are signed.
+func validAfterBuffer(d time.Duration) func(now time.Time) *big.Int {
+	return func(now time.Time) *big.Int {
+		return big.NewInt(now.Add(-d).Unix())
+	}
+}
+
+// WithComputeUnitLimit sets the compute unit limit of Solana payment transactions
+// (default DefaultComputeUnitLimit). The limit multiplies the priority fee paid by the
+// fee payer.
+func WithComputeUnitLimit(units uint32) SignerOption {
+	return func(s *Signer) error {
+		if units == 0 {
+			return fmt.Errorf("compute unit limit must be positive")
+		}
+		s.computeUnitLimit = units
+		return nil
+	}
+}
+
+// WithPriorityFee sets the compute unit price of Solana payment transactions, in
+// microlamports per compute unit (default DefaultPriorityFee). With WithAutoPriorityFee
+// it is the lowest price paid.
+func WithPriorityFee(microlamports uint64) SignerOption {
+	return func(s *Signer) error {
+		s.priorityFee = microlamports
+		return nil
+	}
+}
+
+// WithAutoPriorityFee prices Solana payment transactions from the prioritization fees
+// recently paid to write the token accounts of the transfer, as reported by the
+// getRecentPrioritizationFees RPC method, so payments keep landing during congestion.
+// The price is the 75th percentile of the recent fees, no lower than the WithPriorityFee
+// price and no higher than maxMicrolamports. If the RPC call fails the WithPriorityFee
+// price is used.
+func WithAutoPriorityFee(maxMicrolamports uint64) SignerOption {
+	return func(s *Signer) error {
+		if maxMicrolamports == 0 {
+			return fmt.Errorf("maximum priority fee must be positive")
+		}
+		s.maxPriorityFee = maxMicrolamports
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for CDP API and Solana RPC requests, e.g. one
+// built with facilitator.NewHTTPClient to go through a proxy or present a client certificate.
+func WithHTTPClient(client *http.Client) SignerOption {
+	return func(s *Signer) error {
+		s.httpClient = client
+		return nil
+	}
+}
+
 // Network implements x402.Signer.
 func (s *Signer) Network() string {
 	return s.network
@@ -340,7 +459,11 @@ func (s *Signer) signEVM(requirements *x402.PaymentRequirement, amount *big.Int)
 	}
 
 	// Build EIP-712 typed data for CDP API
-	typedData := s.buildEIP712TypedData(tokenAddress, auth)
+	primaryType := "TransferWithAuthorization"
+	if requirements.ReceiveWithAuthorization() {
+		primaryType = "ReceiveWithAuthorization"
+	}
+	typedData := s.buildEIP712TypedData(tokenAddress, primaryType, auth)
 
 	// Call CDP API to sign
 	signature, err := s.signTypedData(ctx, typedData)
@@ -402,6 +525,8 @@ func (s *Signer) signSVM(requirements *x402.PaymentRequirement, amount *big.Int)
 		decimals,
 		feePayer,
 		blockhash,
+		s.computeUnitPrice(ctx, requirements.Asset, requirements.PayTo),
+		requirements.Reference(),
 	)
 	if err != nil {
 		return nil, err
@@ -426,7 +551,8 @@ func (s *Signer) signSVM(requirements *x402.PaymentRequirement, amount *big.Int)
 	return payload, nil
 }
 
-// eip3009Auth represents the parameters for EIP-3009 transferWithAuthorization.
+// eip3009Auth represents the parameters for EIP-3009 transferWithAuthorization and
+// receiveWithAuthorization.
 type eip3009Auth struct {
 	From        string
 	To          string
@@ -445,10 +571,9 @@ func (s *Signer) createEIP3009Authorization(to string, value *big.Int, timeoutSe
 	}
 
 	// Set validity window
-	// Subtract 10 seconds from validAfter to account for clock drift between client and server
-	now := time.Now().Unix()
-	validAfter := big.NewInt(now - 10)
-	validBefore := big.NewInt(now + int64(timeoutSeconds))
+	now := time.Now()
+	validAfter := s.validAfter(now)
+	validBefore := big.NewInt(now.Unix() + int64(timeoutSeconds))
 
 	return &eip3009Auth{
 		From:        s.address,
@@ -490,7 +615,8 @@ type typeField struct {
 }
 
 // buildEIP712TypedData constructs the EIP-712 typed data structure for EIP-3009 authorization.
-func (s *Signer) buildEIP712TypedData(tokenAddress string, auth *eip3009Auth) typedData {
+// primaryType is TransferWithAuthorization or ReceiveWithAuthorization, which share their fields.
+func (s *Signer) buildEIP712TypedData(tokenAddress, primaryType string, auth *eip3009Auth) typedData {
 	return typedData{
 		Domain: typedDataDomain{
 			Name:              s.eip3009Name,
@@ -505,7 +631,7 @@ func (s *Signer) buildEIP712TypedData(tokenAddress string, auth *eip3009Auth) ty
 				{Name: "chainId", Type: "uint256"},
 				{Name: "verifyingContract", Type: "address"},
 			},
-			"TransferWithAuthorization": {
+			primaryType: {
 				{Name: "from", Type: "address"},
 				{Name: "to", Type: "address"},
 				{Name: "value", Type: "uint256"},
@@ -514,7 +640,7 @@ func (s *Signer) buildEIP712TypedData(tokenAddress string, auth *eip3009Auth) ty
 				{Name: "nonce", Type: "bytes32"},
 			},
 		},
-		PrimaryType: "TransferWithAuthorization",
+		PrimaryType: primaryType,
 		Message: map[string]interface{}{
 			"from":        auth.From,
 			"to":          auth.To,
@@ -572,9 +698,83 @@ func extractFeePayer(requirements *x402.PaymentRequirement) (string, error) {
 	return feePayerStr, nil
 }
 
+// defaultRPCClient is the HTTP client for Solana RPC requests when none is configured,
+// shared so concurrent Sign calls reuse its connections.
+var defaultRPCClient = &http.Client{Timeout: 10 * time.Second}
+
 // getRecentBlockhash retrieves a recent blockhash directly from the Solana network.
-// CDP doesn't provide a blockhash endpoint, so we fetch it from the public RPC.
 func (s *Signer) getRecentBlockhash(ctx context.Context) (string, error) {
+	var result struct {
+		Context struct {
+			Slot uint64 `json:"slot"`
+		} `json:"context"`
+		Value struct {
+			Blockhash            string `json:"blockhash"`
+			LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+		} `json:"value"`
+	}
+	err := s.solanaRPC(ctx, "getLatestBlockhash", []interface{}{map[string]string{"commitment": "finalized"}}, &result)
+	if err != nil {
+		return "", err
+	}
+
+	if result.Value.Blockhash == "" {
+		return "", fmt.Errorf("empty blockhash in RPC response")
+	}
+
+	return result.Value.Blockhash, nil
+}
+
+// computeUnitPrice returns the priority fee of a transfer of mint to recipient, in
+// microlamports per compute unit.
+func (s *Signer) computeUnitPrice(ctx context.Context, mint, recipient string) uint64 {
+	if s.maxPriorityFee == 0 {
+		return s.priorityFee
+	}
+
+	// Fees are paid to lock the accounts written, the source and destination token accounts
+	sourceATA, err := deriveAssociatedTokenAddress(s.address, mint)
+	if err != nil {
+		return s.priorityFee
+	}
+	destATA, err := deriveAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return s.priorityFee
+	}
+	var recent []struct {
+		Slot              uint64 `json:"slot"`
+		PrioritizationFee uint64 `json:"prioritizationFee"`
+	}
+	if err := s.solanaRPC(ctx, "getRecentPrioritizationFees", []interface{}{[]string{sourceATA, destATA}}, &recent); err != nil {
+		return s.priorityFee
+	}
+	fees := make([]uint64, len(recent))
+	for i, fee := range recent {
+		fees[i] = fee.PrioritizationFee
+	}
+	return autoPriorityFee(fees, s.priorityFee, s.maxPriorityFee)
+}
+
+// autoPriorityFee returns the priorityFeePercentile percentile of fees, clamped to
+// [floor, ceiling].
+func autoPriorityFee(fees []uint64, floor, ceiling uint64) uint64 {
+	fee := floor
+	if len(fees) > 0 {
+		sorted := append([]uint64(nil), fees...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if p := sorted[(len(sorted)-1)*priorityFeePercentile/100]; p > fee {
+			fee = p
+		}
+	}
+	if fee > ceiling {
+		fee = ceiling
+	}
+	return fee
+}
+
+// solanaRPC calls the Solana JSON-RPC method with params on the signer's network,
+// decoding its result into result.
+func (s *Signer) solanaRPC(ctx context.Context, method string, params []interface{}, result interface{}) error {
 	// Get RPC URL for the network
 	var rpcURL string
 	switch strings.ToLower(s.network) {
@@ -585,10 +785,9 @@ func (s *Signer) getRecentBlockhash(ctx context.Context) (string, error) {
 	case "testnet":
 		rpcURL = "https://api.testnet.solana.com"
 	default:
-		return "", fmt.Errorf("unsupported Solana network: %s", s.network)
+		return fmt.Errorf("unsupported Solana network: %s", s.network)
 	}
 
-	// Call Solana RPC getLatestBlockhash method
 	type rpcRequest struct {
 		JsonRPC string        `json:"jsonrpc"`
 		ID      int           `json:"id"`
@@ -597,16 +796,8 @@ func (s *Signer) getRecentBlockhash(ctx context.Context) (string, error) {
 	}
 
 	type rpcResponse struct {
-		Result struct {
-			Context struct {
-				Slot uint64 `json:"slot"`
-			} `json:"context"`
-			Value struct {
-				Blockhash            string `json:"blockhash"`
-				LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
-			} `json:"value"`
-		} `json:"result"`
-		Error *struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
 			Code    int    `json:"code"`
 			Message string `json:"message"`
 		} `json:"error"`
@@ -615,42 +806,45 @@ func (s *Signer) getRecentBlockhash(ctx context.Context) (string, error) {
 	reqBody := rpcRequest{
 		JsonRPC: "2.0",
 		ID:      1,
-		Method:  "getLatestBlockhash",
-		Params:  []interface{}{map[string]string{"commitment": "finalized"}},
+		Method:  method,
+		Params:  params,
 	}
 
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("marshal RPC request: %w", err)
+		return fmt.Errorf("marshal RPC request: %w", err)
 	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", rpcURL, bytes.NewReader(reqJSON))
 	if err != nil {
-		return "", fmt.Errorf("create HTTP request: %w", err)
+		return fmt.Errorf("create HTTP request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := s.httpClient
+	if client == nil {
+		client = defaultRPCClient
+	}
 	httpResp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("RPC request failed: %w", err)
+		return fmt.Errorf("RPC request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	var rpcResp rpcResponse
 	if err := json.NewDecoder(httpResp.Body).Decode(&rpcResp); err != nil {
-		return "", fmt.Errorf("decode RPC response: %w", err)
+		return fmt.Errorf("decode RPC response: %w", err)
 	}
 
 	if rpcResp.Error != nil {
-		return "", fmt.Errorf("RPC error: %s", rpcResp.Error.Message)
+		return fmt.Errorf("RPC error: %s", rpcResp.Error.Message)
 	}
 
-	if rpcResp.Result.Value.Blockhash == "" {
-		return "", fmt.Errorf("empty blockhash in RPC response")
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("decode RPC result: %w", err)
 	}
 
-	return rpcResp.Result.Value.Blockhash, nil
+	return nil
 }
 
 // solanaTransactionRequest represents the transaction structure for CDP signing.
@@ -682,6 +876,8 @@ func (s *Signer) buildSolanaTransaction(
 	decimals uint8,
 	feePayer string,
 	blockhash string,
+	computeUnitPrice uint64,
+	memo string,
 ) (*solanaTransactionRequest, error) {
 	// Derive associated token accounts (this follows the SPL Token standard)
 	// Source ATA: derived from signer's address + mint
@@ -697,8 +893,8 @@ func (s *Signer) buildSolanaTransaction(
 	}
 
 	// Build compute budget instructions (matching svm/signer.go pattern)
-	computeUnitLimitInst := buildComputeUnitLimitInstruction(200_000)
-	computeUnitPriceInst := buildComputeUnitPriceInstruction(10_000)
+	computeUnitLimitInst := buildComputeUnitLimitInstruction(s.computeUnitLimit)
+	computeUnitPriceInst := buildComputeUnitPriceInstruction(computeUnitPrice)
 
 	// Build TransferChecked instruction
 	transferInst := buildTransferCheckedInstruction(
@@ -721,6 +917,11 @@ func (s *Signer) buildSolanaTransaction(
 		Blockhash: blockhash,
 	}
 
+	// Record the requirement's reference for the payee to reconcile the payment
+	if memo != "" {
+		tx.Instructions = append(tx.Instructions, buildMemoInstruction(memo))
+	}
+
 	return tx, nil
 }
 
@@ -784,6 +985,15 @@ func buildComputeUnitPriceInstruction(microlamports uint64) solanaInstruction {
 	}
 }
 
+// buildMemoInstruction creates a Memo instruction recording memo, without signers.
+func buildMemoInstruction(memo string) solanaInstruction {
+	return solanaInstruction{
+		ProgramID: "MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr",
+		Accounts:  []solanaAccountMeta{},
+		Data:      hex.EncodeToString([]byte(memo)),
+	}
+}
+
 // buildTransferCheckedInstruction creates a TransferChecked instruction for SPL Token.
 func buildTransferCheckedInstruction(
 	source, mint, destination, owner string,
@@ -15,7 +15,8 @@ import (
 	"github.com/mark3labs/x402-go"
 )
 
-// EIP3009Authorization represents the parameters for EIP-3009 transferWithAuthorization.
+// EIP3009Authorization represents the parameters for EIP-3009 transferWithAuthorization
+// and receiveWithAuthorization.
 type EIP3009Authorization struct {
 	From        common.Address
 	To          common.Address
@@ -25,8 +26,30 @@ type EIP3009Authorization struct {
 	Nonce       common.Hash
 }
 
+// DefaultValidityBuffer is how long before signing EIP-3009 authorizations become valid
+// by default. It accounts for clock drift between client and server, so an authorization
+// is not rejected as not yet valid if the client's clock is slightly ahead.
+const DefaultValidityBuffer = 10 * time.Second
+
+// validAfterFunc returns the validAfter of an authorization signed at now.
+type validAfterFunc func(now time.Time) *big.Int
+
+// validAfterBuffer dates authorizations d before they are signed.
+func validAfterBuffer(d time.Duration) validAfterFunc {
+	return func(now time.Time) *big.Int {
+		return big.NewInt(now.Add(-d).Unix())
+	}
+}
+
 // CreateEIP3009Authorization creates a new EIP-3009 authorization with appropriate timing and nonce.
+// It becomes valid DefaultValidityBuffer before now.
 func CreateEIP3009Authorization(from, to common.Address, value *big.Int, timeoutSeconds int) (*EIP3009Authorization, error) {
+	return createAuthorization(from, to, value, timeoutSeconds, validAfterBuffer(DefaultValidityBuffer))
+}
+
+// createAuthorization creates an EIP-3009 authorization valid from validAfter until
+// timeoutSeconds from now.
+func createAuthorization(from, to common.Address, value *big.Int, timeoutSeconds int, validAfter validAfterFunc) (*EIP3009Authorization, error) {
 	// Generate a cryptographically secure random nonce
 	nonce, err := generateNonce()
 	if err != nil {
@@ -34,17 +57,14 @@ func CreateEIP3009Authorization(from, to common.Address, value *big.Int, timeout
 	}
 
 	// Set validity window
-	// Subtract 10 seconds from validAfter to account for clock drift between client and server
-	// This prevents the authorization from being rejected if the client's clock is slightly ahead
-	now := time.Now().Unix()
-	validAfter := big.NewInt(now - 10)
-	validBefore := big.NewInt(now + int64(timeoutSeconds))
+	now := time.Now()
+	validBefore := big.NewInt(now.Unix() + int64(timeoutSeconds))
 
 	return &EIP3009Authorization{
 		From:        from,
 		To:          to,
 		Value:       value,
-		ValidAfter:  validAfter,
+		ValidAfter:  validAfter(now),
 		ValidBefore: validBefore,
 		Nonce:       nonce,
 	}, nil
@@ -53,8 +73,40 @@ func CreateEIP3009Authorization(from, to common.Address, value *big.Int, timeout
 // SignTransferAuthorization signs an EIP-3009 transferWithAuthorization using EIP-712.
 // The name and version parameters should be provided from the payment requirements.
 func SignTransferAuthorization(privateKey *ecdsa.PrivateKey, tokenAddress common.Address, chainID *big.Int, auth *EIP3009Authorization, name, version string) (string, error) {
-	// Build EIP-712 typed data
-	typedData := apitypes.TypedData{
+	digest, err := HashTransferAuthorization(tokenAddress, chainID, auth, name, version)
+	if err != nil {
+		return "", err
+	}
+	return signDigest(privateKey, digest)
+}
+
+// SignReceiveAuthorization signs an EIP-3009 receiveWithAuthorization using EIP-712.
+// Only auth.To can submit it, which protects the payment from front-running.
+func SignReceiveAuthorization(privateKey *ecdsa.PrivateKey, tokenAddress common.Address, chainID *big.Int, auth *EIP3009Authorization, name, version string) (string, error) {
+	digest, err := HashReceiveAuthorization(tokenAddress, chainID, auth, name, version)
+	if err != nil {
+		return "", err
+	}
+	return signDigest(privateKey, digest)
+}
+
+// HashTransferAuthorization returns the EIP-712 hash of an EIP-3009
+// transferWithAuthorization, which the payer signs and the token contract verifies.
+func HashTransferAuthorization(tokenAddress common.Address, chainID *big.Int, auth *EIP3009Authorization, name, version string) ([]byte, error) {
+	return typedDataHash(authorizationTypedData("TransferWithAuthorization", tokenAddress, chainID, auth, name, version))
+}
+
+// HashReceiveAuthorization returns the EIP-712 hash of an EIP-3009
+// receiveWithAuthorization.
+func HashReceiveAuthorization(tokenAddress common.Address, chainID *big.Int, auth *EIP3009Authorization, name, version string) ([]byte, error) {
+	return typedDataHash(authorizationTypedData("ReceiveWithAuthorization", tokenAddress, chainID, auth, name, version))
+}
+
+// authorizationTypedData builds the EIP-712 typed data of an EIP-3009 authorization of
+// primaryType, TransferWithAuthorization or ReceiveWithAuthorization, which share their
+// fields.
+func authorizationTypedData(primaryType string, tokenAddress common.Address, chainID *big.Int, auth *EIP3009Authorization, name, version string) apitypes.TypedData {
+	return apitypes.TypedData{
 		Types: apitypes.Types{
 			"EIP712Domain": []apitypes.Type{
 				{Name: "name", Type: "string"},
@@ -62,7 +114,7 @@ func SignTransferAuthorization(privateKey *ecdsa.PrivateKey, tokenAddress common
 				{Name: "chainId", Type: "uint256"},
 				{Name: "verifyingContract", Type: "address"},
 			},
-			"TransferWithAuthorization": []apitypes.Type{
+			primaryType: []apitypes.Type{
 				{Name: "from", Type: "address"},
 				{Name: "to", Type: "address"},
 				{Name: "value", Type: "uint256"},
@@ -71,7 +123,7 @@ func SignTransferAuthorization(privateKey *ecdsa.PrivateKey, tokenAddress common
 				{Name: "nonce", Type: "bytes32"},
 			},
 		},
-		PrimaryType: "TransferWithAuthorization",
+		PrimaryType: primaryType,
 		Domain: apitypes.TypedDataDomain{
 			Name:              name,
 			Version:           version,
@@ -87,23 +139,10 @@ func SignTransferAuthorization(privateKey *ecdsa.PrivateKey, tokenAddress common
 			"nonce":       auth.Nonce.Hex(),
 		},
 	}
+}
 
-	// Compute the EIP-712 hash
-	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
-	if err != nil {
-		return "", fmt.Errorf("failed to hash domain: %w", err)
-	}
-
-	messageHash, err := typedData.HashStruct("TransferWithAuthorization", typedData.Message)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash message: %w", err)
-	}
-
-	// Build the final hash: keccak256("\x19\x01" || domainSeparator || messageHash)
-	rawData := append([]byte{0x19, 0x01}, append(domainSeparator, messageHash...)...)
-	digest := crypto.Keccak256(rawData)
-
-	// Sign the digest
+// signDigest signs an EIP-712 hash and returns the hex signature.
+func signDigest(privateKey *ecdsa.PrivateKey, digest []byte) (string, error) {
 	signature, err := crypto.Sign(digest, privateKey)
 	if err != nil {
 		return "", x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to sign authorization", err)
@@ -115,6 +154,23 @@ func SignTransferAuthorization(privateKey *ecdsa.PrivateKey, tokenAddress common
 	return "0x" + hex.EncodeToString(signature), nil
 }
 
+// typedDataHash returns the EIP-712 hash of typedData.
+func typedDataHash(typedData apitypes.TypedData) ([]byte, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
+	}
+
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash message: %w", err)
+	}
+
+	// Build the final hash: keccak256("\x19\x01" || domainSeparator || messageHash)
+	rawData := append([]byte{0x19, 0x01}, append(domainSeparator, messageHash...)...)
+	return crypto.Keccak256(rawData), nil
+}
+
 // generateNonce generates a cryptographically secure 32-byte random nonce.
 func generateNonce() (common.Hash, error) {
 	var nonce [32]byte
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mark3labs/x402-go"
 )
 
 func TestCreateEIP3009Authorization(t *testing.T) {
@@ -275,3 +277,62 @@ func TestSignTransferAuthorization_DifferentTokenAddresses(t *testing.T) {
 		t.Error("signatures should differ for different token addresses")
 	}
 }
+
+func TestSign_ReceiveWithAuthorization(t *testing.T) {
+	token := "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+	signer, err := NewSigner(WithPrivateKey(testPrivateKeyHex), WithNetwork("base"), WithToken(token, "USDC", 6))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	requirement := &x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000000",
+		Asset:             token,
+		PayTo:             "0x2222222222222222222222222222222222222222",
+		MaxTimeoutSeconds: 60,
+	}
+	requirement.SetEIP3009Domain("USD Coin", "2")
+
+	// recovers returns whether the signature of payload recovers the signer from the
+	// hash of its authorization
+	recovers := func(payload *x402.PaymentPayload, hash func(common.Address, *big.Int, *EIP3009Authorization, string, string) ([]byte, error)) bool {
+		evmPayload := payload.Payload.(x402.EVMPayload)
+		a := evmPayload.Authorization
+		value, _ := new(big.Int).SetString(a.Value, 10)
+		validAfter, _ := new(big.Int).SetString(a.ValidAfter, 10)
+		validBefore, _ := new(big.Int).SetString(a.ValidBefore, 10)
+		digest, err := hash(common.HexToAddress(token), big.NewInt(8453), &EIP3009Authorization{
+			From:        common.HexToAddress(a.From),
+			To:          common.HexToAddress(a.To),
+			Value:       value,
+			ValidAfter:  validAfter,
+			ValidBefore: validBefore,
+			Nonce:       common.HexToHash(a.Nonce),
+		}, "USD Coin", "2")
+		if err != nil {
+			t.Fatalf("hash error = %v", err)
+		}
+		sig := hexutil.MustDecode(evmPayload.Signature)
+		sig[64] -= 27
+		pub, err := crypto.SigToPub(digest, sig)
+		return err == nil && crypto.PubkeyToAddress(*pub) == signer.Address()
+	}
+
+	transfer, err := signer.Sign(requirement)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !recovers(transfer, HashTransferAuthorization) || recovers(transfer, HashReceiveAuthorization) {
+		t.Error("expected a transferWithAuthorization by default")
+	}
+
+	requirement.SetReceiveWithAuthorization()
+	receive, err := signer.Sign(requirement)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !recovers(receive, HashReceiveAuthorization) || recovers(receive, HashTransferAuthorization) {
+		t.Error("expected a receiveWithAuthorization for the flagged requirement")
+	}
+}
@@ -14,39 +14,53 @@ import (
 )
 
 // WithKeystore loads a private key from an encrypted keystore file.
+// Reload reads the file again, so the keystore can be replaced to rotate keys.
 func WithKeystore(keystorePath, password string) SignerOption {
 	return func(s *Signer) error {
-		// Read keystore file
-		data, err := os.ReadFile(keystorePath)
-		if err != nil {
-			return fmt.Errorf("%w: %v", x402.ErrInvalidKeystore, err)
-		}
-
-		// Parse keystore JSON
-		var keyJSON struct {
-			Crypto keystore.CryptoJSON `json:"crypto"`
-		}
-		if err := json.Unmarshal(data, &keyJSON); err != nil {
-			return fmt.Errorf("%w: invalid JSON format", x402.ErrInvalidKeystore)
-		}
-
-		// Decrypt the key
-		privateKeyBytes, err := keystore.DecryptDataV3(keyJSON.Crypto, password)
-		if err != nil {
-			return fmt.Errorf("%w: decryption failed", x402.ErrInvalidKeystore)
+		s.loadKey = func() (*ecdsa.PrivateKey, error) {
+			return loadKeystore(keystorePath, password)
 		}
 
-		// Convert to ECDSA private key
-		privateKey, err := crypto.ToECDSA(privateKeyBytes)
+		privateKey, err := s.loadKey()
 		if err != nil {
-			return fmt.Errorf("%w: invalid private key", x402.ErrInvalidKeystore)
+			return err
 		}
-
 		s.privateKey = privateKey
 		return nil
 	}
 }
 
+// loadKeystore reads and decrypts a private key from a keystore file.
+func loadKeystore(keystorePath, password string) (*ecdsa.PrivateKey, error) {
+	// Read keystore file
+	data, err := os.ReadFile(keystorePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", x402.ErrInvalidKeystore, err)
+	}
+
+	// Parse keystore JSON
+	var keyJSON struct {
+		Crypto keystore.CryptoJSON `json:"crypto"`
+	}
+	if err := json.Unmarshal(data, &keyJSON); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON format", x402.ErrInvalidKeystore)
+	}
+
+	// Decrypt the key
+	privateKeyBytes, err := keystore.DecryptDataV3(keyJSON.Crypto, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decryption failed", x402.ErrInvalidKeystore)
+	}
+
+	// Convert to ECDSA private key
+	privateKey, err := crypto.ToECDSA(privateKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid private key", x402.ErrInvalidKeystore)
+	}
+
+	return privateKey, nil
+}
+
 // WithMnemonic derives a private key from a BIP39 mnemonic phrase.
 // The accountIndex parameter selects which HD account to use (typically 0).
 // Derivation path: m/44'/60'/0'/0/{accountIndex}
@@ -68,6 +82,7 @@ func WithMnemonic(mnemonic string, accountIndex uint32) SignerOption {
 		}
 
 		s.privateKey = privateKey
+		s.loadKey = nil
 		return nil
 	}
 }
@@ -0,0 +1,153 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/mark3labs/x402-go"
+)
+
+// noncesSelector is the selector of the EIP-2612 nonces(address) function.
+var noncesSelector = []byte{0x7e, 0xce, 0xbe, 0x00}
+
+// EIP2612Permit represents the parameters of an EIP-2612 permit.
+type EIP2612Permit struct {
+	Owner    common.Address
+	Spender  common.Address
+	Value    *big.Int
+	Nonce    *big.Int
+	Deadline *big.Int
+}
+
+// CreatePermit creates an EIP-2612 permit for value, valid for timeoutSeconds. nonce is
+// the owner's current permit nonce on the token contract.
+func CreatePermit(owner, spender common.Address, value, nonce *big.Int, timeoutSeconds int) *EIP2612Permit {
+	return &EIP2612Permit{
+		Owner:    owner,
+		Spender:  spender,
+		Value:    value,
+		Nonce:    nonce,
+		Deadline: big.NewInt(time.Now().Unix() + int64(timeoutSeconds)),
+	}
+}
+
+// HashPermit returns the EIP-712 hash of an EIP-2612 permit, which the owner signs and
+// the token contract verifies.
+func HashPermit(tokenAddress common.Address, chainID *big.Int, permit *EIP2612Permit, name, version string) ([]byte, error) {
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": []apitypes.Type{
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": []apitypes.Type{
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              name,
+			Version:           version,
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: tokenAddress.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner":    permit.Owner.Hex(),
+			"spender":  permit.Spender.Hex(),
+			"value":    (*math.HexOrDecimal256)(permit.Value),
+			"nonce":    (*math.HexOrDecimal256)(permit.Nonce),
+			"deadline": (*math.HexOrDecimal256)(permit.Deadline),
+		},
+	}
+	return typedDataHash(typedData)
+}
+
+// SignPermit signs an EIP-2612 permit using EIP-712. The name and version parameters
+// should be provided from the payment requirements.
+func SignPermit(privateKey *ecdsa.PrivateKey, tokenAddress common.Address, chainID *big.Int, permit *EIP2612Permit, name, version string) (string, error) {
+	digest, err := HashPermit(tokenAddress, chainID, permit, name, version)
+	if err != nil {
+		return "", err
+	}
+	return signDigest(privateKey, digest)
+}
+
+// WithPermit enables the permit scheme, for tokens implementing EIP-2612 but not
+// EIP-3009. Signing a permit payment reads the signer's permit nonce from the token
+// through the JSON-RPC endpoint rpcURL.
+//
+// Permit nonces are sequential, so a permit is invalidated by any other permit of the
+// same owner on the same token that is settled first: concurrent permit payments for
+// one token may be rejected by the facilitator and need to be retried.
+func WithPermit(rpcURL string) SignerOption {
+	return func(s *Signer) error {
+		client, err := ethclient.Dial(rpcURL)
+		if err != nil {
+			return fmt.Errorf("evm: failed to connect to RPC: %w", err)
+		}
+		s.permitCaller = client
+		return nil
+	}
+}
+
+// signPermit creates the payload of a permit payment of amount of token.
+func (s *Signer) signPermit(requirements *x402.PaymentRequirement, key *signingKey, token common.Address, amount *big.Int, name, version string) (*x402.PaymentPayload, error) {
+	spenderVal, _ := requirements.Extra[x402.ExtraSpender].(string)
+	if !common.IsHexAddress(spenderVal) {
+		return nil, fmt.Errorf("%w: missing or invalid permit spender", x402.ErrInvalidRequirements)
+	}
+	spender := common.HexToAddress(spenderVal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), RPCTimeout)
+	defer cancel()
+	nonce, err := readPermitNonce(ctx, s.permitCaller, token, key.address)
+	if err != nil {
+		return nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to read permit nonce", err)
+	}
+
+	permit := CreatePermit(key.address, spender, amount, nonce, requirements.MaxTimeoutSeconds)
+	signature, err := SignPermit(key.privateKey, token, s.chainID, permit, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      x402.SchemePermit,
+		Network:     s.network,
+		Payload: x402.EVMPermitPayload{
+			Signature: signature,
+			Permit: x402.EVMPermit{
+				Owner:    permit.Owner.Hex(),
+				Spender:  permit.Spender.Hex(),
+				Value:    permit.Value.String(),
+				Nonce:    permit.Nonce.String(),
+				Deadline: permit.Deadline.String(),
+			},
+		},
+	}, nil
+}
+
+// readPermitNonce returns the current permit nonce of owner on token.
+func readPermitNonce(ctx context.Context, client contractCaller, token, owner common.Address) (*big.Int, error) {
+	data := append(append([]byte{}, noncesSelector...), common.LeftPadBytes(owner.Bytes(), 32)...)
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("nonces() failed: %w", err)
+	}
+	return decodeUint(result, 0)
+}
@@ -0,0 +1,114 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mark3labs/x402-go"
+)
+
+func TestSignPermit(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKeyHex)
+	if err != nil {
+		t.Fatalf("HexToECDSA() error = %v", err)
+	}
+	owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+	token := common.HexToAddress("0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb")
+	spender := common.HexToAddress("0x209693Bc6afc0C5328bA36FaF03C514EF312287C")
+	permit := CreatePermit(owner, spender, big.NewInt(1000), big.NewInt(3), 60)
+
+	signature, err := SignPermit(privateKey, token, big.NewInt(8453), permit, "Dai Stablecoin", "1")
+	if err != nil {
+		t.Fatalf("SignPermit() error = %v", err)
+	}
+
+	sig := hexutil.MustDecode(signature)
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		t.Fatalf("signature = %s, want 65 bytes with v of 27 or 28", signature)
+	}
+	digest, err := HashPermit(token, big.NewInt(8453), permit, "Dai Stablecoin", "1")
+	if err != nil {
+		t.Fatalf("HashPermit() error = %v", err)
+	}
+	sig[64] -= 27
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatalf("SigToPub() error = %v", err)
+	}
+	if crypto.PubkeyToAddress(*pub) != owner {
+		t.Errorf("signature recovers %s, want %s", crypto.PubkeyToAddress(*pub).Hex(), owner.Hex())
+	}
+
+	// Another domain yields another digest
+	other, _ := HashPermit(token, big.NewInt(8453), permit, "Dai Stablecoin", "2")
+	if common.BytesToHash(other) == common.BytesToHash(digest) {
+		t.Error("HashPermit() ignores the domain version")
+	}
+}
+
+func TestSign_Permit(t *testing.T) {
+	asset := "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb"
+	spender := "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+	withNonces := func(caller contractCaller) SignerOption {
+		return func(s *Signer) error {
+			s.permitCaller = caller
+			return nil
+		}
+	}
+	requirement := &x402.PaymentRequirement{
+		Scheme:            "permit",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		Asset:             asset,
+		PayTo:             "0x1111111111111111111111111111111111111111",
+		MaxTimeoutSeconds: 60,
+		Extra:             map[string]interface{}{"name": "Dai Stablecoin", "version": "1", "spender": spender},
+	}
+
+	// Without WithPermit the signer only pays the exact scheme
+	plain, err := NewSigner(WithPrivateKey(testPrivateKeyHex), WithNetwork("base"), WithToken(asset, "DAI", 18))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	if plain.CanSign(requirement) {
+		t.Error("CanSign() = true for a permit requirement without WithPermit")
+	}
+
+	signer, err := NewSigner(
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		WithToken(asset, "DAI", 18),
+		withNonces(fakeToken{string(append(append([]byte{}, noncesSelector...), common.LeftPadBytes(common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266").Bytes(), 32)...)): abiWord(7)}),
+	)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	if !signer.CanSign(requirement) {
+		t.Fatal("CanSign() = false for a permit requirement")
+	}
+
+	payload, err := signer.Sign(requirement)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if payload.Scheme != "permit" {
+		t.Errorf("payload.Scheme = %s, want permit", payload.Scheme)
+	}
+	permit, ok := payload.Payload.(x402.EVMPermitPayload)
+	if !ok {
+		t.Fatalf("payload.Payload = %T, want x402.EVMPermitPayload", payload.Payload)
+	}
+	if permit.Permit.Nonce != "7" || permit.Permit.Value != "1000" || permit.Permit.Spender != spender || permit.Permit.Owner != signer.Address().Hex() {
+		t.Errorf("permit = %+v, want nonce 7 and value 1000 for %s", permit.Permit, spender)
+	}
+
+	// A permit requirement needs a spender
+	noSpender := *requirement
+	noSpender.Extra = map[string]interface{}{"name": "Dai Stablecoin", "version": "1"}
+	if _, err := signer.Sign(&noSpender); err == nil {
+		t.Error("Sign() without spender succeeded")
+	}
+}
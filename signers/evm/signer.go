@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -12,14 +14,28 @@ import (
 )
 
 // Signer implements the x402.Signer interface for EVM-compatible chains.
+// Its key can be replaced at runtime with SwapKey or Reload.
+//
+// Signer is safe for concurrent use. Its configuration is immutable after NewSigner
+// returns and the key is swapped atomically, so Sign takes no locks.
 type Signer struct {
+	key          atomic.Pointer[signingKey]
+	privateKey   *ecdsa.PrivateKey // key configured by the options, before NewSigner returns
+	loadKey      func() (*ecdsa.PrivateKey, error)
+	network      string
+	chainID      *big.Int
+	tokens       []x402.TokenConfig
+	domains      map[string]tokenDomain // EIP-712 domains discovered by WithAutoToken, by lowercase address
+	permitCaller contractCaller         // reads permit nonces; nil unless WithPermit enabled the permit scheme
+	priority     int
+	maxAmount    *big.Int
+	validAfter   validAfterFunc // dates EIP-3009 authorizations, per WithValidityBuffer
+}
+
+// signingKey is a private key with the address derived from it.
+type signingKey struct {
 	privateKey *ecdsa.PrivateKey
 	address    common.Address
-	network    string
-	chainID    *big.Int
-	tokens     []x402.TokenConfig
-	priority   int
-	maxAmount  *big.Int
 }
 
 // SignerOption configures a Signer.
@@ -28,7 +44,8 @@ type SignerOption func(*Signer) error
 // NewSigner creates a new EVM signer with the given options.
 func NewSigner(opts ...SignerOption) (*Signer, error) {
 	s := &Signer{
-		priority: 0,
+		priority:   0,
+		validAfter: validAfterBuffer(DefaultValidityBuffer),
 	}
 
 	for _, opt := range opts {
@@ -49,27 +66,52 @@ func NewSigner(opts ...SignerOption) (*Signer, error) {
 	}
 
 	// Derive address and chain ID from network
-	s.address = crypto.PubkeyToAddress(s.privateKey.PublicKey)
+	s.setKey(s.privateKey)
 	chainID, err := getChainID(s.network)
 	if err != nil {
 		return nil, err
 	}
 	s.chainID = chainID
 
+	for address, domain := range s.domains {
+		if domain.chainID != nil && domain.chainID.Cmp(chainID) != 0 {
+			return nil, fmt.Errorf("%w: token %s is on chain %s, not %s", x402.ErrInvalidToken, address, domain.chainID, s.network)
+		}
+	}
+
 	return s, nil
 }
 
 // WithPrivateKey sets the private key from a hex string.
 func WithPrivateKey(hexKey string) SignerOption {
 	return func(s *Signer) error {
-		// Remove 0x prefix if present
-		hexKey = strings.TrimPrefix(hexKey, "0x")
-
-		privateKey, err := crypto.HexToECDSA(hexKey)
+		privateKey, err := parsePrivateKey(hexKey)
 		if err != nil {
-			return x402.ErrInvalidKey
+			return err
 		}
 
+		s.privateKey = privateKey
+		s.loadKey = nil
+		return nil
+	}
+}
+
+// WithPrivateKeyProvider loads the private key from provider, which returns a hex string.
+// Reload calls provider again, so keys kept in a secret manager can be rotated.
+func WithPrivateKeyProvider(provider func() (string, error)) SignerOption {
+	return func(s *Signer) error {
+		s.loadKey = func() (*ecdsa.PrivateKey, error) {
+			hexKey, err := provider()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", x402.ErrInvalidKey, err)
+			}
+			return parsePrivateKey(hexKey)
+		}
+
+		privateKey, err := s.loadKey()
+		if err != nil {
+			return err
+		}
 		s.privateKey = privateKey
 		return nil
 	}
@@ -129,6 +171,35 @@ func WithMaxAmountPerCall(amount string) SignerOption {
 	}
 }
 
+// WithValidityBuffer sets how long before signing EIP-3009 authorizations become valid
+// (default DefaultValidityBuffer), to allow for clock drift between the payer and the
+// verifier. Devices with unreliable clocks or high latency may need a larger buffer.
+func WithValidityBuffer(d time.Duration) SignerOption {
+	return func(s *Signer) error {
+		if d < 0 {
+			return fmt.Errorf("evm: validity buffer must not be negative, got %v", d)
+		}
+		s.validAfter = validAfterBuffer(d)
+		return nil
+	}
+}
+
+// WithValidAfterNow makes EIP-3009 authorizations valid from the moment they are signed,
+// for verifiers that reject a validAfter in the past.
+func WithValidAfterNow() SignerOption {
+	return WithValidityBuffer(0)
+}
+
+// WithValidAfterZero makes EIP-3009 authorizations valid from the Unix epoch, so clock
+// drift can never make them not yet valid. They still expire MaxTimeoutSeconds after
+// signing.
+func WithValidAfterZero() SignerOption {
+	return func(s *Signer) error {
+		s.validAfter = func(time.Time) *big.Int { return new(big.Int) }
+		return nil
+	}
+}
+
 // Network implements x402.Signer.
 func (s *Signer) Network() string {
 	return s.network
@@ -147,7 +218,7 @@ func (s *Signer) CanSign(requirements *x402.PaymentRequirement) bool {
 	}
 
 	// Check scheme match
-	if requirements.Scheme != "exact" {
+	if requirements.Scheme != "exact" && (requirements.Scheme != x402.SchemePermit || s.permitCaller == nil) {
 		return false
 	}
 
@@ -163,6 +234,20 @@ func (s *Signer) CanSign(requirements *x402.PaymentRequirement) bool {
 
 // Sign implements x402.Signer.
 func (s *Signer) Sign(requirements *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	return s.sign(requirements, nil)
+}
+
+// SignBound implements x402.BoundSigner. EIP-2612 permits, whose nonces are sequential,
+// cannot be bound.
+func (s *Signer) SignBound(requirements *x402.PaymentRequirement, nonce [32]byte) (*x402.PaymentPayload, error) {
+	if requirements.Scheme == x402.SchemePermit {
+		return nil, x402.ErrBindingUnsupported
+	}
+	return s.sign(requirements, &nonce)
+}
+
+// sign implements Sign and SignBound, with a random EIP-3009 nonce unless nonce is set.
+func (s *Signer) sign(requirements *x402.PaymentRequirement, nonce *[32]byte) (*x402.PaymentPayload, error) {
 	// Verify we can sign
 	if !s.CanSign(requirements) {
 		return nil, x402.ErrNoValidSigner
@@ -188,25 +273,44 @@ func (s *Signer) Sign(requirements *x402.PaymentRequirement) (*x402.PaymentPaylo
 		}
 	}
 
-	// Extract EIP-3009 domain parameters from requirements
+	// Extract EIP-3009 domain parameters from requirements, or use the discovered domain
 	name, version, err := extractEIP3009Params(requirements)
 	if err != nil {
-		return nil, err
+		domain, ok := s.domains[strings.ToLower(requirements.Asset)]
+		if !ok {
+			return nil, err
+		}
+		name, version = domain.name, domain.version
+	}
+
+	// Use one key for the whole signature even if it is rotated concurrently
+	key := s.key.Load()
+
+	if requirements.Scheme == x402.SchemePermit {
+		return s.signPermit(requirements, key, tokenAddress, amount, name, version)
 	}
 
 	// Create EIP-3009 authorization
-	auth, err := CreateEIP3009Authorization(
-		s.address,
+	auth, err := createAuthorization(
+		key.address,
 		common.HexToAddress(requirements.PayTo),
 		amount,
 		requirements.MaxTimeoutSeconds,
+		s.validAfter,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if nonce != nil {
+		auth.Nonce = common.Hash(*nonce)
+	}
 
 	// Sign the authorization with the correct domain parameters
-	signature, err := SignTransferAuthorization(s.privateKey, tokenAddress, s.chainID, auth, name, version)
+	sign := SignTransferAuthorization
+	if requirements.ReceiveWithAuthorization() {
+		sign = SignReceiveAuthorization
+	}
+	signature, err := sign(key.privateKey, tokenAddress, s.chainID, auth, name, version)
 	if err != nil {
 		return nil, err
 	}
@@ -249,7 +353,49 @@ func (s *Signer) GetMaxAmount() *big.Int {
 
 // Address returns the signer's Ethereum address.
 func (s *Signer) Address() common.Address {
-	return s.address
+	return s.key.Load().address
+}
+
+// SwapKey replaces the signer's private key with hexKey. Payments signed after SwapKey
+// returns use the new key; signatures in progress finish with the old one.
+func (s *Signer) SwapKey(hexKey string) error {
+	privateKey, err := parsePrivateKey(hexKey)
+	if err != nil {
+		return err
+	}
+	s.setKey(privateKey)
+	return nil
+}
+
+// Reload re-reads the private key from the keystore file or provider it was loaded
+// from. It returns an error wrapping x402.ErrInvalidKey if the key was given directly.
+func (s *Signer) Reload() error {
+	if s.loadKey == nil {
+		return fmt.Errorf("%w: signer has no reloadable key source", x402.ErrInvalidKey)
+	}
+	privateKey, err := s.loadKey()
+	if err != nil {
+		return err
+	}
+	s.setKey(privateKey)
+	return nil
+}
+
+// setKey atomically replaces the private key and the address derived from it.
+func (s *Signer) setKey(privateKey *ecdsa.PrivateKey) {
+	s.key.Store(&signingKey{
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	})
+}
+
+// parsePrivateKey parses a hex private key with or without 0x prefix.
+func parsePrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, x402.ErrInvalidKey
+	}
+	return privateKey, nil
 }
 
 // getChainID returns the chain ID for the given network.
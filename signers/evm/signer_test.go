@@ -1,8 +1,12 @@
 package evm
 
 import (
+	"errors"
 	"math/big"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/mark3labs/x402-go"
@@ -441,3 +445,214 @@ func TestTokenPriority(t *testing.T) {
 		t.Errorf("expected ETH priority 0, got %d", priorities["ETH"])
 	}
 }
+
+func TestKeyRotation(t *testing.T) {
+	// Second Hardhat test account (DO NOT use in production)
+	const rotatedKeyHex = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+	originalAddress := "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	rotatedAddress := "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+
+	currentKey := testPrivateKeyHex
+	signer, err := NewSigner(
+		WithPrivateKeyProvider(func() (string, error) { return currentKey, nil }),
+		WithNetwork("base"),
+		WithToken("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", "USDC", 6),
+	)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	var _ x402.KeyRotator = signer
+
+	if signer.Address().Hex() != originalAddress {
+		t.Fatalf("expected address %s, got %s", originalAddress, signer.Address().Hex())
+	}
+
+	// Reload picks up the rotated key from the provider
+	currentKey = rotatedKeyHex
+	if err := signer.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if signer.Address().Hex() != rotatedAddress {
+		t.Errorf("expected address %s after Reload, got %s", rotatedAddress, signer.Address().Hex())
+	}
+
+	// SwapKey replaces the key directly
+	if err := signer.SwapKey("0x" + testPrivateKeyHex); err != nil {
+		t.Fatalf("SwapKey failed: %v", err)
+	}
+	if signer.Address().Hex() != originalAddress {
+		t.Errorf("expected address %s after SwapKey, got %s", originalAddress, signer.Address().Hex())
+	}
+	if err := signer.SwapKey("not-a-key"); !errors.Is(err, x402.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for invalid key, got %v", err)
+	}
+	if signer.Address().Hex() != originalAddress {
+		t.Error("a failed SwapKey must keep the previous key")
+	}
+
+	// Signers created from a literal key have nothing to reload
+	static, _ := NewSigner(
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		WithToken("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", "USDC", 6),
+	)
+	if err := static.Reload(); !errors.Is(err, x402.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey from Reload without key source, got %v", err)
+	}
+}
+
+func TestSigner_ConcurrentSign(t *testing.T) {
+	// Run with -race: Sign must not race with itself or with key rotation
+	signer, err := NewSigner(
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		WithToken("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", "USDC", 6),
+		WithMaxAmountPerCall("1000000"),
+	)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	requirements := &x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		MaxAmountRequired: "500000",
+		PayTo:             "0x1234567890123456789012345678901234567890",
+		MaxTimeoutSeconds: 60,
+		Extra: map[string]interface{}{
+			"name":    "USD Coin",
+			"version": "2",
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := signer.Sign(requirements); err != nil {
+					t.Errorf("Sign failed: %v", err)
+					return
+				}
+				_ = signer.CanSign(requirements)
+				_ = signer.Address()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 10; j++ {
+			if err := signer.SwapKey(testPrivateKeyHex); err != nil {
+				t.Errorf("SwapKey failed: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSign_ValidAfter(t *testing.T) {
+	requirement := &x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		MaxAmountRequired: "500000",
+		PayTo:             "0x1234567890123456789012345678901234567890",
+		MaxTimeoutSeconds: 60,
+		Extra:             map[string]interface{}{"name": "USD Coin", "version": "2"},
+	}
+
+	tests := []struct {
+		name   string
+		opts   []SignerOption
+		before int64 // seconds validAfter is before signing, or -1 for the epoch
+	}{
+		{name: "default buffer", before: 10},
+		{name: "custom buffer", opts: []SignerOption{WithValidityBuffer(2 * time.Minute)}, before: 120},
+		{name: "now", opts: []SignerOption{WithValidAfterNow()}, before: 0},
+		{name: "zero", opts: []SignerOption{WithValidAfterZero()}, before: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]SignerOption{
+				WithPrivateKey(testPrivateKeyHex),
+				WithNetwork("base"),
+				WithToken("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", "USDC", 6),
+			}, tt.opts...)
+			signer, err := NewSigner(opts...)
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+
+			now := time.Now().Unix()
+			payload, err := signer.Sign(requirement)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			auth := payload.Payload.(x402.EVMPayload).Authorization
+			validAfter, _ := strconv.ParseInt(auth.ValidAfter, 10, 64)
+			validBefore, _ := strconv.ParseInt(auth.ValidBefore, 10, 64)
+
+			if tt.before < 0 {
+				if validAfter != 0 {
+					t.Errorf("validAfter = %d, want 0", validAfter)
+				}
+			} else if got := now - validAfter; got < tt.before-1 || got > tt.before {
+				t.Errorf("validAfter is %ds before signing, want %ds", got, tt.before)
+			}
+			if got := validBefore - now; got < 60 || got > 61 {
+				t.Errorf("validBefore is %ds after signing, want 60s", got)
+			}
+		})
+	}
+
+	if _, err := NewSigner(WithValidityBuffer(-time.Second)); err == nil {
+		t.Error("NewSigner() with a negative buffer succeeded")
+	}
+}
+
+func TestSignBound(t *testing.T) {
+	signer, err := NewSigner(
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		WithToken("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", "USDC", 6),
+	)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	requirement := &x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		MaxAmountRequired: "500000",
+		PayTo:             "0x1234567890123456789012345678901234567890",
+		MaxTimeoutSeconds: 60,
+		Extra:             map[string]interface{}{"name": "USD Coin", "version": "2"},
+	}
+
+	// The bound payment's nonce commits to the binding material
+	material := []byte("dpop key thumbprint")
+	payload, err := x402.BindSigners([]x402.Signer{signer}, x402.BindingDPoP, material)[0].Sign(requirement)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if payload.Binding == nil || payload.Binding.Method != x402.BindingDPoP {
+		t.Fatalf("Binding = %+v, want a DPoP binding", payload.Binding)
+	}
+	nonce := payload.Payload.(x402.EVMPayload).Authorization.Nonce
+	if !payload.Binding.Verify(nonce, material) {
+		t.Errorf("nonce %s does not bind the payment", nonce)
+	}
+	if payload.Binding.Verify(nonce, []byte("another key")) {
+		t.Error("nonce binds the payment to another key")
+	}
+
+	permit := *requirement
+	permit.Scheme = x402.SchemePermit
+	if _, err := signer.SignBound(&permit, [32]byte{}); !errors.Is(err, x402.ErrBindingUnsupported) {
+		t.Errorf("SignBound() on a permit error = %v, want ErrBindingUnsupported", err)
+	}
+}
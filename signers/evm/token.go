@@ -0,0 +1,211 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mark3labs/x402-go"
+)
+
+// RPCTimeout bounds the RPC calls the signer makes: WithAutoToken reading a token's
+// metadata, and Sign reading the permit nonce of permit payments.
+const RPCTimeout = 10 * time.Second
+
+// Token metadata function selectors.
+var (
+	symbolSelector       = []byte{0x95, 0xd8, 0x9b, 0x41} // symbol()
+	decimalsSelector     = []byte{0x31, 0x3c, 0xe5, 0x67} // decimals()
+	nameSelector         = []byte{0x06, 0xfd, 0xde, 0x03} // name()
+	versionSelector      = []byte{0x54, 0xfd, 0x4d, 0x50} // version()
+	eip712DomainSelector = []byte{0x84, 0xb0, 0x19, 0x6e} // eip712Domain() (EIP-5267)
+)
+
+// defaultDomainVersion is the EIP-712 domain version of tokens without eip712Domain()
+// or version(), as set by OpenZeppelin's ERC20Permit.
+const defaultDomainVersion = "1"
+
+// tokenDomain is the EIP-712 domain of a token read from its contract.
+type tokenDomain struct {
+	name    string
+	version string
+
+	// chainID is the chain the contract reported, or nil if it did not report one.
+	chainID *big.Int
+}
+
+// WithAutoToken adds the ERC-20 token at address, reading its symbol, decimals and
+// EIP-712 domain from the contract through the JSON-RPC endpoint rpcURL instead of
+// hard-coding them. The domain is read with eip712Domain() (EIP-5267) when the token
+// implements it, and with name() and version() otherwise.
+//
+// The discovered domain is used to sign payments whose requirements do not carry the
+// name and version in Extra. NewSigner fails if the contract reports a chain other than
+// the signer's network.
+func WithAutoToken(address, rpcURL string) SignerOption {
+	return func(s *Signer) error {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("%w: invalid token address %q", x402.ErrInvalidToken, address)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), RPCTimeout)
+		defer cancel()
+
+		client, err := ethclient.DialContext(ctx, rpcURL)
+		if err != nil {
+			return fmt.Errorf("evm: failed to connect to RPC: %w", err)
+		}
+		defer client.Close()
+
+		token, domain, err := discoverToken(ctx, client, common.HexToAddress(address))
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", x402.ErrInvalidToken, address, err)
+		}
+
+		s.tokens = append(s.tokens, token)
+		if s.domains == nil {
+			s.domains = make(map[string]tokenDomain)
+		}
+		s.domains[strings.ToLower(address)] = domain
+		return nil
+	}
+}
+
+// contractCaller is the part of ethclient.Client discoverToken uses.
+type contractCaller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// discoverToken reads the token configuration and EIP-712 domain of the token at address.
+func discoverToken(ctx context.Context, client contractCaller, address common.Address) (x402.TokenConfig, tokenDomain, error) {
+	call := func(selector []byte) ([]byte, error) {
+		return client.CallContract(ctx, ethereum.CallMsg{To: &address, Data: selector}, nil)
+	}
+
+	result, err := call(symbolSelector)
+	if err != nil {
+		return x402.TokenConfig{}, tokenDomain{}, fmt.Errorf("symbol() failed: %w", err)
+	}
+	symbol, err := decodeString(result, 0)
+	if err != nil {
+		return x402.TokenConfig{}, tokenDomain{}, fmt.Errorf("symbol(): %w", err)
+	}
+
+	result, err = call(decimalsSelector)
+	if err != nil {
+		return x402.TokenConfig{}, tokenDomain{}, fmt.Errorf("decimals() failed: %w", err)
+	}
+	decimals, err := decodeUint(result, 0)
+	if err != nil || !decimals.IsUint64() || decimals.Uint64() > 255 {
+		return x402.TokenConfig{}, tokenDomain{}, errors.New("decimals(): invalid result")
+	}
+
+	domain, err := discoverDomain(call)
+	if err != nil {
+		return x402.TokenConfig{}, tokenDomain{}, err
+	}
+
+	token := x402.TokenConfig{
+		Address:  address.Hex(),
+		Symbol:   symbol,
+		Decimals: int(decimals.Uint64()),
+		Name:     domain.name,
+	}
+	return token, domain, nil
+}
+
+// discoverDomain reads a token's EIP-712 domain with eip712Domain(), falling back to
+// name() and version() for tokens that predate EIP-5267.
+func discoverDomain(call func(selector []byte) ([]byte, error)) (tokenDomain, error) {
+	if result, err := call(eip712DomainSelector); err == nil {
+		if domain, err := decodeEIP712Domain(result); err == nil {
+			return domain, nil
+		}
+	}
+
+	result, err := call(nameSelector)
+	if err != nil {
+		return tokenDomain{}, fmt.Errorf("name() failed: %w", err)
+	}
+	name, err := decodeString(result, 0)
+	if err != nil {
+		return tokenDomain{}, fmt.Errorf("name(): %w", err)
+	}
+
+	version := defaultDomainVersion
+	if result, err := call(versionSelector); err == nil {
+		if v, err := decodeString(result, 0); err == nil && v != "" {
+			version = v
+		}
+	}
+	return tokenDomain{name: name, version: version}, nil
+}
+
+// decodeEIP712Domain decodes the result of eip712Domain(): the fields bitmap, name,
+// version, chainId, verifyingContract, salt and extensions.
+func decodeEIP712Domain(data []byte) (tokenDomain, error) {
+	name, err := decodeString(data, 1)
+	if err != nil {
+		return tokenDomain{}, err
+	}
+	version, err := decodeString(data, 2)
+	if err != nil {
+		return tokenDomain{}, err
+	}
+	if name == "" {
+		return tokenDomain{}, errors.New("empty domain name")
+	}
+	domain := tokenDomain{name: name, version: version}
+
+	// Bit 2 of the fields bitmap marks the chainId as part of the domain
+	const chainIDField = 1 << 2
+	if len(data) > 0 && data[0]&chainIDField != 0 {
+		chainID, err := decodeUint(data, 3)
+		if err != nil {
+			return tokenDomain{}, err
+		}
+		domain.chainID = chainID
+	}
+	return domain, nil
+}
+
+// decodeUint decodes the uint256 in the index-th 32-byte word of ABI-encoded data.
+func decodeUint(data []byte, index int) (*big.Int, error) {
+	start := index * 32
+	if len(data) < start+32 {
+		return nil, errors.New("result too short")
+	}
+	return new(big.Int).SetBytes(data[start : start+32]), nil
+}
+
+// decodeString decodes the string whose offset is in the index-th 32-byte word of
+// ABI-encoded data. A lone bytes32 result, returned by some older tokens for symbol()
+// and name(), is decoded as a NUL-padded string.
+func decodeString(data []byte, index int) (string, error) {
+	if index == 0 && len(data) == 32 {
+		return strings.TrimRight(string(data), "\x00"), nil
+	}
+
+	offset, err := decodeUint(data, index)
+	if err != nil {
+		return "", err
+	}
+	if !offset.IsInt64() || offset.Int64() > int64(len(data)) || offset.Int64()%32 != 0 {
+		return "", errors.New("invalid string offset")
+	}
+	length, err := decodeUint(data, int(offset.Int64()/32))
+	if err != nil {
+		return "", err
+	}
+	start := offset.Int64() + 32
+	if !length.IsInt64() || length.Int64() > int64(len(data))-start {
+		return "", errors.New("invalid string length")
+	}
+	return string(data[start : start+length.Int64()]), nil
+}
@@ -0,0 +1,209 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mark3labs/x402-go"
+)
+
+// fakeToken answers contract calls by selector; missing selectors revert.
+type fakeToken map[string][]byte
+
+func (f fakeToken) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	result, ok := f[string(msg.Data)]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	return result, nil
+}
+
+// abiWord returns v as a 32-byte ABI word.
+func abiWord(v int64) []byte {
+	return common.LeftPadBytes(big.NewInt(v).Bytes(), 32)
+}
+
+// abiString returns the ABI encoding of a string at offset: its length and padded bytes.
+func abiString(s string) []byte {
+	padded := make([]byte, (len(s)+31)/32*32)
+	copy(padded, s)
+	return append(abiWord(int64(len(s))), padded...)
+}
+
+// encodeString returns the ABI encoding of a single string return value.
+func encodeString(s string) []byte {
+	return append(abiWord(32), abiString(s)...)
+}
+
+// encodeDomain returns the ABI encoding of an eip712Domain() result with name, version
+// and chainID, without extensions.
+func encodeDomain(name, version string, chainID int64) []byte {
+	fields := make([]byte, 32)
+	fields[0] = 0x0f // name, version, chainId, verifyingContract
+
+	nameData := abiString(name)
+	versionData := abiString(version)
+	head := int64(7 * 32)
+
+	var data []byte
+	data = append(data, fields...)
+	data = append(data, abiWord(head)...)
+	data = append(data, abiWord(head+int64(len(nameData)))...)
+	data = append(data, abiWord(chainID)...)
+	data = append(data, make([]byte, 32)...) // verifyingContract
+	data = append(data, make([]byte, 32)...) // salt
+	data = append(data, abiWord(head+int64(len(nameData)+len(versionData)))...)
+	data = append(data, nameData...)
+	data = append(data, versionData...)
+	data = append(data, abiWord(0)...) // empty extensions
+	return data
+}
+
+func TestDiscoverToken(t *testing.T) {
+	address := common.HexToAddress("0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb")
+
+	bytes32Symbol := make([]byte, 32)
+	copy(bytes32Symbol, "MKR")
+
+	tests := []struct {
+		name        string
+		token       fakeToken
+		wantSymbol  string
+		wantDec     int
+		wantName    string
+		wantVersion string
+		wantChainID int64
+		wantErr     bool
+	}{
+		{
+			name: "eip712Domain",
+			token: fakeToken{
+				string(symbolSelector):       encodeString("DAI"),
+				string(decimalsSelector):     abiWord(18),
+				string(eip712DomainSelector): encodeDomain("Dai Stablecoin", "2", 8453),
+			},
+			wantSymbol: "DAI", wantDec: 18, wantName: "Dai Stablecoin", wantVersion: "2", wantChainID: 8453,
+		},
+		{
+			name: "name and version fallback",
+			token: fakeToken{
+				string(symbolSelector):   encodeString("USDC"),
+				string(decimalsSelector): abiWord(6),
+				string(nameSelector):     encodeString("USD Coin"),
+				string(versionSelector):  encodeString("2"),
+			},
+			wantSymbol: "USDC", wantDec: 6, wantName: "USD Coin", wantVersion: "2",
+		},
+		{
+			name: "default version and bytes32 symbol",
+			token: fakeToken{
+				string(symbolSelector):   bytes32Symbol,
+				string(decimalsSelector): abiWord(18),
+				string(nameSelector):     encodeString("Maker"),
+			},
+			wantSymbol: "MKR", wantDec: 18, wantName: "Maker", wantVersion: "1",
+		},
+		{
+			name: "not a token",
+			token: fakeToken{
+				string(symbolSelector): encodeString("X"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "truncated string",
+			token: fakeToken{
+				string(symbolSelector):   encodeString("USDC")[:40],
+				string(decimalsSelector): abiWord(6),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, domain, err := discoverToken(context.Background(), tt.token, address)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("discoverToken() = %+v, want error", token)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("discoverToken() error = %v", err)
+			}
+			if token.Address != address.Hex() || token.Symbol != tt.wantSymbol || token.Decimals != tt.wantDec {
+				t.Errorf("token = %+v, want %s with %d decimals", token, tt.wantSymbol, tt.wantDec)
+			}
+			if domain.name != tt.wantName || domain.version != tt.wantVersion {
+				t.Errorf("domain = %q/%q, want %q/%q", domain.name, domain.version, tt.wantName, tt.wantVersion)
+			}
+			if tt.wantChainID == 0 && domain.chainID != nil {
+				t.Errorf("domain.chainID = %v, want nil", domain.chainID)
+			}
+			if tt.wantChainID != 0 && (domain.chainID == nil || domain.chainID.Int64() != tt.wantChainID) {
+				t.Errorf("domain.chainID = %v, want %d", domain.chainID, tt.wantChainID)
+			}
+		})
+	}
+}
+
+func TestSign_DiscoveredDomain(t *testing.T) {
+	asset := "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb"
+	withDomain := func(domain tokenDomain) SignerOption {
+		return func(s *Signer) error {
+			s.tokens = append(s.tokens, x402.TokenConfig{Address: asset, Symbol: "DAI", Decimals: 18})
+			s.domains = map[string]tokenDomain{"0x50c5725949a6f0c72e6c4a641f24049a917db0cb": domain}
+			return nil
+		}
+	}
+
+	signer, err := NewSigner(
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		withDomain(tokenDomain{name: "Dai Stablecoin", version: "2", chainID: big.NewInt(8453)}),
+	)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+
+	// The requirement carries no EIP-3009 domain, so the discovered one is used
+	payload, err := signer.Sign(&x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "1000",
+		Asset:             asset,
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if payload.Network != "base" {
+		t.Errorf("payload.Network = %s, want base", payload.Network)
+	}
+
+	_, err = NewSigner(
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		withDomain(tokenDomain{name: "Dai Stablecoin", version: "2", chainID: big.NewInt(1)}),
+	)
+	if !errors.Is(err, x402.ErrInvalidToken) {
+		t.Errorf("NewSigner() with a token on another chain error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestWithAutoToken_InvalidAddress(t *testing.T) {
+	_, err := NewSigner(
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		WithAutoToken("not-an-address", "http://127.0.0.1:0"),
+	)
+	if !errors.Is(err, x402.ErrInvalidToken) {
+		t.Errorf("NewSigner() error = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,63 @@
+package evmlite
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestTypeHashes(t *testing.T) {
+	// The type hashes declared by USDC's FiatToken contract
+	tests := []struct {
+		name string
+		hash []byte
+		want string
+	}{
+		{name: "transfer", hash: transferTypeHash, want: "7c7c6cdb67a18743f49ec6fa9b35f50d52ed05cbed4cc592e13b44501c1a2267"},
+		{name: "receive", hash: receiveTypeHash, want: "d099cc98ef71107a616c4f0f941f04c322d8e254fe26b3c6668db87aae413de8"},
+	}
+
+	for _, tt := range tests {
+		if got := hex.EncodeToString(tt.hash); got != tt.want {
+			t.Errorf("%s type hash = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDomainSeparator(t *testing.T) {
+	// The domain of the EIP-712 specification's example
+	token, err := addressWord("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	if err != nil {
+		t.Fatalf("addressWord: %v", err)
+	}
+	got := hex.EncodeToString(domainSeparator("Ether Mail", "1", big.NewInt(1), token))
+	if want := "f2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; got != want {
+		t.Errorf("domainSeparator() = %s, want %s", got, want)
+	}
+}
+
+func TestHashAuthorization(t *testing.T) {
+	auth := &authorization{
+		from:        "0x857b06519E91e3A54538791bDbb0E22373e36b66",
+		to:          "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		value:       big.NewInt(10000),
+		validAfter:  big.NewInt(1740672089),
+		validBefore: big.NewInt(1740672154),
+	}
+	const usdc = "0x036CbD53842c5426634e7929541eC2318f3dCF7e"
+
+	transfer, err := hashAuthorization(usdc, big.NewInt(84532), auth, "USDC", "2", false)
+	if err != nil {
+		t.Fatalf("hashAuthorization() error = %v", err)
+	}
+	receive, _ := hashAuthorization(usdc, big.NewInt(84532), auth, "USDC", "2", true)
+	otherChain, _ := hashAuthorization(usdc, big.NewInt(8453), auth, "USDC", "2", false)
+	if string(transfer) == string(receive) || string(transfer) == string(otherChain) {
+		t.Error("authorizations of different types or chains hash alike")
+	}
+
+	auth.to = "0xnope"
+	if _, err := hashAuthorization(usdc, big.NewInt(84532), auth, "USDC", "2", false); err == nil {
+		t.Error("hashAuthorization() accepted an invalid address")
+	}
+}
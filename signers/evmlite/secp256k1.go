@@ -0,0 +1,35 @@
+package evmlite
+
+import (
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// parsePrivateKey parses a 32-byte secp256k1 private key, rejecting zero and keys not
+// below the curve order.
+func parsePrivateKey(b []byte) (*secp256k1.PrivateKey, bool) {
+	if len(b) != 32 {
+		return nil, false
+	}
+	var d secp256k1.ModNScalar
+	if overflow := d.SetByteSlice(b); overflow || d.IsZero() {
+		return nil, false
+	}
+	return secp256k1.NewPrivateKey(&d), true
+}
+
+// publicKeyBytes returns the 64-byte uncompressed public key of key, x || y, which
+// Ethereum addresses are derived from.
+func publicKeyBytes(key *secp256k1.PrivateKey) []byte {
+	return key.PubKey().SerializeUncompressed()[1:]
+}
+
+// sign signs a 32-byte digest with key, returning the 65-byte signature r || s || v
+// Ethereum expects: s is in the lower half of the curve order and v is the recovery ID
+// plus 27. The nonce is derived from the key and the digest per RFC 6979, so signatures
+// are deterministic, and identical to those of go-ethereum.
+func sign(key *secp256k1.PrivateKey, digest []byte) []byte {
+	// SignCompact returns v || r || s with v = 27 + recovery ID for uncompressed keys
+	compact := ecdsa.SignCompact(key, digest, false)
+	return append(compact[1:], compact[0])
+}
@@ -0,0 +1,61 @@
+package evmlite
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+func TestSign(t *testing.T) {
+	// The signature of the EIP-712 specification's example mail, by keccak256("cow")
+	key, ok := parsePrivateKey(keccak256([]byte("cow")))
+	if !ok {
+		t.Fatal("parsePrivateKey() rejected the key")
+	}
+	digest, _ := hex.DecodeString("be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2")
+	want := "4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c"
+
+	if got := hex.EncodeToString(sign(key, digest)); got != want {
+		t.Errorf("sign() = %s, want %s", got, want)
+	}
+}
+
+func TestSign_LowS(t *testing.T) {
+	key, _ := parsePrivateKey(big.NewInt(42).FillBytes(make([]byte, 32)))
+	halfN := new(big.Int).Rsh(secp256k1.S256().N, 1)
+	for i := 0; i < 16; i++ {
+		signature := sign(key, keccak256([]byte{byte(i)}))
+		if s := new(big.Int).SetBytes(signature[32:64]); s.Cmp(halfN) > 0 {
+			t.Errorf("digest %d: s = %x is in the upper half of the curve order", i, s)
+		}
+		if v := signature[64]; v != 27 && v != 28 {
+			t.Errorf("digest %d: v = %d, want 27 or 28", i, v)
+		}
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	n := secp256k1.S256().N
+	tests := []struct {
+		name string
+		key  []byte
+		want bool
+	}{
+		{name: "one", key: big.NewInt(1).FillBytes(make([]byte, 32)), want: true},
+		{name: "n - 1", key: new(big.Int).Sub(n, big.NewInt(1)).FillBytes(make([]byte, 32)), want: true},
+		{name: "zero", key: make([]byte, 32), want: false},
+		{name: "n", key: n.FillBytes(make([]byte, 32)), want: false},
+		{name: "short", key: make([]byte, 31), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := parsePrivateKey(tt.key); ok != tt.want {
+				t.Errorf("parsePrivateKey() ok = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
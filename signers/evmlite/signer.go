@@ -0,0 +1,276 @@
+// Package evmlite signs x402 "exact" payments on EVM chains with EIP-3009
+// authorizations, like signers/evm, but without go-ethereum: secp256k1 signing uses the
+// pure-Go, constant-time github.com/decred/dcrd/dcrec/secp256k1/v4 and hashing uses
+// Keccak-256 from golang.org/x/crypto.
+//
+// It is meant for binary-size-sensitive clients such as routers and sensors that pay
+// for per-request data APIs. Build them with the x402lite tag, which also keeps
+// solana-go out of the http package:
+//
+//	go build -tags x402lite ./cmd/sensor
+//
+// The package uses no reflection and no cgo, so it suits TinyGo builds for WASM
+// plugin hosts and microcontrollers; TinyGo builds leave out solana-go without the tag.
+package evmlite
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/mark3labs/x402-go"
+)
+
+// DefaultValidityBuffer is how long before signing EIP-3009 authorizations become valid,
+// to allow for clock drift between the payer and the verifier.
+const DefaultValidityBuffer = 10 * time.Second
+
+// Signer implements x402.Signer and x402.BoundSigner for EVM-compatible chains.
+// Signer is safe for concurrent use; its configuration is immutable after NewSigner.
+type Signer struct {
+	privateKey     *secp256k1.PrivateKey
+	address        string
+	network        string
+	chainID        *big.Int
+	tokens         []x402.TokenConfig
+	priority       int
+	maxAmount      *big.Int
+	validityBuffer time.Duration
+}
+
+// SignerOption configures a Signer.
+type SignerOption func(*Signer) error
+
+// NewSigner creates a new EVM signer with the given options.
+func NewSigner(opts ...SignerOption) (*Signer, error) {
+	s := &Signer{validityBuffer: DefaultValidityBuffer}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.privateKey == nil {
+		return nil, x402.ErrInvalidKey
+	}
+	if s.network == "" {
+		return nil, x402.ErrInvalidNetwork
+	}
+	if len(s.tokens) == 0 {
+		return nil, x402.ErrNoTokens
+	}
+	chainID, err := getChainID(s.network)
+	if err != nil {
+		return nil, err
+	}
+	s.chainID = chainID
+
+	s.address = x402.ChecksumEVMAddress("0x" + hex.EncodeToString(keccak256(publicKeyBytes(s.privateKey))[12:]))
+
+	return s, nil
+}
+
+// WithPrivateKey sets the private key from a hex string, with or without 0x prefix.
+func WithPrivateKey(hexKey string) SignerOption {
+	return func(s *Signer) error {
+		b, err := hex.DecodeString(strings.TrimPrefix(hexKey, "0x"))
+		if err != nil {
+			return x402.ErrInvalidKey
+		}
+		key, ok := parsePrivateKey(b)
+		if !ok {
+			return x402.ErrInvalidKey
+		}
+		s.privateKey = key
+		return nil
+	}
+}
+
+// WithNetwork sets the blockchain network.
+func WithNetwork(network string) SignerOption {
+	return func(s *Signer) error {
+		s.network = network
+		return nil
+	}
+}
+
+// WithToken adds a token configuration.
+func WithToken(address, symbol string, decimals int) SignerOption {
+	return WithTokenPriority(address, symbol, decimals, 0)
+}
+
+// WithTokenPriority adds a token configuration with a priority.
+func WithTokenPriority(address, symbol string, decimals, priority int) SignerOption {
+	return func(s *Signer) error {
+		s.tokens = append(s.tokens, x402.TokenConfig{
+			Address:  address,
+			Symbol:   symbol,
+			Decimals: decimals,
+			Priority: priority,
+		})
+		return nil
+	}
+}
+
+// WithPriority sets the signer priority.
+func WithPriority(priority int) SignerOption {
+	return func(s *Signer) error {
+		s.priority = priority
+		return nil
+	}
+}
+
+// WithMaxAmountPerCall sets the maximum amount per payment call.
+func WithMaxAmountPerCall(amount string) SignerOption {
+	return func(s *Signer) error {
+		maxAmount, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return x402.ErrInvalidAmount
+		}
+		s.maxAmount = maxAmount
+		return nil
+	}
+}
+
+// WithValidityBuffer sets how long before signing EIP-3009 authorizations become valid
+// (default DefaultValidityBuffer). Devices with unreliable clocks may need a larger
+// buffer.
+func WithValidityBuffer(d time.Duration) SignerOption {
+	return func(s *Signer) error {
+		if d < 0 {
+			return fmt.Errorf("evmlite: validity buffer must not be negative, got %v", d)
+		}
+		s.validityBuffer = d
+		return nil
+	}
+}
+
+// Network implements x402.Signer.
+func (s *Signer) Network() string {
+	return s.network
+}
+
+// Scheme implements x402.Signer.
+func (s *Signer) Scheme() string {
+	return "exact"
+}
+
+// CanSign implements x402.Signer. Only the "exact" scheme is supported.
+func (s *Signer) CanSign(requirements *x402.PaymentRequirement) bool {
+	if requirements.Network != s.network || requirements.Scheme != "exact" {
+		return false
+	}
+	for _, token := range s.tokens {
+		if strings.EqualFold(token.Address, requirements.Asset) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign implements x402.Signer.
+func (s *Signer) Sign(requirements *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	var nonce [32]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return s.sign(requirements, nonce)
+}
+
+// SignBound implements x402.BoundSigner.
+func (s *Signer) SignBound(requirements *x402.PaymentRequirement, nonce [32]byte) (*x402.PaymentPayload, error) {
+	return s.sign(requirements, nonce)
+}
+
+// sign implements Sign and SignBound with the given EIP-3009 nonce.
+func (s *Signer) sign(requirements *x402.PaymentRequirement, nonce [32]byte) (*x402.PaymentPayload, error) {
+	if !s.CanSign(requirements) {
+		return nil, x402.ErrNoValidSigner
+	}
+
+	amount, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok || amount.Sign() < 0 || amount.BitLen() > 256 {
+		return nil, x402.ErrInvalidAmount
+	}
+	if s.maxAmount != nil && amount.Cmp(s.maxAmount) > 0 {
+		return nil, x402.ErrAmountExceeded
+	}
+
+	name, _ := requirements.Extra["name"].(string)
+	version, _ := requirements.Extra["version"].(string)
+	if name == "" || version == "" {
+		return nil, fmt.Errorf("missing EIP-3009 parameters: name and version are required in Extra")
+	}
+
+	now := time.Now()
+	auth := &authorization{
+		from:        s.address,
+		to:          requirements.PayTo,
+		value:       amount,
+		validAfter:  big.NewInt(now.Add(-s.validityBuffer).Unix()),
+		validBefore: big.NewInt(now.Unix() + int64(requirements.MaxTimeoutSeconds)),
+		nonce:       nonce,
+	}
+	digest, err := hashAuthorization(requirements.Asset, s.chainID, auth, name, version, requirements.ReceiveWithAuthorization())
+	if err != nil {
+		return nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to hash authorization", err)
+	}
+	signature := sign(s.privateKey, digest)
+
+	return &x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     s.network,
+		Payload: x402.EVMPayload{
+			Signature: "0x" + hex.EncodeToString(signature),
+			Authorization: x402.EVMAuthorization{
+				From:        auth.from,
+				To:          x402.ChecksumEVMAddress(auth.to),
+				Value:       auth.value.String(),
+				ValidAfter:  auth.validAfter.String(),
+				ValidBefore: auth.validBefore.String(),
+				Nonce:       "0x" + hex.EncodeToString(nonce[:]),
+			},
+		},
+	}, nil
+}
+
+// GetPriority implements x402.Signer.
+func (s *Signer) GetPriority() int {
+	return s.priority
+}
+
+// GetTokens implements x402.Signer.
+func (s *Signer) GetTokens() []x402.TokenConfig {
+	return s.tokens
+}
+
+// GetMaxAmount implements x402.Signer.
+func (s *Signer) GetMaxAmount() *big.Int {
+	return s.maxAmount
+}
+
+// Address returns the signer's EIP-55 checksummed Ethereum address.
+func (s *Signer) Address() string {
+	return s.address
+}
+
+// getChainID returns the chain ID for the given network.
+func getChainID(network string) (*big.Int, error) {
+	switch network {
+	case "base":
+		return big.NewInt(8453), nil
+	case "base-sepolia":
+		return big.NewInt(84532), nil
+	case "ethereum":
+		return big.NewInt(1), nil
+	case "sepolia":
+		return big.NewInt(11155111), nil
+	default:
+		return nil, x402.ErrInvalidNetwork
+	}
+}
@@ -0,0 +1,212 @@
+package evmlite
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// Test private key (DO NOT use in production)
+const testPrivateKeyHex = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+const testUSDC = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+
+func newTestSigner(t *testing.T, opts ...SignerOption) *Signer {
+	t.Helper()
+	signer, err := NewSigner(append([]SignerOption{
+		WithPrivateKey(testPrivateKeyHex),
+		WithNetwork("base"),
+		WithToken(testUSDC, "USDC", 6),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	return signer
+}
+
+func testRequirement() *x402.PaymentRequirement {
+	requirement := &x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		MaxAmountRequired: "10000",
+		Asset:             testUSDC,
+		PayTo:             "0x209693bc6afc0c5328ba36faf03c514ef312287c",
+		MaxTimeoutSeconds: 60,
+	}
+	requirement.SetEIP3009Domain("USD Coin", "2")
+	return requirement
+}
+
+func TestNewSigner(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []SignerOption
+		wantErr error
+	}{
+		{
+			name: "valid signer",
+			opts: []SignerOption{WithPrivateKey(testPrivateKeyHex), WithNetwork("base"), WithToken(testUSDC, "USDC", 6)},
+		},
+		{
+			name: "valid signer with 0x prefix",
+			opts: []SignerOption{WithPrivateKey("0x" + testPrivateKeyHex), WithNetwork("base-sepolia"), WithToken(testUSDC, "USDC", 6)},
+		},
+		{
+			name:    "missing private key",
+			opts:    []SignerOption{WithNetwork("base"), WithToken(testUSDC, "USDC", 6)},
+			wantErr: x402.ErrInvalidKey,
+		},
+		{
+			name:    "short private key",
+			opts:    []SignerOption{WithPrivateKey("0x1234"), WithNetwork("base"), WithToken(testUSDC, "USDC", 6)},
+			wantErr: x402.ErrInvalidKey,
+		},
+		{
+			name:    "zero private key",
+			opts:    []SignerOption{WithPrivateKey(strings.Repeat("0", 64)), WithNetwork("base"), WithToken(testUSDC, "USDC", 6)},
+			wantErr: x402.ErrInvalidKey,
+		},
+		{
+			name:    "private key beyond the curve order",
+			opts:    []SignerOption{WithPrivateKey(strings.Repeat("f", 64)), WithNetwork("base"), WithToken(testUSDC, "USDC", 6)},
+			wantErr: x402.ErrInvalidKey,
+		},
+		{
+			name:    "unknown network",
+			opts:    []SignerOption{WithPrivateKey(testPrivateKeyHex), WithNetwork("solana"), WithToken(testUSDC, "USDC", 6)},
+			wantErr: x402.ErrInvalidNetwork,
+		},
+		{
+			name:    "missing tokens",
+			opts:    []SignerOption{WithPrivateKey(testPrivateKeyHex), WithNetwork("base")},
+			wantErr: x402.ErrNoTokens,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSigner(tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewSigner() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSigner_Address(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: testPrivateKeyHex, want: "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{key: strings.Repeat("0", 63) + "1", want: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+	}
+
+	for _, tt := range tests {
+		signer, err := NewSigner(WithPrivateKey(tt.key), WithNetwork("base"), WithToken(testUSDC, "USDC", 6))
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		if got := signer.Address(); got != tt.want {
+			t.Errorf("Address() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestSigner_CanSign(t *testing.T) {
+	signer := newTestSigner(t)
+
+	tests := []struct {
+		name   string
+		modify func(*x402.PaymentRequirement)
+		want   bool
+	}{
+		{name: "matching", modify: func(*x402.PaymentRequirement) {}, want: true},
+		{name: "lower case asset", modify: func(r *x402.PaymentRequirement) { r.Asset = strings.ToLower(r.Asset) }, want: true},
+		{name: "other network", modify: func(r *x402.PaymentRequirement) { r.Network = "base-sepolia" }},
+		{name: "permit scheme", modify: func(r *x402.PaymentRequirement) { r.Scheme = x402.SchemePermit }},
+		{name: "other asset", modify: func(r *x402.PaymentRequirement) { r.Asset = "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requirement := testRequirement()
+			tt.modify(requirement)
+			if got := signer.CanSign(requirement); got != tt.want {
+				t.Errorf("CanSign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigner_Sign(t *testing.T) {
+	signer := newTestSigner(t, WithMaxAmountPerCall("50000"))
+
+	payment, err := signer.Sign(testRequirement())
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	payload := payment.Payload.(x402.EVMPayload)
+	if payment.Scheme != "exact" || payment.Network != "base" {
+		t.Errorf("payment is for %s on %s, want exact on base", payment.Scheme, payment.Network)
+	}
+	if payload.Authorization.From != signer.Address() {
+		t.Errorf("From = %s, want %s", payload.Authorization.From, signer.Address())
+	}
+	if payload.Authorization.To != "0x209693Bc6afc0C5328bA36FaF03C514EF312287C" {
+		t.Errorf("To = %s, want the checksummed payTo", payload.Authorization.To)
+	}
+	if payload.Authorization.Value != "10000" {
+		t.Errorf("Value = %s, want 10000", payload.Authorization.Value)
+	}
+	if len(payload.Signature) != 132 || len(payload.Authorization.Nonce) != 66 {
+		t.Errorf("signature %s and nonce %s have the wrong length", payload.Signature, payload.Authorization.Nonce)
+	}
+
+	again, _ := signer.Sign(testRequirement())
+	if again.Payload.(x402.EVMPayload).Authorization.Nonce == payload.Authorization.Nonce {
+		t.Error("two payments share a nonce")
+	}
+
+	receive := testRequirement()
+	receive.SetReceiveWithAuthorization()
+	var nonce [32]byte
+	bound, _ := signer.SignBound(testRequirement(), nonce)
+	boundReceive, _ := signer.SignBound(receive, nonce)
+	if bound.Payload.(x402.EVMPayload).Signature == boundReceive.Payload.(x402.EVMPayload).Signature {
+		t.Error("receiveWithAuthorization signed like transferWithAuthorization")
+	}
+}
+
+func TestSigner_SignErrors(t *testing.T) {
+	signer := newTestSigner(t, WithMaxAmountPerCall("50000"))
+
+	tests := []struct {
+		name    string
+		modify  func(*x402.PaymentRequirement)
+		wantErr error
+	}{
+		{name: "other network", modify: func(r *x402.PaymentRequirement) { r.Network = "ethereum" }, wantErr: x402.ErrNoValidSigner},
+		{name: "invalid amount", modify: func(r *x402.PaymentRequirement) { r.MaxAmountRequired = "ten" }, wantErr: x402.ErrInvalidAmount},
+		{name: "negative amount", modify: func(r *x402.PaymentRequirement) { r.MaxAmountRequired = "-1" }, wantErr: x402.ErrInvalidAmount},
+		{name: "amount above limit", modify: func(r *x402.PaymentRequirement) { r.MaxAmountRequired = "50001" }, wantErr: x402.ErrAmountExceeded},
+		{name: "missing domain", modify: func(r *x402.PaymentRequirement) { r.Extra = nil }},
+		{name: "invalid payTo", modify: func(r *x402.PaymentRequirement) { r.PayTo = "0x1234" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requirement := testRequirement()
+			tt.modify(requirement)
+			_, err := signer.Sign(requirement)
+			if err == nil {
+				t.Fatal("Sign() succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Sign() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
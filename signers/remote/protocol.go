@@ -0,0 +1,49 @@
+// Package remote implements x402.Signer against a remote signing service, so private
+// keys live on one hardened host while many clients pay through it.
+//
+// The service side is Server, an http.Handler wrapping any local x402.Signer; the
+// cmd/x402-signerd daemon serves it over mutual TLS. Callers are identified by the
+// common name of their client certificate, and each caller is subject to a Policy.
+//
+// The client side is Signer:
+//
+//	signer, err := remote.NewSigner("https://signer.internal:8443",
+//		remote.WithClientCertificate("client.pem", "client-key.pem", "ca.pem"))
+//	client, err := x402http.NewClient(x402http.WithSigner(signer))
+package remote
+
+import "github.com/mark3labs/x402-go"
+
+// Endpoint paths served by Server.
+const (
+	// InfoPath returns the wrapped signer's Info.
+	InfoPath = "/info"
+
+	// SignPath signs a SignRequest.
+	SignPath = "/sign"
+)
+
+// Info describes the signer behind a Server.
+type Info struct {
+	Network   string             `json:"network"`
+	Scheme    string             `json:"scheme"`
+	Address   string             `json:"address,omitempty"`
+	Priority  int                `json:"priority"`
+	Tokens    []x402.TokenConfig `json:"tokens"`
+	MaxAmount string             `json:"maxAmount,omitempty"`
+}
+
+// SignRequest asks the server to sign a payment.
+type SignRequest struct {
+	Requirements x402.PaymentRequirement `json:"requirements"`
+}
+
+// SignResponse carries the signed payment.
+type SignResponse struct {
+	Payment x402.PaymentPayload `json:"payment"`
+}
+
+// errorResponse is the body of every non-200 response.
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,246 @@
+package remote
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+const testAsset = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+
+// localSigner is the key-holding signer wrapped by the server.
+type localSigner struct{}
+
+func (localSigner) Network() string { return "base" }
+func (localSigner) Scheme() string  { return "exact" }
+func (localSigner) CanSign(req *x402.PaymentRequirement) bool {
+	return req.Network == "base" && req.Asset == testAsset
+}
+func (localSigner) GetPriority() int { return 3 }
+func (localSigner) GetTokens() []x402.TokenConfig {
+	return []x402.TokenConfig{{Address: testAsset, Symbol: "USDC", Decimals: 6}}
+}
+func (localSigner) GetMaxAmount() *big.Int { return big.NewInt(5000000) }
+func (localSigner) Sign(req *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	return &x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      req.Scheme,
+		Network:     req.Network,
+		Payload:     map[string]any{"signature": "0xsigned"},
+	}, nil
+}
+
+func testRequirement(amount, payTo string) *x402.PaymentRequirement {
+	return &x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             testAsset,
+		PayTo:             payTo,
+		MaxAmountRequired: amount,
+		MaxTimeoutSeconds: 60,
+	}
+}
+
+// testPKI issues certificates from a throwaway CA.
+type testPKI struct {
+	t    *testing.T
+	ca   *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &testPKI{t: t, ca: ca, key: key, pool: pool}
+}
+
+func (p *testPKI) issue(commonName string, usage x509.ExtKeyUsage) tls.Certificate {
+	p.t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		p.t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.key)
+	if err != nil {
+		p.t.Fatalf("failed to issue certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (p *testPKI) clientTLS(commonName string) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{p.issue(commonName, x509.ExtKeyUsageClientAuth)},
+		RootCAs:      p.pool,
+	}
+}
+
+func newMTLSServer(t *testing.T, pki *testPKI, config ServerConfig) *httptest.Server {
+	t.Helper()
+	handler, err := NewServer(config)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.issue("signerd", x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    pki.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSigner_MutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	server := newMTLSServer(t, pki, ServerConfig{
+		Signer:  localSigner{},
+		Address: "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		Policies: map[string]Policy{
+			"agent-1": {MaxAmountPerCall: "10000", AllowedPayTo: []string{"0xAAAA000000000000000000000000000000000001"}},
+		},
+	})
+
+	signer, err := NewSigner(server.URL, WithTLSConfig(pki.clientTLS("agent-1")))
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	if signer.Network() != "base" || signer.GetPriority() != 3 || signer.GetMaxAmount().Int64() != 5000000 {
+		t.Errorf("unexpected remote signer info: %+v", signer.info)
+	}
+	if signer.Address() != "0x209693Bc6afc0C5328bA36FaF03C514EF312287C" {
+		t.Errorf("unexpected address %s", signer.Address())
+	}
+
+	tests := []struct {
+		name        string
+		requirement *x402.PaymentRequirement
+		wantErr     error
+	}{
+		{"allowed", testRequirement("10000", "0xaaaa000000000000000000000000000000000001"), nil},
+		{"over policy amount", testRequirement("10001", "0xAAAA000000000000000000000000000000000001"), ErrPolicyViolation},
+		{"recipient not allowed", testRequirement("10000", "0xBBBB000000000000000000000000000000000002"), ErrPolicyViolation},
+		{"over signer limit", testRequirement("6000000", "0xAAAA000000000000000000000000000000000001"), x402.ErrAmountExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !signer.CanSign(tt.requirement) {
+				t.Fatal("expected CanSign to accept base USDC")
+			}
+			payment, err := signer.Sign(tt.requirement)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Sign error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			if payment.Payload.(map[string]any)["signature"] != "0xsigned" {
+				t.Errorf("unexpected payload %v", payment.Payload)
+			}
+		})
+	}
+}
+
+func TestSigner_UnknownCallerRejected(t *testing.T) {
+	pki := newTestPKI(t)
+	server := newMTLSServer(t, pki, ServerConfig{
+		Signer:   localSigner{},
+		Policies: map[string]Policy{"agent-1": {}},
+	})
+
+	if _, err := NewSigner(server.URL, WithTLSConfig(pki.clientTLS("intruder"))); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("expected ErrPolicyViolation for unknown caller, got %v", err)
+	}
+
+	// Clients without a certificate fail the TLS handshake
+	if _, err := NewSigner(server.URL, WithTLSConfig(&tls.Config{RootCAs: pki.pool})); err == nil {
+		t.Error("expected error without client certificate")
+	}
+}
+
+func TestServer_DefaultPolicy(t *testing.T) {
+	handler, err := NewServer(ServerConfig{Signer: localSigner{}, DefaultPolicy: &Policy{MaxAmountPerCall: "100"}})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	signer, err := NewSigner(server.URL, WithHTTPClient(http.DefaultClient), WithPriority(1))
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	if signer.GetPriority() != 1 {
+		t.Errorf("expected overridden priority 1, got %d", signer.GetPriority())
+	}
+	if _, err := signer.Sign(testRequirement("100", "0xAAAA000000000000000000000000000000000001")); err != nil {
+		t.Errorf("Sign failed: %v", err)
+	}
+	if _, err := signer.Sign(testRequirement("101", "0xAAAA000000000000000000000000000000000001")); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("expected ErrPolicyViolation, got %v", err)
+	}
+}
+
+func TestNewServer_InvalidPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ServerConfig
+	}{
+		{"no signer", ServerConfig{}},
+		{"bad amount", ServerConfig{Signer: localSigner{}, Policies: map[string]Policy{"a": {MaxAmountPerCall: "abc"}}}},
+		{"bad default amount", ServerConfig{Signer: localSigner{}, DefaultPolicy: &Policy{MaxAmountPerCall: "-5"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewServer(tt.config); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
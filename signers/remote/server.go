@@ -0,0 +1,248 @@
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// ErrPolicyViolation indicates a sign request is not allowed by the caller's policy.
+var ErrPolicyViolation = errors.New("x402: remote signer policy violation")
+
+// maxSignRequestBytes bounds the size of a sign request body.
+const maxSignRequestBytes = 64 << 10
+
+// Policy restricts what a caller may sign.
+type Policy struct {
+	// MaxAmountPerCall caps the amount of a single payment, in atomic units (optional).
+	// The wrapped signer's own per-call limit always applies as well.
+	MaxAmountPerCall string `json:"maxAmountPerCall,omitempty"`
+
+	// AllowedPayTo restricts the recipients the caller may pay (optional, case-insensitive).
+	AllowedPayTo []string `json:"allowedPayTo,omitempty"`
+
+	maxAmount *big.Int
+}
+
+// Check returns ErrPolicyViolation if the policy does not allow paying requirements.
+func (p *Policy) Check(requirements *x402.PaymentRequirement) error {
+	if p.maxAmount != nil {
+		amount, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+		if !ok {
+			return fmt.Errorf("%w: %s", x402.ErrInvalidAmount, requirements.MaxAmountRequired)
+		}
+		if amount.Cmp(p.maxAmount) > 0 {
+			return fmt.Errorf("%w: amount %s exceeds %s", ErrPolicyViolation, amount, p.maxAmount)
+		}
+	}
+
+	if len(p.AllowedPayTo) > 0 {
+		for _, payTo := range p.AllowedPayTo {
+			if strings.EqualFold(payTo, requirements.PayTo) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: recipient %s not allowed", ErrPolicyViolation, requirements.PayTo)
+	}
+	return nil
+}
+
+// compile parses the policy's amount.
+func (p *Policy) compile() error {
+	if p.MaxAmountPerCall == "" {
+		return nil
+	}
+	amount, ok := new(big.Int).SetString(p.MaxAmountPerCall, 10)
+	if !ok || amount.Sign() <= 0 {
+		return fmt.Errorf("%w: max amount per call %q", x402.ErrInvalidAmount, p.MaxAmountPerCall)
+	}
+	p.maxAmount = amount
+	return nil
+}
+
+// ServerConfig configures a Server.
+type ServerConfig struct {
+	// Signer is the local signer that holds the key (required).
+	Signer x402.Signer
+
+	// Address is the signer's address, reported to clients (optional).
+	Address string
+
+	// Policies maps caller names (client certificate common names) to their policy.
+	Policies map[string]Policy
+
+	// DefaultPolicy applies to callers without an entry in Policies. If nil, such
+	// callers are rejected.
+	DefaultPolicy *Policy
+}
+
+// Server is an http.Handler that signs payments on behalf of remote callers.
+// Serve it over mutual TLS (see ServerTLSConfig) so callers are authenticated.
+type Server struct {
+	signer        x402.Signer
+	info          Info
+	policies      map[string]*Policy
+	defaultPolicy *Policy
+	mux           *http.ServeMux
+}
+
+// NewServer creates a Server from config.
+func NewServer(config ServerConfig) (*Server, error) {
+	if config.Signer == nil {
+		return nil, fmt.Errorf("remote: signer is required")
+	}
+
+	s := &Server{
+		signer:   config.Signer,
+		policies: make(map[string]*Policy, len(config.Policies)),
+		info: Info{
+			Network:  config.Signer.Network(),
+			Scheme:   config.Signer.Scheme(),
+			Address:  config.Address,
+			Priority: config.Signer.GetPriority(),
+			Tokens:   config.Signer.GetTokens(),
+		},
+	}
+	if maxAmount := config.Signer.GetMaxAmount(); maxAmount != nil {
+		s.info.MaxAmount = maxAmount.String()
+	}
+
+	for caller, policy := range config.Policies {
+		if err := policy.compile(); err != nil {
+			return nil, fmt.Errorf("policy for %s: %w", caller, err)
+		}
+		s.policies[caller] = &policy
+	}
+	if config.DefaultPolicy != nil {
+		policy := *config.DefaultPolicy
+		if err := policy.compile(); err != nil {
+			return nil, fmt.Errorf("default policy: %w", err)
+		}
+		s.defaultPolicy = &policy
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET "+InfoPath, s.handleInfo)
+	s.mux.HandleFunc("POST "+SignPath, s.handleSign)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.policyFor(r); !ok {
+		writeError(w, http.StatusForbidden, "unknown caller")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.info)
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	logger := slog.Default()
+
+	caller := CallerName(r)
+	policy, ok := s.policyFor(r)
+	if !ok {
+		logger.Warn("sign request from unknown caller", "caller", caller)
+		writeError(w, http.StatusForbidden, "unknown caller")
+		return
+	}
+
+	var req SignRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSignRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid sign request")
+		return
+	}
+
+	if err := policy.Check(&req.Requirements); err != nil {
+		logger.Warn("sign request denied by policy", "caller", caller, "error", err)
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if !s.signer.CanSign(&req.Requirements) {
+		writeError(w, http.StatusUnprocessableEntity, "signer cannot satisfy requirements")
+		return
+	}
+
+	payment, err := s.signer.Sign(&req.Requirements)
+	if err != nil {
+		logger.Error("signing failed", "caller", caller, "error", err)
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	logger.Info("signed payment", "caller", caller, "network", payment.Network,
+		"amount", req.Requirements.MaxAmountRequired, "payTo", req.Requirements.PayTo)
+	writeJSON(w, http.StatusOK, SignResponse{Payment: *payment})
+}
+
+// policyFor returns the policy for the request's caller.
+func (s *Server) policyFor(r *http.Request) (*Policy, bool) {
+	if policy, ok := s.policies[CallerName(r)]; ok {
+		return policy, true
+	}
+	return s.defaultPolicy, s.defaultPolicy != nil
+}
+
+// CallerName returns the common name of the verified client certificate, or "" if the
+// request was not made over mutual TLS.
+func CallerName(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
+
+// ServerTLSConfig returns a TLS configuration that serves certFile/keyFile and requires
+// client certificates signed by the CA in clientCAFile.
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	pool, err := loadCertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// loadCertPool reads PEM certificates from path into a pool.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,208 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// DefaultTimeout bounds each request to the signing service.
+const DefaultTimeout = 10 * time.Second
+
+// Signer implements x402.Signer by asking a remote Server to sign payments.
+// Signer is safe for concurrent use; its configuration is immutable after NewSigner.
+type Signer struct {
+	baseURL    string
+	httpClient *http.Client
+	info       Info
+	maxAmount  *big.Int
+	priority   *int
+}
+
+// SignerOption configures a Signer.
+type SignerOption func(*Signer) error
+
+// NewSigner creates a Signer for the service at baseURL. It fetches the remote signer's
+// network, tokens and limits, so the service must be reachable.
+func NewSigner(baseURL string, opts ...SignerOption) (*Signer, error) {
+	s := &Signer{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	if err := s.do(ctx, http.MethodGet, InfoPath, nil, &s.info); err != nil {
+		return nil, fmt.Errorf("failed to fetch remote signer info: %w", err)
+	}
+	if s.info.Network == "" {
+		return nil, x402.ErrInvalidNetwork
+	}
+	if s.info.MaxAmount != "" {
+		maxAmount, ok := new(big.Int).SetString(s.info.MaxAmount, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: remote max amount %q", x402.ErrInvalidAmount, s.info.MaxAmount)
+		}
+		s.maxAmount = maxAmount
+	}
+	return s, nil
+}
+
+// WithHTTPClient sets the HTTP client used to reach the service.
+func WithHTTPClient(client *http.Client) SignerOption {
+	return func(s *Signer) error {
+		s.httpClient = client
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to reach the service.
+func WithTLSConfig(config *tls.Config) SignerOption {
+	return func(s *Signer) error {
+		s.httpClient = &http.Client{
+			Timeout:   DefaultTimeout,
+			Transport: &http.Transport{TLSClientConfig: config},
+		}
+		return nil
+	}
+}
+
+// WithClientCertificate authenticates to the service with the client certificate in
+// certFile/keyFile and trusts only server certificates signed by the CA in caFile.
+func WithClientCertificate(certFile, keyFile, caFile string) SignerOption {
+	return func(s *Signer) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return err
+		}
+		return WithTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      pool,
+			MinVersion:   tls.VersionTLS12,
+		})(s)
+	}
+}
+
+// WithPriority overrides the priority reported by the remote signer.
+func WithPriority(priority int) SignerOption {
+	return func(s *Signer) error {
+		s.priority = &priority
+		return nil
+	}
+}
+
+// Network implements x402.Signer.
+func (s *Signer) Network() string { return s.info.Network }
+
+// Scheme implements x402.Signer.
+func (s *Signer) Scheme() string { return s.info.Scheme }
+
+// Address returns the remote signer's address, if the service reports one.
+func (s *Signer) Address() string { return s.info.Address }
+
+// CanSign implements x402.Signer using the remote signer's network and tokens.
+// The caller's policy is only checked by the service when signing.
+func (s *Signer) CanSign(requirements *x402.PaymentRequirement) bool {
+	if requirements.Network != s.info.Network || requirements.Scheme != s.info.Scheme {
+		return false
+	}
+	for _, token := range s.info.Tokens {
+		if strings.EqualFold(token.Address, requirements.Asset) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign implements x402.Signer by sending the requirements to the service.
+func (s *Signer) Sign(requirements *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	if s.maxAmount != nil {
+		amount, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+		if !ok {
+			return nil, x402.ErrInvalidAmount
+		}
+		if amount.Cmp(s.maxAmount) > 0 {
+			return nil, x402.ErrAmountExceeded
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	var resp SignResponse
+	if err := s.do(ctx, http.MethodPost, SignPath, SignRequest{Requirements: *requirements}, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %w", x402.ErrSigningFailed, err)
+	}
+	return &resp.Payment, nil
+}
+
+// GetPriority implements x402.Signer.
+func (s *Signer) GetPriority() int {
+	if s.priority != nil {
+		return *s.priority
+	}
+	return s.info.Priority
+}
+
+// GetTokens implements x402.Signer.
+func (s *Signer) GetTokens() []x402.TokenConfig { return s.info.Tokens }
+
+// GetMaxAmount implements x402.Signer.
+func (s *Signer) GetMaxAmount() *big.Int { return s.maxAmount }
+
+// do sends a request to the service and decodes the JSON response into out.
+func (s *Signer) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote signer request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		if resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("%w: %s", ErrPolicyViolation, strings.TrimPrefix(errResp.Error, ErrPolicyViolation.Error()+": "))
+		}
+		return fmt.Errorf("remote signer returned %d: %s", resp.StatusCode, errResp.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
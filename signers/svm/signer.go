@@ -4,25 +4,66 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 
 	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
 	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 	"github.com/mark3labs/x402-go"
 )
 
 // Signer implements the x402.Signer interface for Solana (SVM).
+// Its key can be replaced at runtime with SwapKey or Reload.
+//
+// Signer is safe for concurrent use. Its configuration and RPC client are immutable
+// after NewSigner returns and the key is swapped atomically, so Sign takes no locks.
 type Signer struct {
-	privateKey solana.PrivateKey
-	publicKey  solana.PublicKey
+	key        atomic.Pointer[signingKey]
+	privateKey solana.PrivateKey // key configured by the options, before NewSigner returns
+	loadKey    func() (solana.PrivateKey, error)
 	network    string
 	tokens     []x402.TokenConfig
 	priority   int
 	maxAmount  *big.Int
+	httpClient *http.Client
+	rpcClient  *rpc.Client
+
+	computeUnitLimit uint32
+	priorityFee      uint64 // microlamports per compute unit, or the floor in auto mode
+	maxPriorityFee   uint64 // cap of the automatic priority fee; 0 disables auto mode
+
+	sourceTokenAccount solana.PublicKey // token account paid from; zero for the key's ATA
+
+	memo          func(*x402.PaymentRequirement) string
+	addressTables []solana.PublicKey
+}
+
+const (
+	// DefaultComputeUnitLimit is the compute unit limit of payment transactions by default.
+	DefaultComputeUnitLimit uint32 = 200_000
+
+	// DefaultPriorityFee is the compute unit price of payment transactions by default, in
+	// microlamports per compute unit.
+	DefaultPriorityFee uint64 = 10_000
+
+	// priorityFeePercentile is the percentile of recent prioritization fees paid in auto mode.
+	priorityFeePercentile = 75
+)
+
+// signingKey is a private key with the public key derived from it.
+type signingKey struct {
+	privateKey solana.PrivateKey
+	publicKey  solana.PublicKey
 }
 
 // SignerOption configures a Signer.
@@ -31,7 +72,9 @@ type SignerOption func(*Signer) error
 // NewSigner creates a new Solana signer with the given options.
 func NewSigner(opts ...SignerOption) (*Signer, error) {
 	s := &Signer{
-		priority: 0,
+		priority:         0,
+		computeUnitLimit: DefaultComputeUnitLimit,
+		priorityFee:      DefaultPriorityFee,
 	}
 
 	for _, opt := range opts {
@@ -52,7 +95,17 @@ func NewSigner(opts ...SignerOption) (*Signer, error) {
 	}
 
 	// Derive public key
-	s.publicKey = s.privateKey.PublicKey()
+	s.setKey(s.privateKey)
+
+	// Share one RPC client across Sign calls; unsupported networks fail in Sign
+	if rpcURL, err := getRPCURL(s.network); err == nil {
+		s.rpcClient = rpc.New(rpcURL)
+		if s.httpClient != nil {
+			s.rpcClient = rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(rpcURL, &jsonrpc.RPCClientOpts{
+				HTTPClient: s.httpClient,
+			}))
+		}
+	}
 
 	return s, nil
 }
@@ -65,33 +118,73 @@ func WithPrivateKey(base58Key string) SignerOption {
 			return x402.ErrInvalidKey
 		}
 		s.privateKey = privateKey
+		s.loadKey = nil
 		return nil
 	}
 }
 
-// WithKeygenFile loads a private key from a Solana keygen JSON file.
-func WithKeygenFile(path string) SignerOption {
+// WithPrivateKeyProvider loads the private key from provider, which returns a base58
+// string. Reload calls provider again, so keys kept in a secret manager can be rotated.
+func WithPrivateKeyProvider(provider func() (string, error)) SignerOption {
 	return func(s *Signer) error {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("%w: %v", x402.ErrInvalidKeystore, err)
+		s.loadKey = func() (solana.PrivateKey, error) {
+			base58Key, err := provider()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", x402.ErrInvalidKey, err)
+			}
+			privateKey, err := solana.PrivateKeyFromBase58(base58Key)
+			if err != nil {
+				return nil, x402.ErrInvalidKey
+			}
+			return privateKey, nil
 		}
 
-		// Parse JSON array format: [1, 2, 3, ...]
-		var keyBytes []byte
-		if err := json.Unmarshal(data, &keyBytes); err != nil {
-			return fmt.Errorf("%w: invalid JSON format", x402.ErrInvalidKeystore)
+		privateKey, err := s.loadKey()
+		if err != nil {
+			return err
 		}
+		s.privateKey = privateKey
+		return nil
+	}
+}
 
-		if len(keyBytes) != 64 {
-			return fmt.Errorf("%w: invalid key length", x402.ErrInvalidKeystore)
+// WithKeygenFile loads a private key from a Solana keygen JSON file.
+// Reload reads the file again, so it can be replaced to rotate keys.
+func WithKeygenFile(path string) SignerOption {
+	return func(s *Signer) error {
+		s.loadKey = func() (solana.PrivateKey, error) {
+			return loadKeygenFile(path)
 		}
 
-		s.privateKey = solana.PrivateKey(keyBytes)
+		privateKey, err := s.loadKey()
+		if err != nil {
+			return err
+		}
+		s.privateKey = privateKey
 		return nil
 	}
 }
 
+// loadKeygenFile reads a private key from a Solana keygen JSON file.
+func loadKeygenFile(path string) (solana.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", x402.ErrInvalidKeystore, err)
+	}
+
+	// Parse JSON array format: [1, 2, 3, ...]
+	var keyBytes []byte
+	if err := json.Unmarshal(data, &keyBytes); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON format", x402.ErrInvalidKeystore)
+	}
+
+	if len(keyBytes) != 64 {
+		return nil, fmt.Errorf("%w: invalid key length", x402.ErrInvalidKeystore)
+	}
+
+	return solana.PrivateKey(keyBytes), nil
+}
+
 // WithNetwork sets the blockchain network.
 func WithNetwork(network string) SignerOption {
 	return func(s *Signer) error {
@@ -146,6 +239,110 @@ func WithMaxAmountPerCall(amount string) SignerOption {
 	}
 }
 
+// WithHTTPClient sets the HTTP client used for Solana RPC requests, e.g. one built with
+// facilitator.NewHTTPClient to go through a proxy or present a client certificate.
+func WithHTTPClient(client *http.Client) SignerOption {
+	return func(s *Signer) error {
+		s.httpClient = client
+		return nil
+	}
+}
+
+// WithComputeUnitLimit sets the compute unit limit of payment transactions (default
+// DefaultComputeUnitLimit). The limit multiplies the priority fee paid by the fee payer.
+func WithComputeUnitLimit(units uint32) SignerOption {
+	return func(s *Signer) error {
+		if units == 0 {
+			return fmt.Errorf("compute unit limit must be positive")
+		}
+		s.computeUnitLimit = units
+		return nil
+	}
+}
+
+// WithPriorityFee sets the compute unit price of payment transactions, in microlamports
+// per compute unit (default DefaultPriorityFee). With WithAutoPriorityFee it is the
+// lowest price paid.
+func WithPriorityFee(microlamports uint64) SignerOption {
+	return func(s *Signer) error {
+		s.priorityFee = microlamports
+		return nil
+	}
+}
+
+// WithAutoPriorityFee prices payment transactions from the prioritization fees recently
+// paid to write the token accounts of the transfer, as reported by the
+// getRecentPrioritizationFees RPC method, so payments keep landing during congestion.
+// The price is the 75th percentile of the recent fees, no lower than the WithPriorityFee
+// price and no higher than maxMicrolamports. If the RPC call fails the WithPriorityFee
+// price is used. Facilitators may reject transactions with too high a price, so keep
+// maxMicrolamports within what they accept.
+func WithAutoPriorityFee(maxMicrolamports uint64) SignerOption {
+	return func(s *Signer) error {
+		if maxMicrolamports == 0 {
+			return fmt.Errorf("maximum priority fee must be positive")
+		}
+		s.maxPriorityFee = maxMicrolamports
+		return nil
+	}
+}
+
+// WithSourceTokenAccount pays from the token account at address instead of the
+// associated token account of the signer's key. The key must own the account or be its
+// delegate for at least the amounts paid, as in treasury setups that approve a hot key
+// with SPL Token Approve. The account holds one token, so configure the signer with
+// that token only.
+func WithSourceTokenAccount(address string) SignerOption {
+	return func(s *Signer) error {
+		account, err := solana.PublicKeyFromBase58(address)
+		if err != nil {
+			return fmt.Errorf("invalid source token account: %w", err)
+		}
+		s.sourceTokenAccount = account
+		return nil
+	}
+}
+
+// WithMemo adds a Memo instruction recording memo to payment transactions, e.g. a
+// customer or invoice reference for the payee to reconcile payments with. By default
+// the memo is the requirement's reference (see x402.ExtraReference), if it has one.
+func WithMemo(memo string) SignerOption {
+	return func(s *Signer) error {
+		if !utf8.ValidString(memo) {
+			return fmt.Errorf("invalid memo %q: not valid UTF-8", memo)
+		}
+		s.memo = func(*x402.PaymentRequirement) string { return memo }
+		return nil
+	}
+}
+
+// WithMemoFunc adds a Memo instruction recording memo(requirements) to the transaction
+// paying requirements, e.g. the invoice it settles, instead of the requirement's
+// reference. No memo is added when memo returns an empty string.
+func WithMemoFunc(memo func(requirements *x402.PaymentRequirement) string) SignerOption {
+	return func(s *Signer) error {
+		s.memo = memo
+		return nil
+	}
+}
+
+// WithAddressLookupTables builds payment transactions as versioned transactions that
+// look up accounts in the address lookup tables at addresses, keeping transactions with
+// long memos or extra instructions within MaxTransactionSize. The tables are fetched
+// from the RPC endpoint when signing.
+func WithAddressLookupTables(addresses ...string) SignerOption {
+	return func(s *Signer) error {
+		for _, address := range addresses {
+			table, err := solana.PublicKeyFromBase58(address)
+			if err != nil {
+				return fmt.Errorf("invalid address lookup table %q: %w", address, err)
+			}
+			s.addressTables = append(s.addressTables, table)
+		}
+		return nil
+	}
+}
+
 // Network implements x402.Signer.
 func (s *Signer) Network() string {
 	return s.network
@@ -230,24 +427,49 @@ func (s *Signer) Sign(requirements *x402.PaymentRequirement) (*x402.PaymentPaylo
 	}
 
 	// Fetch recent blockhash from the network
-	client := rpc.New(rpcURL)
 	ctx := context.Background()
-	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	recent, err := s.rpcClient.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get blockhash from %s: %w", rpcURL, err)
 	}
 
+	// Use one key for the whole transaction even if it is rotated concurrently
+	key := s.key.Load()
+
+	// Pay from the configured token account, or else the key's ATA
+	source := s.sourceTokenAccount
+	if source.IsZero() {
+		if source, _, err = solana.FindAssociatedTokenAddress(key.publicKey, mintAddress); err != nil {
+			return nil, fmt.Errorf("failed to find source ATA: %w", err)
+		}
+	}
+
 	// Build the partially signed transaction
-	txBase64, err := BuildPartiallySignedTransfer(
-		s.privateKey,
-		s.publicKey,
-		mintAddress,
-		recipient,
-		amount.Uint64(),
-		decimals,
-		feePayer,
-		recent.Value.Blockhash,
-	)
+	tx := transfer{
+		source:           source,
+		mint:             mintAddress,
+		recipient:        recipient,
+		amount:           amount.Uint64(),
+		decimals:         decimals,
+		feePayer:         feePayer,
+		blockhash:        recent.Value.Blockhash,
+		computeUnitLimit: s.computeUnitLimit,
+		computeUnitPrice: s.computeUnitPrice(ctx, source, mintAddress, recipient),
+	}
+	// Record the requirement's reference unless a memo is configured
+	tx.memo = requirements.Reference()
+	if s.memo != nil {
+		tx.memo = s.memo(requirements)
+	}
+	if !utf8.ValidString(tx.memo) {
+		return nil, fmt.Errorf("invalid memo %q: not valid UTF-8", tx.memo)
+	}
+	if len(s.addressTables) > 0 {
+		if tx.addressTables, err = s.fetchAddressTables(ctx); err != nil {
+			return nil, err
+		}
+	}
+	txBase64, err := buildPartiallySignedTransfer(key.privateKey, key.publicKey, tx)
 	if err != nil {
 		return nil, x402.NewPaymentError(x402.ErrCodeSigningFailed, "failed to build transaction", err)
 	}
@@ -265,6 +487,59 @@ func (s *Signer) Sign(requirements *x402.PaymentRequirement) (*x402.PaymentPaylo
 	return payload, nil
 }
 
+// fetchAddressTables fetches the contents of the signer's address lookup tables.
+func (s *Signer) fetchAddressTables(ctx context.Context) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice, len(s.addressTables))
+	for _, address := range s.addressTables {
+		state, err := addresslookuptable.GetAddressLookupTable(ctx, s.rpcClient, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get address lookup table %s: %w", address, err)
+		}
+		tables[address] = state.Addresses
+	}
+	return tables, nil
+}
+
+// computeUnitPrice returns the priority fee of a transfer of mint from the source token
+// account to recipient, in microlamports per compute unit.
+func (s *Signer) computeUnitPrice(ctx context.Context, source, mint, recipient solana.PublicKey) uint64 {
+	if s.maxPriorityFee == 0 {
+		return s.priorityFee
+	}
+
+	// Fees are paid to lock the accounts written, the source and destination token accounts
+	destATA, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return s.priorityFee
+	}
+	recent, err := s.rpcClient.GetRecentPrioritizationFees(ctx, solana.PublicKeySlice{source, destATA})
+	if err != nil {
+		return s.priorityFee
+	}
+	fees := make([]uint64, len(recent))
+	for i, fee := range recent {
+		fees[i] = fee.PrioritizationFee
+	}
+	return autoPriorityFee(fees, s.priorityFee, s.maxPriorityFee)
+}
+
+// autoPriorityFee returns the priorityFeePercentile percentile of fees, clamped to
+// [floor, ceiling].
+func autoPriorityFee(fees []uint64, floor, ceiling uint64) uint64 {
+	fee := floor
+	if len(fees) > 0 {
+		sorted := append([]uint64(nil), fees...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if p := sorted[(len(sorted)-1)*priorityFeePercentile/100]; p > fee {
+			fee = p
+		}
+	}
+	if fee > ceiling {
+		fee = ceiling
+	}
+	return fee
+}
+
 // getRPCURL returns the RPC URL for the given network
 func getRPCURL(network string) (string, error) {
 	switch strings.ToLower(network) {
@@ -316,11 +591,42 @@ func (s *Signer) GetMaxAmount() *big.Int {
 
 // Address returns the signer's public key as a base58 string.
 func (s *Signer) Address() string {
-	return s.publicKey.String()
+	return s.key.Load().publicKey.String()
+}
+
+// SwapKey replaces the signer's private key with base58Key. Payments signed after
+// SwapKey returns use the new key; signatures in progress finish with the old one.
+func (s *Signer) SwapKey(base58Key string) error {
+	privateKey, err := solana.PrivateKeyFromBase58(base58Key)
+	if err != nil {
+		return x402.ErrInvalidKey
+	}
+	s.setKey(privateKey)
+	return nil
+}
+
+// Reload re-reads the private key from the keygen file or provider it was loaded from.
+// It returns an error wrapping x402.ErrInvalidKey if the key was given directly.
+func (s *Signer) Reload() error {
+	if s.loadKey == nil {
+		return fmt.Errorf("%w: signer has no reloadable key source", x402.ErrInvalidKey)
+	}
+	privateKey, err := s.loadKey()
+	if err != nil {
+		return err
+	}
+	s.setKey(privateKey)
+	return nil
+}
+
+// setKey atomically replaces the private key and the public key derived from it.
+func (s *Signer) setKey(privateKey solana.PrivateKey) {
+	s.key.Store(&signingKey{privateKey: privateKey, publicKey: privateKey.PublicKey()})
 }
 
 // BuildPartiallySignedTransfer creates a partially signed SPL token transfer.
 // The client signs with their private key, and the facilitator will add the fee payer signature.
+// The transaction has the DefaultComputeUnitLimit and DefaultPriorityFee compute budget.
 func BuildPartiallySignedTransfer(
 	clientPrivateKey solana.PrivateKey,
 	clientPublicKey solana.PublicKey,
@@ -331,43 +637,82 @@ func BuildPartiallySignedTransfer(
 	feePayer solana.PublicKey,
 	blockhash solana.Hash,
 ) (string, error) {
-	// Get associated token accounts
 	sourceATA, _, err := solana.FindAssociatedTokenAddress(clientPublicKey, mint)
 	if err != nil {
 		return "", fmt.Errorf("failed to find source ATA: %w", err)
 	}
+	return buildPartiallySignedTransfer(clientPrivateKey, clientPublicKey, transfer{
+		source:           sourceATA,
+		mint:             mint,
+		recipient:        recipient,
+		amount:           amount,
+		decimals:         decimals,
+		feePayer:         feePayer,
+		blockhash:        blockhash,
+		computeUnitLimit: DefaultComputeUnitLimit,
+		computeUnitPrice: DefaultPriorityFee,
+	})
+}
 
-	destATA, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+// transfer describes the transaction built by buildPartiallySignedTransfer.
+type transfer struct {
+	source    solana.PublicKey // token account the client owns or is a delegate of
+	mint      solana.PublicKey
+	recipient solana.PublicKey
+	amount    uint64
+	decimals  uint8
+	feePayer  solana.PublicKey
+	blockhash solana.Hash
+
+	computeUnitLimit uint32
+	computeUnitPrice uint64
+
+	// memo, if not empty, is added in a Memo instruction after the transfer.
+	memo string
+
+	// addressTables are the contents of address lookup tables, by table address. If set,
+	// the transaction is a versioned transaction looking up the accounts they hold.
+	addressTables map[solana.PublicKey]solana.PublicKeySlice
+}
+
+// buildPartiallySignedTransfer is BuildPartiallySignedTransfer for t.
+func buildPartiallySignedTransfer(clientPrivateKey solana.PrivateKey, clientPublicKey solana.PublicKey, t transfer) (string, error) {
+	// Get the recipient's associated token account
+	destATA, _, err := solana.FindAssociatedTokenAddress(t.recipient, t.mint)
 	if err != nil {
 		return "", fmt.Errorf("failed to find destination ATA: %w", err)
 	}
 
 	// Build instruction 3: TransferChecked using official builder
 	transferInst := token.NewTransferCheckedInstructionBuilder().
-		SetAmount(amount).
-		SetDecimals(decimals).
-		SetSourceAccount(sourceATA).
+		SetAmount(t.amount).
+		SetDecimals(t.decimals).
+		SetSourceAccount(t.source).
 		SetDestinationAccount(destATA).
-		SetMintAccount(mint).
+		SetMintAccount(t.mint).
 		SetOwnerAccount(clientPublicKey).
 		Build()
 
 	// Build instructions according to exact_svm spec
 	instructions := []solana.Instruction{
 		// Instruction 0: SetComputeUnitLimit
-		buildSetComputeUnitLimitInstruction(200_000), // 200k compute units
+		buildSetComputeUnitLimitInstruction(t.computeUnitLimit),
 		// Instruction 1: SetComputeUnitPrice
-		buildSetComputeUnitPriceInstruction(10_000), // 10k microlamports per compute unit
+		buildSetComputeUnitPriceInstruction(t.computeUnitPrice), // microlamports per compute unit
 		// Instruction 2: TransferChecked (use official builder from solana-go)
 		transferInst,
 	}
+	if t.memo != "" {
+		// Instruction 3: Memo, for the payee to reconcile the payment
+		instructions = append(instructions, buildMemoInstruction(t.memo))
+	}
 
 	// Create transaction with recent blockhash from the network
-	tx, err := solana.NewTransaction(
-		instructions,
-		blockhash,
-		solana.TransactionPayer(feePayer), // Set fee payer from requirements
-	)
+	options := []solana.TransactionOption{solana.TransactionPayer(t.feePayer)} // Set fee payer from requirements
+	if len(t.addressTables) > 0 {
+		options = append(options, solana.TransactionAddressTables(t.addressTables))
+	}
+	tx, err := solana.NewTransaction(instructions, t.blockhash, options...)
 	if err != nil {
 		return "", fmt.Errorf("failed to create transaction: %w", err)
 	}
@@ -390,6 +735,10 @@ func BuildPartiallySignedTransfer(
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal transaction: %w", err)
 	}
+	if len(txBytes) > MaxTransactionSize {
+		return "", fmt.Errorf("%w: %d bytes, the limit is %d; shorten the memo or use address lookup tables",
+			ErrTransactionTooLarge, len(txBytes), MaxTransactionSize)
+	}
 
 	// Encode to base64
 	return base64.StdEncoding.EncodeToString(txBytes), nil
@@ -398,6 +747,12 @@ func BuildPartiallySignedTransfer(
 // ComputeBudgetProgramID is the Solana Compute Budget program ID
 var ComputeBudgetProgramID = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
 
+// MaxTransactionSize is the largest serialized transaction Solana accepts, in bytes.
+const MaxTransactionSize = 1232
+
+// ErrTransactionTooLarge indicates a payment transaction exceeds MaxTransactionSize.
+var ErrTransactionTooLarge = errors.New("x402: solana transaction too large")
+
 // Token2022ProgramID is the SPL Token-2022 program ID
 var Token2022ProgramID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
 
@@ -440,3 +795,13 @@ func buildSetComputeUnitPriceInstruction(microlamports uint64) solana.Instructio
 		data,
 	)
 }
+
+// buildMemoInstruction creates a Memo instruction recording memo. It requires no
+// signatures, so the memo does not change who must sign the transaction.
+func buildMemoInstruction(memo string) solana.Instruction {
+	return solana.NewInstruction(
+		solana.MemoProgramID,
+		solana.AccountMetaSlice{},
+		[]byte(memo),
+	)
+}
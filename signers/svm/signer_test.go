@@ -1,10 +1,13 @@
 package svm
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gagliardetto/solana-go"
@@ -705,3 +708,242 @@ func TestTransactionStructure(t *testing.T) {
 	t.Logf("Transaction structure validated successfully")
 	t.Logf("Transaction base64: %s", transactionBase64[:50]+"...")
 }
+
+func TestKeyRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "id.json")
+
+	writeKey := func(key solana.PrivateKey) {
+		data, err := json.Marshal(key)
+		if err != nil {
+			t.Fatalf("failed to marshal key: %v", err)
+		}
+		if err := os.WriteFile(keyPath, data, 0600); err != nil {
+			t.Fatalf("failed to write keyfile: %v", err)
+		}
+	}
+
+	original := solana.NewWallet()
+	writeKey(original.PrivateKey)
+
+	signer, err := NewSigner(
+		WithKeygenFile(keyPath),
+		WithNetwork("solana"),
+		WithToken("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "USDC", 6),
+	)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	var _ x402.KeyRotator = signer
+
+	// Reload picks up a replaced keygen file
+	rotated := solana.NewWallet()
+	writeKey(rotated.PrivateKey)
+	if err := signer.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if signer.Address() != rotated.PublicKey().String() {
+		t.Errorf("expected address %s after Reload, got %s", rotated.PublicKey(), signer.Address())
+	}
+
+	// SwapKey replaces the key directly
+	if err := signer.SwapKey(original.PrivateKey.String()); err != nil {
+		t.Fatalf("SwapKey failed: %v", err)
+	}
+	if signer.Address() != original.PublicKey().String() {
+		t.Errorf("expected address %s after SwapKey, got %s", original.PublicKey(), signer.Address())
+	}
+	if err := signer.SwapKey("not-a-key"); err != x402.ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey for invalid key, got %v", err)
+	}
+
+	static, _ := NewSigner(
+		WithPrivateKey(testPrivateKeyBase58),
+		WithNetwork("solana"),
+		WithToken("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "USDC", 6),
+	)
+	if err := static.Reload(); err == nil {
+		t.Error("expected error from Reload without key source")
+	}
+}
+
+// testTransfer returns a transfer of 1 USDC from source with the default compute budget.
+func testTransfer(source solana.PublicKey) transfer {
+	return transfer{
+		source:           source,
+		mint:             solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"),
+		recipient:        solana.MustPublicKeyFromBase58("9B5XszUGdMaxCZ7uSQhPzdks5ZQSmWxrmzCSvtJ6Ns6g"),
+		amount:           1000000,
+		decimals:         6,
+		feePayer:         solana.MustPublicKeyFromBase58("EwWqGE4ZFKLofuestmU4LDdK7XM1N4ALgdZccwYugwGd"),
+		computeUnitLimit: DefaultComputeUnitLimit,
+		computeUnitPrice: DefaultPriorityFee,
+	}
+}
+
+// buildTestTransaction builds and decodes the transaction of transfer t signed by wallet.
+func buildTestTransaction(t *testing.T, wallet *solana.Wallet, tr transfer) *solana.Transaction {
+	t.Helper()
+	txBase64, err := buildPartiallySignedTransfer(wallet.PrivateKey, wallet.PublicKey(), tr)
+	if err != nil {
+		t.Fatalf("failed to build transaction: %v", err)
+	}
+	var tx solana.Transaction
+	if err := tx.UnmarshalBase64(txBase64); err != nil {
+		t.Fatalf("failed to unmarshal transaction: %v", err)
+	}
+	return &tx
+}
+
+func TestComputeBudget(t *testing.T) {
+	tr := testTransfer(solana.NewWallet().PublicKey())
+	tr.computeUnitLimit = 300_000
+	tr.computeUnitPrice = 50_000
+	tx := buildTestTransaction(t, solana.NewWallet(), tr)
+	if got := binary.LittleEndian.Uint32(tx.Message.Instructions[0].Data[1:]); got != 300_000 {
+		t.Errorf("compute unit limit = %d, want 300000", got)
+	}
+	if got := binary.LittleEndian.Uint64(tx.Message.Instructions[1].Data[1:]); got != 50_000 {
+		t.Errorf("compute unit price = %d, want 50000", got)
+	}
+
+	tests := []struct {
+		name string
+		opts []SignerOption
+		want bool
+	}{
+		{name: "limit", opts: []SignerOption{WithComputeUnitLimit(400_000)}, want: true},
+		{name: "zero limit", opts: []SignerOption{WithComputeUnitLimit(0)}},
+		{name: "fee", opts: []SignerOption{WithPriorityFee(0)}, want: true},
+		{name: "auto", opts: []SignerOption{WithAutoPriorityFee(1_000_000)}, want: true},
+		{name: "auto without a cap", opts: []SignerOption{WithAutoPriorityFee(0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]SignerOption{
+				WithPrivateKey(testPrivateKeyBase58),
+				WithNetwork("solana"),
+				WithToken("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "USDC", 6),
+			}, tt.opts...)
+			if _, err := NewSigner(opts...); (err == nil) != tt.want {
+				t.Errorf("NewSigner() error = %v, want success %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoPriorityFee(t *testing.T) {
+	tests := []struct {
+		name string
+		fees []uint64
+		want uint64
+	}{
+		{name: "no recent fees", want: 10_000},
+		{name: "quiet", fees: []uint64{0, 0, 0, 100}, want: 10_000},
+		{name: "congested", fees: []uint64{90_000, 10_000, 20_000, 50_000, 80_000}, want: 80_000},
+		{name: "capped", fees: []uint64{5_000_000, 5_000_000}, want: 1_000_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoPriorityFee(tt.fees, 10_000, 1_000_000); got != tt.want {
+				t.Errorf("autoPriorityFee() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceTokenAccount(t *testing.T) {
+	delegate := solana.NewWallet()
+	treasury := solana.NewWallet().PublicKey()
+	tx := buildTestTransaction(t, delegate, testTransfer(treasury))
+	accounts, err := tx.Message.Instructions[2].ResolveInstructionAccounts(&tx.Message)
+	if err != nil {
+		t.Fatalf("failed to resolve accounts: %v", err)
+	}
+	if !accounts[0].PublicKey.Equals(treasury) {
+		t.Errorf("source = %s, want the treasury account %s", accounts[0].PublicKey, treasury)
+	}
+	if !accounts[3].PublicKey.Equals(delegate.PublicKey()) || !accounts[3].IsSigner {
+		t.Errorf("authority = %s (signer %v), want the delegate %s", accounts[3].PublicKey, accounts[3].IsSigner, delegate.PublicKey())
+	}
+
+	_, err = NewSigner(
+		WithPrivateKey(testPrivateKeyBase58),
+		WithNetwork("solana"),
+		WithToken("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "USDC", 6),
+		WithSourceTokenAccount("not-a-key"),
+	)
+	if err == nil {
+		t.Error("expected error for an invalid source token account")
+	}
+}
+
+func TestMemo(t *testing.T) {
+	tr := testTransfer(solana.NewWallet().PublicKey())
+	tr.memo = "invoice-42"
+	tx := buildTestTransaction(t, solana.NewWallet(), tr)
+
+	if len(tx.Message.Instructions) != 4 {
+		t.Fatalf("expected 4 instructions, got %d", len(tx.Message.Instructions))
+	}
+	memo := tx.Message.Instructions[3]
+	programID, err := tx.Message.Program(memo.ProgramIDIndex)
+	if err != nil {
+		t.Fatalf("failed to get program ID for the memo: %v", err)
+	}
+	if !programID.Equals(solana.MemoProgramID) || string(memo.Data) != "invoice-42" {
+		t.Errorf("memo instruction = %s %q, want the Memo program with %q", programID, memo.Data, "invoice-42")
+	}
+
+	// A memo too long for a transaction is rejected
+	tr.memo = strings.Repeat("x", MaxTransactionSize)
+	if _, err := buildPartiallySignedTransfer(solana.NewWallet().PrivateKey, solana.NewWallet().PublicKey(), tr); !errors.Is(err, ErrTransactionTooLarge) {
+		t.Errorf("expected ErrTransactionTooLarge, got %v", err)
+	}
+
+	if _, err := NewSigner(
+		WithPrivateKey(testPrivateKeyBase58),
+		WithNetwork("solana"),
+		WithToken("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "USDC", 6),
+		WithMemo("\xff"),
+	); err == nil {
+		t.Error("expected error for a memo that is not UTF-8")
+	}
+}
+
+func TestAddressLookupTables(t *testing.T) {
+	wallet := solana.NewWallet()
+	tr := testTransfer(solana.NewWallet().PublicKey())
+	legacy := buildTestTransaction(t, wallet, tr)
+
+	// The table holds the mint, recipient token account and Token program
+	destATA, _, err := solana.FindAssociatedTokenAddress(tr.recipient, tr.mint)
+	if err != nil {
+		t.Fatalf("failed to find destination ATA: %v", err)
+	}
+	table := solana.NewWallet().PublicKey()
+	tr.addressTables = map[solana.PublicKey]solana.PublicKeySlice{
+		table: {tr.mint, destATA, solana.TokenProgramID},
+	}
+	versioned := buildTestTransaction(t, wallet, tr)
+
+	if !versioned.Message.IsVersioned() {
+		t.Fatal("expected a versioned transaction")
+	}
+	if lookups := versioned.Message.AddressTableLookups; len(lookups) != 1 || !lookups[0].AccountKey.Equals(table) {
+		t.Fatalf("address table lookups = %+v, want one lookup in %s", lookups, table)
+	}
+	if len(versioned.Message.AccountKeys) >= len(legacy.Message.AccountKeys) {
+		t.Errorf("versioned transaction has %d static accounts, want fewer than the %d of the legacy one",
+			len(versioned.Message.AccountKeys), len(legacy.Message.AccountKeys))
+	}
+
+	if _, err := NewSigner(
+		WithPrivateKey(testPrivateKeyBase58),
+		WithNetwork("solana"),
+		WithToken("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "USDC", 6),
+		WithAddressLookupTables("not-a-key"),
+	); err == nil {
+		t.Error("expected error for an invalid address lookup table")
+	}
+}
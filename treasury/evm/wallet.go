@@ -0,0 +1,187 @@
+// Package evm provides a treasury.Wallet for ERC-20 tokens (such as USDC) on EVM chains.
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mark3labs/x402-go"
+)
+
+// ERC-20 function selectors.
+var (
+	balanceOfSelector = []byte{0x70, 0xa0, 0x82, 0x31} // balanceOf(address)
+	transferSelector  = []byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+)
+
+// Wallet is a hot wallet holding an ERC-20 token, for sweeping with treasury.Sweeper.
+// It sends EIP-1559 transactions and pays gas in the chain's native token, so the wallet
+// needs a native balance as well.
+type Wallet struct {
+	client     *ethclient.Client
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+	token      common.Address
+	network    string
+	rpcURL     string
+}
+
+// WalletOption configures a Wallet.
+type WalletOption func(*Wallet) error
+
+// NewWallet creates a Wallet. A private key, network, token and RPC URL are required.
+func NewWallet(opts ...WalletOption) (*Wallet, error) {
+	w := &Wallet{}
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.privateKey == nil {
+		return nil, x402.ErrInvalidKey
+	}
+	if w.network == "" {
+		return nil, x402.ErrInvalidNetwork
+	}
+	if w.token == (common.Address{}) {
+		return nil, x402.ErrInvalidToken
+	}
+	if w.rpcURL == "" {
+		return nil, errors.New("evm: RPC URL is required")
+	}
+
+	client, err := ethclient.Dial(w.rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("evm: failed to connect to RPC: %w", err)
+	}
+	w.client = client
+	w.address = crypto.PubkeyToAddress(w.privateKey.PublicKey)
+	return w, nil
+}
+
+// WithPrivateKey sets the hot wallet private key from a hex string.
+func WithPrivateKey(hexKey string) WalletOption {
+	return func(w *Wallet) error {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+		if err != nil {
+			return x402.ErrInvalidKey
+		}
+		w.privateKey = privateKey
+		return nil
+	}
+}
+
+// WithNetwork sets the x402 network identifier (e.g., "base").
+func WithNetwork(network string) WalletOption {
+	return func(w *Wallet) error {
+		if _, err := x402.ValidateNetwork(network); err != nil {
+			return x402.ErrInvalidNetwork
+		}
+		w.network = network
+		return nil
+	}
+}
+
+// WithToken sets the ERC-20 token contract address to sweep.
+func WithToken(address string) WalletOption {
+	return func(w *Wallet) error {
+		if !common.IsHexAddress(address) {
+			return x402.ErrInvalidToken
+		}
+		w.token = common.HexToAddress(address)
+		return nil
+	}
+}
+
+// WithRPCURL sets the JSON-RPC endpoint of the chain.
+func WithRPCURL(url string) WalletOption {
+	return func(w *Wallet) error {
+		w.rpcURL = url
+		return nil
+	}
+}
+
+// Network returns the wallet's network.
+func (w *Wallet) Network() string {
+	return w.network
+}
+
+// Address returns the hot wallet address.
+func (w *Wallet) Address() string {
+	return w.address.Hex()
+}
+
+// Balance returns the wallet's token balance in atomic units.
+func (w *Wallet) Balance(ctx context.Context) (*big.Int, error) {
+	data := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(w.address.Bytes(), 32)...)
+	result, err := w.client.CallContract(ctx, ethereum.CallMsg{To: &w.token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("evm: balanceOf failed: %w", err)
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// Transfer sends amount atomic units of the token to address and returns the
+// transaction hash once the transaction is submitted.
+func (w *Wallet) Transfer(ctx context.Context, to string, amount *big.Int) (string, error) {
+	if !common.IsHexAddress(to) {
+		return "", x402.ErrInvalidAddress
+	}
+	data := append(append([]byte{}, transferSelector...), common.LeftPadBytes(common.HexToAddress(to).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+
+	chainID, err := w.client.ChainID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("evm: failed to get chain ID: %w", err)
+	}
+	nonce, err := w.client.PendingNonceAt(ctx, w.address)
+	if err != nil {
+		return "", fmt.Errorf("evm: failed to get nonce: %w", err)
+	}
+	tipCap, err := w.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return "", fmt.Errorf("evm: failed to get gas tip: %w", err)
+	}
+	head, err := w.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("evm: failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return "", fmt.Errorf("evm: %s does not support EIP-1559 transactions", w.network)
+	}
+	// Allow the base fee to double before the transaction stops being includable
+	feeCap := new(big.Int).Add(tipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	gas, err := w.client.EstimateGas(ctx, ethereum.CallMsg{From: w.address, To: &w.token, Data: data})
+	if err != nil {
+		return "", fmt.Errorf("evm: failed to estimate gas: %w", err)
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &w.token,
+		Value:     new(big.Int),
+		Data:      data,
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), w.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", x402.ErrSigningFailed, err)
+	}
+	if err := w.client.SendTransaction(ctx, signed); err != nil {
+		return "", fmt.Errorf("evm: failed to send transaction: %w", err)
+	}
+	return signed.Hash().Hex(), nil
+}
@@ -0,0 +1,57 @@
+package evm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// Test private key (DO NOT use in production)
+const testPrivateKeyHex = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+func TestNewWallet(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []WalletOption
+		wantErr error
+	}{
+		{
+			name: "valid wallet",
+			opts: []WalletOption{WithPrivateKey(testPrivateKeyHex), WithNetwork("base"), WithToken(x402.BaseMainnet.USDCAddress), WithRPCURL("https://mainnet.base.org")},
+		},
+		{
+			name:    "missing key",
+			opts:    []WalletOption{WithNetwork("base"), WithToken(x402.BaseMainnet.USDCAddress), WithRPCURL("https://mainnet.base.org")},
+			wantErr: x402.ErrInvalidKey,
+		},
+		{
+			name:    "invalid network",
+			opts:    []WalletOption{WithPrivateKey(testPrivateKeyHex), WithNetwork("ethereum-classic")},
+			wantErr: x402.ErrInvalidNetwork,
+		},
+		{
+			name:    "invalid token",
+			opts:    []WalletOption{WithPrivateKey(testPrivateKeyHex), WithNetwork("base"), WithToken("0x123")},
+			wantErr: x402.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet, err := NewWallet(tt.opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewWallet() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWallet() error = %v", err)
+			}
+			if wallet.Address() != "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266" || wallet.Network() != "base" {
+				t.Errorf("unexpected wallet %s on %s", wallet.Address(), wallet.Network())
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Package svm provides a treasury.Wallet for SPL tokens (such as USDC) on Solana.
+package svm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/mark3labs/x402-go"
+)
+
+// Wallet is a hot wallet holding an SPL token, for sweeping with treasury.Sweeper.
+// It pays transaction fees, and the rent for the cold address's token account when
+// it does not exist yet, in SOL, so the wallet needs a SOL balance as well.
+type Wallet struct {
+	client     *rpc.Client
+	privateKey solana.PrivateKey
+	mint       solana.PublicKey
+	decimals   uint8
+	network    string
+	rpcURL     string
+}
+
+// WalletOption configures a Wallet.
+type WalletOption func(*Wallet) error
+
+// NewWallet creates a Wallet. A private key, network and token are required; the RPC
+// URL defaults to the public endpoint of the network.
+func NewWallet(opts ...WalletOption) (*Wallet, error) {
+	w := &Wallet{}
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(w.privateKey) == 0 {
+		return nil, x402.ErrInvalidKey
+	}
+	if w.network == "" {
+		return nil, x402.ErrInvalidNetwork
+	}
+	if w.mint.IsZero() {
+		return nil, x402.ErrInvalidToken
+	}
+	if w.rpcURL == "" {
+		rpcURL, err := getRPCURL(w.network)
+		if err != nil {
+			return nil, err
+		}
+		w.rpcURL = rpcURL
+	}
+
+	w.client = rpc.New(w.rpcURL)
+	return w, nil
+}
+
+// WithPrivateKey sets the hot wallet private key from a base58 string.
+func WithPrivateKey(base58Key string) WalletOption {
+	return func(w *Wallet) error {
+		privateKey, err := solana.PrivateKeyFromBase58(base58Key)
+		if err != nil {
+			return x402.ErrInvalidKey
+		}
+		w.privateKey = privateKey
+		return nil
+	}
+}
+
+// WithNetwork sets the x402 network identifier ("solana" or "solana-devnet").
+func WithNetwork(network string) WalletOption {
+	return func(w *Wallet) error {
+		if _, err := getRPCURL(network); err != nil {
+			return x402.ErrInvalidNetwork
+		}
+		w.network = network
+		return nil
+	}
+}
+
+// WithToken sets the mint address and decimals of the SPL token to sweep.
+func WithToken(mint string, decimals int) WalletOption {
+	return func(w *Wallet) error {
+		mintAddress, err := solana.PublicKeyFromBase58(mint)
+		if err != nil || decimals < 0 || decimals > 255 {
+			return x402.ErrInvalidToken
+		}
+		w.mint = mintAddress
+		w.decimals = uint8(decimals)
+		return nil
+	}
+}
+
+// WithRPCURL sets the RPC endpoint (default: the public endpoint of the network).
+func WithRPCURL(url string) WalletOption {
+	return func(w *Wallet) error {
+		w.rpcURL = url
+		return nil
+	}
+}
+
+// Network returns the wallet's network.
+func (w *Wallet) Network() string {
+	return w.network
+}
+
+// Address returns the hot wallet address.
+func (w *Wallet) Address() string {
+	return w.privateKey.PublicKey().String()
+}
+
+// Balance returns the token balance of the wallet's associated token account in
+// atomic units.
+func (w *Wallet) Balance(ctx context.Context) (*big.Int, error) {
+	account, _, err := solana.FindAssociatedTokenAddress(w.privateKey.PublicKey(), w.mint)
+	if err != nil {
+		return nil, fmt.Errorf("svm: failed to find token account: %w", err)
+	}
+	result, err := w.client.GetTokenAccountBalance(ctx, account, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("svm: failed to get token balance: %w", err)
+	}
+	if result == nil || result.Value == nil {
+		return nil, errors.New("svm: empty token balance response")
+	}
+	balance, ok := new(big.Int).SetString(result.Value.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("svm: invalid token balance %q", result.Value.Amount)
+	}
+	return balance, nil
+}
+
+// Transfer sends amount atomic units of the token to address, creating its associated
+// token account if needed, and returns the transaction signature once the transaction
+// is submitted.
+func (w *Wallet) Transfer(ctx context.Context, to string, amount *big.Int) (string, error) {
+	recipient, err := solana.PublicKeyFromBase58(to)
+	if err != nil {
+		return "", x402.ErrInvalidAddress
+	}
+	if !amount.IsUint64() {
+		return "", fmt.Errorf("%w: %s exceeds the SPL token amount range", x402.ErrInvalidAmount, amount)
+	}
+
+	owner := w.privateKey.PublicKey()
+	sourceATA, _, err := solana.FindAssociatedTokenAddress(owner, w.mint)
+	if err != nil {
+		return "", fmt.Errorf("svm: failed to find source token account: %w", err)
+	}
+	destATA, _, err := solana.FindAssociatedTokenAddress(recipient, w.mint)
+	if err != nil {
+		return "", fmt.Errorf("svm: failed to find destination token account: %w", err)
+	}
+
+	var instructions []solana.Instruction
+	if _, err := w.client.GetAccountInfo(ctx, destATA); errors.Is(err, rpc.ErrNotFound) {
+		instructions = append(instructions, associatedtokenaccount.NewCreateInstruction(owner, recipient, w.mint).Build())
+	} else if err != nil {
+		return "", fmt.Errorf("svm: failed to look up destination token account: %w", err)
+	}
+	instructions = append(instructions, token.NewTransferCheckedInstructionBuilder().
+		SetAmount(amount.Uint64()).
+		SetDecimals(w.decimals).
+		SetSourceAccount(sourceATA).
+		SetDestinationAccount(destATA).
+		SetMintAccount(w.mint).
+		SetOwnerAccount(owner).
+		Build())
+
+	recent, err := w.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return "", fmt.Errorf("svm: failed to get recent blockhash: %w", err)
+	}
+	tx, err := solana.NewTransaction(instructions, recent.Value.Blockhash, solana.TransactionPayer(owner))
+	if err != nil {
+		return "", fmt.Errorf("svm: failed to create transaction: %w", err)
+	}
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(owner) {
+			return &w.privateKey
+		}
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("%w: %v", x402.ErrSigningFailed, err)
+	}
+
+	signature, err := w.client.SendTransaction(ctx, tx)
+	if err != nil {
+		return "", fmt.Errorf("svm: failed to send transaction: %w", err)
+	}
+	return signature.String(), nil
+}
+
+// getRPCURL returns the public RPC URL for the given network.
+func getRPCURL(network string) (string, error) {
+	switch strings.ToLower(network) {
+	case "solana":
+		return rpc.MainNetBeta_RPC, nil
+	case "solana-devnet":
+		return rpc.DevNet_RPC, nil
+	default:
+		return "", fmt.Errorf("%w: %s", x402.ErrInvalidNetwork, network)
+	}
+}
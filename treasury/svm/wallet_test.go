@@ -0,0 +1,57 @@
+package svm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/mark3labs/x402-go"
+)
+
+func TestNewWallet(t *testing.T) {
+	key := solana.NewWallet().PrivateKey
+
+	tests := []struct {
+		name    string
+		opts    []WalletOption
+		wantErr error
+	}{
+		{
+			name: "valid wallet",
+			opts: []WalletOption{WithPrivateKey(key.String()), WithNetwork("solana-devnet"), WithToken(x402.SolanaDevnet.USDCAddress, 6)},
+		},
+		{
+			name:    "missing key",
+			opts:    []WalletOption{WithNetwork("solana-devnet"), WithToken(x402.SolanaDevnet.USDCAddress, 6)},
+			wantErr: x402.ErrInvalidKey,
+		},
+		{
+			name:    "EVM network",
+			opts:    []WalletOption{WithPrivateKey(key.String()), WithNetwork("base")},
+			wantErr: x402.ErrInvalidNetwork,
+		},
+		{
+			name:    "missing token",
+			opts:    []WalletOption{WithPrivateKey(key.String()), WithNetwork("solana")},
+			wantErr: x402.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet, err := NewWallet(tt.opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewWallet() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWallet() error = %v", err)
+			}
+			if wallet.Address() != key.PublicKey().String() || wallet.Network() != "solana-devnet" {
+				t.Errorf("unexpected wallet %s on %s", wallet.Address(), wallet.Network())
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+// Package treasury sweeps received payments from a payTo hot wallet to a cold address,
+// so merchants don't accumulate large balances in operational wallets. A Sweeper checks
+// the hot wallet's token balance periodically and transfers it to the cold address once
+// it exceeds a threshold. Wallets for EVM (ERC-20 transfer) and Solana (SPL transfer)
+// are in the treasury/evm and treasury/svm packages.
+//
+// Example usage:
+//
+//	wallet, err := evm.NewWallet(
+//	    evm.WithPrivateKey(os.Getenv("HOT_WALLET_KEY")),
+//	    evm.WithRPCURL("https://mainnet.base.org"),
+//	    evm.WithToken(x402.BaseMainnet.USDCAddress),
+//	    evm.WithNetwork("base"),
+//	)
+//	sweeper, err := treasury.New(wallet, coldAddress,
+//	    treasury.WithThreshold("500000000"), // 500 USDC
+//	    treasury.WithWebhook("https://ops.example.com/hooks/treasury"),
+//	)
+//	go sweeper.Run(ctx)
+package treasury
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// DefaultInterval is how often Run checks the hot wallet balance by default.
+const DefaultInterval = time.Hour
+
+// ErrSweepFailed indicates the transfer to the cold address failed.
+var ErrSweepFailed = errors.New("x402: treasury sweep failed")
+
+// Wallet is a hot wallet holding one token that can be swept.
+type Wallet interface {
+	// Network returns the x402 network identifier of the wallet (e.g., "base").
+	Network() string
+
+	// Address returns the wallet address, the payTo address payments are received at.
+	Address() string
+
+	// Balance returns the wallet's token balance in atomic units.
+	Balance(ctx context.Context) (*big.Int, error)
+
+	// Transfer sends amount atomic units of the token to address and returns the
+	// transaction hash or signature.
+	Transfer(ctx context.Context, to string, amount *big.Int) (string, error)
+}
+
+// Sweep describes one sweep, or a planned one in dry-run mode. It is the body of
+// webhook notifications.
+type Sweep struct {
+	Network     string    `json:"network"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Balance     string    `json:"balance"`
+	Amount      string    `json:"amount"`
+	Transaction string    `json:"transaction,omitempty"`
+	DryRun      bool      `json:"dryRun,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Sweeper moves a hot wallet's balance to a cold address. Sweeper is safe for
+// concurrent use, but sweeps of the same wallet should not overlap; use a single
+// Run loop per wallet.
+type Sweeper struct {
+	wallet     Wallet
+	to         string
+	threshold  *big.Int
+	reserve    *big.Int
+	interval   time.Duration
+	dryRun     bool
+	webhookURL string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// Option configures a Sweeper.
+type Option func(*Sweeper) error
+
+// New creates a Sweeper that sweeps wallet to the cold address to. By default it sweeps
+// any non-zero balance, in full, every DefaultInterval.
+func New(wallet Wallet, to string, opts ...Option) (*Sweeper, error) {
+	if wallet == nil {
+		return nil, errors.New("treasury: wallet is nil")
+	}
+	if err := x402.ValidateAddress(wallet.Network(), to); err != nil {
+		return nil, fmt.Errorf("treasury: cold address: %w", err)
+	}
+	if strings.EqualFold(to, wallet.Address()) {
+		return nil, errors.New("treasury: cold address is the hot wallet address")
+	}
+
+	s := &Sweeper{
+		wallet:     wallet,
+		to:         to,
+		threshold:  new(big.Int),
+		reserve:    new(big.Int),
+		interval:   DefaultInterval,
+		httpClient: http.DefaultClient,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// WithThreshold sets the balance, in atomic units, the hot wallet must exceed before it
+// is swept (default 0).
+func WithThreshold(atomic string) Option {
+	return func(s *Sweeper) error {
+		threshold, err := parseAtomic(atomic)
+		if err != nil {
+			return fmt.Errorf("treasury: threshold: %w", err)
+		}
+		s.threshold = threshold
+		return nil
+	}
+}
+
+// WithReserve sets the amount, in atomic units, left in the hot wallet after a sweep
+// (default 0).
+func WithReserve(atomic string) Option {
+	return func(s *Sweeper) error {
+		reserve, err := parseAtomic(atomic)
+		if err != nil {
+			return fmt.Errorf("treasury: reserve: %w", err)
+		}
+		s.reserve = reserve
+		return nil
+	}
+}
+
+// WithInterval sets how often Run checks the balance (default DefaultInterval).
+func WithInterval(d time.Duration) Option {
+	return func(s *Sweeper) error {
+		if d <= 0 {
+			return fmt.Errorf("treasury: interval must be positive, got %v", d)
+		}
+		s.interval = d
+		return nil
+	}
+}
+
+// WithDryRun reports the sweeps that would be made, to logs and the webhook, without
+// transferring funds.
+func WithDryRun() Option {
+	return func(s *Sweeper) error {
+		s.dryRun = true
+		return nil
+	}
+}
+
+// WithWebhook sets a URL that receives each Sweep, including failed and dry-run sweeps,
+// as a JSON POST request.
+func WithWebhook(url string) Option {
+	return func(s *Sweeper) error {
+		s.webhookURL = url
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for webhook notifications
+// (default http.DefaultClient).
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Sweeper) error {
+		if client == nil {
+			return errors.New("treasury: HTTP client is nil")
+		}
+		s.httpClient = client
+		return nil
+	}
+}
+
+// WithLogger sets the logger (default slog.Default()).
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Sweeper) error {
+		if logger == nil {
+			return errors.New("treasury: logger is nil")
+		}
+		s.logger = logger
+		return nil
+	}
+}
+
+// Run sweeps the hot wallet once immediately and then every interval until ctx is done,
+// and returns ctx.Err(). Failed sweeps are logged and retried on the next tick.
+func (s *Sweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SweepOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("treasury sweep failed", "network", s.wallet.Network(), "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce checks the hot wallet balance and, if it exceeds the threshold, transfers
+// everything above the reserve to the cold address. It returns nil if there was nothing
+// to sweep. In dry-run mode the returned Sweep has no transaction.
+func (s *Sweeper) SweepOnce(ctx context.Context) (*Sweep, error) {
+	balance, err := s.wallet.Balance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("treasury: failed to get balance: %w", err)
+	}
+	if balance.Cmp(s.threshold) <= 0 {
+		return nil, nil
+	}
+	amount := new(big.Int).Sub(balance, s.reserve)
+	if amount.Sign() <= 0 {
+		return nil, nil
+	}
+
+	sweep := &Sweep{
+		Network:   s.wallet.Network(),
+		From:      s.wallet.Address(),
+		To:        s.to,
+		Balance:   balance.String(),
+		Amount:    amount.String(),
+		DryRun:    s.dryRun,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if s.dryRun {
+		s.logger.Info("treasury sweep (dry run)", "network", sweep.Network, "amount", sweep.Amount, "to", sweep.To)
+		s.notify(ctx, sweep)
+		return sweep, nil
+	}
+
+	tx, err := s.wallet.Transfer(ctx, s.to, amount)
+	if err != nil {
+		sweep.Error = err.Error()
+		s.notify(ctx, sweep)
+		return sweep, fmt.Errorf("%w: %v", ErrSweepFailed, err)
+	}
+	sweep.Transaction = tx
+	s.logger.Info("treasury sweep", "network", sweep.Network, "amount", sweep.Amount, "to", sweep.To, "transaction", tx)
+	s.notify(ctx, sweep)
+	return sweep, nil
+}
+
+// notify posts sweep to the webhook, if one is configured. Notification failures are
+// logged; they do not fail the sweep.
+func (s *Sweeper) notify(ctx context.Context, sweep *Sweep) {
+	if s.webhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(sweep)
+	if err != nil {
+		s.logger.Error("failed to marshal treasury webhook", "error", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		s.logger.Error("failed to create treasury webhook request", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.logger.Warn("treasury webhook failed", "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		s.logger.Warn("treasury webhook rejected", "status", resp.StatusCode)
+	}
+}
+
+// parseAtomic parses a non-negative amount in atomic units.
+func parseAtomic(atomic string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(atomic, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %q is not a non-negative atomic amount", x402.ErrInvalidAmount, atomic)
+	}
+	return amount, nil
+}
@@ -0,0 +1,154 @@
+package treasury
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	hotAddress  = "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+	coldAddress = "0x857b06519E91e3A54538791bDbb0E22373e36b66"
+)
+
+// fakeWallet is an in-memory Wallet.
+type fakeWallet struct {
+	mu          sync.Mutex
+	balance     *big.Int
+	transferErr error
+	transfers   []*big.Int
+}
+
+func (w *fakeWallet) Network() string { return "base-sepolia" }
+func (w *fakeWallet) Address() string { return hotAddress }
+
+func (w *fakeWallet) Balance(context.Context) (*big.Int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return new(big.Int).Set(w.balance), nil
+}
+
+func (w *fakeWallet) Transfer(_ context.Context, to string, amount *big.Int) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.transferErr != nil {
+		return "", w.transferErr
+	}
+	w.transfers = append(w.transfers, amount)
+	w.balance.Sub(w.balance, amount)
+	return "0xtx", nil
+}
+
+func TestSweeper_SweepOnce(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     int64
+		opts        []Option
+		transferErr error
+		wantAmount  string
+		wantTx      string
+		wantErr     error
+	}{
+		{name: "sweeps full balance", balance: 1000, wantAmount: "1000", wantTx: "0xtx"},
+		{name: "below threshold", balance: 1000, opts: []Option{WithThreshold("1000")}},
+		{name: "above threshold", balance: 1001, opts: []Option{WithThreshold("1000")}, wantAmount: "1001", wantTx: "0xtx"},
+		{name: "keeps reserve", balance: 1000, opts: []Option{WithReserve("100")}, wantAmount: "900", wantTx: "0xtx"},
+		{name: "reserve covers balance", balance: 100, opts: []Option{WithReserve("100")}},
+		{name: "empty wallet", balance: 0},
+		{name: "dry run", balance: 1000, opts: []Option{WithDryRun()}, wantAmount: "1000"},
+		{name: "transfer fails", balance: 1000, transferErr: errors.New("insufficient gas"), wantAmount: "1000", wantErr: ErrSweepFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notified []Sweep
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var sweep Sweep
+				_ = json.NewDecoder(r.Body).Decode(&sweep)
+				notified = append(notified, sweep)
+			}))
+			defer webhook.Close()
+
+			wallet := &fakeWallet{balance: big.NewInt(tt.balance), transferErr: tt.transferErr}
+			sweeper, err := New(wallet, coldAddress, append(tt.opts, WithWebhook(webhook.URL))...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			sweep, err := sweeper.SweepOnce(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SweepOnce() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantAmount == "" {
+				if sweep != nil || len(notified) != 0 || len(wallet.transfers) != 0 {
+					t.Errorf("expected no sweep, got %+v", sweep)
+				}
+				return
+			}
+
+			if sweep.Amount != tt.wantAmount || sweep.Transaction != tt.wantTx || sweep.To != coldAddress {
+				t.Errorf("sweep = %+v, want amount %s and transaction %q to %s", sweep, tt.wantAmount, tt.wantTx, coldAddress)
+			}
+			if len(notified) != 1 || notified[0].Amount != tt.wantAmount || notified[0].DryRun != sweep.DryRun {
+				t.Errorf("webhook received %+v, want the sweep", notified)
+			}
+			if tt.wantErr != nil && notified[0].Error == "" {
+				t.Error("expected failed sweep notification to carry the error")
+			}
+			transferred := len(wallet.transfers) == 1
+			if transferred != (tt.wantTx != "") {
+				t.Errorf("transfers = %v", wallet.transfers)
+			}
+		})
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	wallet := &fakeWallet{balance: new(big.Int)}
+
+	tests := []struct {
+		name string
+		to   string
+		opts []Option
+	}{
+		{name: "invalid cold address", to: "0x123"},
+		{name: "cold address is hot wallet", to: hotAddress},
+		{name: "negative threshold", to: coldAddress, opts: []Option{WithThreshold("-1")}},
+		{name: "invalid reserve", to: coldAddress, opts: []Option{WithReserve("1.5")}},
+		{name: "zero interval", to: coldAddress, opts: []Option{WithInterval(0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(wallet, tt.to, tt.opts...); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestSweeper_Run(t *testing.T) {
+	wallet := &fakeWallet{balance: big.NewInt(1000)}
+	sweeper, err := New(wallet, coldAddress, WithInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := sweeper.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v, want context.DeadlineExceeded", err)
+	}
+
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+	if len(wallet.transfers) != 1 || wallet.balance.Sign() != 0 {
+		t.Errorf("expected one sweep of the full balance, got transfers %v and balance %s", wallet.transfers, wallet.balance)
+	}
+}
@@ -1,6 +1,9 @@
 package x402
 
-import "math/big"
+import (
+	"encoding/json"
+	"math/big"
+)
 
 type InputSchemaType string
 
@@ -102,6 +105,14 @@ type PaymentRequirementsResponse struct {
 
 	// Accepts is an array of payment options the server will accept.
 	Accepts []PaymentRequirement `json:"accepts"`
+
+	// Reason is the facilitator's reason code for rejecting the payment the request
+	// carried (e.g. "invalid_exact_evm_payload_authorization_valid_before"). It is empty
+	// when the request carried no payment.
+	Reason string `json:"reason,omitempty"`
+
+	// Payer is the address of the rejected payment, when known.
+	Payer string `json:"payer,omitempty"`
 }
 
 // PaymentPayload represents a signed payment that will be sent to the server.
@@ -119,6 +130,19 @@ type PaymentPayload struct {
 	// For EVM: EVMPayload with signature and authorization
 	// For Solana: SVMPayload with partially signed transaction
 	Payload interface{} `json:"payload"`
+
+	// Simulated marks a dry-run payment. Servers that accept simulated payments serve
+	// them on testnets without verifying or settling them; others reject them.
+	Simulated bool `json:"simulated,omitempty"`
+
+	// Reference is the reference of the requirement paid, if it has one. See
+	// ExtraReference.
+	Reference string `json:"reference,omitempty"`
+
+	// Binding optionally binds the payment to the client's TLS connection or DPoP key,
+	// so a stolen X-PAYMENT header cannot be presented from another connection. See
+	// PaymentBinding.
+	Binding *PaymentBinding `json:"binding,omitempty"`
 }
 
 // TokenConfig represents configuration for a supported token.
@@ -171,6 +195,35 @@ type EVMAuthorization struct {
 	Nonce string `json:"nonce"`
 }
 
+// EVMPermitPayload represents an EVM payment with an EIP-2612 permit, for the permit
+// scheme.
+type EVMPermitPayload struct {
+	// Signature is the hex-encoded ECDSA signature of the permit.
+	Signature string `json:"signature"`
+
+	// Permit contains the EIP-2612 permit parameters.
+	Permit EVMPermit `json:"permit"`
+}
+
+// EVMPermit represents EIP-2612 permit parameters.
+type EVMPermit struct {
+	// Owner is the payer's address.
+	Owner string `json:"owner"`
+
+	// Spender is the address allowed to transfer the payment, from the requirement's
+	// spender extra.
+	Spender string `json:"spender"`
+
+	// Value is the payment amount in atomic units.
+	Value string `json:"value"`
+
+	// Nonce is the owner's permit nonce on the token contract.
+	Nonce string `json:"nonce"`
+
+	// Deadline is the unix timestamp after which the permit is invalid.
+	Deadline string `json:"deadline"`
+}
+
 // SVMPayload represents a Solana payment with a partially signed transaction.
 type SVMPayload struct {
 	// Transaction is the base64-encoded partially signed Solana transaction.
@@ -194,40 +247,49 @@ type SettlementResponse struct {
 
 	// Payer is the address that made the payment.
 	Payer string `json:"payer"`
+
+	// Simulated reports that the payment was simulated and nothing was settled.
+	Simulated bool `json:"simulated,omitempty"`
+
+	// FeePaid is the network fee the facilitator paid to settle, in atomic units of the
+	// network's native token, if it reports it.
+	FeePaid string `json:"feePaid,omitempty"`
+
+	// BlockNumber is the EVM block the transaction was included in, if reported.
+	BlockNumber uint64 `json:"blockNumber,omitempty"`
+
+	// Slot is the Solana slot the transaction was included in, if reported.
+	Slot uint64 `json:"slot,omitempty"`
+
+	// SettledAt is the unix timestamp of the settlement, if reported.
+	SettledAt int64 `json:"settledAt,omitempty"`
+
+	// Reference is the reference of the requirement paid, if it has one, for the
+	// merchant to reconcile the settlement with. See ExtraReference.
+	Reference string `json:"reference,omitempty"`
+
+	// Extra holds the JSON fields SettlementResponse does not know, e.g. from newer
+	// facilitators. They are kept when the response is decoded and written back when
+	// it is encoded, so relaying a settlement does not drop them.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // AmountToBigInt converts a decimal amount string to *big.Int in atomic units.
 // For example, "1.5" with 6 decimals becomes 1500000.
+// The conversion is exact; see ParseAmount for the accepted format.
 func AmountToBigInt(amount string, decimals int) (*big.Int, error) {
-	// Parse decimal string and convert to atomic units
-	value := new(big.Float)
-	if _, ok := value.SetString(amount); !ok {
+	parsed, err := ParseAmount(amount, decimals)
+	if err != nil {
 		return nil, ErrInvalidAmount
 	}
-
-	// Multiply by 10^decimals
-	multiplier := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
-	value.Mul(value, multiplier)
-
-	// Convert to integer
-	result, accuracy := value.Int(nil)
-	if accuracy != big.Exact {
-		return nil, ErrInvalidAmount
-	}
-	return result, nil
+	return parsed.Atomic(), nil
 }
 
 // BigIntToAmount converts a *big.Int in atomic units to a decimal string.
-// For example, 1500000 with 6 decimals becomes "1.5".
+// For example, 1500000 with 6 decimals becomes "1.500000".
 func BigIntToAmount(value *big.Int, decimals int) string {
 	if value == nil {
 		return "0"
 	}
-
-	// Convert to float and divide by 10^decimals
-	f := new(big.Float).SetInt(value)
-	divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
-	f.Quo(f, divisor)
-
-	return f.Text('f', decimals)
+	return NewAtomicAmount(value, decimals).Format()
 }
@@ -104,15 +104,19 @@ func ValidatePaymentRequirement(req x402.PaymentRequirement) error {
 	switch req.Scheme {
 	case "exact", "max", "subscription":
 		// Valid schemes
+	case x402.SchemePermit:
+		if networkType != x402.NetworkTypeEVM {
+			return fmt.Errorf("invalid requirement: scheme %s is only supported on EVM networks", req.Scheme)
+		}
 	case "":
 		return fmt.Errorf("invalid requirement: scheme cannot be empty")
 	default:
 		return fmt.Errorf("invalid requirement: unsupported scheme %s", req.Scheme)
 	}
 
-	// Validate timeout (must be non-negative)
-	if req.MaxTimeoutSeconds < 0 {
-		return fmt.Errorf("invalid requirement: timeout cannot be negative: %d", req.MaxTimeoutSeconds)
+	// Validate timeout (a zero window expires the authorization as soon as it is signed)
+	if req.MaxTimeoutSeconds <= 0 {
+		return fmt.Errorf("invalid requirement: timeout must be positive: %d", req.MaxTimeoutSeconds)
 	}
 
 	// Validate EIP-3009 parameters for EVM chains
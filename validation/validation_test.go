@@ -307,6 +307,30 @@ func TestValidatePaymentRequirement(t *testing.T) {
 			wantErr: true,
 			errMsg:  "unsupported scheme",
 		},
+		{
+			name: "valid permit requirement",
+			req: x402.PaymentRequirement{
+				Scheme:            "permit",
+				Network:           "base",
+				MaxAmountRequired: "10000",
+				Asset:             "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb",
+				PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				MaxTimeoutSeconds: 60,
+			},
+			wantErr: false,
+		},
+		{
+			name: "permit scheme on Solana",
+			req: x402.PaymentRequirement{
+				Scheme:            "permit",
+				Network:           "solana",
+				MaxAmountRequired: "1000000",
+				Asset:             "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+				PayTo:             "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+			},
+			wantErr: true,
+			errMsg:  "only supported on EVM networks",
+		},
 		{
 			name: "negative timeout",
 			req: x402.PaymentRequirement{
@@ -318,7 +342,19 @@ func TestValidatePaymentRequirement(t *testing.T) {
 				MaxTimeoutSeconds: -1,
 			},
 			wantErr: true,
-			errMsg:  "timeout cannot be negative",
+			errMsg:  "timeout must be positive",
+		},
+		{
+			name: "zero timeout",
+			req: x402.PaymentRequirement{
+				Scheme:            "exact",
+				Network:           "base",
+				MaxAmountRequired: "10000",
+				Asset:             "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913",
+				PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			},
+			wantErr: true,
+			errMsg:  "timeout must be positive",
 		},
 		{
 			name: "empty EIP-3009 name",
@@ -328,6 +364,7 @@ func TestValidatePaymentRequirement(t *testing.T) {
 				MaxAmountRequired: "10000",
 				Asset:             "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913",
 				PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				MaxTimeoutSeconds: 60,
 				Extra: map[string]interface{}{
 					"name":    "",
 					"version": "2",
@@ -344,6 +381,7 @@ func TestValidatePaymentRequirement(t *testing.T) {
 				MaxAmountRequired: "10000",
 				Asset:             "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913",
 				PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				MaxTimeoutSeconds: 60,
 				Extra: map[string]interface{}{
 					"name":    "USD Coin",
 					"version": "",
@@ -0,0 +1,77 @@
+package vouchers
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// Signer implements x402.Signer by spending vouchers from a Store instead of signing
+// with a private key.
+type Signer struct {
+	network  string
+	store    Store
+	priority int
+}
+
+// SignerOption configures a Signer.
+type SignerOption func(*Signer)
+
+// NewSigner creates a Signer that pays on network with vouchers from store.
+func NewSigner(network string, store Store, opts ...SignerOption) *Signer {
+	s := &Signer{network: network, store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithPriority sets the signer priority (lower numbers are preferred).
+func WithPriority(priority int) SignerOption {
+	return func(s *Signer) {
+		s.priority = priority
+	}
+}
+
+// Network implements x402.Signer.
+func (s *Signer) Network() string { return s.network }
+
+// Scheme implements x402.Signer.
+func (s *Signer) Scheme() string { return "exact" }
+
+// CanSign implements x402.Signer. It reports whether the store holds an unexpired
+// voucher for exactly these requirements.
+func (s *Signer) CanSign(requirements *x402.PaymentRequirement) bool {
+	if requirements.Network != s.network || requirements.Scheme != s.Scheme() {
+		return false
+	}
+	count, err := s.store.Remaining(context.Background(), requirements)
+	return err == nil && count > 0
+}
+
+// Sign implements x402.Signer. It removes a matching voucher from the store and returns
+// its pre-signed payload, or ErrNoVoucher if none is left.
+func (s *Signer) Sign(requirements *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	voucher, err := s.store.Take(context.Background(), requirements)
+	if err != nil {
+		return nil, err
+	}
+	payment := voucher.Payment
+	return &payment, nil
+}
+
+// GetPriority implements x402.Signer.
+func (s *Signer) GetPriority() int { return s.priority }
+
+// GetTokens implements x402.Signer. Voucher signers are not tied to configured tokens.
+func (s *Signer) GetTokens() []x402.TokenConfig { return nil }
+
+// GetMaxAmount implements x402.Signer. Vouchers carry fixed amounts, so there is no
+// separate per-call limit.
+func (s *Signer) GetMaxAmount() *big.Int { return nil }
+
+// Remaining returns the number of unexpired vouchers left in the signer's store.
+func (s *Signer) Remaining(ctx context.Context) (int, error) {
+	return s.store.Remaining(ctx, nil)
+}
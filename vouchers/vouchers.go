@@ -0,0 +1,198 @@
+// Package vouchers lets a payer pre-sign a batch of single-use payment authorizations
+// ("vouchers") and hand them to a constrained device or agent, which then pays with them
+// instead of holding a private key.
+//
+// The payer issues vouchers for a payment template with a long enough
+// MaxTimeoutSeconds, serializes them with encoding/json, and ships them to the device:
+//
+//	batch, err := vouchers.Issue(evmSigner, template, 50)
+//
+// The device loads them into a Store and pays through a voucher Signer:
+//
+//	store := vouchers.NewMemoryStore(batch...)
+//	client, err := x402http.NewClient(x402http.WithSigner(vouchers.NewSigner("base", store)))
+//
+// Each voucher authorizes exactly one payment of its amount to its recipient, so a
+// compromised device can spend at most the vouchers it holds.
+package vouchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+var (
+	// ErrNoVoucher indicates no unexpired voucher matches the payment requirements.
+	ErrNoVoucher = errors.New("x402: no matching voucher")
+
+	// ErrInvalidTemplate indicates a voucher template cannot be signed.
+	ErrInvalidTemplate = errors.New("x402: invalid voucher template")
+)
+
+// Voucher is a pre-signed payment authorization for one payment.
+type Voucher struct {
+	// Network, Scheme, Asset, PayTo and Amount describe the payment the voucher covers.
+	Network string `json:"network"`
+	Scheme  string `json:"scheme"`
+	Asset   string `json:"asset"`
+	PayTo   string `json:"payTo"`
+	Amount  string `json:"amount"`
+
+	// ExpiresAt is when the signed authorization stops being valid.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// Payment is the signed payload sent in the X-PAYMENT header.
+	Payment x402.PaymentPayload `json:"payment"`
+}
+
+// Matches reports whether the voucher can pay for the requirement at time now.
+func (v *Voucher) Matches(requirement *x402.PaymentRequirement, now time.Time) bool {
+	return v.Network == requirement.Network &&
+		v.Scheme == requirement.Scheme &&
+		strings.EqualFold(v.Asset, requirement.Asset) &&
+		strings.EqualFold(v.PayTo, requirement.PayTo) &&
+		v.Amount == requirement.MaxAmountRequired &&
+		now.Before(v.ExpiresAt)
+}
+
+// Issue signs count vouchers for template with signer. The template's
+// MaxTimeoutSeconds sets how long the vouchers stay valid.
+func Issue(signer x402.Signer, template x402.PaymentRequirement, count int) ([]Voucher, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("%w: count must be positive", ErrInvalidTemplate)
+	}
+	if template.MaxTimeoutSeconds <= 0 {
+		return nil, fmt.Errorf("%w: maxTimeoutSeconds must be positive", ErrInvalidTemplate)
+	}
+	amount, ok := new(big.Int).SetString(template.MaxAmountRequired, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %s", x402.ErrInvalidAmount, template.MaxAmountRequired)
+	}
+	if !signer.CanSign(&template) {
+		return nil, fmt.Errorf("%w: signer cannot sign %s on %s", ErrInvalidTemplate, template.Asset, template.Network)
+	}
+
+	vouchers := make([]Voucher, 0, count)
+	for i := 0; i < count; i++ {
+		// Sign each voucher separately so every one carries a fresh nonce
+		issuedAt := time.Now()
+		payment, err := signer.Sign(&template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign voucher %d: %w", i+1, err)
+		}
+		vouchers = append(vouchers, Voucher{
+			Network:   template.Network,
+			Scheme:    template.Scheme,
+			Asset:     template.Asset,
+			PayTo:     template.PayTo,
+			Amount:    template.MaxAmountRequired,
+			ExpiresAt: issuedAt.Add(time.Duration(template.MaxTimeoutSeconds) * time.Second),
+			Payment:   *payment,
+		})
+	}
+	return vouchers, nil
+}
+
+// Store holds unspent vouchers.
+// Implementations must be safe for concurrent use and must hand out each voucher once.
+type Store interface {
+	// Add stores vouchers.
+	Add(ctx context.Context, vouchers ...Voucher) error
+
+	// Take removes and returns an unexpired voucher matching requirement, or returns
+	// ErrNoVoucher.
+	Take(ctx context.Context, requirement *x402.PaymentRequirement) (*Voucher, error)
+
+	// Remaining counts unexpired vouchers matching requirement, or all unexpired
+	// vouchers if requirement is nil.
+	Remaining(ctx context.Context, requirement *x402.PaymentRequirement) (int, error)
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu       sync.Mutex
+	vouchers []Voucher
+}
+
+// NewMemoryStore creates a MemoryStore holding vouchers.
+func NewMemoryStore(vouchers ...Voucher) *MemoryStore {
+	return &MemoryStore{vouchers: append([]Voucher(nil), vouchers...)}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(_ context.Context, vouchers ...Voucher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vouchers = append(s.vouchers, vouchers...)
+	return nil
+}
+
+// Take implements Store. Vouchers closest to expiry are used first.
+func (s *MemoryStore) Take(_ context.Context, requirement *x402.PaymentRequirement) (*Voucher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.dropExpired(now)
+
+	best := -1
+	for i := range s.vouchers {
+		if s.vouchers[i].Matches(requirement, now) &&
+			(best < 0 || s.vouchers[i].ExpiresAt.Before(s.vouchers[best].ExpiresAt)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("%w: %s %s on %s", ErrNoVoucher, requirement.MaxAmountRequired, requirement.Asset, requirement.Network)
+	}
+
+	voucher := s.vouchers[best]
+	s.vouchers = append(s.vouchers[:best], s.vouchers[best+1:]...)
+	return &voucher, nil
+}
+
+// Remaining implements Store.
+func (s *MemoryStore) Remaining(_ context.Context, requirement *x402.PaymentRequirement) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.dropExpired(now)
+	if requirement == nil {
+		return len(s.vouchers), nil
+	}
+
+	count := 0
+	for i := range s.vouchers {
+		if s.vouchers[i].Matches(requirement, now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Vouchers returns a copy of the unexpired vouchers, e.g. to persist them.
+func (s *MemoryStore) Vouchers() []Voucher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropExpired(time.Now())
+	return append([]Voucher(nil), s.vouchers...)
+}
+
+// dropExpired removes expired vouchers. The caller must hold s.mu.
+func (s *MemoryStore) dropExpired(now time.Time) {
+	kept := s.vouchers[:0]
+	for _, v := range s.vouchers {
+		if now.Before(v.ExpiresAt) {
+			kept = append(kept, v)
+		}
+	}
+	s.vouchers = kept
+}
@@ -0,0 +1,160 @@
+package vouchers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/x402-go"
+)
+
+// countingSigner signs payloads with an increasing nonce.
+type countingSigner struct {
+	signed int
+}
+
+func (s *countingSigner) Network() string                       { return "base" }
+func (s *countingSigner) Scheme() string                        { return "exact" }
+func (s *countingSigner) CanSign(*x402.PaymentRequirement) bool { return true }
+func (s *countingSigner) GetPriority() int                      { return 0 }
+func (s *countingSigner) GetTokens() []x402.TokenConfig         { return nil }
+func (s *countingSigner) GetMaxAmount() *big.Int                { return nil }
+func (s *countingSigner) Sign(req *x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	s.signed++
+	return &x402.PaymentPayload{
+		X402Version: 1,
+		Scheme:      req.Scheme,
+		Network:     req.Network,
+		Payload:     map[string]any{"nonce": fmt.Sprintf("0x%02x", s.signed)},
+	}, nil
+}
+
+func testTemplate() x402.PaymentRequirement {
+	return x402.PaymentRequirement{
+		Scheme:            "exact",
+		Network:           "base",
+		Asset:             "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxAmountRequired: "10000",
+		MaxTimeoutSeconds: 3600,
+	}
+}
+
+func TestIssue(t *testing.T) {
+	signer := &countingSigner{}
+	batch, err := Issue(signer, testTemplate(), 3)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if len(batch) != 3 || signer.signed != 3 {
+		t.Fatalf("Expected 3 signed vouchers, got %d (signed %d)", len(batch), signer.signed)
+	}
+	if batch[0].Payment.Payload.(map[string]any)["nonce"] == batch[1].Payment.Payload.(map[string]any)["nonce"] {
+		t.Error("Expected each voucher to carry its own nonce")
+	}
+	if until := time.Until(batch[0].ExpiresAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("Expected expiry about an hour from now, got %s", until)
+	}
+
+	invalid := []struct {
+		name     string
+		template func() x402.PaymentRequirement
+		count    int
+		wantErr  error
+	}{
+		{"zero count", testTemplate, 0, ErrInvalidTemplate},
+		{"no timeout", func() x402.PaymentRequirement { r := testTemplate(); r.MaxTimeoutSeconds = 0; return r }, 1, ErrInvalidTemplate},
+		{"bad amount", func() x402.PaymentRequirement { r := testTemplate(); r.MaxAmountRequired = "abc"; return r }, 1, x402.ErrInvalidAmount},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Issue(&countingSigner{}, tt.template(), tt.count); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Issue error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	template := testTemplate()
+	batch, _ := Issue(&countingSigner{}, template, 2)
+
+	expired := batch[0]
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	store := NewMemoryStore(append(batch, expired)...)
+
+	if n, _ := store.Remaining(ctx, nil); n != 2 {
+		t.Errorf("Remaining = %d, want 2 (expired vouchers excluded)", n)
+	}
+
+	other := template
+	other.MaxAmountRequired = "20000"
+	if n, _ := store.Remaining(ctx, &other); n != 0 {
+		t.Errorf("Remaining for other amount = %d, want 0", n)
+	}
+	if _, err := store.Take(ctx, &other); !errors.Is(err, ErrNoVoucher) {
+		t.Errorf("Expected ErrNoVoucher for other amount, got %v", err)
+	}
+
+	// Matching is case-insensitive on addresses
+	lower := template
+	lower.PayTo = "0x209693bc6afc0c5328ba36faf03c514ef312287c"
+	for i := 0; i < 2; i++ {
+		if _, err := store.Take(ctx, &lower); err != nil {
+			t.Fatalf("Take %d failed: %v", i+1, err)
+		}
+	}
+	if _, err := store.Take(ctx, &template); !errors.Is(err, ErrNoVoucher) {
+		t.Errorf("Expected ErrNoVoucher once vouchers are spent, got %v", err)
+	}
+}
+
+func TestVoucherJSONRoundTrip(t *testing.T) {
+	batch, _ := Issue(&countingSigner{}, testTemplate(), 2)
+	data, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var loaded []Voucher
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	template := testTemplate()
+	if n, _ := NewMemoryStore(loaded...).Remaining(context.Background(), &template); n != 2 {
+		t.Errorf("Remaining after round trip = %d, want 2", n)
+	}
+}
+
+func TestSigner(t *testing.T) {
+	template := testTemplate()
+	batch, _ := Issue(&countingSigner{}, template, 1)
+	signer := NewSigner("base", NewMemoryStore(batch...), WithPriority(2))
+
+	if signer.GetPriority() != 2 {
+		t.Errorf("GetPriority = %d, want 2", signer.GetPriority())
+	}
+
+	payment, err := x402.NewDefaultPaymentSelector().SelectAndSign([]x402.PaymentRequirement{template}, []x402.Signer{signer})
+	if err != nil {
+		t.Fatalf("SelectAndSign failed: %v", err)
+	}
+	if payment.Network != "base" {
+		t.Errorf("Expected payment on base, got %s", payment.Network)
+	}
+
+	if n, _ := signer.Remaining(context.Background()); n != 0 {
+		t.Errorf("Remaining = %d, want 0", n)
+	}
+	if signer.CanSign(&template) {
+		t.Error("Expected CanSign false once vouchers are spent")
+	}
+	if _, err := signer.Sign(&template); !errors.Is(err, ErrNoVoucher) {
+		t.Errorf("Expected ErrNoVoucher, got %v", err)
+	}
+}
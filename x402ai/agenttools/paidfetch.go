@@ -0,0 +1,290 @@
+// Package agenttools provides x402 tools for Go agent frameworks. The tools implement
+// LangChainGo's tools.Tool interface (Name, Description and Call) without depending
+// on it, so they can be passed to its agents directly and adapted to other frameworks
+// with a few lines.
+//
+// Example usage with LangChainGo:
+//
+//	fetch, err := agenttools.NewPaidFetchTool(signer,
+//	    agenttools.WithMaxPrice("50000"), // at most 0.05 USDC per call
+//	    agenttools.WithSpendingLimit(dailyLimit),
+//	)
+//	agent := agents.NewOneShotAgent(llm, []tools.Tool{fetch})
+package agenttools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/x402-go"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+// DefaultMaxResponseBytes is the default limit on the response body returned to the agent.
+const DefaultMaxResponseBytes = 64 << 10
+
+// PaidFetchTool fetches URLs, paying for them with x402 when the server asks for payment.
+// The agent names the most it is willing to pay for each call; the tool's own price cap
+// and spending limit bound what the agent can spend overall.
+type PaidFetchTool struct {
+	signer           x402.Signer
+	selector         x402.PaymentSelector
+	base             http.RoundTripper
+	limit            *x402.SpendingLimit
+	maxPrice         *big.Int
+	maxResponseBytes int64
+}
+
+// Option configures a PaidFetchTool.
+type Option func(*PaidFetchTool) error
+
+// NewPaidFetchTool creates a PaidFetchTool paying with signer.
+func NewPaidFetchTool(signer x402.Signer, opts ...Option) (*PaidFetchTool, error) {
+	if signer == nil {
+		return nil, errors.New("agenttools: signer is required")
+	}
+
+	t := &PaidFetchTool{
+		signer:           signer,
+		selector:         x402.NewDefaultPaymentSelector(),
+		base:             http.DefaultTransport,
+		maxResponseBytes: DefaultMaxResponseBytes,
+	}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// WithBaseTransport sets the RoundTripper that sends requests (default http.DefaultTransport).
+func WithBaseTransport(base http.RoundTripper) Option {
+	return func(t *PaidFetchTool) error {
+		if base == nil {
+			return errors.New("agenttools: base transport is nil")
+		}
+		t.base = base
+		return nil
+	}
+}
+
+// WithSelector sets the payment selector (default x402.NewDefaultPaymentSelector()).
+func WithSelector(selector x402.PaymentSelector) Option {
+	return func(t *PaidFetchTool) error {
+		if selector == nil {
+			return errors.New("agenttools: selector is nil")
+		}
+		t.selector = selector
+		return nil
+	}
+}
+
+// WithSpendingLimit caps the total the tool pays across calls.
+func WithSpendingLimit(limit *x402.SpendingLimit) Option {
+	return func(t *PaidFetchTool) error {
+		if limit == nil || limit.Limit == nil || limit.Limit.Sign() <= 0 {
+			return fmt.Errorf("%w: spending limit must be positive", x402.ErrInvalidAmount)
+		}
+		t.limit = limit
+		return nil
+	}
+}
+
+// WithMaxPrice caps the price of a single call, in atomic units. It is also the price
+// used when the agent names none.
+func WithMaxPrice(atomic string) Option {
+	return func(t *PaidFetchTool) error {
+		price, err := parsePrice(atomic)
+		if err != nil {
+			return err
+		}
+		t.maxPrice = price
+		return nil
+	}
+}
+
+// WithMaxResponseBytes sets how much of the response body is returned to the agent
+// (default DefaultMaxResponseBytes). Longer bodies are truncated.
+func WithMaxResponseBytes(n int64) Option {
+	return func(t *PaidFetchTool) error {
+		if n <= 0 {
+			return errors.New("agenttools: max response bytes must be positive")
+		}
+		t.maxResponseBytes = n
+		return nil
+	}
+}
+
+// Name implements LangChainGo's tools.Tool.
+func (t *PaidFetchTool) Name() string {
+	return "paid_fetch"
+}
+
+// Description implements LangChainGo's tools.Tool.
+func (t *PaidFetchTool) Description() string {
+	return `Fetches a URL, paying for it with cryptocurrency if the server requires payment.
+Input is a JSON object: {"url": "https://...", "max_price": "10000", "method": "GET", "body": "..."}.
+max_price is the most you are willing to pay, in the token's smallest unit (1000000 = 1 USDC).
+method defaults to GET and body is optional. A bare URL is also accepted.
+The result is a JSON object with the response status, body, and the cost paid.`
+}
+
+// FetchInput is the input of a PaidFetchTool call.
+type FetchInput struct {
+	URL      string `json:"url"`
+	MaxPrice string `json:"max_price,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Body     string `json:"body,omitempty"`
+}
+
+// FetchResult is the result of a PaidFetchTool call, returned to the agent as JSON.
+type FetchResult struct {
+	Status      int    `json:"status,omitempty"`
+	Body        string `json:"body,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Cost        string `json:"cost"`
+	Asset       string `json:"asset,omitempty"`
+	Network     string `json:"network,omitempty"`
+	Transaction string `json:"transaction,omitempty"`
+
+	// Error explains why a payment was refused, so the agent can decide what to do.
+	Error string `json:"error,omitempty"`
+}
+
+// Call implements LangChainGo's tools.Tool. Refused payments (over the price or spending
+// limit) are reported in the result; malformed input and request failures are errors.
+func (t *PaidFetchTool) Call(ctx context.Context, input string) (string, error) {
+	fetch, err := parseInput(input)
+	if err != nil {
+		return "", err
+	}
+	result, err := t.Fetch(ctx, fetch)
+	if err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Fetch performs a call without the JSON encoding of Call, for frameworks with typed
+// tool arguments.
+func (t *PaidFetchTool) Fetch(ctx context.Context, input FetchInput) (*FetchResult, error) {
+	maxPrice := t.maxPrice
+	if input.MaxPrice != "" {
+		price, err := parsePrice(input.MaxPrice)
+		if err != nil {
+			return nil, err
+		}
+		if maxPrice == nil || price.Cmp(maxPrice) < 0 {
+			maxPrice = price
+		}
+	}
+	if maxPrice == nil {
+		return nil, errors.New("agenttools: max_price is required")
+	}
+
+	target, err := url.Parse(input.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("agenttools: invalid url %q", input.URL)
+	}
+	method := strings.ToUpper(input.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if input.Body != "" {
+		body = strings.NewReader(input.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("agenttools: %w", err)
+	}
+	if input.Body != "" && json.Valid([]byte(input.Body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	result := &FetchResult{Cost: "0"}
+	transport := &httpx402.X402Transport{
+		Base:          t.base,
+		Signers:       []x402.Signer{&priceCapSigner{Signer: t.signer, maxPrice: maxPrice}},
+		Selector:      t.selector,
+		SpendingLimit: t.limit,
+		OnPaymentSuccess: func(event x402.PaymentEvent) {
+			result.Cost = event.Amount
+			result.Asset = event.Asset
+			result.Network = event.Network
+			result.Transaction = event.Transaction
+		},
+	}
+
+	resp, err := transport.RoundTrip(req)
+	switch {
+	case errors.Is(err, x402.ErrBudgetExceeded):
+		result.Error = "payment refused: spending limit reached"
+		return result, nil
+	case errors.Is(err, x402.ErrNoValidSigner):
+		result.Error = fmt.Sprintf("payment refused: no accepted payment option costs at most %s", maxPrice)
+		return result, nil
+	case err != nil:
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, t.maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("agenttools: failed to read response: %w", err)
+	}
+	if int64(len(data)) > t.maxResponseBytes {
+		data = data[:t.maxResponseBytes]
+		result.Truncated = true
+	}
+	result.Status = resp.StatusCode
+	result.Body = string(data)
+	return result, nil
+}
+
+// parseInput accepts a JSON FetchInput or a bare URL.
+func parseInput(input string) (FetchInput, error) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, "{") {
+		return FetchInput{URL: input}, nil
+	}
+	var fetch FetchInput
+	if err := json.Unmarshal([]byte(input), &fetch); err != nil {
+		return FetchInput{}, fmt.Errorf("agenttools: invalid input: %w", err)
+	}
+	return fetch, nil
+}
+
+func parsePrice(atomic string) (*big.Int, error) {
+	price, ok := new(big.Int).SetString(atomic, 10)
+	if !ok || price.Sign() < 0 {
+		return nil, fmt.Errorf("%w: price %q", x402.ErrInvalidAmount, atomic)
+	}
+	return price, nil
+}
+
+// priceCapSigner lowers the signer's per-call limit to the price the agent accepted.
+type priceCapSigner struct {
+	x402.Signer
+	maxPrice *big.Int
+}
+
+// GetMaxAmount implements x402.Signer.
+func (s *priceCapSigner) GetMaxAmount() *big.Int {
+	if limit := s.Signer.GetMaxAmount(); limit != nil && limit.Cmp(s.maxPrice) < 0 {
+		return limit
+	}
+	return s.maxPrice
+}
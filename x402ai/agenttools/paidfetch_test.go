@@ -0,0 +1,171 @@
+package agenttools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+type testSigner struct{}
+
+func (testSigner) Network() string                       { return "base-sepolia" }
+func (testSigner) Scheme() string                        { return "exact" }
+func (testSigner) CanSign(*x402.PaymentRequirement) bool { return true }
+func (testSigner) GetPriority() int                      { return 0 }
+func (testSigner) GetTokens() []x402.TokenConfig         { return nil }
+func (testSigner) GetMaxAmount() *big.Int                { return nil }
+func (testSigner) Sign(*x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	return &x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia", Payload: map[string]interface{}{"signature": "0xsig"}}, nil
+}
+
+// newPaidServer returns a server charging 1000 atomic units for /paid and echoing
+// the request method and body once paid. Other paths are free.
+func newPaidServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/paid" && r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_ = json.NewEncoder(w).Encode(x402.PaymentRequirementsResponse{
+				X402Version: 1,
+				Accepts: []x402.PaymentRequirement{{
+					Scheme:            "exact",
+					Network:           "base-sepolia",
+					MaxAmountRequired: "1000",
+					Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+					PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+					MaxTimeoutSeconds: 60,
+				}},
+			})
+			return
+		}
+		if r.Header.Get("X-PAYMENT") != "" {
+			settlement, _ := encoding.EncodeSettlement(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia"})
+			w.Header().Set("X-PAYMENT-RESPONSE", settlement)
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, r.Method+" "+string(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPaidFetchTool_Call(t *testing.T) {
+	server := newPaidServer(t)
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		input     string
+		want      FetchResult
+		wantErr   bool
+		wantError bool
+	}{
+		{
+			name:  "paid GET",
+			input: `{"url": "` + server.URL + `/paid", "max_price": "1000"}`,
+			want:  FetchResult{Status: 200, Body: "GET ", Cost: "1000", Network: "base-sepolia", Transaction: "0xtx"},
+		},
+		{
+			name:  "paid POST with body",
+			input: `{"url": "` + server.URL + `/paid", "max_price": "5000", "method": "post", "body": "{\"q\":1}"}`,
+			want:  FetchResult{Status: 200, Body: `POST {"q":1}`, Cost: "1000", Network: "base-sepolia", Transaction: "0xtx"},
+		},
+		{
+			name:  "free resource with bare URL",
+			opts:  []Option{WithMaxPrice("1")},
+			input: server.URL + "/free",
+			want:  FetchResult{Status: 200, Body: "GET ", Cost: "0"},
+		},
+		{
+			name:      "price above agent's max",
+			input:     `{"url": "` + server.URL + `/paid", "max_price": "999"}`,
+			wantError: true,
+		},
+		{
+			name:      "agent's max above tool's cap",
+			opts:      []Option{WithMaxPrice("500")},
+			input:     `{"url": "` + server.URL + `/paid", "max_price": "1000000"}`,
+			wantError: true,
+		},
+		{
+			name:      "spending limit reached",
+			opts:      []Option{WithSpendingLimit(&x402.SpendingLimit{Limit: big.NewInt(999)})},
+			input:     `{"url": "` + server.URL + `/paid", "max_price": "1000"}`,
+			wantError: true,
+		},
+		{
+			name:    "missing max price",
+			input:   `{"url": "` + server.URL + `/paid"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			input:   `{"url": "file:///etc/passwd", "max_price": "1"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed input",
+			input:   `{"url":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, err := NewPaidFetchTool(testSigner{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewPaidFetchTool: %v", err)
+			}
+
+			out, err := tool.Call(context.Background(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Call() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var got FetchResult
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("result is not JSON: %q", out)
+			}
+			if tt.wantError {
+				if got.Error == "" || got.Cost != "0" {
+					t.Errorf("expected refused payment, got %+v", got)
+				}
+				return
+			}
+			tt.want.Asset = got.Asset
+			if got != tt.want {
+				t.Errorf("Call() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaidFetchTool_TruncatesBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("x", 100))
+	}))
+	defer server.Close()
+
+	tool, err := NewPaidFetchTool(testSigner{}, WithMaxPrice("0"), WithMaxResponseBytes(10))
+	if err != nil {
+		t.Fatalf("NewPaidFetchTool: %v", err)
+	}
+	result, err := tool.Fetch(context.Background(), FetchInput{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(result.Body) != 10 || !result.Truncated {
+		t.Errorf("expected 10 truncated bytes, got %d (truncated %v)", len(result.Body), result.Truncated)
+	}
+}
@@ -0,0 +1,377 @@
+// Package x402ai provides a payment-enabled HTTP client for LLM SDKs, so AI APIs that
+// charge per request via x402 can be used with the OpenAI, Anthropic and similar Go
+// clients unchanged.
+//
+// Example usage:
+//
+//	client, err := x402ai.NewHTTPClient(signer,
+//	    x402ai.WithModelBudget("gpt-4o", gpt4oLimit),
+//	    x402ai.WithUsageHook(func(u x402ai.Usage) {
+//	        log.Printf("%s: paid %s for %d tokens", u.Model, u.Amount, u.InputTokens+u.OutputTokens)
+//	    }),
+//	)
+//	openaiClient := openai.NewClient(option.WithHTTPClient(client), option.WithBaseURL(paidAPI))
+//
+//	for model, spend := range client.Spend() {
+//	    fmt.Println(model, spend.Requests, spend.Amount)
+//	}
+package x402ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/x402-go"
+	httpx402 "github.com/mark3labs/x402-go/http"
+)
+
+// UnknownModel tags requests whose body names no model.
+const UnknownModel = "unknown"
+
+// maxInspectedBody bounds the request and response bodies read for model and usage
+// information. Larger bodies are forwarded without inspection.
+const maxInspectedBody = 1 << 20
+
+// Usage describes one request made through the client.
+type Usage struct {
+	// Model is the model named in the request body, or UnknownModel.
+	Model string
+
+	// Amount is the amount paid in atomic units; zero if the request was not paid for.
+	Amount *big.Int
+
+	// Asset and Network identify what the payment was made in, when paid.
+	Asset   string
+	Network string
+
+	// InputTokens and OutputTokens are taken from the response's usage field
+	// (OpenAI and Anthropic formats), or estimated by the TokenEstimator.
+	InputTokens  int
+	OutputTokens int
+
+	// Estimated reports whether the token counts come from the TokenEstimator.
+	Estimated bool
+}
+
+// Spend aggregates the usage of one model.
+type Spend struct {
+	Requests     int
+	Amount       *big.Int
+	InputTokens  int
+	OutputTokens int
+}
+
+// TokenEstimator estimates the input tokens of a request body for model. It is used
+// when the response reports no usage, e.g. for streamed responses.
+type TokenEstimator func(model string, body []byte) int
+
+// Option configures a Client.
+type Option func(*Client) error
+
+// Client is an http.Client that pays for requests with x402 and reports spend per model.
+// It implements the Do method expected by LLM SDKs' HTTP client options.
+type Client struct {
+	*http.Client
+
+	signer         x402.Signer
+	selector       x402.PaymentSelector
+	base           http.RoundTripper
+	defaultLimit   *x402.SpendingLimit
+	modelLimits    map[string]*x402.SpendingLimit
+	tokenEstimator TokenEstimator
+	usageHook      func(Usage)
+
+	mu    sync.Mutex
+	spend map[string]*Spend
+}
+
+// NewHTTPClient creates a Client paying with signer.
+func NewHTTPClient(signer x402.Signer, opts ...Option) (*Client, error) {
+	if signer == nil {
+		return nil, errors.New("x402ai: signer is required")
+	}
+
+	c := &Client{
+		signer:      signer,
+		selector:    x402.NewDefaultPaymentSelector(),
+		base:        http.DefaultTransport,
+		modelLimits: make(map[string]*x402.SpendingLimit),
+		spend:       make(map[string]*Spend),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	c.Client = &http.Client{Transport: &transport{client: c}}
+	return c, nil
+}
+
+// WithBaseTransport sets the RoundTripper that sends requests (default http.DefaultTransport).
+func WithBaseTransport(base http.RoundTripper) Option {
+	return func(c *Client) error {
+		if base == nil {
+			return errors.New("x402ai: base transport is nil")
+		}
+		c.base = base
+		return nil
+	}
+}
+
+// WithSelector sets the payment selector (default x402.NewDefaultPaymentSelector()).
+func WithSelector(selector x402.PaymentSelector) Option {
+	return func(c *Client) error {
+		if selector == nil {
+			return errors.New("x402ai: selector is nil")
+		}
+		c.selector = selector
+		return nil
+	}
+}
+
+// WithSpendingLimit caps the spend of requests for models without their own budget.
+func WithSpendingLimit(limit *x402.SpendingLimit) Option {
+	return func(c *Client) error {
+		if err := validateLimit(limit); err != nil {
+			return err
+		}
+		c.defaultLimit = limit
+		return nil
+	}
+}
+
+// WithModelBudget caps the spend of requests for model. Requests for model are not
+// counted against the WithSpendingLimit budget.
+func WithModelBudget(model string, limit *x402.SpendingLimit) Option {
+	return func(c *Client) error {
+		if model == "" {
+			return errors.New("x402ai: model is required")
+		}
+		if err := validateLimit(limit); err != nil {
+			return err
+		}
+		c.modelLimits[model] = limit
+		return nil
+	}
+}
+
+// WithTokenEstimator sets the estimator used when a response reports no token usage.
+func WithTokenEstimator(estimator TokenEstimator) Option {
+	return func(c *Client) error {
+		c.tokenEstimator = estimator
+		return nil
+	}
+}
+
+// WithUsageHook sets a function called with the usage of every request once its
+// response body has been read or closed. It is called synchronously.
+func WithUsageHook(hook func(Usage)) Option {
+	return func(c *Client) error {
+		c.usageHook = hook
+		return nil
+	}
+}
+
+// Spend returns the aggregated usage per model since the client was created.
+func (c *Client) Spend() map[string]Spend {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := make(map[string]Spend, len(c.spend))
+	for model, spend := range c.spend {
+		copied := *spend
+		copied.Amount = new(big.Int).Set(spend.Amount)
+		report[model] = copied
+	}
+	return report
+}
+
+// TotalSpend returns the usage of all models combined. Amounts are summed in atomic
+// units, so they are only meaningful if every payment used the same asset.
+func (c *Client) TotalSpend() Spend {
+	total := Spend{Amount: new(big.Int)}
+	for _, spend := range c.Spend() {
+		total.Requests += spend.Requests
+		total.Amount.Add(total.Amount, spend.Amount)
+		total.InputTokens += spend.InputTokens
+		total.OutputTokens += spend.OutputTokens
+	}
+	return total
+}
+
+// record adds usage to the spend report and calls the usage hook.
+func (c *Client) record(usage Usage) {
+	c.mu.Lock()
+	spend, ok := c.spend[usage.Model]
+	if !ok {
+		spend = &Spend{Amount: new(big.Int)}
+		c.spend[usage.Model] = spend
+	}
+	spend.Requests++
+	spend.Amount.Add(spend.Amount, usage.Amount)
+	spend.InputTokens += usage.InputTokens
+	spend.OutputTokens += usage.OutputTokens
+	c.mu.Unlock()
+
+	if c.usageHook != nil {
+		c.usageHook(usage)
+	}
+}
+
+// limitFor returns the spending limit applying to model.
+func (c *Client) limitFor(model string) *x402.SpendingLimit {
+	if limit, ok := c.modelLimits[model]; ok {
+		return limit
+	}
+	return c.defaultLimit
+}
+
+func validateLimit(limit *x402.SpendingLimit) error {
+	if limit == nil || limit.Limit == nil || limit.Limit.Sign() <= 0 {
+		return fmt.Errorf("%w: spending limit must be positive", x402.ErrInvalidAmount)
+	}
+	return nil
+}
+
+// transport tags each request with its model and pays through an X402Transport
+// bound to the model's budget.
+type transport struct {
+	client *Client
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.client
+
+	body, err := bufferBody(req)
+	if err != nil {
+		return nil, err
+	}
+	model := modelOf(body)
+
+	usage := Usage{Model: model, Amount: new(big.Int)}
+	payer := &httpx402.X402Transport{
+		Base:          c.base,
+		Signers:       []x402.Signer{c.signer},
+		Selector:      c.selector,
+		SpendingLimit: c.limitFor(model),
+		OnPaymentSuccess: func(event x402.PaymentEvent) {
+			if amount, ok := new(big.Int).SetString(event.Amount, 10); ok {
+				usage.Amount = amount
+			}
+			usage.Asset = event.Asset
+			usage.Network = event.Network
+		},
+	}
+
+	resp, err := payer.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	finish := func(response []byte) {
+		usage.InputTokens, usage.OutputTokens, _ = usageOf(response)
+		if usage.InputTokens == 0 && usage.OutputTokens == 0 && c.tokenEstimator != nil {
+			usage.InputTokens = c.tokenEstimator(model, body)
+			usage.Estimated = true
+		}
+		c.record(usage)
+	}
+
+	// Only complete JSON responses carry usage; streamed responses are reported as is
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		finish(nil)
+		return resp, nil
+	}
+	resp.Body = &usageBody{ReadCloser: resp.Body, finish: finish}
+	return resp, nil
+}
+
+// bufferBody reads the request body so it can be inspected and resent with a payment.
+func bufferBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil && req.ContentLength > maxInspectedBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("x402ai: failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// modelOf returns the model named in a JSON request body.
+func modelOf(body []byte) string {
+	var request struct {
+		Model string `json:"model"`
+	}
+	if len(body) > maxInspectedBody || json.Unmarshal(body, &request) != nil || request.Model == "" {
+		return UnknownModel
+	}
+	return request.Model
+}
+
+// usageOf returns the token usage reported in a JSON response body, in either the
+// OpenAI (prompt/completion tokens) or Anthropic (input/output tokens) format.
+func usageOf(body []byte) (input, output int, ok bool) {
+	var response struct {
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			InputTokens      int `json:"input_tokens"`
+			OutputTokens     int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &response) != nil || response.Usage == nil {
+		return 0, 0, false
+	}
+	u := response.Usage
+	return u.PromptTokens + u.InputTokens, u.CompletionTokens + u.OutputTokens, true
+}
+
+// usageBody captures a JSON response body as it is read and reports usage once, at EOF
+// or Close.
+type usageBody struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	finish func([]byte)
+	done   bool
+}
+
+func (b *usageBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.buf.Len()+n <= maxInspectedBody {
+		b.buf.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.report()
+	}
+	return n, err
+}
+
+func (b *usageBody) Close() error {
+	b.report()
+	return b.ReadCloser.Close()
+}
+
+func (b *usageBody) report() {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.finish(b.buf.Bytes())
+}
@@ -0,0 +1,204 @@
+package x402ai
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/x402-go"
+	"github.com/mark3labs/x402-go/encoding"
+)
+
+type testSigner struct{}
+
+func (testSigner) Network() string                       { return "base-sepolia" }
+func (testSigner) Scheme() string                        { return "exact" }
+func (testSigner) CanSign(*x402.PaymentRequirement) bool { return true }
+func (testSigner) GetPriority() int                      { return 0 }
+func (testSigner) GetTokens() []x402.TokenConfig         { return nil }
+func (testSigner) GetMaxAmount() *big.Int                { return nil }
+func (testSigner) Sign(*x402.PaymentRequirement) (*x402.PaymentPayload, error) {
+	return &x402.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia", Payload: map[string]interface{}{"signature": "0xsig"}}, nil
+}
+
+// newLLMServer returns a server charging price for every request and answering with
+// response once paid. It fails the test if a paid request arrives without its body.
+func newLLMServer(t *testing.T, price string, contentType, response string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPaymentRequired)
+			_ = json.NewEncoder(w).Encode(x402.PaymentRequirementsResponse{
+				X402Version: 1,
+				Accepts: []x402.PaymentRequirement{{
+					Scheme:            "exact",
+					Network:           "base-sepolia",
+					MaxAmountRequired: price,
+					Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+					PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+					MaxTimeoutSeconds: 60,
+				}},
+			})
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if len(body) == 0 {
+			t.Error("paid request lost its body")
+		}
+		settlement, _ := encoding.EncodeSettlement(x402.SettlementResponse{Success: true, Transaction: "0xtx", Network: "base-sepolia"})
+		w.Header().Set("X-PAYMENT-RESPONSE", settlement)
+		w.Header().Set("Content-Type", contentType)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func post(t *testing.T, client *Client, url, body string) error {
+	t.Helper()
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		signer  x402.Signer
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "defaults", signer: testSigner{}},
+		{name: "no signer", wantErr: true},
+		{name: "zero model budget", signer: testSigner{}, opts: []Option{WithModelBudget("gpt-4o", &x402.SpendingLimit{Limit: big.NewInt(0)})}, wantErr: true},
+		{name: "unnamed model budget", signer: testSigner{}, opts: []Option{WithModelBudget("", &x402.SpendingLimit{Limit: big.NewInt(1)})}, wantErr: true},
+		{name: "nil base transport", signer: testSigner{}, opts: []Option{WithBaseTransport(nil)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHTTPClient(tt.signer, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewHTTPClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_SpendReporting(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		response    string
+		estimator   TokenEstimator
+		wantInput   int
+		wantOutput  int
+		wantEst     bool
+	}{
+		{
+			name:        "openai usage",
+			contentType: "application/json",
+			response:    `{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":30,"total_tokens":42}}`,
+			wantInput:   12,
+			wantOutput:  30,
+		},
+		{
+			name:        "anthropic usage",
+			contentType: "application/json; charset=utf-8",
+			response:    `{"content":[],"usage":{"input_tokens":7,"output_tokens":3}}`,
+			wantInput:   7,
+			wantOutput:  3,
+		},
+		{
+			name:        "streamed response is estimated",
+			contentType: "text/event-stream",
+			response:    "data: {}\n\n",
+			estimator:   func(model string, body []byte) int { return len(body) },
+			wantInput:   len(`{"model":"gpt-4o","messages":[]}`),
+			wantEst:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newLLMServer(t, "1000", tt.contentType, tt.response)
+
+			var usages []Usage
+			client, err := NewHTTPClient(testSigner{},
+				WithTokenEstimator(tt.estimator),
+				WithUsageHook(func(u Usage) { usages = append(usages, u) }),
+			)
+			if err != nil {
+				t.Fatalf("NewHTTPClient: %v", err)
+			}
+
+			for range 2 {
+				if err := post(t, client, server.URL, `{"model":"gpt-4o","messages":[]}`); err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+			}
+
+			if len(usages) != 2 {
+				t.Fatalf("expected 2 usage reports, got %d", len(usages))
+			}
+			u := usages[0]
+			if u.Model != "gpt-4o" || u.Amount.String() != "1000" || u.Network != "base-sepolia" {
+				t.Errorf("unexpected usage %+v", u)
+			}
+			if u.InputTokens != tt.wantInput || u.OutputTokens != tt.wantOutput || u.Estimated != tt.wantEst {
+				t.Errorf("tokens = %d/%d (estimated %v), want %d/%d (estimated %v)",
+					u.InputTokens, u.OutputTokens, u.Estimated, tt.wantInput, tt.wantOutput, tt.wantEst)
+			}
+
+			spend := client.Spend()["gpt-4o"]
+			if spend.Requests != 2 || spend.Amount.String() != "2000" || spend.InputTokens != 2*tt.wantInput {
+				t.Errorf("unexpected spend %+v", spend)
+			}
+			if total := client.TotalSpend(); total.Requests != 2 || total.Amount.String() != "2000" {
+				t.Errorf("unexpected total spend %+v", total)
+			}
+		})
+	}
+}
+
+func TestClient_ModelBudget(t *testing.T) {
+	server := newLLMServer(t, "1000", "application/json", `{"usage":{"prompt_tokens":1,"completion_tokens":1}}`)
+
+	client, err := NewHTTPClient(testSigner{},
+		WithModelBudget("gpt-4o", &x402.SpendingLimit{Limit: big.NewInt(1500)}),
+		WithSpendingLimit(&x402.SpendingLimit{Limit: big.NewInt(10000)}),
+	)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+
+	if err := post(t, client, server.URL, `{"model":"gpt-4o"}`); err != nil {
+		t.Fatalf("first gpt-4o request failed: %v", err)
+	}
+	if err := post(t, client, server.URL, `{"model":"gpt-4o"}`); !errors.Is(err, x402.ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded for gpt-4o, got %v", err)
+	}
+
+	// Other models draw from the default budget
+	if err := post(t, client, server.URL, `{"model":"gpt-4o-mini"}`); err != nil {
+		t.Fatalf("gpt-4o-mini request failed: %v", err)
+	}
+	if err := post(t, client, server.URL, `not json`); err != nil {
+		t.Fatalf("untagged request failed: %v", err)
+	}
+
+	spend := client.Spend()
+	if spend["gpt-4o"].Requests != 1 || spend["gpt-4o-mini"].Requests != 1 || spend[UnknownModel].Requests != 1 {
+		t.Errorf("unexpected spend %+v", spend)
+	}
+}